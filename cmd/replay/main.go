@@ -0,0 +1,145 @@
+// Command replay reproduces a conversation from a transcript: it feeds the user's messages and button
+// taps from a "/transcript <id> file" download through the FSM with the fake adapter and prints every
+// point where the bot now answers differently.
+//
+//	go run ./cmd/replay -user 123456 -config record_config.yaml transcript-123456.txt
+//
+// The environment is read like the bot reads it (SURVEYS_DIR, TARGET_USER_ID, THERAPIST_IDS,
+// FEATURE_FLAGS, MESSAGES_FILE, LOG_LEVEL), so set it as in production. Run with the bot's TZ: the
+// file's times are local and every event is replayed at its captured time.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logging"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/transcript"
+)
+
+func main() {
+	cfgPath := flag.String("config", "record_config.yaml", "record config the bot ran with")
+	userID := flag.Int64("user", 0, "Telegram ID of the user the transcript belongs to")
+	name := flag.String("name", "Replay", "first name of the user")
+	language := flag.String("lang", "", "language code of the user's Telegram client")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -user <id> [flags] <transcript file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *userID == 0 || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	user := botport.Sender{ID: *userID, FirstName: *name, LanguageCode: *language}
+	diverged, err := replayFile(*cfgPath, flag.Arg(0), user)
+	if err != nil {
+		// Not log: logging.Setup routes it through slog, which may be filtered out.
+		fmt.Fprintln(os.Stderr, "replay:", err)
+		os.Exit(2)
+	}
+	if diverged {
+		os.Exit(1)
+	}
+}
+
+// replayFile replays the transcript at path with the config at cfgPath and reports whether it diverged.
+func replayFile(cfgPath, path string, user botport.Sender) (bool, error) {
+	level, err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		return false, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+	if os.Getenv("LOG_LEVEL") == "" {
+		level = slog.LevelWarn // The handler logs every update at info
+	}
+	logging.Setup(os.Stderr, level)
+
+	if err := loadConfig(cfgPath); err != nil {
+		return false, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open the transcript: %w", err)
+	}
+	defer file.Close()
+	entries, err := parseTranscript(file, time.Local)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse '%s': %w", path, err)
+	}
+
+	divergences := newReplayer(config.GetConfig, user).run(context.Background(), entries)
+	report(os.Stdout, entries, divergences)
+	return len(divergences) > 0, nil
+}
+
+// loadConfig loads the record config and the settings from the environment the way main does.
+func loadConfig(path string) error {
+	questions.RegisterBuiltins()
+	if err := config.LoadConfig(path); err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := config.LoadTemplatesFromEnv(); err != nil {
+		return fmt.Errorf("failed to load survey templates: %w", err)
+	}
+	// Unlike the bot, a replay runs without a therapist when TARGET_USER_ID is unset.
+	if os.Getenv("TARGET_USER_ID") != "" {
+		if err := config.LoadTargetUserIDFromEnv(); err != nil {
+			return fmt.Errorf("failed to read TARGET_USER_ID: %w", err)
+		}
+	}
+	if err := config.LoadTherapistsFromEnv(); err != nil {
+		return fmt.Errorf("failed to read THERAPIST_IDS: %w", err)
+	}
+	if err := config.LoadFeatureFlagsFromEnv(); err != nil {
+		return fmt.Errorf("failed to read feature flags: %w", err)
+	}
+	if err := config.LoadMessagesFromEnv(); err != nil {
+		return fmt.Errorf("failed to load messages: %w", err)
+	}
+	return nil
+}
+
+// report prints each divergence with the bot's messages from the transcript and from the replay.
+func report(w io.Writer, entries []transcript.Entry, divergences []divergence) {
+	events := 0
+	for _, entry := range entries {
+		if entry.Direction == transcript.Inbound {
+			events++
+		}
+	}
+	for _, d := range divergences {
+		fmt.Fprintf(w, "step %d, %s 👤 %s\n", d.Step, d.Input.Time.Format(fileTimeLayout), describe(d.Input))
+		fmt.Fprintln(w, "  transcript:")
+		writeEntries(w, d.Want)
+		fmt.Fprintln(w, "  replay:")
+		writeEntries(w, d.Got)
+	}
+	fmt.Fprintf(w, "replayed %d events, %d diverged\n", events, len(divergences))
+}
+
+func writeEntries(w io.Writer, entries []transcript.Entry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "    (nothing)")
+	}
+	for _, entry := range entries {
+		fmt.Fprintf(w, "    🤖 %s\n", strings.ReplaceAll(describe(entry), "\n", "\n       "))
+	}
+}
+
+// describe shows an entry's kind next to its text unless it is a plain message.
+func describe(entry transcript.Entry) string {
+	if entry.Kind == "message" || entry.Kind == "command" {
+		return entry.Text
+	}
+	return "[" + entry.Kind + "] " + entry.Text
+}
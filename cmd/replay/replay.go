@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/transcript"
+)
+
+// divergence is a user event after which the bot answered differently than in the transcript.
+type divergence struct {
+	Step  int // 1-based number of the user event
+	Input transcript.Entry
+	Want  []transcript.Entry
+	Got   []transcript.Entry
+}
+
+// replayer feeds one user's events to a handler talking to a fake adapter. The bot's messages to the
+// user are collected the way production transcripts collect them, so they compare entry by entry.
+type replayer struct {
+	adapter *fakeadapter.FakeAdapter
+	handler *fsm.Handler
+	log     *transcript.Log
+	user    botport.Sender
+}
+
+func newReplayer(recordConfig func() *config.RecordConfig, user botport.Sender) *replayer {
+	adapter := &fakeadapter.FakeAdapter{}
+	log := transcript.New(transcript.Limits{})
+	return &replayer{
+		adapter: adapter,
+		handler: fsm.NewHandler(transcript.Wrap(adapter, log), recordConfig),
+		log:     log,
+		user:    user,
+	}
+}
+
+// run replays the user's entries in order, each at the time it was captured, and compares the bot's
+// messages to the user with the ones that followed it in the transcript. Bot messages before the
+// first user event (reminders, nudges) have no cause to replay and are skipped.
+func (r *replayer) run(ctx context.Context, entries []transcript.Entry) []divergence {
+	defer fsm.SetClock(time.Now)
+
+	var divergences []divergence
+	step := 0
+	for i, entry := range entries {
+		if entry.Direction != transcript.Inbound {
+			continue
+		}
+		step++
+		var want []transcript.Entry
+		for _, next := range entries[i+1:] {
+			if next.Direction == transcript.Inbound {
+				break
+			}
+			want = append(want, next)
+		}
+
+		at := entry.Time
+		fsm.SetClock(func() time.Time { return at })
+		seen := len(r.log.Entries(r.user.ID))
+		r.handler.HandleUpdate(ctx, r.event(step, entry))
+		got := r.log.Entries(r.user.ID)[seen:]
+
+		if !sameEntries(want, got) {
+			divergences = append(divergences, divergence{Step: step, Input: entry, Want: want, Got: got})
+		}
+	}
+	return divergences
+}
+
+// event rebuilds the inbound event an entry was recorded from. A callback is sent from the latest bot
+// message carrying its button, or the latest message when none does.
+func (r *replayer) event(step int, entry transcript.Entry) botport.InboundEvent {
+	event := botport.InboundEvent{
+		ID:        int64(step),
+		Kind:      botport.EventMessage,
+		From:      r.user,
+		ChatID:    r.user.ID,
+		MessageID: step,
+		Text:      entry.Text,
+	}
+	switch entry.Kind {
+	case "message":
+	case "command":
+		event.Command, event.Args = splitCommand(entry.Text)
+	case "callback":
+		event.Kind = botport.EventCallback
+		event.CallbackID = "replay"
+		event.Data = entry.Text
+		event.MessageID, event.Text = r.buttonMessage(entry.Text)
+	default:
+		event.Media = &botport.Media{Type: entry.Kind}
+	}
+	return event
+}
+
+func (r *replayer) buttonMessage(data string) (int, string) {
+	var last *fakeadapter.Call
+	for i := len(r.adapter.Calls) - 1; i >= 0; i-- {
+		call := &r.adapter.Calls[i]
+		if call.ChatID != r.user.ID || (call.Op != "send_message" && call.Op != "edit_message") {
+			continue
+		}
+		if call.Keyboard.HasCallback(data) {
+			return call.MessageID, call.Text
+		}
+		if last == nil {
+			last = call
+		}
+	}
+	if last == nil {
+		return 0, ""
+	}
+	return last.MessageID, last.Text
+}
+
+// splitCommand splits "/cmd@bot args" the way Telegram does.
+func splitCommand(text string) (string, string) {
+	command, args, _ := strings.Cut(text, " ")
+	command, _, _ = strings.Cut(strings.TrimPrefix(command, "/"), "@")
+	return command, strings.TrimSpace(args)
+}
+
+func sameEntries(want, got []transcript.Entry) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if want[i].Kind != got[i].Kind || want[i].Text != got[i].Text {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/transcript"
+)
+
+func TestParseTranscript(t *testing.T) {
+	file := strings.Join([]string{
+		"2026-10-16 10:00:00 👤 /start",
+		"2026-10-16 10:00:01 🤖 👤 Имя: Ann",
+		"",
+		"Выберите действие:",
+		"2026-10-16 10:00:05 👤 🔘 section:day",
+		"2026-10-16 10:00:05 🤖 ✏️ Mood?",
+		"2026-10-16 10:00:09 👤 📎 photo at the sea",
+		"2026-10-16 10:00:09 🤖 📎 document",
+		"",
+	}, "\n")
+
+	entries, err := parseTranscript(strings.NewReader(file), time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []transcript.Entry{
+		{Direction: transcript.Inbound, Kind: "command", Text: "/start"},
+		{Direction: transcript.Outbound, Kind: "message", Text: "👤 Имя: Ann\n\nВыберите действие:"},
+		{Direction: transcript.Inbound, Kind: "callback", Text: "section:day"},
+		{Direction: transcript.Outbound, Kind: "edit", Text: "Mood?"},
+		{Direction: transcript.Inbound, Kind: "photo", Text: "at the sea"},
+		{Direction: transcript.Outbound, Kind: "document", Text: ""},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("parsed %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, entry := range entries {
+		if entry.Direction != want[i].Direction || entry.Kind != want[i].Kind || entry.Text != want[i].Text {
+			t.Fatalf("entry %d = %+v, want %+v", i, entry, want[i])
+		}
+	}
+	if got := entries[2].Time; !got.Equal(time.Date(2026, 10, 16, 10, 0, 5, 0, time.UTC)) {
+		t.Fatalf("time %v", got)
+	}
+
+	if _, err := parseTranscript(strings.NewReader("not a transcript\n"), time.UTC); err == nil {
+		t.Fatalf("expected an error for a file without entries")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	questions.RegisterBuiltins()
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"day": {Title: "Day", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Mood?", Type: "text", StoreKey: "mood"}}},
+	}}
+	user := botport.Sender{ID: 42, FirstName: "Ann"}
+	at := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	inbound := []transcript.Entry{
+		{Time: at, Direction: transcript.Inbound, Kind: "command", Text: "/start"},
+		{Time: at, Direction: transcript.Inbound, Kind: "message", Text: config.Message("ru", config.MsgButtonFillRecord)},
+		{Time: at, Direction: transcript.Inbound, Kind: "callback", Text: fsm.CallbackSectionPrefix + "day"},
+		{Time: at, Direction: transcript.Inbound, Kind: "message", Text: "fine"},
+	}
+
+	// A first run records what the bot answers today; it becomes the captured transcript.
+	answers := map[int][]transcript.Entry{}
+	for _, d := range newReplayer(func() *config.RecordConfig { return rc }, user).run(context.Background(), inbound) {
+		answers[d.Step] = d.Got
+	}
+	var captured []transcript.Entry
+	for i, entry := range inbound {
+		captured = append(captured, entry)
+		captured = append(captured, answers[i+1]...)
+	}
+	if !hasText(answers[3], "Mood?") {
+		t.Fatalf("the section callback did not reach the question: %+v", answers[3])
+	}
+
+	changed := make([]transcript.Entry, len(captured))
+	copy(changed, captured)
+	for i := range changed {
+		if changed[i].Direction == transcript.Outbound && changed[i].Text == "Mood?" {
+			changed[i].Text = "How are you?"
+		}
+	}
+
+	tests := []struct {
+		name      string
+		entries   []transcript.Entry
+		wantSteps []int
+	}{
+		{name: "same answers", entries: captured},
+		{name: "changed prompt", entries: changed, wantSteps: []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			divergences := newReplayer(func() *config.RecordConfig { return rc }, user).run(context.Background(), tt.entries)
+			var steps []int
+			for _, d := range divergences {
+				steps = append(steps, d.Step)
+			}
+			if len(steps) != len(tt.wantSteps) || (len(steps) > 0 && steps[0] != tt.wantSteps[0]) {
+				t.Fatalf("diverged at steps %v, want %v", steps, tt.wantSteps)
+			}
+		})
+	}
+}
+
+func hasText(entries []transcript.Entry, text string) bool {
+	for _, entry := range entries {
+		if strings.Contains(entry.Text, text) {
+			return true
+		}
+	}
+	return false
+}
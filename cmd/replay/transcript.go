@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/transcript"
+)
+
+// fileTimeLayout is the time format of the transcript file "/transcript <id> file" sends.
+const fileTimeLayout = "2006-01-02 15:04:05"
+
+// entryLine matches the first line of an entry: "<time> 👤|🤖 <text>". Lines that do not match continue
+// the text of the entry above them.
+var entryLine = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) (👤|🤖) ?(.*)$`)
+
+// parseTranscript reads a transcript file back into entries. Times are read in loc, the zone of the
+// bot that wrote the file.
+func parseTranscript(r io.Reader, loc *time.Location) ([]transcript.Entry, error) {
+	var entries []transcript.Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		match := entryLine.FindStringSubmatch(line)
+		if match == nil {
+			if len(entries) == 0 {
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+				return nil, fmt.Errorf("line %d: expected '<time> 👤|🤖 <text>', got %q", n, line)
+			}
+			entries[len(entries)-1].Text += "\n" + line
+			continue
+		}
+		at, err := time.ParseInLocation(fileTimeLayout, match[1], loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		direction := transcript.Inbound
+		if match[2] == "🤖" {
+			direction = transcript.Outbound
+		}
+		kind, text := entryKind(direction, match[3])
+		entries = append(entries, transcript.Entry{Time: at, Direction: direction, Kind: kind, Text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the transcript: %w", err)
+	}
+	return entries, nil
+}
+
+// entryKind undoes the markers renderTranscriptEntry puts in front of callbacks, edits and media.
+func entryKind(direction transcript.Direction, text string) (string, string) {
+	if data, ok := strings.CutPrefix(text, "🔘 "); ok {
+		return "callback", data
+	}
+	if edited, ok := strings.CutPrefix(text, "✏️ "); ok {
+		return "edit", edited
+	}
+	if media, ok := strings.CutPrefix(text, "📎 "); ok {
+		kind, caption, _ := strings.Cut(media, " ")
+		return kind, caption
+	}
+	if direction == transcript.Inbound && strings.HasPrefix(text, "/") {
+		return "command", text
+	}
+	return "message", text
+}
@@ -1,22 +1,60 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/bot"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/telegramadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/buildinfo"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/dispatch"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/idgen"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logbuffer"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logging"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/metrics"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/storeport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/report/pdf"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/scheduler"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/storage/postgresadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/transcript"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
+	"text/template"
 	"time"
 )
 
+// lifecycleInfo feeds the startup/shutdown notification templates.
+type lifecycleInfo struct {
+	Version    string
+	ConfigHash string
+	Host       string
+	StartedAt  string
+	Uptime     string
+}
+
 func main() {
+	level, err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		log.Panicf("Invalid LOG_LEVEL: %v", err)
+	}
+	logging.Setup(os.Stderr, level, logbuffer.Default.Handler(level))
+	slog.Info("starting telegram-survey-bot", "version", buildinfo.String())
+	metrics.SetGauge("telegram_survey_bot_build_info", "Build metadata of the running bot.", buildinfo.Labels(), 1)
+	startMetricsServer(os.Getenv("METRICS_ADDR"))
 
 	questions.RegisterBuiltins()
 
@@ -24,7 +62,13 @@ func main() {
 	if err := config.LoadConfig(cfgPath); err != nil {
 		log.Panicf("Failed to load configuration: %v", err)
 	}
-	log.Println("Configuration loaded successfully.")
+	slog.Info("configuration loaded")
+	if err := config.LoadTemplatesFromEnv(); err != nil {
+		log.Panicf("Failed to load survey templates: %v", err)
+	}
+	if err := checkQuestionTypes(); err != nil {
+		log.Panicf("Configuration does not match the question types of this build: %v", err)
+	}
 
 	loadedConfig := config.GetConfig()
 
@@ -35,62 +79,328 @@ func main() {
 	if err := config.LoadTargetUserIDFromEnv(); err != nil {
 		log.Panicf("Failed to read TARGET_USER_ID: %v", err)
 	}
+	if err := config.LoadTherapistsFromEnv(); err != nil {
+		log.Panicf("Failed to read THERAPIST_IDS: %v", err)
+	}
+	if err := config.LoadFeatureFlagsFromEnv(); err != nil {
+		log.Panicf("Failed to read feature flags: %v", err)
+	}
+	if err := config.LoadMessagesFromEnv(); err != nil {
+		log.Panicf("Failed to load messages: %v", err)
+	}
 
 	botClient, err := bot.NewClient(botToken)
 	if err != nil {
 		log.Panicf("Failed to initialize bot client: %v", err)
 	}
-	log.Printf("Authorized on account %s", botClient.Self.UserName)
+	slog.Info("authorized", "account", botClient.Self.UserName)
 
-	botPort, err := telegramadapter.New(botClient, log.Default())
+	telegramPort, err := telegramadapter.New(botClient, slog.Default())
 	if err != nil {
 		log.Panicf("Failed to create telegram adapter: %v", err)
 	}
+	transcripts, err := openTranscripts(os.Getenv("TRANSCRIPT_MAX_MESSAGES"), os.Getenv("TRANSCRIPT_MAX_AGE"))
+	if err != nil {
+		log.Panicf("Invalid transcript settings: %v", err)
+	}
+	fsm.SetTranscripts(transcripts)
+	botPort := transcript.Wrap(telegramPort, transcripts)
 
-	notifyTargetOnStartup(botPort)
+	if err := questions.CheckTransport(loadedConfig, botPort.Capabilities()); err != nil {
+		log.Panicf("Configuration is not supported by the Telegram adapter: %v", err)
+	}
+	for _, id := range config.TemplateIDs() {
+		templateConf, _ := config.Template(id)
+		if err := questions.CheckTransport(templateConf, botPort.Capabilities()); err != nil {
+			log.Panicf("Survey '%s' is not supported by the Telegram adapter: %v", id, err)
+		}
+	}
+
+	startedAt := time.Now()
+	notifications := config.LoadNotificationConfigFromEnv()
+	notifyTarget(botPort, notifications, notifications.StartupTemplate, startedAt)
+
+	reminderSchedule, err := scheduler.Open(os.Getenv("REMINDERS_FILE"))
+	if err != nil {
+		log.Panicf("Failed to load personal reminders: %v", err)
+	}
+	fsm.SetReminderSchedule(reminderSchedule)
 
-	fsmCreator := fsm.NewFSMCreator()
-	stateStore := state.NewStore(fsmCreator)
-	updates := botClient.GetUpdatesChan(60)
-	log.Println("Starting update processing...")
+	idGenerator, err := idgen.New(os.Getenv("RECORD_ID_FORMAT"))
+	if err != nil {
+		log.Panicf("Invalid RECORD_ID_FORMAT: %v", err)
+	}
+	fsm.SetIDGenerator(idGenerator)
+
+	if fontPath := os.Getenv("REPORT_FONT"); fontPath != "" {
+		font, err := pdf.LoadFont(fontPath)
+		if err != nil {
+			log.Panicf("Failed to load REPORT_FONT: %v", err)
+		}
+		fsm.SetReportFont(font)
+	}
 
+	handler := fsm.NewHandler(botPort, config.GetConfig)
+	stateStore := handler.Store()
+	keys, err := openKeyring(os.Getenv("RECORD_ENCRYPTION_KEY"), os.Getenv("RECORD_ENCRYPTION_OLD_KEYS"))
+	if err != nil {
+		log.Panicf("Invalid record encryption keys: %v", err)
+	}
+	storePort, err := openStorePort(context.Background(), os.Getenv("STORE_BACKEND"), os.Getenv("DATABASE_URL"), keys, os.Getenv("RECORD_ENCRYPTION_RESEAL") == "true")
+	if err != nil {
+		log.Panicf("Failed to open the store backend: %v", err)
+	}
+	if storePort != nil {
+		defer storePort.Close()
+		if err := stateStore.SetBackend(storeport.NewBackend(storePort), 0); err != nil {
+			log.Panicf("Failed to load the store backend: %v", err)
+		}
+	}
+	metrics.RegisterCollector(fsm.StoreMetricsCollector(stateStore))
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	events := telegramadapter.Listen(ctx, botClient.GetUpdatesChan(60))
+	slog.Info("starting update processing")
+
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigs
-		log.Println("Shutdown signal received...")
+		slog.Info("shutdown signal received")
 		cancel()
 	}()
 
+	jobs := &backgroundJobs{}
+	if idle := os.Getenv("STATE_EVICT_AFTER"); idle != "" {
+		jobs.evictAfter, err = time.ParseDuration(idle)
+		if err != nil || jobs.evictAfter <= 0 {
+			log.Panicf("Invalid STATE_EVICT_AFTER %q", idle)
+		}
+	}
+	jobs.restart(ctx, botPort, loadedConfig, stateStore)
+	config.OnReload(func(cfg *config.RecordConfig) {
+		jobs.restart(ctx, botPort, cfg, stateStore)
+		go fsm.ReconcileUsers(ctx, botPort, cfg, stateStore)
+	})
+	if interval := os.Getenv("CONFIG_WATCH_INTERVAL"); interval != "" {
+		every, err := time.ParseDuration(interval)
+		if err != nil || every <= 0 {
+			log.Panicf("Invalid CONFIG_WATCH_INTERVAL %q", interval)
+		}
+		go config.WatchConfig(ctx, every, fsm.TransportCheck(botPort))
+	}
+	var drainTimeout time.Duration
+	if drain := os.Getenv("DRAIN_TIMEOUT"); drain != "" {
+		drainTimeout, err = time.ParseDuration(drain)
+		if err != nil || drainTimeout <= 0 {
+			log.Panicf("Invalid DRAIN_TIMEOUT %q", drain)
+		}
+	}
+	workers := 0
+	if n := os.Getenv("HANDLER_WORKERS"); n != "" {
+		workers, err = strconv.Atoi(n)
+		if err != nil || workers <= 0 {
+			log.Panicf("Invalid HANDLER_WORKERS %q", n)
+		}
+	}
+	handlers := dispatch.New(ctx, workers)
+
 	for {
 		select {
-		case update := <-updates:
-			if update.UpdateID == 0 {
+		case event, ok := <-events:
+			if !ok {
+				events = nil
 				continue
 			}
-			go fsm.HandleUpdate(ctx, update, botPort, loadedConfig, stateStore)
+			handlers.Submit(event.From.ID, func(ctx context.Context) {
+				handler.HandleUpdate(ctx, event)
+			})
 		case <-ctx.Done():
-			log.Println("Stopping update processing loop...")
+			slog.Info("stopping update processing loop", "pending", handlers.Pending())
+			if err := handlers.Drain(drainTimeout); err != nil {
+				slog.Warn("shutting down before all handlers finished", "err", err)
+			}
+			notifyTarget(botPort, notifications, notifications.ShutdownTemplate, startedAt)
 			return
 		}
 	}
 }
 
-func notifyTargetOnStartup(botPort botport.BotPort) {
+// backgroundJobs runs the periodic jobs on one config and restarts them when the config is reloaded.
+type backgroundJobs struct {
+	mu         sync.Mutex
+	cancel     context.CancelFunc
+	evictAfter time.Duration // Idle time before a user's state leaves memory; 0 keeps everyone
+}
+
+func (j *backgroundJobs) restart(ctx context.Context, botPort botport.BotPort, cfg *config.RecordConfig, store *state.Store) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.cancel != nil {
+		j.cancel()
+	}
+	var jobsCtx context.Context
+	jobsCtx, j.cancel = context.WithCancel(ctx)
+
+	go fsm.RunReminders(jobsCtx, botPort, cfg, store)
+	go fsm.RunPersonalReminders(jobsCtx, botPort, cfg, store)
+	go fsm.RunStuckStateSweep(jobsCtx, cfg, store)
+	go fsm.RunAutoForward(jobsCtx, botPort, cfg, store)
+	go fsm.RunWeeklyReports(jobsCtx, botPort, cfg, store)
+	go fsm.RunEviction(jobsCtx, cfg, store, j.evictAfter)
+}
+
+// checkQuestionTypes runs questions.CheckStrategies on the main config and every survey template and
+// reports them all together.
+func checkQuestionTypes() error {
+	var errs []error
+	if err := questions.CheckStrategies(config.GetConfig()); err != nil {
+		errs = append(errs, fmt.Errorf("main config: %w", err))
+	}
+	for _, id := range config.TemplateIDs() {
+		templateConf, _ := config.Template(id)
+		if err := questions.CheckStrategies(templateConf); err != nil {
+			errs = append(errs, fmt.Errorf("survey '%s': %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// openStorePort opens the storage backend named by STORE_BACKEND. "memory" (the default) returns nil:
+// the store then keeps everything in process memory. With keys the backend stores answers encrypted;
+// reseal rewrites what is stored with the current key before the bot starts.
+func openStorePort(ctx context.Context, backend, dsn string, keys *storeport.Keyring, reseal bool) (storeport.StorePort, error) {
+	switch backend {
+	case "", "memory":
+		if keys != nil {
+			slog.WarnContext(ctx, "RECORD_ENCRYPTION_KEY has no effect without a STORE_BACKEND")
+		}
+		return nil, nil
+	case "postgres":
+		if dsn == "" {
+			return nil, fmt.Errorf("STORE_BACKEND=postgres needs DATABASE_URL")
+		}
+		slog.InfoContext(ctx, "storing users and records in Postgres", "encrypted", keys != nil)
+		adapter, err := postgresadapter.Open(ctx, dsn, keys)
+		if err != nil || !reseal {
+			return adapter, err
+		}
+		rewritten, err := adapter.Reseal(ctx)
+		if err != nil {
+			_ = adapter.Close()
+			return nil, err
+		}
+		slog.InfoContext(ctx, "stored users and records sealed with the current key", "rows", rewritten)
+		return adapter, nil
+	}
+	return nil, fmt.Errorf("unknown STORE_BACKEND %q (want memory or postgres)", backend)
+}
+
+// openKeyring builds the keyring of RECORD_ENCRYPTION_KEY, a base64 AES-256 key, and the
+// comma-separated RECORD_ENCRYPTION_OLD_KEYS it replaced; nil without a key.
+func openKeyring(current, old string) (*storeport.Keyring, error) {
+	if current == "" {
+		if old != "" {
+			return nil, fmt.Errorf("RECORD_ENCRYPTION_OLD_KEYS needs RECORD_ENCRYPTION_KEY")
+		}
+		return nil, nil
+	}
+	var keys [][]byte
+	for _, encoded := range append([]string{current}, strings.Split(old, ",")...) {
+		encoded = strings.TrimSpace(encoded)
+		if encoded == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %d is not base64: %w", len(keys)+1, err)
+		}
+		keys = append(keys, key)
+	}
+	return storeport.NewKeyring(keys...)
+}
+
+// openTranscripts returns the conversation log enabled by TRANSCRIPT_MAX_MESSAGES, or nil without it.
+// TRANSCRIPT_MAX_AGE defaults to 30 days.
+func openTranscripts(maxMessages, maxAge string) (*transcript.Log, error) {
+	if maxMessages == "" {
+		return nil, nil
+	}
+	limits := transcript.Limits{MaxAge: 30 * 24 * time.Hour}
+	var err error
+	if limits.MaxEntries, err = strconv.Atoi(maxMessages); err != nil || limits.MaxEntries <= 0 {
+		return nil, fmt.Errorf("TRANSCRIPT_MAX_MESSAGES %q is not a positive number", maxMessages)
+	}
+	if maxAge != "" {
+		if limits.MaxAge, err = time.ParseDuration(maxAge); err != nil || limits.MaxAge <= 0 {
+			return nil, fmt.Errorf("TRANSCRIPT_MAX_AGE %q is not a positive duration", maxAge)
+		}
+	}
+	slog.Info("conversation transcripts enabled", "max_messages", limits.MaxEntries, "max_age", limits.MaxAge)
+	return transcript.New(limits), nil
+}
+
+// startMetricsServer serves /metrics on addr; an empty addr disables the endpoint.
+func startMetricsServer(addr string) {
+	if addr == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	go func() {
+		slog.Info("serving metrics", "addr", addr+"/metrics")
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error("metrics server stopped", "err", err)
+		}
+	}()
+}
+
+func notifyTarget(botPort botport.BotPort, notifications config.NotificationConfig, tplText string, startedAt time.Time) {
 	targetUserID := config.GetTargetUserID()
-	if targetUserID == 0 {
+	if targetUserID == 0 || !notifications.Enabled {
+		return
+	}
+	text, err := renderLifecycleMessage(tplText, startedAt)
+	if err != nil {
+		slog.Error("failed to render lifecycle notification", "err", err)
 		return
 	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := botPort.SendMessage(ctx, targetUserID, "Бот запущен и готов принимать ответы.", nil)
+	_, err = botPort.SendMessage(ctx, targetUserID, text, nil)
 	if err != nil {
-		log.Printf("[main] Failed to send startup notification to %d: %v", targetUserID, err)
+		slog.Error("failed to send lifecycle notification", "target_id", targetUserID, "err", err)
 		return
 	}
-	log.Printf("[main] Startup notification sent to %d", targetUserID)
+	slog.Info("lifecycle notification sent", "target_id", targetUserID)
+}
+
+func renderLifecycleMessage(tplText string, startedAt time.Time) (string, error) {
+	tpl, err := template.New("lifecycle").Parse(tplText)
+	if err != nil {
+		return "", err
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	configHash := config.GetConfigHash()
+	if len(configHash) > 12 {
+		configHash = configHash[:12]
+	}
+	info := lifecycleInfo{
+		Version:    buildinfo.Version,
+		ConfigHash: configHash,
+		Host:       host,
+		StartedAt:  startedAt.Format("02.01.2006 15:04:05"),
+		Uptime:     time.Since(startedAt).Round(time.Second).String(),
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, info); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
 }
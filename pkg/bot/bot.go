@@ -1,8 +1,9 @@
 package bot
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -24,12 +25,12 @@ func NewClient(token string) (*Client, error) {
 
 	api.Debug = false
 
-	log.Printf("Verifying API token...")
+	slog.Info("verifying API token")
 	ok, err := api.GetMe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify bot token with GetMe(): %w", err)
 	}
-	log.Printf("Token verified successfully.")
+	slog.Info("token verified")
 
 	client := &Client{
 		api:  api,
@@ -39,15 +40,29 @@ func NewClient(token string) (*Client, error) {
 	return client, nil
 }
 
-func (c *Client) SendMessage(chatID int64, text string, markup interface{}) (tgbotapi.Message, error) {
+// MessageOptions are the delivery settings of SendMessage.
+type MessageOptions struct {
+	ReplyTo             int
+	DisableNotification bool
+	ProtectContent      bool
+}
+
+func (c *Client) SendMessage(chatID int64, text string, markup interface{}, opts MessageOptions) (tgbotapi.Message, error) {
 	msg := tgbotapi.NewMessage(chatID, text)
 
 	msg.ParseMode = ""
+	msg.ReplyToMessageID = opts.ReplyTo
+	msg.AllowSendingWithoutReply = opts.ReplyTo != 0
+	msg.DisableNotification = opts.DisableNotification
 
 	if markup != nil {
 		msg.ReplyMarkup = markup
 	}
 
+	if opts.ProtectContent {
+		return c.sendProtectedMessage(msg)
+	}
+
 	sentMsg, err := c.api.Send(msg)
 	if err != nil {
 		return tgbotapi.Message{}, fmt.Errorf("failed to send message: %w", err)
@@ -55,10 +70,35 @@ func (c *Client) SendMessage(chatID int64, text string, markup interface{}) (tgb
 	return sentMsg, nil
 }
 
+// sendProtectedMessage sends msg with protect_content, which the tgbotapi version in use does not
+// model, so the request parameters are built by hand.
+func (c *Client) sendProtectedMessage(msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	params := make(tgbotapi.Params)
+	params.AddNonZero64("chat_id", msg.ChatID)
+	params.AddNonEmpty("text", msg.Text)
+	params.AddNonZero("reply_to_message_id", msg.ReplyToMessageID)
+	params.AddBool("allow_sending_without_reply", msg.AllowSendingWithoutReply)
+	params.AddBool("disable_notification", msg.DisableNotification)
+	params.AddBool("protect_content", true)
+	if err := params.AddInterface("reply_markup", msg.ReplyMarkup); err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("failed to encode reply markup: %w", err)
+	}
+
+	resp, err := c.api.MakeRequest("sendMessage", params)
+	if err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("failed to send message: %w", err)
+	}
+	var sentMsg tgbotapi.Message
+	if err := json.Unmarshal(resp.Result, &sentMsg); err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("failed to decode sent message: %w", err)
+	}
+	return sentMsg, nil
+}
+
 func (c *Client) EditMessageText(chatID int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error) {
 	if messageID == 0 {
-		log.Printf("Warning: EditMessageText called with messageID=0 for chat %d. Sending new message instead.", chatID)
-		return c.SendMessage(chatID, text, markup)
+		slog.Warn("EditMessageText called without a message ID, sending a new message", "chat_id", chatID)
+		return c.SendMessage(chatID, text, markup, MessageOptions{})
 	}
 
 	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
@@ -72,7 +112,7 @@ func (c *Client) EditMessageText(chatID int64, messageID int, text string, marku
 	if err != nil {
 
 		if err.Error() == "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message" {
-			log.Printf("Message %d in chat %d was not modified, ignoring error.", messageID, chatID)
+			slog.Debug("message not modified, ignoring", "message_id", messageID, "chat_id", chatID)
 
 			return tgbotapi.Message{MessageID: messageID, Chat: &tgbotapi.Chat{ID: chatID}}, nil
 		}
@@ -118,6 +158,76 @@ func (c *Client) DeleteMessage(chatID int64, messageID int) error {
 	return nil
 }
 
+// SendSticker sends a sticker by file_id. ProtectContent is not supported for media and is ignored.
+func (c *Client) SendSticker(chatID int64, fileID string, opts MessageOptions) (tgbotapi.Message, error) {
+	msg := tgbotapi.NewSticker(chatID, tgbotapi.FileID(fileID))
+	msg.ReplyToMessageID = opts.ReplyTo
+	msg.AllowSendingWithoutReply = opts.ReplyTo != 0
+	msg.DisableNotification = opts.DisableNotification
+
+	sentMsg, err := c.api.Send(msg)
+	if err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("failed to send sticker: %w", err)
+	}
+	return sentMsg, nil
+}
+
+// SendAnimation sends an animation by file_id. ProtectContent is not supported for media and is ignored.
+func (c *Client) SendAnimation(chatID int64, fileID string, caption string, opts MessageOptions) (tgbotapi.Message, error) {
+	msg := tgbotapi.NewAnimation(chatID, tgbotapi.FileID(fileID))
+	msg.Caption = caption
+	msg.ReplyToMessageID = opts.ReplyTo
+	msg.AllowSendingWithoutReply = opts.ReplyTo != 0
+	msg.DisableNotification = opts.DisableNotification
+
+	sentMsg, err := c.api.Send(msg)
+	if err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("failed to send animation: %w", err)
+	}
+	return sentMsg, nil
+}
+
+// SendDocument uploads data as a file named name.
+func (c *Client) SendDocument(chatID int64, name string, data []byte, caption string, opts MessageOptions) (tgbotapi.Message, error) {
+	file := tgbotapi.FileBytes{Name: name, Bytes: data}
+	if opts.ProtectContent {
+		return c.sendProtectedDocument(chatID, file, caption, opts)
+	}
+
+	msg := tgbotapi.NewDocument(chatID, file)
+	msg.Caption = caption
+	msg.ReplyToMessageID = opts.ReplyTo
+	msg.AllowSendingWithoutReply = opts.ReplyTo != 0
+	msg.DisableNotification = opts.DisableNotification
+
+	sentMsg, err := c.api.Send(msg)
+	if err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("failed to send document: %w", err)
+	}
+	return sentMsg, nil
+}
+
+// sendProtectedDocument uploads file with protect_content, built by hand like sendProtectedMessage.
+func (c *Client) sendProtectedDocument(chatID int64, file tgbotapi.FileBytes, caption string, opts MessageOptions) (tgbotapi.Message, error) {
+	params := make(tgbotapi.Params)
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonEmpty("caption", caption)
+	params.AddNonZero("reply_to_message_id", opts.ReplyTo)
+	params.AddBool("allow_sending_without_reply", opts.ReplyTo != 0)
+	params.AddBool("disable_notification", opts.DisableNotification)
+	params.AddBool("protect_content", true)
+
+	resp, err := c.api.UploadFiles("sendDocument", params, []tgbotapi.RequestFile{{Name: "document", Data: file}})
+	if err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("failed to send document: %w", err)
+	}
+	var sentMsg tgbotapi.Message
+	if err := json.Unmarshal(resp.Result, &sentMsg); err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("failed to decode sent document: %w", err)
+	}
+	return sentMsg, nil
+}
+
 func (c *Client) GetUpdatesChan(timeout int) tgbotapi.UpdatesChannel {
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = timeout
@@ -0,0 +1,58 @@
+package fakeadapter
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+// Chaos makes a FakeAdapter fail calls at random the way Telegram does under load: rate limits,
+// "message is not modified" on edits, and timeouts. A failed call is not recorded, as if it never
+// reached the chat. Errors scripted with Fail take precedence.
+type Chaos struct {
+	Rate float64    // Chance of a call failing, from 0 to 1
+	Rand *rand.Rand // Seeded by the test so a failing run can be reproduced
+	Ops  []string   // Ops that may fail; empty means all of them
+
+	Injected map[string]int // Faults injected so far, by BotError code
+}
+
+// NewChaos returns a Chaos failing calls with the given rate, seeded with seed.
+func NewChaos(rate float64, seed uint64) *Chaos {
+	return &Chaos{Rate: rate, Rand: rand.New(rand.NewPCG(seed, 0))}
+}
+
+// fault picks the error to return from op, or nil to let the call through.
+func (c *Chaos) fault(op string) error {
+	if !c.affects(op) || c.Rand.Float64() >= c.Rate {
+		return nil
+	}
+	var err *botport.BotError
+	switch n := c.Rand.IntN(3); {
+	case n == 0 && op == "edit_message":
+		err = MessageNotModified(op)
+	case n < 2:
+		err = RateLimited(op, time.Duration(1+c.Rand.IntN(30))*time.Second)
+	default:
+		err = wrapContextError(op, context.DeadlineExceeded)
+	}
+	if c.Injected == nil {
+		c.Injected = make(map[string]int)
+	}
+	c.Injected[err.Code]++
+	return err
+}
+
+func (c *Chaos) affects(op string) bool {
+	if len(c.Ops) == 0 {
+		return true
+	}
+	for _, candidate := range c.Ops {
+		if candidate == op {
+			return true
+		}
+	}
+	return false
+}
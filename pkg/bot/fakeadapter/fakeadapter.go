@@ -15,6 +15,10 @@ type FakeAdapter struct {
 	Calls         []Call
 	NextMessageID int
 	FailNext      map[string]error
+	// Caps overrides the reported capabilities; nil reports full Telegram-like support.
+	Caps *botport.Capabilities
+	// Chaos, when set, fails calls at random; see Chaos.
+	Chaos *Chaos
 }
 
 // Call captures a bot operation invocation.
@@ -24,13 +28,25 @@ type Call struct {
 	MessageID int
 	Text      string
 	Markup    interface{}
+	Keyboard  Keyboard // Markup decoded into rows of buttons
 	Callback  string
+	FileID    string // Media file_id of send_sticker and send_animation, file name of send_document
+	Data      []byte // Uploaded content of send_document
+	Options   botport.SendOptions
 }
 
 var _ botport.BotPort = (*FakeAdapter)(nil)
 
+// Capabilities returns Caps, or full support when unset.
+func (f *FakeAdapter) Capabilities() botport.Capabilities {
+	if f.Caps != nil {
+		return *f.Caps
+	}
+	return botport.Capabilities{Text: true, Callbacks: true, Attachments: true, EditInPlace: true}
+}
+
 // SendMessage records a send operation and returns a synthetic BotMessage.
-func (f *FakeAdapter) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}) (botport.BotMessage, error) {
+func (f *FakeAdapter) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts ...botport.SendOption) (botport.BotMessage, error) {
 	if err := ctx.Err(); err != nil {
 		return botport.BotMessage{}, wrapContextError("send_message", err)
 	}
@@ -38,7 +54,7 @@ func (f *FakeAdapter) SendMessage(ctx context.Context, chatID int64, text string
 		return botport.BotMessage{}, err
 	}
 	msgID := f.nextMessageID()
-	f.record(Call{Op: "send_message", ChatID: chatID, MessageID: msgID, Text: text, Markup: markup})
+	f.record(Call{Op: "send_message", ChatID: chatID, MessageID: msgID, Text: text, Markup: markup, Options: botport.ApplySendOptions(opts)})
 	return f.botMessage(chatID, msgID, text), nil
 }
 
@@ -81,6 +97,45 @@ func (f *FakeAdapter) DeleteMessage(ctx context.Context, chatID int64, messageID
 	return nil
 }
 
+// SendSticker records a sticker send.
+func (f *FakeAdapter) SendSticker(ctx context.Context, chatID int64, fileID string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return botport.BotMessage{}, wrapContextError("send_sticker", err)
+	}
+	if err := f.maybeFail("send_sticker"); err != nil {
+		return botport.BotMessage{}, err
+	}
+	msgID := f.nextMessageID()
+	f.record(Call{Op: "send_sticker", ChatID: chatID, MessageID: msgID, FileID: fileID, Options: botport.ApplySendOptions(opts)})
+	return f.botMessage(chatID, msgID, ""), nil
+}
+
+// SendAnimation records an animation send; the caption is kept in Text.
+func (f *FakeAdapter) SendAnimation(ctx context.Context, chatID int64, fileID string, caption string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return botport.BotMessage{}, wrapContextError("send_animation", err)
+	}
+	if err := f.maybeFail("send_animation"); err != nil {
+		return botport.BotMessage{}, err
+	}
+	msgID := f.nextMessageID()
+	f.record(Call{Op: "send_animation", ChatID: chatID, MessageID: msgID, Text: caption, FileID: fileID, Options: botport.ApplySendOptions(opts)})
+	return f.botMessage(chatID, msgID, caption), nil
+}
+
+// SendDocument records a document upload; the caption is kept in Text.
+func (f *FakeAdapter) SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return botport.BotMessage{}, wrapContextError("send_document", err)
+	}
+	if err := f.maybeFail("send_document"); err != nil {
+		return botport.BotMessage{}, err
+	}
+	msgID := f.nextMessageID()
+	f.record(Call{Op: "send_document", ChatID: chatID, MessageID: msgID, Text: caption, FileID: fileName, Data: data, Options: botport.ApplySendOptions(opts)})
+	return f.botMessage(chatID, msgID, caption), nil
+}
+
 // Fail configures the next call for op to return err (wrapped as BotError if needed).
 func (f *FakeAdapter) Fail(op string, err error) {
 	f.mu.Lock()
@@ -104,6 +159,19 @@ func (f *FakeAdapter) LastCall(op string) *Call {
 	return nil
 }
 
+// ChatCalls returns a copy of the calls made in chatID so far; safe while other goroutines use f.
+func (f *FakeAdapter) ChatCalls(chatID int64) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []Call
+	for _, call := range f.Calls {
+		if call.ChatID == chatID {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
 func (f *FakeAdapter) botMessage(chatID int64, messageID int, text string) botport.BotMessage {
 	return botport.BotMessage{
 		ChatID:    chatID,
@@ -126,6 +194,7 @@ func (f *FakeAdapter) nextMessageID() int {
 }
 
 func (f *FakeAdapter) record(call Call) {
+	call.Keyboard = DecodeKeyboard(call.Markup)
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	f.Calls = append(f.Calls, call)
@@ -134,11 +203,11 @@ func (f *FakeAdapter) record(call Call) {
 func (f *FakeAdapter) maybeFail(op string) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	if f.FailNext == nil {
-		return nil
-	}
 	err, ok := f.FailNext[op]
 	if !ok {
+		if f.Chaos != nil {
+			return f.Chaos.fault(op)
+		}
 		return nil
 	}
 	delete(f.FailNext, op)
@@ -148,7 +217,7 @@ func (f *FakeAdapter) maybeFail(op string) error {
 	return &botport.BotError{Op: op, Code: "fake_error", Wrapped: err}
 }
 
-func wrapContextError(op string, err error) error {
+func wrapContextError(op string, err error) *botport.BotError {
 	switch err {
 	case context.Canceled:
 		return &botport.BotError{Op: op, Code: "context_canceled", Wrapped: err}
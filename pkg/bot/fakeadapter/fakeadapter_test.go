@@ -24,6 +24,22 @@ func TestSendMessageRecordsCall(t *testing.T) {
 	}
 }
 
+func TestSendMediaRecordsFileID(t *testing.T) {
+	f := &FakeAdapter{}
+	if _, err := f.SendSticker(context.Background(), 1, "sticker-id"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := f.SendAnimation(context.Background(), 1, "anim-id", "caption"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if call := f.LastCall("send_sticker"); call == nil || call.FileID != "sticker-id" {
+		t.Fatalf("recorded sticker mismatch: %+v", call)
+	}
+	if call := f.LastCall("send_animation"); call == nil || call.FileID != "anim-id" || call.Text != "caption" {
+		t.Fatalf("recorded animation mismatch: %+v", call)
+	}
+}
+
 func TestEditMessageUsesProvidedID(t *testing.T) {
 	f := &FakeAdapter{}
 	msg, err := f.EditMessage(context.Background(), 2, 99, "edit", nil)
@@ -97,3 +113,40 @@ func TestAnswerCallbackRecorded(t *testing.T) {
 		t.Fatalf("expected callback recorded, got %+v", call)
 	}
 }
+
+func TestChaosFailsCalls(t *testing.T) {
+	tests := []struct {
+		name       string
+		chaos      *Chaos
+		wantFailed int
+	}{
+		{name: "never", chaos: NewChaos(0, 1)},
+		{name: "always", chaos: NewChaos(1, 1), wantFailed: 20},
+		{name: "other ops only", chaos: &Chaos{Rate: 1, Rand: NewChaos(0, 1).Rand, Ops: []string{"delete_message"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &FakeAdapter{Chaos: tt.chaos}
+			failed := 0
+			for i := 0; i < 20; i++ {
+				if _, err := f.EditMessage(context.Background(), 1, 2, "x", nil); err != nil {
+					var be *botport.BotError
+					if !errors.As(err, &be) {
+						t.Fatalf("expected BotError, got %T", err)
+					}
+					failed++
+				}
+			}
+			if failed != tt.wantFailed || len(f.Calls) != 20-failed {
+				t.Fatalf("%d of 20 edits failed, %d recorded; want %d failed", failed, len(f.Calls), tt.wantFailed)
+			}
+			injected := 0
+			for _, n := range tt.chaos.Injected {
+				injected += n
+			}
+			if injected != failed {
+				t.Fatalf("Injected counts %v, want %d faults", tt.chaos.Injected, failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package fakeadapter
+
+import (
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Keyboard kinds.
+const (
+	KeyboardInline = "inline" // Buttons under the message, with callback data
+	KeyboardReply  = "reply"  // Buttons replacing the phone keyboard, sending their label
+	KeyboardRemove = "remove" // Removes the reply keyboard
+)
+
+// Keyboard is the markup of a call decoded into rows of buttons, so tests can assert on the layout
+// without the Telegram types. The zero Keyboard means the call had no markup.
+type Keyboard struct {
+	Kind string
+	Rows [][]Button
+}
+
+// Button is one keyboard button. Data is the callback data of inline buttons.
+type Button struct {
+	Label string
+	Data  string
+}
+
+// DecodeKeyboard reads markup as passed to the bot port; unknown markup decodes to the zero Keyboard.
+func DecodeKeyboard(markup interface{}) Keyboard {
+	switch m := markup.(type) {
+	case botport.Keyboard:
+		return decodeNeutral(m)
+	case *botport.Keyboard:
+		if m == nil {
+			return Keyboard{}
+		}
+		return decodeNeutral(*m)
+	case *botport.ReplyKeyboard:
+		if m == nil {
+			return Keyboard{}
+		}
+		return decodeReply(*m)
+	case botport.ReplyKeyboard:
+		return decodeReply(m)
+	case botport.RemoveKeyboard, *botport.RemoveKeyboard:
+		return Keyboard{Kind: KeyboardRemove}
+	case tgbotapi.InlineKeyboardMarkup:
+		return decodeInline(m)
+	case *tgbotapi.InlineKeyboardMarkup:
+		if m == nil {
+			return Keyboard{}
+		}
+		return decodeInline(*m)
+	case tgbotapi.ReplyKeyboardMarkup:
+		keyboard := Keyboard{Kind: KeyboardReply}
+		for _, row := range m.Keyboard {
+			buttons := make([]Button, 0, len(row))
+			for _, b := range row {
+				buttons = append(buttons, Button{Label: b.Text})
+			}
+			keyboard.Rows = append(keyboard.Rows, buttons)
+		}
+		return keyboard
+	case tgbotapi.ReplyKeyboardRemove:
+		return Keyboard{Kind: KeyboardRemove}
+	default:
+		return Keyboard{}
+	}
+}
+
+func decodeNeutral(m botport.Keyboard) Keyboard {
+	keyboard := Keyboard{Kind: KeyboardInline}
+	for _, row := range m.Rows {
+		buttons := make([]Button, 0, len(row))
+		for _, b := range row {
+			buttons = append(buttons, Button{Label: b.Label, Data: b.Action})
+		}
+		keyboard.Rows = append(keyboard.Rows, buttons)
+	}
+	return keyboard
+}
+
+func decodeReply(m botport.ReplyKeyboard) Keyboard {
+	keyboard := Keyboard{Kind: KeyboardReply}
+	for _, row := range m.Rows {
+		buttons := make([]Button, 0, len(row))
+		for _, label := range row {
+			buttons = append(buttons, Button{Label: label})
+		}
+		keyboard.Rows = append(keyboard.Rows, buttons)
+	}
+	return keyboard
+}
+
+func decodeInline(m tgbotapi.InlineKeyboardMarkup) Keyboard {
+	keyboard := Keyboard{Kind: KeyboardInline}
+	for _, row := range m.InlineKeyboard {
+		buttons := make([]Button, 0, len(row))
+		for _, b := range row {
+			button := Button{Label: b.Text}
+			if b.CallbackData != nil {
+				button.Data = *b.CallbackData
+			}
+			buttons = append(buttons, button)
+		}
+		keyboard.Rows = append(keyboard.Rows, buttons)
+	}
+	return keyboard
+}
+
+// Buttons returns all buttons row by row.
+func (k Keyboard) Buttons() []Button {
+	var buttons []Button
+	for _, row := range k.Rows {
+		buttons = append(buttons, row...)
+	}
+	return buttons
+}
+
+// Labels returns the labels of all buttons row by row.
+func (k Keyboard) Labels() []string {
+	var labels []string
+	for _, b := range k.Buttons() {
+		labels = append(labels, b.Label)
+	}
+	return labels
+}
+
+// Callbacks returns the callback data of all inline buttons row by row.
+func (k Keyboard) Callbacks() []string {
+	var data []string
+	for _, b := range k.Buttons() {
+		if b.Data != "" {
+			data = append(data, b.Data)
+		}
+	}
+	return data
+}
+
+// ButtonByData returns the button with the given callback data.
+func (k Keyboard) ButtonByData(data string) (Button, bool) {
+	for _, b := range k.Buttons() {
+		if b.Data == data {
+			return b, true
+		}
+	}
+	return Button{}, false
+}
+
+// ButtonByLabel returns the first button with the given label.
+func (k Keyboard) ButtonByLabel(label string) (Button, bool) {
+	for _, b := range k.Buttons() {
+		if b.Label == label {
+			return b, true
+		}
+	}
+	return Button{}, false
+}
+
+// HasCallback reports whether a button carries data.
+func (k Keyboard) HasCallback(data string) bool {
+	_, ok := k.ButtonByData(data)
+	return ok
+}
+
+// String renders the layout for failure messages: one bracketed row per line, "label → data" buttons.
+func (k Keyboard) String() string {
+	if k.Kind == "" {
+		return "<no keyboard>"
+	}
+	var sb strings.Builder
+	sb.WriteString(k.Kind)
+	for _, row := range k.Rows {
+		sb.WriteString("\n[")
+		for i, b := range row {
+			if i > 0 {
+				sb.WriteString(" | ")
+			}
+			sb.WriteString(b.Label)
+			if b.Data != "" {
+				sb.WriteString(" → " + b.Data)
+			}
+		}
+		sb.WriteString("]")
+	}
+	return sb.String()
+}
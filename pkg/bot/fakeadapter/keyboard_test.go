@@ -0,0 +1,70 @@
+package fakeadapter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestDecodeKeyboard(t *testing.T) {
+	inline := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Yes", "a:yes"), tgbotapi.NewInlineKeyboardButtonData("No", "a:no")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Back", "back")),
+	)
+	neutral := botport.NewKeyboard(
+		botport.NewRow(botport.NewButton("Yes", "a:yes"), botport.NewButton("No", "a:no")),
+		botport.NewRow(botport.NewButton("Back", "back")),
+	)
+	tests := []struct {
+		name   string
+		markup interface{}
+		want   Keyboard
+	}{
+		{name: "none", markup: nil, want: Keyboard{}},
+		{name: "inline value", markup: inline, want: Keyboard{Kind: KeyboardInline, Rows: [][]Button{{{"Yes", "a:yes"}, {"No", "a:no"}}, {{"Back", "back"}}}}},
+		{name: "inline pointer", markup: &inline, want: Keyboard{Kind: KeyboardInline, Rows: [][]Button{{{"Yes", "a:yes"}, {"No", "a:no"}}, {{"Back", "back"}}}}},
+		{name: "neutral", markup: neutral, want: Keyboard{Kind: KeyboardInline, Rows: [][]Button{{{"Yes", "a:yes"}, {"No", "a:no"}}, {{"Back", "back"}}}}},
+		{name: "reply", markup: tgbotapi.NewReplyKeyboard(tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("Fill"))), want: Keyboard{Kind: KeyboardReply, Rows: [][]Button{{{Label: "Fill"}}}}},
+		{name: "remove", markup: tgbotapi.NewRemoveKeyboard(true), want: Keyboard{Kind: KeyboardRemove}},
+		{name: "neutral reply", markup: botport.NewReplyKeyboard([]string{"Fill"}), want: Keyboard{Kind: KeyboardReply, Rows: [][]Button{{{Label: "Fill"}}}}},
+		{name: "neutral remove", markup: botport.RemoveKeyboard{}, want: Keyboard{Kind: KeyboardRemove}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DecodeKeyboard(tt.markup); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("DecodeKeyboard = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallKeyboardMatchers(t *testing.T) {
+	f := &FakeAdapter{}
+	markup := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Yes", "a:yes"), tgbotapi.NewInlineKeyboardButtonData("No", "a:no")),
+	)
+	if _, err := f.SendMessage(context.Background(), 1, "Sure?", markup); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keyboard := f.LastCall("send_message").Keyboard
+	if got := keyboard.Labels(); !reflect.DeepEqual(got, []string{"Yes", "No"}) {
+		t.Fatalf("labels %v", got)
+	}
+	if got := keyboard.Callbacks(); !reflect.DeepEqual(got, []string{"a:yes", "a:no"}) {
+		t.Fatalf("callbacks %v", got)
+	}
+	if b, ok := keyboard.ButtonByLabel("No"); !ok || b.Data != "a:no" {
+		t.Fatalf("ButtonByLabel(No) = %+v, %t", b, ok)
+	}
+	if !keyboard.HasCallback("a:yes") || keyboard.HasCallback("a:maybe") {
+		t.Fatalf("HasCallback mismatch in %s", keyboard)
+	}
+	if want := "inline\n[Yes → a:yes | No → a:no]"; keyboard.String() != want {
+		t.Fatalf("String() = %q, want %q", keyboard.String(), want)
+	}
+}
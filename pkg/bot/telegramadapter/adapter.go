@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"regexp"
 	"strings"
 	"time"
@@ -19,70 +19,127 @@ import (
 // Package telegramadapter implements botport.BotPort using the existing Telegram client.
 // See PRPs/ai_docs/botport_hex_adapter.md for naming conventions and error semantics.
 
-// Logger defines the minimal logging interface used by the adapter.
-type Logger interface {
-	Printf(format string, args ...any)
-}
-
 type telegramClient interface {
-	SendMessage(chatID int64, text string, markup interface{}) (tgbotapi.Message, error)
+	SendMessage(chatID int64, text string, markup interface{}, opts bot.MessageOptions) (tgbotapi.Message, error)
 	EditMessageText(chatID int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error)
 	AnswerCallback(callbackID string, text string) error
 	DeleteMessage(chatID int64, messageID int) error
+	SendSticker(chatID int64, fileID string, opts bot.MessageOptions) (tgbotapi.Message, error)
+	SendAnimation(chatID int64, fileID string, caption string, opts bot.MessageOptions) (tgbotapi.Message, error)
+	SendDocument(chatID int64, name string, data []byte, caption string, opts bot.MessageOptions) (tgbotapi.Message, error)
 }
 
-// Adapter wraps a Telegram client and satisfies botport.BotPort.
+// Adapter wraps a Telegram client and satisfies botport.BotPort. Sends and edits go through a per-chat
+// queue that retries them when Telegram rate-limits the bot (see RetryPolicy).
 type Adapter struct {
 	client telegramClient
-	logger Logger
+	logger *slog.Logger
+	retry  RetryPolicy
+	queue  chatQueue
+	sleep  func(ctx context.Context, d time.Duration) error
 }
 
 var _ telegramClient = (*bot.Client)(nil)
 var _ botport.BotPort = (*Adapter)(nil)
 
-// New constructs a Telegram adapter with the provided bot client and logger.
-func New(client telegramClient, logger Logger) (*Adapter, error) {
+// New constructs a Telegram adapter with the provided bot client and logger; a nil logger means
+// slog.Default().
+func New(client telegramClient, logger *slog.Logger) (*Adapter, error) {
 	if client == nil {
 		return nil, fmt.Errorf("telegramadapter: client is nil")
 	}
 	if logger == nil {
-		logger = log.Default()
+		logger = slog.Default()
 	}
 	return &Adapter{
 		client: client,
 		logger: logger,
+		retry:  RetryPolicy{MaxRetries: DefaultMaxRetries, MaxWait: DefaultMaxWait},
+		sleep:  sleepContext,
 	}, nil
 }
 
-// SendMessage dispatches a new Telegram message and returns a botport.BotMessage record.
-func (a *Adapter) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}) (botport.BotMessage, error) {
+// Capabilities reports the Telegram feature set used by the bot.
+func (a *Adapter) Capabilities() botport.Capabilities {
+	return botport.Capabilities{Text: true, Callbacks: true, Attachments: true, EditInPlace: true}
+}
+
+// SendMessage dispatches a new Telegram message and returns a botport.BotMessage record. Text over
+// botport.MaxMessageLength is sent as several messages in a row, split by botport.SplitText: the
+// markup goes with the last one, which is the message returned with the IDs of all parts in PartIDs,
+// and a reply threads the first one. Other sends to the chat wait until all parts are out; when a part
+// fails, the earlier ones stay sent.
+func (a *Adapter) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts ...botport.SendOption) (botport.BotMessage, error) {
 	if err := ctx.Err(); err != nil {
 		return botport.BotMessage{}, wrapContextError("send_message", err)
 	}
-	msg, err := a.client.SendMessage(chatID, text, markup)
+	markup = telegramMarkup(markup)
+	parts := botport.SplitText(text, botport.MaxMessageLength)
+	release, err := a.queue.acquire(ctx, chatID)
 	if err != nil {
-		return botport.BotMessage{}, a.wrapAndLogError("send_message", chatID, 0, err)
+		return botport.BotMessage{}, wrapContextError("send_message", err)
+	}
+	defer release()
+
+	var msg tgbotapi.Message
+	var partIDs []int
+	options := messageOptions(opts)
+	onRetry := botport.ApplySendOptions(opts).OnRetry
+	for i, part := range parts {
+		var partMarkup interface{}
+		if i == len(parts)-1 {
+			partMarkup = markup
+		}
+		if i > 0 {
+			options.ReplyTo = 0
+		}
+		err := a.retryRateLimited(ctx, "send_message", chatID, func() error {
+			var err error
+			if msg, err = a.client.SendMessage(chatID, part, partMarkup, options); err != nil {
+				return a.wrapAndLogError(ctx, "send_message", chatID, 0, err)
+			}
+			return nil
+		}, onRetry)
+		if err != nil {
+			return botport.BotMessage{}, err
+		}
+		partIDs = append(partIDs, msg.MessageID)
 	}
 	bm := toBotMessage(msg, markup)
-	a.log("send_message", map[string]any{"chat_id": bm.ChatID, "message_id": bm.MessageID})
+	if len(parts) > 1 {
+		bm.PartIDs = partIDs
+	}
+	a.log(ctx, "send_message", "chat_id", bm.ChatID, "message_id", bm.MessageID, "parts", len(parts))
 	return bm, nil
 }
 
-// EditMessage edits an existing Telegram message.
+// EditMessage edits an existing Telegram message. An edit cannot become several messages, so text over
+// botport.MaxMessageLength is cut to fit by botport.TruncateText, marked with an ellipsis.
 func (a *Adapter) EditMessage(ctx context.Context, chatID int64, messageID int, text string, markup interface{}) (botport.BotMessage, error) {
 	if err := ctx.Err(); err != nil {
 		return botport.BotMessage{}, wrapContextError("edit_message", err)
 	}
-	inlineMarkup, err := toInlineKeyboard(markup)
+	if truncated := botport.TruncateText(text, botport.MaxMessageLength); truncated != text {
+		a.logger.WarnContext(ctx, "edit over the message length limit truncated", "chat_id", chatID, "message_id", messageID, "length", len([]rune(text)))
+		text = truncated
+	}
+	inlineMarkup, err := toInlineKeyboard(telegramMarkup(markup))
 	if err != nil {
 		return botport.BotMessage{}, botport.NewBotError("edit_message", "bad_payload", err)
 	}
-	msg, err := a.client.EditMessageText(chatID, messageID, text, inlineMarkup)
+	var msg tgbotapi.Message
+	err = a.sendQueued(ctx, "edit_message", chatID, func() error {
+		var err error
+		if msg, err = a.client.EditMessageText(chatID, messageID, text, inlineMarkup); err != nil {
+			return a.wrapAndLogError(ctx, "edit_message", chatID, messageID, err)
+		}
+		return nil
+	})
 	if err != nil {
-		return botport.BotMessage{}, a.wrapAndLogError("edit_message", chatID, messageID, err)
+		return botport.BotMessage{}, err
 	}
 	bm := toBotMessage(msg, inlineMarkup)
-	a.log("edit_message", map[string]any{"chat_id": bm.ChatID, "message_id": bm.MessageID})
+	a.log(ctx, "edit_message", "chat_id", bm.ChatID, "message_id", bm.MessageID)
 	return bm, nil
 }
 
@@ -92,9 +149,9 @@ func (a *Adapter) AnswerCallback(ctx context.Context, callbackID string, text st
 		return wrapContextError("answer_callback", err)
 	}
 	if err := a.client.AnswerCallback(callbackID, text); err != nil {
-		return a.wrapAndLogError("answer_callback", 0, 0, err)
+		return a.wrapAndLogError(ctx, "answer_callback", 0, 0, err)
 	}
-	a.log("answer_callback", map[string]any{"callback_id": callbackID})
+	a.log(ctx, "answer_callback", "callback_id", callbackID)
 	return nil
 }
 
@@ -104,28 +161,128 @@ func (a *Adapter) DeleteMessage(ctx context.Context, chatID int64, messageID int
 		return wrapContextError("delete_message", err)
 	}
 	if err := a.client.DeleteMessage(chatID, messageID); err != nil {
-		return a.wrapAndLogError("delete_message", chatID, messageID, err)
+		return a.wrapAndLogError(ctx, "delete_message", chatID, messageID, err)
 	}
-	a.log("delete_message", map[string]any{"chat_id": chatID, "message_id": messageID})
+	a.log(ctx, "delete_message", "chat_id", chatID, "message_id", messageID)
 	return nil
 }
 
-func (a *Adapter) wrapAndLogError(op string, chatID int64, messageID int, err error) error {
+// SendSticker sends a Telegram sticker by file_id.
+func (a *Adapter) SendSticker(ctx context.Context, chatID int64, fileID string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return botport.BotMessage{}, wrapContextError("send_sticker", err)
+	}
+	msg, err := a.client.SendSticker(chatID, fileID, messageOptions(opts))
+	if err != nil {
+		return botport.BotMessage{}, a.wrapAndLogError(ctx, "send_sticker", chatID, 0, err)
+	}
+	bm := toBotMessage(msg, nil)
+	a.log(ctx, "send_sticker", "chat_id", bm.ChatID, "message_id", bm.MessageID)
+	return bm, nil
+}
+
+// SendAnimation sends a Telegram animation (GIF or silent video) by file_id with an optional caption.
+func (a *Adapter) SendAnimation(ctx context.Context, chatID int64, fileID string, caption string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return botport.BotMessage{}, wrapContextError("send_animation", err)
+	}
+	msg, err := a.client.SendAnimation(chatID, fileID, caption, messageOptions(opts))
+	if err != nil {
+		return botport.BotMessage{}, a.wrapAndLogError(ctx, "send_animation", chatID, 0, err)
+	}
+	bm := toBotMessage(msg, nil)
+	a.log(ctx, "send_animation", "chat_id", bm.ChatID, "message_id", bm.MessageID)
+	return bm, nil
+}
+
+// SendDocument uploads data to Telegram as a document named fileName.
+func (a *Adapter) SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return botport.BotMessage{}, wrapContextError("send_document", err)
+	}
+	msg, err := a.client.SendDocument(chatID, fileName, data, caption, messageOptions(opts))
+	if err != nil {
+		return botport.BotMessage{}, a.wrapAndLogError(ctx, "send_document", chatID, 0, err)
+	}
+	bm := toBotMessage(msg, nil)
+	a.log(ctx, "send_document", "chat_id", bm.ChatID, "message_id", bm.MessageID, "file_name", fileName, "bytes", len(data))
+	return bm, nil
+}
+
+// messageOptions converts port send options to the client's.
+func messageOptions(opts []botport.SendOption) bot.MessageOptions {
+	options := botport.ApplySendOptions(opts)
+	return bot.MessageOptions{
+		ReplyTo:             options.ReplyTo,
+		DisableNotification: options.DisableNotification,
+		ProtectContent:      options.ProtectContent,
+	}
+}
+
+func (a *Adapter) wrapAndLogError(ctx context.Context, op string, chatID int64, messageID int, err error) error {
 	wrapped := wrapTelegramError(op, err)
-	a.log(op, map[string]any{
-		"chat_id":    chatID,
-		"message_id": messageID,
-		"code":       getBotErrorCode(wrapped),
-		"error":      err.Error(),
-	})
+	if a.logger != nil {
+		a.logger.WarnContext(ctx, "botport call failed", "op", op, "chat_id", chatID, "message_id", messageID,
+			"code", getBotErrorCode(wrapped), "err", err)
+	}
 	return wrapped
 }
 
-func (a *Adapter) log(op string, attrs map[string]any) {
+// log records a finished botport call; ctx carries the correlation ID of the update that caused it.
+func (a *Adapter) log(ctx context.Context, op string, attrs ...any) {
 	if a.logger == nil {
 		return
 	}
-	a.logger.Printf("botport op=%s attrs=%v", op, attrs)
+	a.logger.DebugContext(ctx, "botport call", append([]any{"op", op}, attrs...)...)
+}
+
+// telegramMarkup converts the neutral botport keyboards into Telegram markup; Telegram markup and nil
+// pass through unchanged.
+func telegramMarkup(markup interface{}) interface{} {
+	switch v := markup.(type) {
+	case botport.Keyboard:
+		return inlineKeyboard(v)
+	case *botport.Keyboard:
+		if v == nil {
+			return nil
+		}
+		return inlineKeyboard(*v)
+	case botport.ReplyKeyboard:
+		return replyKeyboard(v)
+	case *botport.ReplyKeyboard:
+		if v == nil {
+			return nil
+		}
+		return replyKeyboard(*v)
+	case botport.RemoveKeyboard, *botport.RemoveKeyboard:
+		return tgbotapi.NewRemoveKeyboard(true)
+	default:
+		return markup
+	}
+}
+
+func replyKeyboard(keyboard botport.ReplyKeyboard) tgbotapi.ReplyKeyboardMarkup {
+	rows := make([][]tgbotapi.KeyboardButton, 0, len(keyboard.Rows))
+	for _, row := range keyboard.Rows {
+		buttons := make([]tgbotapi.KeyboardButton, 0, len(row))
+		for _, label := range row {
+			buttons = append(buttons, tgbotapi.NewKeyboardButton(label))
+		}
+		rows = append(rows, buttons)
+	}
+	return tgbotapi.NewReplyKeyboard(rows...)
+}
+
+func inlineKeyboard(keyboard botport.Keyboard) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(keyboard.Rows))
+	for _, row := range keyboard.Rows {
+		buttons := make([]tgbotapi.InlineKeyboardButton, 0, len(row))
+		for _, button := range row {
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(button.Label, button.Action))
+		}
+		rows = append(rows, buttons)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
 func toInlineKeyboard(markup interface{}) (*tgbotapi.InlineKeyboardMarkup, error) {
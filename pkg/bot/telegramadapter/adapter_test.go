@@ -3,9 +3,14 @@ package telegramadapter
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -24,7 +29,7 @@ func TestAdapterSendMessageSuccess(t *testing.T) {
 			return tgbotapi.Message{MessageID: messageID, Text: text, Chat: &tgbotapi.Chat{ID: chatID}}, nil
 		},
 	}
-	adapter, err := New(fc, testLogger{t})
+	adapter, err := New(fc, testLogger(t))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -66,10 +71,11 @@ func TestAdapterSendMessageWrapsRateLimitError(t *testing.T) {
 			return tgbotapi.Message{}, nil
 		},
 	}
-	adapter, err := New(fc, testLogger{t})
+	adapter, err := New(fc, testLogger(t))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	adapter.SetRetryPolicy(RetryPolicy{})
 
 	_, err = adapter.SendMessage(context.Background(), 1, "hi", nil)
 	if err == nil {
@@ -87,6 +93,309 @@ func TestAdapterSendMessageWrapsRateLimitError(t *testing.T) {
 	}
 }
 
+func TestAdapterRetriesRateLimitedSends(t *testing.T) {
+	rateLimited := errors.New("Too Many Requests: retry after 2")
+
+	tests := []struct {
+		name      string
+		failures  int // Rate-limited attempts before the client succeeds
+		failWith  error
+		policy    RetryPolicy
+		wantCalls int
+		wantWaits []time.Duration
+		wantCode  string
+	}{
+		{name: "succeeds after retries", failures: 2, failWith: rateLimited, policy: RetryPolicy{MaxRetries: 3, MaxWait: time.Minute}, wantCalls: 3, wantWaits: []time.Duration{2 * time.Second, 2 * time.Second}},
+		{name: "gives up after max retries", failures: 5, failWith: rateLimited, policy: RetryPolicy{MaxRetries: 2, MaxWait: time.Minute}, wantCalls: 3, wantWaits: []time.Duration{2 * time.Second, 2 * time.Second}, wantCode: "rate_limited"},
+		{name: "wait longer than allowed", failures: 1, failWith: rateLimited, policy: RetryPolicy{MaxRetries: 3, MaxWait: time.Second}, wantCalls: 1, wantCode: "rate_limited"},
+		{name: "no retry after", failures: 1, failWith: errors.New("Too Many Requests"), policy: RetryPolicy{MaxRetries: 3, MaxWait: time.Minute}, wantCalls: 2, wantWaits: []time.Duration{time.Second}},
+		{name: "other errors are not retried", failures: 1, failWith: errors.New("Bad Request: chat not found"), policy: RetryPolicy{MaxRetries: 3, MaxWait: time.Minute}, wantCalls: 1, wantCode: "bad_request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, op := range []string{"send_message", "edit_message"} {
+				calls := 0
+				respond := func(chatID int64, messageID int) (tgbotapi.Message, error) {
+					calls++
+					if calls <= tt.failures {
+						return tgbotapi.Message{}, tt.failWith
+					}
+					return tgbotapi.Message{MessageID: messageID, Chat: &tgbotapi.Chat{ID: chatID}}, nil
+				}
+				fc := &fakeClient{
+					sendFn: func(chatID int64, _ string, _ interface{}) (tgbotapi.Message, error) { return respond(chatID, 9) },
+					editFn: func(chatID int64, messageID int, _ string, _ *tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error) {
+						return respond(chatID, messageID)
+					},
+				}
+				adapter, err := New(fc, testLogger(t))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				adapter.SetRetryPolicy(tt.policy)
+				var waits []time.Duration
+				adapter.sleep = func(_ context.Context, d time.Duration) error {
+					waits = append(waits, d)
+					return nil
+				}
+
+				var msg botport.BotMessage
+				retries := len(tt.wantWaits) // Edits take no OnRetry
+				if op == "send_message" {
+					retries = 0
+					msg, err = adapter.SendMessage(context.Background(), 4, "hi", nil, botport.OnRetry(func(err error) {
+						if !botport.IsCode(err, "rate_limited") {
+							t.Errorf("OnRetry got %v, want the rate limit", err)
+						}
+						retries++
+					}))
+				} else {
+					msg, err = adapter.EditMessage(context.Background(), 4, 9, "hi", nil)
+				}
+
+				if calls != tt.wantCalls || len(waits) != len(tt.wantWaits) || retries != len(tt.wantWaits) {
+					t.Fatalf("%s: %d calls, waits %v, %d retries told; want %d, %v", op, calls, waits, retries, tt.wantCalls, tt.wantWaits)
+				}
+				for i := range waits {
+					if waits[i] != tt.wantWaits[i] {
+						t.Fatalf("%s: waits %v, want %v", op, waits, tt.wantWaits)
+					}
+				}
+				if tt.wantCode != "" {
+					if !botport.IsCode(err, tt.wantCode) {
+						t.Fatalf("%s: expected %s, got %v", op, tt.wantCode, err)
+					}
+					continue
+				}
+				if err != nil || msg.MessageID != 9 {
+					t.Fatalf("%s: msg=%+v err=%v", op, msg, err)
+				}
+			}
+		})
+	}
+}
+
+func TestAdapterQueueKeepsChatOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	firstTry := true
+	fc := &fakeClient{
+		sendFn: func(chatID int64, text string, _ interface{}) (tgbotapi.Message, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if text == "first" && firstTry {
+				firstTry = false
+				return tgbotapi.Message{}, errors.New("Too Many Requests: retry after 1")
+			}
+			order = append(order, text)
+			return tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}}, nil
+		},
+	}
+	adapter, err := New(fc, testLogger(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waiting := make(chan struct{})
+	resume := make(chan struct{})
+	adapter.sleep = func(context.Context, time.Duration) error {
+		close(waiting)
+		<-resume
+		return nil
+	}
+
+	firstDone := make(chan error)
+	go func() {
+		_, err := adapter.SendMessage(context.Background(), 1, "first", nil)
+		firstDone <- err
+	}()
+	<-waiting
+
+	// Another chat is not held up by the rate-limited one.
+	if _, err := adapter.SendMessage(context.Background(), 2, "other chat", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The same chat waits behind the retry.
+	secondDone := make(chan error)
+	go func() {
+		_, err := adapter.SendMessage(context.Background(), 1, "second", nil)
+		secondDone <- err
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := adapter.SendMessage(ctx, 1, "given up", nil); !botport.IsCode(err, "context_deadline") {
+		t.Fatalf("expected the queued call to time out, got %v", err)
+	}
+
+	close(resume)
+	if err := <-firstDone; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := <-secondDone; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"other chat", "first", "second"}
+	if len(order) != len(want) {
+		t.Fatalf("sent %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("sent %v, want %v", order, want)
+		}
+	}
+	if len(adapter.queue.lanes) != 0 {
+		t.Fatalf("idle chats must not keep a queue, got %d", len(adapter.queue.lanes))
+	}
+}
+
+func TestAdapterDeleteMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		clientErr error
+		wantCode  string
+	}{
+		{name: "deleted"},
+		{name: "already gone", clientErr: errors.New("Bad Request: message to delete not found"), wantCode: "bad_request"},
+		{name: "blocked", clientErr: errors.New("Forbidden: bot was blocked by the user"), wantCode: "forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []int
+			fc := &fakeClient{delFn: func(chatID int64, messageID int) error {
+				deleted = append(deleted, messageID)
+				return tt.clientErr
+			}}
+			adapter, err := New(fc, testLogger(t))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = adapter.DeleteMessage(context.Background(), 3, 11)
+			if len(deleted) != 1 || deleted[0] != 11 {
+				t.Fatalf("client deletes = %v, want [11]", deleted)
+			}
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !botport.IsCode(err, tt.wantCode) {
+				t.Fatalf("expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestAdapterConvertsNeutralKeyboard(t *testing.T) {
+	keyboard := botport.NewKeyboard(
+		botport.NewRow(botport.NewButton("Yes", "a:yes"), botport.NewButton("No", "a:no")),
+		botport.NewRow(botport.NewButton("Back", "a:back")),
+	)
+	tests := []struct {
+		name   string
+		markup interface{}
+	}{
+		{name: "pointer", markup: keyboard},
+		{name: "value", markup: *keyboard},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent interface{}
+			var edited *tgbotapi.InlineKeyboardMarkup
+			fc := &fakeClient{
+				sendFn: func(chatID int64, text string, markup interface{}) (tgbotapi.Message, error) {
+					sent = markup
+					return tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}}, nil
+				},
+				editFn: func(chatID int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error) {
+					edited = markup
+					return tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}}, nil
+				},
+			}
+			adapter, err := New(fc, testLogger(t))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, err := adapter.SendMessage(context.Background(), 1, "text", tt.markup); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			if _, err := adapter.EditMessage(context.Background(), 1, 2, "text", tt.markup); err != nil {
+				t.Fatalf("edit: %v", err)
+			}
+			inline, ok := sent.(tgbotapi.InlineKeyboardMarkup)
+			if !ok {
+				t.Fatalf("sent markup %T, want inline keyboard", sent)
+			}
+			if edited == nil {
+				t.Fatalf("edit passed no keyboard")
+			}
+			for _, got := range []tgbotapi.InlineKeyboardMarkup{inline, *edited} {
+				if len(got.InlineKeyboard) != 2 || len(got.InlineKeyboard[0]) != 2 {
+					t.Fatalf("layout %+v, want rows of 2 and 1", got.InlineKeyboard)
+				}
+				button := got.InlineKeyboard[0][1]
+				if button.Text != "No" || button.CallbackData == nil || *button.CallbackData != "a:no" {
+					t.Fatalf("button %+v, want No → a:no", button)
+				}
+			}
+		})
+	}
+}
+
+func TestTelegramMarkup(t *testing.T) {
+	var nilKeyboard *botport.Keyboard
+	inline := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("ok", "data")))
+	tests := []struct {
+		name   string
+		markup interface{}
+		want   interface{}
+	}{
+		{name: "nil", markup: nil, want: nil},
+		{name: "nil keyboard", markup: nilKeyboard, want: nil},
+		{name: "telegram markup passes through", markup: inline, want: inline},
+		{name: "reply", markup: botport.NewReplyKeyboard([]string{"Fill"}, []string{"Send", "Export"}), want: tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("Fill")),
+			tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("Send"), tgbotapi.NewKeyboardButton("Export")),
+		)},
+		{name: "remove", markup: botport.RemoveKeyboard{}, want: tgbotapi.NewRemoveKeyboard(true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := telegramMarkup(tt.markup); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("telegramMarkup = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdapterEditMessageTruncatesLongText(t *testing.T) {
+	var edited string
+	fc := &fakeClient{
+		editFn: func(chatID int64, messageID int, text string, _ *tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error) {
+			edited = text
+			return tgbotapi.Message{MessageID: messageID, Text: text, Chat: &tgbotapi.Chat{ID: chatID}}, nil
+		},
+	}
+	adapter, err := New(fc, testLogger(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	paragraph := strings.Repeat("слово ", 300)
+	long := strings.Join([]string{paragraph, paragraph, paragraph}, "\n\n")
+
+	msg, err := adapter.EditMessage(context.Background(), 7, 5, long, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len([]rune(edited)); n > botport.MaxMessageLength || !strings.HasSuffix(edited, "…") || !strings.HasPrefix(long, strings.TrimSuffix(edited, "…")) {
+		t.Fatalf("edited to %d characters ending %q; want the text cut to the limit and marked", n, edited[len(edited)-10:])
+	}
+	if msg.MessageID != 5 {
+		t.Fatalf("returned %+v, want the edited message", msg)
+	}
+}
+
 func TestAdapterEditMessageRejectsInvalidMarkup(t *testing.T) {
 	fc := &fakeClient{
 		sendFn: func(int64, string, interface{}) (tgbotapi.Message, error) {
@@ -96,7 +405,7 @@ func TestAdapterEditMessageRejectsInvalidMarkup(t *testing.T) {
 			return tgbotapi.Message{}, nil
 		},
 	}
-	adapter, err := New(fc, testLogger{t})
+	adapter, err := New(fc, testLogger(t))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -114,14 +423,160 @@ func TestAdapterEditMessageRejectsInvalidMarkup(t *testing.T) {
 	}
 }
 
+func TestAdapterSplitsLongMessages(t *testing.T) {
+	paragraph := strings.Repeat("слово ", 300) // 1800 characters
+	long := strings.Join([]string{paragraph, paragraph, paragraph}, "\n\n")
+	rateLimited := errors.New("Too Many Requests: retry after 1")
+
+	tests := []struct {
+		name      string
+		text      string
+		failPart  int   // 1-based part whose first attempt fails; 0 for none
+		failWith  error // Error of that attempt
+		wantTexts int   // Messages that reach the chat
+		wantCode  string
+	}{
+		{name: "short text is one message", text: "hello", wantTexts: 1},
+		{name: "long text is split on paragraphs", text: long, wantTexts: 2},
+		{name: "rate-limited part is retried alone", text: long, failPart: 2, failWith: rateLimited, wantTexts: 2},
+		{name: "failed part stops the rest", text: long, failPart: 2, failWith: errors.New("Bad Request: chat not found"), wantTexts: 1, wantCode: "bad_request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var texts []string
+			var markups []interface{}
+			failed := false
+			fc := &fakeClient{
+				sendFn: func(chatID int64, text string, markup interface{}) (tgbotapi.Message, error) {
+					if !failed && len(texts)+1 == tt.failPart {
+						failed = true
+						return tgbotapi.Message{}, tt.failWith
+					}
+					texts = append(texts, text)
+					markups = append(markups, markup)
+					return tgbotapi.Message{MessageID: 100 + len(texts), Text: text, Chat: &tgbotapi.Chat{ID: chatID}}, nil
+				},
+			}
+			adapter, err := New(fc, testLogger(t))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			adapter.sleep = func(context.Context, time.Duration) error { return nil }
+			keyboard := botport.NewKeyboard(botport.NewRow(botport.NewButton("ok", "data")))
+
+			msg, err := adapter.SendMessage(context.Background(), 7, tt.text, keyboard, botport.ReplyTo(17), botport.Silent())
+			if tt.wantCode != "" {
+				if !botport.IsCode(err, tt.wantCode) || len(texts) != tt.wantTexts {
+					t.Fatalf("err = %v after %d messages, want %s after %d", err, len(texts), tt.wantCode, tt.wantTexts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(texts) != tt.wantTexts {
+				t.Fatalf("sent %d messages, want %d", len(texts), tt.wantTexts)
+			}
+			if got := strings.Join(texts, "\n\n"); got != tt.text {
+				t.Fatalf("parts do not add up to the text")
+			}
+			for i, text := range texts {
+				if n := len([]rune(text)); n > botport.MaxMessageLength {
+					t.Fatalf("part %d has %d characters", i, n)
+				}
+				if last := i == len(texts)-1; (markups[i] != nil) != last {
+					t.Fatalf("part %d markup = %v; only the last part carries it", i, markups[i])
+				}
+			}
+			for i, opts := range fc.sentOpts {
+				if !opts.DisableNotification || (opts.ReplyTo == 17) != (i == 0) {
+					t.Fatalf("attempt %d options = %+v; every part is silent and only the first replies", i, opts)
+				}
+			}
+			wantIDs := []int{101}
+			if len(texts) > 1 {
+				wantIDs = []int{101, 102}
+			}
+			if msg.MessageID != 100+len(texts) || msg.Payload != texts[len(texts)-1] || !reflect.DeepEqual(msg.MessageIDs(), wantIDs) {
+				t.Fatalf("returned %+v, want the last part with the IDs of all", msg)
+			}
+		})
+	}
+}
+
+func TestAdapterSendMessagePassesOptions(t *testing.T) {
+	fc := &fakeClient{}
+	adapter, err := New(fc, testLogger(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := adapter.SendMessage(context.Background(), 1, "plain", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fc.sendOpts != (bot.MessageOptions{}) {
+		t.Fatalf("expected no options, got %+v", fc.sendOpts)
+	}
+	if _, err := adapter.SendMessage(context.Background(), 1, "threaded", nil, botport.ReplyTo(17), botport.Silent(), botport.Protected()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := bot.MessageOptions{ReplyTo: 17, DisableNotification: true, ProtectContent: true}
+	if fc.sendOpts != want {
+		t.Fatalf("options = %+v, want %+v", fc.sendOpts, want)
+	}
+}
+
+func TestAdapterSendMedia(t *testing.T) {
+	var sent []string
+	fc := &fakeClient{
+		mediaFn: func(kind string, chatID int64, fileID string, caption string) (tgbotapi.Message, error) {
+			sent = append(sent, kind+":"+fileID)
+			if fileID == "gone" {
+				return tgbotapi.Message{}, errors.New("Bad Request: wrong file identifier")
+			}
+			return tgbotapi.Message{MessageID: 5, Caption: caption, Chat: &tgbotapi.Chat{ID: chatID}}, nil
+		},
+	}
+	adapter, err := New(fc, testLogger(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg, err := adapter.SendAnimation(context.Background(), 3, "anim-id", "Ура")
+	if err != nil || msg.ChatID != 3 || msg.MessageID != 5 || msg.Payload != "Ура" {
+		t.Fatalf("animation: msg=%+v err=%v", msg, err)
+	}
+	if _, err := adapter.SendSticker(context.Background(), 3, "sticker-id"); err != nil {
+		t.Fatalf("sticker: %v", err)
+	}
+	if _, err := adapter.SendSticker(context.Background(), 3, "gone"); !botport.IsCode(err, "bad_request") {
+		t.Fatalf("expected bad_request, got %v", err)
+	}
+	if _, err := adapter.SendDocument(context.Background(), 3, "records.csv", []byte("a,b"), "Экспорт", botport.Protected()); err != nil {
+		t.Fatalf("document: %v", err)
+	}
+	if !fc.sendOpts.ProtectContent {
+		t.Fatalf("document options not passed: %+v", fc.sendOpts)
+	}
+	if len(sent) != 4 || sent[0] != "animation:anim-id" || sent[1] != "sticker:sticker-id" || sent[3] != "document:records.csv" {
+		t.Fatalf("unexpected client calls: %v", sent)
+	}
+}
+
 type fakeClient struct {
-	sendFn func(chatID int64, text string, markup interface{}) (tgbotapi.Message, error)
-	editFn func(chatID int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error)
-	cbFn   func(callbackID string, text string) error
-	delFn  func(chatID int64, messageID int) error
+	sendFn  func(chatID int64, text string, markup interface{}) (tgbotapi.Message, error)
+	editFn  func(chatID int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error)
+	cbFn    func(callbackID string, text string) error
+	delFn   func(chatID int64, messageID int) error
+	mediaFn func(kind string, chatID int64, fileID string, caption string) (tgbotapi.Message, error)
+
+	sendOpts bot.MessageOptions   // Options of the last SendMessage
+	sentOpts []bot.MessageOptions // Options of every SendMessage, in order
 }
 
-func (f *fakeClient) SendMessage(chatID int64, text string, markup interface{}) (tgbotapi.Message, error) {
+func (f *fakeClient) SendMessage(chatID int64, text string, markup interface{}, opts bot.MessageOptions) (tgbotapi.Message, error) {
+	f.sendOpts = opts
+	f.sentOpts = append(f.sentOpts, opts)
 	if f.sendFn == nil {
 		return tgbotapi.Message{}, nil
 	}
@@ -149,10 +604,38 @@ func (f *fakeClient) DeleteMessage(chatID int64, messageID int) error {
 	return f.delFn(chatID, messageID)
 }
 
-type testLogger struct {
+func (f *fakeClient) SendSticker(chatID int64, fileID string, opts bot.MessageOptions) (tgbotapi.Message, error) {
+	if f.mediaFn == nil {
+		return tgbotapi.Message{}, nil
+	}
+	return f.mediaFn("sticker", chatID, fileID, "")
+}
+
+func (f *fakeClient) SendDocument(chatID int64, name string, data []byte, caption string, opts bot.MessageOptions) (tgbotapi.Message, error) {
+	f.sendOpts = opts
+	if f.mediaFn == nil {
+		return tgbotapi.Message{}, nil
+	}
+	return f.mediaFn("document", chatID, name, caption)
+}
+
+func (f *fakeClient) SendAnimation(chatID int64, fileID string, caption string, opts bot.MessageOptions) (tgbotapi.Message, error) {
+	if f.mediaFn == nil {
+		return tgbotapi.Message{}, nil
+	}
+	return f.mediaFn("animation", chatID, fileID, caption)
+}
+
+// testWriter sends the adapter's log lines to the test log.
+type testWriter struct {
 	t *testing.T
 }
 
-func (l testLogger) Printf(format string, args ...any) {
-	l.t.Logf(format, args...)
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Log(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func testLogger(t *testing.T) *slog.Logger {
+	return slog.New(slog.NewTextHandler(testWriter{t}, &slog.HandlerOptions{Level: slog.LevelDebug}))
 }
@@ -0,0 +1,111 @@
+package telegramadapter
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Listen turns Telegram updates into botport.InboundEvents until ctx is done or updates is closed.
+// Updates the bot does not handle are dropped.
+func Listen(ctx context.Context, updates <-chan tgbotapi.Update) <-chan botport.InboundEvent {
+	events := make(chan botport.InboundEvent)
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				event, ok := InboundEvent(update)
+				if !ok {
+					slog.DebugContext(ctx, "ignoring update", "update_id", update.UpdateID)
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events
+}
+
+// InboundEvent normalizes a message or callback update. It reports false for other update types and
+// for updates without a sender or chat.
+func InboundEvent(update tgbotapi.Update) (botport.InboundEvent, bool) {
+	event := botport.InboundEvent{ID: int64(update.UpdateID)}
+	switch {
+	case update.Message != nil:
+		message := update.Message
+		if message.From == nil || message.Chat == nil {
+			return botport.InboundEvent{}, false
+		}
+		event.Kind = botport.EventMessage
+		event.From = sender(message.From)
+		event.ChatID = message.Chat.ID
+		event.MessageID = message.MessageID
+		if message.ReplyToMessage != nil {
+			event.ReplyTo = message.ReplyToMessage.MessageID
+		}
+		event.Text = message.Text
+		if message.IsCommand() {
+			event.Command = message.Command()
+			event.Args = message.CommandArguments()
+		}
+		if media := messageMedia(message); media != nil {
+			event.Media = media
+			event.Text = message.Caption
+		}
+	case update.CallbackQuery != nil:
+		query := update.CallbackQuery
+		if query.From == nil || query.Message == nil || query.Message.Chat == nil {
+			return botport.InboundEvent{}, false
+		}
+		event.Kind = botport.EventCallback
+		event.From = sender(query.From)
+		event.ChatID = query.Message.Chat.ID
+		event.MessageID = query.Message.MessageID
+		event.Text = query.Message.Text
+		event.CallbackID = query.ID
+		event.Data = query.Data
+	default:
+		return botport.InboundEvent{}, false
+	}
+	return event, true
+}
+
+func sender(user *tgbotapi.User) botport.Sender {
+	return botport.Sender{ID: user.ID, FirstName: user.FirstName, LastName: user.LastName, Username: user.UserName, LanguageCode: user.LanguageCode}
+}
+
+// messageMedia returns the file attached to message, or nil for a text message. Of a photo it keeps
+// the largest size; an animation is checked before the document Telegram also sends with it.
+func messageMedia(message *tgbotapi.Message) *botport.Media {
+	switch {
+	case len(message.Photo) > 0:
+		return &botport.Media{Type: "photo", FileID: message.Photo[len(message.Photo)-1].FileID}
+	case message.Voice != nil:
+		return &botport.Media{Type: "voice", FileID: message.Voice.FileID}
+	case message.Audio != nil:
+		return &botport.Media{Type: "audio", FileID: message.Audio.FileID}
+	case message.Video != nil:
+		return &botport.Media{Type: "video", FileID: message.Video.FileID}
+	case message.Sticker != nil:
+		return &botport.Media{Type: "sticker", FileID: message.Sticker.FileID}
+	case message.Animation != nil:
+		return &botport.Media{Type: "animation", FileID: message.Animation.FileID}
+	case message.Document != nil:
+		return &botport.Media{Type: "document", FileID: message.Document.FileID}
+	default:
+		return nil
+	}
+}
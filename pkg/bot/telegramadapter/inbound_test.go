@@ -0,0 +1,103 @@
+package telegramadapter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestInboundEvent(t *testing.T) {
+	user := &tgbotapi.User{ID: 7, FirstName: "Anna", LastName: "K", UserName: "anna", LanguageCode: "en"}
+	sender := botport.Sender{ID: 7, FirstName: "Anna", LastName: "K", Username: "anna", LanguageCode: "en"}
+	chat := &tgbotapi.Chat{ID: 70}
+
+	tests := []struct {
+		name   string
+		update tgbotapi.Update
+		want   botport.InboundEvent
+		wantOK bool
+	}{
+		{
+			name:   "text",
+			update: tgbotapi.Update{UpdateID: 1, Message: &tgbotapi.Message{MessageID: 3, From: user, Chat: chat, Text: "hello"}},
+			want:   botport.InboundEvent{ID: 1, Kind: botport.EventMessage, From: sender, ChatID: 70, MessageID: 3, Text: "hello"},
+			wantOK: true,
+		},
+		{
+			name:   "reply",
+			update: tgbotapi.Update{UpdateID: 9, Message: &tgbotapi.Message{MessageID: 12, From: user, Chat: chat, Text: "see you", ReplyToMessage: &tgbotapi.Message{MessageID: 11}}},
+			want:   botport.InboundEvent{ID: 9, Kind: botport.EventMessage, From: sender, ChatID: 70, MessageID: 12, ReplyTo: 11, Text: "see you"},
+			wantOK: true,
+		},
+		{
+			name: "command addressed to the bot",
+			update: tgbotapi.Update{UpdateID: 2, Message: &tgbotapi.Message{MessageID: 4, From: user, Chat: chat, Text: "/start@survey_bot section_day",
+				Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 17}}}},
+			want:   botport.InboundEvent{ID: 2, Kind: botport.EventMessage, From: sender, ChatID: 70, MessageID: 4, Text: "/start@survey_bot section_day", Command: "start", Args: "section_day"},
+			wantOK: true,
+		},
+		{
+			name: "photo with a caption",
+			update: tgbotapi.Update{UpdateID: 3, Message: &tgbotapi.Message{MessageID: 5, From: user, Chat: chat, Caption: "my day",
+				Photo: []tgbotapi.PhotoSize{{FileID: "small"}, {FileID: "large"}}}},
+			want:   botport.InboundEvent{ID: 3, Kind: botport.EventMessage, From: sender, ChatID: 70, MessageID: 5, Text: "my day", Media: &botport.Media{Type: "photo", FileID: "large"}},
+			wantOK: true,
+		},
+		{
+			name: "animation",
+			update: tgbotapi.Update{UpdateID: 4, Message: &tgbotapi.Message{MessageID: 6, From: user, Chat: chat,
+				Animation: &tgbotapi.Animation{FileID: "gif"}, Document: &tgbotapi.Document{FileID: "gif"}}},
+			want:   botport.InboundEvent{ID: 4, Kind: botport.EventMessage, From: sender, ChatID: 70, MessageID: 6, Media: &botport.Media{Type: "animation", FileID: "gif"}},
+			wantOK: true,
+		},
+		{
+			name: "callback",
+			update: tgbotapi.Update{UpdateID: 5, CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: user, Data: "action:save",
+				Message: &tgbotapi.Message{MessageID: 8, Chat: chat, Text: "Sections"}}},
+			want:   botport.InboundEvent{ID: 5, Kind: botport.EventCallback, From: sender, ChatID: 70, MessageID: 8, Text: "Sections", CallbackID: "cb", Data: "action:save"},
+			wantOK: true,
+		},
+		{name: "message without sender", update: tgbotapi.Update{UpdateID: 6, Message: &tgbotapi.Message{Chat: chat, Text: "hi"}}},
+		{name: "callback without message", update: tgbotapi.Update{UpdateID: 7, CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: user}}},
+		{name: "edited message", update: tgbotapi.Update{UpdateID: 8, EditedMessage: &tgbotapi.Message{From: user, Chat: chat, Text: "hi"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := InboundEvent(tt.update)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %t, want %t", ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("event %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListenDropsUnhandledUpdates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := make(chan tgbotapi.Update, 2)
+	updates <- tgbotapi.Update{UpdateID: 1, EditedMessage: &tgbotapi.Message{Text: "ignored"}}
+	updates <- tgbotapi.Update{UpdateID: 2, Message: &tgbotapi.Message{From: &tgbotapi.User{ID: 7}, Chat: &tgbotapi.Chat{ID: 7}, Text: "hi"}}
+
+	events := Listen(ctx, updates)
+	select {
+	case event := <-events:
+		if event.ID != 2 || event.Text != "hi" {
+			t.Fatalf("event %+v, want update 2", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+
+	close(updates)
+	if _, ok := <-events; ok {
+		t.Fatal("events stay open after the updates are closed")
+	}
+}
@@ -0,0 +1,126 @@
+package telegramadapter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+// Defaults of the retry of rate-limited sends.
+const (
+	DefaultMaxRetries = 3
+	DefaultMaxWait    = 30 * time.Second
+	// fallbackRetryAfter is waited when Telegram rate-limits without saying for how long.
+	fallbackRetryAfter = time.Second
+)
+
+// RetryPolicy bounds how rate-limited sends and edits are retried. A call is retried at most
+// MaxRetries times, and only while Telegram asks to wait no longer than MaxWait; otherwise the
+// rate_limited error is returned to the caller.
+type RetryPolicy struct {
+	MaxRetries int
+	MaxWait    time.Duration
+}
+
+// SetRetryPolicy replaces the retry policy; a zero MaxRetries turns retries off.
+func (a *Adapter) SetRetryPolicy(policy RetryPolicy) {
+	a.retry = policy
+}
+
+// chatQueue lets one send or edit per chat reach Telegram at a time, in the order the calls arrived, so
+// a call waiting out a rate limit keeps the later messages of that chat behind it.
+type chatQueue struct {
+	mu    sync.Mutex
+	lanes map[int64]*lane
+}
+
+type lane struct {
+	slot  chan struct{} // Holds a token while a call of the chat is in flight
+	users int           // Calls holding or waiting for the slot
+}
+
+// acquire waits for chatID's turn; release must be called when the call is done.
+func (q *chatQueue) acquire(ctx context.Context, chatID int64) (release func(), err error) {
+	q.mu.Lock()
+	if q.lanes == nil {
+		q.lanes = make(map[int64]*lane)
+	}
+	l := q.lanes[chatID]
+	if l == nil {
+		l = &lane{slot: make(chan struct{}, 1)}
+		q.lanes[chatID] = l
+	}
+	l.users++
+	q.mu.Unlock()
+
+	done := func() {
+		q.mu.Lock()
+		l.users--
+		if l.users == 0 {
+			delete(q.lanes, chatID)
+		}
+		q.mu.Unlock()
+	}
+	select {
+	case l.slot <- struct{}{}:
+	case <-ctx.Done():
+		done()
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-l.slot
+		done()
+	}, nil
+}
+
+// sendQueued runs call in chatID's queue and retries it after Telegram's RetryAfter while it is
+// rate-limited, within the adapter's RetryPolicy. call returns the wrapped error of the client.
+func (a *Adapter) sendQueued(ctx context.Context, op string, chatID int64, call func() error) error {
+	release, err := a.queue.acquire(ctx, chatID)
+	if err != nil {
+		return wrapContextError(op, err)
+	}
+	defer release()
+	return a.retryRateLimited(ctx, op, chatID, call, nil)
+}
+
+// retryRateLimited runs call, retrying it like sendQueued, for a caller already holding chatID's turn.
+// onRetry, when set, is told of every rate limit waited out.
+func (a *Adapter) retryRateLimited(ctx context.Context, op string, chatID int64, call func() error, onRetry func(error)) error {
+	for attempt := 1; ; attempt++ {
+		err := call()
+		var be *botport.BotError
+		if err == nil || !errors.As(err, &be) || be.Code != "rate_limited" || attempt > a.retry.MaxRetries {
+			return err
+		}
+		wait := be.RetryAfter
+		if wait <= 0 {
+			wait = fallbackRetryAfter
+		}
+		if wait > a.retry.MaxWait {
+			return err
+		}
+		a.logger.WarnContext(ctx, "rate limited, retrying", "op", op, "chat_id", chatID, "retry", attempt, "retry_after", wait)
+		if onRetry != nil {
+			onRetry(err)
+		}
+		if err := a.sleep(ctx, wait); err != nil {
+			return wrapContextError(op, err)
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,32 @@
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Build metadata injected via ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/dkalashnik/telegram-survey-bot/pkg/buildinfo.Version=v1.2.3 \
+//	  -X github.com/dkalashnik/telegram-survey-bot/pkg/buildinfo.Commit=abc123 \
+//	  -X github.com/dkalashnik/telegram-survey-bot/pkg/buildinfo.BuildTime=2024-05-01T10:00:00Z"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// String renders a single-line summary suitable for logs and chat replies.
+func String() string {
+	return fmt.Sprintf("version=%s commit=%s built=%s go=%s", Version, Commit, BuildTime, runtime.Version())
+}
+
+// Labels returns the metadata as metric labels for the build_info gauge.
+func Labels() map[string]string {
+	return map[string]string{
+		"version":    Version,
+		"commit":     Commit,
+		"build_time": BuildTime,
+		"goversion":  runtime.Version(),
+	}
+}
@@ -1,22 +1,360 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"text/template"
+	"time"
 )
 
 type RecordConfig struct {
+	// Title names the survey in the /surveys list.
+	Title string `yaml:"title,omitempty"`
+	// Template is the ID of a survey loaded by LoadTemplates; empty for the main config.
+	Template string                   `yaml:"-"`
 	Sections map[string]SectionConfig `yaml:"sections"`
-	Metadata map[string]string        `yaml:"metadata,omitempty"`
+	// QuestionBank defines questions once, by bank ID, for sections to include with `ref`.
+	QuestionBank map[string]QuestionConfig `yaml:"question_bank,omitempty"`
+	Metadata     map[string]string         `yaml:"metadata,omitempty"`
+	Reminders    ReminderConfig            `yaml:"reminders,omitempty"`
+	// ContentFilter screens free-text answers for personal data and banned words.
+	ContentFilter ContentFilterConfig `yaml:"content_filter,omitempty"`
+	// DailyRecord makes a draft belong to the day it was started: the next day it is saved automatically
+	// and a blank record begins, so timed sections of one day land in one record.
+	DailyRecord bool              `yaml:"daily_record,omitempty"`
+	Limits      LimitsConfig      `yaml:"limits,omitempty"`
+	AutoForward AutoForwardConfig `yaml:"auto_forward,omitempty"`
+	Completion  CompletionConfig  `yaml:"completion,omitempty"`
+	// Deadline ("HH:MM") is when a record is due on the day its draft was started. Records saved later
+	// are still accepted but marked late.
+	Deadline string `yaml:"deadline,omitempty"`
+	// WeeklyReport sends a PDF summary of the past week on a fixed weekday.
+	WeeklyReport WeeklyReportConfig `yaml:"weekly_report,omitempty"`
+	// QuietHours sends low-priority messages (reminders, nudges, auto-forward summaries) without a
+	// notification sound while the local time is inside the window.
+	QuietHours *TimeWindow   `yaml:"quiet_hours,omitempty"`
+	Cleanup    CleanupConfig `yaml:"cleanup,omitempty"`
+	// SectionPageSize is how many section buttons one page of the section menu shows; 0 uses
+	// DefaultSectionPageSize.
+	SectionPageSize int `yaml:"section_page_size,omitempty"`
+}
+
+// SectionIDs lists the section IDs in display order; see SectionConfig.Order.
+func (rc *RecordConfig) SectionIDs() []string {
+	ids := make([]string, 0, len(rc.Sections))
+	for id := range rc.Sections {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := rc.Sections[ids[i]].Order, rc.Sections[ids[j]].Order
+		switch {
+		case a == b:
+			return ids[i] < ids[j]
+		case a == 0 || b == 0:
+			return b == 0
+		default:
+			return a < b
+		}
+	})
+	return ids
+}
+
+// DefaultSectionPageSize is the section menu page size when section_page_size is unset.
+const DefaultSectionPageSize = 8
+
+// SectionsPerPage is the configured section menu page size, or DefaultSectionPageSize.
+func (rc *RecordConfig) SectionsPerPage() int {
+	if rc.SectionPageSize <= 0 {
+		return DefaultSectionPageSize
+	}
+	return rc.SectionPageSize
+}
+
+// DefaultCleanupDelay is how long transient messages stay in the chat when cleanup.delay is unset.
+const DefaultCleanupDelay = 30 * time.Second
+
+// CleanupConfig deletes the bot's transient messages (answer feedback, warnings, prompts replaced by a
+// newer one) Delay after they were sent, so the chat history keeps only menus and record summaries.
+type CleanupConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Delay   string `yaml:"delay,omitempty"` // Go duration, e.g. "30s"
+}
+
+// DelayDuration is the configured delay, or DefaultCleanupDelay when it is unset or invalid.
+func (c CleanupConfig) DelayDuration() time.Duration {
+	if c.Delay == "" {
+		return DefaultCleanupDelay
+	}
+	delay, err := time.ParseDuration(c.Delay)
+	if err != nil || delay < 0 {
+		return DefaultCleanupDelay
+	}
+	return delay
+}
+
+// CompletionConfig customizes the message shown after a record is saved. Message is a text/template
+// rendered with the save stats (.Streak, .Total, .Answered, .Scores, .AverageScore); Celebration is an
+// emoji and CelebrationMedia a sticker or animation, each sent as its own message, on every save or,
+// with CelebrateStreak, when the streak of days reaches a multiple of it.
+type CompletionConfig struct {
+	Message          string      `yaml:"message,omitempty"`
+	Celebration      string      `yaml:"celebration,omitempty"`
+	CelebrationMedia MediaConfig `yaml:"celebration_media,omitempty"`
+	CelebrateStreak  int         `yaml:"celebrate_streak,omitempty"`
+}
+
+// MediaConfig references a Telegram sticker or animation by file_id; Sticker wins when both are set.
+type MediaConfig struct {
+	Sticker   string `yaml:"sticker,omitempty"`
+	Animation string `yaml:"animation,omitempty"`
+}
+
+// IsZero reports whether no media is configured.
+func (m MediaConfig) IsZero() bool {
+	return m.Sticker == "" && m.Animation == ""
+}
+
+// AutoForwardConfig forwards saved records to TARGET_USER_ID every day at Time. Only records saved
+// before Cutoff (defaults to Time) on that day or earlier are sent; each record is sent once.
+type AutoForwardConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Time    string `yaml:"time"`             // Local time of day, "HH:MM"
+	Cutoff  string `yaml:"cutoff,omitempty"` // Local time of day, "HH:MM", not after Time
+}
+
+// Recipients of the weekly report.
+const (
+	ReportToUser      = "user"
+	ReportToTherapist = "therapist"
+)
+
+// Formats of the weekly report.
+const (
+	ReportFormatPDF  = "pdf"
+	ReportFormatText = "text"
+)
+
+// WeeklyReportConfig sends every user with records in the past seven days a PDF of them, with score
+// trends and summary statistics, on Weekday at Time. SendTo lists the recipients, "user" and/or
+// "therapist" (TARGET_USER_ID); it defaults to the user. Format "text" sends the short summary of
+// /report as a message instead of the PDF.
+type WeeklyReportConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Weekday string   `yaml:"weekday"`           // English day name, e.g. "monday"
+	Time    string   `yaml:"time"`              // Local time of day, "HH:MM"
+	SendTo  []string `yaml:"send_to,omitempty"` // "user", "therapist"
+	Format  string   `yaml:"format,omitempty"`  // "pdf" (default) or "text"
+}
+
+// TextOnly reports whether the weekly report is sent as a text summary instead of a PDF.
+func (w WeeklyReportConfig) TextOnly() bool {
+	return w.Format == ReportFormatText
+}
+
+// Day returns the configured weekday.
+func (w WeeklyReportConfig) Day() (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(w.Weekday, day.String()) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// Recipients returns SendTo, or the user alone when it is empty.
+func (w WeeklyReportConfig) Recipients() []string {
+	if len(w.SendTo) == 0 {
+		return []string{ReportToUser}
+	}
+	return w.SendTo
+}
+
+// Default size limits, in runes, used when LimitsConfig leaves them unset.
+const (
+	DefaultMaxAnswerLength = 2000
+	DefaultMaxRecordSize   = 20000
+)
+
+// LimitsConfig caps free-text answers and the whole record so forwards and exports stay bounded.
+type LimitsConfig struct {
+	MaxAnswerLength int `yaml:"max_answer_length,omitempty"`
+	MaxRecordSize   int `yaml:"max_record_size,omitempty"`
+}
+
+// AnswerLimit is the maximum answer length for question: its own max_length, else the global one.
+func (l LimitsConfig) AnswerLimit(question QuestionConfig) int {
+	if question.MaxLength > 0 {
+		return question.MaxLength
+	}
+	if l.MaxAnswerLength > 0 {
+		return l.MaxAnswerLength
+	}
+	return DefaultMaxAnswerLength
+}
+
+// RecordLimit is the maximum total size of a record.
+func (l LimitsConfig) RecordLimit() int {
+	if l.MaxRecordSize > 0 {
+		return l.MaxRecordSize
+	}
+	return DefaultMaxRecordSize
+}
+
+// Content filter actions.
+const (
+	FilterActionBlock = "block" // Reject the answer and ask again
+	FilterActionMask  = "mask"  // Store the answer with matches replaced by FilterMask
+	FilterActionFlag  = "flag"  // Store the answer as is and mark it for the therapist
+)
+
+// FilterMask replaces masked matches.
+const FilterMask = "***"
+
+// ContentFilterConfig selects what the content filter looks for and what it does with a match.
+type ContentFilterConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Action      string   `yaml:"action"` // block, mask or flag
+	Phones      bool     `yaml:"phones,omitempty"`
+	Emails      bool     `yaml:"emails,omitempty"`
+	BannedWords []string `yaml:"banned_words,omitempty"` // Matched case-insensitively as whole words
+}
+
+// ReminderConfig describes the daily reminder message and its one-tap start buttons.
+type ReminderConfig struct {
+	Enabled bool             `yaml:"enabled"`
+	Time    string           `yaml:"time"` // Local time of day, "HH:MM"
+	Text    string           `yaml:"text"`
+	Buttons []ReminderButton `yaml:"buttons,omitempty"`
+	Nudges  NudgeConfig      `yaml:"nudges,omitempty"`
+	Media   MediaConfig      `yaml:"media,omitempty"` // Sent before the reminder text
+}
+
+// NudgeConfig escalates follow-ups for users who have not saved a record for AfterDays days.
+// Messages[i] is sent on the i-th missed day past the threshold; the last message repeats until MaxNudges is reached.
+type NudgeConfig struct {
+	AfterDays    int      `yaml:"after_days"`
+	MaxNudges    int      `yaml:"max_nudges"`
+	Messages     []string `yaml:"messages"`
+	NotifyTarget bool     `yaml:"notify_target,omitempty"` // Alert TARGET_USER_ID when the last nudge goes out
+}
+
+// ReminderButton opens Section directly; an empty Section starts/resumes the record at the section menu.
+type ReminderButton struct {
+	Text    string `yaml:"text"`
+	Section string `yaml:"section,omitempty"`
+}
+
+// Telegram profile fields a question can be prefilled from.
+const (
+	PrefillFirstName = "first_name"
+	PrefillLastName  = "last_name"
+	PrefillUsername  = "username"
+	PrefillLanguage  = "language"
+)
+
+// ReminderTimeLayout is the accepted format of ReminderConfig.Time.
+const ReminderTimeLayout = "15:04"
+
+// CallbackDelimiter separates the parts of inline callback data ("answer:<questionID>:<value>"),
+// so question IDs and option values must not contain it.
+const CallbackDelimiter = ":"
+
+const (
+	// MaxCallbackDataBytes is Telegram's limit for inline button callback_data.
+	MaxCallbackDataBytes = 64
+	// maxCallbackPrefixBytes reserves room for the longest FSM prefix carrying a bare ID ("section:").
+	maxCallbackPrefixBytes = len("section:")
+)
+
+// ValidateCallbackData reports an error when data does not fit into Telegram's callback_data limit.
+func ValidateCallbackData(data string) error {
+	if len(data) > MaxCallbackDataBytes {
+		return fmt.Errorf("callback data '%s' is %d bytes, exceeds %d", data, len(data), MaxCallbackDataBytes)
+	}
+	return nil
 }
 
 type SectionConfig struct {
-	Title     string           `yaml:"title"`
+	Title string `yaml:"title"`
+	// Order places the section in menus, forwarded records, exports and reports: sections with an order
+	// come first, lowest first, then the rest by ID. Ties are broken by ID.
+	Order     int              `yaml:"order,omitempty"`
 	Questions []QuestionConfig `yaml:"questions"`
+	// Available limits the section to a time of day, for surveys split into morning/evening parts.
+	Available *TimeWindow `yaml:"available,omitempty"`
+	// Deadline ("HH:MM") is when the section is due on the day the draft was started; a section
+	// completed later is marked late.
+	Deadline string `yaml:"deadline,omitempty"`
+	// Quiz pauses after every buttons answer to show whether it was correct and the option's feedback;
+	// the user moves on with "Далее" and sees the score at the end of the section.
+	Quiz bool `yaml:"quiz,omitempty"`
+	// Sample asks only this many of the section's questions, drawn at random once per record, so a
+	// section can rotate through a pool of prompts. Zero asks them all.
+	Sample int `yaml:"sample,omitempty"`
+}
+
+// DeadlinePassed reports whether at is after the deadline "HH:MM" on the day of started, in started's
+// location. An empty or malformed deadline never passes.
+func DeadlinePassed(deadline string, started, at time.Time) bool {
+	due, err := time.Parse(ReminderTimeLayout, deadline)
+	if deadline == "" || err != nil {
+		return false
+	}
+	dueAt := time.Date(started.Year(), started.Month(), started.Day(), due.Hour(), due.Minute(), 0, 0, started.Location())
+	return at.After(dueAt)
+}
+
+// TimeWindow is a daily local-time range "HH:MM"–"HH:MM"; To before From wraps past midnight.
+type TimeWindow struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// validate checks the window's format; name prefixes the error ("quiet_hours").
+func (w TimeWindow) validate(name string) error {
+	if _, err := time.Parse(ReminderTimeLayout, w.From); err != nil {
+		return fmt.Errorf("config validation failed: %s.from '%s' must use HH:MM format", name, w.From)
+	}
+	if _, err := time.Parse(ReminderTimeLayout, w.To); err != nil {
+		return fmt.Errorf("config validation failed: %s.to '%s' must use HH:MM format", name, w.To)
+	}
+	if w.From == w.To {
+		return fmt.Errorf("config validation failed: %s window is empty", name)
+	}
+	return nil
+}
+
+// Contains reports whether the time of day of t falls in [From, To). A malformed window contains nothing.
+func (w TimeWindow) Contains(t time.Time) bool {
+	from, errFrom := time.Parse(ReminderTimeLayout, w.From)
+	to, errTo := time.Parse(ReminderTimeLayout, w.To)
+	if errFrom != nil || errTo != nil {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	start := from.Hour()*60 + from.Minute()
+	end := to.Hour()*60 + to.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// OpenAt reports whether the section can be answered at t.
+func (s SectionConfig) OpenAt(t time.Time) bool {
+	return s.Available == nil || s.Available.Contains(t)
 }
 
 type QuestionConfig struct {
+	// Ref includes the question_bank entry of that ID. Only ID, StoreKey and ShowIf may be set next to
+	// it; they override the entry's, so the same entry can appear in several sections.
+	Ref string `yaml:"ref,omitempty"`
+
 	ID     string `yaml:"id"`
 	Prompt string `yaml:"prompt"`
 
@@ -24,16 +362,91 @@ type QuestionConfig struct {
 	StoreKey string         `yaml:"store_key"`
 	Options  []ButtonOption `yaml:"options,omitempty"`
 
-	// Text-rating specific configuration
+	// MaxLength overrides limits.max_answer_length for this question's free-text answers.
+	MaxLength int `yaml:"max_length,omitempty"`
+
+	// Validation rejects text answers outside its bounds with an explanation instead of storing them.
+	Validation *ValidationConfig `yaml:"validation,omitempty"`
+
+	// Prefill suggests a Telegram profile field (see PrefillFirstName...) as the answer on the user's first record.
+	Prefill string `yaml:"prefill,omitempty"`
+
+	// PostProcess normalizes the answer before it is stored, in order.
+	PostProcess []PostProcessorConfig `yaml:"post_process,omitempty"`
+
+	// ShowIf asks the question only when an earlier answer matches; otherwise the flow skips it.
+	ShowIf *ShowIfConfig `yaml:"show_if,omitempty"`
+
+	// Optional adds a "Skip" button that moves on without storing an answer.
+	Optional bool `yaml:"optional,omitempty"`
+
+	// Rating configuration (text_rating, rating)
 	RatingMin         int    `yaml:"rating_min,omitempty"`          // Min rating value (default: 1)
-	RatingMax         int    `yaml:"rating_max,omitempty"`          // Max rating value (default: 10)
-	NextButtonLabel   string `yaml:"next_button_label,omitempty"`   // Label for "next" button (default: "➡️ Следующий")
-	FinishButtonLabel string `yaml:"finish_button_label,omitempty"` // Label for "finish" button (default: "✅ Завершить")
+	RatingMax         int    `yaml:"rating_max,omitempty"`          // Max rating value (default: 10; rating: the last label, or 5)
+	NextButtonLabel   string `yaml:"next_button_label,omitempty"`   // Label for "next" button (default: message button_rating_next)
+	FinishButtonLabel string `yaml:"finish_button_label,omitempty"` // Label for "finish" button (default: message button_rating_finish); multi_buttons uses it for "done" (default: button_multi_done)
+
+	// Repeatable questions (text_rating) collect a list of entries. MinEntries hides "finish" until
+	// that many are added; MaxEntries hides "next" on the last one. Zero means no limit.
+	MinEntries int `yaml:"min_entries,omitempty"`
+	MaxEntries int `yaml:"max_entries,omitempty"`
+
+	// Labels are the buttons of a rating scale, one per point from RatingMin up (e.g. 😞 … 😄);
+	// without them the buttons show the numbers.
+	Labels []string `yaml:"labels,omitempty"`
+}
+
+// RatingScale is the scale of a "rating" question: from RatingMin (default 1) to RatingMax, which
+// defaults to the last label, or to 5 without labels.
+func (q QuestionConfig) RatingScale() (int, int) {
+	minRating := q.RatingMin
+	if minRating == 0 {
+		minRating = 1
+	}
+	switch {
+	case q.RatingMax != 0:
+		return minRating, q.RatingMax
+	case len(q.Labels) > 0:
+		return minRating, minRating + len(q.Labels) - 1
+	default:
+		return minRating, 5
+	}
+}
+
+// ShowIfConfig makes a question depend on the answer stored under StoreKey: the question is shown when
+// that answer is one of Values (a list answer when it contains one) or, without Values, when it is
+// answered at all. StoreKey belongs to an earlier question of the section or to another section.
+type ShowIfConfig struct {
+	StoreKey string   `yaml:"store_key"`
+	Values   []string `yaml:"values,omitempty"`
+}
+
+// ValidationConfig bounds the length of a text answer in characters; zero means no bound. The prompt
+// shows the bounds and a rejected answer is told how many characters it is off by.
+type ValidationConfig struct {
+	MinLength int `yaml:"min_length,omitempty"`
+	MaxLength int `yaml:"max_length,omitempty"`
+}
+
+// LengthLimited reports whether v bounds the answer length.
+func (v *ValidationConfig) LengthLimited() bool {
+	return v != nil && (v.MinLength > 0 || v.MaxLength > 0)
+}
+
+// PostProcessorConfig selects an answer post-processor by Name ("trim", "lowercase", "strip_phone",
+// "round", "synonyms") with its parameters.
+type PostProcessorConfig struct {
+	Name      string            `yaml:"name"`
+	Precision int               `yaml:"precision,omitempty"` // round: digits after the decimal point
+	Synonyms  map[string]string `yaml:"synonyms,omitempty"`  // synonyms: variant -> canonical value, case-insensitive
 }
 
 type ButtonOption struct {
 	Text  string `yaml:"text"`
 	Value string `yaml:"value"`
+	// Correct and Feedback are shown right after the option is picked in a quiz section.
+	Correct  bool   `yaml:"correct,omitempty"`
+	Feedback string `yaml:"feedback,omitempty"`
 }
 
 func (rc *RecordConfig) Validate() error {
@@ -43,13 +456,30 @@ func (rc *RecordConfig) Validate() error {
 	if len(rc.Sections) == 0 {
 		return fmt.Errorf("config validation failed: no sections defined")
 	}
+	if err := rc.resolveQuestionBank(); err != nil {
+		return err
+	}
 
 	uniqueStoreKeys := make(map[string]bool)
+	questionSections := make(map[string]string)
+	// Questions that do not fit their type are reported together, so one load lists every one to fix.
+	var typeErrs []error
 
 	for sectionID, section := range rc.Sections {
 		if section.Title == "" {
 			return fmt.Errorf("config validation failed: section '%s' has no title", sectionID)
 		}
+		if section.Order < 0 {
+			return fmt.Errorf("config validation failed: section '%s' has negative order", sectionID)
+		}
+		if len(sectionID)+maxCallbackPrefixBytes > MaxCallbackDataBytes {
+			return fmt.Errorf("config validation failed: section id '%s' is too long for callback data (max %d bytes)", sectionID, MaxCallbackDataBytes-maxCallbackPrefixBytes)
+		}
+		if window := section.Available; window != nil {
+			if err := window.validate(fmt.Sprintf("section '%s' available", sectionID)); err != nil {
+				return err
+			}
+		}
 		if len(section.Questions) == 0 {
 
 			continue
@@ -59,6 +489,22 @@ func (rc *RecordConfig) Validate() error {
 			if question.ID == "" {
 				return fmt.Errorf("config validation failed: question #%d in section '%s' has no id", i+1, sectionID)
 			}
+			if strings.Contains(question.ID, CallbackDelimiter) {
+				return fmt.Errorf("config validation failed: question id '%s' in section '%s' must not contain '%s'", question.ID, sectionID, CallbackDelimiter)
+			}
+			if len(question.ID)+maxCallbackPrefixBytes > MaxCallbackDataBytes {
+				return fmt.Errorf("config validation failed: question id '%s' in section '%s' is too long for callback data (max %d bytes)", question.ID, sectionID, MaxCallbackDataBytes-maxCallbackPrefixBytes)
+			}
+			// Answer callbacks carry only the question ID, so it must be unique across all sections.
+			if otherSection, exists := questionSections[question.ID]; exists {
+				return fmt.Errorf("config validation failed: duplicate question id '%s' (sections '%s' and '%s')", question.ID, otherSection, sectionID)
+			}
+			questionSections[question.ID] = sectionID
+			for j, option := range question.Options {
+				if strings.Contains(option.Value, CallbackDelimiter) {
+					return fmt.Errorf("config validation failed: option #%d value '%s' for question '%s' in section '%s' must not contain '%s'", j+1, option.Value, question.ID, sectionID, CallbackDelimiter)
+				}
+			}
 			if question.Prompt == "" {
 				return fmt.Errorf("config validation failed: question '%s' in section '%s' has no prompt", question.ID, sectionID)
 			}
@@ -72,6 +518,248 @@ func (rc *RecordConfig) Validate() error {
 			uniqueStoreKeys[question.StoreKey] = true
 
 			if err := validateQuestionWithStrategy(sectionID, question); err != nil {
+				typeErrs = append(typeErrs, err)
+			}
+		}
+	}
+	sort.Slice(typeErrs, func(i, j int) bool { return typeErrs[i].Error() < typeErrs[j].Error() })
+	if err := errors.Join(typeErrs...); err != nil {
+		return err
+	}
+	if err := rc.validateShowIf(); err != nil {
+		return err
+	}
+	if err := rc.validateReminders(); err != nil {
+		return err
+	}
+	if rc.QuietHours != nil {
+		if err := rc.QuietHours.validate("quiet_hours"); err != nil {
+			return err
+		}
+	}
+	if err := rc.validateAutoForward(); err != nil {
+		return err
+	}
+	if err := rc.validateDeadlines(); err != nil {
+		return err
+	}
+	if err := rc.validateQuiz(); err != nil {
+		return err
+	}
+	if err := rc.validateSample(); err != nil {
+		return err
+	}
+	if err := rc.validateWeeklyReport(); err != nil {
+		return err
+	}
+	if err := rc.validateCleanup(); err != nil {
+		return err
+	}
+	if err := rc.validateCompletion(); err != nil {
+		return err
+	}
+	if err := rc.validatePrefill(); err != nil {
+		return err
+	}
+	if err := rc.validateLimits(); err != nil {
+		return err
+	}
+	if rc.SectionPageSize < 0 {
+		return fmt.Errorf("config validation failed: section_page_size must not be negative")
+	}
+	return rc.validateContentFilter()
+}
+
+// resolveQuestionBank replaces every question with a ref by a copy of its question_bank entry, with the
+// overrides set next to the ref. The bank ID is the default question ID. Bank entries are checked as
+// part of the sections that include them.
+func (rc *RecordConfig) resolveQuestionBank() error {
+	for bankID, question := range rc.QuestionBank {
+		if question.Ref != "" {
+			return fmt.Errorf("config validation failed: question bank entry '%s' must not have a ref", bankID)
+		}
+	}
+	for sectionID, section := range rc.Sections {
+		for i, question := range section.Questions {
+			if question.Ref == "" {
+				continue
+			}
+			banked, ok := rc.QuestionBank[question.Ref]
+			if !ok {
+				return fmt.Errorf("config validation failed: question #%d in section '%s' refers to unknown question bank entry '%s'", i+1, sectionID, question.Ref)
+			}
+			rest := question
+			rest.Ref, rest.ID, rest.StoreKey, rest.ShowIf = "", "", "", nil
+			if !reflect.DeepEqual(rest, QuestionConfig{}) {
+				return fmt.Errorf("config validation failed: question #%d in section '%s' may only set id, store_key and show_if next to ref '%s'", i+1, sectionID, question.Ref)
+			}
+
+			resolved := banked
+			if resolved.ID == "" {
+				resolved.ID = question.Ref
+			}
+			if question.ID != "" {
+				resolved.ID = question.ID
+			}
+			if question.StoreKey != "" {
+				resolved.StoreKey = question.StoreKey
+			}
+			if question.ShowIf != nil {
+				resolved.ShowIf = question.ShowIf
+			}
+			section.Questions[i] = resolved
+		}
+	}
+	return nil
+}
+
+func (rc *RecordConfig) validateAutoForward() error {
+	autoForward := rc.AutoForward
+	if !autoForward.Enabled {
+		return nil
+	}
+	at, err := time.Parse(ReminderTimeLayout, autoForward.Time)
+	if err != nil {
+		return fmt.Errorf("config validation failed: auto_forward.time '%s' must use HH:MM format", autoForward.Time)
+	}
+	if autoForward.Cutoff == "" {
+		return nil
+	}
+	cutoff, err := time.Parse(ReminderTimeLayout, autoForward.Cutoff)
+	if err != nil {
+		return fmt.Errorf("config validation failed: auto_forward.cutoff '%s' must use HH:MM format", autoForward.Cutoff)
+	}
+	if cutoff.After(at) {
+		return fmt.Errorf("config validation failed: auto_forward.cutoff %s is after auto_forward.time %s", autoForward.Cutoff, autoForward.Time)
+	}
+	return nil
+}
+
+func (rc *RecordConfig) validateDeadlines() error {
+	if rc.Deadline != "" {
+		if _, err := time.Parse(ReminderTimeLayout, rc.Deadline); err != nil {
+			return fmt.Errorf("config validation failed: deadline '%s' must use HH:MM format", rc.Deadline)
+		}
+	}
+	for sectionID, section := range rc.Sections {
+		if section.Deadline == "" {
+			continue
+		}
+		if _, err := time.Parse(ReminderTimeLayout, section.Deadline); err != nil {
+			return fmt.Errorf("config validation failed: section '%s' deadline '%s' must use HH:MM format", sectionID, section.Deadline)
+		}
+	}
+	return nil
+}
+
+// validateQuiz rejects quiz settings that would be ignored: option feedback and correct answers only
+// take effect on buttons questions of quiz sections.
+func (rc *RecordConfig) validateQuiz() error {
+	for sectionID, section := range rc.Sections {
+		for _, question := range section.Questions {
+			for j, option := range question.Options {
+				if !option.Correct && option.Feedback == "" {
+					continue
+				}
+				if !section.Quiz {
+					return fmt.Errorf("config validation failed: option #%d of question '%s' sets correct/feedback but section '%s' is not a quiz", j+1, question.ID, sectionID)
+				}
+				if question.Type != "buttons" {
+					return fmt.Errorf("config validation failed: option #%d of question '%s' in section '%s' sets correct/feedback, which only buttons questions support", j+1, question.ID, sectionID)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateSample keeps a section's sample size between zero and its number of questions.
+func (rc *RecordConfig) validateSample() error {
+	for sectionID, section := range rc.Sections {
+		if section.Sample < 0 || section.Sample > len(section.Questions) {
+			return fmt.Errorf("config validation failed: section '%s' sample %d must be between 0 and its %d questions", sectionID, section.Sample, len(section.Questions))
+		}
+	}
+	return nil
+}
+
+func (rc *RecordConfig) validateWeeklyReport() error {
+	weekly := rc.WeeklyReport
+	if !weekly.Enabled {
+		return nil
+	}
+	if _, ok := weekly.Day(); !ok {
+		return fmt.Errorf("config validation failed: weekly_report.weekday '%s' is not a day name like 'monday'", weekly.Weekday)
+	}
+	if _, err := time.Parse(ReminderTimeLayout, weekly.Time); err != nil {
+		return fmt.Errorf("config validation failed: weekly_report.time '%s' must use HH:MM format", weekly.Time)
+	}
+	for _, to := range weekly.SendTo {
+		if to != ReportToUser && to != ReportToTherapist {
+			return fmt.Errorf("config validation failed: weekly_report.send_to '%s' must be '%s' or '%s'", to, ReportToUser, ReportToTherapist)
+		}
+	}
+	if weekly.Format != "" && weekly.Format != ReportFormatPDF && weekly.Format != ReportFormatText {
+		return fmt.Errorf("config validation failed: weekly_report.format '%s' must be '%s' or '%s'", weekly.Format, ReportFormatPDF, ReportFormatText)
+	}
+	return nil
+}
+
+func (rc *RecordConfig) validateCleanup() error {
+	if rc.Cleanup.Delay == "" {
+		return nil
+	}
+	delay, err := time.ParseDuration(rc.Cleanup.Delay)
+	if err != nil {
+		return fmt.Errorf("config validation failed: cleanup.delay '%s' is not a duration: %w", rc.Cleanup.Delay, err)
+	}
+	if delay < 0 {
+		return fmt.Errorf("config validation failed: cleanup.delay must not be negative")
+	}
+	return nil
+}
+
+func (rc *RecordConfig) validateCompletion() error {
+	completion := rc.Completion
+	if _, err := template.New("completion").Parse(completion.Message); err != nil {
+		return fmt.Errorf("config validation failed: completion.message is not a valid template: %w", err)
+	}
+	if completion.CelebrateStreak < 0 {
+		return fmt.Errorf("config validation failed: completion.celebrate_streak must not be negative")
+	}
+	if completion.CelebrateStreak > 0 && completion.Celebration == "" && completion.CelebrationMedia.IsZero() {
+		return fmt.Errorf("config validation failed: completion.celebrate_streak is set but there is no celebration to send")
+	}
+	return nil
+}
+
+func (rc *RecordConfig) validatePrefill() error {
+	for sectionID, section := range rc.Sections {
+		for _, question := range section.Questions {
+			switch question.Prefill {
+			case "", PrefillFirstName, PrefillLastName, PrefillUsername, PrefillLanguage:
+			default:
+				return fmt.Errorf("config validation failed: question '%s' in section '%s' has prefill '%s', must be one of first_name, last_name, username, language", question.ID, sectionID, question.Prefill)
+			}
+		}
+	}
+	return nil
+}
+
+func (rc *RecordConfig) validateLimits() error {
+	limits := rc.Limits
+	if limits.MaxAnswerLength < 0 || limits.MaxRecordSize < 0 {
+		return fmt.Errorf("config validation failed: limits must not be negative")
+	}
+	if limits.AnswerLimit(QuestionConfig{}) > limits.RecordLimit() {
+		return fmt.Errorf("config validation failed: limits.max_answer_length %d exceeds limits.max_record_size %d", limits.AnswerLimit(QuestionConfig{}), limits.RecordLimit())
+	}
+	for sectionID, section := range rc.Sections {
+		for _, question := range section.Questions {
+			if question.MaxLength < 0 {
+				return fmt.Errorf("config validation failed: question '%s' in section '%s' has negative max_length", question.ID, sectionID)
+			}
+			if err := validateLengthBounds(limits, sectionID, question); err != nil {
 				return err
 			}
 		}
@@ -79,6 +767,108 @@ func (rc *RecordConfig) Validate() error {
 	return nil
 }
 
+// validateLengthBounds keeps the validation block consistent and within the answer limit, which would
+// otherwise cut an answer before its validation gets to explain the bound.
+func validateLengthBounds(limits LimitsConfig, sectionID string, question QuestionConfig) error {
+	v := question.Validation
+	if v == nil {
+		return nil
+	}
+	if v.MinLength < 0 || v.MaxLength < 0 {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' has negative validation lengths", question.ID, sectionID)
+	}
+	if v.MaxLength > 0 && v.MinLength > v.MaxLength {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' has validation.min_length %d above validation.max_length %d", question.ID, sectionID, v.MinLength, v.MaxLength)
+	}
+	if limit := limits.AnswerLimit(question); v.MaxLength > limit || v.MinLength > limit {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' has validation lengths above its answer limit %d", question.ID, sectionID, limit)
+	}
+	return nil
+}
+
+func (rc *RecordConfig) validateContentFilter() error {
+	filter := rc.ContentFilter
+	if !filter.Enabled {
+		return nil
+	}
+	switch filter.Action {
+	case FilterActionBlock, FilterActionMask, FilterActionFlag:
+	default:
+		return fmt.Errorf("config validation failed: content_filter.action '%s' must be one of block, mask, flag", filter.Action)
+	}
+	if !filter.Phones && !filter.Emails && len(filter.BannedWords) == 0 {
+		return fmt.Errorf("config validation failed: content_filter is enabled but checks nothing")
+	}
+	for i, word := range filter.BannedWords {
+		if strings.TrimSpace(word) == "" {
+			return fmt.Errorf("config validation failed: content_filter.banned_words #%d is empty", i+1)
+		}
+	}
+	return nil
+}
+
+// validateShowIf checks that every show_if refers to a store key that is answered before the question:
+// one of an earlier question in the same section or of a question in another section.
+func (rc *RecordConfig) validateShowIf() error {
+	keySections := make(map[string]string)
+	for sectionID, section := range rc.Sections {
+		for _, question := range section.Questions {
+			keySections[question.StoreKey] = sectionID
+		}
+	}
+	for sectionID, section := range rc.Sections {
+		earlier := make(map[string]bool)
+		for _, question := range section.Questions {
+			if cond := question.ShowIf; cond != nil {
+				keySection, known := keySections[cond.StoreKey]
+				switch {
+				case cond.StoreKey == "":
+					return fmt.Errorf("config validation failed: show_if of question '%s' in section '%s' has no store_key", question.ID, sectionID)
+				case !known:
+					return fmt.Errorf("config validation failed: show_if of question '%s' in section '%s' references unknown store_key '%s'", question.ID, sectionID, cond.StoreKey)
+				case keySection == sectionID && !earlier[cond.StoreKey]:
+					return fmt.Errorf("config validation failed: show_if of question '%s' in section '%s' must reference an earlier question, not '%s'", question.ID, sectionID, cond.StoreKey)
+				}
+			}
+			earlier[question.StoreKey] = true
+		}
+	}
+	return nil
+}
+
+func (rc *RecordConfig) validateReminders() error {
+	reminders := rc.Reminders
+	if !reminders.Enabled {
+		return nil
+	}
+	if _, err := time.Parse(ReminderTimeLayout, reminders.Time); err != nil {
+		return fmt.Errorf("config validation failed: reminders.time '%s' must use HH:MM format", reminders.Time)
+	}
+	if reminders.Text == "" {
+		return fmt.Errorf("config validation failed: reminders.text is empty")
+	}
+	if nudges := reminders.Nudges; nudges.AfterDays > 0 {
+		if nudges.MaxNudges <= 0 {
+			return fmt.Errorf("config validation failed: reminders.nudges.max_nudges must be positive")
+		}
+		if len(nudges.Messages) == 0 {
+			return fmt.Errorf("config validation failed: reminders.nudges.messages is empty")
+		}
+	}
+	for i, button := range reminders.Buttons {
+		if button.Text == "" {
+			return fmt.Errorf("config validation failed: reminder button #%d has no text", i+1)
+		}
+		if button.Section == "" {
+			continue
+		}
+		if _, ok := rc.Sections[button.Section]; !ok {
+			return fmt.Errorf("config validation failed: reminder button #%d references unknown section '%s'", i+1, button.Section)
+		}
+	}
+	return nil
+}
+
 type QuestionValidator func(sectionID string, question QuestionConfig) error
 
 var (
@@ -98,7 +888,7 @@ func validateQuestionWithStrategy(sectionID string, question QuestionConfig) err
 		switch question.Type {
 		case "text":
 			if len(question.Options) > 0 {
-				log.Printf("Warning: question '%s' in section '%s' is type 'text' but has options defined", question.ID, sectionID)
+				slog.Warn("text question has options defined", "question", question.ID, "section", sectionID)
 			}
 			return nil
 		case "buttons":
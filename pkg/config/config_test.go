@@ -0,0 +1,603 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateCallbackSafety(t *testing.T) {
+	text := func(id, key string) QuestionConfig {
+		return QuestionConfig{ID: id, Prompt: "?", Type: "text", StoreKey: key}
+	}
+	buttons := func(id, key, value string) QuestionConfig {
+		return QuestionConfig{ID: id, Prompt: "?", Type: "buttons", StoreKey: key, Options: []ButtonOption{{Text: "Opt", Value: value}}}
+	}
+
+	tests := []struct {
+		name     string
+		sections map[string]SectionConfig
+		wantErr  string
+	}{
+		{
+			name: "valid",
+			sections: map[string]SectionConfig{
+				"a": {Title: "A", Questions: []QuestionConfig{text("q1", "k1"), buttons("q2", "k2", "yes")}},
+			},
+		},
+		{
+			name: "duplicate id across sections",
+			sections: map[string]SectionConfig{
+				"a": {Title: "A", Questions: []QuestionConfig{text("q1", "k1")}},
+				"b": {Title: "B", Questions: []QuestionConfig{text("q1", "k2")}},
+			},
+			wantErr: "duplicate question id 'q1'",
+		},
+		{
+			name: "delimiter in option value",
+			sections: map[string]SectionConfig{
+				"a": {Title: "A", Questions: []QuestionConfig{buttons("q1", "k1", "a:b")}},
+			},
+			wantErr: "must not contain ':'",
+		},
+		{
+			name: "delimiter in question id",
+			sections: map[string]SectionConfig{
+				"a": {Title: "A", Questions: []QuestionConfig{text("q:1", "k1")}},
+			},
+			wantErr: "must not contain ':'",
+		},
+		{
+			name: "section id too long for callback data",
+			sections: map[string]SectionConfig{
+				strings.Repeat("s", 60): {Title: "A", Questions: []QuestionConfig{text("q1", "k1")}},
+			},
+			wantErr: "too long for callback data",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&RecordConfig{Sections: tt.sections}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryTypeProblem(t *testing.T) {
+	rc := &RecordConfig{Sections: map[string]SectionConfig{
+		"a": {Title: "A", Questions: []QuestionConfig{
+			{ID: "q1", Prompt: "?", Type: "slider", StoreKey: "k1"},
+			{ID: "q2", Prompt: "?", Type: "text", StoreKey: "k2"},
+		}},
+		"b": {Title: "B", Questions: []QuestionConfig{{ID: "q3", Prompt: "?", Type: "buttons", StoreKey: "k3"}}},
+	}}
+	err := rc.Validate()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	want := "config validation failed: question 'q1' in section 'a' has unknown type 'slider'\n" +
+		"config validation failed: question 'q3' in section 'b' is type 'buttons' but has no options"
+	if err.Error() != want {
+		t.Fatalf("error = %q, want %q", err, want)
+	}
+}
+
+func TestValidateContentFilter(t *testing.T) {
+	sections := map[string]SectionConfig{
+		"a": {Title: "A", Questions: []QuestionConfig{{ID: "q1", Prompt: "?", Type: "text", StoreKey: "k1"}}},
+	}
+
+	tests := []struct {
+		name    string
+		filter  ContentFilterConfig
+		wantErr string
+	}{
+		{name: "disabled", filter: ContentFilterConfig{Action: "bogus"}},
+		{name: "valid", filter: ContentFilterConfig{Enabled: true, Action: FilterActionMask, Phones: true}},
+		{name: "unknown action", filter: ContentFilterConfig{Enabled: true, Action: "drop", Emails: true}, wantErr: "content_filter.action 'drop'"},
+		{name: "no checks", filter: ContentFilterConfig{Enabled: true, Action: FilterActionFlag}, wantErr: "checks nothing"},
+		{name: "empty banned word", filter: ContentFilterConfig{Enabled: true, Action: FilterActionBlock, BannedWords: []string{" "}}, wantErr: "banned_words #1 is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&RecordConfig{Sections: sections, ContentFilter: tt.filter}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCleanup(t *testing.T) {
+	sections := map[string]SectionConfig{
+		"a": {Title: "A", Questions: []QuestionConfig{{ID: "q1", Prompt: "?", Type: "text", StoreKey: "k1"}}},
+	}
+
+	tests := []struct {
+		name      string
+		cleanup   CleanupConfig
+		wantErr   string
+		wantDelay time.Duration
+	}{
+		{name: "default delay", cleanup: CleanupConfig{Enabled: true}, wantDelay: DefaultCleanupDelay},
+		{name: "custom delay", cleanup: CleanupConfig{Enabled: true, Delay: "2m"}, wantDelay: 2 * time.Minute},
+		{name: "not a duration", cleanup: CleanupConfig{Enabled: true, Delay: "soon"}, wantErr: "cleanup.delay 'soon'"},
+		{name: "negative", cleanup: CleanupConfig{Enabled: true, Delay: "-5s"}, wantErr: "must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&RecordConfig{Sections: sections, Cleanup: tt.cleanup}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got := tt.cleanup.DelayDuration(); got != tt.wantDelay {
+					t.Fatalf("DelayDuration() = %v, want %v", got, tt.wantDelay)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateWeeklyReport(t *testing.T) {
+	sections := map[string]SectionConfig{
+		"a": {Title: "A", Questions: []QuestionConfig{{ID: "q1", Prompt: "?", Type: "text", StoreKey: "k1"}}},
+	}
+
+	tests := []struct {
+		name    string
+		weekly  WeeklyReportConfig
+		wantErr string
+	}{
+		{name: "disabled", weekly: WeeklyReportConfig{Weekday: "someday"}},
+		{name: "valid", weekly: WeeklyReportConfig{Enabled: true, Weekday: "Monday", Time: "09:00", SendTo: []string{ReportToUser, ReportToTherapist}}},
+		{name: "bad weekday", weekly: WeeklyReportConfig{Enabled: true, Weekday: "пн", Time: "09:00"}, wantErr: "weekly_report.weekday 'пн'"},
+		{name: "bad time", weekly: WeeklyReportConfig{Enabled: true, Weekday: "sunday", Time: "9am"}, wantErr: "weekly_report.time '9am'"},
+		{name: "bad recipient", weekly: WeeklyReportConfig{Enabled: true, Weekday: "sunday", Time: "09:00", SendTo: []string{"admin"}}, wantErr: "weekly_report.send_to 'admin'"},
+		{name: "text format", weekly: WeeklyReportConfig{Enabled: true, Weekday: "sunday", Time: "09:00", Format: ReportFormatText}},
+		{name: "bad format", weekly: WeeklyReportConfig{Enabled: true, Weekday: "sunday", Time: "09:00", Format: "html"}, wantErr: "weekly_report.format 'html'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&RecordConfig{Sections: sections, WeeklyReport: tt.weekly}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDeadlines(t *testing.T) {
+	section := func(deadline string) map[string]SectionConfig {
+		return map[string]SectionConfig{
+			"a": {Title: "A", Deadline: deadline, Questions: []QuestionConfig{{ID: "q1", Prompt: "?", Type: "text", StoreKey: "k1"}}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		deadline string
+		sections map[string]SectionConfig
+		wantErr  string
+	}{
+		{name: "none", sections: section("")},
+		{name: "valid", deadline: "21:00", sections: section("12:30")},
+		{name: "bad survey deadline", deadline: "9pm", sections: section(""), wantErr: "deadline '9pm'"},
+		{name: "bad section deadline", sections: section("25:00"), wantErr: "section 'a' deadline '25:00'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&RecordConfig{Sections: tt.sections, Deadline: tt.deadline}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateQuiz(t *testing.T) {
+	section := func(quiz bool, questionType string, option ButtonOption) map[string]SectionConfig {
+		return map[string]SectionConfig{
+			"a": {Title: "A", Quiz: quiz, Questions: []QuestionConfig{{ID: "q1", Prompt: "?", Type: questionType, StoreKey: "k1", Options: []ButtonOption{option}}}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		sections map[string]SectionConfig
+		wantErr  string
+	}{
+		{name: "quiz", sections: section(true, "buttons", ButtonOption{Text: "Да", Value: "yes", Correct: true, Feedback: "Верно"})},
+		{name: "plain options outside a quiz", sections: section(false, "buttons", ButtonOption{Text: "Да", Value: "yes"})},
+		{name: "feedback outside a quiz", sections: section(false, "buttons", ButtonOption{Text: "Да", Value: "yes", Feedback: "Верно"}), wantErr: "section 'a' is not a quiz"},
+		{name: "correct on a text question", sections: section(true, "text", ButtonOption{Text: "Да", Value: "yes", Correct: true}), wantErr: "only buttons questions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&RecordConfig{Sections: tt.sections}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSample(t *testing.T) {
+	questions := []QuestionConfig{
+		{ID: "q1", Prompt: "?", Type: "text", StoreKey: "k1"},
+		{ID: "q2", Prompt: "?", Type: "text", StoreKey: "k2"},
+	}
+	tests := []struct {
+		name    string
+		sample  int
+		wantErr string
+	}{
+		{name: "unset", sample: 0},
+		{name: "part of the pool", sample: 1},
+		{name: "whole pool", sample: 2},
+		{name: "negative", sample: -1, wantErr: "sample -1 must be between 0 and its 2 questions"},
+		{name: "larger than the pool", sample: 3, wantErr: "sample 3 must be between 0 and its 2 questions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&RecordConfig{Sections: map[string]SectionConfig{"a": {Title: "A", Sample: tt.sample, Questions: questions}}}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateLengthBounds(t *testing.T) {
+	tests := []struct {
+		name       string
+		validation *ValidationConfig
+		maxLength  int
+		wantErr    string
+	}{
+		{name: "range", validation: &ValidationConfig{MinLength: 10, MaxLength: 200}},
+		{name: "only min", validation: &ValidationConfig{MinLength: 10}},
+		{name: "negative", validation: &ValidationConfig{MaxLength: -1}, wantErr: "negative validation lengths"},
+		{name: "min above max", validation: &ValidationConfig{MinLength: 50, MaxLength: 20}, wantErr: "validation.min_length 50 above validation.max_length 20"},
+		{name: "above the answer limit", validation: &ValidationConfig{MaxLength: 300}, maxLength: 100, wantErr: "above its answer limit 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			question := QuestionConfig{ID: "q1", Prompt: "?", Type: "text", StoreKey: "k1", MaxLength: tt.maxLength, Validation: tt.validation}
+			err := (&RecordConfig{Sections: map[string]SectionConfig{"a": {Title: "A", Questions: []QuestionConfig{question}}}}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuestionBank(t *testing.T) {
+	bank := map[string]QuestionConfig{
+		"mood": {Prompt: "Настроение?", Type: "text", StoreKey: "mood"},
+	}
+
+	tests := []struct {
+		name      string
+		questions map[string][]QuestionConfig // Section ID -> questions
+		wantErr   string
+		wantIDs   map[string]string // Section ID -> resolved "id/store_key" of its first question
+	}{
+		{
+			name:      "ref takes the bank id and store_key",
+			questions: map[string][]QuestionConfig{"a": {{Ref: "mood"}}},
+			wantIDs:   map[string]string{"a": "mood/mood"},
+		},
+		{
+			name: "overrides reuse the entry in another section",
+			questions: map[string][]QuestionConfig{
+				"a": {{Ref: "mood"}},
+				"b": {{Ref: "mood", ID: "evening_mood", StoreKey: "evening_mood"}},
+			},
+			wantIDs: map[string]string{"a": "mood/mood", "b": "evening_mood/evening_mood"},
+		},
+		{
+			name:      "unknown ref",
+			questions: map[string][]QuestionConfig{"a": {{Ref: "sleep"}}},
+			wantErr:   "unknown question bank entry 'sleep'",
+		},
+		{
+			name:      "fields other than the overrides",
+			questions: map[string][]QuestionConfig{"a": {{Ref: "mood", Prompt: "Как вы?"}}},
+			wantErr:   "may only set id, store_key and show_if",
+		},
+		{
+			name: "reused store_key",
+			questions: map[string][]QuestionConfig{
+				"a": {{Ref: "mood"}},
+				"b": {{Ref: "mood", ID: "evening_mood"}},
+			},
+			wantErr: "duplicate store_key 'mood'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := make(map[string]SectionConfig)
+			for id, questions := range tt.questions {
+				sections[id] = SectionConfig{Title: id, Questions: questions}
+			}
+			rc := &RecordConfig{Sections: sections, QuestionBank: bank}
+			err := rc.Validate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for id, want := range tt.wantIDs {
+				q := rc.Sections[id].Questions[0]
+				if got := q.ID + "/" + q.StoreKey; got != want || q.Prompt != "Настроение?" || q.Ref != "" {
+					t.Fatalf("section %s resolved to %+v, want %s", id, q, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeadlinePassed(t *testing.T) {
+	started := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		deadline string
+		at       time.Time
+		want     bool
+	}{
+		{name: "before", deadline: "21:00", at: started.Add(12 * time.Hour), want: false},
+		{name: "exactly at", deadline: "21:00", at: started.Add(13 * time.Hour), want: false},
+		{name: "after", deadline: "21:00", at: started.Add(13*time.Hour + time.Minute), want: true},
+		{name: "next day", deadline: "21:00", at: started.Add(17 * time.Hour), want: true},
+		{name: "no deadline", deadline: "", at: started.AddDate(0, 0, 3), want: false},
+		{name: "malformed", deadline: "9pm", at: started.AddDate(0, 0, 3), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeadlinePassed(tt.deadline, started, tt.at); got != tt.want {
+				t.Fatalf("DeadlinePassed(%q, %s) = %t, want %t", tt.deadline, tt.at.Format("02.01 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeWindowContains(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2025, 3, 1, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name   string
+		window TimeWindow
+		t      time.Time
+		want   bool
+	}{
+		{name: "inside", window: TimeWindow{From: "06:00", To: "12:00"}, t: at(8, 0), want: true},
+		{name: "start is inclusive", window: TimeWindow{From: "06:00", To: "12:00"}, t: at(6, 0), want: true},
+		{name: "end is exclusive", window: TimeWindow{From: "06:00", To: "12:00"}, t: at(12, 0), want: false},
+		{name: "wraps past midnight, late", window: TimeWindow{From: "22:00", To: "02:00"}, t: at(23, 30), want: true},
+		{name: "wraps past midnight, early", window: TimeWindow{From: "22:00", To: "02:00"}, t: at(1, 59), want: true},
+		{name: "wraps past midnight, outside", window: TimeWindow{From: "22:00", To: "02:00"}, t: at(12, 0), want: false},
+		{name: "malformed", window: TimeWindow{From: "6am", To: "12:00"}, t: at(8, 0), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.t); got != tt.want {
+				t.Fatalf("Contains(%s) = %t, want %t", tt.t.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateShowIf(t *testing.T) {
+	question := func(id, key string, cond *ShowIfConfig) QuestionConfig {
+		return QuestionConfig{ID: id, Prompt: "?", Type: "text", StoreKey: key, ShowIf: cond}
+	}
+
+	tests := []struct {
+		name    string
+		cond    *ShowIfConfig
+		wantErr string
+	}{
+		{name: "earlier question", cond: &ShowIfConfig{StoreKey: "k1", Values: []string{"no"}}},
+		{name: "other section", cond: &ShowIfConfig{StoreKey: "other"}},
+		{name: "no store key", cond: &ShowIfConfig{}, wantErr: "has no store_key"},
+		{name: "unknown store key", cond: &ShowIfConfig{StoreKey: "gone"}, wantErr: "unknown store_key 'gone'"},
+		{name: "itself", cond: &ShowIfConfig{StoreKey: "k2"}, wantErr: "must reference an earlier question"},
+		{name: "later question", cond: &ShowIfConfig{StoreKey: "k3"}, wantErr: "must reference an earlier question"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := map[string]SectionConfig{
+				"a": {Title: "A", Questions: []QuestionConfig{question("q1", "k1", nil), question("q2", "k2", tt.cond), question("q3", "k3", nil)}},
+				"b": {Title: "B", Questions: []QuestionConfig{question("q4", "other", nil)}},
+			}
+			err := (&RecordConfig{Sections: sections}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadTherapistsFromEnv(t *testing.T) {
+	SetTargetUserID(100)
+	defer SetTargetUserID(0)
+	defer SetTherapists(nil)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    []int64
+		wantErr bool
+	}{
+		{name: "unset", env: "", want: []int64{100}},
+		{name: "list", env: "200, 300,100", want: []int64{100, 200, 300}},
+		{name: "invalid", env: "200,abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTherapists(nil)
+			t.Setenv("THERAPIST_IDS", tt.env)
+			err := LoadTherapistsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := Therapists()
+			if len(got) != len(tt.want) {
+				t.Fatalf("therapists = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] || !IsTherapist(got[i]) {
+					t.Fatalf("therapists = %v, want %v", got, tt.want)
+				}
+			}
+			if IsTherapist(0) || IsTherapist(999) {
+				t.Fatalf("unknown IDs must not be therapists")
+			}
+		})
+	}
+}
+
+func TestSectionIDs(t *testing.T) {
+	section := func(order int) SectionConfig {
+		return SectionConfig{Title: "S", Order: order, Questions: []QuestionConfig{{ID: fmt.Sprint("q", order), Prompt: "?", Type: "text", StoreKey: fmt.Sprint("k", order)}}}
+	}
+
+	tests := []struct {
+		name     string
+		sections map[string]SectionConfig
+		want     string
+		wantErr  string
+	}{
+		{name: "no order sorts by ID", sections: map[string]SectionConfig{"c": section(0), "a": section(0), "b": section(0)}, want: "a,b,c"},
+		{name: "order wins over ID", sections: map[string]SectionConfig{"a": section(3), "b": section(1), "c": section(2)}, want: "b,c,a"},
+		{name: "unordered sections last", sections: map[string]SectionConfig{"a": section(0), "z": section(1), "m": section(0)}, want: "z,a,m"},
+		{name: "ties by ID", sections: map[string]SectionConfig{"b": section(1), "a": section(1), "c": section(0)}, want: "a,b,c"},
+		{name: "negative order", sections: map[string]SectionConfig{"a": section(-1)}, wantErr: "section 'a' has negative order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &RecordConfig{Sections: tt.sections}
+			if tt.wantErr != "" {
+				if err := rc.Validate(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			for i := 0; i < 20; i++ {
+				if got := strings.Join(rc.SectionIDs(), ","); got != tt.want {
+					t.Fatalf("SectionIDs() = %s, want %s", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFeatureFlagsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   string
+		legacy  string // DELETE_USER_MESSAGES
+		want    []Feature
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "values", flags: "delete_user_messages=TRUE, typed_ratings", want: []Feature{FeatureDeleteUserMessages, FeatureTypedRatings}},
+		{name: "explicit false", flags: "typed_ratings=false"},
+		{name: "typo in value", flags: "delete_user_messages=ture", wantErr: true},
+		{name: "unknown flag", flags: "webhooks=true", wantErr: true},
+		{name: "deprecated alias", legacy: "true", want: []Feature{FeatureDeleteUserMessages}},
+		{name: "flags override the alias", legacy: "true", flags: "delete_user_messages=false"},
+		{name: "typo in alias", legacy: "yes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reset := func() {
+				for _, f := range FeatureNames() {
+					_ = SetFeature(f, false)
+				}
+			}
+			reset()
+			defer reset()
+			t.Setenv("FEATURE_FLAGS", tt.flags)
+			t.Setenv("DELETE_USER_MESSAGES", tt.legacy)
+
+			err := LoadFeatureFlagsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, f := range FeatureNames() {
+				if want := slices.Contains(tt.want, f); FeatureEnabled(f) != want {
+					t.Fatalf("%s enabled = %t, want %t", f, FeatureEnabled(f), want)
+				}
+			}
+		})
+	}
+}
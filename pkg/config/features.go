@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Feature names a runtime toggle for behavior operators may want to switch without a redeploy.
+type Feature string
+
+const (
+	// FeatureDeleteUserMessages removes user text answers from the chat after they are stored.
+	FeatureDeleteUserMessages Feature = "delete_user_messages"
+	// FeatureForwardOnSave sends every saved record to TARGET_USER_ID; the save is rolled back if that fails.
+	FeatureForwardOnSave Feature = "forward_on_save"
+	// FeatureRequireAck keeps forwarded answers until the therapist taps "received" on the forwarded message.
+	FeatureRequireAck Feature = "require_ack"
+	// FeatureProtectContent sends forwards and record views with protect_content, so they cannot be
+	// forwarded or saved from the recipient's client.
+	FeatureProtectContent Feature = "protect_content"
+	// FeatureTypedRatings accepts a rating typed as a number ("8" or "восемь") instead of a button tap.
+	FeatureTypedRatings Feature = "typed_ratings"
+)
+
+var (
+	featureMu sync.RWMutex
+	features  = map[Feature]bool{
+		FeatureDeleteUserMessages: false,
+		FeatureForwardOnSave:      false,
+		FeatureRequireAck:         false,
+		FeatureProtectContent:     false,
+		FeatureTypedRatings:       false,
+	}
+)
+
+// LoadFeatureFlagsFromEnv applies FEATURE_FLAGS ("name=true,other=false" or bare "name" for true); a
+// value other than true or false is an error. DELETE_USER_MESSAGES is a deprecated alias of
+// delete_user_messages: it is applied first, so FEATURE_FLAGS wins, and logged with a warning.
+func LoadFeatureFlagsFromEnv() error {
+	if legacy := strings.TrimSpace(os.Getenv("DELETE_USER_MESSAGES")); legacy != "" {
+		enabled, err := parseFeatureValue(legacy)
+		if err != nil {
+			return fmt.Errorf("invalid DELETE_USER_MESSAGES: %w", err)
+		}
+		slog.Warn("DELETE_USER_MESSAGES is deprecated, set FEATURE_FLAGS=delete_user_messages=true|false instead", "value", legacy)
+		if err := SetFeature(FeatureDeleteUserMessages, enabled); err != nil {
+			return err
+		}
+	}
+	raw := strings.TrimSpace(os.Getenv("FEATURE_FLAGS"))
+	if raw == "" {
+		return nil
+	}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, hasValue := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		enabled := true
+		if hasValue {
+			var err error
+			if enabled, err = parseFeatureValue(value); err != nil {
+				return fmt.Errorf("invalid FEATURE_FLAGS: %s: %w", name, err)
+			}
+		}
+		if err := SetFeature(Feature(name), enabled); err != nil {
+			return fmt.Errorf("invalid FEATURE_FLAGS: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseFeatureValue reads "true" or "false" in any case.
+func parseFeatureValue(raw string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("value %q is not true or false", raw)
+}
+
+// FeatureEnabled reports the current state of the flag; unknown flags are disabled.
+func FeatureEnabled(f Feature) bool {
+	featureMu.RLock()
+	defer featureMu.RUnlock()
+	return features[f]
+}
+
+// SetFeature toggles a known flag at runtime.
+func SetFeature(f Feature, enabled bool) error {
+	featureMu.Lock()
+	defer featureMu.Unlock()
+	if _, ok := features[f]; !ok {
+		return fmt.Errorf("unknown feature flag %q", f)
+	}
+	features[f] = enabled
+	slog.Info("feature flag set", "feature", f, "enabled", enabled)
+	return nil
+}
+
+// FeatureNames lists all known flags in a stable order.
+func FeatureNames() []Feature {
+	featureMu.RLock()
+	defer featureMu.RUnlock()
+	names := make([]Feature, 0, len(features))
+	for f := range features {
+		names = append(names, f)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
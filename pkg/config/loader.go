@@ -1,53 +1,155 @@
 package config
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 var (
 	loadedConfig *RecordConfig
+	configHash   string
+	configPath   string
+	reloadHooks  []func(*RecordConfig)
 
 	configMutex sync.RWMutex
 )
 
-func LoadConfig(filePath string) error {
-	log.Printf("Loading configuration from %s...", filePath)
-
+// readConfig parses and validates filePath without installing it.
+func readConfig(filePath string) (*RecordConfig, string, error) {
 	yamlFile, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to read config file '%s': %w", filePath, err)
+		return nil, "", fmt.Errorf("failed to read config file '%s': %w", filePath, err)
 	}
 
 	var cfg RecordConfig
 
 	err = yaml.Unmarshal(yamlFile, &cfg)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal YAML from '%s': %w", filePath, err)
+		return nil, "", fmt.Errorf("failed to unmarshal YAML from '%s': %w", filePath, err)
 	}
 
 	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("configuration validation failed: %w", err)
+		return nil, "", fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	sum := sha256.Sum256(yamlFile)
+	return &cfg, hex.EncodeToString(sum[:]), nil
+}
+
+func LoadConfig(filePath string) error {
+	slog.Info("loading configuration", "path", filePath)
+
+	cfg, hash, err := readConfig(filePath)
+	if err != nil {
+		return err
 	}
 
 	configMutex.Lock()
-	loadedConfig = &cfg
+	loadedConfig = cfg
+	configHash = hash
+	configPath = filePath
 	configMutex.Unlock()
 
-	log.Printf("Configuration loaded and validated successfully. %d sections found.", len(loadedConfig.Sections))
+	slog.Info("configuration loaded", "sections", len(cfg.Sections))
 	return nil
 }
 
+// OnReload registers fn to run with the new config after every successful ReloadConfig.
+func OnReload(fn func(*RecordConfig)) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	reloadHooks = append(reloadHooks, fn)
+}
+
+// ReloadConfig reads the file given to LoadConfig again and, when it changed, validates it, lets check
+// veto it and swaps it in. The running config stays in place on any error. Users of the old config
+// keep their pointer, so an update being handled finishes on the config it started with.
+func ReloadConfig(check func(*RecordConfig) error) (bool, error) {
+	configMutex.RLock()
+	filePath, oldHash := configPath, configHash
+	configMutex.RUnlock()
+	if filePath == "" {
+		return false, fmt.Errorf("no configuration file loaded")
+	}
+
+	cfg, hash, err := readConfig(filePath)
+	if err != nil {
+		return false, err
+	}
+	if hash == oldHash {
+		return false, nil
+	}
+	if check != nil {
+		if err := check(cfg); err != nil {
+			return false, fmt.Errorf("configuration rejected: %w", err)
+		}
+	}
+
+	configMutex.Lock()
+	loadedConfig = cfg
+	configHash = hash
+	hooks := append([]func(*RecordConfig){}, reloadHooks...)
+	configMutex.Unlock()
+
+	slog.Info("configuration reloaded", "path", filePath, "sections", len(cfg.Sections))
+	for _, hook := range hooks {
+		hook(cfg)
+	}
+	return true, nil
+}
+
+// WatchConfig polls the loaded config file every interval and reloads it when its modification time
+// changes, until ctx is cancelled. Invalid edits are logged and the running config is kept.
+func WatchConfig(ctx context.Context, interval time.Duration, check func(*RecordConfig) error) {
+	configMutex.RLock()
+	filePath := configPath
+	configMutex.RUnlock()
+
+	var lastMod time.Time
+	if info, err := os.Stat(filePath); err == nil {
+		lastMod = info.ModTime()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(filePath)
+			if err != nil || info.ModTime().Equal(lastMod) {
+				continue
+			}
+			lastMod = info.ModTime()
+			if _, err := ReloadConfig(check); err != nil {
+				slog.WarnContext(ctx, "keeping the running configuration", "err", err)
+			}
+		}
+	}
+}
+
 func GetConfig() *RecordConfig {
 	configMutex.RLock()
 	defer configMutex.RUnlock()
 
 	if loadedConfig == nil {
-		log.Println("Warning: GetConfig() called before configuration was loaded.")
+		slog.Warn("GetConfig called before the configuration was loaded")
 	}
 	return loadedConfig
 }
+
+// GetConfigHash returns the SHA-256 of the loaded YAML file, useful to tell deployments apart.
+func GetConfigHash() string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configHash
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const reloadTestConfig = `sections:
+  a:
+    title: "%s"
+    questions:
+      - id: q1
+        prompt: "?"
+        type: text
+        store_key: k1
+`
+
+func TestReloadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "record_config.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(strings.Replace(reloadTestConfig, "%s", "First", 1))
+	if err := LoadConfig(path); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var reloaded []string
+	OnReload(func(cfg *RecordConfig) { reloaded = append(reloaded, cfg.Sections["a"].Title) })
+
+	tests := []struct {
+		name        string
+		content     string
+		check       func(*RecordConfig) error
+		wantChanged bool
+		wantErr     string
+		wantTitle   string
+	}{
+		{name: "unchanged file", content: strings.Replace(reloadTestConfig, "%s", "First", 1), wantTitle: "First"},
+		{name: "invalid file keeps running config", content: "sections: {}\n", wantErr: "no sections", wantTitle: "First"},
+		{name: "vetoed by check", content: strings.Replace(reloadTestConfig, "%s", "Vetoed", 1), check: func(*RecordConfig) error { return errors.New("unsupported") }, wantErr: "rejected: unsupported", wantTitle: "First"},
+		{name: "changed file is swapped in", content: strings.Replace(reloadTestConfig, "%s", "Second", 1), wantChanged: true, wantTitle: "Second"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write(tt.content)
+			changed, err := ReloadConfig(tt.check)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Fatalf("changed = %t, want %t", changed, tt.wantChanged)
+			}
+			if got := GetConfig().Sections["a"].Title; got != tt.wantTitle {
+				t.Fatalf("running title = %q, want %q", got, tt.wantTitle)
+			}
+		})
+	}
+	if strings.Join(reloaded, ",") != "Second" {
+		t.Fatalf("reload hooks ran for %v, want only the swap", reloaded)
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	defer SetTemplates(nil)
+	valid := strings.Replace(reloadTestConfig, "%s", "Sleep", 1)
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantIDs []string
+		wantErr string
+	}{
+		{name: "surveys named by file", files: map[string]string{"sleep.yaml": valid, "mood.yaml": valid, "notes.txt": "ignored"}, wantIDs: []string{"mood", "sleep"}},
+		{name: "empty directory", wantIDs: []string{}},
+		{name: "bad file name", files: map[string]string{"Sleep Log.yaml": valid}, wantErr: "name must match"},
+		{name: "invalid survey", files: map[string]string{"sleep.yaml": "sections: {}\n"}, wantErr: "survey 'sleep'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTemplates(nil)
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := LoadTemplates(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if len(TemplateIDs()) != 0 {
+					t.Fatalf("templates installed despite the error: %v", TemplateIDs())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := TemplateIDs(); strings.Join(got, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Fatalf("templates %v, want %v", got, tt.wantIDs)
+			}
+			for _, id := range tt.wantIDs {
+				if cfg, ok := Template(id); !ok || cfg.Template != id {
+					t.Fatalf("template %q = %+v", id, cfg)
+				}
+			}
+		})
+	}
+}
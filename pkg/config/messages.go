@@ -0,0 +1,639 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MessageKey names a system message of the bot: an error, a notice or a confirmation. Texts written by
+// operators in the record config (prompts, reminders, completion) are not part of the catalog.
+type MessageKey string
+
+// DefaultLanguage is the language of the built-in texts and the fallback for languages without a variant.
+const DefaultLanguage = "ru"
+
+// builtinCatalogs holds the built-in translations of defaultMessages by language.
+var builtinCatalogs = map[string]map[MessageKey]string{
+	"en": englishMessages,
+}
+
+// languageNames names the languages with a built-in catalog in themselves, for the /language menu.
+var languageNames = map[string]string{
+	DefaultLanguage: "Русский",
+	"en":            "English",
+}
+
+const (
+	MsgInternalError          MessageKey = "internal_error"
+	MsgFSMError               MessageKey = "fsm_error"
+	MsgUnknownCommand         MessageKey = "unknown_command"
+	MsgUseButtons             MessageKey = "use_buttons"
+	MsgStaleAnswer            MessageKey = "stale_answer"
+	MsgActionUnavailable      MessageKey = "action_unavailable"
+	MsgQuestionNotFound       MessageKey = "question_not_found"
+	MsgSectionNotFound        MessageKey = "section_not_found"
+	MsgSectionClosed          MessageKey = "section_closed"
+	MsgSectionConfigError     MessageKey = "section_config_error"
+	MsgQuestionNavError       MessageKey = "question_navigation_error"
+	MsgUnknownQuestionType    MessageKey = "unknown_question_type"
+	MsgQuestionPrepareFailed  MessageKey = "question_prepare_failed"
+	MsgStartRecordFailed      MessageKey = "start_record_failed"
+	MsgAlreadyFilling         MessageKey = "already_filling"
+	MsgMainMenuPrompt         MessageKey = "main_menu_prompt"
+	MsgSectionMenuPrompt      MessageKey = "section_menu_prompt"
+	MsgSectionMenuPage        MessageKey = "section_menu_page"
+	MsgChooseQuestion         MessageKey = "choose_question"
+	MsgPrefillHint            MessageKey = "prefill_hint"
+	MsgConfirmSectionChanges  MessageKey = "confirm_section_changes"
+	MsgConfirmOverwriteDraft  MessageKey = "confirm_overwrite_draft"
+	MsgRecordSaved            MessageKey = "record_saved"
+	MsgRecordSavedAndSent     MessageKey = "record_saved_and_sent"
+	MsgRecordForwarded        MessageKey = "record_forwarded"
+	MsgSaveForwardFailed      MessageKey = "save_forward_failed"
+	MsgDraftNotFound          MessageKey = "draft_not_found"
+	MsgExitKeepDraft          MessageKey = "exit_keep_draft"
+	MsgForcedExit             MessageKey = "forced_exit"
+	MsgOperationDone          MessageKey = "operation_done"
+	MsgPreviewFinished        MessageKey = "preview_finished"
+	MsgDailyRollover          MessageKey = "daily_rollover"
+	MsgNoSavedRecords         MessageKey = "no_saved_records"
+	MsgRecordShowFailed       MessageKey = "record_show_failed"
+	MsgNoRecordsToShare       MessageKey = "no_records_to_share"
+	MsgSharePrepareFailed     MessageKey = "share_prepare_failed"
+	MsgShareCopy              MessageKey = "share_copy"
+	MsgNoAnswersToSend        MessageKey = "no_answers_to_send"
+	MsgTargetNotConfigured    MessageKey = "target_not_configured"
+	MsgSentToTarget           MessageKey = "sent_to_target"
+	MsgSentToTargetPendingAck MessageKey = "sent_to_target_pending_ack"
+	MsgSentToSelf             MessageKey = "sent_to_self"
+	MsgDeliveryRenderFailed   MessageKey = "delivery_render_failed"
+	MsgDeliveryEmpty          MessageKey = "delivery_empty"
+	MsgDeliveryFailed         MessageKey = "delivery_failed"
+	MsgDeliveriesUnavailable  MessageKey = "deliveries_unavailable"
+	MsgDeliveriesEmpty        MessageKey = "deliveries_empty"
+	MsgAlreadyAcknowledged    MessageKey = "already_acknowledged"
+	MsgAckConfirmed           MessageKey = "ack_confirmed"
+	MsgAckPatientNotice       MessageKey = "ack_patient_notice"
+	MsgAutoForwardOff         MessageKey = "autoforward_off"
+	MsgAutoForwardOn          MessageKey = "autoforward_on"
+	MsgAutoForwardUnset       MessageKey = "autoforward_not_configured"
+	MsgAutoForwardUsageOn     MessageKey = "autoforward_usage_on"
+	MsgAutoForwardUsageOff    MessageKey = "autoforward_usage_off"
+	MsgAutoForwardSent        MessageKey = "autoforward_sent"
+	MsgAutoForwardFailed      MessageKey = "autoforward_failed"
+	MsgAutoForwardOptOut      MessageKey = "autoforward_opt_out"
+	MsgFilterBlock            MessageKey = "filter_block"
+	MsgFilterMask             MessageKey = "filter_mask"
+	MsgFilterFlag             MessageKey = "filter_flag"
+	MsgRecordFull             MessageKey = "record_full"
+	MsgAnswerTooLong          MessageKey = "answer_too_long"
+	MsgConfigUpdated          MessageKey = "config_updated"
+	MsgConfigUpdatedDropped   MessageKey = "config_updated_dropped"
+	MsgInactivityAlert        MessageKey = "inactivity_alert"
+	MsgTargetBlocked          MessageKey = "target_blocked"
+	MsgTargetReachable        MessageKey = "target_reachable"
+	MsgTargetLinkRestored     MessageKey = "target_link_restored"
+	MsgRelinkUsage            MessageKey = "relink_usage"
+	MsgRelinkRequested        MessageKey = "relink_requested"
+	MsgRelinkRequest          MessageKey = "relink_request"
+	MsgRelinkRequestNoID      MessageKey = "relink_request_no_id"
+	MsgRelinkDone             MessageKey = "relink_done"
+	MsgReplyPrompt            MessageKey = "reply_prompt"
+	MsgReplySent              MessageKey = "reply_sent"
+	MsgReplyFailed            MessageKey = "reply_failed"
+	MsgReplyCancelled         MessageKey = "reply_cancelled"
+	MsgTherapistReply         MessageKey = "therapist_reply"
+	MsgTherapistAssigned      MessageKey = "therapist_assigned"
+	MsgTherapistLinkInvalid   MessageKey = "therapist_link_invalid"
+	MsgTherapistNewPatient    MessageKey = "therapist_new_patient"
+	MsgTranscriptUsage        MessageKey = "transcript_usage"
+	MsgTranscriptDisabled     MessageKey = "transcript_disabled"
+	MsgTranscriptDenied       MessageKey = "transcript_denied"
+	MsgTranscriptEmpty        MessageKey = "transcript_empty"
+	MsgTranscriptHeader       MessageKey = "transcript_header"
+	MsgTranscriptCaption      MessageKey = "transcript_caption"
+	MsgExportUsage            MessageKey = "export_usage"
+	MsgExportUnsupported      MessageKey = "export_unsupported"
+	MsgExportFailed           MessageKey = "export_failed"
+	MsgExportCaption          MessageKey = "export_caption"
+	MsgNotePrompt             MessageKey = "note_prompt"
+	MsgNoteAdded              MessageKey = "note_added"
+	MsgNoteEmpty              MessageKey = "note_empty"
+	MsgNoteCancelled          MessageKey = "note_cancelled"
+	MsgRemindUsage            MessageKey = "remind_usage"
+	MsgRemindSet              MessageKey = "remind_set"
+	MsgRemindCurrent          MessageKey = "remind_current"
+	MsgRemindOff              MessageKey = "remind_off"
+	MsgRemindUnavailable      MessageKey = "remind_unavailable"
+	MsgPersonalReminder       MessageKey = "personal_reminder"
+	MsgSectionNoQuestions     MessageKey = "section_no_questions"
+	MsgChooseAnswerToEdit     MessageKey = "choose_answer_to_edit"
+	MsgWeeklyReportCaption    MessageKey = "weekly_report_caption"
+	MsgRecordSavedLate        MessageKey = "record_saved_late"
+	MsgWeeklyReportTherapist  MessageKey = "weekly_report_therapist_caption"
+	MsgReportSummary          MessageKey = "report_summary"
+	MsgReportSummaryTherapist MessageKey = "report_summary_therapist"
+	MsgReportDays             MessageKey = "report_days"
+	MsgReportRating           MessageKey = "report_rating"
+	MsgReportEmpty            MessageKey = "report_empty"
+	MsgQuizYourAnswer         MessageKey = "quiz_your_answer"
+	MsgQuizCorrect            MessageKey = "quiz_correct"
+	MsgQuizWrong              MessageKey = "quiz_wrong"
+	MsgQuizScore              MessageKey = "quiz_score"
+	MsgLanguagePrompt         MessageKey = "language_prompt"
+	MsgLanguageSet            MessageKey = "language_set"
+	MsgLanguageUnknown        MessageKey = "language_unknown"
+	MsgSurveysPrompt          MessageKey = "surveys_prompt"
+	MsgSurveyDefaultTitle     MessageKey = "survey_default_title"
+	MsgSurveyOtherDraft       MessageKey = "survey_other_draft"
+	MsgUserStats              MessageKey = "user_stats"
+	MsgLastRecord             MessageKey = "last_record"
+	MsgRecordStatusSaved      MessageKey = "record_status_saved"
+	MsgRecordListHeader       MessageKey = "record_list_header"
+	MsgRecordListEmptyPage    MessageKey = "record_list_empty_page"
+	MsgRecordListName         MessageKey = "record_list_name"
+	MsgRecordListCity         MessageKey = "record_list_city"
+	MsgRecordView             MessageKey = "record_view"
+	MsgDeliveriesHeader       MessageKey = "deliveries_header"
+	MsgDeliveryDelivered      MessageKey = "delivery_delivered"
+	MsgDeliveryRead           MessageKey = "delivery_read"
+	MsgDeliveryNotDelivered   MessageKey = "delivery_not_delivered"
+	MsgDeliveryRetrying       MessageKey = "delivery_retrying"
+	MsgCurrentAnswer          MessageKey = "current_answer"
+	MsgTimeWindow             MessageKey = "time_window"
+
+	// Feedback of the question types.
+	MsgSendTextAnswer         MessageKey = "send_text_answer"
+	MsgTextAnswerEmpty        MessageKey = "text_answer_empty"
+	MsgChooseWithButtons      MessageKey = "choose_with_buttons"
+	MsgOptionUnavailable      MessageKey = "option_unavailable"
+	MsgMultiUseButtons        MessageKey = "multi_use_buttons"
+	MsgMultiSelectOne         MessageKey = "multi_select_one"
+	MsgRatingPrompt           MessageKey = "rating_prompt"
+	MsgRatingNextOrFinish     MessageKey = "rating_next_or_finish"
+	MsgRatingUseButtons       MessageKey = "rating_use_buttons"
+	MsgRatingTypeHint         MessageKey = "rating_type_hint"
+	MsgRatingNotANumber       MessageKey = "rating_not_a_number"
+	MsgRatingOutOfRange       MessageKey = "rating_out_of_range"
+	MsgRatingUseActionButtons MessageKey = "rating_use_action_buttons"
+	MsgRatingChooseNextFinish MessageKey = "rating_choose_next_finish"
+	MsgRatingLostAnswer       MessageKey = "rating_lost_answer"
+	MsgEntriesNeedMore        MessageKey = "entries_need_more"
+	MsgEntriesLimitReached    MessageKey = "entries_limit_reached"
+	MsgLengthHintMax          MessageKey = "length_hint_max"
+	MsgLengthHintMin          MessageKey = "length_hint_min"
+	MsgLengthHintRange        MessageKey = "length_hint_range"
+	MsgLengthHintCurrent      MessageKey = "length_hint_current"
+	MsgAnswerOverMax          MessageKey = "answer_over_max"
+	MsgAnswerUnderMin         MessageKey = "answer_under_min"
+
+	// Labels of forwarded records and digests.
+	MsgForwardHeader      MessageKey = "forward_header"
+	MsgForwardDate        MessageKey = "forward_date"
+	MsgForwardLate        MessageKey = "forward_late"
+	MsgForwardSectionLate MessageKey = "forward_section_late"
+	MsgForwardNotes       MessageKey = "forward_notes"
+	MsgDigestDay          MessageKey = "digest_day"
+
+	// Button labels.
+	MsgButtonFillRecord     MessageKey = "button_fill_record"
+	MsgButtonSendSelf       MessageKey = "button_send_self"
+	MsgButtonSendTherapist  MessageKey = "button_send_therapist"
+	MsgButtonDeliveries     MessageKey = "button_deliveries"
+	MsgButtonExport         MessageKey = "button_export"
+	MsgButtonAddNote        MessageKey = "button_add_note"
+	MsgButtonNoteCancel     MessageKey = "button_note_cancel"
+	MsgButtonReminderFill   MessageKey = "button_reminder_fill"
+	MsgButtonAck            MessageKey = "button_ack"
+	MsgButtonReply          MessageKey = "button_reply"
+	MsgButtonReplyCancel    MessageKey = "button_reply_cancel"
+	MsgButtonBack           MessageKey = "button_back"
+	MsgButtonNextPage       MessageKey = "button_next_page"
+	MsgButtonToMainMenu     MessageKey = "button_to_main_menu"
+	MsgButtonShare          MessageKey = "button_share"
+	MsgButtonToList         MessageKey = "button_to_list"
+	MsgButtonSaveRecord     MessageKey = "button_save_record"
+	MsgButtonNewRecord      MessageKey = "button_new_record"
+	MsgButtonExitMenu       MessageKey = "button_exit_menu"
+	MsgButtonEditAnswers    MessageKey = "button_edit_answers"
+	MsgButtonSaveAndSend    MessageKey = "button_save_and_send"
+	MsgButtonReviewSection  MessageKey = "button_review_section"
+	MsgButtonJumpMenu       MessageKey = "button_jump_menu"
+	MsgButtonBackToSections MessageKey = "button_back_to_sections"
+	MsgButtonSkip           MessageKey = "button_skip"
+	MsgButtonCancelKeep     MessageKey = "button_cancel_keep"
+	MsgButtonCancelDiscard  MessageKey = "button_cancel_discard"
+	MsgButtonCancelResume   MessageKey = "button_cancel_resume"
+	MsgButtonOverwriteDraft MessageKey = "button_overwrite_draft"
+	MsgButtonKeepDraft      MessageKey = "button_keep_draft"
+	MsgButtonTruncateKeep   MessageKey = "button_truncate_keep"
+	MsgButtonTruncateRetry  MessageKey = "button_truncate_retry"
+	MsgButtonQuizNext       MessageKey = "button_quiz_next"
+	MsgButtonRatingNext     MessageKey = "button_rating_next"
+	MsgButtonRatingFinish   MessageKey = "button_rating_finish"
+	MsgButtonMultiDone      MessageKey = "button_multi_done"
+
+	// Explanations of transport errors (botport.BotError codes).
+	MsgErrRateLimited      MessageKey = "error_rate_limited"
+	MsgErrRateLimitedBrief MessageKey = "error_rate_limited_brief"
+	MsgErrForbidden        MessageKey = "error_forbidden"
+	MsgErrBadRequest       MessageKey = "error_bad_request"
+	MsgErrTimeout          MessageKey = "error_timeout"
+	MsgErrTransport        MessageKey = "error_transport"
+
+	// Explanations of force-exit reasons of the record flow.
+	MsgExitStartCommand   MessageKey = "exit_start_command"
+	MsgExitConfigError    MessageKey = "exit_config_error"
+	MsgExitSectionMenu    MessageKey = "exit_section_menu"
+	MsgExitSectionRemoved MessageKey = "exit_section_removed"
+)
+
+// defaultMessages holds the built-in text of every key, in DefaultLanguage. Texts are fmt formats; an
+// override must use the same verbs in the same order.
+var defaultMessages = map[MessageKey]string{
+	MsgInternalError:          "Произошла внутренняя ошибка. Пожалуйста, попробуйте позже или обратитесь к администратору.",
+	MsgFSMError:               "⚠️ Не удалось перейти к следующему вопросу. Попробуйте ещё раз или вернитесь к выбору секций.",
+	MsgUnknownCommand:         "Неизвестная команда.",
+	MsgUseButtons:             "Пожалуйста, используйте предложенные кнопки или завершите текущее действие.",
+	MsgStaleAnswer:            "⚠️ Ответ на предыдущий вопрос?",
+	MsgActionUnavailable:      "Действие недоступно.",
+	MsgQuestionNotFound:       "Вопрос не найден.",
+	MsgSectionNotFound:        "Секция не найдена.",
+	MsgSectionClosed:          "⏰ «%s» доступна %s.",
+	MsgSectionConfigError:     "Ошибка конфигурации секции.",
+	MsgQuestionNavError:       "Ошибка навигации по вопросам.",
+	MsgUnknownQuestionType:    "Неизвестный тип вопроса. Попробуйте позже.",
+	MsgQuestionPrepareFailed:  "Не удалось подготовить вопрос. Попробуйте позже.",
+	MsgStartRecordFailed:      "Не удалось начать ввод записи. Попробуйте позже.",
+	MsgAlreadyFilling:         "Вы уже заполняете запись.",
+	MsgMainMenuPrompt:         "Выберите действие:",
+	MsgSectionMenuPrompt:      "Выберите секцию для заполнения/редактирования или действие:",
+	MsgSectionMenuPage:        "Страница %d из %d",
+	MsgChooseQuestion:         "%s\nВыберите вопрос:",
+	MsgPrefillHint:            "Из профиля Telegram: %s. Подтвердите кнопкой или напишите свой ответ.",
+	MsgConfirmSectionChanges:  "Вы изменили ответы в этой секции. Сохранить их перед выходом к выбору секций?",
+	MsgConfirmOverwriteDraft:  "У вас есть черновик — перезаписать?",
+	MsgRecordSaved:            "✅ Запись успешно сохранена!",
+	MsgRecordSavedAndSent:     "✅ Запись сохранена и отправлена терапевту!",
+	MsgRecordForwarded:        "📨 Запись отправлена терапевту.",
+	MsgSaveForwardFailed:      "❌ Не удалось отправить запись терапевту, поэтому она не сохранена. Черновик на месте — попробуйте сохранить ещё раз позже.",
+	MsgDraftNotFound:          "⚠️ Ошибка: Не найден черновик для сохранения.",
+	MsgExitKeepDraft:          "Выход из режима добавления. Черновик доступен для продолжения.",
+	MsgForcedExit:             "⚠️ Ввод прерван из-за внутренней ошибки. Черновик сохранён, попробуйте ещё раз.",
+	MsgOperationDone:          "Операция завершена.",
+	MsgPreviewFinished:        "👁 Предпросмотр завершён. Ответы не сохранены.",
+	MsgDailyRollover:          "📅 Черновик за %s сохранён как запись того дня. Начинаем запись за сегодня.",
+	MsgNoSavedRecords:         "У вас еще нет сохраненных записей.",
+	MsgRecordShowFailed:       "Не удалось показать запись.",
+	MsgNoRecordsToShare:       "Нет сохраненных записей для пересылки.",
+	MsgSharePrepareFailed:     "Не удалось подготовить запись для отправки.",
+	MsgShareCopy:              "Чтобы поделиться, скопируйте текст ниже:\n\n---\n%s\n---",
+	MsgNoAnswersToSend:        "Нет ответов для отправки.",
+	MsgTargetNotConfigured:    "Не настроен TARGET_USER_ID, отправка недоступна.",
+	MsgSentToTarget:           "Ответы отправлены на ID %d.",
+	MsgSentToTargetPendingAck: "Ответы отправлены на ID %d. Они будут удалены, когда терапевт подтвердит получение.",
+	MsgSentToSelf:             "Ответы отправлены вам в этот чат.",
+	MsgDeliveryRenderFailed:   "Не удалось сформировать сообщение для отправки.",
+	MsgDeliveryEmpty:          "Нет данных для отправки.",
+	MsgDeliveryFailed:         "Не удалось отправить ответы, попробуйте позже.",
+	MsgDeliveriesUnavailable:  "История отправок недоступна.",
+	MsgDeliveriesEmpty:        "📬 Вы ещё ничего не отправляли терапевту.",
+	MsgAlreadyAcknowledged:    "Уже подтверждено.",
+	MsgAckConfirmed:           "✅ Получение подтверждено",
+	MsgAckPatientNotice:       "✅ Терапевт подтвердил получение ваших ответов. Отправленная запись удалена.",
+	MsgAutoForwardOff:         "Автоотправка терапевту отключена. Включить снова: /autoforward on",
+	MsgAutoForwardOn:          "Автоотправка включена: сохранённые записи уходят терапевту каждый день в %s.",
+	MsgAutoForwardUnset:       "Автоотправка не настроена.",
+	MsgAutoForwardUsageOn:     "Автоотправка включена. Использование: /autoforward on|off",
+	MsgAutoForwardUsageOff:    "Автоотправка отключена. Использование: /autoforward on|off",
+	MsgAutoForwardSent:        "📨 Записей автоматически отправлено терапевту: %d.",
+	MsgAutoForwardFailed:      "❌ Не удалось отправить: %d. Их можно отправить вручную через «%s».",
+	MsgAutoForwardOptOut:      "Отключить автоотправку: /autoforward off",
+	MsgFilterBlock:            "⚠️ Ответ содержит: %s. Уберите это и отправьте ответ ещё раз.",
+	MsgFilterMask:             "⚠️ Ответ содержит: %s. Эти фрагменты заменены на %s.",
+	MsgFilterFlag:             "⚠️ Ответ содержит: %s. Терапевт увидит пометку об этом.",
+	MsgRecordFull:             "📦 Запись достигла предельного размера (%d символов). Сократите другие ответы или сохраните запись и начните новую.",
+	MsgAnswerTooLong:          "✂️ Ответ слишком длинный: %d символов, можно не больше %d.\n\nСохранить первые %d символов или ввести ответ заново?",
+	MsgConfigUpdated:          "Анкета обновилась, продолжаем с доступных вопросов.",
+	MsgConfigUpdatedDropped:   "Анкета обновилась: ответы на удалённые вопросы (%s) больше не хранятся.",
+	MsgInactivityAlert:        "Пользователь %s (ID: %d) давно не заполнял записи.",
+	MsgTargetBlocked:          "🚫 Терапевт заблокировал бота, поэтому ответы не доставлены. Они остались у вас: попросите терапевта разблокировать бота и отправить ему /start — мы сообщим, когда можно будет отправить снова.",
+	MsgTargetReachable:        "✅ Терапевт снова на связи. Отправьте ответы ещё раз через «Отправить Терапевту».",
+	MsgTargetLinkRestored:     "✅ Бот снова может присылать вам ответы. С %s доставка была приостановлена; пациентов, не сумевших отправить ответы: %d. Они получили уведомление и смогут отправить их снова.",
+	MsgRelinkUsage:            "Сменили аккаунт Telegram? Отправьте /relink <ID старого аккаунта> — терапевт перенесёт ваши записи. Если ID неизвестен, отправьте просто /relink.",
+	MsgRelinkRequested:        "🔗 Запрос на перенос записей отправлен терапевту. Мы сообщим, когда записи появятся в этом аккаунте.",
+	MsgRelinkRequest:          "🔗 %s (ID: %d) сменил(а) аккаунт Telegram и просит перенести записи со старого ID %d.",
+	MsgRelinkRequestNoID:      "🔗 %s (ID: %d) сменил(а) аккаунт Telegram и просит перенести записи. Старый ID не указан: найдите его и выполните /admin relink <старый ID> %d.",
+	MsgRelinkDone:             "🔗 Записи со старого аккаунта перенесены: %d. История и отправки терапевту теперь доступны здесь.",
+	MsgReplyPrompt:            "💬 Напишите ответ для %s (ID: %d) одним сообщением — бот перешлёт его.",
+	MsgReplySent:              "💬 Ответ отправлен.",
+	MsgReplyFailed:            "Не удалось отправить ответ. Попробуйте ещё раз или отмените.",
+	MsgReplyCancelled:         "Ответ отменён.",
+	MsgTherapistReply:         "💬 Сообщение от терапевта:\n%s",
+	MsgTherapistAssigned:      "🔗 Готово: записи теперь будут отправляться терапевту, который поделился ссылкой.",
+	MsgTherapistLinkInvalid:   "Ссылка на терапевта недействительна. Записи отправляются туда же, что и раньше.",
+	MsgTherapistNewPatient:    "🔗 %s (ID: %d) подключился по вашей ссылке: его записи будут приходить вам.",
+	MsgTranscriptUsage:        "Использование: /transcript <ID пользователя> [N|file] — последние N сообщений переписки или файл со всей перепиской.",
+	MsgTranscriptDisabled:     "Журнал переписки выключен.",
+	MsgTranscriptDenied:       "Переписка пользователя %d вам недоступна.",
+	MsgTranscriptEmpty:        "Переписки с пользователем %d нет.",
+	MsgTranscriptHeader:       "💬 Переписка с %s (ID: %d), сообщений: %d из %d.",
+	MsgTranscriptCaption:      "💬 Переписка с %s (ID: %d), сообщений: %d.",
+	MsgExportUsage:            "Использование: /export [csv|xlsx|html]",
+	MsgExportUnsupported:      "Этот чат не поддерживает отправку файлов.",
+	MsgExportFailed:           "Не удалось подготовить файл с записями. Попробуйте позже.",
+	MsgExportCaption:          "📤 Ваши записи: %d.",
+	MsgNotePrompt:             "📝 Напишите заметку к записи от %s одним сообщением — она добавится с текущим временем.",
+	MsgNoteAdded:              "📝 Заметка добавлена к записи от %s.",
+	MsgNoteEmpty:              "Заметка должна быть текстом. Напишите её одним сообщением или отмените.",
+	MsgNoteCancelled:          "Заметка не добавлена.",
+	MsgRemindUsage:            "Использование: /remind ЧЧ:ММ — ежедневное напоминание в это время, /remind off — отключить.",
+	MsgRemindSet:              "⏰ Напоминание будет приходить каждый день в %s.",
+	MsgRemindCurrent:          "⏰ Напоминание приходит каждый день в %s. Изменить: /remind ЧЧ:ММ, отключить: /remind off",
+	MsgRemindOff:              "Напоминание отключено.",
+	MsgRemindUnavailable:      "Личные напоминания не настроены.",
+	MsgPersonalReminder:       "⏰ Время заполнить дневник!",
+	MsgSectionNoQuestions:     "В секции «%s» сейчас нет вопросов: они зависят от других ответов.",
+	MsgChooseAnswerToEdit:     "✏️ Выберите ответ, который хотите изменить:",
+	MsgRecordSavedLate:        "⏰ Срок сдачи (%s) уже прошёл: запись отмечена как поздняя.",
+	MsgWeeklyReportCaption:    "📊 Отчёт за неделю %s: записей %d.",
+	MsgWeeklyReportTherapist:  "📊 Отчёт пользователя %s (ID: %d) за неделю %s: записей %d.",
+	MsgReportSummary:          "📊 Сводка за %s\nЗаписей: %d",
+	MsgReportSummaryTherapist: "📊 Сводка пользователя %s (ID: %d) за %s\nЗаписей: %d",
+	MsgReportDays:             "Дней с записями: %d из %d",
+	MsgReportRating:           "• %s: в среднем %s (от %s до %s, ответов: %d)",
+	MsgReportEmpty:            "📊 За %s сохранённых записей нет.",
+	MsgQuizYourAnswer:         "Ваш ответ: %s",
+	MsgQuizCorrect:            "✅ Верно!",
+	MsgQuizWrong:              "❌ Неверно. Правильный ответ: %s",
+	MsgQuizScore:              "🏁 Результат: %d из %d.",
+	MsgLanguagePrompt:         "🌐 Выберите язык:",
+	MsgLanguageSet:            "🌐 Теперь я говорю по-русски.",
+	MsgLanguageUnknown:        "Язык «%s» не поддерживается. Доступны: %s.",
+	MsgSurveysPrompt:          "📋 Выберите анкету:",
+	MsgSurveyDefaultTitle:     "Основная анкета",
+	MsgSurveyOtherDraft:       "У вас есть незавершённый черновик анкеты «%s». Сохраните его или сбросьте, прежде чем начинать другую.",
+	MsgUserStats:              "👤 Имя: %s\n🆔 ID: %d\n📊 Кол-во записей: %d",
+	MsgLastRecord:             "📄 Последняя запись (Статус: %s):\n\n%s",
+	MsgRecordStatusSaved:      "Сохранена (%s)",
+	MsgRecordListHeader:       "🗂️ Список записей (%d - %d из %d):",
+	MsgRecordListEmptyPage:    "Нет записей на этой странице.",
+	MsgRecordListName:         "Имя: %s",
+	MsgRecordListCity:         "Город: %s",
+	MsgRecordView:             "📄 Запись %s (%s):\n\n%s",
+	MsgDeliveriesHeader:       "📬 Отправленные записи:",
+	MsgDeliveryDelivered:      "📤 доставлено",
+	MsgDeliveryRead:           "✅ прочитано",
+	MsgDeliveryNotDelivered:   "❌ не доставлено",
+	MsgDeliveryRetrying:       "🔁 повторная отправка",
+	MsgCurrentAnswer:          "%s\n\nТекущий ответ:\n%s",
+	MsgTimeWindow:             "с %s до %s",
+
+	MsgSendTextAnswer:         "Пожалуйста, отправьте текстовый ответ.",
+	MsgTextAnswerEmpty:        "Текст не должен быть пустым, попробуйте ещё раз.",
+	MsgChooseWithButtons:      "Пожалуйста, выберите ответ с помощью кнопок ниже.",
+	MsgOptionUnavailable:      "Выбранный вариант больше недоступен. Попробуйте снова.",
+	MsgMultiUseButtons:        "Пожалуйста, отметьте варианты кнопками ниже и нажмите «Готово».",
+	MsgMultiSelectOne:         "Отметьте хотя бы один вариант.",
+	MsgRatingPrompt:           "Оцените от %d до %d:",
+	MsgRatingNextOrFinish:     "Выберите действие:",
+	MsgRatingUseButtons:       "Пожалуйста, используйте кнопки для выбора оценки.",
+	MsgRatingTypeHint:         "Можно нажать кнопку или написать число.",
+	MsgRatingNotANumber:       "Не получилось распознать оценку «%s». Нажмите кнопку или напишите число от %d до %d.",
+	MsgRatingOutOfRange:       "Пожалуйста, выберите оценку от %d до %d.",
+	MsgRatingUseActionButtons: "Пожалуйста, используйте кнопки для выбора действия.",
+	MsgRatingChooseNextFinish: "Пожалуйста, выберите 'Следующий' или 'Завершить'.",
+	MsgRatingLostAnswer:       "Не удалось прочитать последний ответ, попробуйте снова.",
+	MsgEntriesNeedMore:        "Добавьте ещё хотя бы %d, прежде чем завершить.",
+	MsgEntriesLimitReached:    "Это последняя запись: можно добавить не больше %d.",
+	MsgLengthHintMax:          "✏️ Не больше %d символов.",
+	MsgLengthHintMin:          "✏️ Не меньше %d символов.",
+	MsgLengthHintRange:        "✏️ От %d до %d символов.",
+	MsgLengthHintCurrent:      "Сейчас в ответе %d символов, осталось %d.",
+	MsgAnswerOverMax:          "✋ В ответе %d символов, а можно не больше %d. Сократите его на %d и отправьте снова.",
+	MsgAnswerUnderMin:         "✋ В ответе %d символов, а нужно не меньше %d. Добавьте ещё %d и отправьте снова.",
+
+	MsgForwardHeader:      "Ответы пользователя %s (ID: %d)",
+	MsgForwardDate:        "Дата записи: %s",
+	MsgForwardLate:        "⏰ Сдано после срока (%s)",
+	MsgForwardSectionLate: "⏰ после срока",
+	MsgForwardNotes:       "📝 Заметки:",
+	MsgDigestDay:          "📅 %s — записей: %d",
+
+	MsgButtonFillRecord:     "Заполнить запись",
+	MsgButtonSendSelf:       "Отправить Себе",
+	MsgButtonSendTherapist:  "Отправить Терапевту",
+	MsgButtonDeliveries:     "📬 Отправленные",
+	MsgButtonExport:         "📤 Экспорт",
+	MsgButtonAddNote:        "📝 Заметка к записи",
+	MsgButtonNoteCancel:     "✖️ Отменить заметку",
+	MsgButtonReminderFill:   "📝 Заполнить",
+	MsgButtonAck:            "✅ Получено",
+	MsgButtonReply:          "💬 Ответить",
+	MsgButtonReplyCancel:    "✖️ Отменить ответ",
+	MsgButtonBack:           "⬅️ Назад",
+	MsgButtonNextPage:       "Вперед ➡️",
+	MsgButtonToMainMenu:     "⬆️ В главное меню",
+	MsgButtonShare:          "✉️ Поделиться",
+	MsgButtonToList:         "⬅️ К списку",
+	MsgButtonSaveRecord:     "💾 Сохранить запись",
+	MsgButtonNewRecord:      "🆕 Начать новую запись",
+	MsgButtonExitMenu:       "⬆️ Выйти в меню",
+	MsgButtonEditAnswers:    "✏️ Изменить ответ",
+	MsgButtonSaveAndSend:    "💾 Сохранить и отправить терапевту",
+	MsgButtonReviewSection:  "⏮ Просмотреть отвеченные",
+	MsgButtonJumpMenu:       "📑 К вопросу…",
+	MsgButtonBackToSections: "⬅️ Назад к выбору секций",
+	MsgButtonSkip:           "⏭ Пропустить",
+	MsgButtonCancelKeep:     "💾 Сохранить ответы",
+	MsgButtonCancelDiscard:  "🗑 Отменить ответы",
+	MsgButtonCancelResume:   "↩️ Вернуться к вопросу",
+	MsgButtonOverwriteDraft: "🗑 Перезаписать",
+	MsgButtonKeepDraft:      "↩️ Оставить черновик",
+	MsgButtonTruncateKeep:   "✂️ Сохранить первые %d",
+	MsgButtonTruncateRetry:  "✏️ Ввести заново",
+	MsgButtonQuizNext:       "➡️ Далее",
+	MsgButtonRatingNext:     "➡️ Следующий",
+	MsgButtonRatingFinish:   "✅ Завершить",
+	MsgButtonMultiDone:      "✅ Готово",
+
+	MsgErrRateLimited:      "⏳ Телеграм ограничил отправку, попробуйте через %d сек.",
+	MsgErrRateLimitedBrief: "⏳ Телеграм ограничил отправку, попробуйте чуть позже.",
+	MsgErrForbidden:        "🚫 Получатель заблокировал бота или ещё не начал с ним чат.",
+	MsgErrBadRequest:       "Телеграм отклонил сообщение. Если это повторится, сообщите администратору.",
+	MsgErrTimeout:          "Телеграм не ответил вовремя, попробуйте ещё раз.",
+	MsgErrTransport:        "Не удалось связаться с Телеграмом, попробуйте позже.",
+
+	MsgExitStartCommand:   "Ввод прерван командой /start. Черновик сохранён.",
+	MsgExitConfigError:    "⚠️ Этот вопрос сейчас нельзя заполнить из-за ошибки в настройке анкеты. Черновик сохранён, сообщите администратору.",
+	MsgExitSectionMenu:    "⚠️ Не удалось показать список секций. Черновик сохранён, откройте запись ещё раз.",
+	MsgExitSectionRemoved: "Анкета обновилась, и эта секция больше недоступна. Черновик сохранён.",
+}
+
+var (
+	messagesMu       sync.RWMutex
+	messageOverrides map[MessageKey]map[string]string // key -> language -> text
+)
+
+// formatVerb matches a fmt verb; "%%" is removed before matching.
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+func formatVerbs(text string) string {
+	return strings.Join(formatVerb.FindAllString(strings.ReplaceAll(text, "%%", ""), -1), " ")
+}
+
+// LoadMessagesFromEnv loads the catalog file named by MESSAGES_FILE; without it the built-in texts are used.
+func LoadMessagesFromEnv() error {
+	path := strings.TrimSpace(os.Getenv("MESSAGES_FILE"))
+	if path == "" {
+		return nil
+	}
+	return LoadMessages(path)
+}
+
+// LoadMessages reads a catalog file mapping message keys to texts per language:
+//
+//	unknown_command:
+//	  ru: "Неизвестная команда."
+//	  en: "Unknown command."
+//
+// Keys missing from the file keep their built-in text.
+func LoadMessages(filePath string) error {
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read messages file '%s': %w", filePath, err)
+	}
+	var overrides map[MessageKey]map[string]string
+	if err := yaml.Unmarshal(raw, &overrides); err != nil {
+		return fmt.Errorf("failed to unmarshal YAML from '%s': %w", filePath, err)
+	}
+	if err := SetMessages(overrides); err != nil {
+		return fmt.Errorf("messages file '%s': %w", filePath, err)
+	}
+	slog.Info("loaded message overrides", "count", len(overrides), "path", filePath)
+	return nil
+}
+
+// SetMessages replaces the catalog overrides after checking that every key exists and every text
+// keeps the format verbs of the built-in one.
+func SetMessages(overrides map[MessageKey]map[string]string) error {
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, string(key))
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		builtin, ok := defaultMessages[MessageKey(key)]
+		if !ok {
+			return fmt.Errorf("unknown message key '%s'", key)
+		}
+		for lang, text := range overrides[MessageKey(key)] {
+			if strings.TrimSpace(text) == "" {
+				return fmt.Errorf("message '%s' has an empty '%s' text", key, lang)
+			}
+			if got, want := formatVerbs(text), formatVerbs(builtin); got != want {
+				return fmt.Errorf("message '%s' in '%s' must use the placeholders [%s], got [%s]", key, lang, want, got)
+			}
+		}
+	}
+
+	messagesMu.Lock()
+	messageOverrides = overrides
+	messagesMu.Unlock()
+	return nil
+}
+
+// Languages lists the languages users can choose: DefaultLanguage, those with a built-in catalog, then
+// those only the overrides have.
+func Languages() []string {
+	langs := []string{DefaultLanguage}
+	seen := map[string]bool{DefaultLanguage: true}
+	var extra []string
+	for lang := range builtinCatalogs {
+		extra = append(extra, lang)
+		seen[lang] = true
+	}
+	messagesMu.RLock()
+	for _, variants := range messageOverrides {
+		for lang := range variants {
+			if lang = strings.ToLower(lang); !seen[lang] {
+				extra = append(extra, lang)
+				seen[lang] = true
+			}
+		}
+	}
+	messagesMu.RUnlock()
+	sort.Strings(extra)
+	return append(langs, extra...)
+}
+
+// LanguageName returns the name of lang in itself, or the code for languages without a built-in catalog.
+func LanguageName(lang string) string {
+	if name, ok := languageNames[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+// MessageVariants returns every text of key across the languages, for matching what a user sent back,
+// such as a reply keyboard label, whatever language it was shown in.
+func MessageVariants(key MessageKey) []string {
+	var texts []string
+	if text, ok := defaultMessages[key]; ok {
+		texts = append(texts, text)
+	}
+	for _, catalog := range builtinCatalogs {
+		if text, ok := catalog[key]; ok {
+			texts = append(texts, text)
+		}
+	}
+	messagesMu.RLock()
+	for _, text := range messageOverrides[key] {
+		texts = append(texts, text)
+	}
+	messagesMu.RUnlock()
+	return texts
+}
+
+// Message renders key in lang. A regional code such as "en-US" falls back to "en"; overrides of the
+// language come first, then its built-in catalog, then DefaultLanguage.
+func Message(lang string, key MessageKey, args ...any) string {
+	format := lookupMessage(lang, key)
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func lookupMessage(lang string, key MessageKey) string {
+	messagesMu.RLock()
+	variants := messageOverrides[key]
+	messagesMu.RUnlock()
+
+	lang = strings.ToLower(lang)
+	base, _, _ := strings.Cut(lang, "-")
+	for _, candidate := range []string{lang, base} {
+		if text, ok := variants[candidate]; ok && candidate != "" {
+			return text
+		}
+	}
+	for _, candidate := range []string{lang, base} {
+		if text, ok := builtinCatalogs[candidate][key]; ok {
+			return text
+		}
+	}
+	if text, ok := variants[DefaultLanguage]; ok {
+		return text
+	}
+	if text, ok := defaultMessages[key]; ok {
+		return text
+	}
+	return string(key)
+}
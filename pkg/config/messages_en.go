@@ -0,0 +1,223 @@
+package config
+
+// englishMessages is the built-in English catalog. Every key of defaultMessages has a text here with
+// the same format verbs.
+var englishMessages = map[MessageKey]string{
+	MsgInternalError:          "Something went wrong on our side. Please try again later or contact the administrator.",
+	MsgFSMError:               "⚠️ Could not move on to the next question. Try again or go back to the sections.",
+	MsgUnknownCommand:         "Unknown command.",
+	MsgUseButtons:             "Please use the buttons below or finish the current action.",
+	MsgStaleAnswer:            "⚠️ An answer to the previous question?",
+	MsgActionUnavailable:      "This action is not available.",
+	MsgQuestionNotFound:       "Question not found.",
+	MsgSectionNotFound:        "Section not found.",
+	MsgSectionClosed:          "⏰ “%s” is open %s.",
+	MsgSectionConfigError:     "The section is misconfigured.",
+	MsgQuestionNavError:       "Could not navigate the questions.",
+	MsgUnknownQuestionType:    "Unknown question type. Please try again later.",
+	MsgQuestionPrepareFailed:  "Could not prepare the question. Please try again later.",
+	MsgStartRecordFailed:      "Could not start a record. Please try again later.",
+	MsgAlreadyFilling:         "You are already filling in a record.",
+	MsgMainMenuPrompt:         "Choose an action:",
+	MsgSectionMenuPrompt:      "Choose a section to fill in or edit, or an action:",
+	MsgSectionMenuPage:        "Page %d of %d",
+	MsgChooseQuestion:         "%s\nChoose a question:",
+	MsgPrefillHint:            "From your Telegram profile: %s. Confirm with the button or type your own answer.",
+	MsgConfirmSectionChanges:  "You changed answers in this section. Keep them before going back to the sections?",
+	MsgConfirmOverwriteDraft:  "You have a draft. Overwrite it?",
+	MsgRecordSaved:            "✅ Record saved!",
+	MsgRecordSavedAndSent:     "✅ Record saved and sent to your therapist!",
+	MsgRecordForwarded:        "📨 Record sent to your therapist.",
+	MsgSaveForwardFailed:      "❌ Could not send the record to your therapist, so it was not saved. Your draft is kept, try saving again later.",
+	MsgDraftNotFound:          "⚠️ Error: no draft to save.",
+	MsgExitKeepDraft:          "Left the record. You can continue the draft later.",
+	MsgForcedExit:             "⚠️ Input stopped because of an internal error. Your draft is kept, please try again.",
+	MsgOperationDone:          "Done.",
+	MsgPreviewFinished:        "👁 Preview finished. No answers were saved.",
+	MsgDailyRollover:          "📅 The draft of %s was saved as that day's record. Starting today's record.",
+	MsgNoSavedRecords:         "You have no saved records yet.",
+	MsgRecordShowFailed:       "Could not show the record.",
+	MsgNoRecordsToShare:       "No saved records to share.",
+	MsgSharePrepareFailed:     "Could not prepare the record for sharing.",
+	MsgShareCopy:              "To share, copy the text below:\n\n---\n%s\n---",
+	MsgNoAnswersToSend:        "No answers to send.",
+	MsgTargetNotConfigured:    "TARGET_USER_ID is not set, sending is unavailable.",
+	MsgSentToTarget:           "Answers sent to ID %d.",
+	MsgSentToTargetPendingAck: "Answers sent to ID %d. They will be deleted once your therapist confirms receipt.",
+	MsgSentToSelf:             "Answers sent to you in this chat.",
+	MsgDeliveryRenderFailed:   "Could not build the message to send.",
+	MsgDeliveryEmpty:          "Nothing to send.",
+	MsgDeliveryFailed:         "Could not send the answers, please try again later.",
+	MsgDeliveriesUnavailable:  "The history of sent records is unavailable.",
+	MsgDeliveriesEmpty:        "📬 You have not sent anything to your therapist yet.",
+	MsgAlreadyAcknowledged:    "Already confirmed.",
+	MsgAckConfirmed:           "✅ Receipt confirmed",
+	MsgAckPatientNotice:       "✅ Your therapist confirmed receiving your answers. The sent record was deleted.",
+	MsgAutoForwardOff:         "Auto-send to your therapist is off. Turn it back on: /autoforward on",
+	MsgAutoForwardOn:          "Auto-send is on: saved records go to your therapist every day at %s.",
+	MsgAutoForwardUnset:       "Auto-send is not configured.",
+	MsgAutoForwardUsageOn:     "Auto-send is on. Usage: /autoforward on|off",
+	MsgAutoForwardUsageOff:    "Auto-send is off. Usage: /autoforward on|off",
+	MsgAutoForwardSent:        "📨 Records sent to your therapist automatically: %d.",
+	MsgAutoForwardFailed:      "❌ Could not send: %d. You can send them by hand with “%s”.",
+	MsgAutoForwardOptOut:      "Turn off auto-send: /autoforward off",
+	MsgFilterBlock:            "⚠️ The answer contains: %s. Remove it and send the answer again.",
+	MsgFilterMask:             "⚠️ The answer contains: %s. These parts were replaced with %s.",
+	MsgFilterFlag:             "⚠️ The answer contains: %s. Your therapist will see a note about it.",
+	MsgRecordFull:             "📦 The record has reached its size limit (%d characters). Shorten other answers, or save the record and start a new one.",
+	MsgAnswerTooLong:          "✂️ The answer is too long: %d characters, at most %d allowed.\n\nKeep the first %d characters or type the answer again?",
+	MsgConfigUpdated:          "The survey was updated, continuing with the available questions.",
+	MsgConfigUpdatedDropped:   "The survey was updated: answers to removed questions (%s) are no longer kept.",
+	MsgInactivityAlert:        "User %s (ID: %d) has not filled in records for a while.",
+	MsgTargetBlocked:          "🚫 Your therapist blocked the bot, so the answers were not delivered. You still have them: ask your therapist to unblock the bot and send it /start — we will tell you when you can send again.",
+	MsgTargetReachable:        "✅ Your therapist is reachable again. Send your answers once more with “Send to therapist”.",
+	MsgTargetLinkRestored:     "✅ The bot can send you answers again. Delivery was suspended since %s; patients who could not send their answers: %d. They were notified and can send them again.",
+	MsgRelinkUsage:            "Changed your Telegram account? Send /relink <old account ID> and your therapist will move your records. If you don't know the ID, just send /relink.",
+	MsgRelinkRequested:        "🔗 The request to move your records was sent to your therapist. We will tell you when the records appear in this account.",
+	MsgRelinkRequest:          "🔗 %s (ID: %d) changed their Telegram account and asks to move the records from the old ID %d.",
+	MsgRelinkRequestNoID:      "🔗 %s (ID: %d) changed their Telegram account and asks to move the records. The old ID is not given: find it and run /admin relink <old ID> %d.",
+	MsgRelinkDone:             "🔗 Records moved from the old account: %d. History and sent records are now available here.",
+	MsgReplyPrompt:            "💬 Write your reply to %s (ID: %d) in one message and the bot will pass it on.",
+	MsgReplySent:              "💬 Reply sent.",
+	MsgReplyFailed:            "Could not send the reply. Try again or cancel.",
+	MsgReplyCancelled:         "Reply cancelled.",
+	MsgTherapistReply:         "💬 Message from your therapist:\n%s",
+	MsgTherapistAssigned:      "🔗 Done: your records now go to the therapist who shared this link.",
+	MsgTherapistLinkInvalid:   "This therapist link is not valid. Your records go where they went before.",
+	MsgTherapistNewPatient:    "🔗 %s (ID: %d) joined with your link; their records will come to you.",
+	MsgTranscriptUsage:        "Usage: /transcript <user ID> [N|file] for the last N messages of the conversation or a file with all of it.",
+	MsgTranscriptDisabled:     "Conversation transcripts are off.",
+	MsgTranscriptDenied:       "You cannot view the conversation of user %d.",
+	MsgTranscriptEmpty:        "There is no conversation with user %d.",
+	MsgTranscriptHeader:       "💬 Conversation with %s (ID: %d), messages: %d of %d.",
+	MsgTranscriptCaption:      "💬 Conversation with %s (ID: %d), messages: %d.",
+	MsgExportUsage:            "Usage: /export [csv|xlsx|html]",
+	MsgExportUnsupported:      "This chat cannot receive files.",
+	MsgExportFailed:           "Could not prepare the file with your records. Please try again later.",
+	MsgExportCaption:          "📤 Your records: %d.",
+	MsgNotePrompt:             "📝 Write a note for your record of %s in one message; it is added with the current time.",
+	MsgNoteAdded:              "📝 Note added to the record of %s.",
+	MsgNoteEmpty:              "A note must be text. Write it in one message or cancel.",
+	MsgNoteCancelled:          "Note cancelled.",
+	MsgRemindUsage:            "Usage: /remind HH:MM for a daily reminder at that time, /remind off to turn it off.",
+	MsgRemindSet:              "⏰ The reminder will come every day at %s.",
+	MsgRemindCurrent:          "⏰ The reminder comes every day at %s. Change it: /remind HH:MM, turn it off: /remind off",
+	MsgRemindOff:              "Reminder turned off.",
+	MsgRemindUnavailable:      "Personal reminders are not configured.",
+	MsgPersonalReminder:       "⏰ Time to fill in your diary!",
+	MsgSectionNoQuestions:     "Section “%s” has no questions right now: they depend on other answers.",
+	MsgChooseAnswerToEdit:     "✏️ Choose the answer to change:",
+	MsgRecordSavedLate:        "⏰ The deadline (%s) has passed: the record is marked as late.",
+	MsgWeeklyReportCaption:    "📊 Report for the week %s: %d records.",
+	MsgWeeklyReportTherapist:  "📊 Report of user %s (ID: %d) for the week %s: %d records.",
+	MsgReportSummary:          "📊 Summary for %s\nRecords: %d",
+	MsgReportSummaryTherapist: "📊 Summary of user %s (ID: %d) for %s\nRecords: %d",
+	MsgReportDays:             "Days with records: %d of %d",
+	MsgReportRating:           "• %s: %s on average (%s to %s, %d answers)",
+	MsgReportEmpty:            "📊 No saved records for %s.",
+	MsgQuizYourAnswer:         "Your answer: %s",
+	MsgQuizCorrect:            "✅ Correct!",
+	MsgQuizWrong:              "❌ Wrong. The correct answer: %s",
+	MsgQuizScore:              "🏁 Score: %d of %d.",
+	MsgLanguagePrompt:         "🌐 Choose a language:",
+	MsgLanguageSet:            "🌐 I'll speak English from now on.",
+	MsgLanguageUnknown:        "Language “%s” is not supported. Available: %s.",
+	MsgSurveysPrompt:          "📋 Choose a survey:",
+	MsgSurveyDefaultTitle:     "Main survey",
+	MsgSurveyOtherDraft:       "You have an unfinished draft of “%s”. Save or discard it before starting another survey.",
+	MsgUserStats:              "👤 Name: %s\n🆔 ID: %d\n📊 Records: %d",
+	MsgLastRecord:             "📄 Last record (status: %s):\n\n%s",
+	MsgRecordStatusSaved:      "saved %s",
+	MsgRecordListHeader:       "🗂️ Records (%d - %d of %d):",
+	MsgRecordListEmptyPage:    "No records on this page.",
+	MsgRecordListName:         "Name: %s",
+	MsgRecordListCity:         "City: %s",
+	MsgRecordView:             "📄 Record %s (%s):\n\n%s",
+	MsgDeliveriesHeader:       "📬 Sent records:",
+	MsgDeliveryDelivered:      "📤 delivered",
+	MsgDeliveryRead:           "✅ read",
+	MsgDeliveryNotDelivered:   "❌ not delivered",
+	MsgDeliveryRetrying:       "🔁 resending",
+	MsgCurrentAnswer:          "%s\n\nCurrent answer:\n%s",
+	MsgTimeWindow:             "from %s to %s",
+
+	MsgSendTextAnswer:         "Please send a text answer.",
+	MsgTextAnswerEmpty:        "The text must not be empty, please try again.",
+	MsgChooseWithButtons:      "Please choose an answer with the buttons below.",
+	MsgOptionUnavailable:      "The chosen option is no longer available. Please try again.",
+	MsgMultiUseButtons:        "Please tick the options with the buttons below and press “Done”.",
+	MsgMultiSelectOne:         "Tick at least one option.",
+	MsgRatingPrompt:           "Rate from %d to %d:",
+	MsgRatingNextOrFinish:     "Choose an action:",
+	MsgRatingUseButtons:       "Please use the buttons to choose a rating.",
+	MsgRatingTypeHint:         "Tap a button or type the number.",
+	MsgRatingNotANumber:       "Could not read “%s” as a rating. Tap a button or type a number from %d to %d.",
+	MsgRatingOutOfRange:       "Please choose a rating from %d to %d.",
+	MsgRatingUseActionButtons: "Please use the buttons to choose an action.",
+	MsgRatingChooseNextFinish: "Please choose “Next” or “Finish”.",
+	MsgRatingLostAnswer:       "Could not read the last answer, please try again.",
+	MsgEntriesNeedMore:        "Add at least %d more before finishing.",
+	MsgEntriesLimitReached:    "This is the last entry: at most %d can be added.",
+	MsgLengthHintMax:          "✏️ Up to %d characters.",
+	MsgLengthHintMin:          "✏️ At least %d characters.",
+	MsgLengthHintRange:        "✏️ From %d to %d characters.",
+	MsgLengthHintCurrent:      "The current answer has %d characters, %d left.",
+	MsgAnswerOverMax:          "✋ The answer has %d characters, but at most %d are allowed. Shorten it by %d and send it again.",
+	MsgAnswerUnderMin:         "✋ The answer has %d characters, but at least %d are needed. Add %d more and send it again.",
+
+	MsgForwardHeader:      "Answers of %s (ID: %d)",
+	MsgForwardDate:        "Record date: %s",
+	MsgForwardLate:        "⏰ Submitted after the deadline (%s)",
+	MsgForwardSectionLate: "⏰ late",
+	MsgForwardNotes:       "📝 Notes:",
+	MsgDigestDay:          "📅 %s — records: %d",
+
+	MsgButtonFillRecord:     "Fill in a record",
+	MsgButtonSendSelf:       "Send to myself",
+	MsgButtonSendTherapist:  "Send to therapist",
+	MsgButtonDeliveries:     "📬 Sent",
+	MsgButtonExport:         "📤 Export",
+	MsgButtonAddNote:        "📝 Add note to last record",
+	MsgButtonNoteCancel:     "✖️ Cancel note",
+	MsgButtonReminderFill:   "📝 Fill in",
+	MsgButtonAck:            "✅ Received",
+	MsgButtonReply:          "💬 Reply",
+	MsgButtonReplyCancel:    "✖️ Cancel reply",
+	MsgButtonBack:           "⬅️ Back",
+	MsgButtonNextPage:       "Next ➡️",
+	MsgButtonToMainMenu:     "⬆️ Main menu",
+	MsgButtonShare:          "✉️ Share",
+	MsgButtonToList:         "⬅️ To the list",
+	MsgButtonSaveRecord:     "💾 Save record",
+	MsgButtonNewRecord:      "🆕 Start a new record",
+	MsgButtonExitMenu:       "⬆️ Exit to menu",
+	MsgButtonEditAnswers:    "✏️ Change an answer",
+	MsgButtonSaveAndSend:    "💾 Save and send to therapist",
+	MsgButtonReviewSection:  "⏮ Review answered",
+	MsgButtonJumpMenu:       "📑 Go to question…",
+	MsgButtonBackToSections: "⬅️ Back to sections",
+	MsgButtonSkip:           "⏭ Skip",
+	MsgButtonCancelKeep:     "💾 Keep answers",
+	MsgButtonCancelDiscard:  "🗑 Discard answers",
+	MsgButtonCancelResume:   "↩️ Back to the question",
+	MsgButtonOverwriteDraft: "🗑 Overwrite",
+	MsgButtonKeepDraft:      "↩️ Keep the draft",
+	MsgButtonTruncateKeep:   "✂️ Keep the first %d",
+	MsgButtonTruncateRetry:  "✏️ Type again",
+	MsgButtonQuizNext:       "➡️ Next",
+	MsgButtonRatingNext:     "➡️ Next",
+	MsgButtonRatingFinish:   "✅ Finish",
+	MsgButtonMultiDone:      "✅ Done",
+
+	MsgErrRateLimited:      "⏳ Telegram limited sending, try again in %d s.",
+	MsgErrRateLimitedBrief: "⏳ Telegram limited sending, try again a bit later.",
+	MsgErrForbidden:        "🚫 The recipient blocked the bot or has not started a chat with it yet.",
+	MsgErrBadRequest:       "Telegram rejected the message. If this happens again, tell the administrator.",
+	MsgErrTimeout:          "Telegram did not answer in time, please try again.",
+	MsgErrTransport:        "Could not reach Telegram, please try again later.",
+
+	MsgExitStartCommand:   "Input stopped by /start. Your draft is kept.",
+	MsgExitConfigError:    "⚠️ This question cannot be answered right now because of a survey configuration error. Your draft is kept, please tell the administrator.",
+	MsgExitSectionMenu:    "⚠️ Could not show the sections. Your draft is kept, open the record again.",
+	MsgExitSectionRemoved: "The survey was updated and this section is no longer available. Your draft is kept.",
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMessageLanguageFallback(t *testing.T) {
+	if err := SetMessages(map[MessageKey]map[string]string{
+		MsgUnknownCommand: {"en": "Unknown command.", "en-gb": "Unknown command, mate."},
+		MsgSentToTarget:   {"ru": "Отправлено на %d."},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = SetMessages(nil) }()
+
+	tests := []struct {
+		name string
+		lang string
+		key  MessageKey
+		args []any
+		want string
+	}{
+		{name: "exact language", lang: "en", key: MsgUnknownCommand, want: "Unknown command."},
+		{name: "regional variant", lang: "en-GB", key: MsgUnknownCommand, want: "Unknown command, mate."},
+		{name: "regional falls back to base", lang: "en-US", key: MsgUnknownCommand, want: "Unknown command."},
+		{name: "missing language uses built-in", lang: "de", key: MsgUnknownCommand, want: "Неизвестная команда."},
+		{name: "default language override", lang: "", key: MsgSentToTarget, args: []any{7}, want: "Отправлено на 7."},
+		{name: "built-in catalog", lang: "en-US", key: MsgOperationDone, want: "Done."},
+		{name: "no catalog uses default", lang: "de", key: MsgOperationDone, want: "Операция завершена."},
+		{name: "unknown key", lang: "en", key: "nope", want: "nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Message(tt.lang, tt.key, tt.args...); got != tt.want {
+				t.Fatalf("Message(%q, %q) = %q, want %q", tt.lang, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuiltinCatalogs(t *testing.T) {
+	for lang, catalog := range builtinCatalogs {
+		t.Run(lang, func(t *testing.T) {
+			for key, builtin := range defaultMessages {
+				text, ok := catalog[key]
+				if !ok {
+					t.Fatalf("message '%s' has no '%s' text", key, lang)
+				}
+				if got, want := formatVerbs(text), formatVerbs(builtin); got != want {
+					t.Fatalf("message '%s' in '%s' uses [%s], want [%s]", key, lang, got, want)
+				}
+			}
+			if len(catalog) != len(defaultMessages) {
+				t.Fatalf("catalog '%s' has %d keys, want %d", lang, len(catalog), len(defaultMessages))
+			}
+		})
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	if err := SetMessages(map[MessageKey]map[string]string{MsgUnknownCommand: {"de": "Unbekannter Befehl."}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = SetMessages(nil) }()
+
+	if got := strings.Join(Languages(), ","); got != "ru,de,en" {
+		t.Fatalf("Languages() = %s, want ru,de,en", got)
+	}
+	if got := LanguageName("en"); got != "English" {
+		t.Fatalf("LanguageName(en) = %q", got)
+	}
+	variants := strings.Join(MessageVariants(MsgUnknownCommand), "|")
+	for _, want := range []string{"Неизвестная команда.", "Unknown command.", "Unbekannter Befehl."} {
+		if !strings.Contains(variants, want) {
+			t.Fatalf("MessageVariants = %q, missing %q", variants, want)
+		}
+	}
+}
+
+func TestLoadMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "valid", yaml: "unknown_command:\n  en: \"Unknown command.\"\nsent_to_target:\n  en: \"Sent to %d.\"\n"},
+		{name: "unknown key", yaml: "hello:\n  en: \"Hi\"\n", wantErr: "unknown message key 'hello'"},
+		{name: "missing placeholder", yaml: "sent_to_target:\n  en: \"Sent.\"\n", wantErr: "placeholders [%d]"},
+		{name: "wrong placeholder", yaml: "sent_to_target:\n  en: \"Sent to %s.\"\n", wantErr: "got [%s]"},
+		{name: "empty text", yaml: "unknown_command:\n  en: \" \"\n", wantErr: "empty 'en' text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() { _ = SetMessages(nil) }()
+			path := filepath.Join(t.TempDir(), "messages.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			err := LoadMessages(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
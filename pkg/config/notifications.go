@@ -0,0 +1,36 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	defaultStartupTemplate  = "Бот запущен и готов принимать ответы.\nВерсия: {{.Version}}\nКонфиг: {{.ConfigHash}}\nХост: {{.Host}}"
+	defaultShutdownTemplate = "Бот останавливается.\nВерсия: {{.Version}}\nХост: {{.Host}}\nАптайм: {{.Uptime}}"
+)
+
+// NotificationConfig controls the lifecycle messages sent to TARGET_USER_ID.
+// Templates are text/template strings rendered with version, config hash, host and uptime.
+type NotificationConfig struct {
+	Enabled          bool
+	StartupTemplate  string
+	ShutdownTemplate string
+}
+
+// LoadNotificationConfigFromEnv reads NOTIFY_TARGET_LIFECYCLE (default true) and the optional
+// NOTIFY_STARTUP_TEMPLATE / NOTIFY_SHUTDOWN_TEMPLATE overrides.
+func LoadNotificationConfigFromEnv() NotificationConfig {
+	cfg := NotificationConfig{
+		Enabled:          !strings.EqualFold(os.Getenv("NOTIFY_TARGET_LIFECYCLE"), "false"),
+		StartupTemplate:  defaultStartupTemplate,
+		ShutdownTemplate: defaultShutdownTemplate,
+	}
+	if tpl := os.Getenv("NOTIFY_STARTUP_TEMPLATE"); tpl != "" {
+		cfg.StartupTemplate = tpl
+	}
+	if tpl := os.Getenv("NOTIFY_SHUTDOWN_TEMPLATE"); tpl != "" {
+		cfg.ShutdownTemplate = tpl
+	}
+	return cfg
+}
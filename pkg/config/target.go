@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 )
 
 var (
 	targetUserID int64
+	therapistIDs []int64
 	targetMu     sync.RWMutex
 )
 
@@ -41,3 +43,58 @@ func SetTargetUserID(id int64) {
 	targetUserID = id
 	targetMu.Unlock()
 }
+
+// LoadTherapistsFromEnv reads THERAPIST_IDS, the comma-separated IDs of the therapists users can be
+// assigned to besides TARGET_USER_ID. It is optional.
+func LoadTherapistsFromEnv() error {
+	var ids []int64
+	for _, raw := range strings.Split(os.Getenv("THERAPIST_IDS"), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid THERAPIST_IDS entry: %q", raw)
+		}
+		ids = append(ids, id)
+	}
+	SetTherapists(ids)
+	return nil
+}
+
+// SetTherapists replaces the THERAPIST_IDS list. Tests use it to skip the environment.
+func SetTherapists(ids []int64) {
+	targetMu.Lock()
+	therapistIDs = append([]int64(nil), ids...)
+	targetMu.Unlock()
+}
+
+// Therapists returns the users records can be sent to: TARGET_USER_ID, when set, then THERAPIST_IDS.
+func Therapists() []int64 {
+	targetMu.RLock()
+	defer targetMu.RUnlock()
+	ids := make([]int64, 0, len(therapistIDs)+1)
+	if targetUserID != 0 {
+		ids = append(ids, targetUserID)
+	}
+	for _, id := range therapistIDs {
+		if id != targetUserID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// IsTherapist reports whether id is TARGET_USER_ID or one of THERAPIST_IDS.
+func IsTherapist(id int64) bool {
+	if id == 0 {
+		return false
+	}
+	for _, therapist := range Therapists() {
+		if therapist == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// templateIDPattern keeps template IDs short and safe to put into callback data.
+var templateIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+var (
+	templates   map[string]*RecordConfig
+	templatesMu sync.RWMutex
+)
+
+// LoadTemplatesFromEnv loads the extra surveys from the directory named by SURVEYS_DIR; without it
+// only the main config is offered.
+func LoadTemplatesFromEnv() error {
+	dir := strings.TrimSpace(os.Getenv("SURVEYS_DIR"))
+	if dir == "" {
+		return nil
+	}
+	return LoadTemplates(dir)
+}
+
+// LoadTemplates reads every *.yaml file in dir as a survey template named after the file without its
+// extension, so surveys/sleep.yaml becomes the template "sleep". Each file is a complete record config
+// and is validated like the main one. Templates are read once; edits need a restart.
+func LoadTemplates(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return fmt.Errorf("failed to list surveys in '%s': %w", dir, err)
+	}
+	loaded := make(map[string]*RecordConfig, len(paths))
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), ".yaml")
+		if !templateIDPattern.MatchString(id) {
+			return fmt.Errorf("survey file '%s': name must match %s", path, templateIDPattern)
+		}
+		cfg, _, err := readConfig(path)
+		if err != nil {
+			return fmt.Errorf("survey '%s': %w", id, err)
+		}
+		cfg.Template = id
+		loaded[id] = cfg
+	}
+	SetTemplates(loaded)
+	slog.Info("loaded survey templates", "count", len(loaded), "dir", dir)
+	return nil
+}
+
+// SetTemplates replaces the survey templates. Tests use it to skip the files.
+func SetTemplates(next map[string]*RecordConfig) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+	templates = next
+}
+
+// Template returns the survey template with the given ID.
+func Template(id string) (*RecordConfig, bool) {
+	templatesMu.RLock()
+	defer templatesMu.RUnlock()
+	cfg, ok := templates[id]
+	return cfg, ok
+}
+
+// TemplateIDs lists the loaded survey templates, sorted.
+func TemplateIDs() []string {
+	templatesMu.RLock()
+	defer templatesMu.RUnlock()
+	ids := make([]string, 0, len(templates))
+	for id := range templates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,98 @@
+// Package dialog routes the therapist's replies to the users whose records they read. Each therapist
+// has a short FSM: "Reply" under a forwarded record moves it from idle to composing with that user as
+// the recipient, and relaying the next text or cancelling moves it back.
+package dialog
+
+import (
+	"context"
+	"sync"
+
+	"github.com/looplab/fsm"
+)
+
+// Dialog states and events.
+const (
+	StateIdle      = "idle"
+	StateComposing = "composing"
+
+	EventOpen   = "open"
+	EventSend   = "send"
+	EventCancel = "cancel"
+)
+
+// chat is one therapist's dialog FSM and the user their reply goes to.
+type chat struct {
+	fsm       *fsm.FSM
+	recipient int64
+}
+
+// Router holds the dialog of every therapist who has opened one. It is safe for concurrent use.
+type Router struct {
+	mu    sync.Mutex
+	chats map[int64]*chat
+}
+
+// NewRouter returns a router with no open dialogs.
+func NewRouter() *Router {
+	return &Router{chats: make(map[int64]*chat)}
+}
+
+func newChatFSM() *fsm.FSM {
+	return fsm.NewFSM(StateIdle, fsm.Events{
+		{Name: EventOpen, Src: []string{StateIdle}, Dst: StateComposing},
+		{Name: EventSend, Src: []string{StateComposing}, Dst: StateIdle},
+		{Name: EventCancel, Src: []string{StateComposing}, Dst: StateIdle},
+	}, fsm.Callbacks{})
+}
+
+// Open starts a reply from therapistID to recipient. Opening another reply while composing only
+// changes the recipient.
+func (r *Router) Open(therapistID, recipient int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.chats[therapistID]
+	if !ok {
+		c = &chat{fsm: newChatFSM()}
+		r.chats[therapistID] = c
+	}
+	c.recipient = recipient
+	if c.fsm.Current() == StateIdle {
+		_ = c.fsm.Event(context.Background(), EventOpen)
+	}
+}
+
+// Recipient returns the user therapistID is writing to, if they are composing a reply.
+func (r *Router) Recipient(therapistID int64) (int64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.chats[therapistID]
+	if !ok || c.fsm.Current() != StateComposing {
+		return 0, false
+	}
+	return c.recipient, true
+}
+
+// Sent closes the reply of therapistID once its text was relayed.
+func (r *Router) Sent(therapistID int64) {
+	r.finish(therapistID, EventSend)
+}
+
+// Cancel closes the reply of therapistID without relaying anything. It reports whether a reply was open.
+func (r *Router) Cancel(therapistID int64) bool {
+	return r.finish(therapistID, EventCancel)
+}
+
+func (r *Router) finish(therapistID int64, event string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.chats[therapistID]
+	if !ok || c.fsm.Current() != StateComposing {
+		return false
+	}
+	_ = c.fsm.Event(context.Background(), event)
+	delete(r.chats, therapistID)
+	return true
+}
@@ -0,0 +1,33 @@
+package dialog
+
+import "testing"
+
+func TestRouter(t *testing.T) {
+	router := NewRouter()
+	steps := []struct {
+		name          string
+		do            func()
+		wantRecipient int64 // 0 when the therapist is not composing
+	}{
+		{name: "nothing open", do: func() {}},
+		{name: "open", do: func() { router.Open(1, 10) }, wantRecipient: 10},
+		{name: "open another", do: func() { router.Open(1, 20) }, wantRecipient: 20},
+		{name: "sent", do: func() { router.Sent(1) }},
+		{name: "sent twice", do: func() { router.Sent(1) }},
+		{name: "reopen", do: func() { router.Open(1, 10) }, wantRecipient: 10},
+		{name: "cancel", do: func() { router.Cancel(1) }},
+	}
+	for _, step := range steps {
+		step.do()
+		recipient, ok := router.Recipient(1)
+		if ok != (step.wantRecipient != 0) || recipient != step.wantRecipient {
+			t.Fatalf("%s: recipient %d (composing %t), want %d", step.name, recipient, ok, step.wantRecipient)
+		}
+	}
+	if router.Cancel(1) {
+		t.Fatalf("cancelling without an open reply must report false")
+	}
+	if _, ok := router.Recipient(2); ok {
+		t.Fatalf("another therapist must not share the dialog")
+	}
+}
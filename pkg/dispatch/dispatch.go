@@ -0,0 +1,162 @@
+// Package dispatch runs update handlers on a bounded pool of workers. Handlers submitted under the same
+// key (a user) run one at a time in submission order, so a burst of messages from one user is handled
+// in sequence; different keys run in parallel. Shutdown waits for the queued handlers to finish.
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDrainTimeout is how long Drain waits when no timeout is configured.
+	DefaultDrainTimeout = 10 * time.Second
+	// DefaultWorkers is the pool size when New is given none.
+	DefaultWorkers = 8
+)
+
+// Handler handles one update. Its context is not cancelled by the shutdown signal, only when a drain
+// times out, so an in-flight handler keeps talking to Telegram and the store until it is done.
+type Handler func(ctx context.Context)
+
+// Dispatcher queues handlers per key and runs them on its workers.
+type Dispatcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queues   map[int64][]Handler // Pending handlers per key
+	busy     map[int64]bool      // Keys a worker is running a handler of
+	ready    []int64             // Keys with pending handlers and no worker, in arrival order
+	pending  int                 // Handlers queued or running
+	draining bool                // Submit refuses new handlers
+	stopped  bool                // Workers exit
+}
+
+// New starts a dispatcher with the given number of workers (DefaultWorkers when not positive). Its
+// handlers get the values of parent but outlive its cancellation.
+func New(parent context.Context, workers int) *Dispatcher {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	d := &Dispatcher{
+		ctx:    ctx,
+		cancel: cancel,
+		queues: make(map[int64][]Handler),
+		busy:   make(map[int64]bool),
+	}
+	d.cond = sync.NewCond(&d.mu)
+	for range workers {
+		go d.work()
+	}
+	return d
+}
+
+// Submit queues handler behind the earlier handlers of key. It returns false without queueing once
+// Drain has started.
+func (d *Dispatcher) Submit(key int64, handler Handler) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.pending++
+	d.queues[key] = append(d.queues[key], handler)
+	if !d.busy[key] && len(d.queues[key]) == 1 {
+		d.ready = append(d.ready, key)
+		d.cond.Signal()
+	}
+	return true
+}
+
+// Pending returns the number of handlers queued or running.
+func (d *Dispatcher) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.pending
+}
+
+func (d *Dispatcher) work() {
+	for {
+		d.mu.Lock()
+		for len(d.ready) == 0 && !d.stopped {
+			d.cond.Wait()
+		}
+		if d.stopped {
+			d.mu.Unlock()
+			return
+		}
+		key := d.ready[0]
+		d.ready = d.ready[1:]
+		handler := d.queues[key][0]
+		d.queues[key] = d.queues[key][1:]
+		d.busy[key] = true
+		d.mu.Unlock()
+
+		handler(d.ctx)
+
+		d.mu.Lock()
+		delete(d.busy, key)
+		d.pending--
+		if len(d.queues[key]) > 0 {
+			d.ready = append(d.ready, key)
+		} else {
+			delete(d.queues, key)
+		}
+		// Wakes another worker for the requeued key and Drain when nothing is left.
+		d.cond.Broadcast()
+		d.mu.Unlock()
+	}
+}
+
+// Drain stops accepting handlers and waits for the queued and running ones. When timeout expires first,
+// the queued handlers are dropped, the context of the running ones is cancelled, and Drain reports how
+// many did not finish without waiting for them. A timeout of 0 means DefaultDrainTimeout.
+func (d *Dispatcher) Drain(timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		d.mu.Lock()
+		for d.pending > 0 {
+			d.cond.Wait()
+		}
+		d.mu.Unlock()
+		close(finished)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-finished:
+	case <-timer.C:
+		d.mu.Lock()
+		err = fmt.Errorf("dispatch: %d handlers unfinished after %s", d.pending, timeout)
+		for key, queue := range d.queues {
+			d.pending -= len(queue)
+			if !d.busy[key] {
+				delete(d.queues, key)
+			} else {
+				d.queues[key] = nil
+			}
+		}
+		d.ready = nil
+		d.mu.Unlock()
+	}
+	d.cancel()
+	d.mu.Lock()
+	d.stopped = true
+	d.cond.Broadcast()
+	d.mu.Unlock()
+	return err
+}
@@ -0,0 +1,108 @@
+package dispatch
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	tests := []struct {
+		name      string
+		work      time.Duration // How long the handler runs unless cancelled
+		timeout   time.Duration
+		wantErr   string
+		wantEnded bool // The handler ran to completion
+	}{
+		{name: "handler finishes", work: 20 * time.Millisecond, timeout: time.Second, wantEnded: true},
+		{name: "timeout cancels the handler", work: time.Minute, timeout: 20 * time.Millisecond, wantErr: "2 handlers unfinished"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent, cancel := context.WithCancel(context.Background())
+			d := New(parent, 1)
+			ended := make(chan bool, 1)
+			started := make(chan struct{})
+			var queuedRan atomic.Bool
+			d.Submit(1, func(ctx context.Context) {
+				close(started)
+				select {
+				case <-time.After(tt.work):
+					ended <- true
+				case <-ctx.Done():
+					ended <- false
+				}
+			})
+			d.Submit(1, func(context.Context) { queuedRan.Store(true) })
+			<-started
+			cancel() // The shutdown signal must not reach the handler.
+
+			err := d.Drain(tt.timeout)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+			if got := <-ended; got != tt.wantEnded {
+				t.Fatalf("handler completed = %t, want %t", got, tt.wantEnded)
+			}
+			if queuedRan.Load() != tt.wantEnded {
+				t.Fatalf("queued handler ran = %t, want %t", queuedRan.Load(), tt.wantEnded)
+			}
+		})
+	}
+}
+
+func TestSubmitAfterDrain(t *testing.T) {
+	d := New(context.Background(), 1)
+	if err := d.Drain(time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Submit(1, func(context.Context) { t.Error("handler ran after Drain") }) {
+		t.Fatalf("Submit accepted a handler after Drain")
+	}
+}
+
+func TestSubmitOrdersPerKey(t *testing.T) {
+	const workers, keys, perKey = 3, 6, 50
+	d := New(context.Background(), workers)
+
+	var mu sync.Mutex
+	order := make(map[int64][]int)
+	var running, peak atomic.Int32
+	for i := range perKey {
+		for key := range int64(keys) {
+			d.Submit(key, func(context.Context) {
+				if n := running.Add(1); n > peak.Load() {
+					peak.Store(n)
+				}
+				mu.Lock()
+				order[key] = append(order[key], i)
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+			})
+		}
+	}
+	if err := d.Drain(5 * time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if peak.Load() > workers {
+		t.Fatalf("%d handlers ran at once, want at most %d", peak.Load(), workers)
+	}
+	for key := range int64(keys) {
+		if len(order[key]) != perKey {
+			t.Fatalf("key %d ran %d handlers, want %d", key, len(order[key]), perKey)
+		}
+		for i, got := range order[key] {
+			if got != i {
+				t.Fatalf("key %d ran handler %d at position %d", key, got, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+)
+
+// utf8BOM makes Excel read the file as UTF-8; without it Cyrillic text comes out garbled.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// CSV encodes the table as comma-separated UTF-8 with a byte order mark.
+func CSV(table Table) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(utf8BOM)
+	w := csv.NewWriter(&buf)
+	if err := w.Write(table.Header); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(table.Rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+// Package export renders a user's saved records as a downloadable file: a CSV table, XLSX for
+// spreadsheet apps that mangle CSV encodings, or a print-friendly HTML page.
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+	FormatHTML Format = "html"
+)
+
+// DateLayout formats the creation time column.
+const DateLayout = "02.01.2006 15:04"
+
+// ParseFormat returns the format named s; empty means CSV.
+func ParseFormat(s string) (Format, bool) {
+	switch Format(strings.ToLower(strings.TrimSpace(s))) {
+	case "", FormatCSV:
+		return FormatCSV, true
+	case FormatXLSX:
+		return FormatXLSX, true
+	case FormatHTML:
+		return FormatHTML, true
+	default:
+		return "", false
+	}
+}
+
+// Table is the tabular view of records: one column per question and one row per record.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// BuildTable lays out records in the given order. Columns are the creation time followed by every
+// question, sections in their configured order as in forwarded messages; unanswered questions are empty cells.
+// When a record has notes, a last column lists them one per line.
+func BuildTable(recordConfig *config.RecordConfig, records []*state.Record) Table {
+	sectionIDs := recordConfig.SectionIDs()
+
+	var questions []config.QuestionConfig
+	table := Table{Header: []string{"Дата записи"}}
+	for _, sectionID := range sectionIDs {
+		section := recordConfig.Sections[sectionID]
+		for _, q := range section.Questions {
+			questions = append(questions, q)
+			table.Header = append(table.Header, fmt.Sprintf("%s: %s", section.Title, q.Prompt))
+		}
+	}
+
+	withNotes := false
+	for _, record := range records {
+		withNotes = withNotes || len(record.Notes) > 0
+	}
+	if withNotes {
+		table.Header = append(table.Header, "Заметки")
+	}
+
+	for _, record := range records {
+		row := make([]string, 0, len(table.Header))
+		row = append(row, record.CreatedAt.Format(DateLayout))
+		for _, q := range questions {
+			row = append(row, record.GetString(q))
+		}
+		if withNotes {
+			row = append(row, notesText(record.Notes))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// notesText puts each note on its own line after its time.
+func notesText(notes []state.Note) string {
+	lines := make([]string, 0, len(notes))
+	for _, note := range notes {
+		lines = append(lines, note.At.Format(DateLayout)+": "+note.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Render encodes records in format: the table of records for CSV and XLSX, one block per record for
+// HTML. It returns the file name to send the data under.
+func Render(format Format, recordConfig *config.RecordConfig, records []*state.Record, now time.Time) (string, []byte, error) {
+	var data []byte
+	var err error
+	switch format {
+	case FormatCSV:
+		data, err = CSV(BuildTable(recordConfig, records))
+	case FormatXLSX:
+		data, err = XLSX(BuildTable(recordConfig, records))
+	case FormatHTML:
+		data, err = HTML(recordConfig, records, now)
+	default:
+		return "", nil, fmt.Errorf("unknown export format %q", format)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("export %s: %w", format, err)
+	}
+	return fmt.Sprintf("records-%s.%s", now.Format("2006-01-02"), format), data, nil
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func testRecords() (*config.RecordConfig, []*state.Record) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"b_evening": {Title: "Вечер", Questions: []config.QuestionConfig{{ID: "q2", Prompt: "Итог", Type: "text", StoreKey: "summary"}}},
+		"a_morning": {Title: "Утро", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Настроение", Type: "text", StoreKey: "mood"}}},
+	}}
+	first := state.NewRecord()
+	first.CreatedAt = time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	first.Data["mood"] = state.StringAnswer("хорошо, спокойно")
+	second := state.NewRecord()
+	second.CreatedAt = time.Date(2025, 3, 2, 21, 0, 0, 0, time.UTC)
+	second.Data["summary"] = state.StringAnswer("строка 1\nстрока 2 <&>")
+	return rc, []*state.Record{first, second}
+}
+
+func TestCSV(t *testing.T) {
+	rc, records := testRecords()
+	name, data, err := Render(FormatCSV, rc, records, time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if name != "records-2025-03-03.csv" {
+		t.Fatalf("name = %q", name)
+	}
+	if !bytes.HasPrefix(data, utf8BOM) {
+		t.Fatalf("CSV must start with a UTF-8 BOM")
+	}
+	rows, err := csv.NewReader(bytes.NewReader(data[len(utf8BOM):])).ReadAll()
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	want := [][]string{
+		{"Дата записи", "Утро: Настроение", "Вечер: Итог"},
+		{"01.03.2025 09:30", "хорошо, спокойно", ""},
+		{"02.03.2025 21:00", "", "строка 1\nстрока 2 <&>"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Fatalf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	rc, records := testRecords()
+	name, data, err := Render(FormatXLSX, rc, records, time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.HasSuffix(name, ".xlsx") {
+		t.Fatalf("name = %q", name)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		body, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(body)
+	}
+	for _, part := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if _, ok := parts[part]; !ok {
+			t.Fatalf("missing part %s", part)
+		}
+	}
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{`<c r="B2" t="inlineStr"><is><t xml:space="preserve">хорошо, спокойно</t>`, `<c r="C3"`, "строка 2 &lt;&amp;&gt;"} {
+		if !strings.Contains(sheet, want) {
+			t.Fatalf("sheet lacks %q:\n%s", want, sheet)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	rc, records := testRecords()
+	rc.Sections["a_morning"] = config.SectionConfig{Title: "Утро", Questions: []config.QuestionConfig{
+		{ID: "q1", Prompt: "Настроение", Type: "text", StoreKey: "mood"},
+		{ID: "q3", Prompt: "Что радовало", Type: "multi_buttons", StoreKey: "joys"},
+		{ID: "q4", Prompt: "Мысли", Type: "scored", StoreKey: "thoughts"},
+		{ID: "q5", Prompt: "Почему?", Type: "text", StoreKey: "why", ShowIf: &config.ShowIfConfig{StoreKey: "mood", Values: []string{"плохо"}}},
+	}}
+	records[0].ID = "7KQ2M9XD4H"
+	records[0].Data["joys"] = state.ListAnswer("прогулка", "книга")
+	records[0].Data["thoughts"] = state.ScoredAnswer(state.ScoredEntry{Text: "всё получится", Score: 7})
+	records[0].Flag("mood", "phone")
+	records[1].AddNote(time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC), "выспалась <3")
+
+	name, data, err := Render(FormatHTML, rc, records, time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if name != "records-2025-03-03.html" {
+		t.Fatalf("name = %q", name)
+	}
+	page := string(data)
+	for _, want := range []string{
+		"<!DOCTYPE html>", "@media print", "page-break-before",
+		"<h2>01.03.2025 09:30 <span class=\"id\">#7KQ2M9XD4H</span></h2>",
+		"<h3>Утро</h3>", "<dd>хорошо, спокойно</dd>", "⚠️ phone",
+		"<li>прогулка</li><li>книга</li>",
+		"<li>всё получится<span class=\"score\">7</span></li>",
+		"<dd class=\"empty\">—</dd>",
+		"строка 2 &lt;&amp;&gt;",
+		"<h3>Заметки</h3>", "<dt>03.03.2025 08:00</dt>\n<dd>выспалась &lt;3</dd>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("page lacks %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "Почему?") {
+		t.Fatalf("questions hidden by show_if must be left out")
+	}
+	if strings.Count(page, `<section class="record">`) != 2 {
+		t.Fatalf("expected one block per record")
+	}
+	if strings.Count(page, "<h3>Заметки</h3>") != 1 {
+		t.Fatalf("only records with notes get the notes block")
+	}
+}
+
+func TestBuildTableNotes(t *testing.T) {
+	tests := []struct {
+		name       string
+		notes      []state.Note
+		wantHeader string
+		wantNotes  []string // Last cell of each row; nil without a notes column
+	}{
+		{name: "no notes", wantHeader: "Вечер: Итог"},
+		{
+			name: "notes",
+			notes: []state.Note{
+				{At: time.Date(2025, 3, 2, 22, 0, 0, 0, time.UTC), Text: "позже"},
+				{At: time.Date(2025, 3, 3, 8, 0, 0, 0, time.UTC), Text: "утром"},
+			},
+			wantHeader: "Заметки",
+			wantNotes:  []string{"", "02.03.2025 22:00: позже\n03.03.2025 08:00: утром"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, records := testRecords()
+			records[1].Notes = tt.notes
+			table := BuildTable(rc, records)
+			if got := table.Header[len(table.Header)-1]; got != tt.wantHeader {
+				t.Fatalf("last column = %q, want %q", got, tt.wantHeader)
+			}
+			for i, want := range tt.wantNotes {
+				if got := table.Rows[i][len(table.Rows[i])-1]; got != want {
+					t.Fatalf("row %d notes = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   Format
+		wantOK bool
+	}{
+		{in: "", want: FormatCSV, wantOK: true},
+		{in: " XLSX ", want: FormatXLSX, wantOK: true},
+		{in: "html", want: FormatHTML, wantOK: true},
+		{in: "pdf"},
+	}
+	for _, tt := range tests {
+		got, ok := ParseFormat(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Fatalf("ParseFormat(%q) = %q, %t", tt.in, got, ok)
+		}
+	}
+	if got := xlsxColumn(27); got != "AB" {
+		t.Fatalf("xlsxColumn(27) = %q, want AB", got)
+	}
+}
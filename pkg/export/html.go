@@ -0,0 +1,150 @@
+package export
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/idgen"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// htmlPage is the data of the HTML template.
+type htmlPage struct {
+	Generated string
+	Records   []htmlRecord
+}
+
+type htmlRecord struct {
+	ID       string // Short code; empty for records without an ID
+	Created  string
+	Sections []htmlSection
+	Notes    []htmlNote
+}
+
+// htmlNote is a note added to the record after saving it.
+type htmlNote struct {
+	At   string
+	Text string
+}
+
+type htmlSection struct {
+	Title string
+	Items []htmlItem
+}
+
+// htmlItem is one question with its answer in the form that suits its kind.
+type htmlItem struct {
+	Prompt string
+	Text   string              // Single-line or multi-line answers
+	List   []string            // List answers
+	Scored []state.ScoredEntry // Entries with a score
+	Flags  string              // Content filter findings
+}
+
+var htmlTpl = template.Must(template.New("records").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Записи дневника</title>
+<style>
+body { font-family: Georgia, "Times New Roman", serif; font-size: 12pt; color: #000; max-width: 48em; margin: 2em auto; padding: 0 1em; }
+h1 { font-size: 16pt; margin: 0 0 .2em; }
+.generated { color: #555; font-size: 9pt; margin-bottom: 1.5em; }
+.record { border-top: 2px solid #000; padding-top: .5em; margin-bottom: 2em; }
+.record h2 { font-size: 14pt; margin: 0 0 .5em; }
+.record h2 .id { font-family: "Courier New", monospace; font-size: 10pt; color: #555; }
+h3 { font-size: 12pt; margin: 1em 0 .3em; border-bottom: 1px solid #999; }
+dl { margin: 0; }
+dt { font-weight: bold; margin-top: .5em; }
+dd { margin: .1em 0 0 1.5em; white-space: pre-wrap; }
+dd.empty { color: #777; }
+dd ul { margin: 0; padding-left: 1.2em; }
+.score { font-family: "Courier New", monospace; border: 1px solid #000; border-radius: 3px; padding: 0 .3em; margin-left: .4em; }
+.flags { color: #8a4b00; font-size: 10pt; }
+@page { margin: 2cm; }
+@media print {
+	body { margin: 0; max-width: none; }
+	.record { page-break-inside: avoid; }
+	.record + .record { page-break-before: always; }
+}
+</style>
+</head>
+<body>
+<h1>Записи дневника</h1>
+<div class="generated">Сформировано {{.Generated}}</div>
+{{range .Records}}<section class="record">
+<h2>{{.Created}}{{if .ID}} <span class="id">#{{.ID}}</span>{{end}}</h2>
+{{range .Sections}}<h3>{{.Title}}</h3>
+<dl>
+{{range .Items}}<dt>{{.Prompt}}</dt>
+{{if .List}}<dd><ul>{{range .List}}<li>{{.}}</li>{{end}}</ul></dd>
+{{else if .Scored}}<dd><ul>{{range .Scored}}<li>{{.Text}}<span class="score">{{.Score}}</span></li>{{end}}</ul></dd>
+{{else if .Text}}<dd>{{.Text}}</dd>
+{{else}}<dd class="empty">—</dd>
+{{end}}{{if .Flags}}<dd class="flags">⚠️ {{.Flags}}</dd>
+{{end}}{{end}}</dl>
+{{end}}{{if .Notes}}<h3>Заметки</h3>
+<dl>
+{{range .Notes}}<dt>{{.At}}</dt>
+<dd>{{.Text}}</dd>
+{{end}}</dl>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+// HTML renders records, in the given order, as a standalone page meant for printing and archiving:
+// one block per record, printed on its own page, with every shown question of every section (in
+// section order as in forwarded messages). List answers become bullet lists and scored entries carry their
+// score; notes added after saving close the block.
+func HTML(recordConfig *config.RecordConfig, records []*state.Record, now time.Time) ([]byte, error) {
+	sectionIDs := recordConfig.SectionIDs()
+
+	page := htmlPage{Generated: now.Format(DateLayout)}
+	for _, record := range records {
+		view := htmlRecord{Created: record.CreatedAt.Format(DateLayout)}
+		if record.ID != "" {
+			view.ID = idgen.ShortCode(record.ID)
+		}
+		for _, sectionID := range sectionIDs {
+			sectionConf := recordConfig.Sections[sectionID]
+			section := htmlSection{Title: sectionConf.Title}
+			for _, q := range sectionConf.Questions {
+				if !record.Visible(q) {
+					continue
+				}
+				section.Items = append(section.Items, htmlAnswer(record, q))
+			}
+			if len(section.Items) > 0 {
+				view.Sections = append(view.Sections, section)
+			}
+		}
+		for _, note := range record.Notes {
+			view.Notes = append(view.Notes, htmlNote{At: note.At.Format(DateLayout), Text: note.Text})
+		}
+		page.Records = append(page.Records, view)
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTpl.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func htmlAnswer(record *state.Record, q config.QuestionConfig) htmlItem {
+	item := htmlItem{Prompt: q.Prompt, Flags: strings.Join(record.Flags[q.StoreKey], ", ")}
+	answer := record.Answer(q)
+	switch answer.Kind {
+	case state.AnswerList:
+		item.List = answer.List
+	case state.AnswerScored:
+		item.Scored = answer.Scored
+	default:
+		item.Text = answer.String()
+	}
+	return item
+}
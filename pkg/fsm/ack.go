@@ -0,0 +1,166 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// forwardToTherapist sends the latest record to the therapist and tells the user how it went.
+func forwardToTherapist(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, targetUserID int64, store *state.Store, requireAck bool) {
+	record := selectRecordForForward(userState, store)
+	if record == nil {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNoAnswersToSend), nil)
+		return
+	}
+
+	if _, err := sendDelivery(ctx, botPort, recordConfig, userState, record, targetUserID, store, requireAck); err != nil {
+		_, _ = botPort.SendMessage(ctx, chatID, deliveryFailureText(userState, err), nil)
+		return
+	}
+
+	confirmation := tr(userState, config.MsgSentToTarget, targetUserID)
+	if requireAck {
+		confirmation = tr(userState, config.MsgSentToTargetPendingAck, targetUserID)
+	}
+	_, _ = botPort.SendMessage(ctx, chatID, confirmation, nil)
+}
+
+// sendDelivery forwards record to targetUserID and logs the attempt, successful or not, in the store's
+// deliveries table. The message carries a "reply" button; with requireAck also a "received" one, and the
+// patient's answers stay until acknowledgeDelivery runs.
+func sendDelivery(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, userState *state.UserState, record *state.Record, targetUserID int64, store *state.Store, requireAck bool) (*state.Delivery, error) {
+	delivery := &state.Delivery{
+		ID:       idGenerator.NewID(),
+		UserID:   userState.UserID,
+		RecordID: deliveryRecordID(record),
+		TargetID: targetUserID,
+		SentAt:   time.Now(),
+	}
+	ackID := ""
+	if requireAck {
+		ackID = delivery.ID
+	}
+	msg, err := deliverRecord(ctx, botPort, recordConfig, userState, record, targetUserID, store, therapistKeyboard(store.Lang(targetUserID), userState.UserID, ackID), markRetrying(store, delivery))
+	if err != nil {
+		slog.ErrorContext(ctx, "delivering the record failed", "err", err)
+		delivery.Status = state.DeliveryFailed
+		delivery.Error = err.Error()
+		store.AddDelivery(delivery)
+		return delivery, err
+	}
+	delivery.Status = state.DeliveryDelivered
+	delivery.MessageID, delivery.PartIDs = msg.MessageID, msg.PartIDs
+	store.AddDelivery(delivery)
+	slog.InfoContext(ctx, "delivery sent", "delivery_id", delivery.ID, "user_id", userState.UserID, "target_id", targetUserID, "ack", requireAck)
+	return delivery, nil
+}
+
+// deliveryRecordID returns the ID a delivery refers to record by. A forwarded draft gets its ID now
+// and keeps it when saved, so the acknowledgement finds it either way.
+func deliveryRecordID(record *state.Record) string {
+	if record.ID == "" {
+		record.ID = idGenerator.NewID()
+	}
+	return record.ID
+}
+
+// markRetrying shows deliveries as retrying while the transport waits out a rate limit to send them.
+// The store gets copies, as the deliveries are only added for good once the send is over.
+func markRetrying(store *state.Store, deliveries ...*state.Delivery) botport.SendOption {
+	return botport.OnRetry(func(err error) {
+		for _, delivery := range deliveries {
+			retrying := *delivery
+			retrying.Status = state.DeliveryRetrying
+			retrying.Error = err.Error()
+			store.AddDelivery(&retrying)
+		}
+	})
+}
+
+// acknowledgeDelivery handles the therapist's "received" tap: the delivery, and any other delivery of the
+// same message, is marked acknowledged, the forwarded records are cleared from the patient, and both
+// sides are told.
+func acknowledgeDelivery(ctx context.Context, query *botport.InboundEvent, therapist *state.UserState, botPort botport.BotPort, store *state.Store, deliveryID string) {
+	if store == nil {
+		slog.WarnContext(ctx, "no store to acknowledge the delivery", "delivery_id", deliveryID)
+		return
+	}
+	delivery, ok := store.Delivery(deliveryID)
+	if !ok || delivery.TargetID != therapist.UserID {
+		slog.WarnContext(ctx, "user cannot acknowledge the delivery", "user_id", therapist.UserID, "delivery_id", deliveryID)
+		_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(therapist, config.MsgActionUnavailable))
+		return
+	}
+	if _, err := store.AckDelivery(deliveryID, time.Now()); err != nil {
+		slog.ErrorContext(ctx, "acknowledging the delivery failed", "err", err)
+		_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(therapist, config.MsgAlreadyAcknowledged))
+		return
+	}
+	slog.InfoContext(ctx, "delivery acknowledged", "delivery_id", deliveryID, "therapist_id", therapist.UserID)
+	// A digest carries several deliveries behind one button; acknowledge them together.
+	recordIDs := []string{delivery.RecordID}
+	for _, sibling := range store.MessageDeliveries(delivery.TargetID, delivery.MessageID) {
+		if sibling.ID == deliveryID || sibling.UserID != delivery.UserID {
+			continue
+		}
+		if _, err := store.AckDelivery(sibling.ID, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "acknowledging a sibling delivery failed", "err", err)
+			continue
+		}
+		recordIDs = append(recordIDs, sibling.RecordID)
+	}
+
+	if delivery.UserID == therapist.UserID {
+		releasePatient(ctx, therapist, botPort, store, recordIDs)
+	} else {
+		// The patient is locked only once the therapist's Mu is released.
+		whenUnlocked(ctx, func() {
+			patient, ok := store.LockUser(delivery.UserID)
+			if !ok {
+				slog.WarnContext(ctx, "patient of the delivery is gone", "user_id", delivery.UserID, "delivery_id", deliveryID)
+				return
+			}
+			defer patient.Mu.Unlock()
+			releasePatient(ctx, patient, botPort, store, recordIDs)
+			syncUser(store, patient)
+		})
+	}
+
+	text := query.Text + "\n\n" + tr(therapist, config.MsgAckConfirmed)
+	emptyKeyboard := botport.NewKeyboard()
+	if _, err := botPort.EditMessage(ctx, query.ChatID, query.MessageID, text, emptyKeyboard); err != nil && !botport.IsCode(err, "message_not_modified") {
+		slog.ErrorContext(ctx, "updating the forwarded message failed", "message_id", query.MessageID, "err", err)
+	}
+}
+
+// releasePatient drops the acknowledged records from the patient and tells them. Caller must hold
+// patient.Mu.
+func releasePatient(ctx context.Context, patient *state.UserState, botPort botport.BotPort, store *state.Store, recordIDs []string) {
+	for _, id := range recordIDs {
+		releaseAcknowledgedRecord(patient, store, id)
+	}
+	_, _ = botPort.SendMessage(ctx, patient.UserID, tr(patient, config.MsgAckPatientNotice), nil)
+}
+
+// releaseAcknowledgedRecord drops the acknowledged record id from the patient, whether it is in memory
+// or only in the backend. A draft is dropped only while the patient is not editing it, so an ongoing
+// survey is never cut short.
+func releaseAcknowledgedRecord(patient *state.UserState, store *state.Store, id string) {
+	if id == "" {
+		return
+	}
+	if draft := patient.CurrentRecord; draft != nil && draft.ID == id {
+		if patient.RecordFSM.Current() == StateRecordIdle {
+			patient.CurrentRecord = nil
+		}
+		return
+	}
+	if err := store.DeleteRecord(patient.UserID, id); err != nil {
+		slog.Error("deleting the acknowledged record failed", "user_id", patient.UserID, "record_id", id, "err", err)
+	}
+}
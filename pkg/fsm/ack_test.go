@@ -0,0 +1,85 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestForwardWaitsForTherapistAck(t *testing.T) {
+	config.SetTargetUserID(500)
+	if err := config.SetFeature(config.FeatureRequireAck, true); err != nil {
+		t.Fatalf("enable flag: %v", err)
+	}
+	defer func() { _ = config.SetFeature(config.FeatureRequireAck, false) }()
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+
+	adapter := &fakeadapter.FakeAdapter{}
+	store := newTestHandler(adapter, rc).Store()
+	patient := store.GetOrCreateUserState(10, "Patient")
+	therapist := store.GetOrCreateUserState(500, "Therapist")
+	stranger := store.GetOrCreateUserState(11, "Stranger")
+	record := state.NewRecord()
+	record.Data["f1"] = state.StringAnswer("Value")
+	record.IsSaved = true
+	patient.Records = []*state.Record{record}
+
+	handleForwardAnsweredSections(context.Background(), patient, adapter, rc, 10, store)
+
+	if len(patient.Records) != 1 {
+		t.Fatalf("record must be kept until acknowledged")
+	}
+	pending := store.PendingDeliveries(10)
+	if len(pending) != 1 || pending[0].TargetID != 500 {
+		t.Fatalf("pending deliveries = %+v", pending)
+	}
+	forwarded := adapter.Calls[0]
+	ackData := CallbackAckPrefix + pending[0].ID
+	if forwarded.ChatID != 500 || !forwarded.Keyboard.HasCallback(ackData) {
+		t.Fatalf("forward must carry the ack button, got %+v", forwarded)
+	}
+
+	tests := []struct {
+		name        string
+		user        *state.UserState
+		wantRecords int
+		wantAnswer  string
+	}{
+		{name: "stranger cannot acknowledge", user: stranger, wantRecords: 1, wantAnswer: "Действие недоступно."},
+		{name: "therapist acknowledges", user: therapist, wantRecords: 0},
+		{name: "second tap is ignored", user: therapist, wantRecords: 0, wantAnswer: "Уже подтверждено."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter.Calls = nil
+			query := callbackQuery(tt.user.UserID, forwarded.MessageID, ackData)
+
+			handleCallbackQuery(context.Background(), query, tt.user, adapter, rc, store)
+
+			if len(patient.Records) != tt.wantRecords {
+				t.Fatalf("patient records = %d, want %d", len(patient.Records), tt.wantRecords)
+			}
+			if tt.wantAnswer != "" {
+				if call := adapter.LastCall("answer_callback"); call == nil || call.Text != tt.wantAnswer {
+					t.Fatalf("callback answer = %+v, want %q", call, tt.wantAnswer)
+				}
+				return
+			}
+			notified := false
+			for _, call := range adapter.Calls {
+				if call.ChatID == 10 && strings.Contains(call.Text, "подтвердил получение") {
+					notified = true
+				}
+			}
+			if !notified {
+				t.Fatalf("patient must be told about the acknowledgment, calls: %+v", adapter.Calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/buildinfo"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logbuffer"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logging"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// isAdmin reports whether userID may use operator commands; the admin is the configured TARGET_USER_ID.
+func isAdmin(userID int64) bool {
+	target := config.GetTargetUserID()
+	return target != 0 && userID == target
+}
+
+// handleAdminCommand serves operator-only commands and reports whether the message was consumed.
+// HandleUpdate routes every message through it first; non-admins and other messages fall through to
+// regular handling, so admin commands stay invisible to users.
+func handleAdminCommand(ctx context.Context, message *botport.InboundEvent, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) bool {
+	if !message.IsCommand() || !isAdmin(userState.UserID) {
+		return false
+	}
+	chatID := message.ChatID
+
+	switch message.Command {
+	case "admin":
+		handleAdminSubcommand(ctx, chatID, userState, strings.Fields(message.Args), botPort, recordConfig, store)
+		return true
+	case "version":
+		slog.InfoContext(ctx, "admin requested version", "admin_id", userState.UserID)
+		_, _ = botPort.SendMessage(ctx, chatID, fmt.Sprintf("Версия: %s\nКоммит: %s\nСборка: %s", buildinfo.Version, buildinfo.Commit, buildinfo.BuildTime), nil)
+		return true
+	case "stats", "users", "broadcast":
+		if store == nil {
+			_, _ = botPort.SendMessage(ctx, chatID, "Хранилище недоступно.", nil)
+			return true
+		}
+		switch message.Command {
+		case "stats":
+			whenUnlocked(ctx, func() { _, _ = botPort.SendMessage(ctx, chatID, renderStats(store, time.Now()), nil) })
+		case "users":
+			whenUnlocked(ctx, func() { _, _ = botPort.SendMessage(ctx, chatID, renderUsers(store, time.Now()), nil) })
+		default:
+			text := strings.TrimSpace(message.Args)
+			if text == "" {
+				_, _ = botPort.SendMessage(ctx, chatID, "Использование: /broadcast <текст>", nil)
+				return true
+			}
+			_, _ = botPort.SendMessage(ctx, chatID, broadcast(ctx, userState, botPort, store, text), nil)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// handleAdminSubcommand dispatches "/admin <subcommand> [args...]".
+func handleAdminSubcommand(ctx context.Context, chatID int64, userState *state.UserState, args []string, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
+	if len(args) == 0 {
+		_, _ = botPort.SendMessage(ctx, chatID, adminHelpText, nil)
+		return
+	}
+	slog.InfoContext(ctx, "admin command", "admin_id", userState.UserID, "args", strings.Join(args, " "))
+
+	switch args[0] {
+	case "flags":
+		_, _ = botPort.SendMessage(ctx, chatID, renderFeatureFlags(), nil)
+	case "flag":
+		if len(args) != 3 || (args[2] != "on" && args[2] != "off") {
+			_, _ = botPort.SendMessage(ctx, chatID, "Использование: /admin flag <name> on|off", nil)
+			return
+		}
+		if err := config.SetFeature(config.Feature(args[1]), args[2] == "on"); err != nil {
+			_, _ = botPort.SendMessage(ctx, chatID, fmt.Sprintf("Ошибка: %v", err), nil)
+			return
+		}
+		_, _ = botPort.SendMessage(ctx, chatID, renderFeatureFlags(), nil)
+	case "preview":
+		if len(args) > 2 {
+			_, _ = botPort.SendMessage(ctx, chatID, "Использование: /admin preview [section]", nil)
+			return
+		}
+		sectionID := ""
+		if len(args) == 2 {
+			sectionID = args[1]
+		}
+		startPreview(ctx, chatID, userState, sectionID, botPort, recordConfig, store)
+	case "reload":
+		changed, err := config.ReloadConfig(TransportCheck(botPort))
+		switch {
+		case err != nil:
+			_, _ = botPort.SendMessage(ctx, chatID, fmt.Sprintf("Ошибка: %v\nРаботает прежняя конфигурация.", err), nil)
+		case !changed:
+			_, _ = botPort.SendMessage(ctx, chatID, "Конфигурация не изменилась.", nil)
+		default:
+			_, _ = botPort.SendMessage(ctx, chatID, fmt.Sprintf("Конфигурация перезагружена: %d секций.", len(config.GetConfig().Sections)), nil)
+		}
+	case "user":
+		adminID := userState.UserID
+		whenUnlocked(ctx, func() { _, _ = botPort.SendMessage(ctx, chatID, inspectUser(adminID, args[1:], store), nil) })
+	case "logs":
+		_, _ = botPort.SendMessage(ctx, chatID, renderLogTail(args[1:]), nil)
+	case "stats":
+		if store == nil {
+			_, _ = botPort.SendMessage(ctx, chatID, "Хранилище недоступно.", nil)
+			return
+		}
+		whenUnlocked(ctx, func() { _, _ = botPort.SendMessage(ctx, chatID, renderStoreStats(store.Stats()), nil) })
+	case "relink":
+		if len(args) != 3 {
+			_, _ = botPort.SendMessage(ctx, chatID, "Использование: /admin relink <старый ID> <новый ID>", nil)
+			return
+		}
+		oldID, errOld := strconv.ParseInt(args[1], 10, 64)
+		newID, errNew := strconv.ParseInt(args[2], 10, 64)
+		if errOld != nil || errNew != nil {
+			_, _ = botPort.SendMessage(ctx, chatID, "Использование: /admin relink <старый ID> <новый ID>", nil)
+			return
+		}
+		adminID := userState.UserID
+		whenUnlocked(ctx, func() {
+			_, _ = botPort.SendMessage(ctx, chatID, relinkUser(ctx, adminID, botPort, store, oldID, newID), nil)
+		})
+	case "assign":
+		adminID := userState.UserID
+		whenUnlocked(ctx, func() { _, _ = botPort.SendMessage(ctx, chatID, assignTherapist(adminID, args[1:], store), nil) })
+	case "transcript":
+		handleTranscriptCommand(ctx, userState, botPort, chatID, store, args[1:])
+	default:
+		_, _ = botPort.SendMessage(ctx, chatID, adminHelpText, nil)
+	}
+}
+
+const (
+	defaultLogTail   = 20
+	maxLogLineLength = 200
+	maxLogReplySize  = 3500
+)
+
+// renderLogTail parses "[N] [level=warn] [user=<id>]" and formats matching entries from the log ring buffer.
+func renderLogTail(args []string) string {
+	filter := logbuffer.Filter{Limit: defaultLogTail, MinLevel: slog.LevelDebug}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		switch {
+		case !ok:
+			if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+				filter.Limit = n
+			}
+		case key == "level":
+			if level, err := logging.ParseLevel(value); err == nil {
+				filter.MinLevel = level
+			}
+		case key == "user":
+			filter.UserID = value
+		}
+	}
+
+	entries := logbuffer.Default.Tail(filter)
+	if len(entries) == 0 {
+		return "Нет подходящих записей в журнале."
+	}
+	// Walk from the newest entry so the most recent lines survive the reply size cap.
+	lines := make([]string, 0, len(entries))
+	size := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		line := fmt.Sprintf("%s [%s] %s", e.Time.Format("15:04:05"), strings.ToLower(e.Level.String()), truncateString(e.Message, maxLogLineLength))
+		if size+len(line) > maxLogReplySize {
+			break
+		}
+		size += len(line) + 1
+		lines = append(lines, line)
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+const adminHelpText = `Команды администратора:
+/version — версия сборки
+/stats — пользователи и записи за сегодня
+/users — пользователи и число их записей
+/broadcast <текст> — сообщение всем пользователям
+/admin flags — список флагов
+/admin flag <name> on|off — переключить флаг
+/admin preview [section] — пройти анкету или одну секцию так, как её видят пользователи, без сохранения
+/admin reload — перечитать record_config.yaml
+/admin user <id> [reset|cleardraft] — состояние пользователя и ремонт
+/admin logs [N] [level=warn|error] [user=<id>] — последние записи журнала
+/admin stats — объём хранилища и оценка памяти
+/admin relink <старый ID> <новый ID> — перенести записи пользователя на новый аккаунт
+/admin assign <ID пользователя> <ID терапевта|default> — кому отправляются записи пользователя
+/admin transcript <ID пользователя> [N|file] — последние сообщения переписки с пользователем или файл`
+
+func renderFeatureFlags() string {
+	var b strings.Builder
+	b.WriteString("Флаги:\n")
+	for _, f := range config.FeatureNames() {
+		mark := "⚪️"
+		if config.FeatureEnabled(f) {
+			mark = "🟢"
+		}
+		fmt.Fprintf(&b, "%s %s\n", mark, f)
+	}
+	return b.String()
+}
+
+// previewLabel heads the section menu and every prompt of a preview.
+const previewLabel = "👁 Предпросмотр"
+
+// startPreview runs the survey, or only sectionID when set, in the admin chat against a throwaway
+// record; the real draft is parked in PreviewBackup and restored by enterRecordIdle once the preview
+// ends.
+func startPreview(ctx context.Context, chatID int64, userState *state.UserState, sectionID string, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
+	if _, ok := recordConfig.Sections[sectionID]; sectionID != "" && !ok {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgSectionNotFound), nil)
+		return
+	}
+	if userState.RecordFSM.Current() != StateRecordIdle {
+		_, _ = botPort.SendMessage(ctx, chatID, "Сначала завершите текущую запись.", nil)
+		return
+	}
+
+	slog.InfoContext(ctx, "admin previews section", "admin_id", userState.UserID, "section", sectionID)
+	userState.PreviewBackup = userState.CurrentRecord
+	userState.CurrentRecord = newDraft()
+	userState.Preview = true
+	_, _ = botPort.SendMessage(ctx, chatID, previewLabel+": ответы не будут сохранены или отправлены.", nil)
+	if sectionID != "" {
+		openSectionDirectly(ctx, userState, botPort, recordConfig, store, chatID, sectionID)
+		return
+	}
+	userState.CurrentSection = ""
+	userState.CurrentQuestion = 0
+	if err := userState.RecordFSM.Event(ctx, EventStartRecord, recordEvent{User: userState, ChatID: chatID}); err != nil {
+		slog.ErrorContext(ctx, "starting the preview failed", "admin_id", userState.UserID, "err", err)
+		finishPreview(userState)
+	}
+}
+
+// finishPreview drops the throwaway record and restores the admin's real draft.
+func finishPreview(userState *state.UserState) {
+	userState.CurrentRecord = userState.PreviewBackup
+	userState.PreviewBackup = nil
+	userState.Preview = false
+}
+
+// inspectUser renders the state of a user and optionally repairs it with "reset" (force the FSMs back
+// to idle) or "cleardraft" (drop a corrupted draft). It locks the user; callers hold no user's Mu.
+func inspectUser(adminID int64, args []string, store *state.Store) string {
+	if len(args) == 0 || len(args) > 2 {
+		return "Использование: /admin user <id> [reset|cleardraft]"
+	}
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Sprintf("Некорректный ID: %s", args[0])
+	}
+
+	if store == nil {
+		return "Хранилище недоступно."
+	}
+	target, ok := store.LockUser(userID)
+	if !ok {
+		return fmt.Sprintf("Пользователь %d не найден.", userID)
+	}
+	defer target.Mu.Unlock()
+
+	if len(args) == 2 {
+		switch args[1] {
+		case "reset":
+			resetUserFlow(target)
+			slog.Info("admin reset the FSMs of a user", "admin_id", adminID, "user_id", userID)
+		case "cleardraft":
+			target.CurrentRecord = nil
+			slog.Info("admin cleared the draft of a user", "admin_id", adminID, "user_id", userID)
+		default:
+			return "Использование: /admin user <id> [reset|cleardraft]"
+		}
+	}
+	return renderUserState(target, store)
+}
+
+// resetUserFlow forces both FSMs back to idle without callbacks and clears navigation state; the draft is kept.
+func resetUserFlow(userState *state.UserState) {
+	userState.MainMenuFSM.SetState(StateIdle)
+	userState.RecordFSM.SetState(StateRecordIdle)
+	userState.CurrentSection = ""
+	userState.CurrentQuestion = 0
+	userState.SectionSnapshot = nil
+	userState.LastMessageID = 0
+	userState.ListOffset = 0
+	if userState.Preview {
+		finishPreview(userState)
+	}
+}
+
+func renderUserState(userState *state.UserState, store *state.Store) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Пользователь %d (%s)\n", userState.UserID, userState.UserName)
+	fmt.Fprintf(&b, "Главное меню: %s\n", userState.MainMenuFSM.Current())
+	fmt.Fprintf(&b, "Запись: %s\n", userState.RecordFSM.Current())
+	if userState.CurrentSection != "" {
+		fmt.Fprintf(&b, "Секция: %s, вопрос #%d\n", userState.CurrentSection, userState.CurrentQuestion)
+	}
+	fmt.Fprintf(&b, "Сохранённых записей: %d\n", savedRecordCount(userState, store))
+	if userState.Therapist != 0 {
+		fmt.Fprintf(&b, "Терапевт: %d\n", userState.Therapist)
+	}
+	if userState.CurrentRecord == nil {
+		b.WriteString("Черновик: нет")
+		return b.String()
+	}
+
+	keys := make([]string, 0, len(userState.CurrentRecord.Data))
+	for key := range userState.CurrentRecord.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(&b, "Черновик (%d полей):", len(keys))
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\n- %s: %s", key, truncateString(userState.CurrentRecord.Data[key].String(), 50))
+	}
+	return b.String()
+}
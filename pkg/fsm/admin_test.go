@@ -0,0 +1,285 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestAdminFlagToggle(t *testing.T) {
+	config.SetTargetUserID(100)
+	defer func() { _ = config.SetFeature(config.FeatureDeleteUserMessages, false) }()
+	adapter := &fakeadapter.FakeAdapter{}
+	rc := &config.RecordConfig{}
+	handler := newTestHandler(adapter, rc)
+	admin := &state.UserState{UserID: 100, MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+
+	handleAdminCommand(context.Background(), commandMessage(100, "/admin flag delete_user_messages on"), admin, adapter, rc, nil)
+
+	if !config.FeatureEnabled(config.FeatureDeleteUserMessages) {
+		t.Fatalf("expected flag enabled by admin command")
+	}
+	if call := adapter.LastCall("send_message"); call == nil || !strings.Contains(call.Text, "🟢 delete_user_messages") {
+		t.Fatalf("expected flag listing in reply, got %+v", call)
+	}
+}
+
+func TestAdminCommandsHiddenFromUsers(t *testing.T) {
+	config.SetTargetUserID(100)
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, &config.RecordConfig{})
+
+	handler.HandleUpdate(context.Background(), *commandMessage(101, "/admin flag delete_user_messages on"))
+
+	if config.FeatureEnabled(config.FeatureDeleteUserMessages) {
+		t.Fatalf("non-admin must not toggle flags")
+	}
+	if call := adapter.LastCall("send_message"); call == nil || call.Text != "Неизвестная команда." {
+		t.Fatalf("expected unknown command reply, got %+v", call)
+	}
+}
+
+func TestAdminPreviewDoesNotSaveAnswers(t *testing.T) {
+	questions.RegisterBuiltins()
+	config.SetTargetUserID(100)
+	rc := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {Title: "Section", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Name?", Type: "text", StoreKey: "name"}}},
+		},
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	draft := state.NewRecord()
+	draft.Data["name"] = state.StringAnswer("real draft")
+	admin := &state.UserState{UserID: 100, CurrentRecord: draft, MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+	ctx := context.Background()
+
+	handleAdminCommand(ctx, commandMessage(100, "/admin preview sec"), admin, adapter, rc, nil)
+	if !admin.Preview || admin.RecordFSM.Current() != StateAnsweringQuestion {
+		t.Fatalf("expected preview to open the section, state=%s preview=%t", admin.RecordFSM.Current(), admin.Preview)
+	}
+
+	handleMessage(ctx, textMessage(100, "preview answer"), admin, adapter, rc, nil)
+	handleCallbackQuery(ctx, callbackQuery(100, admin.LastMessageID, CallbackActionPrefix+ActionSaveRecord), admin, adapter, rc, nil)
+
+	if len(admin.Records) != 0 {
+		t.Fatalf("preview must not save records, got %d", len(admin.Records))
+	}
+	if admin.Preview || admin.CurrentRecord != draft || draft.Data["name"].String() != "real draft" {
+		t.Fatalf("expected real draft restored untouched, got %+v", admin.CurrentRecord)
+	}
+}
+
+func TestAdminPreviewWholeSurvey(t *testing.T) {
+	questions.RegisterBuiltins()
+	config.SetTargetUserID(100)
+	defer config.SetTargetUserID(0)
+	rc := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"a": {Title: "Section A", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Name?", Type: "text", StoreKey: "name"}}},
+			"b": {Title: "Section B", Questions: []config.QuestionConfig{{ID: "q2", Prompt: "City?", Type: "text", StoreKey: "city"}}},
+		},
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	admin := &state.UserState{UserID: 100, MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+	ctx := context.Background()
+
+	handleAdminCommand(ctx, commandMessage(100, "/admin preview"), admin, adapter, rc, nil)
+	menu := adapter.LastCall("send_message")
+	if !admin.Preview || admin.RecordFSM.Current() != StateSelectingSection || !strings.HasPrefix(menu.Text, previewLabel) {
+		t.Fatalf("expected a labeled section menu, state=%s preview=%t text=%q", admin.RecordFSM.Current(), admin.Preview, menu.Text)
+	}
+	if menu.Keyboard.HasCallback(CallbackActionPrefix + ActionSaveAndSend) {
+		t.Fatalf("a preview must not offer sending to the therapist")
+	}
+
+	handleCallbackQuery(ctx, callbackQuery(100, admin.LastMessageID, CallbackSectionPrefix+"b"), admin, adapter, rc, nil)
+	if prompt := adapter.LastCall("edit_message"); prompt == nil || !strings.HasPrefix(prompt.Text, previewLabel+"\nCity?") {
+		t.Fatalf("expected a labeled prompt, got %+v", prompt)
+	}
+	handleMessage(ctx, textMessage(100, "Rome"), admin, adapter, rc, nil)
+	handleCallbackQuery(ctx, callbackQuery(100, admin.LastMessageID, CallbackActionPrefix+ActionExitMenu), admin, adapter, rc, nil)
+
+	if admin.Preview || admin.CurrentRecord != nil || len(admin.Records) != 0 {
+		t.Fatalf("preview must leave nothing behind, draft=%+v records=%d", admin.CurrentRecord, len(admin.Records))
+	}
+}
+
+func TestAdminUserInspectAndRepair(t *testing.T) {
+	config.SetTargetUserID(100)
+	adapter := &fakeadapter.FakeAdapter{}
+	rc := &config.RecordConfig{}
+	store := newTestHandler(adapter, rc).Store()
+	admin := store.GetOrCreateUserState(100, "Admin")
+	stuck := store.GetOrCreateUserState(200, "Stuck")
+	stuck.RecordFSM.SetState(StateAnsweringQuestion)
+	stuck.CurrentSection = "gone"
+	stuck.CurrentRecord = state.NewRecord()
+	stuck.CurrentRecord.Data["mood"] = state.StringAnswer("ok")
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		command    string
+		wantText   string
+		wantRecord string
+		wantDraft  bool
+	}{
+		{name: "inspect", command: "/admin user 200", wantText: "- mood: ok", wantRecord: StateAnsweringQuestion, wantDraft: true},
+		{name: "reset", command: "/admin user 200 reset", wantText: "Запись: " + StateRecordIdle, wantRecord: StateRecordIdle, wantDraft: true},
+		{name: "clear draft", command: "/admin user 200 cleardraft", wantText: "Черновик: нет", wantRecord: StateRecordIdle},
+		{name: "unknown user", command: "/admin user 300", wantText: "Пользователь 300 не найден.", wantRecord: StateRecordIdle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handleAdminCommand(ctx, commandMessage(100, tt.command), admin, adapter, rc, store)
+
+			if call := adapter.LastCall("send_message"); call == nil || !strings.Contains(call.Text, tt.wantText) {
+				t.Fatalf("expected reply containing %q, got %+v", tt.wantText, call)
+			}
+			if got := stuck.RecordFSM.Current(); got != tt.wantRecord {
+				t.Fatalf("record state = %s, want %s", got, tt.wantRecord)
+			}
+			if (stuck.CurrentRecord != nil) != tt.wantDraft {
+				t.Fatalf("draft present = %t, want %t", stuck.CurrentRecord != nil, tt.wantDraft)
+			}
+		})
+	}
+}
+
+func TestAdminStatsUsersBroadcast(t *testing.T) {
+	config.SetTargetUserID(100)
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, &config.RecordConfig{})
+	store := handler.Store()
+	store.GetOrCreateUserState(100, "Admin")
+	anna := store.GetOrCreateUserState(201, "Anna")
+	for _, createdAt := range []time.Time{time.Now(), time.Now().AddDate(0, 0, -3)} {
+		record := state.NewRecord()
+		record.IsSaved = true
+		record.CreatedAt = createdAt
+		anna.Records = append(anna.Records, record)
+	}
+	boris := store.GetOrCreateUserState(202, "Boris")
+	boris.CurrentRecord = state.NewRecord()
+	boris.CurrentRecord.Data["mood"] = state.StringAnswer("ok")
+
+	tests := []struct {
+		name          string
+		from          int64
+		command       string
+		wantReply     []string
+		wantBroadcast []int64
+	}{
+		{name: "stats", from: 100, command: "/stats", wantReply: []string{"Пользователей: 3", "Записей всего: 2", "Записей сегодня: 1", "черновиков: 1"}},
+		{name: "users", from: 100, command: "/users", wantReply: []string{"201 — Anna: записей 2", "202 — Boris: записей 0, черновик"}},
+		{name: "broadcast", from: 100, command: "/broadcast Завтра бот не работает", wantReply: []string{"Рассылка отправлена: 2."}, wantBroadcast: []int64{201, 202}},
+		{name: "broadcast without text", from: 100, command: "/broadcast", wantReply: []string{"Использование: /broadcast"}},
+		{name: "hidden from users", from: 201, command: "/stats", wantReply: []string{"Неизвестная команда."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter.Calls = nil
+
+			handler.HandleUpdate(context.Background(), *commandMessage(tt.from, tt.command))
+
+			var reply *fakeadapter.Call
+			var broadcastTo []int64
+			for i, call := range adapter.Calls {
+				if call.Op != "send_message" {
+					continue
+				}
+				if call.ChatID == tt.from {
+					reply = &adapter.Calls[i]
+				} else {
+					broadcastTo = append(broadcastTo, call.ChatID)
+				}
+			}
+			for _, want := range tt.wantReply {
+				if reply == nil || !strings.Contains(reply.Text, want) {
+					t.Fatalf("expected reply containing %q, got %+v", want, reply)
+				}
+			}
+			if fmt.Sprint(broadcastTo) != fmt.Sprint(tt.wantBroadcast) {
+				t.Fatalf("broadcast to %v, want %v", broadcastTo, tt.wantBroadcast)
+			}
+		})
+	}
+}
+
+// TestCrossUserCommandsReleaseTheSender holds user 200, as that user's own handler would, while the
+// admin runs a command on them, and then takes the admin the way a handler of 200 acting on the admin
+// would. Had the admin's handler kept its Mu while waiting for 200, the two would deadlock.
+func TestCrossUserCommandsReleaseTheSender(t *testing.T) {
+	config.SetTargetUserID(100)
+	defer config.SetTargetUserID(0)
+	rc := &config.RecordConfig{}
+
+	tests := []struct {
+		name      string
+		update    *botport.InboundEvent
+		wantReply string
+	}{
+		{name: "inspect", update: commandMessage(100, "/admin user 200"), wantReply: "Patient"},
+		{name: "assign", update: commandMessage(100, "/admin assign 200 default"), wantReply: "отправляются: 100"},
+		{name: "relink", update: commandMessage(100, "/admin relink 200 201"), wantReply: "Перенесено с 200 на 201"},
+		{name: "store stats", update: commandMessage(100, "/admin stats"), wantReply: "2"},
+		{name: "users", update: commandMessage(100, "/users"), wantReply: "200"},
+		{name: "acknowledge", update: callbackQuery(100, 5, CallbackAckPrefix+"d1"), wantReply: config.Message("", config.MsgAckPatientNotice)},
+		{name: "open a reply", update: callbackQuery(100, 5, CallbackReplyPrefix+"200"), wantReply: config.Message("", config.MsgReplyPrompt, "Patient", 200)},
+		{name: "reply to a forward", update: func() *botport.InboundEvent {
+			reply := textMessage(100, "See you on Monday")
+			reply.ReplyTo = 5
+			return reply
+		}(), wantReply: config.Message("", config.MsgReplySent)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer dialogs.Cancel(100)
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			store := handler.Store()
+			admin := store.GetOrCreateUserState(100, "Admin")
+			patient := store.GetOrCreateUserState(200, "Before")
+			store.AddDelivery(&state.Delivery{ID: "d1", UserID: 200, RecordID: "r1", TargetID: 100, MessageID: 5, Status: state.DeliveryDelivered})
+
+			patient.Mu.Lock()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				handler.HandleUpdate(context.Background(), *tt.update)
+			}()
+			time.Sleep(20 * time.Millisecond) // Let the admin's handler reach user 200
+
+			acquired := false
+			for deadline := time.Now().Add(time.Second); !acquired && time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+				acquired = admin.Mu.TryLock()
+			}
+			if acquired {
+				admin.Mu.Unlock()
+			}
+			patient.UserName = "Patient" // Seen only by a handler that waited for the lock
+			patient.Mu.Unlock()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatalf("the admin's handler did not finish")
+			}
+			if !acquired {
+				t.Fatalf("the admin stayed locked while the handler waited for user 200")
+			}
+			if reply := adapter.LastCall("send_message"); reply == nil || !strings.Contains(reply.Text, tt.wantReply) {
+				t.Fatalf("reply %+v, want %q", reply, tt.wantReply)
+			}
+		})
+	}
+}
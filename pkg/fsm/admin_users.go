@@ -0,0 +1,109 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+const maxUsersReplySize = 3500
+
+// userSummary is what the admin commands read of one user.
+type userSummary struct {
+	UserID       int64
+	UserName     string
+	Records      int
+	RecordsToday int
+	HasDraft     bool
+}
+
+// summarizeUsers reads every known user, newest IDs last, locking one at a time. Callers hold no
+// user's Mu.
+func summarizeUsers(store *state.Store, now time.Time) []userSummary {
+	ids := store.UserIDs()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	summaries := make([]userSummary, 0, len(ids))
+	for _, userID := range ids {
+		userState, ok := store.LockUser(userID)
+		if !ok {
+			continue
+		}
+		summaries = append(summaries, userSummary{
+			UserID:       userID,
+			UserName:     userState.UserName,
+			Records:      store.CountRecords(userID, state.RecordFilter{}),
+			RecordsToday: len(store.FindByDate(userID, now)),
+			HasDraft:     userState.CurrentRecord != nil && len(userState.CurrentRecord.Data) > 0,
+		})
+		userState.Mu.Unlock()
+	}
+	return summaries
+}
+
+// renderStats answers /stats.
+func renderStats(store *state.Store, now time.Time) string {
+	summaries := summarizeUsers(store, now)
+	records, today, drafts := 0, 0, 0
+	for _, s := range summaries {
+		records += s.Records
+		today += s.RecordsToday
+		if s.HasDraft {
+			drafts++
+		}
+	}
+	return fmt.Sprintf("Пользователей: %d\nЗаписей всего: %d\nЗаписей сегодня: %d\nНезаконченных черновиков: %d", len(summaries), records, today, drafts)
+}
+
+// renderUsers answers /users with one line per user, cut to the reply size.
+func renderUsers(store *state.Store, now time.Time) string {
+	summaries := summarizeUsers(store, now)
+	if len(summaries) == 0 {
+		return "Пользователей пока нет."
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Пользователи (%d):", len(summaries))
+	for i, s := range summaries {
+		line := fmt.Sprintf("\n%d — %s: записей %d", s.UserID, truncateString(s.UserName, 30), s.Records)
+		if s.HasDraft {
+			line += ", черновик"
+		}
+		if b.Len()+len(line) > maxUsersReplySize {
+			fmt.Fprintf(&b, "\n… и ещё %d", len(summaries)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// broadcast sends text to every known user except the admin and reports how many got it.
+func broadcast(ctx context.Context, admin *state.UserState, botPort botport.BotPort, store *state.Store, text string) string {
+	ids := store.UserIDs()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	sent, failed := 0, 0
+	for _, userID := range ids {
+		if userID == admin.UserID {
+			continue
+		}
+		if _, err := botPort.SendMessage(ctx, userID, text, nil); err != nil {
+			slog.ErrorContext(ctx, "broadcast to user failed", "user_id", userID, "err", err)
+			failed++
+			continue
+		}
+		sent++
+	}
+	slog.InfoContext(ctx, "broadcast sent", "admin_id", admin.UserID, "sent", sent, "failed", failed)
+	if failed > 0 {
+		return fmt.Sprintf("📣 Рассылка отправлена: %d, не доставлено: %d.", sent, failed)
+	}
+	return fmt.Sprintf("📣 Рассылка отправлена: %d.", sent)
+}
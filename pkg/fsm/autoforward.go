@@ -0,0 +1,127 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logging"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// RunAutoForward forwards saved records to TARGET_USER_ID once a day at auto_forward.time until ctx is cancelled.
+func RunAutoForward(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
+	autoForward := recordConfig.AutoForward
+	if !autoForward.Enabled {
+		return
+	}
+	at, err := time.Parse(config.ReminderTimeLayout, autoForward.Time)
+	if err != nil {
+		slog.ErrorContext(ctx, "invalid auto-forward time", "time", autoForward.Time, "err", err)
+		return
+	}
+	cutoff := at
+	if autoForward.Cutoff != "" {
+		if cutoff, err = time.Parse(config.ReminderTimeLayout, autoForward.Cutoff); err != nil {
+			slog.ErrorContext(ctx, "invalid auto-forward cutoff", "cutoff", autoForward.Cutoff, "err", err)
+			return
+		}
+	}
+
+	for {
+		wait := time.Until(nextDailyRun(time.Now(), at.Hour(), at.Minute()))
+		slog.InfoContext(ctx, "next auto-forward scheduled", "in", wait.Round(time.Second))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if config.GetTargetUserID() == 0 {
+			slog.WarnContext(ctx, "TARGET_USER_ID is not configured, skipping auto-forward")
+			continue
+		}
+		if targetLink.isBroken(config.GetTargetUserID()) {
+			slog.WarnContext(ctx, "target blocked the bot, auto-forward to it waits until it writes again", "target_id", config.GetTargetUserID())
+		}
+		now := time.Now()
+		before := time.Date(now.Year(), now.Month(), now.Day(), cutoff.Hour(), cutoff.Minute(), 0, 0, now.Location())
+		runCtx := logging.NewContext(ctx)
+		for _, userID := range store.UserIDs() {
+			autoForwardUser(runCtx, botPort, recordConfig, store, userID, before)
+		}
+	}
+}
+
+// autoForwardUser sends every saved record of userID that was saved before cutoff and has not reached
+// their therapist yet, one digest message per day, then tells the user what was sent.
+func autoForwardUser(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, userID int64, cutoff time.Time) {
+	userState, ok := store.LockUser(userID)
+	if !ok {
+		return
+	}
+	defer userState.Mu.Unlock()
+	targetUserID := targetFor(userState)
+	if userState.AutoForwardOff || userID == targetUserID {
+		return
+	}
+	if targetLink.isBroken(targetUserID) {
+		return
+	}
+
+	requireAck := config.FeatureEnabled(config.FeatureRequireAck)
+	sent, failed := sendDigestDeliveries(ctx, botPort, recordConfig, userState, pendingAutoForward(userState, store, cutoff), targetUserID, store, requireAck)
+	if sent == 0 && failed == 0 {
+		return
+	}
+	slog.InfoContext(ctx, "records auto-forwarded", "user_id", userID, "sent", sent, "failed", failed)
+
+	var lines []string
+	if sent > 0 {
+		lines = append(lines, tr(userState, config.MsgAutoForwardSent, sent))
+	}
+	if failed > 0 {
+		lines = append(lines, tr(userState, config.MsgAutoForwardFailed, failed, tr(userState, ButtonMainMenuSendTherapist)))
+	}
+	lines = append(lines, tr(userState, config.MsgAutoForwardOptOut))
+	_, _ = botPort.SendMessage(ctx, userID, strings.Join(lines, "\n"), nil, lowPriorityOptions(recordConfig)...)
+}
+
+// pendingAutoForward lists saved records created before cutoff that have not been delivered yet,
+// oldest first.
+func pendingAutoForward(userState *state.UserState, store *state.Store, cutoff time.Time) []*state.Record {
+	var pending []*state.Record
+	for _, record := range store.ListRecords(userState.UserID, state.RecordFilter{To: cutoff}, state.Page{}) {
+		if !store.Delivered(record.ID) {
+			pending = append(pending, record)
+		}
+	}
+	slices.Reverse(pending)
+	return pending
+}
+
+// handleAutoForwardCommand switches the user's auto-forward opt-out: /autoforward on|off.
+func handleAutoForwardCommand(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, arg string) {
+	if !recordConfig.AutoForward.Enabled {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgAutoForwardUnset), nil)
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "off":
+		userState.AutoForwardOff = true
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgAutoForwardOff), nil)
+	case "on":
+		userState.AutoForwardOff = false
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgAutoForwardOn, recordConfig.AutoForward.Time), nil)
+	default:
+		usage := config.MsgAutoForwardUsageOn
+		if userState.AutoForwardOff {
+			usage = config.MsgAutoForwardUsageOff
+		}
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, usage), nil)
+	}
+}
@@ -0,0 +1,98 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestAutoForwardUser(t *testing.T) {
+	config.SetTargetUserID(700)
+	config.SetTherapists([]int64{800})
+	defer config.SetTargetUserID(0)
+	defer config.SetTherapists(nil)
+	rc := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+		},
+		AutoForward: config.AutoForwardConfig{Enabled: true, Time: "21:00", Cutoff: "20:00"},
+	}
+	cutoff := time.Date(2025, 3, 1, 20, 0, 0, 0, time.Local)
+	saved := func(at time.Time) *state.Record {
+		record := state.NewRecord()
+		record.ID = at.Format("0102-1504")
+		record.Data["f1"] = state.StringAnswer("Value")
+		record.IsSaved = true
+		record.CreatedAt = at
+		return record
+	}
+
+	tests := []struct {
+		name          string
+		records       []*state.Record
+		alreadySent   int // how many of records were delivered before
+		optOut        bool
+		therapist     int64
+		wantTarget    int64 // Defaults to TARGET_USER_ID
+		wantForwarded int
+	}{
+		{name: "saved before cutoff", records: []*state.Record{saved(cutoff.Add(-time.Hour)), saved(cutoff.Add(-25 * time.Hour))}, wantForwarded: 2},
+		{name: "saved after cutoff waits", records: []*state.Record{saved(cutoff.Add(time.Minute))}, wantForwarded: 0},
+		{name: "already delivered", records: []*state.Record{saved(cutoff.Add(-time.Hour))}, alreadySent: 1, wantForwarded: 0},
+		{name: "opted out", records: []*state.Record{saved(cutoff.Add(-time.Hour))}, optOut: true, wantForwarded: 0},
+		{name: "assigned therapist", records: []*state.Record{saved(cutoff.Add(-time.Hour))}, therapist: 800, wantTarget: 800, wantForwarded: 1},
+		{name: "therapist no longer configured", records: []*state.Record{saved(cutoff.Add(-time.Hour))}, therapist: 900, wantForwarded: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			userState := store.GetOrCreateUserState(20, "Patient")
+			userState.Records = tt.records
+			userState.AutoForwardOff = tt.optOut
+			userState.Therapist = tt.therapist
+			for _, record := range tt.records[:tt.alreadySent] {
+				store.AddDelivery(&state.Delivery{ID: "old", UserID: 20, RecordID: record.ID, Status: state.DeliveryRead})
+			}
+
+			autoForwardUser(context.Background(), adapter, rc, store, 20, cutoff)
+
+			wantTarget := tt.wantTarget
+			if wantTarget == 0 {
+				wantTarget = 700
+			}
+			forwarded := 0
+			for _, call := range adapter.Calls {
+				if call.ChatID == wantTarget {
+					forwarded++
+				}
+			}
+			if forwarded != tt.wantForwarded {
+				t.Fatalf("forwarded = %d, want %d", forwarded, tt.wantForwarded)
+			}
+			if tt.wantForwarded > 0 && adapter.LastCall("send_message").ChatID != 20 {
+				t.Fatalf("user must get a summary, calls: %+v", adapter.Calls)
+			}
+		})
+	}
+}
+
+func TestAutoForwardCommandTogglesOptOut(t *testing.T) {
+	rc := &config.RecordConfig{AutoForward: config.AutoForwardConfig{Enabled: true, Time: "21:00"}}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	userState := &state.UserState{UserID: 21, MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+
+	handleMessage(context.Background(), commandMessage(21, "/autoforward off"), userState, adapter, rc, nil)
+	if !userState.AutoForwardOff {
+		t.Fatalf("/autoforward off must opt the user out")
+	}
+	handleMessage(context.Background(), commandMessage(21, "/autoforward on"), userState, adapter, rc, nil)
+	if userState.AutoForwardOff {
+		t.Fatalf("/autoforward on must opt the user back in")
+	}
+}
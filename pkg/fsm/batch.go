@@ -0,0 +1,90 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+// editBatch coalesces the edits made while leaving a screen. Edits are held back and merged per
+// message, so only the last text of each message reaches the chat; they go out right before the next
+// send or on Flush. An exit then costs one visible edit followed by the menu, whatever the callbacks
+// on the way did.
+type editBatch struct {
+	botport.BotPort
+	pending []pendingEdit
+}
+
+type pendingEdit struct {
+	chatID    int64
+	messageID int
+	text      string
+	markup    interface{}
+}
+
+var _ botport.BotPort = (*editBatch)(nil)
+
+func newEditBatch(botPort botport.BotPort) *editBatch {
+	return &editBatch{BotPort: botPort}
+}
+
+// EditMessage queues the edit, replacing a queued edit of the same message. Edits without a message ID
+// are sent as new messages by adapters, so they are not held back.
+func (b *editBatch) EditMessage(ctx context.Context, chatID int64, messageID int, text string, markup interface{}) (botport.BotMessage, error) {
+	if messageID == 0 {
+		b.Flush(ctx)
+		return b.BotPort.EditMessage(ctx, chatID, messageID, text, markup)
+	}
+	for i := range b.pending {
+		if b.pending[i].chatID == chatID && b.pending[i].messageID == messageID {
+			b.pending[i].text = text
+			b.pending[i].markup = markup
+			return toBotMessageFromPort(chatID, messageID, text, markup), nil
+		}
+	}
+	b.pending = append(b.pending, pendingEdit{chatID: chatID, messageID: messageID, text: text, markup: markup})
+	return toBotMessageFromPort(chatID, messageID, text, markup), nil
+}
+
+func (b *editBatch) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts ...botport.SendOption) (botport.BotMessage, error) {
+	b.Flush(ctx)
+	return b.BotPort.SendMessage(ctx, chatID, text, markup, opts...)
+}
+
+func (b *editBatch) SendSticker(ctx context.Context, chatID int64, fileID string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	b.Flush(ctx)
+	return b.BotPort.SendSticker(ctx, chatID, fileID, opts...)
+}
+
+func (b *editBatch) SendAnimation(ctx context.Context, chatID int64, fileID string, caption string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	b.Flush(ctx)
+	return b.BotPort.SendAnimation(ctx, chatID, fileID, caption, opts...)
+}
+
+func (b *editBatch) SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	b.Flush(ctx)
+	return b.BotPort.SendDocument(ctx, chatID, fileName, data, caption, opts...)
+}
+
+func (b *editBatch) DeleteMessage(ctx context.Context, chatID int64, messageID int) error {
+	b.Flush(ctx)
+	return b.BotPort.DeleteMessage(ctx, chatID, messageID)
+}
+
+// Flush applies the queued edits in order. The caller already got a successful result, so an edit
+// that fails is sent as a new message instead and its text is not lost.
+func (b *editBatch) Flush(ctx context.Context) {
+	pending := b.pending
+	b.pending = nil
+	for _, edit := range pending {
+		_, err := b.BotPort.EditMessage(ctx, edit.chatID, edit.messageID, edit.text, edit.markup)
+		if err == nil || botport.IsCode(err, "message_not_modified") {
+			continue
+		}
+		slog.WarnContext(ctx, "edit failed, sending instead", "message_id", edit.messageID, "chat_id", edit.chatID, "err", err)
+		if _, err := b.BotPort.SendMessage(ctx, edit.chatID, edit.text, nil); err != nil {
+			slog.ErrorContext(ctx, "fallback send failed", "chat_id", edit.chatID, "err", err)
+		}
+	}
+}
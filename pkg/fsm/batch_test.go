@@ -0,0 +1,73 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestEditBatchCoalescesEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		failErr error
+		wantOps []string
+		wantTxt []string
+	}{
+		{name: "last edit wins, then the send", wantOps: []string{"edit_message", "send_message"}, wantTxt: []string{"second", "menu"}},
+		{name: "failed edit is sent instead", failErr: errors.New("message can't be edited"), wantOps: []string{"send_message", "send_message"}, wantTxt: []string{"second", "menu"}},
+		{name: "unmodified edit is fine", failErr: fakeadapter.MessageNotModified("edit_message"), wantOps: []string{"send_message"}, wantTxt: []string{"menu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			if tt.failErr != nil {
+				adapter.Fail("edit_message", tt.failErr)
+			}
+			batch := newEditBatch(adapter)
+			ctx := context.Background()
+
+			_, _ = batch.EditMessage(ctx, 1, 5, "first", nil)
+			_, _ = batch.EditMessage(ctx, 1, 5, "second", nil)
+			if len(adapter.Calls) != 0 {
+				t.Fatalf("edits must wait for the next send, calls: %+v", adapter.Calls)
+			}
+			_, _ = batch.SendMessage(ctx, 1, "menu", nil)
+			batch.Flush(ctx)
+
+			if len(adapter.Calls) != len(tt.wantOps) {
+				t.Fatalf("calls = %+v, want ops %v", adapter.Calls, tt.wantOps)
+			}
+			for i, call := range adapter.Calls {
+				if call.Op != tt.wantOps[i] || call.Text != tt.wantTxt[i] {
+					t.Fatalf("call #%d = %s %q, want %s %q", i, call.Op, call.Text, tt.wantOps[i], tt.wantTxt[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExitToMenuIsOneEditPlusMenu(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	userState := &state.UserState{UserID: 14, CurrentRecord: state.NewRecord(), MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+	userState.RecordFSM.SetState(StateSelectingSection)
+
+	handleCallbackQuery(context.Background(), callbackQuery(14, 30, CallbackActionPrefix+ActionExitMenu), userState, adapter, rc, nil)
+
+	var ops []string
+	for _, call := range adapter.Calls {
+		if call.Op != "answer_callback" {
+			ops = append(ops, call.Op)
+		}
+	}
+	if len(ops) != 2 || ops[0] != "edit_message" || ops[1] != "send_message" {
+		t.Fatalf("exit must be one edit and the menu, got %v", ops)
+	}
+}
@@ -0,0 +1,20 @@
+package fsm
+
+import (
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+)
+
+// splitCallbackData splits inline callback data into its prefix, delimiter included, and the value
+// after it. Data without a delimiter is all prefix, so it matches no known prefix.
+func splitCallbackData(data string) (prefix, value string) {
+	prefix, value, _ = strings.Cut(data, config.CallbackDelimiter)
+	return prefix + config.CallbackDelimiter, value
+}
+
+// splitAnswerCallback splits the value of an answer callback, built by questions.AnswerCallbackData,
+// into the question ID and the option value.
+func splitAnswerCallback(value string) (questionID, option string, ok bool) {
+	return strings.Cut(value, config.CallbackDelimiter)
+}
@@ -0,0 +1,144 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// callbackSeeds are well-formed, truncated and malformed payloads of every callback prefix.
+var callbackSeeds = []string{
+	"", ":", "::", "answer", "\xff:\xfe",
+	CallbackAnswerPrefix, CallbackAnswerPrefix + "b1", CallbackAnswerPrefix + "b1:", CallbackAnswerPrefix + "b1:yes", CallbackAnswerPrefix + "b1:_skip", CallbackAnswerPrefix + "t1:yes", CallbackAnswerPrefix + ":yes",
+	CallbackSectionPrefix, CallbackSectionPrefix + "day", CallbackSectionPrefix + "nope", CallbackSectionPrefix + "day:extra",
+	CallbackSectionNavPrefix + "back", CallbackSectionNavPrefix + "next", CallbackSectionNavPrefix,
+	CallbackActionPrefix, CallbackActionPrefix + ActionSaveRecord, CallbackActionPrefix + ActionCancelSection, CallbackActionPrefix + ActionEditAnswers, CallbackActionPrefix + ActionNewConfirm, CallbackActionPrefix + ActionExitMenu, CallbackActionPrefix + ActionNoteCancel,
+	CallbackListNavPrefix + "next", CallbackListNavPrefix + "back", CallbackListNavPrefix + "tomenu",
+	CallbackJumpPrefix, CallbackJumpPrefix + "t1", CallbackJumpPrefix + "zz",
+	CallbackEditPrefix, CallbackEditPrefix + "day", CallbackEditPrefix + "day:", CallbackEditPrefix + "day:t1", CallbackEditPrefix + ":t1",
+	CallbackRemindPrefix, CallbackRemindPrefix + "day", CallbackAckPrefix, CallbackAckPrefix + "x",
+	CallbackRelinkPrefix, CallbackRelinkPrefix + "1", CallbackRelinkPrefix + "1:", CallbackRelinkPrefix + "a:b",
+	CallbackRecordPrefix, CallbackRecordPrefix + "view", CallbackRecordPrefix + "view:", CallbackRecordPrefix + ":x",
+	CallbackQuizPrefix, CallbackLanguagePrefix, CallbackLanguagePrefix + "en", CallbackSurveyPrefix, CallbackSurveyPrefix + "nope",
+	CallbackReplyPrefix, CallbackReplyPrefix + "cancel", CallbackReplyPrefix + "-1",
+}
+
+func fuzzRecordConfig() *config.RecordConfig {
+	return &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"day": {Title: "Day", Questions: []config.QuestionConfig{
+			{ID: "t1", Prompt: "Mood?", Type: "text", StoreKey: "mood"},
+			{ID: "b1", Prompt: "Slept?", Type: "buttons", StoreKey: "slept", Optional: true, Options: []config.ButtonOption{{Text: "Yes", Value: "yes"}}},
+		}},
+		"night": {Title: "Night", Questions: []config.QuestionConfig{{ID: "n1", Prompt: "Hours?", Type: "text", StoreKey: "hours"}}},
+	}}
+}
+
+// checkUserState reports the first way userState is inconsistent with rc.
+func checkUserState(rc *config.RecordConfig, userState *state.UserState) error {
+	recordState := userState.RecordFSM.Current()
+	switch recordState {
+	case StateRecordIdle:
+		return nil
+	case StateSelectingSection, StateAnsweringQuestion:
+	default:
+		return fmt.Errorf("unknown record state %q", recordState)
+	}
+	if userState.CurrentRecord == nil || userState.CurrentRecord.Data == nil {
+		return fmt.Errorf("%s without a draft", recordState)
+	}
+	if userState.SectionPage < 0 || userState.ListOffset < 0 {
+		return fmt.Errorf("negative page: section %d, list offset %d", userState.SectionPage, userState.ListOffset)
+	}
+	if recordState == StateAnsweringQuestion {
+		section, ok := rc.Sections[userState.CurrentSection]
+		if !ok {
+			return fmt.Errorf("answering unknown section %q", userState.CurrentSection)
+		}
+		if userState.CurrentQuestion < 0 || userState.CurrentQuestion >= len(section.Questions) {
+			return fmt.Errorf("question %d out of range in section %q", userState.CurrentQuestion, userState.CurrentSection)
+		}
+	}
+	return nil
+}
+
+func FuzzHandleCallbackQuery(f *testing.F) {
+	questions.RegisterBuiltins()
+	for _, seed := range callbackSeeds {
+		f.Add(seed)
+	}
+	starts := []struct {
+		state    string
+		question int // Current question in "day" while answering
+	}{
+		{state: StateRecordIdle},
+		{state: StateSelectingSection},
+		{state: StateAnsweringQuestion, question: 0},
+		{state: StateAnsweringQuestion, question: 1},
+	}
+
+	f.Fuzz(func(t *testing.T, data string) {
+		rc := fuzzRecordConfig()
+		for _, start := range starts {
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			userState := store.GetOrCreateUserState(11, "User")
+			if start.state != StateRecordIdle {
+				userState.CurrentRecord = state.NewRecord()
+				userState.RecordFSM.SetState(start.state)
+			}
+			if start.state == StateAnsweringQuestion {
+				userState.CurrentSection, userState.CurrentQuestion = "day", start.question
+			}
+
+			handleCallbackQuery(context.Background(), callbackQuery(11, 1, data), userState, adapter, rc, store)
+
+			if err := checkUserState(rc, userState); err != nil {
+				t.Fatalf("callback %q from %s/%d: %v", data, start.state, start.question, err)
+			}
+		}
+	})
+}
+
+func FuzzSplitCallbackData(f *testing.F) {
+	for _, seed := range callbackSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		prefix, value := splitCallbackData(data)
+		if !strings.HasSuffix(prefix, config.CallbackDelimiter) || strings.Count(prefix, config.CallbackDelimiter) != 1 {
+			t.Fatalf("prefix %q of %q must end with its only delimiter", prefix, data)
+		}
+		want := data
+		if !strings.Contains(data, config.CallbackDelimiter) {
+			want += config.CallbackDelimiter
+		}
+		if prefix+value != want {
+			t.Fatalf("split %q into %q + %q", data, prefix, value)
+		}
+	})
+}
+
+func FuzzAnswerCallbackData(f *testing.F) {
+	f.Add("b1", "yes")
+	f.Add("q", "")
+	f.Add("mood", "a:b")
+	f.Fuzz(func(t *testing.T, questionID, option string) {
+		if strings.Contains(questionID, config.CallbackDelimiter) {
+			t.Skip("question IDs must not contain the delimiter")
+		}
+		prefix, value := splitCallbackData(questions.AnswerCallbackData(questionID, option))
+		if prefix != CallbackAnswerPrefix {
+			t.Fatalf("prefix %q, want %q", prefix, CallbackAnswerPrefix)
+		}
+		gotID, gotOption, ok := splitAnswerCallback(value)
+		if !ok || gotID != questionID || gotOption != option {
+			t.Fatalf("decoded (%q, %q, %t), want (%q, %q)", gotID, gotOption, ok, questionID, option)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+// fillScript fills both sections of fuzzRecordConfig: a reply label, an inline tap or typed text per step.
+var fillScript = []scriptStep{
+	{text: "/start"},
+	{text: config.Message("ru", config.MsgButtonFillRecord)},
+	{tap: CallbackSectionPrefix + "day"},
+	{text: "fine"},
+	{tap: questions.AnswerCallbackData("b1", "yes")},
+	{tap: CallbackSectionPrefix + "night"},
+	{text: "7"},
+}
+
+// fillScriptAnswers is the draft fillScript leaves.
+var fillScriptAnswers = map[string]string{"mood": "fine", "slept": "yes", "hours": "7"}
+
+type scriptStep struct {
+	tap  string // Callback data of a button the bot showed; the step is skipped when none carries it
+	text string
+}
+
+// event builds the step's event from userID. A tap comes from the newest message carrying its button
+// and fails when there is none.
+func (s scriptStep) event(adapter *fakeadapter.FakeAdapter, userID int64) (botport.InboundEvent, string, bool) {
+	switch {
+	case s.tap != "":
+		messageID, text, ok := buttonMessage(adapter, userID, s.tap)
+		if !ok {
+			return botport.InboundEvent{}, "no button " + s.tap, false
+		}
+		return botport.InboundEvent{Kind: botport.EventCallback, From: botport.Sender{ID: userID}, ChatID: userID, MessageID: messageID, Text: text, CallbackID: "cb", Data: s.tap}, "tap " + s.tap, true
+	case strings.HasPrefix(s.text, "/"):
+		return *commandMessage(userID, s.text), s.text, true
+	default:
+		return *textMessage(userID, s.text), fmt.Sprintf("type %q", s.text), true
+	}
+}
+
+// playFillScript runs fillScript once for userID and returns what each step did.
+func playFillScript(ctx context.Context, handler *Handler, adapter *fakeadapter.FakeAdapter, userID int64) []string {
+	var log []string
+	for _, step := range fillScript {
+		event, description, ok := step.event(adapter, userID)
+		if ok {
+			handler.HandleUpdate(ctx, event)
+		}
+		log = append(log, description)
+	}
+	return log
+}
+
+// buttonMessage finds the newest message in chatID carrying a button with data.
+func buttonMessage(adapter *fakeadapter.FakeAdapter, chatID int64, data string) (int, string, bool) {
+	calls := adapter.ChatCalls(chatID)
+	for i := len(calls) - 1; i >= 0; i-- {
+		if calls[i].Keyboard.HasCallback(data) {
+			return calls[i].MessageID, calls[i].Text, true
+		}
+	}
+	return 0, "", false
+}
+
+func TestChaosKeepsDraftCoherent(t *testing.T) {
+	questions.RegisterBuiltins()
+	rc := fuzzRecordConfig()
+	const runs, attempts = 100, 3
+	injected := map[string]int{}
+
+	for seed := uint64(1); seed <= runs; seed++ {
+		chaos := fakeadapter.NewChaos(0.3, seed)
+		adapter := &fakeadapter.FakeAdapter{Chaos: chaos}
+		handler := newTestHandler(adapter, rc)
+		ctx := context.Background()
+		var log []string
+		fail := func(format string, args ...any) {
+			t.Helper()
+			t.Fatalf("seed %d: %s\nevents:\n  %s", seed, fmt.Sprintf(format, args...), strings.Join(log, "\n  "))
+		}
+
+		// The user retries the flow a few times while calls keep failing; nothing they answered is lost.
+		answered := map[string]string{}
+		for attempt := 0; attempt < attempts; attempt++ {
+			log = append(log, playFillScript(ctx, handler, adapter, navigationUser)...)
+			userState, _ := handler.Store().Get(navigationUser)
+			if err := checkUserState(rc, userState); err != nil {
+				fail("%v", err)
+			}
+			if err := checkLastMessage(adapter, userState); err != nil {
+				fail("%v", err)
+			}
+			for key, value := range answered {
+				if userState.CurrentRecord == nil || userState.CurrentRecord.Data[key].String() != value {
+					fail("answer %q = %q lost from the draft", key, value)
+				}
+			}
+			if userState.CurrentRecord != nil {
+				for key, answer := range userState.CurrentRecord.Data {
+					answered[key] = answer.String()
+				}
+			}
+		}
+
+		// Once Telegram calms down the same flow completes the draft.
+		adapter.Chaos = nil
+		log = append(log, "chaos off")
+		log = append(log, playFillScript(ctx, handler, adapter, navigationUser)...)
+		userState, _ := handler.Store().Get(navigationUser)
+		if userState.RecordFSM.Current() != StateSelectingSection {
+			fail("ended in %s, want %s", userState.RecordFSM.Current(), StateSelectingSection)
+		}
+		for key, value := range fillScriptAnswers {
+			if got := userState.CurrentRecord.Data[key].String(); got != value {
+				fail("draft %s = %q, want %q", key, got, value)
+			}
+		}
+		for code, n := range chaos.Injected {
+			injected[code] += n
+		}
+	}
+
+	for _, code := range []string{"rate_limited", "message_not_modified", "context_deadline"} {
+		if injected[code] == 0 {
+			t.Fatalf("chaos never injected %s: %v", code, injected)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+// afterFunc runs f once d has passed. Tests replace it to run cleanups right away.
+var afterFunc = func(d time.Duration, f func()) { time.AfterFunc(d, f) }
+
+// scheduleCleanup deletes messageID from the chat after cleanup.delay, when cleanup is enabled. The
+// deletion outlives the update that caused it, so it does not use the update's context.
+func scheduleCleanup(botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int) {
+	if recordConfig == nil || !recordConfig.Cleanup.Enabled || messageID == 0 {
+		return
+	}
+	if batch, ok := botPort.(*editBatch); ok {
+		botPort = batch.BotPort
+	}
+	afterFunc(recordConfig.Cleanup.DelayDuration(), func() {
+		if err := botPort.DeleteMessage(context.Background(), chatID, messageID); err != nil {
+			slog.Warn("deleting the transient message failed", "message_id", messageID, "chat_id", chatID, "err", err)
+		}
+	})
+}
+
+// sendTransient sends a message that is only useful for a moment, such as answer feedback or a
+// warning, and schedules it for cleanup.
+func sendTransient(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, text string, opts ...botport.SendOption) {
+	msg, err := botPort.SendMessage(ctx, chatID, text, nil, opts...)
+	if err != nil {
+		return
+	}
+	for _, messageID := range msg.MessageIDs() {
+		scheduleCleanup(botPort, recordConfig, chatID, messageID)
+	}
+}
@@ -0,0 +1,97 @@
+package fsm
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestCleanupDeletesTransientMessages(t *testing.T) {
+	questions.RegisterBuiltins()
+	var delays []time.Duration
+	afterFunc = func(d time.Duration, f func()) {
+		delays = append(delays, d)
+		f()
+	}
+	defer func() { afterFunc = func(d time.Duration, f func()) { time.AfterFunc(d, f) } }()
+
+	tests := []struct {
+		name        string
+		cleanup     config.CleanupConfig
+		noEdits     bool
+		wantDeleted []string // Texts of the deleted messages
+		wantDelay   time.Duration
+	}{
+		{name: "disabled", cleanup: config.CleanupConfig{}},
+		{
+			name:        "feedback is deleted, edited prompt stays",
+			cleanup:     config.CleanupConfig{Enabled: true, Delay: "1m"},
+			wantDeleted: []string{"Пожалуйста, выберите ответ с помощью кнопок ниже."},
+			wantDelay:   time.Minute,
+		},
+		{
+			name:        "replaced prompt is deleted",
+			cleanup:     config.CleanupConfig{Enabled: true},
+			noEdits:     true,
+			wantDeleted: []string{"Pick?", "Пожалуйста, выберите ответ с помощью кнопок ниже."},
+			wantDelay:   config.DefaultCleanupDelay,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delays = nil
+			recordConfig := &config.RecordConfig{
+				Sections: map[string]config.SectionConfig{
+					"sec": {Title: "Section", Questions: []config.QuestionConfig{
+						{ID: "q1", Prompt: "Pick?", Type: "buttons", StoreKey: "k1", Options: []config.ButtonOption{{Text: "Yes", Value: "yes"}}},
+					}},
+				},
+				Cleanup: tt.cleanup,
+			}
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, recordConfig)
+			userState := &state.UserState{
+				UserID:         4,
+				CurrentRecord:  state.NewRecord(),
+				CurrentSection: "sec",
+				MainMenuFSM:    handler.NewMainMenuFSM(),
+				RecordFSM:      handler.NewRecordFSM(),
+			}
+			userState.RecordFSM.SetState(StateAnsweringQuestion)
+			if tt.noEdits {
+				adapter.Caps = &botport.Capabilities{Text: true, Callbacks: true}
+			}
+			askCurrentQuestion(context.Background(), userState, adapter, recordConfig, 0)
+
+			handleMessage(context.Background(), textMessage(4, "maybe"), userState, adapter, recordConfig, nil)
+
+			sent := make(map[int]string)
+			var deleted []string
+			for _, call := range adapter.Calls {
+				switch call.Op {
+				case "send_message":
+					sent[call.MessageID] = call.Text
+				case "delete_message":
+					deleted = append(deleted, strings.SplitN(sent[call.MessageID], "\n", 2)[0])
+				}
+			}
+			sort.Strings(deleted)
+			if strings.Join(deleted, "|") != strings.Join(tt.wantDeleted, "|") {
+				t.Fatalf("deleted %q, want %q", deleted, tt.wantDeleted)
+			}
+			for _, d := range delays {
+				if d != tt.wantDelay {
+					t.Fatalf("delay = %v, want %v", d, tt.wantDelay)
+				}
+			}
+		})
+	}
+}
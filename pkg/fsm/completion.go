@@ -0,0 +1,96 @@
+package fsm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// completionStats is the data available to completion.message.
+type completionStats struct {
+	Streak       int    // Consecutive days, ending with the save day, with a saved record
+	Total        int    // Saved records of the user
+	Answered     int    // Answered questions in the saved record
+	Scores       int    // Scored entries in the saved record
+	AverageScore string // Average of those scores with one decimal, empty without scores
+}
+
+// buildCompletionStats collects the stats of record, which is already appended to the user's records.
+func buildCompletionStats(userState *state.UserState, store *state.Store, record *state.Record) completionStats {
+	saved := savedRecordMetas(userState, store)
+	stats := completionStats{Streak: saveStreak(saved, record.CreatedAt), Total: len(saved)}
+	var sum int
+	for _, answer := range record.Data {
+		if answer.IsEmpty() {
+			continue
+		}
+		stats.Answered++
+		for _, entry := range answer.Scored {
+			sum += entry.Score
+			stats.Scores++
+		}
+	}
+	if stats.Scores > 0 {
+		stats.AverageScore = fmt.Sprintf("%.1f", float64(sum)/float64(stats.Scores))
+	}
+	return stats
+}
+
+// saveStreak counts consecutive days, ending with day, on which at least one record was saved.
+func saveStreak(saved []state.RecordMeta, day time.Time) int {
+	savedOn := make(map[string]bool)
+	for _, meta := range saved {
+		savedOn[meta.CreatedAt.Format("2006-01-02")] = true
+	}
+	streak := 0
+	for savedOn[day.Format("2006-01-02")] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// completionText renders completion.message for the saved record. Without a template, or when it fails
+// to render, fallback is used.
+func completionText(recordConfig *config.RecordConfig, stats completionStats, fallback string) string {
+	if recordConfig == nil || recordConfig.Completion.Message == "" {
+		return fallback
+	}
+	tpl, err := template.New("completion").Parse(recordConfig.Completion.Message)
+	if err != nil {
+		slog.Error("invalid completion template", "err", err)
+		return fallback
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, stats); err != nil {
+		slog.Error("rendering the completion template failed", "err", err)
+		return fallback
+	}
+	return buf.String()
+}
+
+// celebrate sends completion.celebration and completion.celebration_media after a save, when the
+// streak qualifies.
+func celebrate(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, stats completionStats) {
+	if recordConfig == nil {
+		return
+	}
+	completion := recordConfig.Completion
+	if completion.Celebration == "" && completion.CelebrationMedia.IsZero() {
+		return
+	}
+	if every := completion.CelebrateStreak; every > 0 && stats.Streak%every != 0 {
+		return
+	}
+	if completion.Celebration != "" {
+		_, _ = botPort.SendMessage(ctx, chatID, completion.Celebration, nil)
+	}
+	sendMedia(ctx, botPort, chatID, completion.CelebrationMedia)
+}
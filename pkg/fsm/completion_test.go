@@ -0,0 +1,80 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestCompletionMessage(t *testing.T) {
+	savedDaysAgo := func(days int) *state.Record {
+		return &state.Record{ID: fmt.Sprintf("old-%d", days), Data: stringAnswers(map[string]string{"f1": "old"}), IsSaved: true, CreatedAt: time.Now().AddDate(0, 0, -days)}
+	}
+
+	tests := []struct {
+		name           string
+		completion     config.CompletionConfig
+		records        []*state.Record
+		wantText       string
+		wantCelebrated bool
+		wantSticker    string
+	}{
+		{name: "default", wantText: "✅ Запись успешно сохранена!"},
+		{
+			name:       "template with streak and score",
+			completion: config.CompletionConfig{Message: "Дней подряд: {{.Streak}}, записей: {{.Total}}, средняя оценка: {{.AverageScore}}"},
+			records:    []*state.Record{savedDaysAgo(1), savedDaysAgo(2), savedDaysAgo(4)},
+			wantText:   "Дней подряд: 3, записей: 4, средняя оценка: 7.5",
+		},
+		{name: "celebration every save", completion: config.CompletionConfig{Celebration: "🎉"}, wantText: "✅ Запись успешно сохранена!", wantCelebrated: true},
+		{name: "celebration on streak milestone", completion: config.CompletionConfig{Celebration: "🎉", CelebrateStreak: 2}, records: []*state.Record{savedDaysAgo(1)}, wantText: "✅ Запись успешно сохранена!", wantCelebrated: true},
+		{name: "celebration sticker", completion: config.CompletionConfig{CelebrationMedia: config.MediaConfig{Sticker: "sticker-id"}}, wantText: "✅ Запись успешно сохранена!", wantSticker: "sticker-id"},
+		{name: "no celebration off milestone", completion: config.CompletionConfig{Celebration: "🎉", CelebrateStreak: 2}, wantText: "✅ Запись успешно сохранена!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &config.RecordConfig{
+				Sections: map[string]config.SectionConfig{
+					"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+				},
+				Completion: tt.completion,
+			}
+			draft := state.NewRecord()
+			draft.Data["f1"] = state.StringAnswer("Value")
+			draft.Data["moods"] = state.ScoredAnswer(state.ScoredEntry{Text: "радость", Score: 8}, state.ScoredEntry{Text: "усталость", Score: 7})
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := handler.Store().GetOrCreateUserState(12, "")
+			userState.Records = tt.records
+			userState.CurrentRecord = draft
+			userState.RecordFSM.SetState(StateSelectingSection)
+
+			handleCallbackQuery(context.Background(), callbackQuery(12, 40, CallbackActionPrefix+ActionSaveRecord), userState, adapter, rc, nil)
+
+			if status := adapter.LastCall("edit_message"); status == nil || status.Text != tt.wantText {
+				t.Fatalf("completion message = %+v, want %q", status, tt.wantText)
+			}
+			celebrated := false
+			for _, call := range adapter.Calls {
+				if call.Op == "send_message" && call.Text == "🎉" {
+					celebrated = true
+				}
+			}
+			if celebrated != tt.wantCelebrated {
+				t.Fatalf("celebrated = %t, want %t", celebrated, tt.wantCelebrated)
+			}
+			sticker := ""
+			if call := adapter.LastCall("send_sticker"); call != nil {
+				sticker = call.FileID
+			}
+			if sticker != tt.wantSticker {
+				t.Fatalf("sticker = %q, want %q", sticker, tt.wantSticker)
+			}
+		})
+	}
+}
@@ -1,8 +1,15 @@
 package fsm
 
+import (
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+)
+
 const (
-	StateIdle        = "idle"
-	StateViewingList = "viewingList"
+	StateIdle          = "idle"
+	StateViewingList   = "viewingList"
+	StateViewingRecord = "viewingRecord"
+	StateAddingNote    = "addingNote"
 )
 
 const (
@@ -17,7 +24,12 @@ const (
 	EventViewList       = "view_list"
 	EventListNext       = "list_next"
 	EventListBack       = "list_back"
+	EventViewRecord     = "view_record"
+	EventBackToList     = "back_to_list"
 	EventBackToIdle     = "back_to_idle"
+	EventAddNote        = "add_note"
+	EventSaveNote       = "save_note"
+	EventCancelNote     = "cancel_note"
 )
 
 const (
@@ -32,22 +44,69 @@ const (
 )
 
 const (
-	CallbackActionPrefix  = "action:"
-	CallbackSectionPrefix = "section:"
-	CallbackAnswerPrefix  = "answer:"
-	CallbackListNavPrefix = "list_nav:"
+	CallbackActionPrefix     = "action:"
+	CallbackSectionPrefix    = "section:"
+	CallbackAnswerPrefix     = questions.AnswerCallbackPrefix
+	CallbackListNavPrefix    = "list_nav:"
+	CallbackJumpPrefix       = "jump:"
+	CallbackEditPrefix       = "edit:"
+	CallbackRemindPrefix     = "remind:"
+	CallbackAckPrefix        = "ack:"
+	CallbackRelinkPrefix     = "relink:"
+	CallbackRecordPrefix     = "record:"
+	CallbackQuizPrefix       = "quiz:"
+	CallbackLanguagePrefix   = "lang:"
+	CallbackSurveyPrefix     = "survey:"
+	CallbackReplyPrefix      = "reply:"
+	CallbackSectionNavPrefix = "section_nav:"
 )
 
 const (
 	ActionSaveRecord    = "save_record"
+	ActionSaveAndSend   = "save_and_send"
 	ActionNewRecord     = "new_record"
 	ActionExitMenu      = "exit_menu"
 	ActionCancelSection = "cancel_section"
 	ActionShareLast     = "share_last"
+	ActionReviewSection = "review_section"
+	ActionJumpMenu      = "jump_menu"
+	ActionCancelKeep    = "cancel_keep"
+	ActionCancelDiscard = "cancel_discard"
+	ActionCancelResume  = "cancel_resume"
+	ActionNewConfirm    = "new_record_confirm"
+	ActionNewKeep       = "new_record_keep"
+	ActionTruncateKeep  = "truncate_keep"
+	ActionTruncateRetry = "truncate_retry"
+	ActionPrefillAccept = "prefill_accept"
+	ActionEditAnswers   = "edit_answers"
+	ActionEditBack      = "edit_back"
+	ActionNoteCancel    = "note_cancel"
+)
+
+// Record view actions, sent as record:<action>[:<record id>].
+const (
+	RecordActionView  = "view"
+	RecordActionShare = "share"
+	RecordActionList  = "list"
 )
 
+// DeepLinkSectionPrefix marks /start payloads that open a section directly (t.me/<bot>?start=section_<id>).
+const DeepLinkSectionPrefix = "section_"
+
+// DeepLinkTherapistPrefix marks /start payloads that assign the user to a therapist from THERAPIST_IDS
+// (t.me/<bot>?start=therapist_<id>).
+const DeepLinkTherapistPrefix = "therapist_"
+
+// Main menu reply buttons, by catalog key: the label is shown in the user's language and recognised in
+// any of them.
 const (
-	ButtonMainMenuFillRecord    = "Заполнить запись"
-	ButtonMainMenuSendSelf      = "Отправить Себе"
-	ButtonMainMenuSendTherapist = "Отправить Терапевту"
+	ButtonMainMenuFillRecord    = config.MsgButtonFillRecord
+	ButtonMainMenuSendSelf      = config.MsgButtonSendSelf
+	ButtonMainMenuSendTherapist = config.MsgButtonSendTherapist
+	ButtonMainMenuDeliveries    = config.MsgButtonDeliveries
+	ButtonMainMenuExport        = config.MsgButtonExport
+	ButtonMainMenuAddNote       = config.MsgButtonAddNote
 )
+
+// ButtonReminderFill opens the section menu from a personal reminder without configured buttons.
+const ButtonReminderFill = config.MsgButtonReminderFill
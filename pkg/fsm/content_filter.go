@@ -0,0 +1,104 @@
+package fsm
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+const (
+	findingPhone  = "номер телефона"
+	findingEmail  = "email"
+	findingBanned = "недопустимые слова"
+)
+
+var (
+	phonePattern = regexp.MustCompile(`\+?\d(?:[\s\-()]*\d){9,}`)
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// filterMatch is one detector of the content filter; mask replaces what it found.
+type filterMatch struct {
+	finding string
+	pattern *regexp.Regexp
+	mask    string
+}
+
+// contentFilter screens free-text answers according to config.ContentFilterConfig.
+type contentFilter struct {
+	action   string
+	matchers []filterMatch
+}
+
+func newContentFilter(cfg config.ContentFilterConfig) *contentFilter {
+	if !cfg.Enabled {
+		return nil
+	}
+	f := &contentFilter{action: cfg.Action}
+	if cfg.Phones {
+		f.matchers = append(f.matchers, filterMatch{finding: findingPhone, pattern: phonePattern, mask: config.FilterMask})
+	}
+	if cfg.Emails {
+		f.matchers = append(f.matchers, filterMatch{finding: findingEmail, pattern: emailPattern, mask: config.FilterMask})
+	}
+	if len(cfg.BannedWords) > 0 {
+		words := make([]string, len(cfg.BannedWords))
+		for i, word := range cfg.BannedWords {
+			words[i] = regexp.QuoteMeta(strings.TrimSpace(word))
+		}
+		// \b is ASCII-only in RE2, so word boundaries are spelled out to cover Cyrillic.
+		pattern := regexp.MustCompile(`(?i)(^|[^\p{L}\p{N}_])(?:` + strings.Join(words, "|") + `)([^\p{L}\p{N}_]|$)`)
+		f.matchers = append(f.matchers, filterMatch{finding: findingBanned, pattern: pattern, mask: "${1}" + config.FilterMask + "${2}"})
+	}
+	return f
+}
+
+// scan returns the findings in text and the text with every match masked.
+func (f *contentFilter) scan(text string) (findings []string, masked string) {
+	masked = text
+	for _, m := range f.matchers {
+		if !m.pattern.MatchString(masked) {
+			continue
+		}
+		findings = append(findings, m.finding)
+		// A match consumes its trailing boundary, so an adjacent second match needs another pass.
+		masked = m.pattern.ReplaceAllString(m.pattern.ReplaceAllString(masked, m.mask), m.mask)
+	}
+	return findings, masked
+}
+
+// screenFreeText applies the configured content filter to a free-text answer before the strategy sees it.
+// It returns the text to hand to the strategy and false when the answer was blocked; the user is warned
+// about every finding, and in flag mode the findings are attached to the draft for the therapist.
+func screenFreeText(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, question config.QuestionConfig, text string) (string, bool) {
+	filter := newContentFilter(recordConfig.ContentFilter)
+	if filter == nil {
+		return text, true
+	}
+	findings, masked := filter.scan(text)
+	if len(findings) == 0 {
+		return text, true
+	}
+	found := strings.Join(findings, ", ")
+
+	switch filter.action {
+	case config.FilterActionBlock:
+		sendTransient(ctx, botPort, recordConfig, chatID, tr(userState, config.MsgFilterBlock, found))
+		return text, false
+	case config.FilterActionMask:
+		sendTransient(ctx, botPort, recordConfig, chatID, tr(userState, config.MsgFilterMask, found, config.FilterMask))
+		return masked, true
+	default:
+		if userState.CurrentRecord != nil {
+			for _, finding := range findings {
+				userState.CurrentRecord.Flag(question.StoreKey, finding)
+			}
+		}
+		sendTransient(ctx, botPort, recordConfig, chatID, tr(userState, config.MsgFilterFlag, found))
+		return text, true
+	}
+}
@@ -0,0 +1,121 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestContentFilterScan(t *testing.T) {
+	filter := newContentFilter(config.ContentFilterConfig{
+		Enabled:     true,
+		Action:      config.FilterActionMask,
+		Phones:      true,
+		Emails:      true,
+		BannedWords: []string{"дурак"},
+	})
+
+	tests := []struct {
+		name         string
+		text         string
+		wantFindings []string
+		wantMasked   string
+	}{
+		{name: "clean", text: "Всё хорошо, спал 8 часов", wantMasked: "Всё хорошо, спал 8 часов"},
+		{name: "phone", text: "звоните +7 (999) 123-45-67", wantFindings: []string{findingPhone}, wantMasked: "звоните ***"},
+		{name: "email", text: "пишите a.b@example.com", wantFindings: []string{findingEmail}, wantMasked: "пишите ***"},
+		{name: "banned word any case", text: "Сам Дурак, дурак!", wantFindings: []string{findingBanned}, wantMasked: "Сам ***, ***!"},
+		{name: "banned word inside another word", text: "дураками не рождаются", wantMasked: "дураками не рождаются"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, masked := filter.scan(tt.text)
+			if !reflect.DeepEqual(findings, tt.wantFindings) {
+				t.Fatalf("findings = %v, want %v", findings, tt.wantFindings)
+			}
+			if masked != tt.wantMasked {
+				t.Fatalf("masked = %q, want %q", masked, tt.wantMasked)
+			}
+		})
+	}
+}
+
+func TestContentFilterActions(t *testing.T) {
+	questions.RegisterBuiltins()
+
+	tests := []struct {
+		name       string
+		action     string
+		wantState  string
+		wantAnswer string
+		wantFlags  []string
+	}{
+		{name: "block asks again", action: config.FilterActionBlock, wantState: StateAnsweringQuestion},
+		{name: "mask stores masked text", action: config.FilterActionMask, wantState: StateSelectingSection, wantAnswer: "почта ***"},
+		{name: "flag stores text and marks it", action: config.FilterActionFlag, wantState: StateSelectingSection, wantAnswer: "почта me@example.com", wantFlags: []string{findingEmail}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &config.RecordConfig{
+				Sections: map[string]config.SectionConfig{
+					"notes": {Title: "Notes", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Заметка?", Type: "text", StoreKey: "note"}}},
+				},
+				ContentFilter: config.ContentFilterConfig{Enabled: true, Action: tt.action, Emails: true},
+			}
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := &state.UserState{
+				UserID:         5,
+				CurrentRecord:  state.NewRecord(),
+				CurrentSection: "notes",
+				MainMenuFSM:    handler.NewMainMenuFSM(),
+				RecordFSM:      handler.NewRecordFSM(),
+			}
+			userState.RecordFSM.SetState(StateAnsweringQuestion)
+
+			handleMessage(context.Background(), textMessage(5, "почта me@example.com"), userState, adapter, rc, nil)
+
+			if got := userState.RecordFSM.Current(); got != tt.wantState {
+				t.Fatalf("state = %s, want %s", got, tt.wantState)
+			}
+			if got := userState.CurrentRecord.Data["note"].String(); got != tt.wantAnswer {
+				t.Fatalf("answer = %q, want %q", got, tt.wantAnswer)
+			}
+			if got := userState.CurrentRecord.Flags["note"]; !reflect.DeepEqual(got, tt.wantFlags) {
+				t.Fatalf("flags = %v, want %v", got, tt.wantFlags)
+			}
+			warned := false
+			for _, call := range adapter.Calls {
+				if strings.Contains(call.Text, "⚠️ Ответ содержит: "+findingEmail) {
+					warned = true
+				}
+			}
+			if !warned {
+				t.Fatalf("expected a warning, calls: %+v", adapter.Calls)
+			}
+		})
+	}
+}
+
+func TestForwardShowsContentFlags(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"notes": {Title: "Notes", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Заметка?", Type: "text", StoreKey: "note"}}},
+	}}
+	record := state.NewRecord()
+	record.Data["note"] = state.StringAnswer("позвоните 89991234567")
+	record.Flag("note", findingPhone)
+
+	text, err := renderForwardMessage(buildForwardPayload(rc, record, &state.UserState{UserID: 1}))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.Contains(text, "позвоните 89991234567\n  ⚠️ "+findingPhone+"\n") {
+		t.Fatalf("flag missing from forward text:\n%s", text)
+	}
+}
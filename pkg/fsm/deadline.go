@@ -0,0 +1,33 @@
+package fsm
+
+import (
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// draftStart returns the moment deadlines of record count from: when its draft was started.
+func draftStart(record *state.Record, fallback time.Time) time.Time {
+	if record.StartedAt.IsZero() {
+		return fallback
+	}
+	return record.StartedAt
+}
+
+// markRecordLate flags record, being saved at savedAt, when that is past the survey deadline.
+func markRecordLate(recordConfig *config.RecordConfig, record *state.Record, savedAt time.Time) {
+	if recordConfig == nil || record == nil {
+		return
+	}
+	record.Late = config.DeadlinePassed(recordConfig.Deadline, draftStart(record, savedAt), savedAt)
+}
+
+// markSectionLate flags the section of record completed at now when that is past the section deadline.
+// A section stays late once marked, even if it is edited again in time.
+func markSectionLate(sectionID string, sectionConf config.SectionConfig, record *state.Record, now time.Time) {
+	if record == nil || !config.DeadlinePassed(sectionConf.Deadline, draftStart(record, now), now) {
+		return
+	}
+	record.MarkSectionLate(sectionID)
+}
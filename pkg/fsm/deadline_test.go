@@ -0,0 +1,66 @@
+package fsm
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestLateRecordForward(t *testing.T) {
+	defer func() { clock = time.Now }()
+	started := time.Date(2025, 3, 1, 8, 0, 0, 0, time.Local)
+	rc := &config.RecordConfig{
+		Deadline: "21:00",
+		Sections: map[string]config.SectionConfig{
+			"morning": {Title: "Утро", Deadline: "12:00", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Сон", StoreKey: "sleep"}}},
+			"evening": {Title: "Вечер", Questions: []config.QuestionConfig{{ID: "q2", Prompt: "День", StoreKey: "day"}}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		sectionAt   time.Duration // Since started
+		savedAt     time.Duration
+		saved       bool // Otherwise the draft is sent at savedAt
+		wantLate    bool
+		wantSection bool
+	}{
+		{name: "in time", sectionAt: time.Hour, savedAt: 2 * time.Hour, saved: true},
+		{name: "late section only", sectionAt: 5 * time.Hour, savedAt: 6 * time.Hour, saved: true, wantSection: true},
+		{name: "saved after the deadline", sectionAt: time.Hour, savedAt: 14 * time.Hour, saved: true, wantLate: true},
+		{name: "draft sent after the deadline", sectionAt: time.Hour, savedAt: 14 * time.Hour, wantLate: true},
+		{name: "draft sent in time", sectionAt: time.Hour, savedAt: 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := state.NewRecord()
+			record.StartedAt = started
+			record.Data = stringAnswers(map[string]string{"sleep": "8", "day": "ok"})
+			markSectionLate("morning", rc.Sections["morning"], record, started.Add(tt.sectionAt))
+			markSectionLate("evening", rc.Sections["evening"], record, started.Add(tt.sectionAt))
+			clock = func() time.Time { return started.Add(tt.savedAt) }
+			if tt.saved {
+				record.IsSaved = true
+				record.CreatedAt = started.Add(tt.savedAt)
+				markRecordLate(rc, record, record.CreatedAt)
+			}
+
+			text, err := renderForwardMessage(buildForwardPayload(rc, record, &state.UserState{UserID: 1}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Contains(text, "Сдано после срока (21:00)"); got != tt.wantLate {
+				t.Fatalf("record marked late = %t, want %t:\n%s", got, tt.wantLate, text)
+			}
+			if got := strings.Contains(text, "## Утро ⏰ после срока"); got != tt.wantSection {
+				t.Fatalf("section marked late = %t, want %t:\n%s", got, tt.wantSection, text)
+			}
+			if strings.Contains(text, "## Вечер ⏰") {
+				t.Fatalf("section without a deadline marked late:\n%s", text)
+			}
+		})
+	}
+}
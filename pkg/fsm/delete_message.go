@@ -2,15 +2,15 @@ package fsm
 
 import (
 	"context"
-	"log"
-	"os"
-	"strings"
+	"log/slog"
 
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
 )
 
-// deleteUserTextMessage removes user messages for text-type answers when enabled.
+// deleteUserTextMessage removes a user's typed answer (to any question type that takes text) when the
+// delete_user_messages flag is on.
 func deleteUserTextMessage(ctx context.Context, botPort botport.BotPort, chatID int64, messageID int, questionType string) {
 	if messageID == 0 {
 		return
@@ -18,14 +18,15 @@ func deleteUserTextMessage(ctx context.Context, botPort botport.BotPort, chatID
 	if !deleteEnabled() {
 		return
 	}
-	if strings.ToLower(questionType) != questions.TypeText {
+	strategy := questions.Get(questionType)
+	if strategy == nil || !strategy.Capabilities().NeedsText {
 		return
 	}
 	if err := botPort.DeleteMessage(ctx, chatID, messageID); err != nil {
-		log.Printf("[deleteUserTextMessage] failed to delete message %d for chat %d: %v", messageID, chatID, err)
+		slog.WarnContext(ctx, "deleting the user message failed", "message_id", messageID, "chat_id", chatID, "err", err)
 	}
 }
 
 func deleteEnabled() bool {
-	return strings.EqualFold(os.Getenv("DELETE_USER_MESSAGES"), "true")
+	return config.FeatureEnabled(config.FeatureDeleteUserMessages)
 }
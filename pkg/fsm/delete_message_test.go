@@ -0,0 +1,52 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestDeleteUserTextMessage(t *testing.T) {
+	questions.RegisterBuiltins()
+	defer func() { _ = config.SetFeature(config.FeatureDeleteUserMessages, false) }()
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		question   config.QuestionConfig
+		answer     string
+		wantDelete bool
+	}{
+		{name: "text answer", enabled: true, question: config.QuestionConfig{ID: "q1", Prompt: "Name?", Type: "text", StoreKey: "k1"}, answer: "Ann", wantDelete: true},
+		{name: "text rating entry", enabled: true, question: config.QuestionConfig{ID: "q1", Prompt: "Events?", Type: "text_rating", StoreKey: "k1"}, answer: "walk", wantDelete: true},
+		{name: "typed reply to buttons", enabled: true, question: config.QuestionConfig{ID: "q1", Prompt: "Mood?", Type: "buttons", StoreKey: "k1", Options: []config.ButtonOption{{Text: "Ok", Value: "ok"}}}, answer: "ok"},
+		{name: "flag off", question: config.QuestionConfig{ID: "q1", Prompt: "Name?", Type: "text", StoreKey: "k1"}, answer: "Ann"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := config.SetFeature(config.FeatureDeleteUserMessages, tt.enabled); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+				"sec": {Title: "Section", Questions: []config.QuestionConfig{tt.question}},
+			}}
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := &state.UserState{UserID: 8, CurrentRecord: state.NewRecord(), MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+			userState.RecordFSM.SetState(StateAnsweringQuestion)
+			userState.CurrentSection = "sec"
+
+			msg := textMessage(8, tt.answer)
+			handleMessage(context.Background(), msg, userState, adapter, rc, nil)
+
+			deleted := adapter.LastCall("delete_message")
+			if got := deleted != nil && deleted.MessageID == msg.MessageID; got != tt.wantDelete {
+				t.Fatalf("user message deleted = %t, want %t", got, tt.wantDelete)
+			}
+		})
+	}
+}
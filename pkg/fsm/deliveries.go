@@ -0,0 +1,52 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// deliveriesShown caps the list of sent records.
+const deliveriesShown = 10
+
+var deliveryStatusLabels = map[state.DeliveryStatus]config.MessageKey{
+	state.DeliveryDelivered: config.MsgDeliveryDelivered,
+	state.DeliveryRead:      config.MsgDeliveryRead,
+	state.DeliveryFailed:    config.MsgDeliveryNotDelivered,
+	state.DeliveryRetrying:  config.MsgDeliveryRetrying,
+}
+
+// showDeliveries lists the user's latest forwards to the therapist with their status.
+func showDeliveries(ctx context.Context, userState *state.UserState, botPort botport.BotPort, chatID int64, store *state.Store) {
+	if store == nil {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgDeliveriesUnavailable), nil)
+		return
+	}
+	_, _ = botPort.SendMessage(ctx, chatID, renderDeliveries(userState, store.Deliveries(userState.UserID)), nil)
+}
+
+func renderDeliveries(userState *state.UserState, deliveries []*state.Delivery) string {
+	if len(deliveries) == 0 {
+		return tr(userState, config.MsgDeliveriesEmpty)
+	}
+	var b strings.Builder
+	b.WriteString(tr(userState, config.MsgDeliveriesHeader) + "\n")
+	if len(deliveries) > deliveriesShown {
+		deliveries = deliveries[:deliveriesShown]
+	}
+	for _, d := range deliveries {
+		label := string(d.Status)
+		if key, ok := deliveryStatusLabels[d.Status]; ok {
+			label = tr(userState, key)
+		}
+		fmt.Fprintf(&b, "\n%s — %s", d.SentAt.Format("02.01.2006 15:04"), label)
+		if d.Acked() {
+			fmt.Fprintf(&b, " (%s)", d.AckedAt.Format("02.01 15:04"))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestRenderDeliveries(t *testing.T) {
+	sent := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		deliveries []*state.Delivery
+		want       []string
+	}{
+		{name: "empty", want: []string{"ещё ничего не отправляли"}},
+		{
+			name: "statuses",
+			deliveries: []*state.Delivery{
+				{Status: state.DeliveryRead, SentAt: sent, AckedAt: sent.Add(time.Hour)},
+				{Status: state.DeliveryFailed, SentAt: sent},
+				{Status: state.DeliveryRetrying, SentAt: sent},
+				{Status: state.DeliveryDelivered, SentAt: sent},
+			},
+			want: []string{"01.03.2025 09:30 — ✅ прочитано (01.03 10:30)", "❌ не доставлено", "🔁 повторная отправка", "📤 доставлено"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderDeliveries(nil, tt.deliveries)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Fatalf("missing %q in:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliveriesViewShowsFailedForward(t *testing.T) {
+	config.SetTargetUserID(600)
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+	adapter := &fakeadapter.FakeAdapter{}
+	store := newTestHandler(adapter, rc).Store()
+	userState := store.GetOrCreateUserState(12, "Patient")
+	userState.CurrentRecord = state.NewRecord()
+	userState.CurrentRecord.Data["f1"] = state.StringAnswer("Value")
+	adapter.Fail("send_message", errors.New("blocked"))
+
+	handleMessage(context.Background(), textMessage(12, tr(nil, ButtonMainMenuSendTherapist)), userState, adapter, rc, store)
+	handleMessage(context.Background(), textMessage(12, tr(nil, ButtonMainMenuDeliveries)), userState, adapter, rc, store)
+
+	deliveries := store.Deliveries(12)
+	if len(deliveries) != 1 || deliveries[0].Status != state.DeliveryFailed || deliveries[0].Error == "" {
+		t.Fatalf("deliveries = %+v, want one failed", deliveries)
+	}
+	if view := adapter.LastCall("send_message"); view == nil || !strings.Contains(view.Text, "❌ не доставлено") {
+		t.Fatalf("expected failed delivery in the view, got %+v", view)
+	}
+}
+
+// retryingPort waits out one rate limit on every send, noting the deliveries of userID meanwhile.
+type retryingPort struct {
+	*fakeadapter.FakeAdapter
+	store  *state.Store
+	userID int64
+	during []state.Delivery
+}
+
+func (p *retryingPort) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts ...botport.SendOption) (botport.BotMessage, error) {
+	if onRetry := botport.ApplySendOptions(opts).OnRetry; onRetry != nil {
+		onRetry(fakeadapter.RateLimited("send_message", time.Second))
+		for _, d := range p.store.Deliveries(p.userID) {
+			p.during = append(p.during, *d)
+		}
+	}
+	return p.FakeAdapter.SendMessage(ctx, chatID, text, markup, opts...)
+}
+
+func TestDeliveryShowsRetryingWhileRateLimited(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+	adapter := &fakeadapter.FakeAdapter{}
+	store := newTestHandler(adapter, rc).Store()
+	userState := store.GetOrCreateUserState(13, "Patient")
+	record := state.NewRecord()
+	record.Data["f1"] = state.StringAnswer("Value")
+	port := &retryingPort{FakeAdapter: adapter, store: store, userID: 13}
+
+	if _, err := sendDelivery(context.Background(), port, rc, userState, record, 600, store, false); err != nil {
+		t.Fatalf("sendDelivery: %v", err)
+	}
+
+	if len(port.during) != 1 || port.during[0].Status != state.DeliveryRetrying || !strings.Contains(port.during[0].Error, "rate_limited") {
+		t.Fatalf("deliveries while waiting = %+v, want one retrying", port.during)
+	}
+	if got := store.Deliveries(13); len(got) != 1 || got[0].Status != state.DeliveryDelivered || got[0].ID != port.during[0].ID {
+		t.Fatalf("deliveries after the send = %+v, want the same one delivered", got)
+	}
+}
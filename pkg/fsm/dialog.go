@@ -0,0 +1,136 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/dialog"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// ReplyCancel is the value of the reply:<value> callback that drops the reply being written.
+const ReplyCancel = "cancel"
+
+// dialogs tracks the replies the therapist is writing. They are kept in memory only; a restart drops
+// an unfinished reply.
+var dialogs = dialog.NewRouter()
+
+// therapistKeyboard is the markup of a record forwarded to the therapist, labelled in their language
+// lang: "received" for a delivery awaiting acknowledgement (ackID, empty without one) and "reply" to
+// write back to userID.
+func therapistKeyboard(lang string, userID int64, ackID string) *botport.Keyboard {
+	row := botport.NewRow()
+	if ackID != "" {
+		row = append(row, botport.NewButton(config.Message(lang, config.MsgButtonAck), CallbackAckPrefix+ackID))
+	}
+	row = append(row, botport.NewButton(config.Message(lang, config.MsgButtonReply), CallbackReplyPrefix+strconv.FormatInt(userID, 10)))
+	return botport.NewKeyboard(row)
+}
+
+// handleReplyCallback serves the therapist's "reply" tap under a forwarded record, and the cancel
+// button of the prompt it opens.
+func handleReplyCallback(ctx context.Context, query *botport.InboundEvent, therapist *state.UserState, botPort botport.BotPort, store *state.Store, value string) {
+	chatID := query.ChatID
+	if value == ReplyCancel {
+		dialogs.Cancel(therapist.UserID)
+		if _, err := botPort.EditMessage(ctx, chatID, query.MessageID, tr(therapist, config.MsgReplyCancelled), botport.NewKeyboard()); err != nil && !botport.IsCode(err, "message_not_modified") {
+			slog.ErrorContext(ctx, "updating the reply prompt failed", "message_id", query.MessageID, "err", err)
+		}
+		return
+	}
+
+	userID, err := strconv.ParseInt(value, 10, 64)
+	therapistID, lang := therapist.UserID, therapist.Lang()
+	whenUnlocked(ctx, func() {
+		patient, ok := answerablePatient(store, therapistID, userID)
+		if err != nil || !ok {
+			slog.WarnContext(ctx, "user cannot reply", "user_id", therapistID, "value", value)
+			_ = botPort.AnswerCallback(ctx, query.CallbackID, config.Message(lang, config.MsgActionUnavailable))
+			return
+		}
+
+		dialogs.Open(therapistID, userID)
+		slog.InfoContext(ctx, "therapist writes a reply", "therapist_id", therapistID, "user_id", userID)
+		keyboard := botport.NewKeyboard(botport.NewRow(
+			botport.NewButton(config.Message(lang, config.MsgButtonReplyCancel), CallbackReplyPrefix+ReplyCancel),
+		))
+		_, _ = botPort.SendMessage(ctx, chatID, config.Message(lang, config.MsgReplyPrompt, patient.name, userID), keyboard)
+	})
+}
+
+// replyPatient is what a therapist's reply needs of the patient.
+type replyPatient struct {
+	name string
+	lang string
+}
+
+// answerablePatient reads userID with their Mu held and reports whether therapistID may answer them:
+// only the therapist the user's records go to may. Callers hold no user's Mu.
+func answerablePatient(store *state.Store, therapistID, userID int64) (replyPatient, bool) {
+	if store == nil {
+		return replyPatient{}, false
+	}
+	patient, ok := store.LockUser(userID)
+	if !ok {
+		return replyPatient{}, false
+	}
+	defer patient.Mu.Unlock()
+	return replyPatient{name: patient.UserName, lang: patient.Lang()}, targetFor(patient) == therapistID
+}
+
+// relayReply passes the text of message to the user the therapist is replying to and reports whether
+// it takes the message: the one of the open reply, or else the one whose forwarded record message
+// answers with a Telegram reply, to any of its parts. Commands, main menu buttons and messages without
+// text are left to the usual handling. The patient is read and written to once the therapist's Mu is
+// released.
+func relayReply(ctx context.Context, message *botport.InboundEvent, therapist *state.UserState, botPort botport.BotPort, store *state.Store) bool {
+	recipient, ok := dialogs.Recipient(therapist.UserID)
+	if !ok {
+		recipient, ok = repliedPatient(therapist, store, message.ReplyTo)
+	}
+	if !ok || message.IsCommand() || strings.TrimSpace(message.Text) == "" {
+		return false
+	}
+	if _, isMenu := mainMenuButton(message.Text); isMenu {
+		return false
+	}
+
+	chatID, text := message.ChatID, message.Text
+	therapistID, lang := therapist.UserID, therapist.Lang()
+	whenUnlocked(ctx, func() {
+		patient, ok := answerablePatient(store, therapistID, recipient)
+		if !ok {
+			slog.WarnContext(ctx, "user cannot reply", "user_id", therapistID, "recipient", recipient)
+			dialogs.Cancel(therapistID)
+			_, _ = botPort.SendMessage(ctx, chatID, config.Message(lang, config.MsgActionUnavailable), nil)
+			return
+		}
+		if _, err := botPort.SendMessage(ctx, recipient, config.Message(patient.lang, config.MsgTherapistReply, text), nil); err != nil {
+			slog.ErrorContext(ctx, "relaying the reply failed", "user_id", recipient, "err", err)
+			_, _ = botPort.SendMessage(ctx, chatID, config.Message(lang, config.MsgReplyFailed), nil)
+			return
+		}
+		dialogs.Sent(therapistID)
+		slog.InfoContext(ctx, "reply relayed", "therapist_id", therapistID, "user_id", recipient)
+		_, _ = botPort.SendMessage(ctx, chatID, config.Message(lang, config.MsgReplySent), nil)
+	})
+	return true
+}
+
+// repliedPatient returns the patient whose record was forwarded to therapist in message messageID, or
+// in a forward that message is a part of. Whether the therapist may still answer them is checked
+// with answerablePatient.
+func repliedPatient(therapist *state.UserState, store *state.Store, messageID int) (int64, bool) {
+	if store == nil || messageID == 0 {
+		return 0, false
+	}
+	deliveries := store.MessageDeliveries(therapist.UserID, messageID)
+	if len(deliveries) == 0 {
+		return 0, false
+	}
+	return deliveries[0].UserID, true
+}
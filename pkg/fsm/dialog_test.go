@@ -0,0 +1,167 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestTherapistReply(t *testing.T) {
+	config.SetTargetUserID(600)
+	defer config.SetTargetUserID(0)
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+	replyData := CallbackReplyPrefix + "20"
+
+	tests := []struct {
+		name      string
+		from      int64
+		failSend  bool
+		cancel    bool
+		wantReply bool   // The patient gets the therapist's text
+		wantText  string // Last message to the one who tapped "reply"
+	}{
+		{name: "relayed", from: 600, wantReply: true, wantText: config.Message("ru", config.MsgReplySent)},
+		{name: "send fails", from: 600, failSend: true, wantText: config.Message("ru", config.MsgReplyFailed)},
+		{name: "cancelled", from: 600, cancel: true},
+		{name: "not the therapist", from: 21},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer dialogs.Cancel(tt.from)
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			patient := store.GetOrCreateUserState(20, "Anna")
+			user := store.GetOrCreateUserState(tt.from, "Reader")
+			record := state.NewRecord()
+			record.Data["f1"] = state.StringAnswer("Value")
+			record.IsSaved = true
+			patient.Records = []*state.Record{record}
+
+			handleForwardAnsweredSections(context.Background(), patient, adapter, rc, 20, store)
+			if forwarded := adapter.Calls[0]; forwarded.ChatID != 600 || !forwarded.Keyboard.HasCallback(replyData) {
+				t.Fatalf("forward must carry the reply button, got %+v", forwarded)
+			}
+
+			adapter.Calls = nil
+			handleCallbackQuery(context.Background(), callbackQuery(tt.from, 5, replyData), user, adapter, rc, store)
+			if tt.from != 600 {
+				if answer := adapter.LastCall("answer_callback"); answer == nil || answer.Text != config.Message("ru", config.MsgActionUnavailable) {
+					t.Fatalf("callback answer %+v, want the action to be unavailable", answer)
+				}
+				if _, ok := dialogs.Recipient(tt.from); ok {
+					t.Fatalf("only the therapist may open a reply")
+				}
+				return
+			}
+			prompt := adapter.LastCall("send_message")
+			if prompt == nil || prompt.Text != config.Message("ru", config.MsgReplyPrompt, "Anna", 20) || !prompt.Keyboard.HasCallback(CallbackReplyPrefix+ReplyCancel) {
+				t.Fatalf("reply prompt %+v", prompt)
+			}
+
+			if tt.cancel {
+				handleCallbackQuery(context.Background(), callbackQuery(600, prompt.MessageID, CallbackReplyPrefix+ReplyCancel), user, adapter, rc, store)
+				if edit := adapter.LastCall("edit_message"); edit == nil || edit.Text != config.Message("ru", config.MsgReplyCancelled) {
+					t.Fatalf("prompt after cancel %+v", edit)
+				}
+			}
+			if tt.failSend {
+				adapter.Fail("send_message", errors.New("network down"))
+			}
+			adapter.Calls = nil
+			handleMessage(context.Background(), textMessage(600, "See you on Monday"), user, adapter, rc, store)
+
+			relayed := false
+			for _, call := range adapter.Calls {
+				if call.ChatID == 20 && call.Text == config.Message("ru", config.MsgTherapistReply, "See you on Monday") {
+					relayed = true
+				}
+			}
+			if relayed != tt.wantReply {
+				t.Fatalf("relayed = %t, want %t: %+v", relayed, tt.wantReply, adapter.Calls)
+			}
+			if tt.wantText != "" {
+				if last := adapter.LastCall("send_message"); last == nil || last.ChatID != 600 || last.Text != tt.wantText {
+					t.Fatalf("therapist got %+v, want %q", last, tt.wantText)
+				}
+			}
+			_, composing := dialogs.Recipient(600)
+			if composing != tt.failSend {
+				t.Fatalf("composing = %t after the text, want %t", composing, tt.failSend)
+			}
+		})
+	}
+}
+
+// splittingPort sends every forward to the therapist as two parts, as the Telegram adapter does with a
+// record over the length limit.
+type splittingPort struct {
+	*fakeadapter.FakeAdapter
+	therapistID int64
+}
+
+func (p *splittingPort) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts ...botport.SendOption) (botport.BotMessage, error) {
+	msg, err := p.FakeAdapter.SendMessage(ctx, chatID, text, markup, opts...)
+	if err == nil && chatID == p.therapistID && markup != nil {
+		msg.PartIDs = []int{msg.MessageID - 1000, msg.MessageID}
+	}
+	return msg, err
+}
+
+func TestTherapistReplyToAnyPartOfAForward(t *testing.T) {
+	config.SetTargetUserID(600)
+	defer config.SetTargetUserID(0)
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+
+	tests := []struct {
+		name      string
+		replyTo   func(forwarded botport.BotMessage) int
+		wantReply bool
+	}{
+		{name: "first part", replyTo: func(m botport.BotMessage) int { return m.PartIDs[0] }, wantReply: true},
+		{name: "last part", replyTo: func(m botport.BotMessage) int { return m.MessageID }, wantReply: true},
+		{name: "another message", replyTo: func(botport.BotMessage) int { return 3 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			port := &splittingPort{FakeAdapter: adapter, therapistID: 600}
+			store := newTestHandler(adapter, rc).Store()
+			patient := store.GetOrCreateUserState(20, "Anna")
+			therapist := store.GetOrCreateUserState(600, "Therapist")
+			record := state.NewRecord()
+			record.Data["f1"] = state.StringAnswer("Value")
+			record.IsSaved = true
+			patient.Records = []*state.Record{record}
+
+			delivery, err := sendDelivery(context.Background(), port, rc, patient, record, 600, store, false)
+			if err != nil {
+				t.Fatalf("sendDelivery: %v", err)
+			}
+			forwarded := botport.BotMessage{MessageID: delivery.MessageID, PartIDs: delivery.PartIDs}
+
+			adapter.Calls = nil
+			reply := textMessage(600, "See you on Monday")
+			reply.ReplyTo = tt.replyTo(forwarded)
+			handleMessage(context.Background(), reply, therapist, port, rc, store)
+
+			relayed := false
+			for _, call := range adapter.Calls {
+				if call.ChatID == 20 && call.Text == config.Message("ru", config.MsgTherapistReply, "See you on Monday") {
+					relayed = true
+				}
+			}
+			if relayed != tt.wantReply {
+				t.Fatalf("relayed = %t, want %t: %+v", relayed, tt.wantReply, adapter.Calls)
+			}
+		})
+	}
+}
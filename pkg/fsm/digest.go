@@ -0,0 +1,162 @@
+package fsm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// maxDigestLength caps a digest message in characters, below Telegram's 4096 so the header fits. A day
+// that does not fit is sent as several digests.
+const maxDigestLength = 4000
+
+type digestEntry struct {
+	Time     string
+	Late     bool
+	Sections []forwardSection
+}
+
+type digestPayload struct {
+	Lang     string
+	UserID   int64
+	UserName string
+	Day      string
+	Entries  []digestEntry
+}
+
+var digestTpl = template.Must(template.New("digest").Funcs(templateFuncs).Parse(`{{tr .Lang "forward_header" .UserName .UserID}}
+{{tr .Lang "digest_day" .Day (len .Entries)}}
+{{$lang := .Lang}}{{range .Entries}}
+🕘 {{.Time}}{{if .Late}} {{tr $lang "forward_section_late"}}{{end}}
+{{range .Sections}}## {{.Title}}{{if .Late}} {{tr $lang "forward_section_late"}}{{end}}
+{{range .Questions}}- {{.Prompt}}:
+  {{.Answer}}
+{{if .Flags}}  ⚠️ {{.Flags}}
+{{end}}{{end}}{{end}}{{end}}`))
+
+// sendDigestDeliveries forwards records to targetUserID compacted into one message per day, oldest day
+// first, instead of one message per record. Every record still gets its own delivery entry; entries sent
+// together share the message, and with requireAck its "received" button acknowledges all of them.
+func sendDigestDeliveries(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, userState *state.UserState, records []*state.Record, targetUserID int64, store *state.Store, requireAck bool) (sent, failed int) {
+	lang := store.Lang(targetUserID)
+	for _, batch := range digestBatches(recordConfig, userState, records, lang) {
+		now := time.Now()
+		deliveries := make([]*state.Delivery, 0, len(batch))
+		for _, record := range batch {
+			deliveries = append(deliveries, &state.Delivery{
+				ID:       idGenerator.NewID(),
+				UserID:   userState.UserID,
+				RecordID: deliveryRecordID(record),
+				TargetID: targetUserID,
+				SentAt:   now,
+			})
+		}
+		ackID := ""
+		if requireAck {
+			ackID = deliveries[0].ID
+		}
+
+		msg, err := deliverDigest(ctx, botPort, recordConfig, userState, batch, targetUserID, lang, therapistKeyboard(lang, userState.UserID, ackID), markRetrying(store, deliveries...))
+		for _, delivery := range deliveries {
+			if err != nil {
+				delivery.Status = state.DeliveryFailed
+				delivery.Error = err.Error()
+			} else {
+				delivery.Status = state.DeliveryDelivered
+				delivery.MessageID, delivery.PartIDs = msg.MessageID, msg.PartIDs
+			}
+			store.AddDelivery(delivery)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "sending the digest failed", "err", err)
+			failed += len(batch)
+			continue
+		}
+		sent += len(batch)
+		slog.InfoContext(ctx, "digest sent", "records", len(batch), "user_id", userState.UserID, "target_id", targetUserID, "ack", requireAck)
+	}
+	return sent, failed
+}
+
+// digestBatches groups records by the day they were created and splits a day that would not fit into
+// one message in lang.
+func digestBatches(recordConfig *config.RecordConfig, userState *state.UserState, records []*state.Record, lang string) [][]*state.Record {
+	sorted := append([]*state.Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	var batches [][]*state.Record
+	var current []*state.Record
+	for _, record := range sorted {
+		if len(current) > 0 {
+			sameDayAsBatch := sameDay(current[0].CreatedAt, record.CreatedAt)
+			text, err := renderDigestMessage(buildDigestPayload(recordConfig, userState, append(current, record), lang))
+			if !sameDayAsBatch || err != nil || utf8.RuneCountInString(text) > maxDigestLength {
+				batches = append(batches, current)
+				current = nil
+			}
+		}
+		current = append(current, record)
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// deliverDigest renders records as one digest in lang, the language of targetUserID, and sends it like
+// deliverRecord does.
+func deliverDigest(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, userState *state.UserState, records []*state.Record, targetUserID int64, lang string, markup interface{}, opts ...botport.SendOption) (botport.BotMessage, error) {
+	if err := checkTargetLink(userState, targetUserID); err != nil {
+		return botport.BotMessage{}, fmt.Errorf("digest for user %d to %d: %w", userState.UserID, targetUserID, err)
+	}
+	text, err := renderDigestMessage(buildDigestPayload(recordConfig, userState, records, lang))
+	if err != nil {
+		return botport.BotMessage{}, fmt.Errorf("digest for user %d: %w: %v", userState.UserID, errForwardRender, err)
+	}
+	msg, err := botPort.SendMessage(ctx, targetUserID, text, markup, append(recordSendOptions(), opts...)...)
+	if err != nil {
+		if noteTargetForbidden(userState, targetUserID, err) {
+			return botport.BotMessage{}, fmt.Errorf("digest for user %d to %d: %w: %w", userState.UserID, targetUserID, errTargetBlocked, err)
+		}
+		return botport.BotMessage{}, fmt.Errorf("digest for user %d to %d: %w: %w", userState.UserID, targetUserID, errForwardSend, err)
+	}
+	return msg, nil
+}
+
+// buildDigestPayload lists records of one day under a single header in lang; records without a creation
+// time are dated today.
+func buildDigestPayload(recordConfig *config.RecordConfig, userState *state.UserState, records []*state.Record, lang string) digestPayload {
+	payload := digestPayload{Lang: lang, UserID: userState.UserID, UserName: userState.UserName}
+	for _, record := range records {
+		created := record.CreatedAt
+		if created.IsZero() {
+			created = time.Now()
+		}
+		if payload.Day == "" {
+			payload.Day = created.Format("02.01.2006")
+		}
+		payload.Entries = append(payload.Entries, digestEntry{
+			Time:     created.Format("15:04"),
+			Late:     record.Late,
+			Sections: buildForwardPayload(recordConfig, record, userState).Sections,
+		})
+	}
+	return payload
+}
+
+func renderDigestMessage(payload digestPayload) (string, error) {
+	var buf bytes.Buffer
+	if err := digestTpl.Execute(&buf, payload); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,111 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestDigestCompactsRecordsPerDay(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+	day := time.Date(2025, 3, 1, 9, 30, 0, 0, time.Local)
+	saved := func(at time.Time, answer string) *state.Record {
+		record := state.NewRecord()
+		record.Data["f1"] = state.StringAnswer(answer)
+		record.IsSaved = true
+		record.CreatedAt = at
+		return record
+	}
+	long := strings.Repeat("я", maxDigestLength/2)
+
+	tests := []struct {
+		name         string
+		records      []*state.Record
+		wantMessages []string // Substrings of each forwarded message, in order
+	}{
+		{
+			name:         "same day in one message",
+			records:      []*state.Record{saved(day.Add(2*time.Hour), "later"), saved(day, "earlier")},
+			wantMessages: []string{"📅 01.03.2025 — записей: 2\n\n🕘 09:30\n## Main\n- Field:\n  earlier\n\n🕘 11:30\n## Main\n- Field:\n  later"},
+		},
+		{
+			name:         "one message per day",
+			records:      []*state.Record{saved(day, "first"), saved(day.Add(24*time.Hour), "second")},
+			wantMessages: []string{"📅 01.03.2025 — записей: 1", "📅 02.03.2025 — записей: 1"},
+		},
+		{
+			name:         "day over the size limit is split",
+			records:      []*state.Record{saved(day, long), saved(day.Add(time.Hour), long)},
+			wantMessages: []string{"🕘 09:30", "🕘 10:30"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			patient := store.GetOrCreateUserState(30, "Patient")
+
+			sent, failed := sendDigestDeliveries(context.Background(), adapter, rc, patient, tt.records, 700, store, false)
+
+			if sent != len(tt.records) || failed != 0 {
+				t.Fatalf("sent=%d failed=%d, want %d sent", sent, failed, len(tt.records))
+			}
+			if len(adapter.Calls) != len(tt.wantMessages) {
+				t.Fatalf("messages = %d, want %d: %+v", len(adapter.Calls), len(tt.wantMessages), adapter.Calls)
+			}
+			for i, want := range tt.wantMessages {
+				if !strings.Contains(adapter.Calls[i].Text, want) {
+					t.Fatalf("message %d = %q, want it to contain %q", i, adapter.Calls[i].Text, want)
+				}
+			}
+			if got := store.Deliveries(30); len(got) != len(tt.records) {
+				t.Fatalf("deliveries = %d, want one per record", len(got))
+			}
+		})
+	}
+}
+
+func TestDigestAckReleasesAllRecords(t *testing.T) {
+	config.SetTargetUserID(500)
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+	adapter := &fakeadapter.FakeAdapter{}
+	store := newTestHandler(adapter, rc).Store()
+	patient := store.GetOrCreateUserState(31, "Patient")
+	therapist := store.GetOrCreateUserState(500, "Therapist")
+	day := time.Date(2025, 3, 1, 9, 30, 0, 0, time.Local)
+	for _, at := range []time.Time{day, day.Add(time.Hour)} {
+		record := state.NewRecord()
+		record.Data["f1"] = state.StringAnswer("Value")
+		record.IsSaved = true
+		record.CreatedAt = at
+		patient.Records = append(patient.Records, record)
+	}
+
+	sendDigestDeliveries(context.Background(), adapter, rc, patient, patient.Records, 500, store, true)
+	pending := store.PendingDeliveries(31)
+	if len(pending) != 2 || len(adapter.Calls) != 1 {
+		t.Fatalf("expected 2 pending deliveries in one message, got %d in %d", len(pending), len(adapter.Calls))
+	}
+
+	forwarded := adapter.Calls[0]
+	ackData := CallbackAckPrefix + pending[0].ID
+	if !forwarded.Keyboard.HasCallback(ackData) {
+		t.Fatalf("digest must carry one ack button for %s, got %+v", ackData, forwarded.Keyboard)
+	}
+	query := callbackQuery(500, forwarded.MessageID, ackData)
+	query.Text = forwarded.Text
+	handleCallbackQuery(context.Background(), query, therapist, adapter, rc, store)
+
+	if len(store.PendingDeliveries(31)) != 0 || len(patient.Records) != 0 {
+		t.Fatalf("one tap must acknowledge the whole digest, pending=%d records=%d", len(store.PendingDeliveries(31)), len(patient.Records))
+	}
+}
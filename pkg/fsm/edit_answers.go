@@ -0,0 +1,71 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// showEditAnswersMenu replaces the section menu with the answered questions of the draft, one button
+// per answer in section order. A button carries the section and question IDs, so a stale list still
+// opens the right question.
+func showEditAnswersMenu(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int) {
+	keyboard := botport.NewKeyboard()
+	for _, sectionID := range recordConfig.SectionIDs() {
+		sectionConf := recordConfig.Sections[sectionID]
+		if !sectionOpen(userState, sectionConf) {
+			continue
+		}
+		for _, q := range sectionConf.Questions {
+			answer := currentAnswer(userState.CurrentRecord, q)
+			if answer == "" || !userState.CurrentRecord.Visible(q) {
+				continue
+			}
+			label := fmt.Sprintf("%s: %s", truncateString(q.Prompt, 30), truncateString(answer, 20))
+			keyboard.AddRow(botport.NewButton(label, CallbackEditPrefix+sectionID+":"+q.ID))
+		}
+	}
+	keyboard.AddRow(botport.NewButton(tr(userState, config.MsgButtonBack), CallbackActionPrefix+ActionEditBack))
+
+	text := tr(userState, config.MsgChooseAnswerToEdit)
+	sentMsg, err := botPort.EditMessage(ctx, chatID, messageID, text, keyboard)
+	if err != nil && !botport.IsCode(err, "message_not_modified") {
+		slog.ErrorContext(ctx, "showing the answers failed", "user_id", userState.UserID, "err", err)
+		return
+	}
+	if err == nil {
+		setLastPrompt(userState, chatID, sentMsg.MessageID, text, keyboard)
+	}
+}
+
+// editAnswer re-asks one answered question of the draft. The answer goes through the usual flow, and
+// processAnswer then returns to the section menu instead of moving on to the next question.
+func editAnswer(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int, sectionID, questionID string) bool {
+	sectionConf, ok := recordConfig.Sections[sectionID]
+	if !ok || !sectionOpen(userState, sectionConf) {
+		slog.InfoContext(ctx, "section not available", "section", sectionID, "user_id", userState.UserID)
+		return false
+	}
+	for idx, q := range sectionConf.Questions {
+		if q.ID != questionID || !userState.CurrentRecord.Visible(q) {
+			continue
+		}
+		slog.InfoContext(ctx, "user edits an answer", "user_id", userState.UserID, "question", questionID, "section", sectionID)
+		userState.CurrentSection = sectionID
+		userState.CurrentQuestion = idx
+		userState.SectionSnapshot = snapshotSection(sectionConf, userState.CurrentRecord)
+		userState.EditingAnswer = true
+		if err := userState.RecordFSM.Event(ctx, EventSelectSection, recordEvent{User: userState, ChatID: chatID, MessageID: messageID}); err != nil {
+			slog.ErrorContext(ctx, "select section event failed", "user_id", userState.UserID, "err", err)
+			userState.EditingAnswer = false
+			_ = userState.RecordFSM.Event(ctx, EventForceExit, recordEvent{User: userState, ChatID: chatID, MessageID: messageID, Reason: reasonSelectSectionFailed})
+		}
+		return true
+	}
+	slog.WarnContext(ctx, "question not found in section", "question", questionID, "section", sectionID, "user_id", userState.UserID)
+	return false
+}
@@ -0,0 +1,70 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestEditAnswerReturnsToSectionMenu(t *testing.T) {
+	questions.RegisterBuiltins()
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"a": {Title: "A", Questions: []config.QuestionConfig{
+			{ID: "name", Prompt: "Name?", Type: "text", StoreKey: "name"},
+			{ID: "city", Prompt: "City?", Type: "text", StoreKey: "city"},
+			{ID: "job", Prompt: "Job?", Type: "text", StoreKey: "job"},
+		}},
+	}}
+
+	tests := []struct {
+		name      string
+		data      string
+		wantState string
+		wantCity  string
+	}{
+		{name: "answered question", data: CallbackEditPrefix + "a:city", wantState: StateSelectingSection, wantCity: "Rome"},
+		{name: "unknown question", data: CallbackEditPrefix + "a:age", wantState: StateSelectingSection, wantCity: "Paris"},
+		{name: "unknown section", data: CallbackEditPrefix + "b:city", wantState: StateSelectingSection, wantCity: "Paris"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := &state.UserState{UserID: 3, CurrentRecord: state.NewRecord(), MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+			userState.RecordFSM.SetState(StateSelectingSection)
+			userState.CurrentRecord.Data = stringAnswers(map[string]string{"name": "Ann", "city": "Paris"})
+
+			showSectionSelectionMenu(context.Background(), userState, adapter, rc, 3, 0, userState.CurrentRecord, nil)
+			if !adapter.LastCall("send_message").Keyboard.HasCallback(CallbackActionPrefix + ActionEditAnswers) {
+				t.Fatalf("section menu must offer editing answers")
+			}
+			handleCallbackQuery(context.Background(), callbackQuery(3, 1, CallbackActionPrefix+ActionEditAnswers), userState, adapter, rc, nil)
+			list := adapter.LastCall("edit_message").Keyboard
+			if !list.HasCallback(CallbackEditPrefix+"a:name") || !list.HasCallback(CallbackEditPrefix+"a:city") {
+				t.Fatalf("answered questions must be listed, got %+v", list)
+			}
+			if list.HasCallback(CallbackEditPrefix + "a:job") {
+				t.Fatalf("unanswered questions must not be listed")
+			}
+
+			handleCallbackQuery(context.Background(), callbackQuery(3, 1, tt.data), userState, adapter, rc, nil)
+			if userState.RecordFSM.Current() == StateAnsweringQuestion {
+				handleMessage(context.Background(), textMessage(3, "Rome"), userState, adapter, rc, nil)
+			}
+
+			if got := userState.RecordFSM.Current(); got != tt.wantState {
+				t.Fatalf("state = %s, want %s", got, tt.wantState)
+			}
+			if got := userState.CurrentRecord.GetString(rc.Sections["a"].Questions[1]); got != tt.wantCity {
+				t.Fatalf("city = %q, want %q", got, tt.wantCity)
+			}
+			if userState.EditingAnswer {
+				t.Fatalf("editing flag must be cleared")
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package fsm
+
+import (
+	"errors"
+	"math"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// Reasons passed with EventForceExit. They go to the log as is; users see exitReasonText.
+const (
+	reasonStartCommand           = "command /start used"
+	reasonMissingStrategy        = "missing question strategy"
+	reasonStrategyAnswerFailed   = "strategy failed while handling answer"
+	reasonStrategyCallbackFailed = "strategy failed while handling callback"
+	reasonSectionMenuFailed      = "error displaying section menu"
+	reasonSelectSectionFailed    = "failed to select section"
+	reasonSectionRemoved         = "section removed from config"
+)
+
+// exitReasonMessages explains the known force-exit reasons; any other reason is an internal error.
+var exitReasonMessages = map[string]config.MessageKey{
+	reasonStartCommand:           config.MsgExitStartCommand,
+	reasonMissingStrategy:        config.MsgExitConfigError,
+	reasonStrategyAnswerFailed:   config.MsgExitConfigError,
+	reasonStrategyCallbackFailed: config.MsgExitConfigError,
+	reasonSectionMenuFailed:      config.MsgExitSectionMenu,
+	reasonSelectSectionFailed:    config.MsgExitSectionMenu,
+	reasonSectionRemoved:         config.MsgExitSectionRemoved,
+}
+
+// exitReasonText is what the user reads when the record flow is force-exited for reason.
+func exitReasonText(userState *state.UserState, reason string) string {
+	if key, ok := exitReasonMessages[reason]; ok {
+		return tr(userState, key)
+	}
+	return tr(userState, config.MsgForcedExit)
+}
+
+// botErrorText explains a transport error to the user by its BotError code, or returns fallback
+// when err carries no code the user can act on.
+func botErrorText(userState *state.UserState, err error, fallback string) string {
+	var be *botport.BotError
+	if !errors.As(err, &be) {
+		return fallback
+	}
+	switch be.Code {
+	case "rate_limited":
+		if be.RetryAfter > 0 {
+			return tr(userState, config.MsgErrRateLimited, int(math.Ceil(be.RetryAfter.Seconds())))
+		}
+		return tr(userState, config.MsgErrRateLimitedBrief)
+	case "forbidden":
+		return tr(userState, config.MsgErrForbidden)
+	case "bad_request", "bad_payload":
+		return tr(userState, config.MsgErrBadRequest)
+	case "context_deadline":
+		return tr(userState, config.MsgErrTimeout)
+	case "unknown":
+		return tr(userState, config.MsgErrTransport)
+	}
+	return fallback
+}
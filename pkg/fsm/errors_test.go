@@ -0,0 +1,85 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestBotErrorText(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "rate limited with retry", err: fakeadapter.RateLimited("send_message", 2500*time.Millisecond), want: "попробуйте через 3 сек"},
+		{name: "rate limited without retry", err: fakeadapter.RateLimited("send_message", 0), want: "попробуйте чуть позже"},
+		{name: "wrapped forbidden", err: fmt.Errorf("forward: %w", botport.NewBotError("send_message", "forbidden", nil)), want: "заблокировал бота"},
+		{name: "bad request", err: botport.NewBotError("send_message", "bad_request", nil), want: "отклонил сообщение"},
+		{name: "timeout", err: botport.NewBotError("send_message", "context_deadline", nil), want: "не ответил вовремя"},
+		{name: "unknown transport error", err: botport.NewBotError("send_message", "unknown", nil), want: "связаться с Телеграмом"},
+		{name: "unexplained code", err: botport.NewBotError("send_message", "fake_error", nil), want: "fallback"},
+		{name: "not a bot error", err: errors.New("boom"), want: "fallback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := botErrorText(nil, tt.err, "fallback"); !strings.Contains(got, tt.want) {
+				t.Fatalf("botErrorText() = %q, want containing %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitReasonText(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   config.MessageKey
+	}{
+		{reason: reasonStartCommand, want: config.MsgExitStartCommand},
+		{reason: reasonMissingStrategy, want: config.MsgExitConfigError},
+		{reason: reasonSectionMenuFailed, want: config.MsgExitSectionMenu},
+		{reason: reasonSectionRemoved, want: config.MsgExitSectionRemoved},
+		{reason: "callback panicked", want: config.MsgForcedExit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			got := exitReasonText(nil, tt.reason)
+			if got != config.Message("", tt.want) {
+				t.Fatalf("exitReasonText(%q) = %q, want %s", tt.reason, got, tt.want)
+			}
+			if strings.Contains(got, tt.reason) {
+				t.Fatalf("internal reason leaked to the user: %q", got)
+			}
+		})
+	}
+}
+
+func TestForwardFailureExplainsRateLimit(t *testing.T) {
+	config.SetTargetUserID(778)
+	defer config.SetTargetUserID(0)
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", StoreKey: "f1"}}},
+	}}
+	rec := state.NewRecord()
+	rec.Data["f1"] = state.StringAnswer("Value")
+	rec.IsSaved = true
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	userState := &state.UserState{UserID: 2, Records: []*state.Record{rec}, MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+	adapter.Fail("send_message", fakeadapter.RateLimited("send_message", 7*time.Second))
+
+	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 2, nil)
+
+	notice := adapter.LastCall("send_message")
+	if notice == nil || !strings.Contains(notice.Text, "через 7 сек") {
+		t.Fatalf("expected rate limit explanation, got %+v", notice)
+	}
+}
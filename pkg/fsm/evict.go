@@ -0,0 +1,95 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+const maxEvictionInterval = 15 * time.Minute
+
+// RunEviction periodically drops users idle for longer than idleAfter from memory until ctx is
+// cancelled. A non-positive idleAfter disables eviction.
+func RunEviction(ctx context.Context, recordConfig *config.RecordConfig, store *state.Store, idleAfter time.Duration) {
+	if idleAfter <= 0 {
+		return
+	}
+	interval := idleAfter
+	if interval > maxEvictionInterval {
+		interval = maxEvictionInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			evictIdleUsers(now, recordConfig, store, idleAfter)
+		}
+	}
+}
+
+func evictIdleUsers(now time.Time, recordConfig *config.RecordConfig, store *state.Store, idleAfter time.Duration) int {
+	idleBefore := now.Add(-idleAfter)
+	archived := store.HasUserArchive()
+	evicted := 0
+	for _, userID := range store.LoadedUserIDs() {
+		userState, ok := store.Get(userID)
+		if !ok {
+			continue
+		}
+		userState.Mu.Lock()
+		if evictable(now, idleBefore, userState, recordConfig, store, archived) {
+			done, err := store.Evict(userID, idleBefore)
+			if err != nil {
+				slog.Warn("keeping the user in memory", "user_id", userID, "err", err)
+			} else if done {
+				evicted++
+			}
+		}
+		userState.Mu.Unlock()
+	}
+	if evicted > 0 {
+		slog.Info("idle users evicted", "count", evicted)
+	}
+	return evicted
+}
+
+// evictable reports whether the user has been idle since before idleBefore and nothing in their state
+// is still needed in memory: no open screen or draft, no delivery waiting for the therapist and no
+// record waiting for the auto-forward. Without an archive the state is dropped, so only users with
+// no records, default settings and no reminder to get qualify; archived users stay known to the
+// scheduled jobs. Caller must hold userState.Mu.
+func evictable(now, idleBefore time.Time, userState *state.UserState, recordConfig *config.RecordConfig, store *state.Store, archived bool) bool {
+	lastActivity := userState.LastActivity
+	if lastActivity.IsZero() {
+		lastActivity = userState.CreatedAt
+	}
+	if !lastActivity.Before(idleBefore) {
+		return false
+	}
+	if userState.MainMenuFSM.Current() != StateIdle || userState.RecordFSM.Current() != StateRecordIdle {
+		return false
+	}
+	if (userState.CurrentRecord != nil && len(userState.CurrentRecord.Data) > 0) || userState.PendingAnswer != "" || userState.Preview {
+		return false
+	}
+	if len(store.PendingDeliveries(userState.UserID)) > 0 {
+		return false
+	}
+	if !archived {
+		if (recordConfig != nil && recordConfig.Reminders.Enabled) || hasPersonalReminder(userState.UserID) {
+			return false
+		}
+		return store.CountRecords(userState.UserID, state.RecordFilter{}) == 0 && !userState.AutoForwardOff && userState.Language == "" && userState.Therapist == 0
+	}
+	if recordConfig != nil && recordConfig.AutoForward.Enabled && !userState.AutoForwardOff {
+		return len(pendingAutoForward(userState, store, now)) == 0
+	}
+	return true
+}
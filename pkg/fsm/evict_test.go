@@ -0,0 +1,135 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+type mapArchive map[int64]*state.UserState
+
+func (a mapArchive) SaveUser(userState *state.UserState) error {
+	a[userState.UserID] = userState
+	return nil
+}
+
+func (a mapArchive) LoadUser(userID int64) (*state.UserState, bool, error) {
+	userState, ok := a[userID]
+	delete(a, userID)
+	return userState, ok, nil
+}
+
+func (a mapArchive) DeleteUser(userID int64) error {
+	delete(a, userID)
+	return nil
+}
+
+func (a mapArchive) UserIDs() ([]int64, error) {
+	ids := make([]int64, 0, len(a))
+	for id := range a {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func TestEvictIdleUsers(t *testing.T) {
+	now := time.Now().Add(25 * time.Hour) // The store last saw the user over a day before
+	rc := &config.RecordConfig{AutoForward: config.AutoForwardConfig{Enabled: true, Time: "21:00"}}
+	saved := func(userState *state.UserState) {
+		record := state.NewRecord()
+		record.IsSaved = true
+		record.CreatedAt = now.Add(-48 * time.Hour)
+		userState.Records = append(userState.Records, record)
+	}
+
+	tests := []struct {
+		name      string
+		archive   bool
+		reminders bool
+		idle      time.Duration
+		setup     func(*state.UserState)
+		wantEvict bool
+	}{
+		{name: "idle blank user", idle: 48 * time.Hour, wantEvict: true},
+		{name: "recently active", idle: time.Hour},
+		{name: "open record screen", idle: 48 * time.Hour, setup: func(u *state.UserState) { u.RecordFSM.SetState(StateSelectingSection) }},
+		{name: "draft with answers", idle: 48 * time.Hour, setup: func(u *state.UserState) {
+			u.CurrentRecord = state.NewRecord()
+			u.CurrentRecord.Data["k"] = state.StringAnswer("v")
+		}},
+		{name: "records without archive", idle: 48 * time.Hour, setup: saved},
+		{name: "daily reminders without archive", reminders: true, idle: 48 * time.Hour},
+		{name: "daily reminders archived", archive: true, reminders: true, idle: 48 * time.Hour, wantEvict: true},
+		{name: "records waiting for auto-forward", archive: true, idle: 48 * time.Hour, setup: saved},
+		{name: "records archived when opted out", archive: true, idle: 48 * time.Hour, setup: func(u *state.UserState) {
+			saved(u)
+			u.AutoForwardOff = true
+		}, wantEvict: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore()
+			archive := mapArchive{}
+			if tt.archive {
+				store.SetUserArchive(archive)
+			}
+			userState := store.GetOrCreateUserState(7, "User")
+			userState.LastActivity = now.Add(-tt.idle)
+			if tt.setup != nil {
+				tt.setup(userState)
+			}
+
+			cfg := *rc
+			cfg.Reminders.Enabled = tt.reminders
+			evicted := evictIdleUsers(now, &cfg, store, 24*time.Hour)
+
+			if got := evicted == 1; got != tt.wantEvict {
+				t.Fatalf("evicted = %d, want eviction %t", evicted, tt.wantEvict)
+			}
+			if len(store.LoadedUserIDs()) == 1 == tt.wantEvict {
+				t.Fatalf("user IDs after eviction: %v", store.LoadedUserIDs())
+			}
+			if !tt.archive || !tt.wantEvict || tt.reminders {
+				return
+			}
+			restored := store.GetOrCreateUserState(7, "User")
+			if restored != userState || len(restored.Records) != 1 || !restored.AutoForwardOff {
+				t.Fatalf("restored state %+v, want the archived one", restored)
+			}
+		})
+	}
+}
+
+func TestEvictedUserGetsReminder(t *testing.T) {
+	now := time.Now().Add(25 * time.Hour)
+	rc := &config.RecordConfig{Reminders: config.ReminderConfig{Enabled: true, Time: "20:00", Text: "Time to write"}}
+	store := newTestStore()
+	store.SetUserArchive(mapArchive{})
+	userState := store.GetOrCreateUserState(7, "User")
+	userState.LastActivity = now.Add(-48 * time.Hour)
+	if evicted := evictIdleUsers(now, rc, store, 24*time.Hour); evicted != 1 {
+		t.Fatalf("evicted = %d, want 1", evicted)
+	}
+
+	adapter := &fakeadapter.FakeAdapter{}
+	sendReminders(context.Background(), adapter, rc, store)
+
+	if len(adapter.Calls) != 1 || adapter.Calls[0].ChatID != 7 || adapter.Calls[0].Text != "Time to write" {
+		t.Fatalf("the evicted user must get the reminder, got %+v", adapter.Calls)
+	}
+}
+
+func TestEvictSkipsUserLookedUpAfterIdleCheck(t *testing.T) {
+	store := newTestStore()
+	userState := store.GetOrCreateUserState(7, "User")
+	userState.LastActivity = time.Now().Add(-48 * time.Hour)
+
+	// The store saw the user a moment ago, e.g. an update waiting for the lock.
+	if evicted := evictIdleUsers(time.Now(), &config.RecordConfig{}, store, 24*time.Hour); evicted != 0 {
+		t.Fatalf("evicted a user another update holds")
+	}
+}
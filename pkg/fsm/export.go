@@ -0,0 +1,53 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/export"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// handleExport sends the user's saved records as a file: "/export [csv|xlsx|html]" or the main menu button,
+// which exports CSV.
+func handleExport(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, store *state.Store, arg string) {
+	format, ok := export.ParseFormat(arg)
+	if !ok {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgExportUsage), nil)
+		return
+	}
+	if !botPort.Capabilities().Attachments {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgExportUnsupported), nil)
+		return
+	}
+	if store == nil {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgExportFailed), nil)
+		return
+	}
+
+	// The store lists newest first; a table reads better in date order.
+	records := store.ListRecords(userState.UserID, state.RecordFilter{}, state.Page{})
+	if len(records) == 0 {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNoSavedRecords), nil)
+		return
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	name, data, err := export.Render(format, recordConfig, records, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "rendering the export failed", "user_id", userState.UserID, "err", err)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgExportFailed), nil)
+		return
+	}
+	if _, err := botPort.SendDocument(ctx, chatID, name, data, tr(userState, config.MsgExportCaption, len(records)), recordSendOptions()...); err != nil {
+		slog.ErrorContext(ctx, "sending the export failed", "file_name", name, "user_id", userState.UserID, "err", err)
+		_, _ = botPort.SendMessage(ctx, chatID, botErrorText(userState, err, tr(userState, config.MsgExportFailed)), nil)
+		return
+	}
+	slog.InfoContext(ctx, "records exported", "user_id", userState.UserID, "records", len(records), "format", format)
+}
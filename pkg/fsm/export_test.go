@@ -0,0 +1,72 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestExportSendsRecordsAsDocument(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+
+	tests := []struct {
+		name     string
+		message  string
+		records  int
+		caps     *botport.Capabilities
+		wantFile string // Suffix of the sent file name; empty when no file is expected
+		wantText string // Reply when no file is sent
+	}{
+		{name: "menu button exports csv", message: tr(nil, ButtonMainMenuExport), records: 2, wantFile: ".csv"},
+		{name: "xlsx command", message: "/export xlsx", records: 1, wantFile: ".xlsx"},
+		{name: "html command", message: "/export html", records: 1, wantFile: ".html"},
+		{name: "unknown format", message: "/export pdf", records: 1, wantText: "Использование: /export [csv|xlsx|html]"},
+		{name: "no records", message: "/export", wantText: config.Message("", config.MsgNoSavedRecords)},
+		{name: "transport without files", message: "/export", records: 1, caps: &botport.Capabilities{Text: true}, wantText: config.Message("", config.MsgExportUnsupported)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{Caps: tt.caps}
+			store := newTestHandler(adapter, rc).Store()
+			userState := store.GetOrCreateUserState(40, "User")
+			for i := 0; i < tt.records; i++ {
+				record := state.NewRecord()
+				record.Data["f1"] = state.StringAnswer("answer")
+				record.IsSaved = true
+				record.CreatedAt = time.Now().Add(-time.Duration(i) * time.Hour)
+				userState.Records = append([]*state.Record{record}, userState.Records...)
+			}
+			message := textMessage(40, tt.message)
+			if strings.HasPrefix(tt.message, "/") {
+				message = commandMessage(40, tt.message)
+			}
+
+			handleMessage(context.Background(), message, userState, adapter, rc, store)
+
+			doc := adapter.LastCall("send_document")
+			if tt.wantFile == "" {
+				if doc != nil {
+					t.Fatalf("unexpected document %+v", doc)
+				}
+				if reply := adapter.LastCall("send_message"); reply == nil || reply.Text != tt.wantText {
+					t.Fatalf("reply = %+v, want %q", reply, tt.wantText)
+				}
+				return
+			}
+			if doc == nil || !strings.HasSuffix(doc.FileID, tt.wantFile) || len(doc.Data) == 0 {
+				t.Fatalf("expected %s document, got %+v", tt.wantFile, doc)
+			}
+			if !strings.Contains(doc.Text, "записи") {
+				t.Fatalf("caption = %q", doc.Text)
+			}
+		})
+	}
+}
@@ -3,9 +3,10 @@ package fsm
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"sort"
+	"log/slog"
+	"strings"
 	"text/template"
 	"time"
 
@@ -21,86 +22,98 @@ const (
 type forwardQuestion struct {
 	Prompt string
 	Answer string
+	Flags  string // Content filter findings, empty when the answer is clean
 }
 
 type forwardSection struct {
 	Title     string
+	Late      bool // Completed after the section deadline
 	Questions []forwardQuestion
 }
 
+// forwardNote is a note added to the record after saving it.
+type forwardNote struct {
+	At   string
+	Text string
+}
+
 type forwardPayload struct {
+	Lang      string // Language of the labels: the reader's
 	UserID    int64
 	UserName  string
 	CreatedAt string
+	Late      bool   // Saved, or for a draft sent, after the survey deadline
+	Deadline  string // Survey deadline, "HH:MM"
 	Sections  []forwardSection
+	Notes     []forwardNote
 }
 
-var forwardTpl = template.Must(template.New("forward").Parse(`Ответы пользователя {{.UserName}} (ID: {{.UserID}})
-Дата записи: {{.CreatedAt}}
-{{range .Sections}}## {{.Title}}
+// templateFuncs lets the record templates take their labels from the message catalog.
+var templateFuncs = template.FuncMap{"tr": config.Message}
+
+var forwardTpl = template.Must(template.New("forward").Funcs(templateFuncs).Parse(`{{tr .Lang "forward_header" .UserName .UserID}}
+{{tr .Lang "forward_date" .CreatedAt}}
+{{if .Late}}{{tr .Lang "forward_late" .Deadline}}
+{{end}}{{$lang := .Lang}}{{range .Sections}}## {{.Title}}{{if .Late}} {{tr $lang "forward_section_late"}}{{end}}
 {{range .Questions}}- {{.Prompt}}:
   {{.Answer}}
-{{end}}
-{{end}}`))
+{{if .Flags}}  ⚠️ {{.Flags}}
+{{end}}{{end}}
+{{end}}{{if .Notes}}{{tr .Lang "forward_notes"}}
+{{range .Notes}}- {{.At}}: {{.Text}}
+{{end}}{{end}}`))
 
-func handleForwardAnsweredSections(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
-	targetUserID := config.GetTargetUserID()
-	handleForwardToTarget(ctx, userState, botPort, recordConfig, chatID, targetUserID, false)
+func handleForwardAnsweredSections(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, store *state.Store) {
+	targetUserID := targetFor(userState)
+	if store != nil && targetUserID != 0 {
+		forwardToTherapist(ctx, userState, botPort, recordConfig, chatID, targetUserID, store, config.FeatureEnabled(config.FeatureRequireAck))
+		return
+	}
+	handleForwardToTarget(ctx, userState, botPort, recordConfig, store, chatID, targetUserID, false)
 }
 
-func handleForwardToTarget(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, targetUserID int64, clearOnSuccess bool) {
-	forwardWithTarget(ctx, userState, botPort, recordConfig, chatID, targetUserID, clearOnSuccess, true, func(id int64) string {
-		return fmt.Sprintf("Ответы отправлены на ID %d.", id)
+func handleForwardToTarget(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, chatID int64, targetUserID int64, clearOnSuccess bool) {
+	forwardWithTarget(ctx, userState, botPort, recordConfig, store, chatID, targetUserID, clearOnSuccess, true, func(id int64) string {
+		return tr(userState, config.MsgSentToTarget, id)
 	})
 }
 
-func handleForwardToSelf(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
-	forwardWithTarget(ctx, userState, botPort, recordConfig, chatID, chatID, false, false, func(id int64) string {
-		return "Ответы отправлены вам в этот чат."
+func handleForwardToSelf(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, chatID int64) {
+	forwardWithTarget(ctx, userState, botPort, recordConfig, store, chatID, chatID, false, false, func(id int64) string {
+		return tr(userState, config.MsgSentToSelf)
 	})
 }
 
-func forwardWithTarget(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, targetUserID int64, clearOnSuccess bool, requireConfigured bool, successText func(int64) string) {
-	record := selectRecordForForward(userState)
+func forwardWithTarget(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, chatID int64, targetUserID int64, clearOnSuccess bool, requireConfigured bool, successText func(int64) string) {
+	record := selectRecordForForward(userState, store)
 	if record == nil {
-		_, _ = botPort.SendMessage(ctx, chatID, "Нет ответов для отправки.", nil)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNoAnswersToSend), nil)
 		return
 	}
 
 	if requireConfigured && targetUserID == 0 {
-		log.Printf("[handleForwardAnsweredSections] TARGET_USER_ID is not configured")
-		_, _ = botPort.SendMessage(ctx, chatID, "Не настроен TARGET_USER_ID, отправка недоступна.", nil)
-		return
-	}
-
-	payload := buildForwardPayload(recordConfig, record, userState)
-	text, err := renderForwardMessage(payload)
-	if err != nil {
-		log.Printf("[handleForwardAnsweredSections] render error for user %d: %v", userState.UserID, err)
-		_, _ = botPort.SendMessage(ctx, chatID, "Не удалось сформировать сообщение для отправки.", nil)
+		slog.WarnContext(ctx, "TARGET_USER_ID is not configured")
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgTargetNotConfigured), nil)
 		return
 	}
 
-	if len(text) == 0 {
-		log.Printf("[handleForwardAnsweredSections] empty rendered text for user %d", userState.UserID)
-		_, _ = botPort.SendMessage(ctx, chatID, "Нет данных для отправки.", nil)
-		return
+	slog.InfoContext(ctx, "forwarding the record", "record_id", record.ID, "user_id", userState.UserID, "target_id", targetUserID, "clear", clearOnSuccess)
+	var markup interface{}
+	if targetUserID != userState.UserID {
+		markup = therapistKeyboard(store.Lang(targetUserID), userState.UserID, "")
 	}
-
-	log.Printf("[handleForwardAnsweredSections] forwarding record %s for user %d to target %d (clear=%t)", record.ID, userState.UserID, targetUserID, clearOnSuccess)
-	_, err = botPort.SendMessage(ctx, targetUserID, text, nil)
-	if err != nil {
-		log.Printf("[handleForwardAnsweredSections] forward error for user %d to %d: %v", userState.UserID, targetUserID, err)
-		_, _ = botPort.SendMessage(ctx, chatID, "Не удалось отправить ответы, попробуйте позже.", nil)
+	if _, err := deliverRecord(ctx, botPort, recordConfig, userState, record, targetUserID, store, markup); err != nil {
+		slog.ErrorContext(ctx, "forwarding the record failed", "err", err)
+		_, _ = botPort.SendMessage(ctx, chatID, deliveryFailureText(userState, err), nil)
 		return
 	}
 
 	if clearOnSuccess {
 		if targetUserID == chatID {
-			log.Printf("[handleForwardAnsweredSections] TARGET_USER_ID %d matches requester chat %d; check configuration if a different recipient was expected", targetUserID, chatID)
+			slog.WarnContext(ctx, "TARGET_USER_ID is the requester; check the configuration if another recipient was expected", "target_id", targetUserID, "chat_id", chatID)
 		}
 
-		clearUserAnswers(userState, record)
+		clearUserAnswers(userState, store, record)
 	}
 
 	if targetUserID == chatID && !clearOnSuccess {
@@ -111,13 +124,87 @@ func forwardWithTarget(ctx context.Context, userState *state.UserState, botPort
 	_, _ = botPort.SendMessage(ctx, chatID, confirmation, nil)
 }
 
+// dropRecord removes record from the saved records, preserving the others. With a store a saved record
+// is deleted by its ID, so the copy in the backend goes too.
+func dropRecord(userState *state.UserState, store *state.Store, record *state.Record) {
+	if store != nil && record.IsSaved {
+		if err := store.DeleteRecord(userState.UserID, record.ID); err != nil {
+			slog.Error("deleting the record failed", "user_id", userState.UserID, "record_id", record.ID, "err", err)
+		}
+		return
+	}
+	filtered := make([]*state.Record, 0, len(userState.Records))
+	for _, r := range userState.Records {
+		if r == nil || r == record {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	userState.Records = filtered
+}
+
+// Delivery failure stages reported by deliverRecord.
+var (
+	errForwardRender = errors.New("render failed")
+	errForwardEmpty  = errors.New("nothing to send")
+	errForwardSend   = errors.New("send failed")
+)
+
+// deliverRecord renders record and sends it to targetUserID with markup and opts. The returned error
+// wraps errForwardRender, errForwardEmpty, errForwardSend or errTargetBlocked.
+func deliverRecord(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, userState *state.UserState, record *state.Record, targetUserID int64, store *state.Store, markup interface{}, opts ...botport.SendOption) (botport.BotMessage, error) {
+	if err := checkTargetLink(userState, targetUserID); err != nil {
+		return botport.BotMessage{}, fmt.Errorf("forward for user %d to %d: %w", userState.UserID, targetUserID, err)
+	}
+	payload := buildForwardPayload(recordConfig, record, userState)
+	if targetUserID != userState.UserID {
+		payload.Lang = store.Lang(targetUserID)
+	}
+	text, err := renderForwardMessage(payload)
+	if err != nil {
+		return botport.BotMessage{}, fmt.Errorf("forward for user %d: %w: %v", userState.UserID, errForwardRender, err)
+	}
+	if len(text) == 0 {
+		return botport.BotMessage{}, fmt.Errorf("forward for user %d: %w", userState.UserID, errForwardEmpty)
+	}
+	msg, err := botPort.SendMessage(ctx, targetUserID, text, markup, append(recordSendOptions(), opts...)...)
+	if err != nil {
+		if noteTargetForbidden(userState, targetUserID, err) {
+			return botport.BotMessage{}, fmt.Errorf("forward for user %d to %d: %w: %w", userState.UserID, targetUserID, errTargetBlocked, err)
+		}
+		return botport.BotMessage{}, fmt.Errorf("forward for user %d to %d: %w: %w", userState.UserID, targetUserID, errForwardSend, err)
+	}
+	return msg, nil
+}
+
+// recordSendOptions returns the send options for messages that carry record contents.
+func recordSendOptions() []botport.SendOption {
+	if config.FeatureEnabled(config.FeatureProtectContent) {
+		return []botport.SendOption{botport.Protected()}
+	}
+	return nil
+}
+
+// deliveryFailureText explains a deliverRecord error to the user; send failures are explained by their
+// transport error code when it has one.
+func deliveryFailureText(userState *state.UserState, err error) string {
+	switch {
+	case errors.Is(err, errForwardRender):
+		return tr(userState, config.MsgDeliveryRenderFailed)
+	case errors.Is(err, errForwardEmpty):
+		return tr(userState, config.MsgDeliveryEmpty)
+	case errors.Is(err, errTargetBlocked):
+		return tr(userState, config.MsgTargetBlocked)
+	default:
+		return botErrorText(userState, err, tr(userState, config.MsgDeliveryFailed))
+	}
+}
+
 // selectRecordForForward chooses the most recent saved record if present; otherwise falls back to the current draft.
 // Only the selected record is cleared after a successful forward; other saved records remain intact.
-func selectRecordForForward(userState *state.UserState) *state.Record {
-	for i := len(userState.Records) - 1; i >= 0; i-- {
-		if userState.Records[i] != nil && userState.Records[i].IsSaved {
-			return userState.Records[i]
-		}
+func selectRecordForForward(userState *state.UserState, store *state.Store) *state.Record {
+	if saved := lastSavedRecord(userState, store); saved != nil {
+		return saved
 	}
 	if userState.CurrentRecord != nil {
 		return userState.CurrentRecord
@@ -126,31 +213,28 @@ func selectRecordForForward(userState *state.UserState) *state.Record {
 }
 
 func buildForwardPayload(recordConfig *config.RecordConfig, record *state.Record, userState *state.UserState) forwardPayload {
+	recordConfig = recordConfigFor(recordConfig, record)
 	sections := make([]forwardSection, 0, len(recordConfig.Sections))
-	sectionIDs := make([]string, 0, len(recordConfig.Sections))
-	for id := range recordConfig.Sections {
-		sectionIDs = append(sectionIDs, id)
-	}
-	sort.Strings(sectionIDs)
-
-	for _, sectionID := range sectionIDs {
+	for _, sectionID := range recordConfig.SectionIDs() {
 		sectionConf := recordConfig.Sections[sectionID]
 		qs := make([]forwardQuestion, 0, len(sectionConf.Questions))
 		for _, q := range sectionConf.Questions {
-			answer := ""
-			if record != nil && record.Data != nil {
-				answer = record.Data[q.StoreKey]
+			if !record.Visible(q) {
+				continue
 			}
+			answer := record.GetString(q)
 			if answer == "" {
 				answer = noAnswerPlaceholder
 			}
 			qs = append(qs, forwardQuestion{
 				Prompt: q.Prompt,
 				Answer: answer,
+				Flags:  strings.Join(record.Flags[q.StoreKey], ", "),
 			})
 		}
 		sections = append(sections, forwardSection{
 			Title:     sectionConf.Title,
+			Late:      record.SectionLate(sectionID),
 			Questions: qs,
 		})
 	}
@@ -159,12 +243,26 @@ func buildForwardPayload(recordConfig *config.RecordConfig, record *state.Record
 	if created.IsZero() {
 		created = time.Now()
 	}
+	late := record.Late
+	if !record.IsSaved {
+		// A draft sent as is is submitted now.
+		now := clock()
+		late = config.DeadlinePassed(recordConfig.Deadline, draftStart(record, now), now)
+	}
+	notes := make([]forwardNote, 0, len(record.Notes))
+	for _, note := range record.Notes {
+		notes = append(notes, forwardNote{At: note.At.Format("02.01.2006 15:04"), Text: note.Text})
+	}
 
 	return forwardPayload{
+		Lang:      userState.Lang(),
 		UserID:    userState.UserID,
 		UserName:  userState.UserName,
 		CreatedAt: created.Format("02.01.2006 15:04"),
+		Late:      late,
+		Deadline:  recordConfig.Deadline,
 		Sections:  sections,
+		Notes:     notes,
 	}
 }
 
@@ -176,16 +274,8 @@ func renderForwardMessage(payload forwardPayload) (string, error) {
 	return buf.String(), nil
 }
 
-func clearUserAnswers(userState *state.UserState, forwarded *state.Record) {
-	// Preserve other saved records; drop only the forwarded record/draft.
-	filtered := make([]*state.Record, 0, len(userState.Records))
-	for _, r := range userState.Records {
-		if r == nil || r == forwarded {
-			continue
-		}
-		filtered = append(filtered, r)
-	}
-	userState.Records = filtered
+func clearUserAnswers(userState *state.UserState, store *state.Store, forwarded *state.Record) {
+	dropRecord(userState, store, forwarded)
 	if userState.CurrentRecord == forwarded {
 		userState.CurrentRecord = nil
 	}
@@ -24,7 +24,7 @@ func TestBuildForwardPayloadUsesNoAnswer(t *testing.T) {
 			},
 		},
 	}
-	record := &state.Record{Data: map[string]string{"k1": "answer 1"}}
+	record := &state.Record{Data: stringAnswers(map[string]string{"k1": "answer 1"})}
 	userState := &state.UserState{UserID: 42, UserName: "Tester"}
 
 	payload := buildForwardPayload(rc, record, userState)
@@ -41,6 +41,38 @@ func TestBuildForwardPayloadUsesNoAnswer(t *testing.T) {
 	}
 }
 
+func TestSectionOrderInMenuAndForward(t *testing.T) {
+	section := func(title string, order int) config.SectionConfig {
+		return config.SectionConfig{Title: title, Order: order, Questions: []config.QuestionConfig{{ID: title, Prompt: "P", Type: "text", StoreKey: title}}}
+	}
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{"a": section("A", 2), "b": section("B", 1), "c": section("C", 0)}}
+	want := []string{"B", "A", "C"}
+
+	var titles []string
+	for _, s := range buildForwardPayload(rc, state.NewRecord(), &state.UserState{UserID: 42}).Sections {
+		titles = append(titles, s.Title)
+	}
+	if strings.Join(titles, ",") != strings.Join(want, ",") {
+		t.Fatalf("forwarded sections %v, want %v", titles, want)
+	}
+
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	userState := &state.UserState{UserID: 42, CurrentRecord: state.NewRecord(), MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+	showSectionSelectionMenu(context.Background(), userState, adapter, rc, 42, 0, userState.CurrentRecord, nil)
+	var labels []string
+	for _, label := range adapter.LastCall("send_message").Keyboard.Labels() {
+		for _, title := range want {
+			if strings.HasPrefix(label, title+" ") {
+				labels = append(labels, title)
+			}
+		}
+	}
+	if strings.Join(labels, ",") != strings.Join(want, ",") {
+		t.Fatalf("menu sections %v, want %v", labels, want)
+	}
+}
+
 func TestHandleForwardAnsweredSectionsSuccessClearsAnswers(t *testing.T) {
 	config.SetTargetUserID(999)
 	rc := &config.RecordConfig{
@@ -54,20 +86,20 @@ func TestHandleForwardAnsweredSectionsSuccessClearsAnswers(t *testing.T) {
 		},
 	}
 	rec := state.NewRecord()
-	rec.Data["name"] = "Alice"
+	rec.Data["name"] = state.StringAnswer("Alice")
 	rec.IsSaved = true
 
-	fsmCreator := NewFSMCreator()
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
 	userState := &state.UserState{
 		UserID:      1,
 		UserName:    "User One",
 		Records:     []*state.Record{rec},
-		MainMenuFSM: fsmCreator.NewMainMenuFSM(),
-		RecordFSM:   fsmCreator.NewRecordFSM(),
+		MainMenuFSM: handler.NewMainMenuFSM(),
+		RecordFSM:   handler.NewRecordFSM(),
 	}
-	adapter := &fakeadapter.FakeAdapter{}
 
-	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 1)
+	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 1, nil)
 
 	if len(userState.Records) != 1 {
 		t.Fatalf("expected records preserved after forward, got %d", len(userState.Records))
@@ -100,21 +132,21 @@ func TestHandleForwardAnsweredSectionsFailureKeepsAnswers(t *testing.T) {
 		},
 	}
 	rec := state.NewRecord()
-	rec.Data["f1"] = "Value"
+	rec.Data["f1"] = state.StringAnswer("Value")
 	rec.IsSaved = true
 
-	fsmCreator := NewFSMCreator()
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
 	userState := &state.UserState{
 		UserID:      2,
 		UserName:    "User Two",
 		Records:     []*state.Record{rec},
-		MainMenuFSM: fsmCreator.NewMainMenuFSM(),
-		RecordFSM:   fsmCreator.NewRecordFSM(),
+		MainMenuFSM: handler.NewMainMenuFSM(),
+		RecordFSM:   handler.NewRecordFSM(),
 	}
-	adapter := &fakeadapter.FakeAdapter{}
 	adapter.Fail("send_message", fakeadapter.RateLimited("send_message", 0))
 
-	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 2)
+	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 2, nil)
 
 	if len(userState.Records) == 0 {
 		t.Fatalf("expected answers retained on failure")
@@ -137,20 +169,20 @@ func TestHandleForwardToSelfDoesNotClearAnswers(t *testing.T) {
 		},
 	}
 	rec := state.NewRecord()
-	rec.Data["f1"] = "Self"
+	rec.Data["f1"] = state.StringAnswer("Self")
 	rec.IsSaved = true
 
-	fsmCreator := NewFSMCreator()
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
 	userState := &state.UserState{
 		UserID:      10,
 		UserName:    "Self",
 		Records:     []*state.Record{rec},
-		MainMenuFSM: fsmCreator.NewMainMenuFSM(),
-		RecordFSM:   fsmCreator.NewRecordFSM(),
+		MainMenuFSM: handler.NewMainMenuFSM(),
+		RecordFSM:   handler.NewRecordFSM(),
 	}
-	adapter := &fakeadapter.FakeAdapter{}
 
-	handleForwardToSelf(context.Background(), userState, adapter, rc, userState.UserID)
+	handleForwardToSelf(context.Background(), userState, adapter, rc, nil, userState.UserID)
 
 	if len(userState.Records) != 1 {
 		t.Fatalf("expected records kept after sending to self, got %d", len(userState.Records))
@@ -166,16 +198,16 @@ func TestHandleForwardToSelfDoesNotClearAnswers(t *testing.T) {
 func TestHandleForwardAnsweredSectionsEmptyAnswers(t *testing.T) {
 	config.SetTargetUserID(555)
 	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{}}
-	fsmCreator := NewFSMCreator()
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
 	userState := &state.UserState{
 		UserID:      3,
 		UserName:    "Empty User",
-		MainMenuFSM: fsmCreator.NewMainMenuFSM(),
-		RecordFSM:   fsmCreator.NewRecordFSM(),
+		MainMenuFSM: handler.NewMainMenuFSM(),
+		RecordFSM:   handler.NewRecordFSM(),
 	}
-	adapter := &fakeadapter.FakeAdapter{}
 
-	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 3)
+	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 3, nil)
 
 	call := adapter.LastCall("send_message")
 	if call == nil || call.ChatID != 3 || call.Text == "" {
@@ -189,16 +221,16 @@ func TestHandleForwardAnsweredSectionsEmptyAnswers(t *testing.T) {
 func TestHandleForwardAnsweredSectionsMissingTarget(t *testing.T) {
 	config.SetTargetUserID(0)
 	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{}}
-	fsmCreator := NewFSMCreator()
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
 	userState := &state.UserState{
 		UserID:      4,
 		UserName:    "NoTarget",
-		MainMenuFSM: fsmCreator.NewMainMenuFSM(),
-		RecordFSM:   fsmCreator.NewRecordFSM(),
+		MainMenuFSM: handler.NewMainMenuFSM(),
+		RecordFSM:   handler.NewRecordFSM(),
 	}
-	adapter := &fakeadapter.FakeAdapter{}
 
-	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 4)
+	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 4, nil)
 
 	call := adapter.LastCall("send_message")
 	if call == nil || call.ChatID != 4 || call.Text == "" {
@@ -219,18 +251,18 @@ func TestHandleForwardAnsweredSectionsRenderError(t *testing.T) {
 		},
 	}
 	rec := state.NewRecord()
-	rec.Data["f1"] = "Value"
+	rec.Data["f1"] = state.StringAnswer("Value")
 	rec.IsSaved = true
 
-	fsmCreator := NewFSMCreator()
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
 	userState := &state.UserState{
 		UserID:      5,
 		UserName:    "RenderFail",
 		Records:     []*state.Record{rec},
-		MainMenuFSM: fsmCreator.NewMainMenuFSM(),
-		RecordFSM:   fsmCreator.NewRecordFSM(),
+		MainMenuFSM: handler.NewMainMenuFSM(),
+		RecordFSM:   handler.NewRecordFSM(),
 	}
-	adapter := &fakeadapter.FakeAdapter{}
 
 	originalTpl := forwardTpl
 	defer func() { forwardTpl = originalTpl }()
@@ -238,7 +270,7 @@ func TestHandleForwardAnsweredSectionsRenderError(t *testing.T) {
 		"fail": func() (string, error) { return "", errors.New("boom") },
 	}).Parse(`{{fail}}`))
 
-	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 5)
+	handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 5, nil)
 
 	if len(userState.Records) == 0 {
 		t.Fatalf("expected records retained on render error")
@@ -248,3 +280,155 @@ func TestHandleForwardAnsweredSectionsRenderError(t *testing.T) {
 		t.Fatalf("expected error notice to chat 5, got %+v", call)
 	}
 }
+
+func TestForwardOnSaveIsAtomic(t *testing.T) {
+	config.SetTargetUserID(900)
+	if err := config.SetFeature(config.FeatureForwardOnSave, true); err != nil {
+		t.Fatalf("enable flag: %v", err)
+	}
+	defer func() { _ = config.SetFeature(config.FeatureForwardOnSave, false) }()
+	rc := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		failForward bool
+		wantState   string
+		wantSaved   int
+	}{
+		{name: "delivered record is saved", wantState: StateRecordIdle, wantSaved: 1},
+		{name: "failed delivery keeps the draft", failForward: true, wantState: StateSelectingSection, wantSaved: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			draft := state.NewRecord()
+			draft.Data["f1"] = state.StringAnswer("Value")
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := &state.UserState{
+				UserID:        6,
+				CurrentRecord: draft,
+				MainMenuFSM:   handler.NewMainMenuFSM(),
+				RecordFSM:     handler.NewRecordFSM(),
+			}
+			userState.RecordFSM.SetState(StateSelectingSection)
+			if tt.failForward {
+				adapter.Fail("send_message", errors.New("network down"))
+			}
+
+			handleCallbackQuery(context.Background(), callbackQuery(6, 1, CallbackActionPrefix+ActionSaveRecord), userState, adapter, rc, nil)
+
+			if got := userState.RecordFSM.Current(); got != tt.wantState {
+				t.Fatalf("state = %s, want %s", got, tt.wantState)
+			}
+			if len(userState.Records) != tt.wantSaved {
+				t.Fatalf("saved records = %d, want %d", len(userState.Records), tt.wantSaved)
+			}
+			if tt.failForward {
+				if userState.CurrentRecord != draft || draft.IsSaved || draft.ID != "" {
+					t.Fatalf("draft must stay untouched after a failed delivery: %+v", userState.CurrentRecord)
+				}
+				return
+			}
+			delivered := false
+			for _, call := range adapter.Calls {
+				if call.Op == "send_message" && call.ChatID == 900 && strings.Contains(call.Text, "Value") {
+					delivered = true
+				}
+			}
+			if !delivered {
+				t.Fatalf("expected record sent to target, calls: %+v", adapter.Calls)
+			}
+		})
+	}
+}
+
+func TestSaveAndSendButton(t *testing.T) {
+	config.SetTargetUserID(901)
+	rc := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+		},
+	}
+	draft := state.NewRecord()
+	draft.Data["f1"] = state.StringAnswer("Value")
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	userState := &state.UserState{
+		UserID:        7,
+		CurrentRecord: draft,
+		MainMenuFSM:   handler.NewMainMenuFSM(),
+		RecordFSM:     handler.NewRecordFSM(),
+	}
+
+	if err := userState.RecordFSM.Event(context.Background(), EventStartRecord, recordEvent{User: userState, ChatID: 7}); err != nil {
+		t.Fatalf("start record: %v", err)
+	}
+	menu := adapter.LastCall("send_message")
+	if menu == nil || !menu.Keyboard.HasCallback(CallbackActionPrefix+ActionSaveAndSend) {
+		t.Fatalf("section menu must offer save and send, got %+v", menu)
+	}
+
+	handleCallbackQuery(context.Background(), callbackQuery(7, menu.MessageID, CallbackActionPrefix+ActionSaveAndSend), userState, adapter, rc, nil)
+
+	if len(userState.Records) != 1 || !userState.Records[0].IsSaved {
+		t.Fatalf("record must be saved, records: %+v", userState.Records)
+	}
+	var delivered, reported bool
+	for _, call := range adapter.Calls {
+		if call.ChatID == 901 && strings.Contains(call.Text, "Value") {
+			delivered = true
+		}
+		if strings.Contains(call.Text, "сохранена и отправлена терапевту") {
+			reported = true
+		}
+	}
+	if !delivered || !reported {
+		t.Fatalf("delivered=%t reported=%t, calls: %+v", delivered, reported, adapter.Calls)
+	}
+}
+
+func TestProtectContentFlag(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+
+	tests := []struct {
+		name          string
+		enabled       bool
+		wantProtected bool
+	}{
+		{name: "off by default", enabled: false, wantProtected: false},
+		{name: "on", enabled: true, wantProtected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := config.SetFeature(config.FeatureProtectContent, tt.enabled); err != nil {
+				t.Fatalf("set flag: %v", err)
+			}
+			defer func() { _ = config.SetFeature(config.FeatureProtectContent, false) }()
+			record := state.NewRecord()
+			record.Data["f1"] = state.StringAnswer("Value")
+			record.IsSaved = true
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			userState := store.GetOrCreateUserState(13, "User")
+			userState.Records = []*state.Record{record}
+
+			handleForwardToSelf(context.Background(), userState, adapter, rc, nil, 13)
+			viewLastRecordHandler(context.Background(), userState, adapter, rc, 13, store)
+
+			if len(adapter.Calls) != 2 {
+				t.Fatalf("expected forward and view, calls: %+v", adapter.Calls)
+			}
+			for _, call := range adapter.Calls {
+				if call.Options.ProtectContent != tt.wantProtected {
+					t.Fatalf("%q protect_content = %t, want %t", call.Text, call.Options.ProtectContent, tt.wantProtected)
+				}
+			}
+		})
+	}
+}
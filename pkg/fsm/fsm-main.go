@@ -4,12 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/idgen"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
-	"log"
+	"log/slog"
 	"strings"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/looplab/fsm"
 )
 
@@ -21,107 +21,109 @@ func NewMainMenuFSM(initialState string) *fsm.FSM {
 		{Name: EventViewList, Src: []string{StateIdle}, Dst: StateViewingList},
 		{Name: EventListNext, Src: []string{StateViewingList}, Dst: StateViewingList},
 		{Name: EventListBack, Src: []string{StateViewingList}, Dst: StateViewingList},
-		{Name: EventBackToIdle, Src: []string{StateViewingList}, Dst: StateIdle},
+		{Name: EventViewRecord, Src: []string{StateViewingList}, Dst: StateViewingRecord},
+		{Name: EventBackToList, Src: []string{StateViewingRecord}, Dst: StateViewingList},
+		{Name: EventBackToIdle, Src: []string{StateViewingList, StateViewingRecord}, Dst: StateIdle},
+		{Name: EventAddNote, Src: []string{StateIdle}, Dst: StateAddingNote},
+		{Name: EventSaveNote, Src: []string{StateAddingNote}, Dst: StateIdle},
+		{Name: EventCancelNote, Src: []string{StateAddingNote}, Dst: StateIdle},
 	}
 
 	return fsm.NewFSM(initialState, events, callbacks)
 }
 
-func sendMainMenu(ctx context.Context, botPort botport.BotPort, userState *state.UserState) {
-	log.Printf("Entering sendMainMenu for user %d", userState.UserID)
-	recordCount := len(userState.Records)
+func sendMainMenu(ctx context.Context, botPort botport.BotPort, userState *state.UserState, store *state.Store) {
+	slog.DebugContext(ctx, "sending the main menu", "user_id", userState.UserID)
+	recordCount := savedRecordCount(userState, store)
 	userName := userState.UserName
 	userID := userState.UserID
 
-	stats := fmt.Sprintf("👤 Имя: %s\n🆔 ID: %d\n📊 Кол-во записей: %d",
-		userName, userID, recordCount)
-	log.Printf("Stats: %s", stats)
+	stats := tr(userState, config.MsgUserStats, userName, userID, recordCount)
+	slog.DebugContext(ctx, "main menu stats", "stats", stats)
 
-	mainMenuKeyboard := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton(ButtonMainMenuFillRecord),
-		),
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton(ButtonMainMenuSendSelf),
-			tgbotapi.NewKeyboardButton(ButtonMainMenuSendTherapist),
-		),
+	mainMenuKeyboard := botport.NewReplyKeyboard(
+		[]string{tr(userState, ButtonMainMenuFillRecord), tr(userState, ButtonMainMenuAddNote)},
+		[]string{tr(userState, ButtonMainMenuSendSelf), tr(userState, ButtonMainMenuSendTherapist)},
+		[]string{tr(userState, ButtonMainMenuDeliveries), tr(userState, ButtonMainMenuExport)},
 	)
 
-	_, err := botPort.SendMessage(ctx, userState.UserID, stats+"\n\nВыберите действие:", mainMenuKeyboard)
+	_, err := botPort.SendMessage(ctx, userState.UserID, stats+"\n\n"+tr(userState, config.MsgMainMenuPrompt), mainMenuKeyboard)
 	if err != nil {
-		log.Printf("[sendMainMenu] Error sending main menu for user %d: %v", userState.UserID, err)
+		slog.ErrorContext(ctx, "sending the main menu failed", "user_id", userState.UserID, "err", err)
 	} else {
-		log.Printf("[sendMainMenu] Main menu sent to user %d", userState.UserID)
+		userState.ReplyKeyboard = true
+		slog.DebugContext(ctx, "main menu sent", "user_id", userState.UserID)
 	}
 }
 
-func viewLastRecordHandler(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
-	var lastRecord *state.Record
-	for i := len(userState.Records) - 1; i >= 0; i-- {
-		if userState.Records[i].IsSaved {
-			lastRecord = userState.Records[i]
-			break
+var mainMenuButtons = []config.MessageKey{
+	ButtonMainMenuFillRecord,
+	ButtonMainMenuSendSelf,
+	ButtonMainMenuSendTherapist,
+	ButtonMainMenuDeliveries,
+	ButtonMainMenuExport,
+	ButtonMainMenuAddNote,
+}
+
+// mainMenuButton returns the main menu button labelled text, in whatever language it was shown.
+func mainMenuButton(text string) (config.MessageKey, bool) {
+	for _, key := range mainMenuButtons {
+		for _, label := range config.MessageVariants(key) {
+			if text == label {
+				return key, true
+			}
 		}
 	}
+	return "", false
+}
 
+func viewLastRecordHandler(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, store *state.Store) {
+	lastRecord := store.LastRecord(userState.UserID)
 	if lastRecord == nil {
-		_, _ = botPort.SendMessage(ctx, chatID, "У вас еще нет сохраненных записей.", nil)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNoSavedRecords), nil)
 		return
 	}
 
 	payload := buildForwardPayload(recordConfig, lastRecord, userState)
 	recordText, err := renderForwardMessage(payload)
 	if err != nil {
-		log.Printf("[viewLastRecordHandler] Error rendering last record for user %d: %v", chatID, err)
-		_, _ = botPort.SendMessage(ctx, chatID, "Не удалось показать запись.", nil)
+		slog.ErrorContext(ctx, "rendering the last record failed", "chat_id", chatID, "err", err)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgRecordShowFailed), nil)
 		return
 	}
-	status := fmt.Sprintf("Сохранена (%s)", payload.CreatedAt)
+	status := tr(userState, config.MsgRecordStatusSaved, payload.CreatedAt)
 
-	shareKeyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✉️ Поделиться", CallbackActionPrefix+ActionShareLast),
+	shareKeyboard := botport.NewKeyboard(
+		botport.NewRow(
+			botport.NewButton(tr(userState, config.MsgButtonShare), CallbackActionPrefix+ActionShareLast),
 		),
 	)
 
-	msgText := fmt.Sprintf("📄 Последняя запись (Статус: %s):\n\n%s", status, recordText)
-	_, err = botPort.SendMessage(ctx, chatID, msgText, shareKeyboard)
+	msgText := tr(userState, config.MsgLastRecord, status, recordText)
+	_, err = botPort.SendMessage(ctx, chatID, msgText, shareKeyboard, recordSendOptions()...)
 	if err != nil {
-		log.Printf("[viewLastRecordHandler] Error sending last record for user %d: %v", chatID, err)
+		slog.ErrorContext(ctx, "sending the last record failed", "chat_id", chatID, "err", err)
 	}
 }
 
-func viewListHandler(ctx context.Context, userState *state.UserState, botPort botport.BotPort, chatID int64, messageID int) {
+func viewListHandler(ctx context.Context, userState *state.UserState, botPort botport.BotPort, chatID int64, messageID int, store *state.Store) {
 	const pageSize = 5
 
 	offset := userState.ListOffset
-	allRecords := make([]*state.Record, len(userState.Records))
-	copy(allRecords, userState.Records)
-
-	savedRecords := []*state.Record{}
-	for _, r := range allRecords {
-		if r.IsSaved {
-			savedRecords = append(savedRecords, r)
-		}
-	}
-	totalRecords := len(savedRecords)
+	totalRecords := store.CountRecords(userState.UserID, state.RecordFilter{})
 
 	if totalRecords == 0 {
-		text := "У вас еще нет сохраненных записей."
-		var kbd interface{}
-		if messageID != 0 {
-			kbd = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
-		}
+		text := tr(userState, config.MsgNoSavedRecords)
 		if messageID != 0 {
-			_, _ = botPort.EditMessage(ctx, chatID, messageID, text, kbd.(*tgbotapi.InlineKeyboardMarkup))
+			_, _ = botPort.EditMessage(ctx, chatID, messageID, text, botport.NewKeyboard())
 		} else {
-			_, _ = botPort.SendMessage(ctx, chatID, text, kbd)
+			_, _ = botPort.SendMessage(ctx, chatID, text, nil)
 		}
 
 		if userState.MainMenuFSM.Current() == StateViewingList {
-			err := userState.MainMenuFSM.Event(ctx, EventBackToIdle, userState, chatID)
+			err := userState.MainMenuFSM.Event(ctx, EventBackToIdle)
 			if err != nil {
-				log.Printf("[viewListHandler] Error transitioning main FSM to idle for user %d: %v", chatID, err)
+				slog.ErrorContext(ctx, "main FSM back to idle failed", "chat_id", chatID, "err", err)
 			}
 		}
 		return
@@ -145,31 +147,25 @@ func viewListHandler(ctx context.Context, userState *state.UserState, botPort bo
 		end = totalRecords
 	}
 
-	pageRecords := []*state.Record{}
+	var pageRecords []state.RecordMeta
 	if start < end {
-		revStart := totalRecords - end
-		revEnd := totalRecords - start
-		if revStart < 0 {
-			revStart = 0
-		}
-		pageRecords = savedRecords[revStart:revEnd]
+		pageRecords = store.ListRecordMetas(userState.UserID, state.RecordFilter{}, state.Page{Offset: start, Limit: end - start})
 	}
 
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("🗂️ Список записей (%d - %d из %d):\n\n", start+1, end, totalRecords))
+	builder.WriteString(tr(userState, config.MsgRecordListHeader, start+1, end, totalRecords) + "\n\n")
 
 	if len(pageRecords) == 0 && totalRecords > 0 {
-		builder.WriteString("Нет записей на этой странице.")
+		builder.WriteString(tr(userState, config.MsgRecordListEmptyPage))
 	} else {
-		for i := len(pageRecords) - 1; i >= 0; i-- {
-			r := pageRecords[i]
-			builder.WriteString(fmt.Sprintf("📌 ID: ...%s (%s)\n", getLastNChars(r.ID, 6), r.CreatedAt.Format("02.01.06 15:04")))
+		for _, r := range pageRecords {
+			builder.WriteString(fmt.Sprintf("📌 ID: %s (%s)\n", idgen.ShortCode(r.ID), r.CreatedAt.Format("02.01.06 15:04")))
 
-			if name, ok := r.Data["name"]; ok && name != "" {
-				builder.WriteString(fmt.Sprintf("   Имя: %s\n", truncateString(name, 25)))
+			if name := r.Preview["name"]; name != "" {
+				builder.WriteString("   " + tr(userState, config.MsgRecordListName, truncateString(name, 25)) + "\n")
 			}
-			if city, ok := r.Data["city"]; ok && city != "" {
-				builder.WriteString(fmt.Sprintf("   Город: %s\n", truncateString(city, 25)))
+			if city := r.Preview["city"]; city != "" {
+				builder.WriteString("   " + tr(userState, config.MsgRecordListCity, truncateString(city, 25)) + "\n")
 			}
 			builder.WriteString("---\n")
 		}
@@ -177,18 +173,19 @@ func viewListHandler(ctx context.Context, userState *state.UserState, botPort bo
 
 	hasPrev := start > 0
 	hasNext := end < totalRecords
-	keyboard := listNavigationKeyboard(hasPrev, hasNext)
+	keyboard := listNavigationKeyboard(userState, hasPrev, hasNext)
+	keyboard.Rows = append(recordViewRows(pageRecords), keyboard.Rows...)
 
 	text := builder.String()
 	if messageID != 0 {
-		_, err := botPort.EditMessage(ctx, chatID, messageID, text, &keyboard)
+		_, err := botPort.EditMessage(ctx, chatID, messageID, text, keyboard)
 		if err != nil && !strings.Contains(err.Error(), "message is not modified") {
-			log.Printf("[viewListHandler] Error editing list for user %d: %v", chatID, err)
+			slog.ErrorContext(ctx, "editing the record list failed", "chat_id", chatID, "err", err)
 		}
 	} else {
 		_, err := botPort.SendMessage(ctx, chatID, text, keyboard)
 		if err != nil {
-			log.Printf("[viewListHandler] Error sending list for user %d: %v", chatID, err)
+			slog.ErrorContext(ctx, "sending the record list failed", "chat_id", chatID, "err", err)
 		}
 	}
 }
@@ -200,22 +197,22 @@ func formatRecordForDisplay(r *state.Record) string {
 	var sb strings.Builder
 
 	if val, ok := r.Data["name"]; ok {
-		sb.WriteString(fmt.Sprintf("Имя: %s\n", val))
+		sb.WriteString(fmt.Sprintf("Имя: %s\n", val.String()))
 	}
 	if val, ok := r.Data["city"]; ok {
-		sb.WriteString(fmt.Sprintf("Город: %s\n", val))
+		sb.WriteString(fmt.Sprintf("Город: %s\n", val.String()))
 	}
 	if val, ok := r.Data["age"]; ok {
-		sb.WriteString(fmt.Sprintf("Возраст: %s\n", val))
+		sb.WriteString(fmt.Sprintf("Возраст: %s\n", val.String()))
 	}
 	if val, ok := r.Data["company"]; ok {
-		sb.WriteString(fmt.Sprintf("Компания: %s\n", val))
+		sb.WriteString(fmt.Sprintf("Компания: %s\n", val.String()))
 	}
 	if val, ok := r.Data["employment"]; ok {
-		sb.WriteString(fmt.Sprintf("Занятость: %s\n", val))
+		sb.WriteString(fmt.Sprintf("Занятость: %s\n", val.String()))
 	}
 	if val, ok := r.Data["notes"]; ok {
-		sb.WriteString(fmt.Sprintf("Заметки: %s\n", val))
+		sb.WriteString(fmt.Sprintf("Заметки: %s\n", val.String()))
 	}
 
 	text := sb.String()
@@ -225,26 +222,26 @@ func formatRecordForDisplay(r *state.Record) string {
 	return text
 }
 
-func listNavigationKeyboard(hasPrev, hasNext bool) tgbotapi.InlineKeyboardMarkup {
-	row := []tgbotapi.InlineKeyboardButton{}
+func listNavigationKeyboard(userState *state.UserState, hasPrev, hasNext bool) *botport.Keyboard {
+	row := []botport.Button{}
 	if hasPrev {
-		row = append(row, tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", CallbackListNavPrefix+"back"))
+		row = append(row, botport.NewButton(tr(userState, config.MsgButtonBack), CallbackListNavPrefix+"back"))
 	}
 	if hasNext {
-		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Вперед ➡️", CallbackListNavPrefix+"next"))
+		row = append(row, botport.NewButton(tr(userState, config.MsgButtonNextPage), CallbackListNavPrefix+"next"))
 	}
 
-	backRow := []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("⬆️ В главное меню", CallbackListNavPrefix+"tomenu"),
+	backRow := []botport.Button{
+		botport.NewButton(tr(userState, config.MsgButtonToMainMenu), CallbackListNavPrefix+"tomenu"),
 	}
 
 	if len(row) > 0 {
-		return tgbotapi.NewInlineKeyboardMarkup(row, backRow)
+		return botport.NewKeyboard(row, backRow)
 	} else if len(backRow) > 0 {
-		return tgbotapi.NewInlineKeyboardMarkup(backRow)
+		return botport.NewKeyboard(backRow)
 	}
 
-	return tgbotapi.NewInlineKeyboardMarkup()
+	return botport.NewKeyboard()
 }
 
 func truncateString(s string, n int) string {
@@ -257,9 +254,3 @@ func truncateString(s string, n int) string {
 	}
 	return string(runes[:n]) + "..."
 }
-func getLastNChars(s string, n int) string {
-	if len(s) <= n {
-		return s
-	}
-	return s[len(s)-n:]
-}
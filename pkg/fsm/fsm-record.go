@@ -2,26 +2,26 @@ package fsm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
-	"log"
-	"sort"
+	"log/slog"
 	"strings"
-	"time"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/looplab/fsm"
 )
 
-func NewRecordFSM(initialState string) *fsm.FSM {
+// newRecordFSM returns a record FSM in initialState whose callbacks use h's dependencies.
+func (h *Handler) newRecordFSM(initialState string) *fsm.FSM {
 
 	callbacks := fsm.Callbacks{
-		"enter_" + StateSelectingSection:  enterSelectingSection,
-		"enter_" + StateAnsweringQuestion: enterAnsweringQuestion,
-		"enter_" + StateRecordIdle:        enterRecordIdle,
+		"enter_" + StateSelectingSection:  h.enterSelectingSection,
+		"enter_" + StateAnsweringQuestion: h.enterAnsweringQuestion,
+		"enter_" + StateRecordIdle:        h.enterRecordIdle,
+		"before_" + EventSaveFullRecord:   h.beforeSaveFullRecord,
 	}
 
 	events := fsm.Events{
@@ -39,109 +39,161 @@ func NewRecordFSM(initialState string) *fsm.FSM {
 	return fsm.NewFSM(initialState, events, callbacks)
 }
 
-func enterSelectingSection(ctx context.Context, e *fsm.Event) {
-	log.Printf("[enterSelectingSection] START - Event: %s, Src: %s", e.Event, e.Src)
+// forwardOnSave reports whether the save event must deliver the record: forward_on_save is on or the
+// user picked "save and send".
+func forwardOnSave(ev recordEvent) bool {
+	return config.FeatureEnabled(config.FeatureForwardOnSave) || ev.Forward
+}
 
-	if len(e.Args) < 4 {
-		log.Printf("[enterSelectingSection] FATAL: Not enough arguments (got %d, expected at least 4)", len(e.Args))
+// beforeSaveFullRecord runs the save side effects before the transition commits. When forwardOnSave
+// holds, the draft is sent to TARGET_USER_ID first and a failed delivery cancels the save, so the record
+// is either saved and delivered or left untouched as a draft.
+func (h *Handler) beforeSaveFullRecord(ctx context.Context, e *fsm.Event) {
+	ev, ok := recordEventOf(e)
+	if !ok || !forwardOnSave(ev) {
 		return
 	}
-	userState, okS := e.Args[0].(*state.UserState)
-	botPort, okB := e.Args[1].(botport.BotPort)
-	recordConfig, okC := e.Args[2].(*config.RecordConfig)
-	chatID, okCh := e.Args[3].(int64)
-	var messageID int
-	if len(e.Args) > 4 {
-		messageID, _ = e.Args[4].(int)
-	}
-
-	if !okS || userState == nil {
-		log.Printf("[enterSelectingSection] FATAL: Failed to cast or nil UserState arg")
+	userState, botPort, chatID := ev.User, h.bot, ev.ChatID
+	if userState.Preview || userState.CurrentRecord == nil {
 		return
 	}
-	if !okB || botPort == nil {
-		log.Printf("[enterSelectingSection] FATAL: Failed to cast or nil BotPort arg")
-		return
+	recordConfig := h.recordConfig(userState)
+
+	targetUserID := targetFor(userState)
+	err := fmt.Errorf("TARGET_USER_ID is not configured")
+	if targetUserID != 0 {
+		_, err = deliverRecord(ctx, botPort, recordConfig, userState, userState.CurrentRecord, targetUserID, h.store, therapistKeyboard(h.store.Lang(targetUserID), userState.UserID, ""))
 	}
-	if !okC || recordConfig == nil {
-		log.Printf("[enterSelectingSection] FATAL: Failed to cast or nil RecordConfig arg")
+	if err != nil {
+		slog.WarnContext(ctx, "save rolled back", "user_id", userState.UserID, "err", err)
+		text := tr(userState, config.MsgSaveForwardFailed)
+		if errors.Is(err, errTargetBlocked) {
+			text += "\n" + tr(userState, config.MsgTargetBlocked)
+		} else if hint := botErrorText(userState, err, ""); hint != "" {
+			text += "\n" + hint
+		}
+		_, _ = botPort.SendMessage(ctx, chatID, text, nil)
+		e.Cancel(err)
 		return
 	}
-	if !okCh {
-		log.Printf("[enterSelectingSection] FATAL: Failed to cast ChatID arg")
+	slog.InfoContext(ctx, "draft delivered, committing the save", "user_id", userState.UserID, "target_id", targetUserID)
+}
+
+func (h *Handler) enterSelectingSection(ctx context.Context, e *fsm.Event) {
+	slog.DebugContext(ctx, "entering selecting_section", "event", e.Event, "src", e.Src)
+
+	ev, ok := recordEventOf(e)
+	if !ok {
+		slog.ErrorContext(ctx, "selecting_section: event without user", "args", len(e.Args))
 		return
 	}
+	userState, botPort, recordConfig := ev.User, h.bot, h.recordConfig(ev.User)
+	chatID, messageID := ev.ChatID, ev.MessageID
 
 	userID := userState.UserID
-	log.Printf("[enterSelectingSection] Args extracted successfully for User %d. messageID: %d", userID, messageID)
+	slog.DebugContext(ctx, "selecting_section arguments extracted", "user_id", userID, "message_id", messageID)
 
 	if recordConfig.Sections == nil {
-		log.Printf("[enterSelectingSection] Error: RecordConfig.Sections is nil for user %d", userID)
+		slog.ErrorContext(ctx, "record config has no sections", "user_id", userID)
 		logAndForceExit(e, "RecordConfig.Sections is nil")
 		return
 	}
 	sections := recordConfig.Sections
-	log.Printf("[enterSelectingSection] Config check passed for User %d. Number of sections: %d", userID, len(sections))
+	slog.DebugContext(ctx, "section config checked", "user_id", userID, "sections", len(sections))
 
 	currentRec := userState.CurrentRecord
 	if currentRec == nil {
-		log.Printf("[enterSelectingSection] Error: UserState.CurrentRecord is nil for user %d", userID)
+		slog.ErrorContext(ctx, "current record is nil", "user_id", userID)
 		logAndForceExit(e, "UserState.CurrentRecord is nil")
 		return
 	}
 	if currentRec.Data == nil {
-		log.Printf("[enterSelectingSection] Error: UserState.CurrentRecord.Data is nil for user %d", userID)
+		slog.ErrorContext(ctx, "current record has no data", "user_id", userID)
 		logAndForceExit(e, "UserState.CurrentRecord.Data is nil")
 		return
 	}
-	recordData := currentRec.Data
-	log.Printf("[enterSelectingSection] CurrentRecord check passed for User %d.", userID)
+	slog.DebugContext(ctx, "current record checked", "user_id", userID)
 
-	showSectionSelectionMenu(ctx, userState, botPort, recordConfig, chatID, messageID, recordData, e)
+	showSectionSelectionMenu(ctx, userState, botPort, recordConfig, chatID, messageID, currentRec, e)
 }
 
-func showSectionSelectionMenu(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int, recordData map[string]string, evt *fsm.Event) {
-	prompt := "Выберите секцию для заполнения/редактирования или действие:"
-	keyboard := tgbotapi.NewInlineKeyboardMarkup()
-	log.Printf("[enterSelectingSection] Building keyboard for User %d...", chatID)
+func showSectionSelectionMenu(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int, record *state.Record, evt *fsm.Event) {
+	prompt := tr(userState, config.MsgSectionMenuPrompt)
+	if userState.Preview {
+		prompt = previewLabel + "\n" + prompt
+	}
+	keyboard := botport.NewKeyboard()
+	slog.DebugContext(ctx, "building the section keyboard", "chat_id", chatID)
 
-	sectionIDs := getSortedSectionIDs(recordConfig.Sections)
-	for _, sectionID := range sectionIDs {
+	var closed, open []string
+	for _, sectionID := range recordConfig.SectionIDs() {
 		sectionConf := recordConfig.Sections[sectionID]
-		hasData := sectionHasData(sectionConf, recordData)
-		buttonText := sectionConf.Title
-		if hasData {
-			buttonText += " ✅"
+		if !sectionOpen(userState, sectionConf) {
+			closed = append(closed, fmt.Sprintf("🔒 %s — %s", sectionConf.Title, windowText(userState, sectionConf.Available)))
+			continue
 		}
+		open = append(open, sectionID)
+	}
+
+	pageSize := recordConfig.SectionsPerPage()
+	pages := (len(open) + pageSize - 1) / pageSize
+	if userState.SectionPage >= pages {
+		userState.SectionPage = pages - 1
+	}
+	if userState.SectionPage < 0 {
+		userState.SectionPage = 0
+	}
+	start := userState.SectionPage * pageSize
+	end := min(start+pageSize, len(open))
 
-		row := tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(buttonText, CallbackSectionPrefix+sectionID),
+	for _, sectionID := range open[start:end] {
+		sectionConf := recordConfig.Sections[sectionID]
+		answered, total := sectionProgress(sectionConf, record)
+		buttonText := sectionButtonText(sectionConf.Title, answered, total)
+
+		row := botport.NewRow(
+			botport.NewButton(buttonText, CallbackSectionPrefix+sectionID),
 		)
-		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, row)
+		keyboard.AddRow(row...)
+	}
+	if pages > 1 {
+		prompt += "\n" + tr(userState, config.MsgSectionMenuPage, userState.SectionPage+1, pages)
+		keyboard.AddRow(sectionNavRow(userState, userState.SectionPage > 0, end < len(open))...)
+	}
+
+	if len(closed) > 0 {
+		prompt += "\n\n" + strings.Join(closed, "\n")
 	}
 
-	actionRow := tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("💾 Сохранить запись", CallbackActionPrefix+ActionSaveRecord),
-		tgbotapi.NewInlineKeyboardButtonData("🆕 Начать новую запись", CallbackActionPrefix+ActionNewRecord),
+	actionRow := botport.NewRow(
+		botport.NewButton(tr(userState, config.MsgButtonSaveRecord), CallbackActionPrefix+ActionSaveRecord),
+		botport.NewButton(tr(userState, config.MsgButtonNewRecord), CallbackActionPrefix+ActionNewRecord),
 	)
-	exitRow := tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("⬆️ Выйти в меню", CallbackActionPrefix+ActionExitMenu),
+	exitRow := botport.NewRow(
+		botport.NewButton(tr(userState, config.MsgButtonExitMenu), CallbackActionPrefix+ActionExitMenu),
 	)
-	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, actionRow, exitRow)
+	if draftHasAnswers(userState.CurrentRecord) {
+		keyboard.AddRow(botport.NewButton(tr(userState, config.MsgButtonEditAnswers), CallbackActionPrefix+ActionEditAnswers))
+	}
+	keyboard.AddRow(actionRow...)
+	if targetFor(userState) != 0 && !userState.Preview {
+		keyboard.AddRow(botport.NewButton(tr(userState, config.MsgButtonSaveAndSend), CallbackActionPrefix+ActionSaveAndSend))
+	}
+	keyboard.AddRow(exitRow...)
 
 	var sentMsg botport.BotMessage
 	var err error
 	if messageID != 0 {
-		sentMsg, err = botPort.EditMessage(ctx, chatID, messageID, prompt, &keyboard)
+		sentMsg, err = botPort.EditMessage(ctx, chatID, messageID, prompt, keyboard)
 	} else {
 		sentMsg, err = botPort.SendMessage(ctx, chatID, prompt, keyboard)
 	}
 
 	if err != nil {
 		if !strings.Contains(err.Error(), "message is not modified") {
-			log.Printf("[enterSelectingSection] Error sending/editing message for user %d: %v", chatID, err)
+			slog.ErrorContext(ctx, "sending the section menu failed", "chat_id", chatID, "err", err)
 			if evt != nil {
-				_ = evt.FSM.Event(ctx, EventForceExit, userState, botPort, recordConfig, chatID, 0, "error displaying section menu")
+				_ = evt.FSM.Event(ctx, EventForceExit, recordEvent{User: userState, ChatID: chatID, Reason: reasonSectionMenuFailed})
 			}
 		} else {
 			sentMsg.MessageID = messageID
@@ -149,16 +201,27 @@ func showSectionSelectionMenu(ctx context.Context, userState *state.UserState, b
 	}
 
 	if err == nil || strings.Contains(err.Error(), "message is not modified") {
-		userState.LastMessageID = sentMsg.MessageID
-		userState.LastPrompt = toBotMessageFromPort(chatID, sentMsg.MessageID, prompt, &keyboard)
-		log.Printf("[enterSelectingSection] Section selection menu shown/updated for user %d (MessageID: %d)", chatID, sentMsg.MessageID)
+		setLastPrompt(userState, chatID, sentMsg.MessageID, prompt, keyboard)
+		slog.DebugContext(ctx, "section menu shown", "chat_id", chatID, "message_id", sentMsg.MessageID)
 	}
 
-	log.Printf("[enterSelectingSection] END - User %d", chatID)
+	slog.DebugContext(ctx, "selecting_section entered", "chat_id", chatID)
+}
+
+// sectionNavRow is the row of buttons paging through the section menu.
+func sectionNavRow(userState *state.UserState, hasPrev, hasNext bool) []botport.Button {
+	row := []botport.Button{}
+	if hasPrev {
+		row = append(row, botport.NewButton(tr(userState, config.MsgButtonBack), CallbackSectionNavPrefix+"back"))
+	}
+	if hasNext {
+		row = append(row, botport.NewButton(tr(userState, config.MsgButtonNextPage), CallbackSectionNavPrefix+"next"))
+	}
+	return row
 }
 
 func askCurrentQuestion(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, messageIDToEdit int) {
-	log.Printf("[askCurrentQuestion] Preparing question for user %d, potentially editing message %d", userState.UserID, messageIDToEdit)
+	slog.DebugContext(ctx, "preparing the question", "user_id", userState.UserID, "edit_message_id", messageIDToEdit)
 
 	sectionID := userState.CurrentSection
 	qIndex := userState.CurrentQuestion
@@ -166,22 +229,22 @@ func askCurrentQuestion(ctx context.Context, userState *state.UserState, botPort
 
 	sectionConf, okSec := recordConfig.Sections[sectionID]
 	if !okSec {
-		log.Printf("[askCurrentQuestion] Error: Section '%s' not found in config for user %d", sectionID, userState.UserID)
-		_, _ = botPort.SendMessage(ctx, userState.UserID, "Ошибка конфигурации секции.", nil)
+		slog.ErrorContext(ctx, "section not found in config", "section", sectionID, "user_id", userState.UserID)
+		_, _ = botPort.SendMessage(ctx, userState.UserID, tr(userState, config.MsgSectionConfigError), nil)
 		return
 	}
 
 	if qIndex < 0 || qIndex >= len(sectionConf.Questions) {
-		log.Printf("[askCurrentQuestion] Error: Invalid question index %d for section '%s' user %d", qIndex, sectionID, userState.UserID)
-		_, _ = botPort.SendMessage(ctx, userState.UserID, "Ошибка навигации по вопросам.", nil)
+		slog.ErrorContext(ctx, "invalid question index", "question_index", qIndex, "section", sectionID, "user_id", userState.UserID)
+		_, _ = botPort.SendMessage(ctx, userState.UserID, tr(userState, config.MsgQuestionNavError), nil)
 		return
 	}
 
 	question := sectionConf.Questions[qIndex]
 	strategy := questions.Get(question.Type)
 	if strategy == nil {
-		log.Printf("[askCurrentQuestion] Error: No strategy registered for type '%s'", question.Type)
-		_, _ = botPort.SendMessage(ctx, userState.UserID, "Неизвестный тип вопроса. Попробуйте позже.", nil)
+		slog.ErrorContext(ctx, "no strategy for question type", "type", question.Type)
+		_, _ = botPort.SendMessage(ctx, userState.UserID, tr(userState, config.MsgUnknownQuestionType), nil)
 		return
 	}
 
@@ -200,104 +263,166 @@ func askCurrentQuestion(ctx context.Context, userState *state.UserState, botPort
 
 	prompt, err := strategy.Render(renderCtx)
 	if err != nil {
-		log.Printf("[askCurrentQuestion] Error rendering question '%s': %v", question.ID, err)
-		_, _ = botPort.SendMessage(ctx, userState.UserID, "Не удалось подготовить вопрос. Попробуйте позже.", nil)
+		slog.ErrorContext(ctx, "rendering the question failed", "question", question.ID, "err", err)
+		_, _ = botPort.SendMessage(ctx, userState.UserID, tr(userState, config.MsgQuestionPrepareFailed), nil)
 		return
 	}
 
-	var keyboard *tgbotapi.InlineKeyboardMarkup
-	if prompt.Keyboard != nil {
-		keyboard = prompt.Keyboard
-	} else {
-		empty := tgbotapi.NewInlineKeyboardMarkup()
-		keyboard = &empty
+	keyboard := prompt.Keyboard
+	if keyboard == nil {
+		keyboard = botport.NewKeyboard()
 	}
 
-	cancelRow := tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад к выбору секций", CallbackActionPrefix+ActionCancelSection))
-	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, cancelRow)
+	suggestion := prefillSuggestion(userState, question)
+	if suggestion != "" {
+		keyboard.AddRow(botport.NewButton("✅ "+suggestion, CallbackActionPrefix+ActionPrefillAccept))
+	}
+
+	var navRow []botport.Button
+	if qIndex > 0 {
+		navRow = append(navRow, botport.NewButton(tr(userState, config.MsgButtonReviewSection), CallbackActionPrefix+ActionReviewSection))
+	}
+	if len(sectionConf.Questions) > 1 {
+		navRow = append(navRow, botport.NewButton(tr(userState, config.MsgButtonJumpMenu), CallbackActionPrefix+ActionJumpMenu))
+	}
+	keyboard.AddRow(navRow...)
+	keyboard.AddRow(botport.NewButton(tr(userState, config.MsgButtonBackToSections), CallbackActionPrefix+ActionCancelSection))
+
+	promptText := prompt.Text
+	if existing := currentAnswer(userState.CurrentRecord, question); existing != "" {
+		promptText = tr(userState, config.MsgCurrentAnswer, prompt.Text, existing)
+	} else if suggestion != "" {
+		promptText = prompt.Text + "\n\n" + tr(userState, config.MsgPrefillHint, suggestion)
+	}
+	if userState.Preview {
+		promptText = previewLabel + "\n" + promptText
+	}
+
+	forceNew := prompt.ForceNew || !strategy.Capabilities().EditInPlace || !botPort.Capabilities().EditInPlace
 
 	var sentMsg botport.BotMessage
-	isEdit := (messageIDToEdit != 0) && !prompt.ForceNew
+	isEdit := (messageIDToEdit != 0) && !forceNew
 
 	effectiveMessageID := messageIDToEdit
-	if effectiveMessageID == 0 && lastMsgID != 0 && !prompt.ForceNew {
+	if effectiveMessageID == 0 && lastMsgID != 0 && !forceNew {
 		effectiveMessageID = lastMsgID
 		isEdit = true
-		log.Printf("[askCurrentQuestion] Using LastMessageID (%d) for editing", effectiveMessageID)
+		slog.DebugContext(ctx, "editing the last message", "message_id", effectiveMessageID)
 	}
 
 	if isEdit && effectiveMessageID != 0 {
-		sentMsg, err = botPort.EditMessage(ctx, userState.UserID, effectiveMessageID, prompt.Text, keyboard)
+		sentMsg, err = botPort.EditMessage(ctx, userState.UserID, effectiveMessageID, promptText, keyboard)
 	} else {
-		sentMsg, err = botPort.SendMessage(ctx, userState.UserID, prompt.Text, keyboard)
+		sentMsg, err = botPort.SendMessage(ctx, userState.UserID, promptText, keyboard)
 	}
 
 	if err != nil {
 		if isEdit && botport.IsCode(err, "message_not_modified") {
-			log.Printf("[askCurrentQuestion] Message %d not modified.", effectiveMessageID)
+			slog.DebugContext(ctx, "message not modified", "message_id", effectiveMessageID)
 			sentMsg = botport.BotMessage{ChatID: userState.UserID, MessageID: effectiveMessageID, Transport: "telegram"}
 		} else {
-			log.Printf("[askCurrentQuestion] Error sending/editing question prompt for user %d (Q: %s): %v", userState.UserID, question.ID, err)
+			slog.ErrorContext(ctx, "sending the question prompt failed", "user_id", userState.UserID, "question", question.ID, "err", err)
 			return
 		}
 	} else {
-		log.Printf("[askCurrentQuestion] Question '%s' sent/edited successfully. MessageID: %d", question.ID, sentMsg.MessageID)
+		slog.DebugContext(ctx, "question prompt sent", "question", question.ID, "message_id", sentMsg.MessageID)
+	}
+	if lastMsgID != 0 && sentMsg.MessageID != lastMsgID {
+		// The previous prompt was replaced by a new message; its buttons are stale.
+		scheduleCleanup(botPort, recordConfig, userState.UserID, lastMsgID)
 	}
 
 	userState.LastMessageID = sentMsg.MessageID
 	userState.LastPrompt = sentMsg
-	log.Printf("[askCurrentQuestion] Set LastMessageID to %d for user %d", sentMsg.MessageID, userState.UserID)
-	log.Printf("[askCurrentQuestion] END - User %d", userState.UserID)
+	slog.DebugContext(ctx, "last message ID set", "message_id", sentMsg.MessageID, "user_id", userState.UserID)
+	slog.DebugContext(ctx, "question asked", "user_id", userState.UserID)
 }
 
-func enterAnsweringQuestion(ctx context.Context, e *fsm.Event) {
-	log.Printf("[enterAnsweringQuestion] ****** ENTER CALLBACK START ****** - Event: %s, Src: %s", e.Event, e.Src)
-	if len(e.Args) < 4 {
-		log.Printf("[enterAnsweringQuestion] Error: not enough args")
+// showQuestionJumpMenu replaces the current prompt with a numbered list of the section questions.
+// Each button carries the question ID so jumps stay valid even if the list message is stale.
+func showQuestionJumpMenu(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int) {
+	sectionConf, ok := recordConfig.Sections[userState.CurrentSection]
+	if !ok {
+		slog.ErrorContext(ctx, "section not found in config", "section", userState.CurrentSection, "user_id", userState.UserID)
 		return
 	}
-	userState, okS := e.Args[0].(*state.UserState)
-	botPort, okB := e.Args[1].(botport.BotPort)
-	recordConfig, okC := e.Args[2].(*config.RecordConfig)
-	messageIDFromEvent := 0
-	if len(e.Args) > 4 {
-		messageIDFromEvent, _ = e.Args[4].(int)
+
+	keyboard := botport.NewKeyboard()
+	number := 0
+	for idx, q := range sectionConf.Questions {
+		if !userState.CurrentRecord.Visible(q) {
+			continue
+		}
+		number++
+		label := fmt.Sprintf("%d. %s", number, truncateString(q.Prompt, 40))
+		if currentAnswer(userState.CurrentRecord, q) != "" {
+			label += " ✅"
+		}
+		if idx == userState.CurrentQuestion {
+			label = "▶️ " + label
+		}
+		keyboard.AddRow(botport.NewButton(label, CallbackJumpPrefix+q.ID))
 	}
 
-	if !okS || !okB || !okC {
-		log.Printf("[enterAnsweringQuestion] Error: invalid arg types")
+	text := tr(userState, config.MsgChooseQuestion, sectionConf.Title)
+	sentMsg, err := botPort.EditMessage(ctx, chatID, messageID, text, keyboard)
+	if err != nil && !botport.IsCode(err, "message_not_modified") {
+		slog.ErrorContext(ctx, "showing the question list failed", "user_id", userState.UserID, "err", err)
 		return
 	}
-
-	askCurrentQuestion(ctx, userState, botPort, recordConfig, messageIDFromEvent)
-	log.Printf("[enterAnsweringQuestion] ****** ENTER CALLBACK END ****** - Event: %s, Src: %s", e.Event, e.Src)
+	if err == nil {
+		setLastPrompt(userState, chatID, sentMsg.MessageID, text, keyboard)
+	}
 }
 
-func enterRecordIdle(ctx context.Context, e *fsm.Event) {
-	if len(e.Args) < 4 {
-		log.Printf("[enterRecordIdle] Error: not enough args for event %s", e.Event)
-		return
+// jumpToQuestion moves the cursor to the question with the given ID inside the current section.
+func jumpToQuestion(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, questionID string, messageID int) bool {
+	sectionConf, ok := recordConfig.Sections[userState.CurrentSection]
+	if !ok {
+		return false
 	}
-	userState, okS := e.Args[0].(*state.UserState)
-	botPort, okB := e.Args[1].(botport.BotPort)
-	chatID, okCh := e.Args[3].(int64)
-	var messageID int
-	if len(e.Args) > 4 {
-		messageID, _ = e.Args[4].(int)
+	for idx, q := range sectionConf.Questions {
+		if q.ID == questionID && userState.CurrentRecord.Visible(q) {
+			slog.InfoContext(ctx, "user jumps to a question", "user_id", userState.UserID, "question", questionID, "question_index", idx)
+			userState.CurrentQuestion = idx
+			askCurrentQuestion(ctx, userState, botPort, recordConfig, messageID)
+			return true
+		}
 	}
+	return false
+}
 
-	var failureReason string
-	if len(e.Args) > 5 {
-		failureReason, _ = e.Args[5].(string)
+// currentAnswer returns the stored answer for the question so re-asked prompts show what will be overwritten.
+func currentAnswer(record *state.Record, question config.QuestionConfig) string {
+	return record.GetString(question)
+}
+
+func (h *Handler) enterAnsweringQuestion(ctx context.Context, e *fsm.Event) {
+	slog.DebugContext(ctx, "entering answering_question", "event", e.Event, "src", e.Src)
+	ev, ok := recordEventOf(e)
+	if !ok {
+		slog.ErrorContext(ctx, "answering_question: event without user", "args", len(e.Args))
+		return
 	}
 
-	if !okS || !okB || !okCh {
-		log.Printf("[enterRecordIdle] Error: Invalid argument types for event %s, user %d", e.Event, userState.UserID)
-		sendMainMenu(ctx, botPort, userState)
+	askCurrentQuestion(ctx, ev.User, h.bot, h.recordConfig(ev.User), ev.MessageID)
+	slog.DebugContext(ctx, "answering_question entered", "event", e.Event, "src", e.Src)
+}
+
+func (h *Handler) enterRecordIdle(ctx context.Context, e *fsm.Event) {
+	ev, ok := recordEventOf(e)
+	if !ok {
+		slog.ErrorContext(ctx, "record_idle: event without user", "event", e.Event, "args", len(e.Args))
 		return
 	}
+	userState, botPort, recordConfig := ev.User, h.bot, h.recordConfig(ev.User)
+	chatID, messageID, failureReason := ev.ChatID, ev.MessageID, ev.Reason
 
-	log.Printf("[enterRecordIdle] User %d entering RecordIdle state via event '%s'. MessageID: %d", chatID, e.Event, messageID)
+	slog.DebugContext(ctx, "entering record_idle", "chat_id", chatID, "event", e.Event, "message_id", messageID)
+
+	batch := newEditBatch(botPort)
+	defer batch.Flush(ctx)
+	botPort = batch
 
 	finalText := ""
 	clearDraft := false
@@ -305,38 +430,64 @@ func enterRecordIdle(ctx context.Context, e *fsm.Event) {
 
 	recordToFinalize := userState.CurrentRecord
 
-	switch e.Event {
-	case EventSaveFullRecord:
-		if recordToFinalize != nil {
-			recordToFinalize.IsSaved = true
-			recordToFinalize.CreatedAt = time.Now()
-			recordToFinalize.ID = fmt.Sprintf("%d-%d", userState.UserID, recordToFinalize.CreatedAt.UnixNano())
-			finalText = "✅ Запись успешно сохранена!"
-			saveRecord = true
-			clearDraft = true
-			log.Printf("[enterRecordIdle] Record marked for saving for user %d.", chatID)
-		} else {
-			finalText = "⚠️ Ошибка: Не найден черновик для сохранения."
-			log.Printf("[enterRecordIdle] Error: CurrentRecord was nil when trying to save for user %d", chatID)
+	if userState.Preview {
+		finishPreview(userState)
+		finalText = tr(userState, config.MsgPreviewFinished)
+		slog.InfoContext(ctx, "preview finished", "chat_id", chatID, "event", e.Event)
+	} else {
+		switch e.Event {
+		case EventSaveFullRecord:
+			if recordToFinalize != nil {
+				recordToFinalize.IsSaved = true
+				recordToFinalize.Transient = nil
+				recordToFinalize.FirstRecord = false
+				recordToFinalize.CreatedAt = clock()
+				if recordToFinalize.ID == "" { // A forwarded draft keeps the ID its delivery refers to
+					recordToFinalize.ID = idGenerator.NewID()
+				}
+				markRecordLate(recordConfig, recordToFinalize, recordToFinalize.CreatedAt)
+				finalText = tr(userState, config.MsgRecordSaved)
+				if forwardOnSave(ev) {
+					finalText = tr(userState, config.MsgRecordSavedAndSent)
+				}
+				saveRecord = true
+				clearDraft = true
+				slog.InfoContext(ctx, "record marked for saving", "chat_id", chatID)
+			} else {
+				finalText = tr(userState, config.MsgDraftNotFound)
+				slog.ErrorContext(ctx, "no current record to save", "chat_id", chatID)
+				clearDraft = true
+			}
+		case EventExitToMainMenu:
+			finalText = tr(userState, config.MsgExitKeepDraft)
+			clearDraft = false
+			slog.InfoContext(ctx, "exiting to the main menu, draft kept", "chat_id", chatID)
+		case EventForceExit:
+			finalText = exitReasonText(userState, failureReason)
+			clearDraft = false
+			slog.WarnContext(ctx, "force exiting the record", "chat_id", chatID, "reason", failureReason)
+		default:
+			finalText = tr(userState, config.MsgOperationDone)
 			clearDraft = true
+			slog.WarnContext(ctx, "record FSM idle via unexpected event", "chat_id", chatID, "event", e.Event)
 		}
-	case EventExitToMainMenu:
-		finalText = "Выход из режима добавления. Черновик доступен для продолжения."
-		clearDraft = false
-		log.Printf("[enterRecordIdle] Exiting to main menu, draft kept for user %d.", chatID)
-	case EventForceExit:
-		finalText = fmt.Sprintf("⚠️ Произошла ошибка (%s). Ввод прерван. Черновик сохранен.", failureReason)
-		clearDraft = false
-		log.Printf("[enterRecordIdle] Force exiting record input for user %d. Reason: %s", chatID, failureReason)
-	default:
-		finalText = "Операция завершена."
-		clearDraft = true
-		log.Printf("[enterRecordIdle] Warning: RecordFSM entered idle state for user %d via unexpected event: %s", chatID, e.Event)
 	}
 
+	var stats completionStats
 	if saveRecord && recordToFinalize != nil {
 		userState.Records = append(userState.Records, recordToFinalize)
-		log.Printf("[enterRecordIdle] Record %s appended for user %d. Total records: %d", recordToFinalize.ID, chatID, len(userState.Records))
+		userState.NudgesSent = 0
+		slog.InfoContext(ctx, "record saved", "record_id", recordToFinalize.ID, "chat_id", chatID, "records", len(userState.Records))
+		stats = buildCompletionStats(userState, h.store, recordToFinalize)
+		if custom := completionText(recordConfig, stats, ""); custom != "" {
+			finalText = custom
+			if forwardOnSave(ev) {
+				finalText += "\n" + tr(userState, config.MsgRecordForwarded)
+			}
+		}
+		if recordToFinalize.Late {
+			finalText += "\n" + tr(userState, config.MsgRecordSavedLate, recordConfig.Deadline)
+		}
 	}
 
 	userState.CurrentSection = ""
@@ -344,41 +495,39 @@ func enterRecordIdle(ctx context.Context, e *fsm.Event) {
 	userState.LastMessageID = 0
 	if clearDraft {
 		userState.CurrentRecord = nil
-		log.Printf("[enterRecordIdle] Draft cleared for user %d.", chatID)
+		slog.InfoContext(ctx, "draft cleared", "chat_id", chatID)
 	}
 
 	if messageID != 0 {
-		emptyKeyboard := &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
-		_, err := botPort.EditMessage(ctx, chatID, messageID, finalText, emptyKeyboard)
-		if err != nil && !strings.Contains(err.Error(), "message is not modified") {
-			log.Printf("[enterRecordIdle] Error editing message %d for user %d: %v. Sending new message.", messageID, chatID, err)
-			_, _ = botPort.SendMessage(ctx, chatID, finalText, nil)
-		} else {
-			log.Printf("[enterRecordIdle] Final status message shown/edited for user %d.", chatID)
-		}
+		// The batch sends the status as a new message if the edit fails.
+		emptyKeyboard := botport.NewKeyboard()
+		_, _ = botPort.EditMessage(ctx, chatID, messageID, finalText, emptyKeyboard)
 	} else {
 
 		_, _ = botPort.SendMessage(ctx, chatID, finalText, nil)
 	}
+	if saveRecord {
+		celebrate(ctx, botPort, recordConfig, chatID, stats)
+	}
 
-	sendMainMenu(ctx, botPort, userState)
+	sendMainMenu(ctx, botPort, userState, h.store)
 }
 
 func logAndForceExit(e *fsm.Event, errorMsg string) {
-	log.Printf("Error in Record FSM callback: %s. Event: %s, Src: %s", errorMsg, e.Event, e.Src)
-	if len(e.Args) >= 4 {
-		userState, _ := e.Args[0].(*state.UserState)
-		botPort, _ := e.Args[1].(botport.BotPort)
-		recordConfig, _ := e.Args[2].(*config.RecordConfig)
-		chatID, _ := e.Args[3].(int64)
-		var messageID int
-		if len(e.Args) > 4 {
-			messageID, _ = e.Args[4].(int)
-		}
-		_ = e.FSM.Event(context.Background(), EventForceExit, userState, botPort, recordConfig, chatID, messageID, errorMsg)
-	} else {
-		log.Printf("Cannot trigger EventForceExit: not enough arguments in event %s", e.Event)
+	slog.Error("record FSM callback failed", "reason", errorMsg, "event", e.Event, "src", e.Src)
+	ev, ok := recordEventOf(e)
+	if !ok {
+		slog.Error("cannot force exit: event without user", "event", e.Event)
+		return
 	}
+	ev.Reason = errorMsg
+	_ = e.FSM.Event(context.Background(), EventForceExit, ev)
+}
+
+// setLastPrompt remembers the message the user acts on next, so the following answer or edit goes to it.
+func setLastPrompt(userState *state.UserState, chatID int64, messageID int, text string, markup interface{}) {
+	userState.LastMessageID = messageID
+	userState.LastPrompt = toBotMessageFromPort(chatID, messageID, text, markup)
 }
 
 func toBotMessageFromPort(chatID int64, messageID int, text string, markup interface{}) botport.BotMessage {
@@ -394,23 +543,30 @@ func toBotMessageFromPort(chatID int64, messageID int, text string, markup inter
 	}
 }
 
-func sectionHasData(sectionConf config.SectionConfig, recordData map[string]string) bool {
-	if recordData == nil {
-		return false
-	}
+// sectionProgress counts how many shown questions of the section already have a stored answer.
+func sectionProgress(sectionConf config.SectionConfig, record *state.Record) (answered int, total int) {
 	for _, q := range sectionConf.Questions {
-		if data, exists := recordData[q.StoreKey]; exists && data != "" {
-			return true
+		if !record.Visible(q) {
+			continue
+		}
+		total++
+		if record.HasAnswer(q) {
+			answered++
 		}
 	}
-	return false
+	return answered, total
 }
 
-func getSortedSectionIDs(sections map[string]config.SectionConfig) []string {
-	keys := make([]string, 0, len(sections))
-	for k := range sections {
-		keys = append(keys, k)
+// sectionButtonText marks fully answered sections with ✅ and partially answered ones with 🟡.
+func sectionButtonText(title string, answered, total int) string {
+	switch {
+	case total == 0:
+		return title
+	case answered == 0:
+		return fmt.Sprintf("%s %d/%d", title, answered, total)
+	case answered >= total:
+		return fmt.Sprintf("%s ✅ %d/%d", title, answered, total)
+	default:
+		return fmt.Sprintf("%s 🟡 %d/%d", title, answered, total)
 	}
-	sort.Strings(keys)
-	return keys
 }
@@ -3,169 +3,244 @@ package fsm
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logging"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
-
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/transcript"
 )
 
-func HandleUpdate(ctx context.Context, update tgbotapi.Update, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
-
-	var userID int64
-	var chatID int64
-	var userName string
-	var from *tgbotapi.User
-
-	if update.Message != nil {
-		if update.Message.From == nil {
-			log.Printf("Warning: Received message with nil From field")
-			return
-		}
-		from = update.Message.From
-		chatID = update.Message.Chat.ID
-	} else if update.CallbackQuery != nil {
-		if update.CallbackQuery.From == nil {
-			log.Printf("Warning: Received callback with nil From field")
-			return
-		}
-		from = update.CallbackQuery.From
-		if update.CallbackQuery.Message == nil || update.CallbackQuery.Message.Chat == nil {
-			log.Printf("Warning: Received callback query with nil Message or Chat field")
-			return
-		}
-		chatID = update.CallbackQuery.Message.Chat.ID
-	} else {
+// HandleUpdate routes one event from a transport to the sender's FSMs.
+func (h *Handler) HandleUpdate(ctx context.Context, event botport.InboundEvent) {
+	ctx = logging.NewContext(ctx)
+	botPort, store := h.bot, h.store
 
-		log.Printf("Ignoring update type: %v", update)
+	if event.Kind != botport.EventMessage && event.Kind != botport.EventCallback {
+		slog.DebugContext(ctx, "ignoring event kind", "event_id", event.ID, "kind", event.Kind)
 		return
 	}
-
-	userID = from.ID
-	userName = from.FirstName
-	if from.LastName != "" {
-		userName += " " + from.LastName
+	if event.From.ID == 0 || event.ChatID == 0 {
+		slog.WarnContext(ctx, "event without sender or chat", "event_id", event.ID, "kind", event.Kind)
+		return
 	}
+	from, chatID := event.From, event.ChatID
+	userID, userName := from.ID, from.Name()
+	slog.DebugContext(ctx, "event received", "event_id", event.ID, "kind", event.Kind, "user_id", userID)
 
 	userState := store.GetOrCreateUserState(userID, userName)
 	if userState == nil {
-		log.Printf("Error: Failed to get or create user state for user %d", userID)
+		slog.ErrorContext(ctx, "failed to get or create user state", "user_id", userID)
 
 		if chatID != 0 {
-			_, _ = botPort.SendMessage(ctx, chatID, "Произошла внутренняя ошибка. Пожалуйста, попробуйте позже или обратитесь к администратору.", nil)
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgInternalError), nil)
 		}
 		return
 	}
 
+	transcripts.Add(userID, transcript.FromEvent(event))
+
+	ctx, runUnlocked := withUnlockedQueue(ctx)
+	defer runUnlocked()
 	userState.Mu.Lock()
 	defer userState.Mu.Unlock()
+	defer syncUser(store, userState)
+	userState.LastActivity = time.Now()
+	userState.Profile = state.Profile{FirstName: from.FirstName, LastName: from.LastName, Username: from.Username, LanguageCode: from.LanguageCode}
+	store.NoteLang(userState)
+	if config.IsTherapist(userID) {
+		restoreTargetLink(ctx, botPort, store, userState)
+	}
 
-	if update.Message != nil {
-		handleMessage(ctx, update.Message, userState, botPort, recordConfig)
-	} else if update.CallbackQuery != nil {
-		handleCallbackQuery(ctx, update.CallbackQuery, userState, botPort, recordConfig)
+	recordConfig := h.recordConfig(userState)
+
+	switch event.Kind {
+	case botport.EventMessage:
+		if handleAdminCommand(ctx, &event, userState, botPort, recordConfig, store) {
+			return
+		}
+		handleMessage(ctx, &event, userState, botPort, recordConfig, store)
+	case botport.EventCallback:
+		handleCallbackQuery(ctx, &event, userState, botPort, recordConfig, store)
+	}
+}
+
+// syncUser writes userState to the store's backend, if there is one. Whatever fails is written again
+// on the user's next update.
+func syncUser(store *state.Store, userState *state.UserState) {
+	if err := store.Sync(userState); err != nil {
+		slog.Error("writing user to the backend failed", "user_id", userState.UserID, "err", err)
 	}
 }
 
-func handleMessage(ctx context.Context, message *tgbotapi.Message, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig) {
-	chatID := message.Chat.ID
+func handleMessage(ctx context.Context, message *botport.InboundEvent, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
+	chatID := message.ChatID
 	text := message.Text
 	userMessageID := message.MessageID
 
 	if message.IsCommand() {
-		switch message.Command() {
+		switch message.Command {
 		case "start":
-			chatID := message.Chat.ID
+			chatID := message.ChatID
+			dropNote(ctx, userState)
+
+			payload := strings.TrimSpace(message.Args)
+			if rawID, ok := strings.CutPrefix(payload, DeepLinkTherapistPrefix); ok {
+				handleTherapistLink(ctx, userState, botPort, store, chatID, rawID)
+			}
+			if strings.HasPrefix(payload, DeepLinkSectionPrefix) {
+				sectionID := strings.TrimPrefix(payload, DeepLinkSectionPrefix)
+				slog.InfoContext(ctx, "/start deep link into section", "user_id", userState.UserID, "section", sectionID)
+				openSectionDirectly(ctx, userState, botPort, recordConfig, store, chatID, sectionID)
+				return
+			}
 
 			if userState.RecordFSM.Current() != StateRecordIdle {
-				log.Printf("User %d used /start, resetting RecordFSM from %s to idle", userState.UserID, userState.RecordFSM.Current())
+				slog.InfoContext(ctx, "/start resets the record FSM", "user_id", userState.UserID, "state", userState.RecordFSM.Current())
 
 				lastMsgID := userState.LastMessageID
 
-				err := userState.RecordFSM.Event(ctx, EventForceExit, userState, botPort, recordConfig, chatID, lastMsgID, "command /start used")
+				err := userState.RecordFSM.Event(ctx, EventForceExit, recordEvent{User: userState, ChatID: chatID, MessageID: lastMsgID, Reason: reasonStartCommand})
 
 				if err != nil {
 
-					log.Printf("Error triggering EventForceExit via /start for user %d: %v. Attempting SetState.", userState.UserID, err)
+					slog.ErrorContext(ctx, "force exit on /start failed, setting the state directly", "user_id", userState.UserID, "err", err)
 
 					userState.RecordFSM.SetState(StateRecordIdle)
 
-					log.Printf("Manually cleaning up state and sending main menu after SetState fallback for user %d", userState.UserID)
+					slog.InfoContext(ctx, "cleaning up state after the direct state change", "user_id", userState.UserID)
 
 					userState.CurrentSection = ""
 					userState.CurrentQuestion = 0
 					userState.LastMessageID = 0
 
-					sendMainMenu(ctx, botPort, userState)
+					sendMainMenu(ctx, botPort, userState, store)
 
 				}
 
 			} else {
 
-				log.Printf("User %d used /start while already in idle state. Sending main menu.", userState.UserID)
-				sendMainMenu(ctx, botPort, userState)
+				slog.InfoContext(ctx, "/start while idle, sending main menu", "user_id", userState.UserID)
+				sendMainMenu(ctx, botPort, userState, store)
+			}
+			return
+
+		case "autoforward":
+			handleAutoForwardCommand(ctx, userState, botPort, recordConfig, chatID, message.Args)
+			return
+
+		case "export":
+			handleExport(ctx, userState, botPort, recordConfig, chatID, store, message.Args)
+			return
+
+		case "remind":
+			handleRemindCommand(ctx, userState, botPort, chatID, message.Args)
+			return
+
+		case "relink":
+			handleRelinkCommand(ctx, userState, botPort, store, chatID, message.Args)
+			return
+
+		case "language":
+			handleLanguageCommand(ctx, userState, botPort, store, chatID, message.Args)
+			return
+
+		case "surveys":
+			handleSurveysCommand(ctx, userState, botPort, recordConfig, chatID)
+			return
+
+		case "report":
+			handleReportCommand(ctx, userState, botPort, recordConfig, chatID, store)
+			return
+
+		case "transcript":
+			if !config.IsTherapist(userState.UserID) {
+				_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgUnknownCommand), nil)
+				return
 			}
+			handleTranscriptCommand(ctx, userState, botPort, chatID, store, strings.Fields(message.Args))
 			return
 
 		default:
-			_, _ = botPort.SendMessage(ctx, chatID, "Неизвестная команда.", nil)
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgUnknownCommand), nil)
 			return
 		}
 	}
 
+	if relayReply(ctx, message, userState, botPort, store) {
+		return
+	}
+
 	mainState := userState.MainMenuFSM.Current()
 	recordState := userState.RecordFSM.Current()
 
 	if recordState == StateAnsweringQuestion {
+		userState.PendingAnswer = ""
 		sectionConf, question, err := resolveCurrentQuestion(recordConfig, userState)
 		if err != nil {
-			log.Printf("[handleMessage] %v", err)
-			_ = userState.RecordFSM.Event(ctx, EventForceExit, userState, botPort, recordConfig, chatID, userState.LastMessageID, "invalid state/config for text answer")
+			slog.ErrorContext(ctx, "handling message failed", "err", err)
+			recoverFromConfigDrift(ctx, userState, botPort, recordConfig, chatID)
 			return
 		}
 
-		strategy := questions.Get(question.Type)
-		if strategy == nil {
-			log.Printf("[handleMessage] Error: No strategy for question type '%s'", question.Type)
-			_ = userState.RecordFSM.Event(ctx, EventForceExit, userState, botPort, recordConfig, chatID, userState.LastMessageID, "missing question strategy")
-			return
+		accepted := true
+		if strategy := questions.Get(question.Type); strategy != nil && strategy.Capabilities().NeedsText {
+			text, accepted = screenFreeText(ctx, userState, botPort, recordConfig, chatID, question, text)
+			if accepted {
+				accepted = enforceAnswerLimits(ctx, userState, botPort, recordConfig, chatID, question, text)
+			}
 		}
-
-		answerCtx := buildAnswerContext(userState, sectionConf, question, chatID, userState.LastMessageID, "", userState.LastPrompt, botPort)
-		result, err := strategy.HandleAnswer(answerCtx, questions.AnswerInput{
-			Source:    questions.InputSourceText,
-			Text:      text,
-			MessageID: userState.LastMessageID,
-		})
-		if err != nil {
-			log.Printf("[handleMessage] Error processing answer for user %d: %v", userState.UserID, err)
-			_ = userState.RecordFSM.Event(ctx, EventForceExit, userState, botPort, recordConfig, chatID, userState.LastMessageID, "strategy failed while handling answer")
+		if !accepted {
+			if userState.PendingAnswer == "" {
+				askCurrentQuestion(ctx, userState, botPort, recordConfig, userState.LastMessageID)
+			}
+			deleteUserTextMessage(ctx, botPort, chatID, userMessageID, question.Type)
 			return
 		}
 
-		handleAnswerResult(ctx, result, userState, botPort, recordConfig, userState.LastMessageID)
+		submitTextAnswer(ctx, userState, botPort, recordConfig, chatID, sectionConf, question, text)
 		deleteUserTextMessage(ctx, botPort, chatID, userMessageID, question.Type)
 		return
 	}
 
+	if mainState == StateAddingNote && recordState == StateRecordIdle {
+		if _, isMenu := mainMenuButton(text); !isMenu {
+			addNote(ctx, userState, botPort, chatID, store, text)
+			return
+		}
+		// A main menu button drops the note and does what it says.
+		dropNote(ctx, userState)
+		mainState = userState.MainMenuFSM.Current()
+	}
+
 	if mainState == StateIdle && recordState == StateRecordIdle {
-		switch text {
+		button, _ := mainMenuButton(text)
+		switch button {
 		case ButtonMainMenuFillRecord:
-			log.Printf("[handleMessage] User %d initiated record creation", userState.UserID)
+			slog.InfoContext(ctx, "user starts a record", "user_id", userState.UserID)
 
-			startOrResumeRecordCreation(ctx, userState, botPort, recordConfig, chatID)
+			startOrResumeRecordCreation(ctx, userState, botPort, recordConfig, store, chatID)
 
 		case ButtonMainMenuSendSelf:
-			log.Printf("[handleMessage] User %d requested forward to self", userState.UserID)
-			handleForwardToSelf(ctx, userState, botPort, recordConfig, chatID)
+			slog.InfoContext(ctx, "user forwards the record to self", "user_id", userState.UserID)
+			handleForwardToSelf(ctx, userState, botPort, recordConfig, store, chatID)
 
 		case ButtonMainMenuSendTherapist:
-			log.Printf("[handleMessage] User %d requested forward to therapist", userState.UserID)
-			handleForwardAnsweredSections(ctx, userState, botPort, recordConfig, chatID)
+			slog.InfoContext(ctx, "user forwards the record to the therapist", "user_id", userState.UserID)
+			handleForwardAnsweredSections(ctx, userState, botPort, recordConfig, chatID, store)
+
+		case ButtonMainMenuDeliveries:
+			showDeliveries(ctx, userState, botPort, chatID, store)
+
+		case ButtonMainMenuExport:
+			handleExport(ctx, userState, botPort, recordConfig, chatID, store, "")
+
+		case ButtonMainMenuAddNote:
+			startNote(ctx, userState, botPort, chatID, store)
 
 		default:
 
@@ -173,29 +248,47 @@ func handleMessage(ctx context.Context, message *tgbotapi.Message, userState *st
 		return
 	}
 
-	_, _ = botPort.SendMessage(ctx, chatID, "Пожалуйста, используйте предложенные кнопки или завершите текущее действие.", nil)
+	sendTransient(ctx, botPort, recordConfig, chatID, tr(userState, config.MsgUseButtons))
 }
 
-func handleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig) {
-	chatID := query.Message.Chat.ID
-	messageID := query.Message.MessageID
+// submitTextAnswer feeds a free-text answer to the current question's strategy and moves the flow on.
+func submitTextAnswer(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, sectionConf config.SectionConfig, question config.QuestionConfig, text string) {
+	strategy := questions.Get(question.Type)
+	if strategy == nil {
+		slog.ErrorContext(ctx, "no strategy for question type", "type", question.Type)
+		_ = userState.RecordFSM.Event(ctx, EventForceExit, recordEvent{User: userState, ChatID: chatID, MessageID: userState.LastMessageID, Reason: reasonMissingStrategy})
+		return
+	}
+
+	answerCtx := buildAnswerContext(userState, sectionConf, question, chatID, userState.LastMessageID, "", userState.LastPrompt, botPort)
+	result, err := strategy.HandleAnswer(answerCtx, questions.AnswerInput{
+		Source:    questions.InputSourceText,
+		Text:      text,
+		MessageID: userState.LastMessageID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "processing text answer failed", "user_id", userState.UserID, "err", err)
+		_ = userState.RecordFSM.Event(ctx, EventForceExit, recordEvent{User: userState, ChatID: chatID, MessageID: userState.LastMessageID, Reason: reasonStrategyAnswerFailed})
+		return
+	}
+
+	handleAnswerResult(ctx, result, userState, botPort, recordConfig, userState.LastMessageID)
+}
+
+func handleCallbackQuery(ctx context.Context, query *botport.InboundEvent, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
+	chatID := query.ChatID
+	messageID := query.MessageID
 	data := query.Data
 
-	err := botPort.AnswerCallback(ctx, query.ID, "")
+	err := botPort.AnswerCallback(ctx, query.CallbackID, "")
 	if err != nil {
-		log.Printf("[handleCallbackQuery] Error answering callback %s for user %d: %v", query.ID, userState.UserID, err)
+		slog.ErrorContext(ctx, "answering callback failed", "callback_id", query.CallbackID, "user_id", userState.UserID, "err", err)
 
 	}
 
-	parts := strings.SplitN(data, ":", 2)
-	prefix := parts[0] + ":"
-	value := ""
-	if len(parts) > 1 {
-		value = parts[1]
-	}
+	prefix, value := splitCallbackData(data)
 
-	log.Printf("[handleCallbackQuery] Received callback: Prefix='%s', Value='%s', UserID=%d, State=%s/%s",
-		prefix, value, userState.UserID, userState.MainMenuFSM.Current(), userState.RecordFSM.Current())
+	slog.InfoContext(ctx, "callback received", "prefix", prefix, "value", value, "user_id", userState.UserID, "main_state", userState.MainMenuFSM.Current(), "record_state", userState.RecordFSM.Current())
 
 	recordState := userState.RecordFSM.Current()
 	mainState := userState.MainMenuFSM.Current()
@@ -203,118 +296,256 @@ func handleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery, use
 	switch prefix {
 	case CallbackAnswerPrefix:
 		if recordState == StateAnsweringQuestion {
-
-			answerParts := strings.SplitN(value, ":", 2)
-			if len(answerParts) != 2 {
-				log.Printf("[handleCallbackQuery] Error: Invalid answer callback data format '%s' for user %d", value, userState.UserID)
+			questionID, optionValue, ok := splitAnswerCallback(value)
+			if !ok {
+				slog.WarnContext(ctx, "invalid answer callback data", "value", value, "user_id", userState.UserID)
 				return
 			}
-			questionID := answerParts[0]
-			optionValue := answerParts[1]
 
-			currentQID := ""
-			currentSectionConf, okSec := recordConfig.Sections[userState.CurrentSection]
-			if okSec && userState.CurrentQuestion >= 0 && userState.CurrentQuestion < len(currentSectionConf.Questions) {
-				currentQID = currentSectionConf.Questions[userState.CurrentQuestion].ID
+			currentSectionConf, currentQuestion, err := resolveCurrentQuestion(recordConfig, userState)
+			if err != nil {
+				slog.ErrorContext(ctx, "handling callback failed", "err", err)
+				recoverFromConfigDrift(ctx, userState, botPort, recordConfig, chatID)
+				return
 			}
+			currentQID := currentQuestion.ID
 
 			if currentQID == questionID {
-				log.Printf("[handleCallbackQuery] Processing button answer for user %d (Q: %s, Value: %s)", userState.UserID, questionID, optionValue)
+				slog.InfoContext(ctx, "button answer", "user_id", userState.UserID, "question", questionID, "value", optionValue)
 
-				question := currentSectionConf.Questions[userState.CurrentQuestion]
+				question := currentQuestion
 				strategy := questions.Get(question.Type)
 				if strategy == nil {
-					log.Printf("[handleCallbackQuery] Error: No strategy for question type '%s'", question.Type)
-					_ = userState.RecordFSM.Event(ctx, EventForceExit, userState, botPort, recordConfig, chatID, messageID, "missing question strategy")
+					slog.ErrorContext(ctx, "no strategy for question type", "type", question.Type)
+					_ = userState.RecordFSM.Event(ctx, EventForceExit, recordEvent{User: userState, ChatID: chatID, MessageID: messageID, Reason: reasonMissingStrategy})
 					return
 				}
 
-				answerCtx := buildAnswerContext(userState, currentSectionConf, question, chatID, messageID, query.ID, userState.LastPrompt, botPort)
+				answerCtx := buildAnswerContext(userState, currentSectionConf, question, chatID, messageID, query.CallbackID, userState.LastPrompt, botPort)
 				result, err := strategy.HandleAnswer(answerCtx, questions.AnswerInput{
 					Source:       questions.InputSourceCallback,
 					CallbackData: optionValue,
 					MessageID:    messageID,
 				})
 				if err != nil {
-					log.Printf("[handleCallbackQuery] Error processing callback answer for user %d: %v", userState.UserID, err)
-					_ = userState.RecordFSM.Event(ctx, EventForceExit, userState, botPort, recordConfig, chatID, messageID, "strategy failed while handling callback")
+					slog.ErrorContext(ctx, "processing callback answer failed", "user_id", userState.UserID, "err", err)
+					_ = userState.RecordFSM.Event(ctx, EventForceExit, recordEvent{User: userState, ChatID: chatID, MessageID: messageID, Reason: reasonStrategyCallbackFailed})
 					return
 				}
 
 				handleAnswerResult(ctx, result, userState, botPort, recordConfig, messageID)
 				return
 			} else {
-				log.Printf("[handleCallbackQuery] Warning: Received answer for question '%s', but current question is '%s' for user %d. Ignoring.", questionID, currentQID, userState.UserID)
-				_ = botPort.AnswerCallback(ctx, query.ID, "⚠️ Ответ на предыдущий вопрос?")
+				slog.WarnContext(ctx, "answer for another question ignored", "question", questionID, "current_question", currentQID, "user_id", userState.UserID)
+				_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgStaleAnswer))
 				return
 			}
 
 		} else {
-			log.Printf("[handleCallbackQuery] Warning: Received answer callback from user %d but not in AnsweringQuestion state (%s)", userState.UserID, recordState)
+			slog.WarnContext(ctx, "answer callback outside answering_question", "user_id", userState.UserID, "state", recordState)
 			return
 		}
 
 	case CallbackSectionPrefix:
 		if recordState == StateSelectingSection {
 			sectionID := value
-			log.Printf("[handleCallbackQuery] User %d selected section '%s'", userState.UserID, sectionID)
-
-			userState.CurrentSection = sectionID
-			userState.CurrentQuestion = 0
-
-			err := userState.RecordFSM.Event(ctx, EventSelectSection, userState, botPort, recordConfig, chatID, messageID)
-			if err != nil {
-				log.Printf("[handleCallbackQuery] Error triggering EventSelectSection for user %d: %v", userState.UserID, err)
-
-				_ = userState.RecordFSM.Event(ctx, EventForceExit, userState, botPort, recordConfig, chatID, messageID, "failed to select section")
-			}
+			slog.InfoContext(ctx, "section selected", "user_id", userState.UserID, "section", sectionID)
+			selectSection(ctx, userState, botPort, recordConfig, chatID, messageID, sectionID)
 		} else {
-			log.Printf("[handleCallbackQuery] Warning: Received section selection callback from user %d but not in SelectingSection state (%s)", userState.UserID, recordState)
+			slog.WarnContext(ctx, "section callback outside selecting_section", "user_id", userState.UserID, "state", recordState)
 		}
 		return
 
+	case CallbackSectionNavPrefix:
+		if recordState != StateSelectingSection {
+			slog.WarnContext(ctx, "section page callback outside selecting_section", "user_id", userState.UserID, "state", recordState)
+			_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgActionUnavailable))
+			return
+		}
+		switch value {
+		case "next":
+			userState.SectionPage++
+		case "back":
+			userState.SectionPage--
+		default:
+			slog.WarnContext(ctx, "unknown section page action", "action", value, "user_id", userState.UserID)
+			return
+		}
+		slog.InfoContext(ctx, "section menu page", "user_id", userState.UserID, "page", userState.SectionPage)
+		showSectionSelectionMenu(ctx, userState, botPort, recordConfig, chatID, messageID, userState.CurrentRecord, nil)
+		return
+
 	case CallbackActionPrefix:
 		actionName := value
 		switch actionName {
 		case ActionCancelSection:
 			if recordState == StateAnsweringQuestion {
-				log.Printf("[handleCallbackQuery] User %d cancelled section input", userState.UserID)
-				err := userState.RecordFSM.Event(ctx, EventCancelSection, userState, botPort, recordConfig, chatID, messageID)
-				if err != nil {
-					log.Printf("[handleCallbackQuery] Error triggering EventCancelSection for user %d: %v", userState.UserID, err)
+				slog.InfoContext(ctx, "user asks to leave the section", "user_id", userState.UserID, "section", userState.CurrentSection)
+				sectionConf, ok := recordConfig.Sections[userState.CurrentSection]
+				if ok && sectionChangedSinceSnapshot(sectionConf, userState.CurrentRecord, userState.SectionSnapshot) {
+					showCancelSectionConfirmation(ctx, userState, botPort, chatID, messageID)
+					return
 				}
+				cancelSection(ctx, userState, botPort, recordConfig, chatID, messageID)
+			}
+		case ActionCancelKeep:
+			if recordState == StateAnsweringQuestion {
+				slog.InfoContext(ctx, "user left the section keeping answers", "user_id", userState.UserID)
+				cancelSection(ctx, userState, botPort, recordConfig, chatID, messageID)
+			}
+		case ActionCancelDiscard:
+			if recordState == StateAnsweringQuestion {
+				slog.InfoContext(ctx, "user left the section discarding answers", "user_id", userState.UserID)
+				if sectionConf, ok := recordConfig.Sections[userState.CurrentSection]; ok {
+					restoreSection(sectionConf, userState.CurrentRecord, userState.SectionSnapshot)
+				}
+				cancelSection(ctx, userState, botPort, recordConfig, chatID, messageID)
+			}
+		case ActionCancelResume:
+			if recordState == StateAnsweringQuestion {
+				askCurrentQuestion(ctx, userState, botPort, recordConfig, messageID)
+			}
+		case ActionReviewSection:
+			if recordState == StateAnsweringQuestion {
+				slog.InfoContext(ctx, "user reviews the section from the first question", "user_id", userState.UserID, "section", userState.CurrentSection)
+				userState.CurrentQuestion = 0
+				askCurrentQuestion(ctx, userState, botPort, recordConfig, messageID)
+			}
+		case ActionTruncateKeep:
+			if recordState == StateAnsweringQuestion {
+				slog.InfoContext(ctx, "user kept the truncated answer", "user_id", userState.UserID)
+				acceptTruncatedAnswer(ctx, userState, botPort, recordConfig, chatID)
+			}
+		case ActionPrefillAccept:
+			if recordState == StateAnsweringQuestion {
+				acceptPrefill(ctx, userState, botPort, recordConfig, chatID)
+			}
+		case ActionTruncateRetry:
+			if recordState == StateAnsweringQuestion {
+				userState.PendingAnswer = ""
+				askCurrentQuestion(ctx, userState, botPort, recordConfig, messageID)
+			}
+		case ActionJumpMenu:
+			if recordState == StateAnsweringQuestion {
+				showQuestionJumpMenu(ctx, userState, botPort, recordConfig, chatID, messageID)
+			}
+		case ActionEditAnswers:
+			if recordState == StateSelectingSection {
+				showEditAnswersMenu(ctx, userState, botPort, recordConfig, chatID, messageID)
+			}
+		case ActionEditBack:
+			if recordState == StateSelectingSection {
+				showSectionSelectionMenu(ctx, userState, botPort, recordConfig, chatID, messageID, userState.CurrentRecord, nil)
 			}
 		case ActionSaveRecord:
 			if recordState == StateSelectingSection {
-				log.Printf("[handleCallbackQuery] User %d requested save record", userState.UserID)
-				err := userState.RecordFSM.Event(ctx, EventSaveFullRecord, userState, botPort, recordConfig, chatID, messageID)
+				slog.InfoContext(ctx, "user saves the record", "user_id", userState.UserID)
+				err := userState.RecordFSM.Event(ctx, EventSaveFullRecord, recordEvent{User: userState, ChatID: chatID, MessageID: messageID})
+				if err != nil {
+					slog.ErrorContext(ctx, "save event failed", "user_id", userState.UserID, "err", err)
+				}
+			}
+		case ActionSaveAndSend:
+			if recordState == StateSelectingSection {
+				slog.InfoContext(ctx, "user saves and sends the record", "user_id", userState.UserID)
+				err := userState.RecordFSM.Event(ctx, EventSaveFullRecord, recordEvent{User: userState, ChatID: chatID, MessageID: messageID, Forward: true})
 				if err != nil {
-					log.Printf("[handleCallbackQuery] Error triggering EventSaveFullRecord for user %d: %v", userState.UserID, err)
+					slog.WarnContext(ctx, "save and send not completed", "user_id", userState.UserID, "err", err)
 				}
 			}
 		case ActionNewRecord:
-			log.Printf("[handleCallbackQuery] User %d requested new record", userState.UserID)
+			slog.InfoContext(ctx, "user starts a new record", "user_id", userState.UserID)
+			if draftHasAnswers(userState.CurrentRecord) {
+				showNewRecordConfirmation(ctx, userState, botPort, chatID, messageID)
+				return
+			}
+			startNewRecord(ctx, userState, botPort, recordConfig, store, recordState, chatID, messageID)
+		case ActionNewConfirm:
+			slog.InfoContext(ctx, "user overwrites the draft", "user_id", userState.UserID)
+			startNewRecord(ctx, userState, botPort, recordConfig, store, recordState, chatID, messageID)
+		case ActionNewKeep:
+			slog.InfoContext(ctx, "user keeps the draft", "user_id", userState.UserID)
 			if recordState == StateSelectingSection {
-				resetCurrentRecord(ctx, userState, botPort, recordConfig, chatID, messageID)
+				showSectionSelectionMenu(ctx, userState, botPort, recordConfig, chatID, messageID, userState.CurrentRecord, nil)
 			} else if recordState == StateRecordIdle {
-				userState.CurrentRecord = state.NewRecord()
-				startOrResumeRecordCreation(ctx, userState, botPort, recordConfig, chatID)
+				startOrResumeRecordCreation(ctx, userState, botPort, recordConfig, store, chatID)
 			}
 		case ActionExitMenu:
 			if recordState == StateSelectingSection {
-				log.Printf("[handleCallbackQuery] User %d requested exit to menu", userState.UserID)
-				err := userState.RecordFSM.Event(ctx, EventExitToMainMenu, userState, botPort, recordConfig, chatID, messageID)
+				slog.InfoContext(ctx, "user exits to the menu", "user_id", userState.UserID)
+				err := userState.RecordFSM.Event(ctx, EventExitToMainMenu, recordEvent{User: userState, ChatID: chatID, MessageID: messageID})
 				if err != nil {
-					log.Printf("[handleCallbackQuery] Error triggering EventExitToMainMenu for user %d: %v", userState.UserID, err)
+					slog.ErrorContext(ctx, "exit to main menu event failed", "user_id", userState.UserID, "err", err)
 				}
 			}
 		case ActionShareLast:
-			log.Printf("[handleCallbackQuery] User %d requested share last record", userState.UserID)
-			handleShareLastRecord(ctx, userState, botPort, recordConfig, chatID)
+			slog.InfoContext(ctx, "user shares the last record", "user_id", userState.UserID)
+			handleShareLastRecord(ctx, userState, botPort, recordConfig, chatID, store)
+		case ActionNoteCancel:
+			if mainState == StateAddingNote {
+				cancelNote(ctx, userState, botPort, chatID, messageID)
+			}
 
 		default:
-			log.Printf("[handleCallbackQuery] Unknown action '%s' from user %d", actionName, userState.UserID)
+			slog.WarnContext(ctx, "unknown action", "action", actionName, "user_id", userState.UserID)
+		}
+		return
+
+	case CallbackJumpPrefix:
+		if recordState != StateAnsweringQuestion {
+			slog.WarnContext(ctx, "jump callback outside answering_question", "user_id", userState.UserID, "state", recordState)
+			_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgActionUnavailable))
+			return
 		}
+		if !jumpToQuestion(ctx, userState, botPort, recordConfig, value, messageID) {
+			slog.WarnContext(ctx, "question not found in section", "question", value, "section", userState.CurrentSection, "user_id", userState.UserID)
+			_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgQuestionNotFound))
+		}
+		return
+
+	case CallbackEditPrefix:
+		if recordState != StateSelectingSection {
+			slog.WarnContext(ctx, "edit callback outside selecting_section", "user_id", userState.UserID, "state", recordState)
+			_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgActionUnavailable))
+			return
+		}
+		sectionID, questionID, _ := strings.Cut(value, ":")
+		if !editAnswer(ctx, userState, botPort, recordConfig, chatID, messageID, sectionID, questionID) {
+			_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgQuestionNotFound))
+		}
+		return
+
+	case CallbackAckPrefix:
+		acknowledgeDelivery(ctx, query, userState, botPort, store, value)
+		return
+
+	case CallbackRelinkPrefix:
+		handleRelinkCallback(ctx, query, userState, botPort, store, value)
+		return
+
+	case CallbackReplyPrefix:
+		handleReplyCallback(ctx, query, userState, botPort, store, value)
+		return
+
+	case CallbackRemindPrefix:
+		slog.InfoContext(ctx, "reminder button tapped", "user_id", userState.UserID, "section", value)
+		startFromReminder(ctx, userState, botPort, recordConfig, store, chatID, value)
+		return
+
+	case CallbackRecordPrefix:
+		handleRecordCallback(ctx, query, userState, botPort, recordConfig, store, value)
+		return
+
+	case CallbackQuizPrefix:
+		handleQuizNext(ctx, query, userState, botPort, recordConfig, value)
+		return
+
+	case CallbackLanguagePrefix:
+		handleLanguageCallback(ctx, userState, botPort, store, chatID, messageID, value)
+		return
+
+	case CallbackSurveyPrefix:
+		startSurvey(ctx, userState, botPort, recordConfig, store, chatID, value)
 		return
 
 	case CallbackListNavPrefix:
@@ -323,9 +554,9 @@ func handleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery, use
 			switch navAction {
 			case "next":
 				userState.ListOffset += 5
-				log.Printf("[handleCallbackQuery] User %d requested next list page (offset %d)", userState.UserID, userState.ListOffset)
+				slog.InfoContext(ctx, "next list page", "user_id", userState.UserID, "offset", userState.ListOffset)
 
-				viewListHandler(ctx, userState, botPort, chatID, messageID)
+				viewListHandler(ctx, userState, botPort, chatID, messageID, store)
 
 			case "back":
 				newOffset := userState.ListOffset - 5
@@ -333,39 +564,94 @@ func handleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery, use
 					newOffset = 0
 				}
 				userState.ListOffset = newOffset
-				log.Printf("[handleCallbackQuery] User %d requested previous list page (offset %d)", userState.UserID, userState.ListOffset)
+				slog.InfoContext(ctx, "previous list page", "user_id", userState.UserID, "offset", userState.ListOffset)
 
-				viewListHandler(ctx, userState, botPort, chatID, messageID)
+				viewListHandler(ctx, userState, botPort, chatID, messageID, store)
 
 			case "tomenu":
-				log.Printf("[handleCallbackQuery] User %d requested back to menu from list", userState.UserID)
+				slog.InfoContext(ctx, "back to menu from the list", "user_id", userState.UserID)
 
-				err := userState.MainMenuFSM.Event(ctx, EventBackToIdle, userState, botPort, recordConfig, chatID, messageID)
+				err := userState.MainMenuFSM.Event(ctx, EventBackToIdle)
 				if err != nil {
-					log.Printf("[handleCallbackQuery] Error triggering EventBackToIdle for user %d: %v", userState.UserID, err)
+					slog.ErrorContext(ctx, "back to idle event failed", "user_id", userState.UserID, "err", err)
 				}
 
-				emptyKeyboard := &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
-				_, errEdit := botPort.EditMessage(ctx, chatID, messageID, query.Message.Text, emptyKeyboard)
-				if errEdit != nil && !strings.Contains(errEdit.Error(), "message is not modified") {
-					log.Printf("[handleCallbackQuery] Error removing inline keyboard from list message %d: %v", messageID, errEdit)
-				}
-
-				sendMainMenu(ctx, botPort, userState)
+				batch := newEditBatch(botPort)
+				emptyKeyboard := botport.NewKeyboard()
+				_, _ = batch.EditMessage(ctx, chatID, messageID, query.Text, emptyKeyboard)
+				sendMainMenu(ctx, batch, userState, store)
+				batch.Flush(ctx)
 
 			default:
-				log.Printf("[handleCallbackQuery] Unknown list navigation action '%s' from user %d", navAction, userState.UserID)
+				slog.WarnContext(ctx, "unknown list navigation action", "action", navAction, "user_id", userState.UserID)
 			}
 		} else {
-			log.Printf("[handleCallbackQuery] Warning: Received list navigation callback from user %d but not in ViewingList state (%s)", userState.UserID, mainState)
+			slog.WarnContext(ctx, "list navigation outside viewing_list", "user_id", userState.UserID, "state", mainState)
 
-			_ = botPort.AnswerCallback(ctx, query.ID, "Действие недоступно.")
+			_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgActionUnavailable))
 		}
 		return
 
 	default:
-		log.Printf("[handleCallbackQuery] Unknown callback prefix '%s' from user %d", prefix, userState.UserID)
+		slog.WarnContext(ctx, "unknown callback prefix", "prefix", prefix, "user_id", userState.UserID)
+	}
+}
+
+// selectSection positions the user on the first unanswered question of the section and enters it.
+func selectSection(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int, sectionID string) {
+	sectionConf, ok := recordConfig.Sections[sectionID]
+	if !ok {
+		slog.WarnContext(ctx, "unknown section selected", "section", sectionID, "user_id", userState.UserID)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgSectionNotFound), nil)
+		return
+	}
+	if !sectionOpen(userState, sectionConf) {
+		slog.InfoContext(ctx, "section is closed at this time", "section", sectionID, "user_id", userState.UserID)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgSectionClosed, sectionConf.Title, windowText(userState, sectionConf.Available)), nil)
+		return
+	}
+	if len(sectionConf.Questions) > 0 && nextVisibleQuestion(sectionConf, userState.CurrentRecord, 0) < 0 {
+		slog.InfoContext(ctx, "every question of the section is hidden", "section", sectionID, "user_id", userState.UserID)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgSectionNoQuestions, sectionConf.Title), nil)
+		return
+	}
+	userState.CurrentSection = sectionID
+	userState.EditingAnswer = false
+	userState.CurrentQuestion = firstUnansweredQuestion(sectionConf, userState.CurrentRecord)
+	userState.SectionSnapshot = snapshotSection(sectionConf, userState.CurrentRecord)
+
+	err := userState.RecordFSM.Event(ctx, EventSelectSection, recordEvent{User: userState, ChatID: chatID, MessageID: messageID})
+	if err != nil {
+		slog.ErrorContext(ctx, "select section event failed", "user_id", userState.UserID, "err", err)
+
+		_ = userState.RecordFSM.Event(ctx, EventForceExit, recordEvent{User: userState, ChatID: chatID, MessageID: messageID, Reason: reasonSelectSectionFailed})
+	}
+}
+
+// openSectionDirectly creates or resumes the draft and jumps into sectionID, whatever the current record state.
+func openSectionDirectly(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, chatID int64, sectionID string) {
+	if _, ok := recordConfig.Sections[sectionID]; !ok {
+		slog.WarnContext(ctx, "unknown section requested", "section", sectionID, "user_id", userState.UserID)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgSectionNotFound), nil)
+		if userState.RecordFSM.Current() == StateRecordIdle {
+			sendMainMenu(ctx, botPort, userState, store)
+		}
+		return
+	}
+
+	switch userState.RecordFSM.Current() {
+	case StateRecordIdle:
+		startOrResumeRecordCreation(ctx, userState, botPort, recordConfig, store, chatID)
+		if userState.RecordFSM.Current() != StateSelectingSection {
+			return
+		}
+	case StateAnsweringQuestion:
+		slog.InfoContext(ctx, "user leaves the section for another", "user_id", userState.UserID, "section", userState.CurrentSection, "to_section", sectionID)
+		userState.SectionSnapshot = nil
+		userState.RecordFSM.SetState(StateSelectingSection)
 	}
+
+	selectSection(ctx, userState, botPort, recordConfig, chatID, userState.LastMessageID, sectionID)
 }
 
 func processAnswer(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, messageID int) {
@@ -374,44 +660,50 @@ func processAnswer(ctx context.Context, userState *state.UserState, botPort botp
 	qIndex := userState.CurrentQuestion
 	sectionConf, okSec := recordConfig.Sections[sectionID]
 	if !okSec || qIndex < 0 || qIndex >= len(sectionConf.Questions) {
-		log.Printf("[processAnswer] Error: Invalid state/config for user %d (section %s, qIdx %d)", userState.UserID, sectionID, qIndex)
-		_ = userState.RecordFSM.Event(ctx, EventForceExit, userState, botPort, recordConfig, userState.UserID, messageID, "invalid state/config in processAnswer")
+		slog.ErrorContext(ctx, "invalid state or config while processing the answer", "user_id", userState.UserID, "section", sectionID, "question_index", qIndex)
+		recoverFromConfigDrift(ctx, userState, botPort, recordConfig, userState.UserID)
 		return
 	}
-	nextQIndex := qIndex + 1
+	nextQIndex := nextVisibleQuestion(sectionConf, userState.CurrentRecord, qIndex+1)
+	if userState.EditingAnswer {
+		// Only the picked answer was re-asked; go back to the section menu.
+		nextQIndex = -1
+		userState.EditingAnswer = false
+	}
 	var nextEvent string
-	if nextQIndex < len(sectionConf.Questions) {
+	if nextQIndex >= 0 {
 
 		userState.CurrentQuestion = nextQIndex
 		nextEvent = EventAnswerQuestion
-		log.Printf("[processAnswer] Next question for user %d (Index: %d)", userState.UserID, nextQIndex)
+		slog.DebugContext(ctx, "next question", "user_id", userState.UserID, "question_index", nextQIndex)
 	} else {
 
 		userState.CurrentQuestion = 0
 		userState.CurrentSection = ""
 		nextEvent = EventSectionComplete
-		log.Printf("[processAnswer] Section complete for user %d", userState.UserID)
+		markSectionLate(sectionID, sectionConf, userState.CurrentRecord, clock())
+		slog.InfoContext(ctx, "section complete", "user_id", userState.UserID)
 	}
 
-	log.Printf("[processAnswer] Triggering FSM event '%s' for user %d", nextEvent, userState.UserID)
-	err := userState.RecordFSM.Event(ctx, nextEvent, userState, botPort, recordConfig, userState.UserID, messageID)
+	slog.DebugContext(ctx, "triggering FSM event", "event", nextEvent, "user_id", userState.UserID)
+	err := userState.RecordFSM.Event(ctx, nextEvent, recordEvent{User: userState, ChatID: userState.UserID, MessageID: messageID})
 	if err != nil {
 		if isNoTransitionError(err) {
 
-			log.Printf("[processAnswer] FSM self-transition refused (expected for %s). Manually asking next question for user %d.", nextEvent, userState.UserID)
+			slog.DebugContext(ctx, "self-transition refused, asking the next question directly", "event", nextEvent, "user_id", userState.UserID)
 
 			askCurrentQuestion(ctx, userState, botPort, recordConfig, messageID)
 		} else {
 
-			log.Printf("[processAnswer] REAL Error triggering event '%s' for user %d: %v", nextEvent, userState.UserID, err)
+			slog.ErrorContext(ctx, "FSM event failed", "event", nextEvent, "user_id", userState.UserID, "err", err)
 
-			_, _ = botPort.SendMessage(ctx, userState.UserID, "Произошла внутренняя ошибка FSM.", nil)
+			_, _ = botPort.SendMessage(ctx, userState.UserID, tr(userState, config.MsgFSMError), nil)
 
 		}
 	} else {
-		log.Printf("[processAnswer] Successfully triggered FSM event '%s' (transition occurred) for user %d", nextEvent, userState.UserID)
+		slog.DebugContext(ctx, "FSM event triggered", "event", nextEvent, "user_id", userState.UserID)
 	}
-	log.Printf("[processAnswer] END - User %d", userState.UserID)
+	slog.DebugContext(ctx, "answer processed", "user_id", userState.UserID)
 }
 
 func resolveCurrentQuestion(recordConfig *config.RecordConfig, userState *state.UserState) (config.SectionConfig, config.QuestionConfig, error) {
@@ -445,7 +737,7 @@ func buildAnswerContext(userState *state.UserState, sectionConf config.SectionCo
 
 func handleAnswerResult(ctx context.Context, result questions.AnswerResult, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, messageID int) {
 	if result.Feedback != "" {
-		_, _ = botPort.SendMessage(ctx, userState.UserID, result.Feedback, nil)
+		sendTransient(ctx, botPort, recordConfig, userState.UserID, result.Feedback, botport.ReplyTo(messageID))
 	}
 
 	if result.Repeat && !result.Advance {
@@ -454,38 +746,56 @@ func handleAnswerResult(ctx context.Context, result questions.AnswerResult, user
 	}
 
 	if result.Advance {
+		if revealQuizAnswer(ctx, userState, botPort, recordConfig, messageID) {
+			return
+		}
 		processAnswer(ctx, userState, botPort, recordConfig, messageID)
 	}
 }
 
-func startOrResumeRecordCreation(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
+func startOrResumeRecordCreation(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, chatID int64) {
+	rollOverDailyDraft(ctx, userState, botPort, recordConfig, chatID)
 
 	if userState.CurrentRecord == nil {
-		if saved := lastSavedRecord(userState); saved != nil {
-			log.Printf("[startOrResumeRecordCreation] User %d loading last saved record %s into draft.", userState.UserID, saved.ID)
-			copied := state.NewRecord()
+		if saved := lastSavedRecordOf(userState, store, recordConfig.Template); saved != nil && !recordConfig.DailyRecord {
+			slog.InfoContext(ctx, "loading the last saved record into the draft", "user_id", userState.UserID, "record_id", saved.ID)
+			copied := newDraft()
 			for k, v := range saved.Data {
 				copied.Data[k] = v
 			}
+			for k, findings := range saved.Flags {
+				for _, finding := range findings {
+					copied.Flag(k, finding)
+				}
+			}
 			copied.CreatedAt = saved.CreatedAt
 			userState.CurrentRecord = copied
 		} else {
-			log.Printf("[startOrResumeRecordCreation] User %d starting new record.", userState.UserID)
-			userState.CurrentRecord = state.NewRecord()
+			slog.InfoContext(ctx, "starting a new record", "user_id", userState.UserID)
+			userState.CurrentRecord = newDraft()
 		}
+		userState.CurrentRecord.Template = recordConfig.Template
 	} else {
-		log.Printf("[startOrResumeRecordCreation] User %d resuming existing draft.", userState.UserID)
-
+		slog.InfoContext(ctx, "resuming the draft", "user_id", userState.UserID)
+		if notice := orphanNotice(userState, pruneOrphanedAnswers(recordConfig, userState.CurrentRecord)); notice != "" {
+			_, _ = botPort.SendMessage(ctx, chatID, notice, nil)
+		}
+	}
+	if !userState.Preview {
+		drawSamples(recordConfig, userState.CurrentRecord)
 	}
+	userState.CurrentRecord.FirstRecord = savedRecordCount(userState, store) == 0
 
 	userState.CurrentSection = ""
 	userState.CurrentQuestion = 0
+	userState.SectionPage = 0
+	hideReplyKeyboard(ctx, botPort, userState, chatID)
 
-	err := userState.RecordFSM.Event(ctx, EventStartRecord, userState, botPort, recordConfig, chatID, 0)
+	err := userState.RecordFSM.Event(ctx, EventStartRecord, recordEvent{User: userState, ChatID: chatID})
 	if err != nil {
-		log.Printf("[startOrResumeRecordCreation] Error triggering EventStartRecord for user %d: %v", userState.UserID, err)
+		slog.ErrorContext(ctx, "start record event failed", "user_id", userState.UserID, "err", err)
 
-		_, _ = botPort.SendMessage(ctx, chatID, "Не удалось начать ввод записи. Попробуйте позже.", nil)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgStartRecordFailed), nil)
 
 		if userState.RecordFSM.Current() != StateRecordIdle {
 			userState.RecordFSM.SetState(StateRecordIdle)
@@ -494,49 +804,195 @@ func startOrResumeRecordCreation(ctx context.Context, userState *state.UserState
 
 }
 
-func hideKeyboard(ctx context.Context, botPort botport.BotPort, chatID int64, text string) {
+func handleShareLastRecord(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, store *state.Store) {
+	lastRecord := store.LastRecord(userState.UserID)
+	if lastRecord == nil {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNoRecordsToShare), nil)
+		return
+	}
+	shareRecord(ctx, userState, botPort, recordConfig, chatID, lastRecord)
+}
 
-	hideMsg := tgbotapi.NewMessage(chatID, text)
-	hideMsg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
-	if _, err := botPort.SendMessage(ctx, chatID, text, hideMsg.ReplyMarkup); err != nil {
-		log.Printf("[hideKeyboard] Error sending keyboard removal message for user %d: %v", chatID, err)
-	} else {
-		log.Printf("[hideKeyboard] Reply keyboard removal command sent to user %d.", chatID)
+// shareRecord sends the rendered record as plain text for the user to copy.
+func shareRecord(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, record *state.Record) {
+	payload := buildForwardPayload(recordConfig, record, userState)
+	shareText, err := renderForwardMessage(payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "rendering the shared record failed", "user_id", userState.UserID, "err", err)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgSharePrepareFailed), nil)
+		return
 	}
+	_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgShareCopy, shareText), nil)
 }
 
-func handleShareLastRecord(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
+func resetCurrentRecord(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int) {
+	draft := newDraft()
+	draft.FirstRecord = userState.CurrentRecord != nil && userState.CurrentRecord.FirstRecord
+	userState.CurrentRecord = draft
+	userState.CurrentSection = ""
+	userState.CurrentQuestion = 0
+	showSectionSelectionMenu(ctx, userState, botPort, recordConfig, chatID, messageID, userState.CurrentRecord, nil)
+}
 
-	var lastRecord *state.Record
-	for i := len(userState.Records) - 1; i >= 0; i-- {
-		if userState.Records[i].IsSaved {
-			lastRecord = userState.Records[i]
-			break
+func cancelSection(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int) {
+	userState.SectionSnapshot = nil
+	userState.EditingAnswer = false
+	err := userState.RecordFSM.Event(ctx, EventCancelSection, recordEvent{User: userState, ChatID: chatID, MessageID: messageID})
+	if err != nil {
+		slog.ErrorContext(ctx, "cancel section event failed", "user_id", userState.UserID, "err", err)
+	}
+}
+
+func showCancelSectionConfirmation(ctx context.Context, userState *state.UserState, botPort botport.BotPort, chatID int64, messageID int) {
+	keyboard := botport.NewKeyboard(
+		botport.NewRow(
+			botport.NewButton(tr(userState, config.MsgButtonCancelKeep), CallbackActionPrefix+ActionCancelKeep),
+			botport.NewButton(tr(userState, config.MsgButtonCancelDiscard), CallbackActionPrefix+ActionCancelDiscard),
+		),
+		botport.NewRow(
+			botport.NewButton(tr(userState, config.MsgButtonCancelResume), CallbackActionPrefix+ActionCancelResume),
+		),
+	)
+	text := tr(userState, config.MsgConfirmSectionChanges)
+	if _, err := botPort.EditMessage(ctx, chatID, messageID, text, keyboard); err != nil && !botport.IsCode(err, "message_not_modified") {
+		slog.ErrorContext(ctx, "showing the cancel confirmation failed", "chat_id", chatID, "err", err)
+	}
+}
+
+// snapshotSection copies the section answers present when the user entered it.
+func snapshotSection(sectionConf config.SectionConfig, record *state.Record) map[string]state.Answer {
+	snapshot := make(map[string]state.Answer)
+	if record == nil || record.Data == nil {
+		return snapshot
+	}
+	for _, q := range sectionConf.Questions {
+		if value, ok := record.Data[q.StoreKey]; ok {
+			snapshot[q.StoreKey] = value
 		}
 	}
+	return snapshot
+}
 
-	if lastRecord == nil {
-		_, _ = botPort.SendMessage(ctx, chatID, "Нет сохраненных записей для пересылки.", nil)
-		return
+func sectionChangedSinceSnapshot(sectionConf config.SectionConfig, record *state.Record, snapshot map[string]state.Answer) bool {
+	if record == nil || record.Data == nil {
+		return false
 	}
-	payload := buildForwardPayload(recordConfig, lastRecord, userState)
-	shareText, err := renderForwardMessage(payload)
-	if err != nil {
-		log.Printf("[handleShareLastRecord] render error for user %d: %v", userState.UserID, err)
-		_, _ = botPort.SendMessage(ctx, chatID, "Не удалось подготовить запись для отправки.", nil)
+	for _, q := range sectionConf.Questions {
+		if !record.Answer(q).Equal(snapshot[q.StoreKey]) {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreSection rolls the section answers back to the snapshot taken on entry.
+func restoreSection(sectionConf config.SectionConfig, record *state.Record, snapshot map[string]state.Answer) {
+	if record == nil || record.Data == nil {
 		return
 	}
-	_, _ = botPort.SendMessage(ctx, chatID, fmt.Sprintf("Чтобы поделиться, скопируйте текст ниже:\n\n---\n%s\n---", shareText), nil)
+	for _, q := range sectionConf.Questions {
+		delete(record.Transient, q.ID)
+		if value, ok := snapshot[q.StoreKey]; ok {
+			record.Data[q.StoreKey] = value
+		} else {
+			delete(record.Data, q.StoreKey)
+		}
+	}
 }
 
-func resetCurrentRecord(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int) {
-	userState.CurrentRecord = state.NewRecord()
-	userState.CurrentSection = ""
-	userState.CurrentQuestion = 0
-	showSectionSelectionMenu(ctx, userState, botPort, recordConfig, chatID, messageID, userState.CurrentRecord.Data, nil)
+// firstUnansweredQuestion returns the index of the first shown question without a stored answer,
+// restarting at the first shown question when the whole section is already answered so the user can
+// review it.
+func firstUnansweredQuestion(sectionConf config.SectionConfig, record *state.Record) int {
+	for idx, q := range sectionConf.Questions {
+		if record.Visible(q) && !record.HasAnswer(q) {
+			return idx
+		}
+	}
+	if first := nextVisibleQuestion(sectionConf, record, 0); first >= 0 {
+		return first
+	}
+	return 0
+}
+
+// nextVisibleQuestion returns the index of the first question from start on whose show_if holds for
+// the record, or -1 when the rest of the section is skipped.
+func nextVisibleQuestion(sectionConf config.SectionConfig, record *state.Record, start int) int {
+	for idx := start; idx < len(sectionConf.Questions); idx++ {
+		if record.Visible(sectionConf.Questions[idx]) {
+			return idx
+		}
+	}
+	return -1
+}
+
+func startNewRecord(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, recordState string, chatID int64, messageID int) {
+	if recordState == StateSelectingSection {
+		resetCurrentRecord(ctx, userState, botPort, recordConfig, chatID, messageID)
+	} else if recordState == StateRecordIdle {
+		userState.CurrentRecord = newDraft()
+		startOrResumeRecordCreation(ctx, userState, botPort, recordConfig, store, chatID)
+	}
 }
 
-func lastSavedRecord(userState *state.UserState) *state.Record {
+func showNewRecordConfirmation(ctx context.Context, userState *state.UserState, botPort botport.BotPort, chatID int64, messageID int) {
+	keyboard := botport.NewKeyboard(
+		botport.NewRow(
+			botport.NewButton(tr(userState, config.MsgButtonOverwriteDraft), CallbackActionPrefix+ActionNewConfirm),
+			botport.NewButton(tr(userState, config.MsgButtonKeepDraft), CallbackActionPrefix+ActionNewKeep),
+		),
+	)
+	text := tr(userState, config.MsgConfirmOverwriteDraft)
+	var err error
+	if messageID != 0 {
+		_, err = botPort.EditMessage(ctx, chatID, messageID, text, keyboard)
+	} else {
+		_, err = botPort.SendMessage(ctx, chatID, text, keyboard)
+	}
+	if err != nil && !botport.IsCode(err, "message_not_modified") {
+		slog.ErrorContext(ctx, "asking to confirm the new record failed", "chat_id", chatID, "err", err)
+	}
+}
+
+func draftHasAnswers(record *state.Record) bool {
+	if record == nil {
+		return false
+	}
+	for _, value := range record.Data {
+		if !value.IsEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+// savedRecordMetas lists the saved records of the user, newest first. The store also knows the records
+// kept only in the backend; without one, only those in memory are seen.
+func savedRecordMetas(userState *state.UserState, store *state.Store) []state.RecordMeta {
+	if store != nil {
+		return store.ListRecordMetas(userState.UserID, state.RecordFilter{}, state.Page{})
+	}
+	var metas []state.RecordMeta
+	for i := len(userState.Records) - 1; i >= 0; i-- {
+		if r := userState.Records[i]; r != nil && r.IsSaved {
+			metas = append(metas, r.Meta())
+		}
+	}
+	return metas
+}
+
+// savedRecordCount returns how many saved records the user has, as savedRecordMetas sees them.
+func savedRecordCount(userState *state.UserState, store *state.Store) int {
+	if store != nil {
+		return store.CountRecords(userState.UserID, state.RecordFilter{})
+	}
+	return len(savedRecordMetas(userState, nil))
+}
+
+func lastSavedRecord(userState *state.UserState, store *state.Store) *state.Record {
+	if store != nil {
+		return store.LastRecord(userState.UserID)
+	}
 	for i := len(userState.Records) - 1; i >= 0; i-- {
 		r := userState.Records[i]
 		if r != nil && r.IsSaved {
@@ -545,3 +1001,28 @@ func lastSavedRecord(userState *state.UserState) *state.Record {
 	}
 	return nil
 }
+
+// lastSavedRecordOf is lastSavedRecord limited to records of one survey template.
+func lastSavedRecordOf(userState *state.UserState, store *state.Store, template string) *state.Record {
+	if store == nil {
+		for i := len(userState.Records) - 1; i >= 0; i-- {
+			r := userState.Records[i]
+			if r != nil && r.IsSaved && r.Template == template {
+				return r
+			}
+		}
+		return nil
+	}
+	for _, meta := range savedRecordMetas(userState, store) {
+		if meta.Template != template {
+			continue
+		}
+		record, err := store.LoadRecord(userState.UserID, meta.ID)
+		if err != nil {
+			slog.Warn("skipping record", "err", err)
+			continue
+		}
+		return record
+	}
+	return nil
+}
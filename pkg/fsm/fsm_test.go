@@ -2,11 +2,13 @@ package fsm
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
 )
 
@@ -75,3 +77,393 @@ func TestAskCurrentQuestionHandlesMessageNotModified(t *testing.T) {
 		t.Fatalf("expected LastPrompt message id 10, got %+v", userState.LastPrompt)
 	}
 }
+
+func TestSectionButtonTextReflectsProgress(t *testing.T) {
+	section := config.SectionConfig{
+		Title: "Sec",
+		Questions: []config.QuestionConfig{
+			{ID: "q1", StoreKey: "k1"},
+			{ID: "q2", StoreKey: "k2"},
+		},
+	}
+	cases := []struct {
+		name string
+		data map[string]string
+		want string
+	}{
+		{name: "empty", data: map[string]string{}, want: "Sec 0/2"},
+		{name: "partial", data: map[string]string{"k1": "a"}, want: "Sec 🟡 1/2"},
+		{name: "blank answers ignored", data: map[string]string{"k1": "a", "k2": ""}, want: "Sec 🟡 1/2"},
+		{name: "complete", data: map[string]string{"k1": "a", "k2": "b"}, want: "Sec ✅ 2/2"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			answered, total := sectionProgress(section, &state.Record{Data: stringAnswers(tc.data)})
+			if got := sectionButtonText(section.Title, answered, total); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFirstUnansweredQuestion(t *testing.T) {
+	section := config.SectionConfig{
+		Questions: []config.QuestionConfig{
+			{ID: "q1", StoreKey: "k1"},
+			{ID: "q2", StoreKey: "k2"},
+			{ID: "q3", StoreKey: "k3"},
+		},
+	}
+	cases := []struct {
+		name string
+		data map[string]string
+		want int
+	}{
+		{name: "fresh", data: map[string]string{}, want: 0},
+		{name: "resume", data: map[string]string{"k1": "a"}, want: 1},
+		{name: "gap", data: map[string]string{"k1": "a", "k3": "c"}, want: 1},
+		{name: "complete restarts", data: map[string]string{"k1": "a", "k2": "b", "k3": "c"}, want: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			record := &state.Record{Data: stringAnswers(tc.data)}
+			if got := firstUnansweredQuestion(section, record); got != tc.want {
+				t.Fatalf("expected %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestJumpCallbackMovesToQuestion(t *testing.T) {
+	questions.RegisterBuiltins()
+	recordConfig := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {
+				Title: "Section",
+				Questions: []config.QuestionConfig{
+					{ID: "q1", Prompt: "First?", Type: "text", StoreKey: "k1"},
+					{ID: "q2", Prompt: "Second?", Type: "text", StoreKey: "k2"},
+				},
+			},
+		},
+	}
+	adapter := &fakeadapter.FakeAdapter{NextMessageID: 20}
+	handler := newTestHandler(adapter, recordConfig)
+	userState := &state.UserState{
+		UserID:         3,
+		CurrentRecord:  state.NewRecord(),
+		CurrentSection: "sec",
+		LastMessageID:  7,
+		MainMenuFSM:    handler.NewMainMenuFSM(),
+		RecordFSM:      handler.NewRecordFSM(),
+	}
+	userState.RecordFSM.SetState(StateAnsweringQuestion)
+
+	handleCallbackQuery(context.Background(), callbackQuery(3, 7, CallbackJumpPrefix+"q2"), userState, adapter, recordConfig, nil)
+
+	if userState.CurrentQuestion != 1 {
+		t.Fatalf("expected CurrentQuestion=1, got %d", userState.CurrentQuestion)
+	}
+	call := adapter.LastCall("edit_message")
+	if call == nil || call.Text != "Second?" || call.MessageID != 7 {
+		t.Fatalf("expected second prompt edited into message 7, got %+v", call)
+	}
+}
+
+func TestAnswerFeedbackRepliesToPrompt(t *testing.T) {
+	questions.RegisterBuiltins()
+	recordConfig := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {Title: "Section", Questions: []config.QuestionConfig{
+				{ID: "q1", Prompt: "Pick?", Type: "buttons", StoreKey: "k1", Options: []config.ButtonOption{{Text: "Yes", Value: "yes"}}},
+			}},
+		},
+	}
+	adapter := &fakeadapter.FakeAdapter{NextMessageID: 20}
+	handler := newTestHandler(adapter, recordConfig)
+	userState := &state.UserState{
+		UserID:         4,
+		CurrentRecord:  state.NewRecord(),
+		CurrentSection: "sec",
+		LastMessageID:  9,
+		MainMenuFSM:    handler.NewMainMenuFSM(),
+		RecordFSM:      handler.NewRecordFSM(),
+	}
+	userState.RecordFSM.SetState(StateAnsweringQuestion)
+
+	handleMessage(context.Background(), textMessage(4, "maybe"), userState, adapter, recordConfig, nil)
+
+	for _, call := range adapter.Calls {
+		if call.Op == "send_message" && strings.Contains(call.Text, "выберите ответ") {
+			if call.Options.ReplyTo != 9 {
+				t.Fatalf("feedback must reply to prompt 9, got %+v", call.Options)
+			}
+			return
+		}
+	}
+	t.Fatalf("no feedback sent, calls: %+v", adapter.Calls)
+}
+
+func callbackQuery(chatID int64, messageID int, data string) *botport.InboundEvent {
+	return &botport.InboundEvent{
+		Kind:       botport.EventCallback,
+		From:       botport.Sender{ID: chatID},
+		ChatID:     chatID,
+		MessageID:  messageID,
+		CallbackID: "cb",
+		Data:       data,
+	}
+}
+
+func TestCancelSectionDiscardRestoresSnapshot(t *testing.T) {
+	questions.RegisterBuiltins()
+	recordConfig := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {
+				Title: "Section",
+				Questions: []config.QuestionConfig{
+					{ID: "q1", Prompt: "First?", Type: "text", StoreKey: "k1"},
+					{ID: "q2", Prompt: "Second?", Type: "text", StoreKey: "k2"},
+				},
+			},
+		},
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, recordConfig)
+	record := state.NewRecord()
+	record.Data["k1"] = state.StringAnswer("old")
+	userState := &state.UserState{
+		UserID:        4,
+		CurrentRecord: record,
+		MainMenuFSM:   handler.NewMainMenuFSM(),
+		RecordFSM:     handler.NewRecordFSM(),
+	}
+	userState.RecordFSM.SetState(StateSelectingSection)
+	ctx := context.Background()
+
+	handleCallbackQuery(ctx, callbackQuery(4, 1, CallbackSectionPrefix+"sec"), userState, adapter, recordConfig, nil)
+	record.Data["k1"] = state.StringAnswer("new")
+	record.Data["k2"] = state.StringAnswer("added")
+
+	handleCallbackQuery(ctx, callbackQuery(4, 1, CallbackActionPrefix+ActionCancelSection), userState, adapter, recordConfig, nil)
+	if userState.RecordFSM.Current() != StateAnsweringQuestion {
+		t.Fatalf("expected confirmation before leaving section, state=%s", userState.RecordFSM.Current())
+	}
+
+	handleCallbackQuery(ctx, callbackQuery(4, 1, CallbackActionPrefix+ActionCancelDiscard), userState, adapter, recordConfig, nil)
+	if userState.RecordFSM.Current() != StateSelectingSection {
+		t.Fatalf("expected selecting_section after discard, got %s", userState.RecordFSM.Current())
+	}
+	if record.Data["k1"].String() != "old" {
+		t.Fatalf("expected k1 restored to 'old', got %q", record.Data["k1"].String())
+	}
+	if _, ok := record.Data["k2"]; ok {
+		t.Fatalf("expected k2 removed after discard")
+	}
+}
+
+func TestNewRecordAsksBeforeOverwritingDraft(t *testing.T) {
+	recordConfig := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {Title: "Section", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "P", Type: "text", StoreKey: "k1"}}},
+		},
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, recordConfig)
+	draft := state.NewRecord()
+	draft.Data["k1"] = state.StringAnswer("keep me")
+	userState := &state.UserState{
+		UserID:        5,
+		CurrentRecord: draft,
+		MainMenuFSM:   handler.NewMainMenuFSM(),
+		RecordFSM:     handler.NewRecordFSM(),
+	}
+	userState.RecordFSM.SetState(StateSelectingSection)
+	ctx := context.Background()
+
+	handleCallbackQuery(ctx, callbackQuery(5, 2, CallbackActionPrefix+ActionNewRecord), userState, adapter, recordConfig, nil)
+	if userState.CurrentRecord != draft {
+		t.Fatalf("expected draft to survive until confirmation")
+	}
+
+	handleCallbackQuery(ctx, callbackQuery(5, 2, CallbackActionPrefix+ActionNewConfirm), userState, adapter, recordConfig, nil)
+	if userState.CurrentRecord == draft || len(userState.CurrentRecord.Data) != 0 {
+		t.Fatalf("expected fresh draft after confirmation, got %+v", userState.CurrentRecord)
+	}
+}
+
+func TestStartDeepLinkOpensSection(t *testing.T) {
+	questions.RegisterBuiltins()
+	recordConfig := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"a": {Title: "A", Questions: []config.QuestionConfig{{ID: "qa", Prompt: "A?", Type: "text", StoreKey: "ka"}}},
+			"b": {Title: "B", Questions: []config.QuestionConfig{{ID: "qb", Prompt: "B?", Type: "text", StoreKey: "kb"}}},
+		},
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, recordConfig)
+	userState := &state.UserState{
+		UserID:      6,
+		MainMenuFSM: handler.NewMainMenuFSM(),
+		RecordFSM:   handler.NewRecordFSM(),
+	}
+
+	handleMessage(context.Background(), commandMessage(6, "/start section_b"), userState, adapter, recordConfig, nil)
+
+	if userState.RecordFSM.Current() != StateAnsweringQuestion || userState.CurrentSection != "b" {
+		t.Fatalf("expected to answer section b, got state=%s section=%q", userState.RecordFSM.Current(), userState.CurrentSection)
+	}
+	if userState.CurrentRecord == nil {
+		t.Fatalf("expected draft to be created")
+	}
+}
+
+func commandMessage(chatID int64, text string) *botport.InboundEvent {
+	command, args, _ := strings.Cut(text, " ")
+	message := textMessage(chatID, text)
+	message.MessageID = 1
+	message.Command = strings.TrimPrefix(command, "/")
+	message.Args = strings.TrimSpace(args)
+	return message
+}
+
+// newTestHandler returns a handler sending to adapter whose config is always recordConfig.
+func newTestHandler(adapter *fakeadapter.FakeAdapter, recordConfig *config.RecordConfig) *Handler {
+	return NewHandler(adapter, func() *config.RecordConfig { return recordConfig })
+}
+
+// newTestStore returns a store for tests that never fire record FSM events.
+func newTestStore() *state.Store {
+	return newTestHandler(&fakeadapter.FakeAdapter{}, &config.RecordConfig{}).Store()
+}
+
+func textMessage(chatID int64, text string) *botport.InboundEvent {
+	return &botport.InboundEvent{
+		Kind:      botport.EventMessage,
+		From:      botport.Sender{ID: chatID},
+		ChatID:    chatID,
+		MessageID: 2,
+		Text:      text,
+	}
+}
+
+func TestSaveDropsTransientState(t *testing.T) {
+	recordConfig := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {Title: "Section", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "P", Type: "text_rating", StoreKey: "k1"}}},
+		},
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, recordConfig)
+	draft := state.NewRecord()
+	draft.Data["k1"] = state.StringAnswer("- done\n  Рейтинг: 5")
+	draft.Transient = map[string]*state.QuestionScratch{"q1": {Step: "rating", Values: map[string]string{"text": "half-typed"}}}
+	userState := &state.UserState{
+		UserID:        7,
+		CurrentRecord: draft,
+		MainMenuFSM:   handler.NewMainMenuFSM(),
+		RecordFSM:     handler.NewRecordFSM(),
+	}
+	userState.RecordFSM.SetState(StateSelectingSection)
+
+	handleCallbackQuery(context.Background(), callbackQuery(7, 3, CallbackActionPrefix+ActionSaveRecord), userState, adapter, recordConfig, nil)
+
+	if len(userState.Records) != 1 {
+		t.Fatalf("expected saved record, got %d", len(userState.Records))
+	}
+	saved := userState.Records[0]
+	if saved.Transient != nil {
+		t.Fatalf("expected transient state dropped on save, got %+v", saved.Transient)
+	}
+	if strings.Contains(formatRecordForDisplay(saved), "half-typed") {
+		t.Fatalf("transient values must not be displayed")
+	}
+}
+
+func stringAnswers(data map[string]string) map[string]state.Answer {
+	answers := make(map[string]state.Answer, len(data))
+	for key, value := range data {
+		answers[key] = state.StringAnswer(value)
+	}
+	return answers
+}
+
+func TestSystemMessagesFollowUserLanguage(t *testing.T) {
+	if err := config.SetMessages(map[config.MessageKey]map[string]string{
+		config.MsgUnknownCommand: {"en": "Unknown command."},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = config.SetMessages(nil) }()
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{lang: "en", want: "Unknown command."},
+		{lang: "ru", want: "Неизвестная команда."},
+		{lang: "", want: "Неизвестная команда."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, &config.RecordConfig{})
+			userState := &state.UserState{UserID: 3, Profile: state.Profile{LanguageCode: tt.lang}, MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+
+			handleMessage(context.Background(), commandMessage(3, "/bogus"), userState, adapter, &config.RecordConfig{}, nil)
+
+			if call := adapter.LastCall("send_message"); call == nil || call.Text != tt.want {
+				t.Fatalf("reply = %+v, want %q", call, tt.want)
+			}
+		})
+	}
+}
+
+func TestSectionMenuPages(t *testing.T) {
+	rc := &config.RecordConfig{SectionPageSize: 2, Sections: map[string]config.SectionConfig{}}
+	for _, id := range []string{"s1", "s2", "s3", "s4", "s5"} {
+		rc.Sections[id] = config.SectionConfig{Title: id, Questions: []config.QuestionConfig{{ID: id + "q", Prompt: "P", Type: "text", StoreKey: id}}}
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	userState := &state.UserState{UserID: 4, CurrentRecord: state.NewRecord(), MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+	userState.RecordFSM.SetState(StateSelectingSection)
+	showSectionSelectionMenu(context.Background(), userState, adapter, rc, 4, 0, userState.CurrentRecord, nil)
+
+	steps := []struct {
+		name         string
+		data         string // Empty checks the menu as first sent
+		wantSections []string
+		wantPage     int
+		wantBack     bool
+		wantNext     bool
+	}{
+		{name: "first page", wantSections: []string{"s1", "s2"}, wantPage: 1, wantNext: true},
+		{name: "next", data: "next", wantSections: []string{"s3", "s4"}, wantPage: 2, wantBack: true, wantNext: true},
+		{name: "last", data: "next", wantSections: []string{"s5"}, wantPage: 3, wantBack: true},
+		{name: "past the last", data: "next", wantSections: []string{"s5"}, wantPage: 3, wantBack: true},
+		{name: "back", data: "back", wantSections: []string{"s3", "s4"}, wantPage: 2, wantBack: true, wantNext: true},
+	}
+	for _, step := range steps {
+		call := adapter.LastCall("send_message")
+		if step.data != "" {
+			handleCallbackQuery(context.Background(), callbackQuery(4, 1, CallbackSectionNavPrefix+step.data), userState, adapter, rc, nil)
+			call = adapter.LastCall("edit_message")
+		}
+		var sections []string
+		for _, data := range call.Keyboard.Callbacks() {
+			if id, ok := strings.CutPrefix(data, CallbackSectionPrefix); ok {
+				sections = append(sections, id)
+			}
+		}
+		if strings.Join(sections, ",") != strings.Join(step.wantSections, ",") {
+			t.Fatalf("%s: sections %v, want %v", step.name, sections, step.wantSections)
+		}
+		if want := config.Message("ru", config.MsgSectionMenuPage, step.wantPage, 3); !strings.Contains(call.Text, want) {
+			t.Fatalf("%s: prompt %q, want %q", step.name, call.Text, want)
+		}
+		if call.Keyboard.HasCallback(CallbackSectionNavPrefix+"back") != step.wantBack || call.Keyboard.HasCallback(CallbackSectionNavPrefix+"next") != step.wantNext {
+			t.Fatalf("%s: navigation %s, want back %t, next %t", step.name, call.Keyboard, step.wantBack, step.wantNext)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package fsm
+
+import (
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+
+	"github.com/looplab/fsm"
+)
+
+// Handler holds the long-lived dependencies of update handling: the bot port, the user store and the
+// config source. The record FSMs it creates call back into it, so FSM events only carry per-event data.
+type Handler struct {
+	bot    botport.BotPort
+	store  *state.Store
+	config func() *config.RecordConfig
+}
+
+// NewHandler returns a handler sending through botPort. recordConfig is asked on every update, so a
+// reloaded config takes effect without rebuilding the handler.
+func NewHandler(botPort botport.BotPort, recordConfig func() *config.RecordConfig) *Handler {
+	h := &Handler{bot: botPort, config: recordConfig}
+	h.store = state.NewStore(h)
+	return h
+}
+
+// Store returns the store of the handler's users.
+func (h *Handler) Store() *state.Store {
+	return h.store
+}
+
+// NewMainMenuFSM implements state.FSMCreator.
+func (h *Handler) NewMainMenuFSM() *fsm.FSM {
+	return NewMainMenuFSM(StateIdle)
+}
+
+// NewRecordFSM implements state.FSMCreator.
+func (h *Handler) NewRecordFSM() *fsm.FSM {
+	return h.newRecordFSM(StateRecordIdle)
+}
+
+// recordConfig returns the config of userState's draft.
+func (h *Handler) recordConfig(userState *state.UserState) *config.RecordConfig {
+	return recordConfigFor(h.config(), userState.CurrentRecord)
+}
+
+// recordEvent is the single argument of every record FSM event.
+type recordEvent struct {
+	User      *state.UserState
+	ChatID    int64
+	MessageID int    // Message to edit in place; 0 sends a new one
+	Reason    string // Why the record was force-exited, for EventForceExit
+	Forward   bool   // Deliver the record to TARGET_USER_ID as part of EventSaveFullRecord
+}
+
+// recordEventOf returns the recordEvent carried by e.
+func recordEventOf(e *fsm.Event) (recordEvent, bool) {
+	if len(e.Args) == 0 {
+		return recordEvent{}, false
+	}
+	ev, ok := e.Args[0].(recordEvent)
+	return ev, ok && ev.User != nil
+}
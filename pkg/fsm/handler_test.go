@@ -0,0 +1,50 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+func TestHandlerReadsConfigPerUpdate(t *testing.T) {
+	section := func(id string) *config.RecordConfig {
+		return &config.RecordConfig{Sections: map[string]config.SectionConfig{
+			id: {Title: id, Questions: []config.QuestionConfig{{ID: "q1", Prompt: "P", Type: "text", StoreKey: "k"}}},
+		}}
+	}
+	current := section("before")
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := NewHandler(adapter, func() *config.RecordConfig { return current })
+
+	steps := []struct {
+		name        string
+		update      botport.InboundEvent
+		config      *config.RecordConfig
+		wantSection string
+	}{
+		{name: "start", update: *textMessage(4, config.Message("ru", ButtonMainMenuFillRecord)), config: current, wantSection: "before"},
+		{name: "after reload", update: *callbackQuery(4, 1, CallbackActionPrefix+ActionExitMenu), config: section("after")},
+		{name: "restart", update: *textMessage(4, config.Message("ru", ButtonMainMenuFillRecord)), wantSection: "after"},
+	}
+	for _, step := range steps {
+		if step.config != nil {
+			current = step.config
+		}
+		adapter.Calls = nil
+		handler.HandleUpdate(context.Background(), step.update)
+
+		if step.wantSection == "" {
+			continue
+		}
+		menu := adapter.LastCall("send_message")
+		if menu == nil || !menu.Keyboard.HasCallback(CallbackSectionPrefix+step.wantSection) {
+			t.Fatalf("%s: section menu %+v, want section %q", step.name, menu, step.wantSection)
+		}
+	}
+	if handler.Store().GetOrCreateUserState(4, "") == nil {
+		t.Fatalf("the handler must keep its users in its store")
+	}
+}
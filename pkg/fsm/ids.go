@@ -0,0 +1,11 @@
+package fsm
+
+import "github.com/dkalashnik/telegram-survey-bot/pkg/idgen"
+
+// idGenerator makes the IDs of saved records and deliveries.
+var idGenerator idgen.Generator = idgen.Short{}
+
+// SetIDGenerator picks the format of new record and delivery IDs. Call it before handling updates.
+func SetIDGenerator(g idgen.Generator) {
+	idGenerator = g
+}
@@ -0,0 +1,58 @@
+package fsm
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/idgen"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestRecordIDsDoNotLeakUserID(t *testing.T) {
+	defer SetIDGenerator(idgen.Short{})
+
+	const userID = 987654321
+	for _, format := range []string{idgen.FormatShort, idgen.FormatULID, idgen.FormatUUID} {
+		t.Run(format, func(t *testing.T) {
+			g, err := idgen.New(format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			SetIDGenerator(g)
+
+			store := newTestStore()
+			userState := store.GetOrCreateUserState(userID, "patient")
+			record := state.NewRecord()
+			record.IsSaved = true
+			record.CreatedAt = time.Now()
+			record.ID = idGenerator.NewID()
+			userState.Records = append(userState.Records, record)
+			if strings.Contains(record.ID, strconv.Itoa(userID)) {
+				t.Fatalf("record ID %q contains the user ID", record.ID)
+			}
+
+			adapter := &fakeadapter.FakeAdapter{}
+			viewListHandler(context.Background(), userState, adapter, userID, 0, store)
+			text := adapter.LastCall("send_message").Text
+			if !strings.Contains(text, "📌 ID: "+idgen.ShortCode(record.ID)+" ") {
+				t.Fatalf("list must show the short code %s, got %q", idgen.ShortCode(record.ID), text)
+			}
+
+			delivery, err := sendDelivery(context.Background(), adapter, &config.RecordConfig{}, userState, record, 500, store, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if delivery.ID == record.ID || strings.Contains(delivery.ID, strconv.Itoa(userID)) {
+				t.Fatalf("unexpected delivery ID %q", delivery.ID)
+			}
+			if !adapter.LastCall("send_message").Keyboard.HasCallback(CallbackAckPrefix + delivery.ID) {
+				t.Fatalf("ack button must carry the delivery ID %s", delivery.ID)
+			}
+		})
+	}
+}
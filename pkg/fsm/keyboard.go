@@ -0,0 +1,31 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// replyKeyboardHelperText is the body of the message that carries the reply keyboard removal.
+const replyKeyboardHelperText = "⌨️"
+
+// hideReplyKeyboard takes the main menu's reply keyboard off the screen while the user is in record
+// mode, where its buttons do nothing. Telegram removes a reply keyboard only with a new message and
+// inline prompts cannot carry the removal, so a silent helper message does it and is deleted right
+// away; the keyboard stays removed. sendMainMenu brings the keyboard back.
+func hideReplyKeyboard(ctx context.Context, botPort botport.BotPort, userState *state.UserState, chatID int64) {
+	if !userState.ReplyKeyboard {
+		return
+	}
+	msg, err := botPort.SendMessage(ctx, chatID, replyKeyboardHelperText, botport.RemoveKeyboard{}, botport.Silent())
+	if err != nil {
+		slog.ErrorContext(ctx, "removing the reply keyboard failed", "user_id", userState.UserID, "err", err)
+		return
+	}
+	userState.ReplyKeyboard = false
+	if err := botPort.DeleteMessage(ctx, chatID, msg.MessageID); err != nil {
+		slog.ErrorContext(ctx, "deleting the helper message failed", "message_id", msg.MessageID, "user_id", userState.UserID, "err", err)
+	}
+}
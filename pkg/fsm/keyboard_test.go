@@ -0,0 +1,60 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestRecordModeRemovesReplyKeyboardWithoutClutter(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+
+	tests := []struct {
+		name       string
+		menuShown  bool
+		wantHelper bool
+	}{
+		{name: "menu keyboard on screen", menuShown: true, wantHelper: true},
+		{name: "no keyboard to remove", menuShown: false, wantHelper: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := &state.UserState{UserID: 15, MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+			if tt.menuShown {
+				sendMainMenu(context.Background(), adapter, userState, nil)
+			}
+			adapter.Calls = nil
+
+			startOrResumeRecordCreation(context.Background(), userState, adapter, rc, nil, 15)
+
+			var helper *fakeadapter.Call
+			for i, call := range adapter.Calls {
+				if call.Keyboard.Kind == fakeadapter.KeyboardRemove {
+					helper = &adapter.Calls[i]
+				}
+			}
+			if (helper != nil) != tt.wantHelper {
+				t.Fatalf("helper sent = %t, want %t, calls: %+v", helper != nil, tt.wantHelper, adapter.Calls)
+			}
+			if userState.ReplyKeyboard {
+				t.Fatalf("reply keyboard must be marked removed")
+			}
+			if helper == nil {
+				return
+			}
+			if !helper.Options.DisableNotification {
+				t.Fatalf("helper must be silent")
+			}
+			if deleted := adapter.LastCall("delete_message"); deleted == nil || deleted.MessageID != helper.MessageID {
+				t.Fatalf("helper message %d must be deleted, got %+v", helper.MessageID, deleted)
+			}
+		})
+	}
+}
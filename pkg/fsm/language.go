@@ -0,0 +1,70 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// handleLanguageCommand switches the bot's language for the user: /language <code> sets it directly,
+// /language alone offers the languages as buttons.
+func handleLanguageCommand(ctx context.Context, userState *state.UserState, botPort botport.BotPort, store *state.Store, chatID int64, arg string) {
+	if arg = strings.ToLower(strings.TrimSpace(arg)); arg != "" {
+		if setLanguage(userState, store, arg) {
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgLanguageSet), nil)
+			refreshMainMenu(ctx, userState, botPort, store)
+			return
+		}
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgLanguageUnknown, arg, strings.Join(config.Languages(), ", ")), nil)
+		return
+	}
+
+	keyboard := botport.NewKeyboard()
+	for _, lang := range config.Languages() {
+		label := config.LanguageName(lang)
+		if lang == userState.Lang() {
+			label = "✅ " + label
+		}
+		keyboard.AddRow(botport.NewButton(label, CallbackLanguagePrefix+lang))
+	}
+	_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgLanguagePrompt), keyboard)
+}
+
+// handleLanguageCallback applies the language picked from the /language buttons and replaces the
+// buttons with the confirmation.
+func handleLanguageCallback(ctx context.Context, userState *state.UserState, botPort botport.BotPort, store *state.Store, chatID int64, messageID int, lang string) {
+	if !setLanguage(userState, store, lang) {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgActionUnavailable), nil)
+		return
+	}
+	emptyKeyboard := botport.NewKeyboard()
+	if _, err := botPort.EditMessage(ctx, chatID, messageID, tr(userState, config.MsgLanguageSet), emptyKeyboard); err != nil {
+		slog.ErrorContext(ctx, "confirming the language failed", "user_id", userState.UserID, "err", err)
+	}
+	refreshMainMenu(ctx, userState, botPort, store)
+}
+
+// setLanguage makes lang the user's language if it is one of config.Languages. Messages other users
+// send them, such as forwards to a therapist, follow their choice.
+func setLanguage(userState *state.UserState, store *state.Store, lang string) bool {
+	if !slices.Contains(config.Languages(), lang) {
+		return false
+	}
+	userState.Language = lang
+	store.NoteLang(userState)
+	slog.Info("language switched", "user_id", userState.UserID, "language", lang)
+	return true
+}
+
+// refreshMainMenu resends the main menu so its reply buttons are labelled in the new language. A user
+// in the middle of a record gets it when they leave.
+func refreshMainMenu(ctx context.Context, userState *state.UserState, botPort botport.BotPort, store *state.Store) {
+	if userState.MainMenuFSM.Current() == StateIdle && userState.RecordFSM.Current() == StateRecordIdle {
+		sendMainMenu(ctx, botPort, userState, store)
+	}
+}
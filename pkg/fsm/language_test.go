@@ -0,0 +1,122 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestLanguageCommand(t *testing.T) {
+	store := newTestStore()
+	userState := store.GetOrCreateUserState(5, "User")
+	rc := &config.RecordConfig{}
+
+	steps := []struct {
+		name         string
+		message      *botport.InboundEvent
+		wantText     string
+		wantLanguage string
+		wantMenu     bool // The main menu is resent with labels in wantLanguage
+	}{
+		{name: "menu", message: commandMessage(5, "/language"), wantText: config.Message("ru", config.MsgLanguagePrompt)},
+		{name: "unknown", message: commandMessage(5, "/language de"), wantText: config.Message("ru", config.MsgLanguageUnknown, "de", "ru, en")},
+		{name: "switch", message: commandMessage(5, "/language EN"), wantText: config.Message("en", config.MsgLanguageSet), wantLanguage: "en", wantMenu: true},
+		{name: "english label", message: textMessage(5, "📬 Sent"), wantText: config.Message("en", config.MsgDeliveriesEmpty), wantLanguage: "en"},
+		{name: "russian label still works", message: textMessage(5, "📬 Отправленные"), wantText: config.Message("en", config.MsgDeliveriesEmpty), wantLanguage: "en"},
+	}
+	for _, step := range steps {
+		adapter := &fakeadapter.FakeAdapter{}
+		handleMessage(context.Background(), step.message, userState, adapter, rc, store)
+
+		if len(adapter.Calls) == 0 || adapter.Calls[0].Text != step.wantText {
+			t.Fatalf("%s: calls %+v, want reply %q", step.name, adapter.Calls, step.wantText)
+		}
+		if step.name == "menu" && !adapter.Calls[0].Keyboard.HasCallback(CallbackLanguagePrefix+"en") {
+			t.Fatalf("%s: no English button in %+v", step.name, adapter.Calls[0].Keyboard)
+		}
+		if userState.Language != step.wantLanguage || store.Lang(5) != step.wantLanguage {
+			t.Fatalf("%s: language %q, seen by others %q; want %q", step.name, userState.Language, store.Lang(5), step.wantLanguage)
+		}
+		menu := adapter.LastCall("send_message")
+		ok := menu.Keyboard.Kind == fakeadapter.KeyboardReply
+		if ok != step.wantMenu {
+			t.Fatalf("%s: main menu sent = %t, want %t", step.name, ok, step.wantMenu)
+		}
+		if ok && menu.Keyboard.Labels()[0] != config.Message(step.wantLanguage, ButtonMainMenuFillRecord) {
+			t.Fatalf("%s: menu %s", step.name, menu.Keyboard)
+		}
+	}
+}
+
+func TestLanguageCallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantOp       string
+		wantText     string
+		wantLanguage string
+	}{
+		{name: "known language", data: CallbackLanguagePrefix + "en", wantOp: "edit_message", wantText: config.Message("en", config.MsgLanguageSet), wantLanguage: "en"},
+		{name: "unknown language", data: CallbackLanguagePrefix + "xx", wantOp: "send_message", wantText: config.Message("ru", config.MsgActionUnavailable)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, &config.RecordConfig{}).Store()
+			userState := store.GetOrCreateUserState(6, "User")
+
+			handleCallbackQuery(context.Background(), callbackQuery(6, 3, tt.data), userState, adapter, &config.RecordConfig{}, store)
+
+			call := adapter.LastCall(tt.wantOp)
+			if call == nil || call.Text != tt.wantText {
+				t.Fatalf("%s call %+v, want %q", tt.wantOp, call, tt.wantText)
+			}
+			if userState.Language != tt.wantLanguage {
+				t.Fatalf("language %q, want %q", userState.Language, tt.wantLanguage)
+			}
+		})
+	}
+}
+
+func TestForwardLabelsFollowReader(t *testing.T) {
+	store := newTestStore()
+	english := store.GetOrCreateUserState(900, "Therapist")
+	english.Profile.LanguageCode = "en"
+	store.NoteLang(english)
+	store.GetOrCreateUserState(901, "Other therapist")
+
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Mood", Type: "text", StoreKey: "mood"}}},
+	}}
+	record := state.NewRecord()
+	record.IsSaved = true
+	record.Data["mood"] = state.StringAnswer("ok")
+	userState := &state.UserState{UserID: 7, UserName: "Anna", Records: []*state.Record{record}}
+
+	tests := []struct {
+		name       string
+		targetID   int64
+		wantHeader string
+	}{
+		{name: "to the therapist", targetID: 900, wantHeader: config.Message("en", config.MsgForwardHeader, "Anna", 7)},
+		{name: "to another therapist", targetID: 901, wantHeader: config.Message("ru", config.MsgForwardHeader, "Anna", 7)},
+		{name: "to the user", targetID: 7, wantHeader: config.Message("ru", config.MsgForwardHeader, "Anna", 7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			if _, err := deliverRecord(context.Background(), adapter, rc, userState, record, tt.targetID, store, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			call := adapter.LastCall("send_message")
+			if call == nil || !strings.HasPrefix(call.Text, tt.wantHeader) {
+				t.Fatalf("forward %+v, want header %q", call, tt.wantHeader)
+			}
+		})
+	}
+}
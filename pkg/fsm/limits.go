@@ -0,0 +1,79 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"unicode/utf8"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// answerBudget returns how many runes a free-text answer to question may have: the smaller of the
+// answer length limit and what is left of the record size limit.
+func answerBudget(limits config.LimitsConfig, record *state.Record, question config.QuestionConfig) int {
+	budget := limits.AnswerLimit(question)
+	used := record.Size()
+	// A plain text answer is replaced, so its current length is given back.
+	if existing := record.Answer(question); existing.Kind == state.AnswerString {
+		used -= utf8.RuneCountInString(existing.Text)
+	}
+	if left := limits.RecordLimit() - used; left < budget {
+		budget = max(left, 0)
+	}
+	return budget
+}
+
+// enforceAnswerLimits lets text through when it fits the budget. Otherwise the answer is held back:
+// a full record is reported (the caller asks the question again), and an over-long answer is cut,
+// parked in UserState.PendingAnswer and offered on the question prompt with "keep" or "type again".
+func enforceAnswerLimits(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, question config.QuestionConfig, text string) bool {
+	budget := answerBudget(recordConfig.Limits, userState.CurrentRecord, question)
+	length := utf8.RuneCountInString(text)
+	if length <= budget {
+		return true
+	}
+
+	if budget == 0 {
+		slog.InfoContext(ctx, "record reached its size limit", "user_id", userState.UserID, "runes", recordConfig.Limits.RecordLimit())
+		sendTransient(ctx, botPort, recordConfig, chatID, tr(userState, config.MsgRecordFull, recordConfig.Limits.RecordLimit()))
+		return false
+	}
+
+	slog.InfoContext(ctx, "answer over its budget", "user_id", userState.UserID, "question", question.ID, "runes", length, "budget", budget)
+	userState.PendingAnswer = string([]rune(text)[:budget])
+	keyboard := botport.NewKeyboard(
+		botport.NewRow(
+			botport.NewButton(tr(userState, config.MsgButtonTruncateKeep, budget), CallbackActionPrefix+ActionTruncateKeep),
+			botport.NewButton(tr(userState, config.MsgButtonTruncateRetry), CallbackActionPrefix+ActionTruncateRetry),
+		),
+	)
+	prompt := tr(userState, config.MsgAnswerTooLong, length, budget, budget)
+	msg, err := botPort.EditMessage(ctx, chatID, userState.LastMessageID, prompt, keyboard)
+	if err != nil && !botport.IsCode(err, "message_not_modified") {
+		slog.WarnContext(ctx, "edit failed, sending a new message", "chat_id", chatID, "err", err)
+		msg, err = botPort.SendMessage(ctx, chatID, prompt, keyboard)
+	}
+	if err == nil && msg.MessageID != 0 {
+		setLastPrompt(userState, chatID, msg.MessageID, prompt, keyboard)
+	}
+	return false
+}
+
+// acceptTruncatedAnswer submits the answer held back by enforceAnswerLimits.
+func acceptTruncatedAnswer(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
+	text := userState.PendingAnswer
+	userState.PendingAnswer = ""
+	if text == "" {
+		askCurrentQuestion(ctx, userState, botPort, recordConfig, userState.LastMessageID)
+		return
+	}
+	sectionConf, question, err := resolveCurrentQuestion(recordConfig, userState)
+	if err != nil {
+		slog.ErrorContext(ctx, "resolving the truncated question failed", "err", err)
+		recoverFromConfigDrift(ctx, userState, botPort, recordConfig, chatID)
+		return
+	}
+	submitTextAnswer(ctx, userState, botPort, recordConfig, chatID, sectionConf, question, text)
+}
@@ -0,0 +1,98 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestAnswerBudget(t *testing.T) {
+	question := config.QuestionConfig{ID: "q1", Type: "text", StoreKey: "note"}
+
+	tests := []struct {
+		name     string
+		limits   config.LimitsConfig
+		question config.QuestionConfig
+		data     map[string]string
+		want     int
+	}{
+		{name: "defaults", question: question, want: config.DefaultMaxAnswerLength},
+		{name: "global answer limit", limits: config.LimitsConfig{MaxAnswerLength: 10}, question: question, want: 10},
+		{name: "question override", limits: config.LimitsConfig{MaxAnswerLength: 10}, question: config.QuestionConfig{ID: "q1", Type: "text", StoreKey: "note", MaxLength: 5}, want: 5},
+		{name: "record nearly full", limits: config.LimitsConfig{MaxAnswerLength: 10, MaxRecordSize: 12}, question: question, data: map[string]string{"other": "ёёёёёёёё"}, want: 4},
+		{name: "own answer is replaced", limits: config.LimitsConfig{MaxAnswerLength: 10, MaxRecordSize: 12}, question: question, data: map[string]string{"other": "ёёёёёёёё", "note": "abcd"}, want: 4},
+		{name: "record full", limits: config.LimitsConfig{MaxAnswerLength: 10, MaxRecordSize: 8}, question: question, data: map[string]string{"other": "ёёёёёёёёё"}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := &state.Record{Data: stringAnswers(tt.data)}
+			if got := answerBudget(tt.limits, record, tt.question); got != tt.want {
+				t.Fatalf("budget = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlongAnswerOffersTruncation(t *testing.T) {
+	questions.RegisterBuiltins()
+	rc := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"notes": {Title: "Notes", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Заметка?", Type: "text", StoreKey: "note"}}},
+		},
+		Limits: config.LimitsConfig{MaxAnswerLength: 5},
+	}
+
+	tests := []struct {
+		name       string
+		action     string
+		wantState  string
+		wantAnswer string
+	}{
+		{name: "keep truncated", action: ActionTruncateKeep, wantState: StateSelectingSection, wantAnswer: "абвгд"},
+		{name: "type again", action: ActionTruncateRetry, wantState: StateAnsweringQuestion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := &state.UserState{
+				UserID:         8,
+				CurrentRecord:  state.NewRecord(),
+				CurrentSection: "notes",
+				LastMessageID:  1,
+				MainMenuFSM:    handler.NewMainMenuFSM(),
+				RecordFSM:      handler.NewRecordFSM(),
+			}
+			userState.RecordFSM.SetState(StateAnsweringQuestion)
+
+			handleMessage(context.Background(), textMessage(8, "абвгдеёжз"), userState, adapter, rc, nil)
+
+			if userState.CurrentRecord.HasAnswer(rc.Sections["notes"].Questions[0]) {
+				t.Fatalf("over-long answer must not be stored before the user decides")
+			}
+			if userState.PendingAnswer != "абвгд" {
+				t.Fatalf("pending = %q, want truncated text", userState.PendingAnswer)
+			}
+			if edit := adapter.LastCall("edit_message"); edit == nil || !strings.Contains(edit.Text, "9 символов, можно не больше 5") {
+				t.Fatalf("expected truncation prompt, calls: %+v", adapter.Calls)
+			}
+
+			handleCallbackQuery(context.Background(), callbackQuery(8, userState.LastMessageID, CallbackActionPrefix+tt.action), userState, adapter, rc, nil)
+
+			if got := userState.RecordFSM.Current(); got != tt.wantState {
+				t.Fatalf("state = %s, want %s", got, tt.wantState)
+			}
+			if got := userState.CurrentRecord.Data["note"].String(); got != tt.wantAnswer {
+				t.Fatalf("answer = %q, want %q", got, tt.wantAnswer)
+			}
+			if userState.PendingAnswer != "" {
+				t.Fatalf("pending answer must be cleared")
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package fsm
+
+import (
+	"context"
+)
+
+// Lock order: a goroutine holds at most one UserState.Mu at a time. The only exception is
+// relinkUser, which takes the two users it merges in ID order while holding no other. A handler
+// that needs another user therefore queues that work with whenUnlocked: HandleUpdate runs it once
+// the sender's Mu is released, and the work locks whatever users it touches itself.
+
+type unlockedQueueKey struct{}
+
+// withUnlockedQueue returns a context whose whenUnlocked calls are queued, and the function that
+// runs the queue. The caller runs it after releasing the user's Mu.
+func withUnlockedQueue(ctx context.Context) (context.Context, func()) {
+	queue := new([]func())
+	return context.WithValue(ctx, unlockedQueueKey{}, queue), func() {
+		for len(*queue) > 0 {
+			fn := (*queue)[0]
+			*queue = (*queue)[1:]
+			fn()
+		}
+	}
+}
+
+// whenUnlocked runs fn once the handler holds no user's Mu: at the end of HandleUpdate, or right
+// away when ctx has no queue, as outside HandleUpdate no user is locked.
+func whenUnlocked(ctx context.Context, fn func()) {
+	if queue, ok := ctx.Value(unlockedQueueKey{}).(*[]func()); ok {
+		*queue = append(*queue, fn)
+		return
+	}
+	fn()
+}
@@ -0,0 +1,26 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+// sendMedia sends the configured sticker or animation. Media is decoration: it is skipped on transports
+// without attachments, and a failed send is only logged.
+func sendMedia(ctx context.Context, botPort botport.BotPort, chatID int64, media config.MediaConfig, opts ...botport.SendOption) {
+	if media.IsZero() || !botPort.Capabilities().Attachments {
+		return
+	}
+	var err error
+	if media.Sticker != "" {
+		_, err = botPort.SendSticker(ctx, chatID, media.Sticker, opts...)
+	} else {
+		_, err = botPort.SendAnimation(ctx, chatID, media.Animation, "", opts...)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "sending media failed", "chat_id", chatID, "err", err)
+	}
+}
@@ -0,0 +1,17 @@
+package fsm
+
+import (
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// tr renders a catalog message in the user's language.
+func tr(userState *state.UserState, key config.MessageKey, args ...any) string {
+	return config.Message(userState.Lang(), key, args...)
+}
+
+// trTo renders a catalog message for recipientID, in their language. It takes no lock on the
+// recipient, so a handler holding another user's Mu can use it.
+func trTo(store *state.Store, recipientID int64, key config.MessageKey, args ...any) string {
+	return config.Message(store.Lang(recipientID), key, args...)
+}
@@ -0,0 +1,168 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+const navigationUser = 12
+
+// navigationRun drives one user through random events: taps on the buttons the bot showed last, taps
+// on stale buttons, typed answers, /start, and send or edit failures that force the record FSM out.
+type navigationRun struct {
+	rng     *rand.Rand
+	adapter *fakeadapter.FakeAdapter
+	handler *Handler
+	log     []string // Events so far, printed when an invariant breaks
+}
+
+// step sends one random event and returns a description of it and whether it may drop draft answers
+// on purpose (saving, starting over, discarding a section).
+func (r *navigationRun) step(ctx context.Context) (string, bool) {
+	var inline, reply []fakeadapter.Call
+	for _, call := range r.adapter.Calls {
+		if call.ChatID != navigationUser {
+			continue
+		}
+		switch call.Keyboard.Kind {
+		case fakeadapter.KeyboardInline:
+			if len(call.Keyboard.Callbacks()) > 0 {
+				inline = append(inline, call)
+			}
+		case fakeadapter.KeyboardReply:
+			reply = append(reply, call)
+		}
+	}
+
+	switch n := r.rng.IntN(10); {
+	case n < 5 && len(inline) > 0:
+		// Mostly the newest keyboard, sometimes a stale one further up the chat.
+		call := inline[len(inline)-1]
+		if r.rng.IntN(4) == 0 {
+			call = inline[r.rng.IntN(len(inline))]
+		}
+		callbacks := call.Keyboard.Callbacks()
+		data := callbacks[r.rng.IntN(len(callbacks))]
+		r.handler.HandleUpdate(ctx, botport.InboundEvent{Kind: botport.EventCallback, From: botport.Sender{ID: navigationUser}, ChatID: navigationUser, MessageID: call.MessageID, Text: call.Text, CallbackID: "cb", Data: data})
+		return "tap " + data, dropsAnswers(data)
+	case n < 7 && len(reply) > 0:
+		labels := reply[len(reply)-1].Keyboard.Labels()
+		label := labels[r.rng.IntN(len(labels))]
+		r.handler.HandleUpdate(ctx, *textMessage(navigationUser, label))
+		return "press " + label, false
+	case n < 8:
+		r.handler.HandleUpdate(ctx, *commandMessage(navigationUser, "/start"))
+		return "/start", false
+	case n < 9:
+		op := []string{"send_message", "edit_message"}[r.rng.IntN(2)]
+		r.adapter.Fail(op, errors.New("network down"))
+		return "fail next " + op, false
+	default:
+		text := []string{"fine", "yes", "", "a longer answer"}[r.rng.IntN(4)]
+		r.handler.HandleUpdate(ctx, *textMessage(navigationUser, text))
+		return fmt.Sprintf("type %q", text), false
+	}
+}
+
+// dropsAnswers reports whether a tap removes answers from the draft by design.
+func dropsAnswers(data string) bool {
+	switch data {
+	case CallbackActionPrefix + ActionSaveRecord, CallbackActionPrefix + ActionSaveAndSend,
+		CallbackActionPrefix + ActionNewRecord, CallbackActionPrefix + ActionNewConfirm,
+		CallbackActionPrefix + ActionCancelDiscard:
+		return true
+	}
+	return false
+}
+
+// checkLastMessage reports when a user filling a record points at a message the bot never sent to
+// them or already deleted.
+func checkLastMessage(adapter *fakeadapter.FakeAdapter, userState *state.UserState) error {
+	if userState.RecordFSM.Current() == StateRecordIdle {
+		if userState.LastMessageID != 0 {
+			return fmt.Errorf("idle with LastMessageID %d", userState.LastMessageID)
+		}
+		return nil
+	}
+	if userState.LastMessageID == 0 {
+		return fmt.Errorf("%s without LastMessageID", userState.RecordFSM.Current())
+	}
+	if userState.LastPrompt.MessageID != userState.LastMessageID {
+		return fmt.Errorf("LastPrompt is message %d, LastMessageID %d", userState.LastPrompt.MessageID, userState.LastMessageID)
+	}
+	shown := false
+	for _, call := range adapter.ChatCalls(userState.UserID) {
+		if call.MessageID != userState.LastMessageID {
+			continue
+		}
+		switch call.Op {
+		case "send_message", "edit_message":
+			shown = true
+		case "delete_message":
+			shown = false
+		}
+	}
+	if !shown {
+		return fmt.Errorf("LastMessageID %d is not a message in the chat", userState.LastMessageID)
+	}
+	return nil
+}
+
+// answeredKeys lists the store keys the draft has answers for.
+func answeredKeys(userState *state.UserState) []string {
+	if userState.CurrentRecord == nil {
+		return nil
+	}
+	var keys []string
+	for key := range userState.CurrentRecord.Data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestNavigationInvariants(t *testing.T) {
+	questions.RegisterBuiltins()
+	rc := fuzzRecordConfig()
+	const runs, steps = 200, 60
+
+	for seed := uint64(1); seed <= runs; seed++ {
+		adapter := &fakeadapter.FakeAdapter{}
+		run := &navigationRun{rng: rand.New(rand.NewPCG(seed, 0)), adapter: adapter, handler: newTestHandler(adapter, rc)}
+		ctx := context.Background()
+		run.handler.HandleUpdate(ctx, *commandMessage(navigationUser, "/start"))
+		userState, _ := run.handler.Store().Get(navigationUser)
+
+		for i := 0; i < steps; i++ {
+			before := answeredKeys(userState)
+			event, dropping := run.step(ctx)
+			run.log = append(run.log, event)
+
+			fail := func(err error) {
+				t.Fatalf("seed %d, step %d: %v\nevents:\n  %s", seed, i+1, err, strings.Join(run.log, "\n  "))
+			}
+			if err := checkUserState(rc, userState); err != nil {
+				fail(err)
+			}
+			if err := checkLastMessage(adapter, userState); err != nil {
+				fail(err)
+			}
+			if dropping {
+				continue
+			}
+			for _, key := range before {
+				if userState.CurrentRecord == nil || userState.CurrentRecord.Data[key].IsEmpty() {
+					fail(fmt.Errorf("answer %q lost from the draft", key))
+				}
+			}
+		}
+	}
+}
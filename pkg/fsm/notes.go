@@ -0,0 +1,83 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// noteDateLayout formats the date of the record a note goes to, as in forwarded records.
+const noteDateLayout = "02.01.2006 15:04"
+
+// startNote moves the main menu FSM to adding_note and asks for the text of a note to the most recent
+// saved record.
+func startNote(ctx context.Context, userState *state.UserState, botPort botport.BotPort, chatID int64, store *state.Store) {
+	var record *state.Record
+	if store != nil {
+		record = store.LastRecord(userState.UserID)
+	}
+	if record == nil {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNoSavedRecords), nil)
+		return
+	}
+	if err := userState.MainMenuFSM.Event(ctx, EventAddNote); err != nil {
+		slog.ErrorContext(ctx, "add note event failed", "user_id", userState.UserID, "err", err)
+		return
+	}
+
+	keyboard := botport.NewKeyboard(botport.NewRow(
+		botport.NewButton(tr(userState, config.MsgButtonNoteCancel), CallbackActionPrefix+ActionNoteCancel),
+	))
+	_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNotePrompt, record.CreatedAt.Format(noteDateLayout)), keyboard)
+}
+
+// addNote appends text, stamped with the current time, to the most recent saved record and returns the
+// main menu FSM to idle. The record is looked up again, as it may have been forwarded and dropped since
+// the prompt.
+func addNote(ctx context.Context, userState *state.UserState, botPort botport.BotPort, chatID int64, store *state.Store, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNoteEmpty), nil)
+		return
+	}
+
+	var record *state.Record
+	if store != nil {
+		record = store.LastRecord(userState.UserID)
+	}
+	if record == nil {
+		dropNote(ctx, userState)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNoSavedRecords), nil)
+		return
+	}
+
+	record.AddNote(clock(), text)
+	store.UpdateRecord(userState, record)
+	if err := userState.MainMenuFSM.Event(ctx, EventSaveNote); err != nil {
+		slog.ErrorContext(ctx, "save note event failed", "user_id", userState.UserID, "err", err)
+	}
+	slog.InfoContext(ctx, "note added", "user_id", userState.UserID, "record_id", record.ID, "notes", len(record.Notes))
+	_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNoteAdded, record.CreatedAt.Format(noteDateLayout)), nil)
+}
+
+// cancelNote serves the cancel button of the note prompt: the prompt is replaced by a notice.
+func cancelNote(ctx context.Context, userState *state.UserState, botPort botport.BotPort, chatID int64, messageID int) {
+	dropNote(ctx, userState)
+	if _, err := botPort.EditMessage(ctx, chatID, messageID, tr(userState, config.MsgNoteCancelled), botport.NewKeyboard()); err != nil && !botport.IsCode(err, "message_not_modified") {
+		slog.ErrorContext(ctx, "updating the note prompt failed", "message_id", messageID, "err", err)
+	}
+}
+
+// dropNote returns the main menu FSM from adding_note to idle, if it is there, without adding anything.
+func dropNote(ctx context.Context, userState *state.UserState) {
+	if userState.MainMenuFSM.Current() != StateAddingNote {
+		return
+	}
+	if err := userState.MainMenuFSM.Event(ctx, EventCancelNote); err != nil {
+		slog.ErrorContext(ctx, "cancel note event failed", "user_id", userState.UserID, "err", err)
+	}
+}
@@ -0,0 +1,138 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestAddNoteToLastRecord(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+	now := time.Date(2025, 3, 2, 8, 15, 0, 0, time.UTC)
+	defer func() { clock = time.Now }()
+	clock = func() time.Time { return now }
+
+	addNote := tr(nil, ButtonMainMenuAddNote)
+	cancel := CallbackActionPrefix + ActionNoteCancel
+	tests := []struct {
+		name       string
+		records    int
+		steps      []string // Texts to send; callback data is sent as a tap on the newest message
+		wantState  string
+		wantNotes  []string
+		wantReply  string // Text of the last message sent or edited
+		wantCancel bool   // The last message carries the cancel button
+	}{
+		{name: "no saved records", steps: []string{addNote}, wantState: StateIdle, wantReply: config.Message("", config.MsgNoSavedRecords)},
+		{name: "prompt", records: 2, steps: []string{addNote}, wantState: StateAddingNote, wantReply: config.Message("", config.MsgNotePrompt, "01.03.2025 21:00"), wantCancel: true},
+		{name: "note added", records: 2, steps: []string{addNote, "  поздно уснула  "}, wantState: StateIdle, wantNotes: []string{"поздно уснула"}, wantReply: config.Message("", config.MsgNoteAdded, "01.03.2025 21:00")},
+		{name: "second note", records: 1, steps: []string{addNote, "one", addNote, "two"}, wantState: StateIdle, wantNotes: []string{"one", "two"}, wantReply: config.Message("", config.MsgNoteAdded, "01.03.2025 21:00")},
+		{name: "empty text is asked again", records: 1, steps: []string{addNote, "   "}, wantState: StateAddingNote, wantReply: config.Message("", config.MsgNoteEmpty)},
+		{name: "cancel", records: 1, steps: []string{addNote, cancel}, wantState: StateIdle, wantReply: config.Message("", config.MsgNoteCancelled)},
+		{name: "menu button drops the note", records: 1, steps: []string{addNote, tr(nil, ButtonMainMenuDeliveries)}, wantState: StateIdle},
+		{name: "start drops the note", records: 1, steps: []string{addNote, "/start"}, wantState: StateIdle},
+		{name: "stale cancel", records: 1, steps: []string{addNote, "one", cancel}, wantState: StateIdle, wantNotes: []string{"one"}, wantReply: config.Message("", config.MsgNoteAdded, "01.03.2025 21:00")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := handler.Store().GetOrCreateUserState(41, "User")
+			for i := 0; i < tt.records; i++ {
+				record := state.NewRecord()
+				record.ID = "rec-" + string(rune('a'+i))
+				record.Data["f1"] = state.StringAnswer("answer")
+				record.IsSaved = true
+				record.CreatedAt = time.Date(2025, 3, 1, 21-i, 0, 0, 0, time.UTC)
+				userState.Records = append([]*state.Record{record}, userState.Records...)
+			}
+
+			ctx := context.Background()
+			for _, step := range tt.steps {
+				switch {
+				case strings.HasPrefix(step, CallbackActionPrefix):
+					last := adapter.LastCall("send_message")
+					handler.HandleUpdate(ctx, botport.InboundEvent{Kind: botport.EventCallback, From: botport.Sender{ID: 41}, ChatID: 41, MessageID: last.MessageID, CallbackID: "cb", Data: step})
+				case strings.HasPrefix(step, "/"):
+					handler.HandleUpdate(ctx, *commandMessage(41, step))
+				default:
+					handler.HandleUpdate(ctx, *textMessage(41, step))
+				}
+			}
+
+			if got := userState.MainMenuFSM.Current(); got != tt.wantState {
+				t.Fatalf("main state = %s, want %s", got, tt.wantState)
+			}
+			var notes []string
+			if last := handler.Store().LastRecord(41); last != nil {
+				for _, note := range last.Notes {
+					if !note.At.Equal(now) {
+						t.Fatalf("note %q stamped %s, want %s", note.Text, note.At, now)
+					}
+					notes = append(notes, note.Text)
+				}
+			}
+			if strings.Join(notes, "|") != strings.Join(tt.wantNotes, "|") {
+				t.Fatalf("notes = %q, want %q", notes, tt.wantNotes)
+			}
+			if tt.wantReply == "" {
+				return
+			}
+			calls := adapter.ChatCalls(41)
+			if len(calls) == 0 || calls[len(calls)-1].Text != tt.wantReply {
+				t.Fatalf("last message = %+v, want %q", calls, tt.wantReply)
+			}
+			if got := calls[len(calls)-1].Keyboard.HasCallback(cancel); got != tt.wantCancel {
+				t.Fatalf("cancel button shown = %t, want %t", got, tt.wantCancel)
+			}
+		})
+	}
+}
+
+func TestForwardShowsNotes(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+	record := state.NewRecord()
+	record.IsSaved = true
+	record.CreatedAt = time.Date(2025, 3, 1, 21, 0, 0, 0, time.UTC)
+	record.Data["f1"] = state.StringAnswer("answer")
+
+	tests := []struct {
+		name  string
+		notes []string
+		want  string // Tail of the message; empty to expect no notes block
+	}{
+		{name: "without notes"},
+		{name: "with notes", notes: []string{"позже", "ещё"}, want: "📝 Заметки:\n- 02.03.2025 08:00: позже\n- 02.03.2025 08:00: ещё\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record.Notes = nil
+			for _, text := range tt.notes {
+				record.AddNote(time.Date(2025, 3, 2, 8, 0, 0, 0, time.UTC), text)
+			}
+			text, err := renderForwardMessage(buildForwardPayload(rc, record, &state.UserState{UserID: 1, UserName: "User"}))
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if tt.want == "" {
+				if strings.Contains(text, "Заметки") {
+					t.Fatalf("unexpected notes block:\n%s", text)
+				}
+				return
+			}
+			if !strings.HasSuffix(text, tt.want) {
+				t.Fatalf("message = %q, want it to end with %q", text, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// clock is the time source for section windows and daily records; tests pin it.
+var clock = time.Now
+
+// SetClock replaces the time source of section windows, daily records and deadlines. The replay tool
+// uses it to run a transcript at the times it was captured.
+func SetClock(now func() time.Time) {
+	clock = now
+}
+
+// newDraft starts an empty record stamped with the clock, so daily rollover and deadlines see the same
+// time as the rest of the flow.
+func newDraft() *state.Record {
+	record := state.NewRecord()
+	record.StartedAt = clock()
+	return record
+}
+
+// sectionOpen reports whether the user may answer the section now. Previews ignore time windows.
+func sectionOpen(userState *state.UserState, sectionConf config.SectionConfig) bool {
+	return userState.Preview || sectionConf.OpenAt(clock())
+}
+
+// windowText describes when a timed section is available.
+func windowText(userState *state.UserState, window *config.TimeWindow) string {
+	return tr(userState, config.MsgTimeWindow, window.From, window.To)
+}
+
+// rollOverDailyDraft closes yesterday's draft when the record config is daily: a draft with answers is
+// saved as the record of the day it was started, an empty one is dropped. Its lateness is judged at the
+// end of that day, when it was last open to answers, not at the time of the rollover.
+func rollOverDailyDraft(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
+	draft := userState.CurrentRecord
+	if !recordConfig.DailyRecord || draft == nil || userState.Preview || sameDay(draft.StartedAt, clock()) {
+		return
+	}
+	userState.CurrentRecord = nil
+	if !draftHasAnswers(draft) {
+		return
+	}
+
+	draft.IsSaved = true
+	draft.Transient = nil
+	draft.CreatedAt = draft.StartedAt
+	if draft.CreatedAt.IsZero() {
+		draft.CreatedAt = clock()
+	}
+	if draft.ID == "" {
+		draft.ID = idGenerator.NewID()
+	}
+	markRecordLate(recordConfig, draft, endOfDay(draft.CreatedAt))
+	userState.Records = append(userState.Records, draft)
+	slog.InfoContext(ctx, "daily draft saved on rollover", "record_id", draft.ID, "user_id", userState.UserID, "day", draft.CreatedAt.Format("2006-01-02"))
+	_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgDailyRollover, draft.CreatedAt.Format("02.01")), nil)
+}
+
+// endOfDay returns the last instant of the calendar day of t, in t's location.
+func endOfDay(t time.Time) time.Time {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return start.AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,87 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestTimedSectionsAndDailyRecord(t *testing.T) {
+	questions.RegisterBuiltins()
+	defer func() { clock = time.Now }()
+	rc := &config.RecordConfig{
+		DailyRecord: true,
+		Deadline:    "22:00",
+		Sections: map[string]config.SectionConfig{
+			"morning": {Title: "Утро", Available: &config.TimeWindow{From: "06:00", To: "12:00"}, Questions: []config.QuestionConfig{{ID: "m1", Prompt: "Как спали?", Type: "text", StoreKey: "sleep"}}},
+			"evening": {Title: "Вечер", Available: &config.TimeWindow{From: "18:00", To: "23:59"}, Questions: []config.QuestionConfig{{ID: "e1", Prompt: "Как прошёл день?", Type: "text", StoreKey: "day"}}},
+		},
+	}
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	userState := &state.UserState{UserID: 30, MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+	ctx := context.Background()
+
+	steps := []struct {
+		name      string
+		at        time.Duration
+		section   string
+		answer    string
+		wantMenu  []string
+		wantOpen  bool
+		wantSaved int
+	}{
+		{name: "morning offers only the morning part", at: 8 * time.Hour, section: "morning", answer: "хорошо", wantMenu: []string{"🔒 Вечер — с 18:00 до 23:59"}, wantOpen: true},
+		{name: "evening part is refused in the morning", at: 9 * time.Hour, section: "evening", wantOpen: false},
+		{name: "evening adds to the same draft", at: 19 * time.Hour, section: "evening", answer: "спокойно", wantMenu: []string{"🔒 Утро — с 06:00 до 12:00"}, wantOpen: true},
+		{name: "next day saves yesterday's draft", at: 32 * time.Hour, wantSaved: 1},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			clock = func() time.Time { return day.Add(step.at) }
+			userState.RecordFSM.SetState(StateRecordIdle)
+			startOrResumeRecordCreation(ctx, userState, adapter, rc, nil, 30)
+			if len(userState.Records) != step.wantSaved {
+				t.Fatalf("saved records = %d, want %d", len(userState.Records), step.wantSaved)
+			}
+			if step.section == "" {
+				return
+			}
+			menu := adapter.LastCall("send_message")
+			for _, want := range step.wantMenu {
+				if !strings.Contains(menu.Text, want) {
+					t.Fatalf("menu %q missing %q", menu.Text, want)
+				}
+			}
+
+			selectSection(ctx, userState, adapter, rc, 30, 0, step.section)
+			if got := userState.RecordFSM.Current() == StateAnsweringQuestion; got != step.wantOpen {
+				t.Fatalf("section opened = %t, want %t", got, step.wantOpen)
+			}
+			if step.wantOpen {
+				handleMessage(ctx, textMessage(30, step.answer), userState, adapter, rc, nil)
+			}
+		})
+	}
+
+	saved := userState.Records[0]
+	if saved.Data["sleep"].String() != "хорошо" || saved.Data["day"].String() != "спокойно" {
+		t.Fatalf("daily record must hold both parts, got %v", saved.Data)
+	}
+	if !sameDay(saved.CreatedAt, day) {
+		t.Fatalf("daily record dated %s, want %s", saved.CreatedAt, day)
+	}
+	if !saved.Late || len(saved.LateSections) != 0 {
+		t.Fatalf("late = %t, late sections %v; want the record late as its day ended unsaved, its sections in time", saved.Late, saved.LateSections)
+	}
+	if userState.CurrentRecord == nil || draftHasAnswers(userState.CurrentRecord) {
+		t.Fatalf("a blank draft must start for the new day")
+	}
+}
@@ -0,0 +1,59 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// profileValue returns the Telegram profile field named by a question's prefill setting.
+func profileValue(profile state.Profile, field string) string {
+	switch field {
+	case config.PrefillFirstName:
+		return profile.FirstName
+	case config.PrefillLastName:
+		return profile.LastName
+	case config.PrefillUsername:
+		if profile.Username == "" {
+			return ""
+		}
+		return "@" + profile.Username
+	case config.PrefillLanguage:
+		return profile.LanguageCode
+	}
+	return ""
+}
+
+// prefillSuggestion returns the profile value offered as the answer to question. Suggestions are made
+// only on the user's first record (Record.FirstRecord), for unanswered free-text questions.
+func prefillSuggestion(userState *state.UserState, question config.QuestionConfig) string {
+	draft := userState.CurrentRecord
+	if question.Prefill == "" || draft == nil || !draft.FirstRecord || currentAnswer(draft, question) != "" {
+		return ""
+	}
+	if strategy := questions.Get(question.Type); strategy == nil || !strategy.Capabilities().NeedsText {
+		return ""
+	}
+	return profileValue(userState.Profile, question.Prefill)
+}
+
+// acceptPrefill submits the suggested profile value as the answer to the current question.
+func acceptPrefill(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
+	sectionConf, question, err := resolveCurrentQuestion(recordConfig, userState)
+	if err != nil {
+		slog.ErrorContext(ctx, "resolving the prefilled question failed", "err", err)
+		recoverFromConfigDrift(ctx, userState, botPort, recordConfig, chatID)
+		return
+	}
+	suggestion := prefillSuggestion(userState, question)
+	if suggestion == "" {
+		askCurrentQuestion(ctx, userState, botPort, recordConfig, userState.LastMessageID)
+		return
+	}
+	slog.InfoContext(ctx, "profile prefill accepted", "user_id", userState.UserID, "field", question.Prefill, "question", question.ID)
+	submitTextAnswer(ctx, userState, botPort, recordConfig, chatID, sectionConf, question, suggestion)
+}
@@ -0,0 +1,67 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestProfilePrefill(t *testing.T) {
+	questions.RegisterBuiltins()
+	profile := state.Profile{FirstName: "Анна", LastName: "Иванова", Username: "anna", LanguageCode: "ru"}
+	tests := []struct {
+		name       string
+		question   config.QuestionConfig
+		first      bool // The draft is the user's first record
+		wantOffer  string
+		wantAnswer string
+	}{
+		{name: "first name on first record", question: config.QuestionConfig{ID: "q1", Prompt: "Имя?", Type: "text", StoreKey: "name", Prefill: config.PrefillFirstName}, first: true, wantOffer: "Анна", wantAnswer: "Анна"},
+		{name: "username", question: config.QuestionConfig{ID: "q1", Prompt: "Ник?", Type: "text", StoreKey: "name", Prefill: config.PrefillUsername}, first: true, wantOffer: "@anna", wantAnswer: "@anna"},
+		{name: "not after the first record", question: config.QuestionConfig{ID: "q1", Prompt: "Имя?", Type: "text", StoreKey: "name", Prefill: config.PrefillFirstName}},
+		{name: "no prefill configured", question: config.QuestionConfig{ID: "q1", Prompt: "Имя?", Type: "text", StoreKey: "name"}, first: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+				"about": {Title: "О себе", Questions: []config.QuestionConfig{tt.question}},
+			}}
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := &state.UserState{
+				UserID:         9,
+				Profile:        profile,
+				CurrentRecord:  &state.Record{Data: map[string]state.Answer{}, FirstRecord: tt.first},
+				CurrentSection: "about",
+				MainMenuFSM:    handler.NewMainMenuFSM(),
+				RecordFSM:      handler.NewRecordFSM(),
+			}
+			userState.RecordFSM.SetState(StateAnsweringQuestion)
+
+			askCurrentQuestion(context.Background(), userState, adapter, rc, 0)
+
+			prompt := adapter.LastCall("send_message")
+			offered := prompt.Keyboard.HasCallback(CallbackActionPrefix + ActionPrefillAccept)
+			if offered != (tt.wantOffer != "") {
+				t.Fatalf("prefill offered = %t, want %t", offered, tt.wantOffer != "")
+			}
+			if !offered {
+				return
+			}
+			if !strings.Contains(prompt.Text, tt.wantOffer) {
+				t.Fatalf("prompt %q must mention %q", prompt.Text, tt.wantOffer)
+			}
+
+			handleCallbackQuery(context.Background(), callbackQuery(9, prompt.MessageID, CallbackActionPrefix+ActionPrefillAccept), userState, adapter, rc, nil)
+
+			if got := userState.CurrentRecord.GetString(tt.question); got != tt.wantAnswer {
+				t.Fatalf("answer = %q, want %q", got, tt.wantAnswer)
+			}
+		})
+	}
+}
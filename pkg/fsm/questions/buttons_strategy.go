@@ -2,9 +2,10 @@ package questions
 
 import (
 	"fmt"
-	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
 )
 
 type buttonsStrategy struct{}
@@ -18,6 +19,10 @@ func (b *buttonsStrategy) Name() string {
 	return "buttons"
 }
 
+func (b *buttonsStrategy) Capabilities() Capabilities {
+	return Capabilities{NeedsCallbacks: true, EditInPlace: true, AnswerKind: state.AnswerString}
+}
+
 func (b *buttonsStrategy) Validate(sectionID string, question config.QuestionConfig) error {
 	if len(question.Options) == 0 {
 		return fmt.Errorf("config validation failed: question '%s' in section '%s' is type 'buttons' but has no options", question.ID, sectionID)
@@ -33,25 +38,30 @@ func (b *buttonsStrategy) Validate(sectionID string, question config.QuestionCon
 	return nil
 }
 
+func (b *buttonsStrategy) CallbackValues(question config.QuestionConfig) []string {
+	values := make([]string, 0, len(question.Options))
+	for _, option := range question.Options {
+		values = append(values, option.Value)
+	}
+	return values
+}
+
 func (b *buttonsStrategy) Render(ctx RenderContext) (PromptSpec, error) {
-	markup := tgbotapi.NewInlineKeyboardMarkup()
+	keyboard := botport.NewKeyboard()
 	for _, option := range ctx.Question.Options {
 		data := fmt.Sprintf("%s%s:%s", ctx.CallbackPrefix, ctx.Question.ID, option.Value)
-		row := tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(option.Text, data),
-		)
-		markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+		keyboard.AddRow(botport.NewButton(option.Text, data))
 	}
 	return PromptSpec{
 		Text:     ctx.Question.Prompt,
-		Keyboard: &markup,
+		Keyboard: keyboard,
 	}, nil
 }
 
 func (b *buttonsStrategy) HandleAnswer(ctx AnswerContext, input AnswerInput) (AnswerResult, error) {
 	if input.Source != InputSourceCallback {
 		return AnswerResult{
-			Feedback: "Пожалуйста, выберите ответ с помощью кнопок ниже.",
+			Feedback: ctx.message(config.MsgChooseWithButtons),
 			Repeat:   true,
 		}, nil
 	}
@@ -59,16 +69,14 @@ func (b *buttonsStrategy) HandleAnswer(ctx AnswerContext, input AnswerInput) (An
 	option := b.findOption(ctx.Question, input.CallbackData)
 	if option == nil {
 		return AnswerResult{
-			Feedback: "Выбранный вариант больше недоступен. Попробуйте снова.",
+			Feedback: ctx.message(config.MsgOptionUnavailable),
 			Repeat:   true,
 		}, nil
 	}
 
-	record, err := ctx.ensureRecord()
-	if err != nil {
+	if err := storeAnswer(ctx.RenderContext, state.StringAnswer(option.Value)); err != nil {
 		return AnswerResult{}, err
 	}
-	record.Data[ctx.Question.StoreKey] = option.Value
 	return AnswerResult{Advance: true}, nil
 }
 
@@ -29,12 +29,11 @@ func TestButtonsStrategyRender(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if prompt.Keyboard == nil || len(prompt.Keyboard.InlineKeyboard) != 1 {
+	if prompt.Keyboard == nil || len(prompt.Keyboard.Rows) != 1 {
 		t.Fatalf("expected one keyboard row, got %+v", prompt.Keyboard)
 	}
-	dataPtr := prompt.Keyboard.InlineKeyboard[0][0].CallbackData
-	if dataPtr == nil || *dataPtr != "answer:city:a" {
-		t.Fatalf("unexpected callback payload: %v", dataPtr)
+	if action := prompt.Keyboard.Rows[0][0].Action; action != "answer:city:a" {
+		t.Fatalf("unexpected callback payload: %q", action)
 	}
 }
 
@@ -67,7 +66,7 @@ func TestButtonsStrategyHandleAnswer(t *testing.T) {
 	if !result.Advance {
 		t.Fatalf("expected Advance=true")
 	}
-	if record.Data["city"] != "b" {
-		t.Fatalf("expected stored value 'b', got '%s'", record.Data["city"])
+	if record.Data["city"].String() != "b" {
+		t.Fatalf("expected stored value 'b', got '%s'", record.Data["city"].String())
 	}
 }
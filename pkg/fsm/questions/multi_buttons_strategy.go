@@ -0,0 +1,168 @@
+package questions
+
+import (
+	"fmt"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+const (
+	stepSelectOptions = "select"
+
+	// multiButtonsDone is the callback value of the confirm button; options may not use it.
+	multiButtonsDone = "done"
+)
+
+type multiButtonsStrategy struct {
+	steps StepMachine
+}
+
+// NewMultiButtonsStrategy returns a QuestionStrategy for prompts where the user toggles several
+// options and confirms them with a "Done" button. The selection is kept in the question scratch
+// until it is confirmed, then stored as a list in option order.
+func NewMultiButtonsStrategy() QuestionStrategy {
+	s := &multiButtonsStrategy{}
+	s.steps = StepMachine{
+		Initial: stepSelectOptions,
+		Steps: map[string]StepHandler{
+			stepSelectOptions: {Render: s.renderOptions, Handle: s.handleToggle},
+		},
+	}
+	return s
+}
+
+func (s *multiButtonsStrategy) Name() string {
+	return "multi_buttons"
+}
+
+func (s *multiButtonsStrategy) Capabilities() Capabilities {
+	return Capabilities{NeedsCallbacks: true, EditInPlace: true, AnswerKind: state.AnswerList}
+}
+
+func (s *multiButtonsStrategy) Validate(sectionID string, question config.QuestionConfig) error {
+	if len(question.Options) == 0 {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' is type 'multi_buttons' but has no options", question.ID, sectionID)
+	}
+	for idx, option := range question.Options {
+		if option.Text == "" {
+			return fmt.Errorf("config validation failed: option #%d for question '%s' in section '%s' has no text", idx+1, question.ID, sectionID)
+		}
+		if option.Value == "" {
+			return fmt.Errorf("config validation failed: option #%d for question '%s' in section '%s' has no value", idx+1, question.ID, sectionID)
+		}
+		if option.Value == multiButtonsDone {
+			return fmt.Errorf("config validation failed: option #%d for question '%s' in section '%s' uses the reserved value '%s'", idx+1, question.ID, sectionID, multiButtonsDone)
+		}
+	}
+	return nil
+}
+
+func (s *multiButtonsStrategy) CallbackValues(question config.QuestionConfig) []string {
+	values := make([]string, 0, len(question.Options)+1)
+	for _, option := range question.Options {
+		values = append(values, option.Value)
+	}
+	return append(values, multiButtonsDone)
+}
+
+func (s *multiButtonsStrategy) Render(ctx RenderContext) (PromptSpec, error) {
+	if _, err := ctx.ensureRecord(); err != nil {
+		return PromptSpec{}, err
+	}
+	return s.steps.Render(ctx)
+}
+
+func (s *multiButtonsStrategy) renderOptions(ctx RenderContext, scratch *state.QuestionScratch) (PromptSpec, error) {
+	if len(scratch.Values) == 0 {
+		// Editing an answered question starts from the stored selection.
+		for _, value := range ctx.Record.Answer(ctx.Question).List {
+			scratch.Values[value] = "1"
+		}
+	}
+
+	keyboard := botport.NewKeyboard()
+	for _, option := range ctx.Question.Options {
+		label := option.Text
+		if scratch.Values[option.Value] != "" {
+			label = "✅ " + label
+		}
+		data := fmt.Sprintf("%s%s:%s", ctx.CallbackPrefix, ctx.Question.ID, option.Value)
+		keyboard.AddRow(botport.NewButton(label, data))
+	}
+	done := fmt.Sprintf("%s%s:%s", ctx.CallbackPrefix, ctx.Question.ID, multiButtonsDone)
+	keyboard.AddRow(botport.NewButton(s.getDoneButtonLabel(ctx), done))
+
+	return PromptSpec{
+		Text:     ctx.Question.Prompt,
+		Keyboard: keyboard,
+	}, nil
+}
+
+func (s *multiButtonsStrategy) HandleAnswer(ctx AnswerContext, input AnswerInput) (AnswerResult, error) {
+	if _, err := ctx.ensureRecord(); err != nil {
+		return AnswerResult{}, err
+	}
+	return s.steps.HandleAnswer(ctx, input)
+}
+
+func (s *multiButtonsStrategy) handleToggle(ctx AnswerContext, input AnswerInput, scratch *state.QuestionScratch) (AnswerResult, error) {
+	if input.Source != InputSourceCallback {
+		return AnswerResult{
+			Feedback: ctx.message(config.MsgMultiUseButtons),
+			Repeat:   true,
+		}, nil
+	}
+
+	if input.CallbackData == multiButtonsDone {
+		selected := make([]string, 0, len(scratch.Values))
+		for _, option := range ctx.Question.Options {
+			if scratch.Values[option.Value] != "" {
+				selected = append(selected, option.Value)
+			}
+		}
+		if len(selected) == 0 {
+			return AnswerResult{
+				Feedback: ctx.message(config.MsgMultiSelectOne),
+				Repeat:   true,
+			}, nil
+		}
+		if err := storeAnswer(ctx.RenderContext, state.ListAnswer(selected...)); err != nil {
+			return AnswerResult{}, err
+		}
+		// The step machine drops the scratch
+		return AnswerResult{Advance: true}, nil
+	}
+
+	if !s.hasOption(ctx.Question, input.CallbackData) {
+		return AnswerResult{
+			Feedback: ctx.message(config.MsgOptionUnavailable),
+			Repeat:   true,
+		}, nil
+	}
+	if scratch.Values[input.CallbackData] != "" {
+		delete(scratch.Values, input.CallbackData)
+	} else {
+		scratch.Values[input.CallbackData] = "1"
+	}
+	return AnswerResult{
+		Repeat: true, // Re-render to move the checkmark
+	}, nil
+}
+
+func (s *multiButtonsStrategy) hasOption(question config.QuestionConfig, value string) bool {
+	for _, opt := range question.Options {
+		if opt.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *multiButtonsStrategy) getDoneButtonLabel(ctx RenderContext) string {
+	if ctx.Question.FinishButtonLabel != "" {
+		return ctx.Question.FinishButtonLabel
+	}
+	return ctx.message(config.MsgButtonMultiDone)
+}
@@ -0,0 +1,159 @@
+package questions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestMultiButtonsStrategy(t *testing.T) {
+	question := config.QuestionConfig{
+		ID:       "mood",
+		Type:     TypeMultiButtons,
+		Prompt:   "Что вы чувствуете?",
+		StoreKey: "mood",
+		Options: []config.ButtonOption{
+			{Text: "Радость", Value: "joy"},
+			{Text: "Грусть", Value: "sad"},
+			{Text: "Злость", Value: "anger"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		stored       state.Answer
+		inputs       []AnswerInput
+		wantLabels   string // Keyboard labels after the inputs, "|"-joined
+		wantAdvance  bool
+		wantFeedback bool
+		wantStored   string
+	}{
+		{
+			name:       "toggle marks options",
+			inputs:     []AnswerInput{{Source: InputSourceCallback, CallbackData: "sad"}, {Source: InputSourceCallback, CallbackData: "joy"}},
+			wantLabels: "✅ Радость|✅ Грусть|Злость|✅ Готово",
+		},
+		{
+			name:       "second tap unmarks",
+			inputs:     []AnswerInput{{Source: InputSourceCallback, CallbackData: "sad"}, {Source: InputSourceCallback, CallbackData: "sad"}},
+			wantLabels: "Радость|Грусть|Злость|✅ Готово",
+		},
+		{
+			name:        "done stores selection in option order",
+			inputs:      []AnswerInput{{Source: InputSourceCallback, CallbackData: "anger"}, {Source: InputSourceCallback, CallbackData: "joy"}, {Source: InputSourceCallback, CallbackData: "done"}},
+			wantAdvance: true,
+			wantStored:  "joy, anger",
+		},
+		{
+			name:         "done without selection",
+			inputs:       []AnswerInput{{Source: InputSourceCallback, CallbackData: "done"}},
+			wantLabels:   "Радость|Грусть|Злость|✅ Готово",
+			wantFeedback: true,
+		},
+		{
+			name:         "text is rejected",
+			inputs:       []AnswerInput{{Source: InputSourceText, Text: "joy"}},
+			wantLabels:   "Радость|Грусть|Злость|✅ Готово",
+			wantFeedback: true,
+		},
+		{
+			name:         "unknown option",
+			inputs:       []AnswerInput{{Source: InputSourceCallback, CallbackData: "fear"}},
+			wantLabels:   "Радость|Грусть|Злость|✅ Готово",
+			wantFeedback: true,
+		},
+		{
+			name:        "editing starts from stored answer",
+			stored:      state.ListAnswer("sad"),
+			inputs:      []AnswerInput{{Source: InputSourceCallback, CallbackData: "joy"}, {Source: InputSourceCallback, CallbackData: "done"}},
+			wantAdvance: true,
+			wantStored:  "joy, sad",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := NewMultiButtonsStrategy()
+			record := state.NewRecord()
+			if !tt.stored.IsEmpty() {
+				record.Data[question.StoreKey] = tt.stored
+			}
+			renderCtx := RenderContext{
+				UserState:      &state.UserState{CurrentRecord: record},
+				Record:         record,
+				SectionID:      "section",
+				Question:       question,
+				CallbackPrefix: "answer:",
+			}
+			if _, err := strategy.Render(renderCtx); err != nil {
+				t.Fatalf("render: %v", err)
+			}
+
+			var result AnswerResult
+			for _, input := range tt.inputs {
+				var err error
+				result, err = strategy.HandleAnswer(AnswerContext{RenderContext: renderCtx}, input)
+				if err != nil {
+					t.Fatalf("handle %+v: %v", input, err)
+				}
+			}
+			if result.Advance != tt.wantAdvance {
+				t.Fatalf("Advance = %v, want %v", result.Advance, tt.wantAdvance)
+			}
+			if (result.Feedback != "") != tt.wantFeedback {
+				t.Fatalf("Feedback = %q, want feedback: %v", result.Feedback, tt.wantFeedback)
+			}
+			if tt.wantAdvance {
+				if got := record.Data["mood"].String(); got != tt.wantStored {
+					t.Fatalf("stored %q, want %q", got, tt.wantStored)
+				}
+				if _, ok := record.Transient[question.ID]; ok {
+					t.Fatalf("expected scratch to be dropped")
+				}
+				return
+			}
+
+			prompt, err := strategy.Render(renderCtx)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			var labels []string
+			for _, row := range prompt.Keyboard.Rows {
+				labels = append(labels, row[0].Label)
+			}
+			if got := strings.Join(labels, "|"); got != tt.wantLabels {
+				t.Fatalf("labels = %q, want %q", got, tt.wantLabels)
+			}
+			if done := prompt.Keyboard.Rows[len(labels)-1][0].Action; done != "answer:mood:done" {
+				t.Fatalf("unexpected done payload: %q", done)
+			}
+			if _, ok := record.Data["mood"]; ok && tt.stored.IsEmpty() {
+				t.Fatalf("selection must not be stored before done")
+			}
+		})
+	}
+}
+
+func TestMultiButtonsStrategyValidate(t *testing.T) {
+	strategy := NewMultiButtonsStrategy()
+	tests := []struct {
+		name    string
+		options []config.ButtonOption
+		wantErr bool
+	}{
+		{name: "valid", options: []config.ButtonOption{{Text: "A", Value: "a"}}},
+		{name: "no options", wantErr: true},
+		{name: "reserved value", options: []config.ButtonOption{{Text: "Done", Value: "done"}}, wantErr: true},
+		{name: "missing value", options: []config.ButtonOption{{Text: "A"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := strategy.Validate("sec", config.QuestionConfig{ID: "q", Type: TypeMultiButtons, Options: tt.options})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package questions
+
+import (
+	"fmt"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// StepHandler renders and handles one sub-step of a composite question.
+type StepHandler struct {
+	Render func(ctx RenderContext, scratch *state.QuestionScratch) (PromptSpec, error)
+	Handle func(ctx AnswerContext, input AnswerInput, scratch *state.QuestionScratch) (AnswerResult, error)
+}
+
+// StepMachine drives a composite question through named sub-steps. Handlers move between steps with
+// scratch.Step and keep intermediate values in scratch.Values; the scratch lives in Record.Transient,
+// never in Record.Data, and is dropped once the question advances.
+type StepMachine struct {
+	Initial string
+	Steps   map[string]StepHandler
+}
+
+// Render dispatches to the current step, starting at Initial.
+func (m StepMachine) Render(ctx RenderContext) (PromptSpec, error) {
+	scratch, handler, err := m.current(ctx)
+	if err != nil {
+		return PromptSpec{}, err
+	}
+	return handler.Render(ctx, scratch)
+}
+
+// HandleAnswer dispatches to the current step and clears the scratch when the question is done.
+func (m StepMachine) HandleAnswer(ctx AnswerContext, input AnswerInput) (AnswerResult, error) {
+	scratch, handler, err := m.current(ctx.RenderContext)
+	if err != nil {
+		return AnswerResult{}, err
+	}
+	result, err := handler.Handle(ctx, input, scratch)
+	if err == nil && result.Advance {
+		ctx.clearScratch()
+	}
+	return result, err
+}
+
+func (m StepMachine) current(ctx RenderContext) (*state.QuestionScratch, StepHandler, error) {
+	scratch, err := ctx.scratch()
+	if err != nil {
+		return nil, StepHandler{}, err
+	}
+	if scratch.Step == "" {
+		scratch.Step = m.Initial
+	}
+	handler, ok := m.Steps[scratch.Step]
+	if !ok {
+		return nil, StepHandler{}, fmt.Errorf("unknown step: %s", scratch.Step)
+	}
+	return scratch, handler, nil
+}
+
+// scratch returns the per-question scratch space of the draft, creating it on first use.
+func (ctx RenderContext) scratch() (*state.QuestionScratch, error) {
+	record, err := ctx.ensureRecord()
+	if err != nil {
+		return nil, err
+	}
+	if record.Transient == nil {
+		record.Transient = make(map[string]*state.QuestionScratch)
+	}
+	scratch, ok := record.Transient[ctx.Question.ID]
+	if !ok {
+		scratch = &state.QuestionScratch{Values: make(map[string]string)}
+		record.Transient[ctx.Question.ID] = scratch
+	}
+	return scratch, nil
+}
+
+func (ctx RenderContext) clearScratch() {
+	if ctx.Record != nil {
+		delete(ctx.Record.Transient, ctx.Question.ID)
+	}
+}
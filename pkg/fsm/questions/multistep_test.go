@@ -0,0 +1,59 @@
+package questions
+
+import (
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestStepMachineTransitionsAndCleanup(t *testing.T) {
+	machine := StepMachine{
+		Initial: "first",
+		Steps: map[string]StepHandler{
+			"first": {
+				Render: func(RenderContext, *state.QuestionScratch) (PromptSpec, error) { return PromptSpec{Text: "first"}, nil },
+				Handle: func(_ AnswerContext, input AnswerInput, scratch *state.QuestionScratch) (AnswerResult, error) {
+					scratch.Values["first"] = input.Text
+					scratch.Step = "second"
+					return AnswerResult{Repeat: true}, nil
+				},
+			},
+			"second": {
+				Render: func(_ RenderContext, scratch *state.QuestionScratch) (PromptSpec, error) {
+					return PromptSpec{Text: "second after " + scratch.Values["first"]}, nil
+				},
+				Handle: func(AnswerContext, AnswerInput, *state.QuestionScratch) (AnswerResult, error) {
+					return AnswerResult{Advance: true}, nil
+				},
+			},
+		},
+	}
+	record := state.NewRecord()
+	ctx := AnswerContext{RenderContext: RenderContext{Record: record, Question: config.QuestionConfig{ID: "q1"}}}
+
+	steps := []struct {
+		input      string
+		wantPrompt string
+		wantStep   string
+	}{
+		{input: "a", wantPrompt: "second after a", wantStep: "second"},
+		{input: "b", wantPrompt: "first", wantStep: ""},
+	}
+	for _, step := range steps {
+		if _, err := machine.HandleAnswer(ctx, AnswerInput{Source: InputSourceText, Text: step.input}); err != nil {
+			t.Fatalf("HandleAnswer(%q): %v", step.input, err)
+		}
+		gotStep := ""
+		if scratch, ok := record.Transient["q1"]; ok {
+			gotStep = scratch.Step
+		}
+		if gotStep != step.wantStep {
+			t.Fatalf("after %q step = %q, want %q", step.input, gotStep, step.wantStep)
+		}
+		prompt, err := machine.Render(ctx.RenderContext)
+		if err != nil || prompt.Text != step.wantPrompt {
+			t.Fatalf("after %q prompt = %q (%v), want %q", step.input, prompt.Text, err, step.wantPrompt)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package questions
+
+import (
+	"strconv"
+	"strings"
+)
+
+// numberWords maps spelled-out numbers up to 20, the largest rating_max, in Russian and English.
+var numberWords = map[string]int{
+	"ноль": 0, "один": 1, "одна": 1, "одно": 1, "два": 2, "две": 2, "три": 3, "четыре": 4, "пять": 5,
+	"шесть": 6, "семь": 7, "восемь": 8, "девять": 9, "десять": 10, "одиннадцать": 11, "двенадцать": 12,
+	"тринадцать": 13, "четырнадцать": 14, "пятнадцать": 15, "шестнадцать": 16, "семнадцать": 17,
+	"восемнадцать": 18, "девятнадцать": 19, "двадцать": 20,
+
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8,
+	"nine": 9, "ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15,
+	"sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19, "twenty": 20,
+}
+
+// parseTypedNumber reads a whole number typed as digits ("8") or spelled out ("восемь", "Eight!").
+func parseTypedNumber(text string) (int, bool) {
+	word := strings.ToLower(strings.TrimSpace(text))
+	word = strings.TrimRight(word, ".!")
+	word = strings.ReplaceAll(word, "ё", "е")
+	if n, err := strconv.Atoi(word); err == nil {
+		return n, true
+	}
+	n, ok := numberWords[word]
+	return n, ok
+}
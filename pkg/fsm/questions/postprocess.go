@@ -0,0 +1,117 @@
+package questions
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// textProcessor rewrites a single text value.
+type textProcessor func(cfg config.PostProcessorConfig, text string) string
+
+var textProcessors = map[string]textProcessor{
+	"trim": func(_ config.PostProcessorConfig, text string) string {
+		return strings.TrimSpace(text)
+	},
+	"lowercase": func(_ config.PostProcessorConfig, text string) string {
+		return strings.ToLower(text)
+	},
+	"strip_phone": stripPhone,
+	"round":       roundText,
+	"synonyms":    canonicalSynonym,
+}
+
+func validatePostProcessors(sectionID string, question config.QuestionConfig) error {
+	for i, cfg := range question.PostProcess {
+		if _, ok := textProcessors[cfg.Name]; !ok {
+			return fmt.Errorf("config validation failed: post_process #%d of question '%s' in section '%s' has unknown name '%s'", i+1, question.ID, sectionID, cfg.Name)
+		}
+		if cfg.Name == "synonyms" && len(cfg.Synonyms) == 0 {
+			return fmt.Errorf("config validation failed: post_process 'synonyms' of question '%s' in section '%s' has no synonyms", question.ID, sectionID)
+		}
+		if cfg.Name == "round" && cfg.Precision < 0 {
+			return fmt.Errorf("config validation failed: post_process 'round' of question '%s' in section '%s' has negative precision", question.ID, sectionID)
+		}
+	}
+	return nil
+}
+
+// postProcess applies the question's configured processors to every text carried by the answer.
+// Number answers only go through "round".
+func postProcess(question config.QuestionConfig, answer state.Answer) state.Answer {
+	for _, cfg := range question.PostProcess {
+		process, ok := textProcessors[cfg.Name]
+		if !ok {
+			continue
+		}
+		switch answer.Kind {
+		case state.AnswerString:
+			answer.Text = process(cfg, answer.Text)
+		case state.AnswerList:
+			items := make([]string, len(answer.List))
+			for i, item := range answer.List {
+				items[i] = process(cfg, item)
+			}
+			answer.List = items
+		case state.AnswerScored:
+			entries := make([]state.ScoredEntry, len(answer.Scored))
+			for i, entry := range answer.Scored {
+				entries[i] = state.ScoredEntry{Text: process(cfg, entry.Text), Score: entry.Score}
+			}
+			answer.Scored = entries
+		case state.AnswerNumber:
+			if cfg.Name == "round" {
+				answer.Number = roundTo(answer.Number, cfg.Precision)
+			}
+		}
+	}
+	return answer
+}
+
+// storeAnswer post-processes value and writes it to the draft; strategies call it instead of Record.SetAnswer.
+func storeAnswer(ctx RenderContext, value state.Answer) error {
+	record, err := ctx.ensureRecord()
+	if err != nil {
+		return err
+	}
+	return record.SetAnswer(ctx.Question, postProcess(ctx.Question, value))
+}
+
+// stripPhone keeps the digits of a phone number and a leading "+".
+func stripPhone(_ config.PostProcessorConfig, text string) string {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(text) {
+		if unicode.IsDigit(r) || (i == 0 && r == '+') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// roundText rounds numeric text (a decimal comma is accepted) and leaves anything else untouched.
+func roundText(cfg config.PostProcessorConfig, text string) string {
+	n, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(text), ",", ".", 1), 64)
+	if err != nil {
+		return text
+	}
+	return strconv.FormatFloat(roundTo(n, cfg.Precision), 'f', -1, 64)
+}
+
+func roundTo(n float64, precision int) float64 {
+	scale := math.Pow(10, float64(precision))
+	return math.Round(n*scale) / scale
+}
+
+func canonicalSynonym(cfg config.PostProcessorConfig, text string) string {
+	for variant, canonical := range cfg.Synonyms {
+		if strings.EqualFold(strings.TrimSpace(text), variant) {
+			return canonical
+		}
+	}
+	return text
+}
@@ -0,0 +1,40 @@
+package questions
+
+import (
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestPostProcess(t *testing.T) {
+	tests := []struct {
+		name       string
+		processors []config.PostProcessorConfig
+		answer     state.Answer
+		want       state.Answer
+	}{
+		{name: "trim and lowercase", processors: []config.PostProcessorConfig{{Name: "trim"}, {Name: "lowercase"}}, answer: state.StringAnswer("  Hello "), want: state.StringAnswer("hello")},
+		{name: "strip phone", processors: []config.PostProcessorConfig{{Name: "strip_phone"}}, answer: state.StringAnswer("+7 (912) 345-67-89"), want: state.StringAnswer("+79123456789")},
+		{name: "round numeric text", processors: []config.PostProcessorConfig{{Name: "round", Precision: 1}}, answer: state.StringAnswer("3,14159"), want: state.StringAnswer("3.1")},
+		{name: "round leaves words", processors: []config.PostProcessorConfig{{Name: "round"}}, answer: state.StringAnswer("many"), want: state.StringAnswer("many")},
+		{name: "round number", processors: []config.PostProcessorConfig{{Name: "round"}}, answer: state.NumberAnswer(2.6), want: state.NumberAnswer(3)},
+		{name: "synonyms", processors: []config.PostProcessorConfig{{Name: "synonyms", Synonyms: map[string]string{"ага": "да", "yes": "да"}}}, answer: state.StringAnswer("Ага"), want: state.StringAnswer("да")},
+		{name: "list items", processors: []config.PostProcessorConfig{{Name: "trim"}}, answer: state.ListAnswer(" a", "b "), want: state.ListAnswer("a", "b")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := postProcess(config.QuestionConfig{PostProcess: tt.processors}, tt.answer)
+			if !got.Equal(tt.want) {
+				t.Fatalf("postProcess() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePostProcessorsRejectsUnknown(t *testing.T) {
+	question := config.QuestionConfig{ID: "q1", PostProcess: []config.PostProcessorConfig{{Name: "shout"}}}
+	if err := validatePostProcessors("s", question); err == nil {
+		t.Fatalf("expected unknown post-processor to be rejected")
+	}
+}
@@ -0,0 +1,124 @@
+package questions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// maxRatingPoints keeps the scale on one keyboard row; Telegram shows at most 8 buttons side by side.
+const maxRatingPoints = 8
+
+type ratingStrategy struct{}
+
+// NewRatingStrategy returns a QuestionStrategy for a one-tap scale: a single row of numbers or labels
+// (e.g. 😞 … 😄) storing the picked number with its label.
+func NewRatingStrategy() QuestionStrategy {
+	return &ratingStrategy{}
+}
+
+func (s *ratingStrategy) Name() string {
+	return TypeRating
+}
+
+func (s *ratingStrategy) Capabilities() Capabilities {
+	return Capabilities{NeedsCallbacks: true, EditInPlace: true, AnswerKind: state.AnswerNumber}
+}
+
+func (s *ratingStrategy) Validate(sectionID string, question config.QuestionConfig) error {
+	if len(question.Options) > 0 {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' is type 'rating' but has options defined; use labels", question.ID, sectionID)
+	}
+	if question.RatingMin < 0 || question.RatingMax < 0 {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' has a negative rating_min/rating_max", question.ID, sectionID)
+	}
+	minRating, maxRating := question.RatingScale()
+	points := maxRating - minRating + 1
+	if points < 2 || points > maxRatingPoints {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' has a scale of %d points from %d to %d, want 2 to %d", question.ID, sectionID, points, minRating, maxRating, maxRatingPoints)
+	}
+	if len(question.Labels) > 0 && len(question.Labels) != points {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' has %d labels for %d points from %d to %d", question.ID, sectionID, len(question.Labels), points, minRating, maxRating)
+	}
+	for idx, label := range question.Labels {
+		if strings.TrimSpace(label) == "" {
+			return fmt.Errorf("config validation failed: label #%d of question '%s' in section '%s' is empty", idx+1, question.ID, sectionID)
+		}
+	}
+	return nil
+}
+
+func (s *ratingStrategy) CallbackValues(question config.QuestionConfig) []string {
+	minRating, maxRating := question.RatingScale()
+	values := make([]string, 0, maxRating-minRating+1)
+	for i := minRating; i <= maxRating; i++ {
+		values = append(values, strconv.Itoa(i))
+	}
+	return values
+}
+
+func (s *ratingStrategy) Render(ctx RenderContext) (PromptSpec, error) {
+	minRating, maxRating := ctx.Question.RatingScale()
+	row := make([]botport.Button, 0, maxRating-minRating+1)
+	for i := minRating; i <= maxRating; i++ {
+		data := fmt.Sprintf("%s%s:%d", ctx.CallbackPrefix, ctx.Question.ID, i)
+		row = append(row, botport.NewButton(ratingLabel(ctx.Question, i), data))
+	}
+	text := ctx.Question.Prompt
+	if config.FeatureEnabled(config.FeatureTypedRatings) {
+		text += "\n" + ctx.message(config.MsgRatingTypeHint)
+	}
+	return PromptSpec{
+		Text:     text,
+		Keyboard: botport.NewKeyboard(row),
+	}, nil
+}
+
+func (s *ratingStrategy) HandleAnswer(ctx AnswerContext, input AnswerInput) (AnswerResult, error) {
+	minRating, maxRating := ctx.Question.RatingScale()
+	var rating int
+	switch {
+	case input.Source == InputSourceCallback:
+		n, err := strconv.Atoi(input.CallbackData)
+		if err != nil {
+			return AnswerResult{Repeat: true, Feedback: ctx.message(config.MsgOptionUnavailable)}, nil
+		}
+		rating = n
+	case config.FeatureEnabled(config.FeatureTypedRatings):
+		n, ok := parseTypedNumber(input.Text)
+		if !ok {
+			return AnswerResult{
+				Repeat:   true,
+				Feedback: ctx.message(config.MsgRatingNotANumber, strings.TrimSpace(input.Text), minRating, maxRating),
+			}, nil
+		}
+		rating = n
+	default:
+		return AnswerResult{Repeat: true, Feedback: ctx.message(config.MsgRatingUseButtons)}, nil
+	}
+
+	if rating < minRating || rating > maxRating {
+		return AnswerResult{Repeat: true, Feedback: ctx.message(config.MsgRatingOutOfRange, minRating, maxRating)}, nil
+	}
+	label := ""
+	if len(ctx.Question.Labels) > 0 {
+		label = ctx.Question.Labels[rating-minRating]
+	}
+	if err := storeAnswer(ctx.RenderContext, state.RatingAnswer(rating, label)); err != nil {
+		return AnswerResult{}, err
+	}
+	return AnswerResult{Advance: true}, nil
+}
+
+// ratingLabel is the button text of point n: its label, or the number itself.
+func ratingLabel(question config.QuestionConfig, n int) string {
+	minRating, _ := question.RatingScale()
+	if i := n - minRating; i >= 0 && i < len(question.Labels) {
+		return question.Labels[i]
+	}
+	return strconv.Itoa(n)
+}
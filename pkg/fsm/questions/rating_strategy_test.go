@@ -0,0 +1,133 @@
+package questions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+var moodLabels = []string{"😞", "🙁", "😐", "🙂", "😄"}
+
+func TestRatingStrategyValidate(t *testing.T) {
+	strategy := NewRatingStrategy()
+	tests := []struct {
+		name     string
+		question config.QuestionConfig
+		wantErr  string
+	}{
+		{name: "default scale", question: config.QuestionConfig{ID: "q"}},
+		{name: "labels set the scale", question: config.QuestionConfig{ID: "q", Labels: moodLabels}},
+		{name: "labels over an explicit range", question: config.QuestionConfig{ID: "q", RatingMin: 3, RatingMax: 7, Labels: moodLabels}},
+		{name: "options", question: config.QuestionConfig{ID: "q", Options: []config.ButtonOption{{Text: "A", Value: "a"}}}, wantErr: "use labels"},
+		{name: "single point", question: config.QuestionConfig{ID: "q", RatingMin: 3, RatingMax: 3}, wantErr: "scale of 1 points"},
+		{name: "reversed", question: config.QuestionConfig{ID: "q", RatingMin: 5, RatingMax: 2}, wantErr: "scale of -2 points"},
+		{name: "too wide for a row", question: config.QuestionConfig{ID: "q", RatingMax: 10}, wantErr: "scale of 10 points"},
+		{name: "negative", question: config.QuestionConfig{ID: "q", RatingMin: -1}, wantErr: "negative"},
+		{name: "labels do not match the range", question: config.QuestionConfig{ID: "q", RatingMax: 3, Labels: moodLabels}, wantErr: "5 labels for 3 points"},
+		{name: "empty label", question: config.QuestionConfig{ID: "q", Labels: []string{"low", " "}}, wantErr: "label #2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := strategy.Validate("s", tt.question)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRatingStrategyRender(t *testing.T) {
+	strategy := NewRatingStrategy()
+	tests := []struct {
+		name       string
+		question   config.QuestionConfig
+		wantLabels []string
+		wantLast   string
+	}{
+		{name: "numbers", question: config.QuestionConfig{ID: "mood", Prompt: "Mood?"}, wantLabels: []string{"1", "2", "3", "4", "5"}, wantLast: "answer:mood:5"},
+		{name: "labels", question: config.QuestionConfig{ID: "mood", Prompt: "Mood?", RatingMin: 0, Labels: moodLabels}, wantLabels: moodLabels, wantLast: "answer:mood:5"},
+		{name: "shifted range", question: config.QuestionConfig{ID: "mood", Prompt: "Mood?", RatingMin: 3, RatingMax: 4}, wantLabels: []string{"3", "4"}, wantLast: "answer:mood:4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := state.NewRecord()
+			prompt, err := strategy.Render(RenderContext{UserState: &state.UserState{CurrentRecord: record}, Record: record, Question: tt.question, CallbackPrefix: "answer:"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if prompt.Text != "Mood?" || prompt.Keyboard == nil || len(prompt.Keyboard.Rows) != 1 {
+				t.Fatalf("expected the prompt with a single row, got %q %+v", prompt.Text, prompt.Keyboard)
+			}
+			row := prompt.Keyboard.Rows[0]
+			var labels []string
+			for _, button := range row {
+				labels = append(labels, button.Label)
+			}
+			if strings.Join(labels, " ") != strings.Join(tt.wantLabels, " ") {
+				t.Fatalf("labels %v, want %v", labels, tt.wantLabels)
+			}
+			if got := row[len(row)-1].Action; got != tt.wantLast {
+				t.Fatalf("last button sends %q, want %q", got, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestRatingStrategyHandleAnswer(t *testing.T) {
+	strategy := NewRatingStrategy()
+	tests := []struct {
+		name        string
+		question    config.QuestionConfig
+		input       AnswerInput
+		typed       bool // typed_ratings feature flag
+		wantAdvance bool
+		want        state.Answer
+		wantReply   config.MessageKey
+	}{
+		{name: "labelled tap", question: config.QuestionConfig{Labels: moodLabels}, input: AnswerInput{Source: InputSourceCallback, CallbackData: "4"}, wantAdvance: true, want: state.RatingAnswer(4, "🙂")},
+		{name: "plain tap", question: config.QuestionConfig{RatingMin: 2, RatingMax: 6}, input: AnswerInput{Source: InputSourceCallback, CallbackData: "2"}, wantAdvance: true, want: state.RatingAnswer(2, "")},
+		{name: "out of range", question: config.QuestionConfig{Labels: moodLabels}, input: AnswerInput{Source: InputSourceCallback, CallbackData: "6"}, wantReply: config.MsgRatingOutOfRange},
+		{name: "not a number", question: config.QuestionConfig{}, input: AnswerInput{Source: InputSourceCallback, CallbackData: "x"}, wantReply: config.MsgOptionUnavailable},
+		{name: "typed without the flag", question: config.QuestionConfig{}, input: AnswerInput{Source: InputSourceText, Text: "3"}, wantReply: config.MsgRatingUseButtons},
+		{name: "typed word", question: config.QuestionConfig{Labels: moodLabels}, input: AnswerInput{Source: InputSourceText, Text: "три"}, typed: true, wantAdvance: true, want: state.RatingAnswer(3, "😐")},
+		{name: "typed nonsense", question: config.QuestionConfig{}, input: AnswerInput{Source: InputSourceText, Text: "meh"}, typed: true, wantReply: config.MsgRatingNotANumber},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer config.SetFeature(config.FeatureTypedRatings, false)
+			if err := config.SetFeature(config.FeatureTypedRatings, tt.typed); err != nil {
+				t.Fatalf("SetFeature: %v", err)
+			}
+
+			question := tt.question
+			question.ID, question.Type, question.StoreKey = "mood", TypeRating, "mood"
+			record := state.NewRecord()
+			ctx := AnswerContext{RenderContext: RenderContext{UserState: &state.UserState{CurrentRecord: record}, Record: record, Question: question}}
+
+			result, err := strategy.HandleAnswer(ctx, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Advance != tt.wantAdvance {
+				t.Fatalf("Advance = %t, want %t (feedback %q)", result.Advance, tt.wantAdvance, result.Feedback)
+			}
+			if got := record.Data["mood"]; !got.Equal(tt.want) {
+				t.Fatalf("stored %+v, want %+v", got, tt.want)
+			}
+			if tt.wantReply != "" {
+				prefix, _, _ := strings.Cut(config.Message("ru", tt.wantReply), "%")
+				if !result.Repeat || !strings.HasPrefix(result.Feedback, prefix) {
+					t.Fatalf("feedback %q, want %s", result.Feedback, tt.wantReply)
+				}
+			}
+		})
+	}
+}
@@ -2,9 +2,12 @@ package questions
 
 import (
 	"fmt"
-	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"sort"
 	"strings"
 	"sync"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
 )
 
 var (
@@ -22,21 +25,75 @@ func RegisterBuiltins() {
 		registerStrategy(NewTextStrategy())
 		registerStrategy(NewButtonsStrategy())
 		registerStrategy(NewTextRatingStrategy())
+		registerStrategy(NewMultiButtonsStrategy())
+		registerStrategy(NewRatingStrategy())
 	})
 }
 
 func registerValidator() {
 	validatorOnce.Do(func() {
-		config.RegisterQuestionValidator(func(sectionID string, question config.QuestionConfig) error {
-			strat := Get(question.Type)
+		config.RegisterQuestionValidator(validateQuestion)
+		state.RegisterAnswerKindResolver(func(questionType string) (state.AnswerKind, bool) {
+			strat := Get(questionType)
 			if strat == nil {
-				return fmt.Errorf("config validation failed: question '%s' in section '%s' has unknown type '%s'", question.ID, sectionID, question.Type)
+				return "", false
 			}
-			return strat.Validate(sectionID, question)
+			return strat.Capabilities().AnswerKind, true
 		})
 	})
 }
 
+// validateQuestion checks question against the strategy registered for its type.
+func validateQuestion(sectionID string, question config.QuestionConfig) error {
+	strat := Get(question.Type)
+	if strat == nil {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' has unknown type '%s'", question.ID, sectionID, question.Type)
+	}
+	if err := strat.Validate(sectionID, question); err != nil {
+		return err
+	}
+	if err := validatePostProcessors(sectionID, question); err != nil {
+		return err
+	}
+	if err := validateEntryLimits(strat, sectionID, question); err != nil {
+		return err
+	}
+	if question.Validation.LengthLimited() && !strat.Capabilities().ValidatesLength {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' sets validation lengths, which type '%s' does not support", question.ID, sectionID, question.Type)
+	}
+	return validateCallbackPayloads(strat, sectionID, question)
+}
+
+// validateEntryLimits accepts min_entries/max_entries only on repeatable types, with min not above max.
+func validateEntryLimits(strat QuestionStrategy, sectionID string, question config.QuestionConfig) error {
+	if question.MinEntries == 0 && question.MaxEntries == 0 {
+		return nil
+	}
+	if !strat.Capabilities().Repeatable {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' sets min_entries/max_entries, which type '%s' does not support", question.ID, sectionID, question.Type)
+	}
+	if question.MinEntries < 0 || question.MaxEntries < 0 {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' has negative min_entries/max_entries", question.ID, sectionID)
+	}
+	if question.MaxEntries > 0 && question.MinEntries > question.MaxEntries {
+		return fmt.Errorf("config validation failed: question '%s' in section '%s' has min_entries %d above max_entries %d", question.ID, sectionID, question.MinEntries, question.MaxEntries)
+	}
+	return nil
+}
+
+func validateCallbackPayloads(strat QuestionStrategy, sectionID string, question config.QuestionConfig) error {
+	producer, ok := strat.(CallbackProducer)
+	if !ok {
+		return nil
+	}
+	for _, value := range producer.CallbackValues(question) {
+		if err := config.ValidateCallbackData(AnswerCallbackData(question.ID, value)); err != nil {
+			return fmt.Errorf("config validation failed: question '%s' in section '%s': %w", question.ID, sectionID, err)
+		}
+	}
+	return nil
+}
+
 func registerStrategy(strategy QuestionStrategy) {
 	if strategy == nil {
 		panic("cannot register nil strategy")
@@ -58,7 +115,7 @@ func MustRegister(strategy QuestionStrategy) {
 		panic(fmt.Sprintf("question strategy '%s' already registered", strategy.Name()))
 	}
 
-	registry[key] = strategy
+	registry[key] = withSkip(strategy)
 }
 
 // Get returns the strategy for the given type, or nil when absent.
@@ -70,6 +127,19 @@ func Get(name string) QuestionStrategy {
 	return registry[key]
 }
 
+// Names lists the registered question types, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // MustGet returns the registered strategy, panicking when it is missing.
 func MustGet(name string) QuestionStrategy {
 	strat := Get(name)
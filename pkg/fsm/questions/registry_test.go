@@ -1,8 +1,11 @@
 package questions
 
 import (
-	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"strings"
 	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
 )
 
 type fakeStrategy struct {
@@ -19,6 +22,9 @@ func (f *fakeStrategy) Render(RenderContext) (PromptSpec, error) {
 func (f *fakeStrategy) HandleAnswer(AnswerContext, AnswerInput) (AnswerResult, error) {
 	return AnswerResult{Advance: true}, nil
 }
+func (f *fakeStrategy) Capabilities() Capabilities {
+	return Capabilities{NeedsText: true, AnswerKind: state.AnswerString}
+}
 
 func TestMustRegisterPanicsOnDuplicate(t *testing.T) {
 	resetRegistryForTests()
@@ -45,3 +51,61 @@ func TestGetReturnsRegisteredStrategy(t *testing.T) {
 		t.Fatalf("expected to retrieve registered strategy got=%v", got)
 	}
 }
+
+func TestValidatorRejectsOversizedCallbackData(t *testing.T) {
+	resetRegistryForTests()
+	RegisterBuiltins()
+
+	tests := []struct {
+		name     string
+		question config.QuestionConfig
+		wantErr  bool
+	}{
+		{name: "short option", question: config.QuestionConfig{ID: "mood", Prompt: "?", Type: TypeButtons, StoreKey: "mood", Options: []config.ButtonOption{{Text: "Ok", Value: "ok"}}}},
+		{name: "long option", question: config.QuestionConfig{ID: "mood", Prompt: "?", Type: TypeButtons, StoreKey: "mood", Options: []config.ButtonOption{{Text: "Ok", Value: strings.Repeat("v", 60)}}}, wantErr: true},
+		{name: "rating range fits", question: config.QuestionConfig{ID: strings.Repeat("q", 40), Prompt: "?", Type: "text_rating", StoreKey: "r", RatingMax: 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{"s": {Title: "S", Questions: []config.QuestionConfig{tt.question}}}}
+			err := rc.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorEntryLimits(t *testing.T) {
+	resetRegistryForTests()
+	RegisterBuiltins()
+
+	tests := []struct {
+		name     string
+		question config.QuestionConfig
+		wantErr  string
+	}{
+		{name: "limits on text_rating", question: config.QuestionConfig{ID: "r", Prompt: "?", Type: "text_rating", StoreKey: "r", MinEntries: 1, MaxEntries: 3}},
+		{name: "only max", question: config.QuestionConfig{ID: "r", Prompt: "?", Type: "text_rating", StoreKey: "r", MaxEntries: 2}},
+		{name: "min above max", question: config.QuestionConfig{ID: "r", Prompt: "?", Type: "text_rating", StoreKey: "r", MinEntries: 3, MaxEntries: 2}, wantErr: "min_entries 3 above max_entries 2"},
+		{name: "negative", question: config.QuestionConfig{ID: "r", Prompt: "?", Type: "text_rating", StoreKey: "r", MinEntries: -1}, wantErr: "negative"},
+		{name: "not repeatable", question: config.QuestionConfig{ID: "t", Prompt: "?", Type: TypeText, StoreKey: "t", MaxEntries: 2}, wantErr: "does not support"},
+		{name: "length bounds on text", question: config.QuestionConfig{ID: "t", Prompt: "?", Type: TypeText, StoreKey: "t", Validation: &config.ValidationConfig{MaxLength: 20}}},
+		{name: "length bounds on text_rating", question: config.QuestionConfig{ID: "r", Prompt: "?", Type: "text_rating", StoreKey: "r", Validation: &config.ValidationConfig{MinLength: 5}}, wantErr: "sets validation lengths"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{"s": {Title: "S", Questions: []config.QuestionConfig{tt.question}}}}
+			err := rc.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
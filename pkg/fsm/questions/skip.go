@@ -0,0 +1,78 @@
+package questions
+
+import (
+	"fmt"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+// SkipCallbackValue is the answer value of the "Skip" button of optional questions. Options may not use it.
+const SkipCallbackValue = "_skip"
+
+// skippable decorates a strategy so that optional questions get a "Skip" button under the strategy's
+// own keyboard. Skipping advances without storing a value; an earlier answer stays as it was.
+// Required questions pass straight through to the wrapped strategy.
+type skippable struct {
+	QuestionStrategy
+}
+
+// withSkip wraps strategy unless it is already wrapped.
+func withSkip(strategy QuestionStrategy) QuestionStrategy {
+	if _, ok := strategy.(skippable); ok {
+		return strategy
+	}
+	return skippable{QuestionStrategy: strategy}
+}
+
+func (s skippable) Validate(sectionID string, question config.QuestionConfig) error {
+	if err := s.QuestionStrategy.Validate(sectionID, question); err != nil {
+		return err
+	}
+	if !question.Optional {
+		return nil
+	}
+	for _, value := range s.innerCallbackValues(question) {
+		if value == SkipCallbackValue {
+			return fmt.Errorf("config validation failed: optional question '%s' in section '%s' uses the reserved value '%s'", question.ID, sectionID, SkipCallbackValue)
+		}
+	}
+	return nil
+}
+
+// CallbackValues adds the skip value of optional questions to the wrapped strategy's values.
+func (s skippable) CallbackValues(question config.QuestionConfig) []string {
+	values := s.innerCallbackValues(question)
+	if question.Optional {
+		values = append(values, SkipCallbackValue)
+	}
+	return values
+}
+
+func (s skippable) innerCallbackValues(question config.QuestionConfig) []string {
+	producer, ok := s.QuestionStrategy.(CallbackProducer)
+	if !ok {
+		return nil
+	}
+	return producer.CallbackValues(question)
+}
+
+func (s skippable) Render(ctx RenderContext) (PromptSpec, error) {
+	prompt, err := s.QuestionStrategy.Render(ctx)
+	if err != nil || !ctx.Question.Optional {
+		return prompt, err
+	}
+	if prompt.Keyboard == nil {
+		prompt.Keyboard = botport.NewKeyboard()
+	}
+	prompt.Keyboard.AddRow(botport.NewButton(ctx.message(config.MsgButtonSkip), AnswerCallbackData(ctx.Question.ID, SkipCallbackValue)))
+	return prompt, nil
+}
+
+func (s skippable) HandleAnswer(ctx AnswerContext, input AnswerInput) (AnswerResult, error) {
+	if ctx.Question.Optional && input.Source == InputSourceCallback && input.CallbackData == SkipCallbackValue {
+		ctx.clearScratch()
+		return AnswerResult{Advance: true}, nil
+	}
+	return s.QuestionStrategy.HandleAnswer(ctx, input)
+}
@@ -0,0 +1,111 @@
+package questions
+
+import (
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestSkippableQuestions(t *testing.T) {
+	resetRegistryForTests()
+	RegisterBuiltins()
+
+	options := []config.ButtonOption{{Text: "A", Value: "a"}, {Text: "B", Value: "b"}}
+	tests := []struct {
+		name     string
+		question config.QuestionConfig
+	}{
+		{name: "text", question: config.QuestionConfig{ID: "q", Type: TypeText, Prompt: "?", StoreKey: "k"}},
+		{name: "buttons", question: config.QuestionConfig{ID: "q", Type: TypeButtons, Prompt: "?", StoreKey: "k", Options: options}},
+		{name: "text_rating", question: config.QuestionConfig{ID: "q", Type: "text_rating", Prompt: "?", StoreKey: "k"}},
+		{name: "multi_buttons", question: config.QuestionConfig{ID: "q", Type: TypeMultiButtons, Prompt: "?", StoreKey: "k", Options: options}},
+	}
+	skip := AnswerCallbackData("q", SkipCallbackValue)
+
+	for _, tt := range tests {
+		for _, optional := range []bool{false, true} {
+			question := tt.question
+			question.Optional = optional
+			name := tt.name
+			if optional {
+				name += " optional"
+			}
+			t.Run(name, func(t *testing.T) {
+				strategy := MustGet(question.Type)
+				record := state.NewRecord()
+				record.Data["k"] = state.StringAnswer("earlier")
+				ctx := RenderContext{
+					UserState:      &state.UserState{CurrentRecord: record},
+					Record:         record,
+					SectionID:      "s",
+					Question:       question,
+					CallbackPrefix: AnswerCallbackPrefix,
+				}
+
+				prompt, err := strategy.Render(ctx)
+				if err != nil {
+					t.Fatalf("unexpected render error: %v", err)
+				}
+				if got := hasAction(prompt, skip); got != optional {
+					t.Fatalf("optional=%t: skip button shown=%t", optional, got)
+				}
+				if !optional {
+					return
+				}
+
+				result, err := strategy.HandleAnswer(AnswerContext{RenderContext: ctx}, AnswerInput{Source: InputSourceCallback, CallbackData: SkipCallbackValue})
+				if err != nil {
+					t.Fatalf("unexpected answer error: %v", err)
+				}
+				if !result.Advance || result.Repeat || result.Feedback != "" {
+					t.Fatalf("skip result %+v, want a silent advance", result)
+				}
+				if got := record.Data["k"].String(); got != "earlier" {
+					t.Fatalf("skip changed the stored answer to %q", got)
+				}
+				if _, ok := record.Transient["q"]; ok {
+					t.Fatalf("skip left the question's scratch behind")
+				}
+			})
+		}
+	}
+}
+
+func TestValidatorRejectsReservedSkipValue(t *testing.T) {
+	resetRegistryForTests()
+	RegisterBuiltins()
+
+	reserved := []config.ButtonOption{{Text: "Skip", Value: SkipCallbackValue}}
+	tests := []struct {
+		name     string
+		question config.QuestionConfig
+		wantErr  bool
+	}{
+		{name: "required", question: config.QuestionConfig{ID: "q", Prompt: "?", Type: TypeButtons, StoreKey: "k", Options: reserved}},
+		{name: "optional", question: config.QuestionConfig{ID: "q", Prompt: "?", Type: TypeButtons, StoreKey: "k", Options: reserved, Optional: true}, wantErr: true},
+		{name: "optional text", question: config.QuestionConfig{ID: "q", Prompt: "?", Type: TypeText, StoreKey: "k", Optional: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{"s": {Title: "S", Questions: []config.QuestionConfig{tt.question}}}}
+			if err := rc.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func hasAction(prompt PromptSpec, action string) bool {
+	if prompt.Keyboard == nil {
+		return false
+	}
+	for _, row := range prompt.Keyboard.Rows {
+		for _, button := range row {
+			if button.Action == action {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,11 +2,12 @@ package questions
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
-
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // BotPort exposes outbound messaging helpers via the shared ports package.
@@ -18,6 +19,84 @@ type QuestionStrategy interface {
 	Validate(sectionID string, question config.QuestionConfig) error
 	Render(RenderContext) (PromptSpec, error)
 	HandleAnswer(AnswerContext, AnswerInput) (AnswerResult, error)
+	Capabilities() Capabilities
+}
+
+// Capabilities declares what a strategy needs from the transport and how its prompts may be shown.
+type Capabilities struct {
+	NeedsText           bool             // Accepts free-form text replies
+	NeedsCallbacks      bool             // Renders inline buttons
+	ProducesAttachments bool             // Sends or expects files/media
+	EditInPlace         bool             // Prompts may replace the previous message instead of sending a new one
+	Repeatable          bool             // Collects several entries; honours min_entries/max_entries
+	ValidatesLength     bool             // Honours the validation block's min_length/max_length
+	AnswerKind          state.AnswerKind // Kind of value stored in Record.Data
+}
+
+// CheckTransport verifies that transport supports every question type used by recordConfig.
+func CheckTransport(recordConfig *config.RecordConfig, transport botport.Capabilities) error {
+	var problems []string
+	for sectionID, section := range recordConfig.Sections {
+		for _, question := range section.Questions {
+			strat := Get(question.Type)
+			if strat == nil {
+				continue
+			}
+			caps := strat.Capabilities()
+			var missing []string
+			if caps.NeedsText && !transport.Text {
+				missing = append(missing, "text")
+			}
+			if caps.NeedsCallbacks && !transport.Callbacks {
+				missing = append(missing, "callbacks")
+			}
+			if caps.ProducesAttachments && !transport.Attachments {
+				missing = append(missing, "attachments")
+			}
+			if len(missing) > 0 {
+				problems = append(problems, fmt.Sprintf("question '%s' in section '%s' (type '%s') needs %s", question.ID, sectionID, question.Type, strings.Join(missing, ", ")))
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("transport does not support configured questions: %s", strings.Join(problems, "; "))
+}
+
+// CheckStrategies verifies that every question of recordConfig has a registered strategy and passes its
+// validation, so a type the binary lacks fails at boot rather than mid-survey. Unlike the config
+// loader, which may run before RegisterBuiltins, it always goes through the registry, and it reports
+// every problem at once.
+func CheckStrategies(recordConfig *config.RecordConfig) error {
+	var problems []string
+	for sectionID, section := range recordConfig.Sections {
+		for _, question := range section.Questions {
+			if err := validateQuestion(sectionID, question); err != nil {
+				problems = append(problems, strings.TrimPrefix(err.Error(), "config validation failed: "))
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("%d question(s) do not match the registered question types (%s):\n- %s", len(problems), strings.Join(Names(), ", "), strings.Join(problems, "\n- "))
+}
+
+// CallbackProducer is implemented by strategies that render inline buttons. CallbackValues lists every
+// value a question can put after "answer:<questionID>:", so payload sizes can be checked at config load.
+type CallbackProducer interface {
+	CallbackValues(question config.QuestionConfig) []string
+}
+
+// AnswerCallbackPrefix prefixes the callback data of every answer button.
+const AnswerCallbackPrefix = "answer:"
+
+// AnswerCallbackData builds the callback payload of an answer button.
+func AnswerCallbackData(questionID, value string) string {
+	return AnswerCallbackPrefix + questionID + config.CallbackDelimiter + value
 }
 
 // RenderContext captures dependencies for prompt generation.
@@ -44,7 +123,7 @@ type AnswerContext struct {
 // PromptSpec defines the text and markup returned by strategies.
 type PromptSpec struct {
 	Text     string
-	Keyboard *tgbotapi.InlineKeyboardMarkup
+	Keyboard *botport.Keyboard
 	ForceNew bool
 }
 
@@ -57,8 +136,10 @@ const (
 )
 
 const (
-	TypeText    = "text"
-	TypeButtons = "buttons"
+	TypeText         = "text"
+	TypeButtons      = "buttons"
+	TypeMultiButtons = "multi_buttons"
+	TypeRating       = "rating"
 )
 
 // AnswerInput wraps user responses in a transport-agnostic struct.
@@ -76,12 +157,17 @@ type AnswerResult struct {
 	Feedback string
 }
 
+// message renders a catalog message in the user's language.
+func (ctx RenderContext) message(key config.MessageKey, args ...any) string {
+	return config.Message(ctx.UserState.Lang(), key, args...)
+}
+
 func (ctx RenderContext) ensureRecord() (*state.Record, error) {
 	if ctx.Record == nil {
 		return nil, fmt.Errorf("record is nil")
 	}
 	if ctx.Record.Data == nil {
-		ctx.Record.Data = make(map[string]string)
+		ctx.Record.Data = make(map[string]state.Answer)
 	}
 	return ctx.Record, nil
 }
@@ -0,0 +1,104 @@
+package questions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+func TestCheckTransport(t *testing.T) {
+	RegisterBuiltins()
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"s": {Title: "S", Questions: []config.QuestionConfig{
+			{ID: "q1", Prompt: "?", Type: TypeText, StoreKey: "k1"},
+			{ID: "q2", Prompt: "?", Type: TypeButtons, StoreKey: "k2", Options: []config.ButtonOption{{Text: "A", Value: "a"}}},
+		}},
+	}}
+
+	tests := []struct {
+		name      string
+		transport botport.Capabilities
+		wantErr   string
+	}{
+		{name: "full support", transport: botport.Capabilities{Text: true, Callbacks: true}},
+		{name: "no callbacks", transport: botport.Capabilities{Text: true}, wantErr: "question 'q2' in section 's' (type 'buttons') needs callbacks"},
+		{name: "nothing", transport: botport.Capabilities{}, wantErr: "'q1' in section 's' (type 'text') needs text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTransport(rc, tt.transport)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckStrategies(t *testing.T) {
+	resetRegistryForTests()
+	RegisterBuiltins()
+	options := []config.ButtonOption{{Text: "A", Value: "a"}}
+
+	tests := []struct {
+		name     string
+		sections map[string]config.SectionConfig
+		wantErr  []string // Lines the report must contain; none for a compatible config
+	}{
+		{
+			name: "compatible",
+			sections: map[string]config.SectionConfig{
+				"s": {Title: "S", Questions: []config.QuestionConfig{
+					{ID: "q1", Prompt: "?", Type: TypeText, StoreKey: "k1"},
+					{ID: "q2", Prompt: "?", Type: TypeButtons, StoreKey: "k2", Options: options},
+					{ID: "q3", Prompt: "?", Type: TypeRating, StoreKey: "k3"},
+				}},
+			},
+		},
+		{
+			name: "every problem is reported",
+			sections: map[string]config.SectionConfig{
+				"a": {Title: "A", Questions: []config.QuestionConfig{
+					{ID: "q1", Prompt: "?", Type: "slider", StoreKey: "k1"},
+					{ID: "q2", Prompt: "?", Type: TypeText, StoreKey: "k2"},
+				}},
+				"b": {Title: "B", Questions: []config.QuestionConfig{
+					{ID: "q3", Prompt: "?", Type: TypeButtons, StoreKey: "k3"},
+					{ID: "q4", Prompt: "?", Type: TypeRating, StoreKey: "k4", Options: options},
+				}},
+			},
+			wantErr: []string{
+				"3 question(s) do not match the registered question types (buttons, multi_buttons, rating, text, text_rating):",
+				"\n- question 'q1' in section 'a' has unknown type 'slider'",
+				"\n- question 'q3' in section 'b' is type 'buttons' but has no options",
+				"\n- question 'q4' in section 'b' is type 'rating' but has options defined",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckStrategies(&config.RecordConfig{Sections: tt.sections})
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("error = %v, want containing %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -2,29 +2,48 @@ package questions
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 const (
 	stepCollectText   = "text"
 	stepCollectRating = "rating"
 	stepNextOrFinish  = "next_finish"
+
+	scratchText   = "text"
+	scratchRating = "rating"
 )
 
-type TextRatingStrategy struct{}
+type TextRatingStrategy struct {
+	steps StepMachine
+}
 
 func NewTextRatingStrategy() *TextRatingStrategy {
-	return &TextRatingStrategy{}
+	s := &TextRatingStrategy{}
+	s.steps = StepMachine{
+		Initial: stepCollectText,
+		Steps: map[string]StepHandler{
+			stepCollectText:   {Render: s.renderTextPrompt, Handle: s.handleTextInput},
+			stepCollectRating: {Render: s.renderRatingButtons, Handle: s.handleRatingInput},
+			stepNextOrFinish:  {Render: s.renderNextFinishButtons, Handle: s.handleNextFinishInput},
+		},
+	}
+	return s
 }
 
 func (s *TextRatingStrategy) Name() string {
 	return "text_rating"
 }
 
+func (s *TextRatingStrategy) Capabilities() Capabilities {
+	return Capabilities{NeedsText: true, NeedsCallbacks: true, EditInPlace: true, Repeatable: true, AnswerKind: state.AnswerScored}
+}
+
 func (s *TextRatingStrategy) Validate(sectionID string, question config.QuestionConfig) error {
 	if len(question.Options) > 0 {
 		return fmt.Errorf("text_rating question should not have options")
@@ -57,123 +76,95 @@ func (s *TextRatingStrategy) Validate(sectionID string, question config.Question
 	return nil
 }
 
-func (s *TextRatingStrategy) Render(ctx RenderContext) (PromptSpec, error) {
-	record, err := ctx.ensureRecord()
-	if err != nil {
-		return PromptSpec{}, err
+func (s *TextRatingStrategy) CallbackValues(question config.QuestionConfig) []string {
+	minRating, maxRating := s.getRatingRange(question)
+	values := []string{"next", "finish"}
+	for i := minRating; i <= maxRating; i++ {
+		values = append(values, fmt.Sprintf("%d", i))
 	}
+	return values
+}
 
-	// Get current step (default to text collection)
-	stepKey := s.getStepKey(ctx.Question.ID)
-	currentStep := record.Data[stepKey]
-	if currentStep == "" {
-		currentStep = stepCollectText
+func (s *TextRatingStrategy) Render(ctx RenderContext) (PromptSpec, error) {
+	if _, err := ctx.ensureRecord(); err != nil {
+		return PromptSpec{}, err
 	}
+	return s.steps.Render(ctx)
+}
 
-	switch currentStep {
-	case stepCollectText:
-		return PromptSpec{
-			Text:     ctx.Question.Prompt,
-			Keyboard: nil, // No keyboard, expect text input
-		}, nil
-
-	case stepCollectRating:
-		return s.renderRatingButtons(ctx)
-
-	case stepNextOrFinish:
-		return s.renderNextFinishButtons(ctx)
-
-	default:
-		return PromptSpec{}, fmt.Errorf("unknown step: %s", currentStep)
-	}
+func (s *TextRatingStrategy) renderTextPrompt(ctx RenderContext, _ *state.QuestionScratch) (PromptSpec, error) {
+	return PromptSpec{
+		Text:     ctx.Question.Prompt,
+		Keyboard: nil, // No keyboard, expect text input
+	}, nil
 }
 
-func (s *TextRatingStrategy) renderRatingButtons(ctx RenderContext) (PromptSpec, error) {
+func (s *TextRatingStrategy) renderRatingButtons(ctx RenderContext, _ *state.QuestionScratch) (PromptSpec, error) {
 	minRating, maxRating := s.getRatingRange(ctx.Question)
-	text := fmt.Sprintf("Оцените от %d до %d:", minRating, maxRating)
+	text := ctx.message(config.MsgRatingPrompt, minRating, maxRating)
+	if config.FeatureEnabled(config.FeatureTypedRatings) {
+		text += "\n" + ctx.message(config.MsgRatingTypeHint)
+	}
 
 	// Create buttons for the rating range
-	buttons := make([]tgbotapi.InlineKeyboardButton, 0, maxRating-minRating+1)
+	buttons := make([]botport.Button, 0, maxRating-minRating+1)
 	for i := minRating; i <= maxRating; i++ {
 		buttonText := fmt.Sprintf("%d", i)
 		callbackData := fmt.Sprintf("%s%s:%d", ctx.CallbackPrefix, ctx.Question.ID, i)
-		button := tgbotapi.NewInlineKeyboardButtonData(buttonText, callbackData)
-		buttons = append(buttons, button)
+		buttons = append(buttons, botport.NewButton(buttonText, callbackData))
 	}
 
 	// Split buttons into rows of 5
-	var rows [][]tgbotapi.InlineKeyboardButton
+	keyboard := botport.NewKeyboard()
 	for i := 0; i < len(buttons); i += 5 {
 		end := i + 5
 		if end > len(buttons) {
 			end = len(buttons)
 		}
-		rows = append(rows, buttons[i:end])
+		keyboard.AddRow(buttons[i:end]...)
 	}
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
-
 	return PromptSpec{
 		Text:     text,
-		Keyboard: &keyboard,
+		Keyboard: keyboard,
 	}, nil
 }
 
-func (s *TextRatingStrategy) renderNextFinishButtons(ctx RenderContext) (PromptSpec, error) {
-	text := "Выберите действие:"
-
-	nextLabel := s.getNextButtonLabel(ctx.Question)
-	finishLabel := s.getFinishButtonLabel(ctx.Question)
-
-	nextCallback := fmt.Sprintf("%s%s:next", ctx.CallbackPrefix, ctx.Question.ID)
-	finishCallback := fmt.Sprintf("%s%s:finish", ctx.CallbackPrefix, ctx.Question.ID)
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(nextLabel, nextCallback),
-			tgbotapi.NewInlineKeyboardButtonData(finishLabel, finishCallback),
-		),
-	)
+func (s *TextRatingStrategy) renderNextFinishButtons(ctx RenderContext, _ *state.QuestionScratch) (PromptSpec, error) {
+	text := ctx.message(config.MsgRatingNextOrFinish)
+	canNext, canFinish := s.entryActions(ctx)
 
+	var row []botport.Button
+	if canNext {
+		nextCallback := fmt.Sprintf("%s%s:next", ctx.CallbackPrefix, ctx.Question.ID)
+		row = append(row, botport.NewButton(s.getNextButtonLabel(ctx), nextCallback))
+	} else {
+		text += "\n" + ctx.message(config.MsgEntriesLimitReached, ctx.Question.MaxEntries)
+	}
+	if canFinish {
+		finishCallback := fmt.Sprintf("%s%s:finish", ctx.CallbackPrefix, ctx.Question.ID)
+		row = append(row, botport.NewButton(s.getFinishButtonLabel(ctx), finishCallback))
+	} else {
+		text += "\n" + ctx.message(config.MsgEntriesNeedMore, ctx.Question.MinEntries-s.entryCount(ctx))
+	}
 	return PromptSpec{
 		Text:     text,
-		Keyboard: &keyboard,
+		Keyboard: botport.NewKeyboard(row),
 	}, nil
 }
 
 func (s *TextRatingStrategy) HandleAnswer(ctx AnswerContext, input AnswerInput) (AnswerResult, error) {
-	record, err := ctx.ensureRecord()
-	if err != nil {
+	if _, err := ctx.ensureRecord(); err != nil {
 		return AnswerResult{}, err
 	}
-
-	// Get current step
-	stepKey := s.getStepKey(ctx.Question.ID)
-	currentStep := record.Data[stepKey]
-	if currentStep == "" {
-		currentStep = stepCollectText
-	}
-
-	switch currentStep {
-	case stepCollectText:
-		return s.handleTextInput(ctx, input, record, stepKey)
-
-	case stepCollectRating:
-		return s.handleRatingInput(ctx, input, record, stepKey)
-
-	case stepNextOrFinish:
-		return s.handleNextFinishInput(ctx, input, record, stepKey)
-
-	default:
-		return AnswerResult{}, fmt.Errorf("unknown step: %s", currentStep)
-	}
+	return s.steps.HandleAnswer(ctx, input)
 }
 
-func (s *TextRatingStrategy) handleTextInput(ctx AnswerContext, input AnswerInput, record *state.Record, stepKey string) (AnswerResult, error) {
+func (s *TextRatingStrategy) handleTextInput(ctx AnswerContext, input AnswerInput, scratch *state.QuestionScratch) (AnswerResult, error) {
 	if input.Source != InputSourceText {
 		return AnswerResult{
 			Repeat:   true,
-			Feedback: "Пожалуйста, отправьте текстовый ответ.",
+			Feedback: ctx.message(config.MsgSendTextAnswer),
 		}, nil
 	}
 
@@ -181,57 +172,59 @@ func (s *TextRatingStrategy) handleTextInput(ctx AnswerContext, input AnswerInpu
 	if text == "" {
 		return AnswerResult{
 			Repeat:   true,
-			Feedback: "Пожалуйста, отправьте текстовый ответ.",
+			Feedback: ctx.message(config.MsgSendTextAnswer),
 		}, nil
 	}
 
-	// Store text temporarily
-	textKey := s.getTempTextKey(ctx.Question.ID)
-	record.Data[textKey] = text
-
-	// Move to rating step
-	record.Data[stepKey] = stepCollectRating
+	scratch.Values[scratchText] = text
+	scratch.Step = stepCollectRating
 
 	return AnswerResult{
 		Repeat: true, // Re-render to show rating buttons
 	}, nil
 }
 
-func (s *TextRatingStrategy) handleRatingInput(ctx AnswerContext, input AnswerInput, record *state.Record, stepKey string) (AnswerResult, error) {
+func (s *TextRatingStrategy) handleRatingInput(ctx AnswerContext, input AnswerInput, scratch *state.QuestionScratch) (AnswerResult, error) {
+	rating := input.CallbackData
 	if input.Source != InputSourceCallback {
-		return AnswerResult{
-			Repeat:   true,
-			Feedback: "Пожалуйста, используйте кнопки для выбора оценки.",
-		}, nil
+		if !config.FeatureEnabled(config.FeatureTypedRatings) {
+			return AnswerResult{
+				Repeat:   true,
+				Feedback: ctx.message(config.MsgRatingUseButtons),
+			}, nil
+		}
+		typed, ok := parseTypedNumber(input.Text)
+		if !ok {
+			minRating, maxRating := s.getRatingRange(ctx.Question)
+			return AnswerResult{
+				Repeat:   true,
+				Feedback: ctx.message(config.MsgRatingNotANumber, strings.TrimSpace(input.Text), minRating, maxRating),
+			}, nil
+		}
+		rating = strconv.Itoa(typed)
 	}
 
-	// Parse rating from callback data
-	rating := input.CallbackData
 	if !s.isValidRating(ctx.Question, rating) {
 		minRating, maxRating := s.getRatingRange(ctx.Question)
 		return AnswerResult{
 			Repeat:   true,
-			Feedback: fmt.Sprintf("Пожалуйста, выберите оценку от %d до %d.", minRating, maxRating),
+			Feedback: ctx.message(config.MsgRatingOutOfRange, minRating, maxRating),
 		}, nil
 	}
 
-	// Store rating temporarily
-	ratingKey := s.getTempRatingKey(ctx.Question.ID)
-	record.Data[ratingKey] = rating
-
-	// Move to next/finish step
-	record.Data[stepKey] = stepNextOrFinish
+	scratch.Values[scratchRating] = rating
+	scratch.Step = stepNextOrFinish
 
 	return AnswerResult{
 		Repeat: true, // Re-render to show next/finish buttons
 	}, nil
 }
 
-func (s *TextRatingStrategy) handleNextFinishInput(ctx AnswerContext, input AnswerInput, record *state.Record, stepKey string) (AnswerResult, error) {
+func (s *TextRatingStrategy) handleNextFinishInput(ctx AnswerContext, input AnswerInput, scratch *state.QuestionScratch) (AnswerResult, error) {
 	if input.Source != InputSourceCallback {
 		return AnswerResult{
 			Repeat:   true,
-			Feedback: "Пожалуйста, используйте кнопки для выбора действия.",
+			Feedback: ctx.message(config.MsgRatingUseActionButtons),
 		}, nil
 	}
 
@@ -239,51 +232,68 @@ func (s *TextRatingStrategy) handleNextFinishInput(ctx AnswerContext, input Answ
 	if action != "next" && action != "finish" {
 		return AnswerResult{
 			Repeat:   true,
-			Feedback: "Пожалуйста, выберите 'Следующий' или 'Завершить'.",
+			Feedback: ctx.message(config.MsgRatingChooseNextFinish),
 		}, nil
 	}
 
-	// Retrieve temporary data
-	textKey := s.getTempTextKey(ctx.Question.ID)
-	ratingKey := s.getTempRatingKey(ctx.Question.ID)
+	// A keyboard rendered before a limit applied may still offer the other action.
+	canNext, canFinish := s.entryActions(ctx.RenderContext)
+	if action == "next" && !canNext {
+		return AnswerResult{
+			Repeat:   true,
+			Feedback: ctx.message(config.MsgEntriesLimitReached, ctx.Question.MaxEntries),
+		}, nil
+	}
+	if action == "finish" && !canFinish {
+		return AnswerResult{
+			Repeat:   true,
+			Feedback: ctx.message(config.MsgEntriesNeedMore, ctx.Question.MinEntries-s.entryCount(ctx.RenderContext)),
+		}, nil
+	}
 
-	text := record.Data[textKey]
-	rating := record.Data[ratingKey]
+	text := scratch.Values[scratchText]
+	rating := scratch.Values[scratchRating]
 	if text == "" || rating == "" {
 		return AnswerResult{
 			Repeat:   true,
-			Feedback: "Не удалось прочитать последний ответ, попробуйте снова.",
+			Feedback: ctx.message(config.MsgRatingLostAnswer),
 		}, nil
 	}
 
-	entry := s.formatEntry(text, rating)
-	if existing := record.Data[ctx.Question.StoreKey]; existing != "" {
-		record.Data[ctx.Question.StoreKey] = existing + "\n" + entry
-	} else {
-		record.Data[ctx.Question.StoreKey] = entry
+	score, err := strconv.Atoi(rating)
+	if err != nil {
+		return AnswerResult{}, fmt.Errorf("stored rating %q is not a number: %w", rating, err)
+	}
+	entries := append(ctx.Record.Answer(ctx.Question).Scored, state.ScoredEntry{Text: text, Score: score})
+	if err := storeAnswer(ctx.RenderContext, state.ScoredAnswer(entries...)); err != nil {
+		return AnswerResult{}, err
 	}
-
-	// Clean up temporary keys
-	delete(record.Data, stepKey)
-	delete(record.Data, textKey)
-	delete(record.Data, ratingKey)
 
 	if action == "next" {
-		// Reset step for next use
-		record.Data[stepKey] = stepCollectText
+		scratch.Reset(stepCollectText)
 		return AnswerResult{
 			Repeat: true, // Stay on this question for next entry
 		}, nil
 	}
 
-	// action == "finish"
+	// action == "finish"; the step machine drops the scratch
 	return AnswerResult{
 		Advance: true, // Move to next question
 	}, nil
 }
 
-func (s *TextRatingStrategy) formatEntry(text, rating string) string {
-	return fmt.Sprintf("- %s\n  Рейтинг: %s", text, rating)
+// entryCount is the number of entries the answer holds once the one being added is stored.
+func (s *TextRatingStrategy) entryCount(ctx RenderContext) int {
+	return len(ctx.Record.Answer(ctx.Question).Scored) + 1
+}
+
+// entryActions tells which of "next" and "finish" the min_entries/max_entries limits allow for the
+// entry being added.
+func (s *TextRatingStrategy) entryActions(ctx RenderContext) (canNext, canFinish bool) {
+	count := s.entryCount(ctx)
+	canNext = ctx.Question.MaxEntries == 0 || count < ctx.Question.MaxEntries
+	canFinish = count >= ctx.Question.MinEntries
+	return canNext, canFinish
 }
 
 func (s *TextRatingStrategy) isValidRating(question config.QuestionConfig, rating string) bool {
@@ -309,28 +319,16 @@ func (s *TextRatingStrategy) getRatingRange(question config.QuestionConfig) (int
 	return minRating, maxRating
 }
 
-func (s *TextRatingStrategy) getNextButtonLabel(question config.QuestionConfig) string {
-	if question.NextButtonLabel != "" {
-		return question.NextButtonLabel
+func (s *TextRatingStrategy) getNextButtonLabel(ctx RenderContext) string {
+	if ctx.Question.NextButtonLabel != "" {
+		return ctx.Question.NextButtonLabel
 	}
-	return "➡️ Следующий" // Default label
+	return ctx.message(config.MsgButtonRatingNext)
 }
 
-func (s *TextRatingStrategy) getFinishButtonLabel(question config.QuestionConfig) string {
-	if question.FinishButtonLabel != "" {
-		return question.FinishButtonLabel
+func (s *TextRatingStrategy) getFinishButtonLabel(ctx RenderContext) string {
+	if ctx.Question.FinishButtonLabel != "" {
+		return ctx.Question.FinishButtonLabel
 	}
-	return "✅ Завершить" // Default label
-}
-
-func (s *TextRatingStrategy) getStepKey(questionID string) string {
-	return fmt.Sprintf("_step_%s", questionID)
-}
-
-func (s *TextRatingStrategy) getTempTextKey(questionID string) string {
-	return fmt.Sprintf("_text_%s", questionID)
-}
-
-func (s *TextRatingStrategy) getTempRatingKey(questionID string) string {
-	return fmt.Sprintf("_rating_%s", questionID)
+	return ctx.message(config.MsgButtonRatingFinish)
 }
@@ -1,9 +1,10 @@
 package questions
 
 import (
+	"testing"
+
 	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
 	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
-	"testing"
 )
 
 func TestTextRatingStrategy_FullFlow(t *testing.T) {
@@ -67,23 +68,19 @@ func TestTextRatingStrategy_FullFlow(t *testing.T) {
 
 	// Verify final stored value
 	expected := "- Отличный день, все прошло хорошо\n  Рейтинг: 8"
-	if ctx.Record.Data["day_rating"] != expected {
-		t.Fatalf("unexpected stored value: %q", ctx.Record.Data["day_rating"])
+	if ctx.Record.Data["day_rating"].String() != expected {
+		t.Fatalf("unexpected stored value: %q", ctx.Record.Data["day_rating"].String())
 	}
-
-	// Verify temporary keys are cleaned up
-	stepKey := strategy.getStepKey("q1")
-	textKey := strategy.getTempTextKey("q1")
-	ratingKey := strategy.getTempRatingKey("q1")
-
-	if _, exists := ctx.Record.Data[stepKey]; exists {
-		t.Fatalf("expected step key to be cleaned up")
+	if got := ctx.Record.Data["day_rating"]; got.Kind != state.AnswerScored || got.Scored[0].Score != 8 {
+		t.Fatalf("expected scored answer with score 8, got %+v", got)
 	}
-	if _, exists := ctx.Record.Data[textKey]; exists {
-		t.Fatalf("expected temp text key to be cleaned up")
+
+	// Verify scratch state is cleaned up and never leaked into the record
+	if _, exists := ctx.Record.Transient["q1"]; exists {
+		t.Fatalf("expected scratch to be cleaned up")
 	}
-	if _, exists := ctx.Record.Data[ratingKey]; exists {
-		t.Fatalf("expected temp rating key to be cleaned up")
+	if len(ctx.Record.Data) != 1 {
+		t.Fatalf("expected only the final answer in record data, got %v", ctx.Record.Data)
 	}
 }
 
@@ -124,8 +121,8 @@ func TestTextRatingStrategy_NextAction(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	expected := "- Good service\n  Рейтинг: 9"
-	if ctx.Record.Data["feedback"] != expected {
-		t.Fatalf("unexpected stored value after next: %q", ctx.Record.Data["feedback"])
+	if ctx.Record.Data["feedback"].String() != expected {
+		t.Fatalf("unexpected stored value after next: %q", ctx.Record.Data["feedback"].String())
 	}
 	if result.Advance {
 		t.Fatalf("expected Advance=false when choosing 'next'")
@@ -135,9 +132,8 @@ func TestTextRatingStrategy_NextAction(t *testing.T) {
 	}
 
 	// Verify step is reset to text collection
-	stepKey := strategy.getStepKey("q1")
-	if ctx.Record.Data[stepKey] != stepCollectText {
-		t.Fatalf("expected step to be reset to text collection, got: %s", ctx.Record.Data[stepKey])
+	if step := ctx.Record.Transient["q1"].Step; step != stepCollectText {
+		t.Fatalf("expected step to be reset to text collection, got: %s", step)
 	}
 }
 
@@ -174,8 +170,8 @@ func TestTextRatingStrategy_MultipleEntries(t *testing.T) {
 	}
 
 	expected := "- First\n  Рейтинг: 7\n- Second\n  Рейтинг: 5"
-	if record.Data["feedback"] != expected {
-		t.Fatalf("unexpected aggregated value: %q", record.Data["feedback"])
+	if record.Data["feedback"].String() != expected {
+		t.Fatalf("unexpected aggregated value: %q", record.Data["feedback"].String())
 	}
 }
 
@@ -353,7 +349,7 @@ func TestTextRatingStrategy_CustomRatingRange(t *testing.T) {
 	}
 
 	// Reset for next test
-	record.Data[strategy.getStepKey("q1")] = stepCollectRating
+	ctx.Record.Transient["q1"].Step = stepCollectRating
 
 	// Invalid rating (10, out of range)
 	result, err = strategy.HandleAnswer(ctx, AnswerInput{
@@ -388,9 +384,9 @@ func TestTextRatingStrategy_CustomButtonLabels(t *testing.T) {
 	}
 
 	// Set state to next/finish step
-	record.Data[strategy.getStepKey("q1")] = stepNextOrFinish
-	record.Data[strategy.getTempTextKey("q1")] = "Test"
-	record.Data[strategy.getTempRatingKey("q1")] = "8"
+	ctx.Record.Transient = map[string]*state.QuestionScratch{
+		"q1": {Step: stepNextOrFinish, Values: map[string]string{scratchText: "Test", scratchRating: "8"}},
+	}
 
 	// Render next/finish buttons
 	prompt, err := strategy.Render(ctx)
@@ -404,19 +400,19 @@ func TestTextRatingStrategy_CustomButtonLabels(t *testing.T) {
 	}
 
 	// Verify the keyboard has the custom labels
-	if len(prompt.Keyboard.InlineKeyboard) == 0 {
+	if len(prompt.Keyboard.Rows) == 0 {
 		t.Fatalf("expected at least one row in keyboard")
 	}
-	row := prompt.Keyboard.InlineKeyboard[0]
+	row := prompt.Keyboard.Rows[0]
 	if len(row) != 2 {
 		t.Fatalf("expected 2 buttons in row, got %d", len(row))
 	}
 
-	if row[0].Text != "🔄 Add Another" {
-		t.Fatalf("expected first button text '🔄 Add Another', got '%s'", row[0].Text)
+	if row[0].Label != "🔄 Add Another" {
+		t.Fatalf("expected first button text '🔄 Add Another', got '%s'", row[0].Label)
 	}
-	if row[1].Text != "🏁 Done" {
-		t.Fatalf("expected second button text '🏁 Done', got '%s'", row[1].Text)
+	if row[1].Label != "🏁 Done" {
+		t.Fatalf("expected second button text '🏁 Done', got '%s'", row[1].Label)
 	}
 }
 
@@ -476,3 +472,131 @@ func TestTextRatingStrategy_ValidateRatingRange(t *testing.T) {
 		t.Fatalf("unexpected validation error for defaults: %v", err)
 	}
 }
+
+func TestTextRatingStrategy_EntryLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		min, max     int
+		stored       int // Entries already in the answer
+		wantButtons  []string
+		action       string
+		wantAdvance  bool
+		wantFeedback string
+	}{
+		{name: "no limits", stored: 3, wantButtons: []string{"next", "finish"}, action: "finish", wantAdvance: true},
+		{name: "below min hides finish", min: 2, wantButtons: []string{"next"}, action: "finish", wantFeedback: config.Message("ru", config.MsgEntriesNeedMore, 1)},
+		{name: "min reached", min: 2, stored: 1, wantButtons: []string{"next", "finish"}, action: "finish", wantAdvance: true},
+		{name: "max reached hides next", max: 2, stored: 1, wantButtons: []string{"finish"}, action: "next", wantFeedback: config.Message("ru", config.MsgEntriesLimitReached, 2)},
+		{name: "below max", max: 2, wantButtons: []string{"next", "finish"}, action: "next"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := NewTextRatingStrategy()
+			record := state.NewRecord()
+			question := config.QuestionConfig{ID: "q1", Type: "text_rating", StoreKey: "rating", MinEntries: tt.min, MaxEntries: tt.max}
+			var entries []state.ScoredEntry
+			for i := 0; i < tt.stored; i++ {
+				entries = append(entries, state.ScoredEntry{Text: "old", Score: 5})
+			}
+			if tt.stored > 0 {
+				record.Data["rating"] = state.ScoredAnswer(entries...)
+			}
+			record.Transient = map[string]*state.QuestionScratch{
+				"q1": {Step: stepNextOrFinish, Values: map[string]string{scratchText: "New", scratchRating: "8"}},
+			}
+			ctx := AnswerContext{RenderContext: RenderContext{
+				UserState:      &state.UserState{CurrentRecord: record},
+				Record:         record,
+				Question:       question,
+				CallbackPrefix: "answer:",
+			}}
+
+			prompt, err := strategy.Render(ctx.RenderContext)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			row := prompt.Keyboard.Rows[0]
+			if len(row) != len(tt.wantButtons) {
+				t.Fatalf("buttons %+v, want %v", row, tt.wantButtons)
+			}
+			for i, action := range tt.wantButtons {
+				if row[i].Action != "answer:q1:"+action {
+					t.Fatalf("button %d is %q, want %q", i, row[i].Action, action)
+				}
+			}
+
+			result, err := strategy.HandleAnswer(ctx, AnswerInput{Source: InputSourceCallback, CallbackData: tt.action})
+			if err != nil {
+				t.Fatalf("handle: %v", err)
+			}
+			if result.Advance != tt.wantAdvance {
+				t.Fatalf("advance = %t, want %t", result.Advance, tt.wantAdvance)
+			}
+			if result.Feedback != tt.wantFeedback {
+				t.Fatalf("feedback %q, want %q", result.Feedback, tt.wantFeedback)
+			}
+			if tt.wantFeedback != "" {
+				if len(record.Answer(question).Scored) != tt.stored {
+					t.Fatalf("entry stored despite the limit: %+v", record.Data["rating"])
+				}
+			}
+		})
+	}
+}
+
+func TestTextRatingStrategy_TypedRating(t *testing.T) {
+	defer config.SetFeature(config.FeatureTypedRatings, false)
+
+	tests := []struct {
+		name         string
+		enabled      bool
+		typed        string
+		wantScore    int
+		wantFeedback string // Empty when the rating is accepted
+	}{
+		{name: "flag off", typed: "8", wantFeedback: config.Message("ru", config.MsgRatingUseButtons)},
+		{name: "digits", enabled: true, typed: " 8 ", wantScore: 8},
+		{name: "russian word", enabled: true, typed: "Восемь!", wantScore: 8},
+		{name: "english word", enabled: true, typed: "three", wantScore: 3},
+		{name: "zero", enabled: true, typed: "ноль", wantScore: 0},
+		{name: "out of range", enabled: true, typed: "двадцать", wantFeedback: config.Message("ru", config.MsgRatingOutOfRange, 0, 10)},
+		{name: "not a number", enabled: true, typed: "много", wantFeedback: config.Message("ru", config.MsgRatingNotANumber, "много", 0, 10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := config.SetFeature(config.FeatureTypedRatings, tt.enabled); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			strategy := NewTextRatingStrategy()
+			record := state.NewRecord()
+			ctx := AnswerContext{
+				RenderContext: RenderContext{
+					UserState: &state.UserState{CurrentRecord: record},
+					Record:    record,
+					Question:  config.QuestionConfig{ID: "q1", Type: "text_rating", StoreKey: "response"},
+				},
+			}
+			if _, err := strategy.HandleAnswer(ctx, AnswerInput{Source: InputSourceText, Text: "Day"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := strategy.HandleAnswer(ctx, AnswerInput{Source: InputSourceText, Text: tt.typed})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Feedback != tt.wantFeedback {
+				t.Fatalf("feedback %q, want %q", result.Feedback, tt.wantFeedback)
+			}
+			if tt.wantFeedback != "" {
+				return
+			}
+			result, err = strategy.HandleAnswer(ctx, AnswerInput{Source: InputSourceCallback, CallbackData: "finish"})
+			if err != nil || !result.Advance {
+				t.Fatalf("finish: result %+v, err %v", result, err)
+			}
+			if entries := record.Data["response"].Scored; len(entries) != 1 || entries[0].Score != tt.wantScore {
+				t.Fatalf("entries %+v, want score %d", entries, tt.wantScore)
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@ package questions
 
 import (
 	"fmt"
-	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
 )
 
 type textStrategy struct{}
@@ -17,6 +20,10 @@ func (t *textStrategy) Name() string {
 	return "text"
 }
 
+func (t *textStrategy) Capabilities() Capabilities {
+	return Capabilities{NeedsText: true, EditInPlace: true, ValidatesLength: true, AnswerKind: state.AnswerString}
+}
+
 func (t *textStrategy) Validate(sectionID string, question config.QuestionConfig) error {
 	if len(question.Options) > 0 {
 		return fmt.Errorf("config validation failed: question '%s' in section '%s' is type 'text' but has options defined", question.ID, sectionID)
@@ -25,16 +32,60 @@ func (t *textStrategy) Validate(sectionID string, question config.QuestionConfig
 }
 
 func (t *textStrategy) Render(ctx RenderContext) (PromptSpec, error) {
+	text := ctx.Question.Prompt
+	if hint := lengthHint(ctx); hint != "" {
+		text += "\n\n" + hint
+	}
 	return PromptSpec{
-		Text:     ctx.Question.Prompt,
+		Text:     text,
 		Keyboard: nil,
 	}, nil
 }
 
+// lengthHint tells the bounds of the validation block and, when an answer is being replaced, how many
+// characters it has and how many are left.
+func lengthHint(ctx RenderContext) string {
+	v := ctx.Question.Validation
+	var hint string
+	switch {
+	case !v.LengthLimited():
+		return ""
+	case v.MinLength > 0 && v.MaxLength > 0:
+		hint = ctx.message(config.MsgLengthHintRange, v.MinLength, v.MaxLength)
+	case v.MaxLength > 0:
+		hint = ctx.message(config.MsgLengthHintMax, v.MaxLength)
+	default:
+		hint = ctx.message(config.MsgLengthHintMin, v.MinLength)
+	}
+	if v.MaxLength > 0 {
+		if existing := ctx.Record.Answer(ctx.Question); existing.Kind == state.AnswerString {
+			length := utf8.RuneCountInString(existing.Text)
+			hint += " " + ctx.message(config.MsgLengthHintCurrent, length, max(v.MaxLength-length, 0))
+		}
+	}
+	return hint
+}
+
+// checkLength explains why value is outside the validation bounds, or returns "" when it fits.
+func checkLength(ctx AnswerContext, value string) string {
+	v := ctx.Question.Validation
+	if !v.LengthLimited() {
+		return ""
+	}
+	length := utf8.RuneCountInString(value)
+	switch {
+	case v.MaxLength > 0 && length > v.MaxLength:
+		return ctx.message(config.MsgAnswerOverMax, length, v.MaxLength, length-v.MaxLength)
+	case length < v.MinLength:
+		return ctx.message(config.MsgAnswerUnderMin, length, v.MinLength, v.MinLength-length)
+	}
+	return ""
+}
+
 func (t *textStrategy) HandleAnswer(ctx AnswerContext, input AnswerInput) (AnswerResult, error) {
 	if input.Source != InputSourceText {
 		return AnswerResult{
-			Feedback: "Пожалуйста, отправьте текстовый ответ.",
+			Feedback: ctx.message(config.MsgSendTextAnswer),
 			Repeat:   true,
 		}, nil
 	}
@@ -42,16 +93,20 @@ func (t *textStrategy) HandleAnswer(ctx AnswerContext, input AnswerInput) (Answe
 	value := strings.TrimSpace(input.Text)
 	if value == "" {
 		return AnswerResult{
-			Feedback: "Текст не должен быть пустым, попробуйте ещё раз.",
+			Feedback: ctx.message(config.MsgTextAnswerEmpty),
 			Repeat:   true,
 		}, nil
 	}
 
-	record, err := ctx.ensureRecord()
-	if err != nil {
-		return AnswerResult{}, err
+	if feedback := checkLength(ctx, value); feedback != "" {
+		return AnswerResult{
+			Feedback: feedback,
+			Repeat:   true,
+		}, nil
 	}
 
-	record.Data[ctx.Question.StoreKey] = value
+	if err := storeAnswer(ctx.RenderContext, state.StringAnswer(value)); err != nil {
+		return AnswerResult{}, err
+	}
 	return AnswerResult{Advance: true}, nil
 }
@@ -32,8 +32,8 @@ func TestTextStrategyHandleAnswer(t *testing.T) {
 	if !result.Advance {
 		t.Fatalf("expected Advance=true")
 	}
-	if ctx.Record.Data["name"] != "Alice" {
-		t.Fatalf("expected stored value 'Alice', got '%s'", ctx.Record.Data["name"])
+	if ctx.Record.Data["name"].String() != "Alice" {
+		t.Fatalf("expected stored value 'Alice', got '%s'", ctx.Record.Data["name"].String())
 	}
 }
 
@@ -66,3 +66,54 @@ func TestTextStrategyRejectsEmptyInput(t *testing.T) {
 		t.Fatalf("expected Repeat=true to re-ask question")
 	}
 }
+
+func TestTextStrategyLengthValidation(t *testing.T) {
+	strategy := NewTextStrategy()
+	tests := []struct {
+		name         string
+		validation   *config.ValidationConfig
+		existing     string
+		input        string
+		wantPrompt   string
+		wantFeedback string // Empty when the answer is stored
+	}{
+		{name: "no bounds", input: "ok", wantPrompt: "Describe"},
+		{name: "within max", validation: &config.ValidationConfig{MaxLength: 10}, input: "short", wantPrompt: "Describe\n\n" + config.Message("ru", config.MsgLengthHintMax, 10)},
+		{name: "over max", validation: &config.ValidationConfig{MaxLength: 5}, input: "twelve chars", wantPrompt: "Describe\n\n" + config.Message("ru", config.MsgLengthHintMax, 5), wantFeedback: config.Message("ru", config.MsgAnswerOverMax, 12, 5, 7)},
+		{name: "under min counts runes", validation: &config.ValidationConfig{MinLength: 5, MaxLength: 10}, input: "мало", wantPrompt: "Describe\n\n" + config.Message("ru", config.MsgLengthHintRange, 5, 10), wantFeedback: config.Message("ru", config.MsgAnswerUnderMin, 4, 5, 1)},
+		{name: "only min", validation: &config.ValidationConfig{MinLength: 3}, input: "enough", wantPrompt: "Describe\n\n" + config.Message("ru", config.MsgLengthHintMin, 3)},
+		{name: "replacing an answer shows the counter", validation: &config.ValidationConfig{MaxLength: 10}, existing: "old", input: "new", wantPrompt: "Describe\n\n" + config.Message("ru", config.MsgLengthHintMax, 10) + " " + config.Message("ru", config.MsgLengthHintCurrent, 3, 7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := state.NewRecord()
+			if tt.existing != "" {
+				record.Data["note"] = state.StringAnswer(tt.existing)
+			}
+			ctx := AnswerContext{
+				RenderContext: RenderContext{
+					UserState: &state.UserState{CurrentRecord: record},
+					Record:    record,
+					Question:  config.QuestionConfig{ID: "q1", Prompt: "Describe", Type: "text", StoreKey: "note", Validation: tt.validation},
+				},
+			}
+
+			prompt, err := strategy.Render(ctx.RenderContext)
+			if err != nil || prompt.Text != tt.wantPrompt {
+				t.Fatalf("prompt %q (err %v), want %q", prompt.Text, err, tt.wantPrompt)
+			}
+
+			result, err := strategy.HandleAnswer(ctx, AnswerInput{Source: InputSourceText, Text: tt.input})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Feedback != tt.wantFeedback {
+				t.Fatalf("feedback %q, want %q", result.Feedback, tt.wantFeedback)
+			}
+			stored := record.Data["note"].String() == tt.input
+			if stored != (tt.wantFeedback == "") || result.Advance == result.Repeat {
+				t.Fatalf("stored = %t, result %+v", stored, result)
+			}
+		})
+	}
+}
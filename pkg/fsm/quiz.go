@@ -0,0 +1,128 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// revealQuizAnswer replaces the prompt of a just answered buttons question of a quiz section with the
+// verdict and the option's feedback, and a "Далее" button that moves on. It reports false, leaving the
+// flow to advance at once, for any other question.
+func revealQuizAnswer(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, messageID int) bool {
+	sectionConf, question, err := resolveCurrentQuestion(recordConfig, userState)
+	if err != nil || !sectionConf.Quiz || question.Type != questions.TypeButtons {
+		return false
+	}
+	record := userState.CurrentRecord
+	option := quizOption(question, record.Answer(question).String())
+	if option == nil {
+		return false
+	}
+
+	lines := []string{question.Prompt, "", tr(userState, config.MsgQuizYourAnswer, option.Text)}
+	if correct := correctOptions(question); len(correct) > 0 {
+		if option.Correct {
+			lines = append(lines, tr(userState, config.MsgQuizCorrect))
+		} else {
+			lines = append(lines, tr(userState, config.MsgQuizWrong, strings.Join(correct, ", ")))
+		}
+	}
+	if option.Feedback != "" {
+		lines = append(lines, "", option.Feedback)
+	}
+	last := userState.EditingAnswer || nextVisibleQuestion(sectionConf, record, userState.CurrentQuestion+1) < 0
+	if right, total := quizScore(sectionConf, record); last && total > 0 {
+		lines = append(lines, "", tr(userState, config.MsgQuizScore, right, total))
+	}
+	text := strings.Join(lines, "\n")
+	keyboard := botport.NewKeyboard(botport.NewRow(
+		botport.NewButton(tr(userState, config.MsgButtonQuizNext), CallbackQuizPrefix+question.ID),
+	))
+
+	var sent botport.BotMessage
+	if messageID != 0 && botPort.Capabilities().EditInPlace {
+		sent, err = botPort.EditMessage(ctx, userState.UserID, messageID, text, keyboard)
+	} else {
+		sent, err = botPort.SendMessage(ctx, userState.UserID, text, keyboard)
+	}
+	if err != nil && !botport.IsCode(err, "message_not_modified") {
+		slog.ErrorContext(ctx, "showing quiz feedback failed", "question", question.ID, "user_id", userState.UserID, "err", err)
+		return false
+	}
+	slog.InfoContext(ctx, "quiz question answered", "user_id", userState.UserID, "question", question.ID, "value", option.Value)
+	if sent.MessageID != 0 && sent.MessageID != userState.LastMessageID {
+		// The old prompt was not replaced; its buttons are stale.
+		scheduleCleanup(botPort, recordConfig, userState.UserID, userState.LastMessageID)
+	}
+	// The feedback stays in the chat: the next prompt is sent as a new message.
+	userState.LastMessageID = 0
+	return true
+}
+
+// handleQuizNext moves on from the feedback of questionID once the user taps "Далее".
+func handleQuizNext(ctx context.Context, query *botport.InboundEvent, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, questionID string) {
+	if userState.RecordFSM.Current() != StateAnsweringQuestion {
+		_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgActionUnavailable))
+		return
+	}
+	_, question, err := resolveCurrentQuestion(recordConfig, userState)
+	if err != nil {
+		slog.ErrorContext(ctx, "resolving the quiz question failed", "err", err)
+		recoverFromConfigDrift(ctx, userState, botPort, recordConfig, query.ChatID)
+		return
+	}
+	if question.ID != questionID || userState.CurrentRecord.Answer(question).IsEmpty() {
+		_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgStaleAnswer))
+		return
+	}
+
+	emptyKeyboard := botport.NewKeyboard()
+	if _, err := botPort.EditMessage(ctx, query.ChatID, query.MessageID, query.Text, emptyKeyboard); err != nil && !botport.IsCode(err, "message_not_modified") {
+		slog.ErrorContext(ctx, "removing the quiz button failed", "user_id", userState.UserID, "err", err)
+	}
+	processAnswer(ctx, userState, botPort, recordConfig, 0)
+}
+
+// quizScore counts the answered questions of a quiz section that have a correct option (total) and
+// those answered correctly (right).
+func quizScore(sectionConf config.SectionConfig, record *state.Record) (right, total int) {
+	for _, q := range sectionConf.Questions {
+		if q.Type != questions.TypeButtons || len(correctOptions(q)) == 0 || !record.Visible(q) {
+			continue
+		}
+		answer := record.Answer(q)
+		if answer.IsEmpty() {
+			continue
+		}
+		total++
+		if option := quizOption(q, answer.String()); option != nil && option.Correct {
+			right++
+		}
+	}
+	return right, total
+}
+
+func correctOptions(question config.QuestionConfig) []string {
+	var texts []string
+	for _, option := range question.Options {
+		if option.Correct {
+			texts = append(texts, option.Text)
+		}
+	}
+	return texts
+}
+
+func quizOption(question config.QuestionConfig, value string) *config.ButtonOption {
+	for i := range question.Options {
+		if question.Options[i].Value == value {
+			return &question.Options[i]
+		}
+	}
+	return nil
+}
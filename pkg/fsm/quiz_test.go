@@ -0,0 +1,90 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestQuizFeedback(t *testing.T) {
+	questions.RegisterBuiltins()
+	quizConfig := func(quiz bool) *config.RecordConfig {
+		return &config.RecordConfig{Sections: map[string]config.SectionConfig{
+			"quiz": {Title: "Quiz", Quiz: quiz, Questions: []config.QuestionConfig{
+				{ID: "q1", Prompt: "Что помогает при панике?", Type: "buttons", StoreKey: "q1", Options: []config.ButtonOption{
+					{Text: "Дыхание", Value: "breath", Correct: true, Feedback: "Медленный выдох успокаивает."},
+					{Text: "Кофе", Value: "coffee", Feedback: "Кофеин усиливает тревогу."},
+				}},
+				{ID: "q2", Prompt: "Как вы себя чувствуете?", Type: "buttons", StoreKey: "q2", Options: []config.ButtonOption{
+					{Text: "Хорошо", Value: "good", Feedback: "Рады слышать!"},
+					{Text: "Плохо", Value: "bad"},
+				}},
+			}},
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		quiz      bool
+		answer    string
+		wantText  []string // Feedback shown in place of the first prompt; none when the flow moves on at once
+		wantScore string
+	}{
+		{name: "correct answer", quiz: true, answer: "breath", wantText: []string{"Ваш ответ: Дыхание", "✅ Верно!", "Медленный выдох"}, wantScore: "Результат: 1 из 1"},
+		{name: "wrong answer", quiz: true, answer: "coffee", wantText: []string{"Ваш ответ: Кофе", "Правильный ответ: Дыхание", "Кофеин"}, wantScore: "Результат: 0 из 1"},
+		{name: "not a quiz", answer: "breath"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := quizConfig(tt.quiz)
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := &state.UserState{UserID: 5, CurrentRecord: state.NewRecord(), MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+			userState.RecordFSM.SetState(StateAnsweringQuestion)
+			userState.CurrentSection = "quiz"
+			userState.LastMessageID = 1
+
+			handleCallbackQuery(context.Background(), callbackQuery(5, 1, CallbackAnswerPrefix+"q1:"+tt.answer), userState, adapter, rc, nil)
+
+			if tt.wantText == nil {
+				if userState.CurrentQuestion != 1 {
+					t.Fatalf("expected the flow to move on at once, at question %d", userState.CurrentQuestion)
+				}
+				return
+			}
+			reveal := adapter.LastCall("edit_message")
+			for _, want := range tt.wantText {
+				if !strings.Contains(reveal.Text, want) {
+					t.Fatalf("feedback %q does not contain %q", reveal.Text, want)
+				}
+			}
+			if userState.CurrentQuestion != 0 || !reveal.Keyboard.HasCallback(CallbackQuizPrefix+"q1") {
+				t.Fatalf("feedback must wait for \"Далее\" (question %d)", userState.CurrentQuestion)
+			}
+
+			handleCallbackQuery(context.Background(), callbackQuery(5, 1, CallbackQuizPrefix+"q1"), userState, adapter, rc, nil)
+			if userState.CurrentQuestion != 1 || !strings.Contains(adapter.LastCall("send_message").Text, "Как вы себя чувствуете?") {
+				t.Fatalf("\"Далее\" must send the next question as a new message (question %d)", userState.CurrentQuestion)
+			}
+			handleCallbackQuery(context.Background(), callbackQuery(5, 1, CallbackQuizPrefix+"q1"), userState, adapter, rc, nil)
+			if userState.CurrentQuestion != 1 {
+				t.Fatalf("a stale \"Далее\" must be ignored")
+			}
+
+			handleCallbackQuery(context.Background(), callbackQuery(5, 2, CallbackAnswerPrefix+"q2:good"), userState, adapter, rc, nil)
+			last := adapter.LastCall("edit_message").Text
+			if !strings.Contains(last, "Рады слышать!") || !strings.Contains(last, tt.wantScore) || strings.Contains(last, "Верно") {
+				t.Fatalf("unexpected feedback on the last question: %q", last)
+			}
+			handleCallbackQuery(context.Background(), callbackQuery(5, 2, CallbackQuizPrefix+"q2"), userState, adapter, rc, nil)
+			if got := userState.RecordFSM.Current(); got != StateSelectingSection {
+				t.Fatalf("state = %s, want %s", got, StateSelectingSection)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// pruneOrphanedAnswers drops answers whose store key no longer belongs to any configured question and
+// returns the removed keys. Answers follow their store key, so a question moved to another section keeps
+// its answer. Transient state of removed questions is dropped silently.
+func pruneOrphanedAnswers(recordConfig *config.RecordConfig, record *state.Record) []string {
+	if record == nil || record.Data == nil {
+		return nil
+	}
+	storeKeys := make(map[string]bool)
+	questionIDs := make(map[string]bool)
+	for _, sectionConf := range recordConfig.Sections {
+		for _, q := range sectionConf.Questions {
+			storeKeys[q.StoreKey] = true
+			questionIDs[q.ID] = true
+		}
+	}
+	for questionID := range record.Transient {
+		if !questionIDs[questionID] {
+			delete(record.Transient, questionID)
+		}
+	}
+
+	var dropped []string
+	for key := range record.Data {
+		if storeKeys[key] {
+			continue
+		}
+		delete(record.Data, key)
+		delete(record.Flags, key)
+		dropped = append(dropped, key)
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
+// orphanNotice tells the user which answers disappeared with the config update; empty when none did.
+func orphanNotice(userState *state.UserState, dropped []string) string {
+	if len(dropped) == 0 {
+		return ""
+	}
+	return tr(userState, config.MsgConfigUpdatedDropped, strings.Join(dropped, ", "))
+}
+
+// recoverFromConfigDrift repairs a user whose current section or question was removed from config while
+// they were answering: orphaned answers are dropped, the user is told, and the flow continues at the first
+// unanswered question of the section or, if the section itself is gone, at the section menu.
+func recoverFromConfigDrift(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
+	dropped := pruneOrphanedAnswers(recordConfig, userState.CurrentRecord)
+	slog.WarnContext(ctx, "section or question no longer in config, answers dropped", "user_id", userState.UserID, "section", userState.CurrentSection, "question_index", userState.CurrentQuestion, "dropped", dropped)
+
+	notice := tr(userState, config.MsgConfigUpdated)
+	if text := orphanNotice(userState, dropped); text != "" {
+		notice = text
+	}
+	_, _ = botPort.SendMessage(ctx, chatID, notice, nil)
+
+	if sectionConf, ok := recordConfig.Sections[userState.CurrentSection]; ok && len(sectionConf.Questions) > 0 {
+		userState.CurrentQuestion = firstUnansweredQuestion(sectionConf, userState.CurrentRecord)
+		askCurrentQuestion(ctx, userState, botPort, recordConfig, 0)
+		return
+	}
+
+	userState.CurrentSection = ""
+	userState.CurrentQuestion = 0
+	userState.SectionSnapshot = nil
+	if err := userState.RecordFSM.Event(ctx, EventCancelSection, recordEvent{User: userState, ChatID: chatID}); err != nil {
+		slog.ErrorContext(ctx, "returning the user to the section menu failed", "user_id", userState.UserID, "err", err)
+		_ = userState.RecordFSM.Event(ctx, EventForceExit, recordEvent{User: userState, ChatID: chatID, MessageID: userState.LastMessageID, Reason: reasonSectionRemoved})
+	}
+}
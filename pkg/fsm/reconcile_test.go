@@ -0,0 +1,134 @@
+package fsm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestPruneOrphanedAnswers(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"moved": {Title: "Moved", Questions: []config.QuestionConfig{{ID: "q1", Type: "text", StoreKey: "mood"}}},
+	}}
+
+	tests := []struct {
+		name        string
+		data        map[string]string
+		wantData    map[string]string
+		wantDropped []string
+	}{
+		{name: "known answers kept", data: map[string]string{"mood": "ok"}, wantData: map[string]string{"mood": "ok"}},
+		{name: "removed answers dropped", data: map[string]string{"mood": "ok", "sleep": "bad"}, wantData: map[string]string{"mood": "ok"}, wantDropped: []string{"sleep"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := &state.Record{Data: stringAnswers(tt.data)}
+			dropped := pruneOrphanedAnswers(rc, record)
+			if !reflect.DeepEqual(record.Data, stringAnswers(tt.wantData)) {
+				t.Fatalf("data = %v, want %v", record.Data, tt.wantData)
+			}
+			if !reflect.DeepEqual(dropped, tt.wantDropped) {
+				t.Fatalf("dropped = %v, want %v", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestRemovedSectionReturnsToMenu(t *testing.T) {
+	questions.RegisterBuiltins()
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"kept": {Title: "Kept", Questions: []config.QuestionConfig{{ID: "q1", Type: "text", StoreKey: "mood"}}},
+	}}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	record := state.NewRecord()
+	record.Data["mood"] = state.StringAnswer("ok")
+	record.Data["removed_key"] = state.StringAnswer("stale")
+	userState := &state.UserState{
+		UserID:         9,
+		CurrentRecord:  record,
+		CurrentSection: "removed",
+		MainMenuFSM:    handler.NewMainMenuFSM(),
+		RecordFSM:      handler.NewRecordFSM(),
+	}
+	userState.RecordFSM.SetState(StateAnsweringQuestion)
+
+	handleMessage(context.Background(), textMessage(9, "answer"), userState, adapter, rc, nil)
+
+	if got := userState.RecordFSM.Current(); got != StateSelectingSection {
+		t.Fatalf("state = %s, want %s", got, StateSelectingSection)
+	}
+	if _, ok := record.Data["removed_key"]; ok {
+		t.Fatalf("orphaned answer must be dropped")
+	}
+	if record.Data["mood"].String() != "ok" {
+		t.Fatalf("known answer must be kept")
+	}
+	notified := false
+	for _, call := range adapter.Calls {
+		if strings.Contains(call.Text, "removed_key") {
+			notified = true
+		}
+	}
+	if !notified {
+		t.Fatalf("expected user to be told about dropped answers, calls: %+v", adapter.Calls)
+	}
+}
+
+func TestReconcileUsersAfterReload(t *testing.T) {
+	questions.RegisterBuiltins()
+	reloaded := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"kept": {Title: "Kept", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "How is your mood now?", Type: "text", StoreKey: "mood"}}},
+	}}
+
+	tests := []struct {
+		name      string
+		state     string
+		section   string
+		wantState string
+		wantText  string // Expected in one of the messages sent or edited
+	}{
+		{name: "idle user untouched", state: StateRecordIdle, wantState: StateRecordIdle},
+		{name: "answering kept section sees new wording", state: StateAnsweringQuestion, section: "kept", wantState: StateAnsweringQuestion, wantText: "How is your mood now?"},
+		{name: "answering removed section returns to menu", state: StateAnsweringQuestion, section: "removed", wantState: StateSelectingSection, wantText: "removed_key"},
+		{name: "selecting is told about dropped answers", state: StateSelectingSection, wantState: StateSelectingSection, wantText: "removed_key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, reloaded).Store()
+			userState := store.GetOrCreateUserState(12, "User")
+			userState.CurrentRecord = &state.Record{Data: stringAnswers(map[string]string{"mood": "ok", "removed_key": "stale"})}
+			userState.CurrentSection = tt.section
+			userState.LastMessageID = 40
+			userState.RecordFSM.SetState(tt.state)
+
+			ReconcileUsers(context.Background(), adapter, reloaded, store)
+
+			if got := userState.RecordFSM.Current(); got != tt.wantState {
+				t.Fatalf("state = %s, want %s", got, tt.wantState)
+			}
+			if tt.wantText == "" {
+				if len(adapter.Calls) != 0 {
+					t.Fatalf("expected no messages, got %+v", adapter.Calls)
+				}
+				return
+			}
+			found := false
+			for _, call := range adapter.Calls {
+				if strings.Contains(call.Text, tt.wantText) {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("no message with %q, calls: %+v", tt.wantText, adapter.Calls)
+			}
+		})
+	}
+}
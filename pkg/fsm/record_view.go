@@ -0,0 +1,103 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/idgen"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// recordViewRows puts a "View" button per listed record above the list navigation.
+func recordViewRows(metas []state.RecordMeta) [][]botport.Button {
+	rows := make([][]botport.Button, 0, len(metas))
+	for _, m := range metas {
+		label := fmt.Sprintf("👁 %s (%s)", idgen.ShortCode(m.ID), m.CreatedAt.Format("02.01.06 15:04"))
+		rows = append(rows, botport.NewRow(
+			botport.NewButton(label, CallbackRecordPrefix+RecordActionView+":"+m.ID),
+		))
+	}
+	return rows
+}
+
+// handleRecordCallback serves the buttons of the list and of an opened record; value is
+// "<action>[:<record id>]". Viewing and going back move the main menu FSM between viewingList and
+// viewingRecord, sharing works from either.
+func handleRecordCallback(ctx context.Context, query *botport.InboundEvent, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, value string) {
+	chatID := query.ChatID
+	messageID := query.MessageID
+	mainState := userState.MainMenuFSM.Current()
+	action, recordID, _ := strings.Cut(value, ":")
+
+	switch {
+	case action == RecordActionView && mainState == StateViewingList:
+		record := loadListedRecord(userState, store, recordID)
+		if record == nil {
+			_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgRecordShowFailed))
+			return
+		}
+		if err := userState.MainMenuFSM.Event(ctx, EventViewRecord); err != nil {
+			slog.ErrorContext(ctx, "view record event failed", "user_id", userState.UserID, "err", err)
+			return
+		}
+		showRecord(ctx, userState, botPort, recordConfig, chatID, messageID, record)
+
+	case action == RecordActionList && mainState == StateViewingRecord:
+		if err := userState.MainMenuFSM.Event(ctx, EventBackToList); err != nil {
+			slog.ErrorContext(ctx, "back to list event failed", "user_id", userState.UserID, "err", err)
+			return
+		}
+		viewListHandler(ctx, userState, botPort, chatID, messageID, store)
+
+	case action == RecordActionShare:
+		record := loadListedRecord(userState, store, recordID)
+		if record == nil {
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgNoRecordsToShare), nil)
+			return
+		}
+		shareRecord(ctx, userState, botPort, recordConfig, chatID, record)
+
+	default:
+		slog.WarnContext(ctx, "record action unavailable", "action", action, "user_id", userState.UserID, "state", mainState)
+		_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(userState, config.MsgActionUnavailable))
+	}
+}
+
+// loadListedRecord returns the saved record recordID of the user, or nil when it is gone.
+func loadListedRecord(userState *state.UserState, store *state.Store, recordID string) *state.Record {
+	if store == nil || recordID == "" {
+		return nil
+	}
+	record, err := store.LoadRecord(userState.UserID, recordID)
+	if err != nil {
+		slog.Error("loading the listed record failed", "record_id", recordID, "user_id", userState.UserID, "err", err)
+		return nil
+	}
+	return record
+}
+
+// showRecord replaces the list message with the full record, rendered the same way as a forward.
+func showRecord(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, messageID int, record *state.Record) {
+	keyboard := botport.NewKeyboard(
+		botport.NewRow(
+			botport.NewButton(tr(userState, config.MsgButtonToList), CallbackRecordPrefix+RecordActionList),
+			botport.NewButton(tr(userState, config.MsgButtonShare), CallbackRecordPrefix+RecordActionShare+":"+record.ID),
+		),
+	)
+
+	payload := buildForwardPayload(recordConfig, record, userState)
+	recordText, err := renderForwardMessage(payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "rendering the record failed", "record_id", record.ID, "user_id", userState.UserID, "err", err)
+		recordText = tr(userState, config.MsgRecordShowFailed)
+	}
+	text := tr(userState, config.MsgRecordView, idgen.ShortCode(record.ID), payload.CreatedAt, recordText)
+
+	if _, err := botPort.EditMessage(ctx, chatID, messageID, text, keyboard); err != nil && !botport.IsCode(err, "message_not_modified") {
+		slog.ErrorContext(ctx, "showing the record failed", "record_id", record.ID, "user_id", userState.UserID, "err", err)
+	}
+}
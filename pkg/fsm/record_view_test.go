@@ -0,0 +1,107 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestRecordViewFromList(t *testing.T) {
+	const userID = 31
+	recordConfig := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"about": {Title: "About", Questions: []config.QuestionConfig{
+				{ID: "name", Prompt: "Name?", Type: "text", StoreKey: "name"},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		callbacks []string
+		wantState string
+		wantOp    string
+		wantText  string // Substring of the last call of wantOp
+	}{
+		{
+			name:      "view opens the full record",
+			callbacks: []string{"record:view:rec-1"},
+			wantState: StateViewingRecord,
+			wantOp:    "edit_message",
+			wantText:  "A very long name that the list truncates",
+		},
+		{
+			name:      "back returns to the same page",
+			callbacks: []string{"record:view:rec-1", "record:list"},
+			wantState: StateViewingList,
+			wantOp:    "edit_message",
+			wantText:  "🗂️ Список записей (1 - 1 из 1)",
+		},
+		{
+			name:      "share sends the record to copy",
+			callbacks: []string{"record:view:rec-1", "record:share:rec-1"},
+			wantState: StateViewingRecord,
+			wantOp:    "send_message",
+			wantText:  "Чтобы поделиться",
+		},
+		{
+			name:      "unknown record stays in the list",
+			callbacks: []string{"record:view:missing"},
+			wantState: StateViewingList,
+			wantOp:    "answer_callback",
+			wantText:  "Не удалось показать запись.",
+		},
+		{
+			name:      "back outside the record view is refused",
+			callbacks: []string{"record:list"},
+			wantState: StateViewingList,
+			wantOp:    "answer_callback",
+			wantText:  "Действие недоступно",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore()
+			userState := store.GetOrCreateUserState(userID, "patient")
+			record := state.NewRecord()
+			record.ID = "rec-1"
+			record.IsSaved = true
+			record.CreatedAt = time.Now()
+			record.Data = stringAnswers(map[string]string{"name": "A very long name that the list truncates"})
+			userState.Records = append(userState.Records, record)
+			userState.MainMenuFSM.SetState(StateViewingList)
+
+			adapter := &fakeadapter.FakeAdapter{}
+			viewListHandler(context.Background(), userState, adapter, userID, 0, store)
+			list := adapter.LastCall("send_message")
+			if !list.Keyboard.HasCallback("record:view:rec-1") {
+				t.Fatalf("list must have a view button per record")
+			}
+
+			for _, data := range tt.callbacks {
+				handleCallbackQuery(context.Background(), callbackQuery(userID, list.MessageID, data), userState, adapter, recordConfig, store)
+			}
+
+			if got := userState.MainMenuFSM.Current(); got != tt.wantState {
+				t.Fatalf("main state = %s, want %s", got, tt.wantState)
+			}
+			var last *fakeadapter.Call
+			for i := range adapter.Calls {
+				if adapter.Calls[i].Op == tt.wantOp && (tt.wantOp != "answer_callback" || adapter.Calls[i].Text != "") {
+					last = &adapter.Calls[i]
+				}
+			}
+			if last == nil || !strings.Contains(last.Text, tt.wantText) {
+				t.Fatalf("last %s = %+v, want text containing %q", tt.wantOp, last, tt.wantText)
+			}
+			if tt.wantState == StateViewingRecord && !adapter.LastCall("edit_message").Keyboard.HasCallback("record:share:rec-1") {
+				t.Fatalf("record view must offer sharing")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// handleRelinkCommand serves "/relink [old ID]" from a user who switched Telegram accounts. Nothing is
+// moved yet: the admin gets the request and confirms it with a button or /admin relink, since only
+// they can tell that both accounts belong to the same person.
+func handleRelinkCommand(ctx context.Context, userState *state.UserState, botPort botport.BotPort, store *state.Store, chatID int64, args string) {
+	args = strings.TrimSpace(args)
+	var oldID int64
+	if args != "" {
+		var err error
+		if oldID, err = strconv.ParseInt(args, 10, 64); err != nil || oldID <= 0 || oldID == userState.UserID {
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgRelinkUsage), nil)
+			return
+		}
+	}
+	target := config.GetTargetUserID()
+	if target == 0 || target == userState.UserID {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgActionUnavailable), nil)
+		return
+	}
+
+	var text string
+	var markup interface{}
+	if oldID != 0 {
+		text = trTo(store, target, config.MsgRelinkRequest, userState.UserName, userState.UserID, oldID)
+		markup = botport.NewKeyboard(
+			botport.NewRow(
+				botport.NewButton("🔗 Перенести", fmt.Sprintf("%s%d:%d", CallbackRelinkPrefix, oldID, userState.UserID)),
+			),
+		)
+	} else {
+		text = trTo(store, target, config.MsgRelinkRequestNoID, userState.UserName, userState.UserID, userState.UserID)
+	}
+	if _, err := botPort.SendMessage(ctx, target, text, markup); err != nil {
+		slog.ErrorContext(ctx, "sending the relink request failed", "user_id", userState.UserID, "admin_id", target, "err", err)
+		_, _ = botPort.SendMessage(ctx, chatID, botErrorText(userState, err, tr(userState, config.MsgInternalError)), nil)
+		return
+	}
+	slog.InfoContext(ctx, "relink requested", "user_id", userState.UserID, "old_id", oldID)
+	_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgRelinkRequested), nil)
+}
+
+// handleRelinkCallback runs the relink the admin confirmed on a request message; value is "<old>:<new>".
+func handleRelinkCallback(ctx context.Context, query *botport.InboundEvent, admin *state.UserState, botPort botport.BotPort, store *state.Store, value string) {
+	if !isAdmin(admin.UserID) {
+		_ = botPort.AnswerCallback(ctx, query.CallbackID, tr(admin, config.MsgActionUnavailable))
+		return
+	}
+	oldStr, newStr, _ := strings.Cut(value, ":")
+	oldID, errOld := strconv.ParseInt(oldStr, 10, 64)
+	newID, errNew := strconv.ParseInt(newStr, 10, 64)
+	if errOld != nil || errNew != nil {
+		slog.WarnContext(ctx, "invalid relink payload", "value", value)
+		return
+	}
+
+	adminID := admin.UserID
+	whenUnlocked(ctx, func() {
+		result := relinkUser(ctx, adminID, botPort, store, oldID, newID)
+		text := query.Text + "\n\n" + result
+		emptyKeyboard := botport.NewKeyboard()
+		if _, err := botPort.EditMessage(ctx, query.ChatID, query.MessageID, text, emptyKeyboard); err != nil && !botport.IsCode(err, "message_not_modified") {
+			slog.ErrorContext(ctx, "updating the relink request failed", "message_id", query.MessageID, "err", err)
+		}
+	})
+}
+
+// relinkUser moves the history of oldID to newID and tells the user on the new account. It returns the
+// outcome for the admin. The admin cannot be relinked this way; TARGET_USER_ID is changed in the
+// environment instead. Callers hold no user's Mu.
+func relinkUser(ctx context.Context, adminID int64, botPort botport.BotPort, store *state.Store, oldID, newID int64) string {
+	if store == nil {
+		return "Хранилище недоступно."
+	}
+	if oldID == newID || oldID == adminID || newID == adminID {
+		return "Нельзя перенести записи администратора или аккаунт сам в себя."
+	}
+	from, ok := store.LockUser(oldID)
+	if !ok {
+		return fmt.Sprintf("Пользователь %d не найден.", oldID)
+	}
+	name := from.UserName
+	from.Mu.Unlock()
+	to := store.GetOrCreateUserState(newID, name)
+
+	// The one place that holds two users: lock in ID order, so it cannot deadlock with another relink.
+	first, second := from, to
+	if first.UserID > second.UserID {
+		first, second = second, first
+	}
+	first.Mu.Lock()
+	defer first.Mu.Unlock()
+	second.Mu.Lock()
+	defer second.Mu.Unlock()
+
+	records, deliveries, err := store.Relink(from, to)
+	if err != nil {
+		slog.ErrorContext(ctx, "relink failed", "admin_id", adminID, "old_id", oldID, "new_id", newID, "err", err)
+		return fmt.Sprintf("Ошибка: %v", err)
+	}
+	targetLink.relink(oldID, newID)
+	if reminderSchedule != nil {
+		if _, err := reminderSchedule.Move(oldID, newID); err != nil {
+			slog.ErrorContext(ctx, "moving the personal reminder failed", "old_id", oldID, "new_id", newID, "err", err)
+		}
+	}
+	syncUser(store, to)
+	slog.InfoContext(ctx, "user relinked", "admin_id", adminID, "old_id", oldID, "new_id", newID)
+
+	if _, err := botPort.SendMessage(ctx, newID, tr(to, config.MsgRelinkDone, records), nil); err != nil {
+		slog.ErrorContext(ctx, "notifying the relinked user failed", "user_id", newID, "err", err)
+	}
+	return fmt.Sprintf("✅ Перенесено с %d на %d: записей %d, отправок терапевту %d.", oldID, newID, records, deliveries)
+}
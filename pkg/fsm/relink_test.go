@@ -0,0 +1,134 @@
+package fsm
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/scheduler"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestRelinkMovesHistoryToNewAccount(t *testing.T) {
+	config.SetTargetUserID(100)
+	ctx := context.Background()
+	rc := &config.RecordConfig{}
+
+	tests := []struct {
+		name        string
+		request     string // /relink sent from the new account; empty to skip
+		admin       string // Admin command; empty to tap the request button instead
+		wantRequest string // Text of the request the admin gets
+		wantReply   string
+		wantMoved   bool
+	}{
+		{name: "request and button", request: "/relink 200", wantRequest: "со старого ID 200", wantReply: "записей 2, отправок терапевту 1", wantMoved: true},
+		{name: "request without old ID", request: "/relink", admin: "/admin relink 200 201", wantRequest: "/admin relink <старый ID> 201", wantReply: "записей 2", wantMoved: true},
+		{name: "unknown old account", admin: "/admin relink 300 201", wantReply: "Пользователь 300 не найден."},
+		{name: "admin account", admin: "/admin relink 100 201", wantReply: "Нельзя перенести"},
+		{name: "bad arguments", admin: "/admin relink 200", wantReply: "Использование: /admin relink"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := scheduler.Open("")
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			SetReminderSchedule(schedule)
+			defer SetReminderSchedule(nil)
+			if err := schedule.Set(200, "09:00", time.Now()); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			archive := mapArchive{}
+			store.SetUserArchive(archive)
+			admin := store.GetOrCreateUserState(100, "Admin")
+			old := store.GetOrCreateUserState(200, "Patient")
+			archive[200] = &state.UserState{UserID: 200, UserName: "Patient"} // The stored copy of the old account
+			first, second := state.NewRecord(), state.NewRecord()
+			first.ID, second.ID = "r1", "r2"
+			first.IsSaved, second.IsSaved = true, true
+			first.CreatedAt, second.CreatedAt = time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour)
+			old.Records = []*state.Record{first, second}
+			old.AutoForwardOff = true
+			store.AddDelivery(&state.Delivery{ID: "d1", UserID: 200, RecordID: second.ID, TargetID: 100, Status: state.DeliveryDelivered})
+
+			if tt.request != "" {
+				newUser := store.GetOrCreateUserState(201, "Patient")
+				handleMessage(ctx, commandMessage(201, tt.request), newUser, adapter, rc, store)
+				var request *fakeadapter.Call
+				for _, call := range adapter.Calls {
+					if call.Op == "send_message" && call.ChatID == 100 {
+						request = &call
+					}
+				}
+				if request == nil || request.ChatID != 100 || !strings.Contains(request.Text, tt.wantRequest) {
+					t.Fatalf("expected relink request to the admin containing %q, got %+v", tt.wantRequest, request)
+				}
+				if reply := adapter.LastCall("send_message"); reply.ChatID != 201 || reply.Text != config.Message("", config.MsgRelinkRequested) {
+					t.Fatalf("expected confirmation to the user, got %+v", reply)
+				}
+				if len(old.Records) != 2 {
+					t.Fatalf("a request alone must not move records")
+				}
+				if tt.admin == "" {
+					if data := request.Keyboard.Callbacks(); len(data) == 0 || data[0] != CallbackRelinkPrefix+"200:201" {
+						t.Fatalf("expected relink button, got %s", request.Keyboard)
+					}
+					query := callbackQuery(100, 7, request.Keyboard.Callbacks()[0])
+					query.Text = request.Text
+					handleCallbackQuery(ctx, query, admin, adapter, rc, store)
+					if edit := adapter.LastCall("edit_message"); edit == nil || !strings.Contains(edit.Text, tt.wantReply) {
+						t.Fatalf("expected request edited with %q, got %+v", tt.wantReply, edit)
+					}
+				}
+			}
+			if tt.admin != "" {
+				handleAdminCommand(ctx, commandMessage(100, tt.admin), admin, adapter, rc, store)
+				if reply := adapter.LastCall("send_message"); reply == nil || !strings.Contains(reply.Text, tt.wantReply) {
+					t.Fatalf("expected admin reply containing %q, got %+v", tt.wantReply, reply)
+				}
+			}
+
+			moved, ok := store.Get(201)
+			if !tt.wantMoved {
+				if _, stillThere := store.Get(200); !stillThere || len(old.Records) != 2 {
+					t.Fatalf("expected old account untouched")
+				}
+				if at, _ := schedule.Get(200); at != "09:00" {
+					t.Fatalf("expected the reminder kept on the old account")
+				}
+				return
+			}
+			if _, stillThere := store.Get(200); stillThere || slices.Contains(store.UserIDs(), 200) {
+				t.Fatalf("expected old account dropped from the store and its archive")
+			}
+			if !ok || len(moved.Records) != 2 || moved.Records[0] != first || !moved.AutoForwardOff {
+				t.Fatalf("expected history on the new account, got %+v", moved)
+			}
+			if got := store.Deliveries(201); len(got) != 1 || got[0].ID != "d1" {
+				t.Fatalf("expected delivery moved, got %+v", got)
+			}
+			if at, _ := schedule.Get(201); at != "09:00" {
+				t.Fatalf("expected the personal reminder moved, got %q", at)
+			}
+			if _, ok := schedule.Get(200); ok {
+				t.Fatalf("expected no reminder left on the old ID")
+			}
+			notified := false
+			for _, call := range adapter.Calls {
+				if call.ChatID == 201 && strings.Contains(call.Text, "перенесены: 2") {
+					notified = true
+				}
+			}
+			if !notified {
+				t.Fatalf("expected the new account to be notified, calls: %+v", adapter.Calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logging"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// TransportCheck refuses reloaded configs that botPort cannot serve, like the startup check does.
+func TransportCheck(botPort botport.BotPort) func(*config.RecordConfig) error {
+	return func(recordConfig *config.RecordConfig) error {
+		return questions.CheckTransport(recordConfig, botPort.Capabilities())
+	}
+}
+
+// ReconcileUsers moves users who are in the middle of a record onto a reloaded config. Answers to
+// removed questions are dropped with a notice; a user whose current section or question is gone is
+// repaired by recoverFromConfigDrift, and everyone else answering gets the prompt re-rendered with
+// the new wording.
+func ReconcileUsers(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
+	ctx = logging.NewContext(ctx)
+	for _, userID := range store.UserIDs() {
+		userState, ok := store.LockUser(userID)
+		if !ok {
+			continue
+		}
+		reconcileUser(ctx, userState, botPort, recordConfig)
+		userState.Mu.Unlock()
+	}
+}
+
+// reconcileUser applies a reloaded config to one user. Drafts of a survey template are left alone:
+// only the main config reloads. Caller must hold userState.Mu.
+func reconcileUser(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig) {
+	current := userState.RecordFSM.Current()
+	if current == StateRecordIdle || recordConfigFor(recordConfig, userState.CurrentRecord) != recordConfig {
+		return
+	}
+	if current == StateAnsweringQuestion {
+		sectionConf, ok := recordConfig.Sections[userState.CurrentSection]
+		if !ok || userState.CurrentQuestion >= len(sectionConf.Questions) {
+			slog.WarnContext(ctx, "current section or question lost with the reload", "user_id", userState.UserID, "section", userState.CurrentSection, "question_index", userState.CurrentQuestion)
+			recoverFromConfigDrift(ctx, userState, botPort, recordConfig, userState.UserID)
+			return
+		}
+	}
+	if notice := orphanNotice(userState, pruneOrphanedAnswers(recordConfig, userState.CurrentRecord)); notice != "" {
+		_, _ = botPort.SendMessage(ctx, userState.UserID, notice, nil)
+	}
+	if !userState.Preview {
+		drawSamples(recordConfig, userState.CurrentRecord)
+	}
+	if current == StateAnsweringQuestion {
+		askCurrentQuestion(ctx, userState, botPort, recordConfig, userState.LastMessageID)
+	}
+}
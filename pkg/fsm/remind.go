@@ -0,0 +1,109 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logging"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/scheduler"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+const personalReminderInterval = time.Minute
+
+// reminderSchedule holds the personal reminder times set with /remind; nil disables the command.
+var reminderSchedule *scheduler.Schedule
+
+// SetReminderSchedule enables /remind and personal reminders on schedule. Call it before handling
+// updates.
+func SetReminderSchedule(schedule *scheduler.Schedule) {
+	reminderSchedule = schedule
+}
+
+// hasPersonalReminder reports whether userID chose their own reminder time, which replaces the common one.
+func hasPersonalReminder(userID int64) bool {
+	if reminderSchedule == nil {
+		return false
+	}
+	_, ok := reminderSchedule.Get(userID)
+	return ok
+}
+
+// RunPersonalReminders sends each user their daily reminder at the time they chose until ctx is cancelled.
+func RunPersonalReminders(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
+	if reminderSchedule == nil {
+		return
+	}
+	scheduler.Run(ctx, reminderSchedule, personalReminderInterval, func(ctx context.Context, userID int64) {
+		sendPersonalReminder(logging.NewContext(ctx), botPort, recordConfig, store, userID)
+	})
+}
+
+// sendPersonalReminder sends the reminder text of the config, or the default prompt when the common
+// reminder is not configured, with its buttons or a single button opening the section menu.
+func sendPersonalReminder(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, userID int64) {
+	lang := ""
+	if userState, ok := store.Get(userID); ok {
+		userState.Mu.Lock()
+		lang = userState.Lang()
+		userState.Mu.Unlock()
+	}
+	text := recordConfig.Reminders.Text
+	if text == "" {
+		text = config.Message(lang, config.MsgPersonalReminder)
+	}
+
+	keyboard, ok := reminderKeyboard(recordConfig.Reminders)
+	if !ok {
+		keyboard = botport.NewKeyboard(botport.NewRow(
+			botport.NewButton(config.Message(lang, ButtonReminderFill), CallbackRemindPrefix),
+		))
+	}
+	opts := lowPriorityOptions(recordConfig)
+	sendMedia(ctx, botPort, userID, recordConfig.Reminders.Media, opts...)
+	if _, err := botPort.SendMessage(ctx, userID, text, keyboard, opts...); err != nil {
+		slog.ErrorContext(ctx, "sending the personal reminder failed", "user_id", userID, "err", err)
+	}
+}
+
+// handleRemindCommand shows or changes the user's reminder time: /remind HH:MM|off.
+func handleRemindCommand(ctx context.Context, userState *state.UserState, botPort botport.BotPort, chatID int64, arg string) {
+	if reminderSchedule == nil {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgRemindUnavailable), nil)
+		return
+	}
+
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	switch arg {
+	case "":
+		if at, ok := reminderSchedule.Get(userState.UserID); ok {
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgRemindCurrent, at), nil)
+		} else {
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgRemindUsage), nil)
+		}
+	case "off":
+		if err := reminderSchedule.Clear(userState.UserID); err != nil {
+			slog.ErrorContext(ctx, "clearing the reminder failed", "user_id", userState.UserID, "err", err)
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgInternalError), nil)
+			return
+		}
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgRemindOff), nil)
+	default:
+		at, err := time.Parse(scheduler.TimeLayout, arg)
+		if err != nil {
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgRemindUsage), nil)
+			return
+		}
+		normalized := at.Format(scheduler.TimeLayout)
+		if err := reminderSchedule.Set(userState.UserID, normalized, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "saving the reminder failed", "user_id", userState.UserID, "err", err)
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgInternalError), nil)
+			return
+		}
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgRemindSet, normalized), nil)
+	}
+}
@@ -0,0 +1,83 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/scheduler"
+)
+
+func TestRemindCommand(t *testing.T) {
+	schedule, err := scheduler.Open("")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	SetReminderSchedule(schedule)
+	defer SetReminderSchedule(nil)
+
+	store := newTestStore()
+	userState := store.GetOrCreateUserState(5, "User")
+	rc := &config.RecordConfig{}
+
+	steps := []struct {
+		command  string
+		wantText string
+		wantTime string // Scheduled time after the command; empty when none
+	}{
+		{command: "/remind", wantText: config.Message("", config.MsgRemindUsage)},
+		{command: "/remind 9:05", wantText: config.Message("", config.MsgRemindSet, "09:05"), wantTime: "09:05"},
+		{command: "/remind", wantText: config.Message("", config.MsgRemindCurrent, "09:05"), wantTime: "09:05"},
+		{command: "/remind noon", wantText: config.Message("", config.MsgRemindUsage), wantTime: "09:05"},
+		{command: "/remind off", wantText: config.Message("", config.MsgRemindOff)},
+	}
+	for _, step := range steps {
+		adapter := &fakeadapter.FakeAdapter{}
+		handleMessage(context.Background(), commandMessage(5, step.command), userState, adapter, rc, store)
+
+		if call := adapter.LastCall("send_message"); call == nil || call.Text != step.wantText {
+			t.Fatalf("%s: reply %+v, want %q", step.command, call, step.wantText)
+		}
+		if at, _ := schedule.Get(5); at != step.wantTime {
+			t.Fatalf("%s: scheduled %q, want %q", step.command, at, step.wantTime)
+		}
+		if hasPersonalReminder(5) != (step.wantTime != "") {
+			t.Fatalf("%s: personal reminder must replace the common one only while set", step.command)
+		}
+	}
+}
+
+func TestSendPersonalReminder(t *testing.T) {
+	store := newTestStore()
+	store.GetOrCreateUserState(5, "User")
+
+	tests := []struct {
+		name       string
+		reminders  config.ReminderConfig
+		wantText   string
+		wantButton string
+	}{
+		{name: "default prompt", wantText: config.Message("", config.MsgPersonalReminder), wantButton: CallbackRemindPrefix},
+		{
+			name:       "configured reminder",
+			reminders:  config.ReminderConfig{Text: "Time to fill", Buttons: []config.ReminderButton{{Text: "Morning", Section: "morning"}}},
+			wantText:   "Time to fill",
+			wantButton: CallbackRemindPrefix + "morning",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			sendPersonalReminder(context.Background(), adapter, &config.RecordConfig{Reminders: tt.reminders}, store, 5)
+
+			call := adapter.LastCall("send_message")
+			if call == nil || call.ChatID != 5 || call.Text != tt.wantText {
+				t.Fatalf("reminder = %+v, want %q", call, tt.wantText)
+			}
+			if !call.Keyboard.HasCallback(tt.wantButton) {
+				t.Fatalf("reminder keyboard lacks %q: %+v", tt.wantButton, call.Keyboard)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logging"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// RunReminders sends the configured daily reminder to every known user until ctx is cancelled.
+func RunReminders(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
+	reminders := recordConfig.Reminders
+	if !reminders.Enabled {
+		return
+	}
+	at, err := time.Parse(config.ReminderTimeLayout, reminders.Time)
+	if err != nil {
+		slog.ErrorContext(ctx, "invalid reminder time", "time", reminders.Time, "err", err)
+		return
+	}
+
+	for {
+		wait := time.Until(nextDailyRun(time.Now(), at.Hour(), at.Minute()))
+		slog.InfoContext(ctx, "next reminder scheduled", "in", wait.Round(time.Second))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		sendReminders(logging.NewContext(ctx), botPort, recordConfig, store)
+	}
+}
+
+// sendReminders sends the daily reminder to every known user without a personal time, and the
+// inactivity nudges that are due.
+func sendReminders(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
+	for _, userID := range store.UserIDs() {
+		if !hasPersonalReminder(userID) {
+			sendReminder(ctx, botPort, recordConfig, userID)
+		}
+		sendNudgeIfDue(ctx, botPort, recordConfig, store, userID)
+	}
+}
+
+func sendNudgeIfDue(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, userID int64) {
+	userState, ok := store.LockUser(userID)
+	if !ok {
+		return
+	}
+	text, notifyTarget, due := nextNudge(time.Now(), userState, store, recordConfig.Reminders.Nudges)
+	userName := userState.UserName
+	targetID := targetFor(userState)
+	userState.Mu.Unlock()
+	if !due {
+		return
+	}
+
+	opts := lowPriorityOptions(recordConfig)
+	if _, err := botPort.SendMessage(ctx, userID, text, nil, opts...); err != nil {
+		slog.ErrorContext(ctx, "nudging the user failed", "user_id", userID, "err", err)
+	}
+	if notifyTarget && targetID != 0 && targetID != userID {
+		alert := trTo(store, targetID, config.MsgInactivityAlert, userName, userID)
+		if _, err := botPort.SendMessage(ctx, targetID, alert, nil, opts...); err != nil {
+			slog.ErrorContext(ctx, "alerting the target about the user failed", "target_id", targetID, "user_id", userID, "err", err)
+		}
+	}
+}
+
+// nextNudge decides whether a follow-up is due for a user who has not saved a record recently.
+// It runs once per daily tick, so at most one nudge is produced per day. Caller must hold userState.Mu.
+func nextNudge(now time.Time, userState *state.UserState, store *state.Store, nudges config.NudgeConfig) (text string, notifyTarget bool, due bool) {
+	if nudges.AfterDays <= 0 || userState.NudgesSent >= nudges.MaxNudges {
+		return "", false, false
+	}
+	lastActivity := userState.CreatedAt
+	if saved := lastSavedRecord(userState, store); saved != nil {
+		lastActivity = saved.CreatedAt
+	}
+	if now.Sub(lastActivity) < time.Duration(nudges.AfterDays)*24*time.Hour {
+		return "", false, false
+	}
+
+	idx := userState.NudgesSent
+	if idx >= len(nudges.Messages) {
+		idx = len(nudges.Messages) - 1
+	}
+	userState.NudgesSent++
+	return nudges.Messages[idx], nudges.NotifyTarget && userState.NudgesSent == nudges.MaxNudges, true
+}
+
+// lowPriorityOptions sends low-priority messages silently while the clock is inside quiet_hours.
+func lowPriorityOptions(recordConfig *config.RecordConfig) []botport.SendOption {
+	if recordConfig.QuietHours != nil && recordConfig.QuietHours.Contains(clock()) {
+		return []botport.SendOption{botport.Silent()}
+	}
+	return nil
+}
+
+// nextDailyRun returns the next moment after now at hour:minute in now's location.
+func nextDailyRun(now time.Time, hour, minute int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func sendReminder(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
+	opts := lowPriorityOptions(recordConfig)
+	sendMedia(ctx, botPort, chatID, recordConfig.Reminders.Media, opts...)
+	var markup interface{}
+	if keyboard, ok := reminderKeyboard(recordConfig.Reminders); ok {
+		markup = keyboard
+	}
+	if _, err := botPort.SendMessage(ctx, chatID, recordConfig.Reminders.Text, markup, opts...); err != nil {
+		slog.ErrorContext(ctx, "sending the reminder failed", "chat_id", chatID, "err", err)
+	}
+}
+
+func reminderKeyboard(reminders config.ReminderConfig) (*botport.Keyboard, bool) {
+	keyboard := botport.NewKeyboard()
+	for _, button := range reminders.Buttons {
+		keyboard.AddRow(botport.NewButton(button.Text, CallbackRemindPrefix+button.Section))
+	}
+	return keyboard, len(keyboard.Rows) > 0
+}
+
+// startFromReminder handles reminder buttons: a section jumps straight into it, an empty one opens the section menu.
+func startFromReminder(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, chatID int64, sectionID string) {
+	if sectionID != "" {
+		openSectionDirectly(ctx, userState, botPort, recordConfig, store, chatID, sectionID)
+		return
+	}
+	if userState.RecordFSM.Current() == StateRecordIdle {
+		startOrResumeRecordCreation(ctx, userState, botPort, recordConfig, store, chatID)
+		return
+	}
+	_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgAlreadyFilling), nil)
+}
@@ -0,0 +1,115 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestNextDailyRun(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if got := nextDailyRun(now, 20, 30); !got.Equal(time.Date(2024, 5, 1, 20, 30, 0, 0, time.UTC)) {
+		t.Fatalf("expected same-day run, got %v", got)
+	}
+	if got := nextDailyRun(now, 9, 0); !got.Equal(time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected next-day run, got %v", got)
+	}
+}
+
+func TestReminderButtonOpensSection(t *testing.T) {
+	questions.RegisterBuiltins()
+	recordConfig := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"morning": {Title: "Morning", Questions: []config.QuestionConfig{{ID: "q", Prompt: "How?", Type: "text", StoreKey: "k"}}},
+		},
+		Reminders: config.ReminderConfig{
+			Enabled: true,
+			Time:    "09:00",
+			Text:    "Time to fill",
+			Buttons: []config.ReminderButton{{Text: "Заполнить утреннюю секцию", Section: "morning"}},
+		},
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+
+	sendReminder(context.Background(), adapter, recordConfig, 8)
+	call := adapter.LastCall("send_message")
+	if call.Keyboard.Kind != fakeadapter.KeyboardInline || len(call.Keyboard.Rows) != 1 || call.Keyboard.Rows[0][0].Data != CallbackRemindPrefix+"morning" {
+		t.Fatalf("unexpected reminder keyboard: %s", call.Keyboard)
+	}
+
+	handler := newTestHandler(adapter, recordConfig)
+	userState := &state.UserState{UserID: 8, MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+	handleCallbackQuery(context.Background(), callbackQuery(8, call.MessageID, CallbackRemindPrefix+"morning"), userState, adapter, recordConfig, nil)
+
+	if userState.RecordFSM.Current() != StateAnsweringQuestion || userState.CurrentSection != "morning" {
+		t.Fatalf("expected reminder tap to open section, got state=%s section=%q", userState.RecordFSM.Current(), userState.CurrentSection)
+	}
+}
+
+func TestNextNudgeEscalatesAndCaps(t *testing.T) {
+	now := time.Date(2024, 5, 10, 20, 0, 0, 0, time.UTC)
+	nudges := config.NudgeConfig{AfterDays: 2, MaxNudges: 3, Messages: []string{"first", "second"}, NotifyTarget: true}
+	saved := &state.Record{IsSaved: true, CreatedAt: now.AddDate(0, 0, -1)}
+	userState := &state.UserState{Records: []*state.Record{saved}}
+
+	if _, _, due := nextNudge(now, userState, nil, nudges); due {
+		t.Fatalf("expected no nudge one day after saving")
+	}
+
+	saved.CreatedAt = now.AddDate(0, 0, -3)
+	var texts []string
+	var alerts int
+	for i := 0; i < 5; i++ {
+		text, notify, due := nextNudge(now, userState, nil, nudges)
+		if !due {
+			break
+		}
+		texts = append(texts, text)
+		if notify {
+			alerts++
+		}
+	}
+	if len(texts) != 3 || texts[0] != "first" || texts[1] != "second" || texts[2] != "second" {
+		t.Fatalf("unexpected nudge sequence: %v", texts)
+	}
+	if alerts != 1 {
+		t.Fatalf("expected a single target alert on the last nudge, got %d", alerts)
+	}
+}
+
+func TestRemindersAreSilentInQuietHours(t *testing.T) {
+	defer func() { clock = time.Now }()
+	recordConfig := &config.RecordConfig{
+		Reminders:  config.ReminderConfig{Enabled: true, Time: "23:00", Text: "Time to fill", Media: config.MediaConfig{Sticker: "sticker-id"}},
+		QuietHours: &config.TimeWindow{From: "22:00", To: "08:00"},
+	}
+
+	tests := []struct {
+		name       string
+		at         time.Time
+		wantSilent bool
+	}{
+		{name: "night", at: time.Date(2025, 3, 1, 23, 0, 0, 0, time.Local), wantSilent: true},
+		{name: "day", at: time.Date(2025, 3, 1, 19, 0, 0, 0, time.Local), wantSilent: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock = func() time.Time { return tt.at }
+			adapter := &fakeadapter.FakeAdapter{}
+
+			sendReminder(context.Background(), adapter, recordConfig, 8)
+
+			for _, op := range []string{"send_message", "send_sticker"} {
+				call := adapter.LastCall(op)
+				if call == nil || call.Options.DisableNotification != tt.wantSilent {
+					t.Fatalf("%s = %+v, want silent=%t", op, call, tt.wantSilent)
+				}
+			}
+		})
+	}
+}
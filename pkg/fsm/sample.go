@@ -0,0 +1,52 @@
+package fsm
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// samplePerm shuffles question indexes for section sampling; tests replace it to make the draw fixed.
+var samplePerm = rand.Perm
+
+// drawSamples picks the questions of every sampled section the record has not drawn yet. The draw is
+// kept in Record.Sampled, so the record asks, shows and forwards the same questions until it is saved
+// and the next record draws again.
+func drawSamples(recordConfig *config.RecordConfig, record *state.Record) {
+	if record == nil {
+		return
+	}
+	for sectionID, sectionConf := range recordConfig.Sections {
+		if sectionConf.Sample <= 0 || sectionConf.Sample >= len(sectionConf.Questions) || sectionDrawn(sectionConf, record) {
+			continue
+		}
+		picked := samplePerm(len(sectionConf.Questions))[:sectionConf.Sample]
+		sort.Ints(picked)
+		if record.Sampled == nil {
+			record.Sampled = make(map[string]bool)
+		}
+		for _, q := range sectionConf.Questions {
+			record.Sampled[q.ID] = false
+		}
+		ids := make([]string, 0, len(picked))
+		for _, idx := range picked {
+			q := sectionConf.Questions[idx]
+			record.Sampled[q.ID] = true
+			ids = append(ids, q.ID)
+		}
+		slog.Info("questions drawn for the section", "record_id", record.ID, "questions", ids, "section", sectionID)
+	}
+}
+
+// sectionDrawn reports whether the record already holds a draw for the section.
+func sectionDrawn(sectionConf config.SectionConfig, record *state.Record) bool {
+	for _, q := range sectionConf.Questions {
+		if _, ok := record.Sampled[q.ID]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func sampleTestConfig(sample int) *config.RecordConfig {
+	qs := make([]config.QuestionConfig, 0, 4)
+	for _, id := range []string{"p1", "p2", "p3", "p4"} {
+		qs = append(qs, config.QuestionConfig{ID: id, Prompt: id + "?", Type: "text", StoreKey: id})
+	}
+	return &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"pool": {Title: "Pool", Sample: sample, Questions: qs},
+	}}
+}
+
+func TestDrawSamples(t *testing.T) {
+	restore := samplePerm
+	defer func() { samplePerm = restore }()
+
+	tests := []struct {
+		name      string
+		sample    int
+		sampled   map[string]bool // Draw already in the record
+		wantShown []string
+	}{
+		{name: "draws in config order", sample: 2, wantShown: []string{"p2", "p4"}},
+		{name: "whole pool is not sampled", sample: 4, wantShown: []string{"p1", "p2", "p3", "p4"}},
+		{name: "no sampling", sample: 0, wantShown: []string{"p1", "p2", "p3", "p4"}},
+		{name: "earlier draw is kept", sample: 2, sampled: map[string]bool{"p1": true, "p2": false, "p3": true, "p4": false}, wantShown: []string{"p1", "p3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samplePerm = func(n int) []int { return []int{3, 1, 0, 2}[:n] }
+			rc := sampleTestConfig(tt.sample)
+			record := state.NewRecord()
+			record.Sampled = tt.sampled
+
+			drawSamples(rc, record)
+			samplePerm = func(n int) []int { return []int{0, 2, 1, 3}[:n] }
+			drawSamples(rc, record)
+
+			var shown []string
+			for _, q := range rc.Sections["pool"].Questions {
+				if record.Visible(q) {
+					shown = append(shown, q.ID)
+				}
+			}
+			if len(shown) != len(tt.wantShown) {
+				t.Fatalf("shown %v, want %v", shown, tt.wantShown)
+			}
+			for i := range shown {
+				if shown[i] != tt.wantShown[i] {
+					t.Fatalf("shown %v, want %v", shown, tt.wantShown)
+				}
+			}
+		})
+	}
+}
+
+func TestSampledSectionStartsAtDrawnQuestion(t *testing.T) {
+	restore := samplePerm
+	defer func() { samplePerm = restore }()
+	samplePerm = func(n int) []int { return []int{2, 0, 1, 3}[:n] }
+
+	rc := sampleTestConfig(1)
+	adapter := &fakeadapter.FakeAdapter{}
+	store := newTestHandler(adapter, rc).Store()
+	userState := store.GetOrCreateUserState(10, "User")
+
+	startOrResumeRecordCreation(context.Background(), userState, adapter, rc, nil, 10)
+	selectSection(context.Background(), userState, adapter, rc, 10, 0, "pool")
+
+	if userState.CurrentSection != "pool" || userState.CurrentQuestion != 2 {
+		t.Fatalf("at section %q question %d, want the drawn question 2", userState.CurrentSection, userState.CurrentQuestion)
+	}
+	if call := adapter.LastCall("edit_message"); call == nil || !strings.Contains(call.Text, "p3?") {
+		t.Fatalf("prompt %+v, want question p3", call)
+	}
+}
@@ -0,0 +1,86 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestShowIfSkipsHiddenQuestions(t *testing.T) {
+	questions.RegisterBuiltins()
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Section", Questions: []config.QuestionConfig{
+			{ID: "q1", Prompt: "Mood?", Type: "buttons", StoreKey: "mood", Options: []config.ButtonOption{{Text: "Good", Value: "good"}, {Text: "Bad", Value: "bad"}}},
+			{ID: "q2", Prompt: "What went wrong?", Type: "text", StoreKey: "why", ShowIf: &config.ShowIfConfig{StoreKey: "mood", Values: []string{"bad"}}},
+			{ID: "q3", Prompt: "Tell us more about it?", Type: "text", StoreKey: "more", ShowIf: &config.ShowIfConfig{StoreKey: "why"}},
+		}},
+	}}
+
+	tests := []struct {
+		name         string
+		mood         string
+		wantQuestion int // Index after answering q1; -1 when the section completes
+		wantState    string
+		wantProgress string // answered/total after answering q1
+	}{
+		{name: "condition holds", mood: "bad", wantQuestion: 1, wantState: StateAnsweringQuestion, wantProgress: "1/2"},
+		{name: "rest of the section hidden", mood: "good", wantQuestion: -1, wantState: StateSelectingSection, wantProgress: "1/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, rc)
+			userState := &state.UserState{
+				UserID:         9,
+				CurrentRecord:  state.NewRecord(),
+				CurrentSection: "sec",
+				MainMenuFSM:    handler.NewMainMenuFSM(),
+				RecordFSM:      handler.NewRecordFSM(),
+			}
+			userState.RecordFSM.SetState(StateAnsweringQuestion)
+			userState.CurrentRecord.Data["mood"] = state.StringAnswer(tt.mood)
+
+			processAnswer(context.Background(), userState, adapter, rc, 0)
+
+			if got := userState.RecordFSM.Current(); got != tt.wantState {
+				t.Fatalf("state = %s, want %s", got, tt.wantState)
+			}
+			if tt.wantQuestion >= 0 && userState.CurrentQuestion != tt.wantQuestion {
+				t.Fatalf("question = %d, want %d", userState.CurrentQuestion, tt.wantQuestion)
+			}
+			answered, total := sectionProgress(rc.Sections["sec"], userState.CurrentRecord)
+			if got := fmt.Sprintf("%d/%d", answered, total); got != tt.wantProgress {
+				t.Fatalf("progress = %s, want %s", got, tt.wantProgress)
+			}
+			payload := buildForwardPayload(rc, userState.CurrentRecord, userState)
+			if got := len(payload.Sections[0].Questions); got != total {
+				t.Fatalf("forwarded %d questions, want the %d shown", got, total)
+			}
+		})
+	}
+}
+
+func TestSectionWithOnlyHiddenQuestions(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"a":     {Title: "A", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Pain?", Type: "text", StoreKey: "pain"}}},
+		"later": {Title: "Later", Questions: []config.QuestionConfig{{ID: "q2", Prompt: "Where?", Type: "text", StoreKey: "where", ShowIf: &config.ShowIfConfig{StoreKey: "pain"}}}},
+	}}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	userState := &state.UserState{UserID: 9, CurrentRecord: state.NewRecord(), MainMenuFSM: handler.NewMainMenuFSM(), RecordFSM: handler.NewRecordFSM()}
+	userState.RecordFSM.SetState(StateSelectingSection)
+
+	selectSection(context.Background(), userState, adapter, rc, 9, 0, "later")
+
+	if userState.RecordFSM.Current() != StateSelectingSection || userState.CurrentSection != "" {
+		t.Fatalf("expected to stay in the section menu, state=%s section=%q", userState.RecordFSM.Current(), userState.CurrentSection)
+	}
+	if call := adapter.LastCall("send_message"); call == nil || call.Text != config.Message("", config.MsgSectionNoQuestions, "Later") {
+		t.Fatalf("expected a notice, got %+v", call)
+	}
+}
@@ -0,0 +1,336 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/storeport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/storage/memadapter"
+)
+
+func TestSavedRecordsSurviveRestart(t *testing.T) {
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Section", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "City?", Type: "text", StoreKey: "city"}}},
+	}}
+	port := memadapter.New()
+	adapter := &fakeadapter.FakeAdapter{}
+
+	handler := newTestHandler(adapter, rc)
+	store := handler.Store()
+	if err := store.SetBackend(storeport.NewBackend(port), 0); err != nil {
+		t.Fatalf("SetBackend: %v", err)
+	}
+	userState := store.GetOrCreateUserState(12, "")
+	userState.CurrentRecord = state.NewRecord()
+	userState.CurrentRecord.Data["city"] = state.StringAnswer("Rome")
+	userState.RecordFSM.SetState(StateSelectingSection)
+	handler.HandleUpdate(context.Background(), *callbackQuery(12, 1, CallbackActionPrefix+ActionSaveRecord))
+
+	ids := port.RecordIDs(12)
+	if len(ids) != 1 || ids[0] != userState.Records[0].ID {
+		t.Fatalf("saved record must be written, backend has %v", ids)
+	}
+	if _, ok, _ := port.GetUserState(context.Background(), 12); !ok {
+		t.Fatalf("user must be written")
+	}
+
+	restarted := newTestHandler(adapter, rc).Store()
+	if err := restarted.SetBackend(storeport.NewBackend(port), 0); err != nil {
+		t.Fatalf("SetBackend: %v", err)
+	}
+	restored := restarted.GetOrCreateUserState(12, "")
+	if restored.RecordFSM.Current() != StateRecordIdle {
+		t.Fatalf("restored user must start idle, got %s", restored.RecordFSM.Current())
+	}
+	viewListHandler(context.Background(), restored, adapter, 12, 0, restarted)
+	if text := adapter.LastCall("send_message").Text; !strings.Contains(text, "Город: Rome") {
+		t.Fatalf("records must be listed after a restart, got %q", text)
+	}
+}
+
+func TestDraftResumesAfterRestart(t *testing.T) {
+	questions.RegisterBuiltins()
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Section", Questions: []config.QuestionConfig{
+			{ID: "q1", Prompt: "City?", Type: "text", StoreKey: "city"},
+			{ID: "q2", Prompt: "Weather?", Type: "text", StoreKey: "weather"},
+		}},
+	}}
+	port := memadapter.New()
+	adapter := &fakeadapter.FakeAdapter{}
+
+	handler := newTestHandler(adapter, rc)
+	store := handler.Store()
+	if err := store.SetBackend(storeport.NewBackend(port), 0); err != nil {
+		t.Fatalf("SetBackend: %v", err)
+	}
+	for _, update := range []*botport.InboundEvent{
+		textMessage(13, config.Message("ru", ButtonMainMenuFillRecord)),
+		callbackQuery(13, 1, CallbackSectionPrefix+"sec"),
+		textMessage(13, "Rome"),
+	} {
+		handler.HandleUpdate(context.Background(), *update)
+	}
+
+	restartedHandler := newTestHandler(adapter, rc)
+	restarted := restartedHandler.Store()
+	if err := restarted.SetBackend(storeport.NewBackend(port), 0); err != nil {
+		t.Fatalf("SetBackend: %v", err)
+	}
+	restored := restarted.GetOrCreateUserState(13, "")
+	if restored.RecordFSM.Current() != StateAnsweringQuestion || restored.CurrentSection != "sec" || restored.CurrentQuestion != 1 {
+		t.Fatalf("restored at %s, section %q, question %d; want the second question", restored.RecordFSM.Current(), restored.CurrentSection, restored.CurrentQuestion)
+	}
+
+	restartedHandler.HandleUpdate(context.Background(), *textMessage(13, "Sunny"))
+	draft := restored.CurrentRecord
+	if draft == nil || draft.Data["city"].String() != "Rome" || draft.Data["weather"].String() != "Sunny" {
+		t.Fatalf("draft %+v, want both answers", draft)
+	}
+}
+
+func TestStoredRecordsAfterRestart(t *testing.T) {
+	config.SetTargetUserID(600)
+	defer config.SetTargetUserID(0)
+	if err := config.SetFeature(config.FeatureRequireAck, true); err != nil {
+		t.Fatalf("SetFeature: %v", err)
+	}
+	defer func() { _ = config.SetFeature(config.FeatureRequireAck, false) }()
+	rc := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {Title: "Section", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "City?", Type: "text", StoreKey: "city"}}},
+		},
+		AutoForward: config.AutoForwardConfig{Enabled: true, Time: "21:00", Cutoff: "20:00"},
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		run   func(store *state.Store, userState *state.UserState, adapter *fakeadapter.FakeAdapter)
+		check func(t *testing.T, store *state.Store, port *memadapter.MemAdapter, adapter *fakeadapter.FakeAdapter)
+	}{
+		{
+			name: "main menu count",
+			run: func(store *state.Store, userState *state.UserState, adapter *fakeadapter.FakeAdapter) {
+				sendMainMenu(ctx, adapter, userState, store)
+			},
+			check: func(t *testing.T, _ *state.Store, _ *memadapter.MemAdapter, adapter *fakeadapter.FakeAdapter) {
+				if text := adapter.LastCall("send_message").Text; !strings.Contains(text, config.Message("ru", config.MsgUserStats, "", 12, 1)) {
+					t.Fatalf("menu %q must count the stored record", text)
+				}
+			},
+		},
+		{
+			name: "forward and acknowledge",
+			run: func(store *state.Store, userState *state.UserState, adapter *fakeadapter.FakeAdapter) {
+				handleForwardAnsweredSections(ctx, userState, adapter, rc, 12, store)
+				delivery := store.PendingDeliveries(12)[0]
+				therapist := store.GetOrCreateUserState(600, "Therapist")
+				acknowledgeDelivery(ctx, callbackQuery(600, 5, CallbackAckPrefix+delivery.ID), therapist, adapter, store, delivery.ID)
+			},
+			check: func(t *testing.T, store *state.Store, port *memadapter.MemAdapter, adapter *fakeadapter.FakeAdapter) {
+				if forward := adapter.Calls[0]; forward.ChatID != 600 || !strings.Contains(forward.Text, "Rome") {
+					t.Fatalf("the stored record must be forwarded, got %+v", forward)
+				}
+				if ids := port.RecordIDs(12); len(ids) != 0 {
+					t.Fatalf("acknowledged record must be deleted from the backend, has %v", ids)
+				}
+			},
+		},
+		{
+			name: "auto-forward",
+			run: func(store *state.Store, userState *state.UserState, adapter *fakeadapter.FakeAdapter) {
+				autoForwardUser(ctx, adapter, rc, store, 12, clock().Add(time.Hour))
+			},
+			check: func(t *testing.T, _ *state.Store, _ *memadapter.MemAdapter, adapter *fakeadapter.FakeAdapter) {
+				if forward := adapter.Calls[0]; forward.ChatID != 600 || !strings.Contains(forward.Text, "Rome") {
+					t.Fatalf("the stored record must be auto-forwarded, got %+v", adapter.Calls)
+				}
+			},
+		},
+		{
+			name: "relink",
+			run: func(store *state.Store, userState *state.UserState, adapter *fakeadapter.FakeAdapter) {
+				admin := store.GetOrCreateUserState(600, "Admin")
+				relinkUser(ctx, admin.UserID, adapter, store, 12, 13)
+			},
+			check: func(t *testing.T, store *state.Store, port *memadapter.MemAdapter, _ *fakeadapter.FakeAdapter) {
+				if ids := port.RecordIDs(13); len(ids) != 1 {
+					t.Fatalf("the stored record must move to the new account, backend has %v", ids)
+				}
+				if n := store.CountRecords(13, state.RecordFilter{}); n != 1 {
+					t.Fatalf("new account has %d records, want 1", n)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := memadapter.New()
+			record := state.NewRecord()
+			record.ID, record.IsSaved, record.CreatedAt = "r1", true, clock().Add(-time.Hour)
+			record.Data["city"] = state.StringAnswer("Rome")
+			if err := port.SaveRecord(ctx, 12, record); err != nil {
+				t.Fatalf("SaveRecord: %v", err)
+			}
+
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			if err := store.SetBackend(storeport.NewBackend(port), 0); err != nil {
+				t.Fatalf("SetBackend: %v", err)
+			}
+			userState := store.GetOrCreateUserState(12, "")
+			tt.run(store, userState, adapter)
+			tt.check(t, store, port, adapter)
+		})
+	}
+}
+
+func TestDeliveriesAfterRestart(t *testing.T) {
+	config.SetTargetUserID(600)
+	defer config.SetTargetUserID(0)
+	if err := config.SetFeature(config.FeatureRequireAck, true); err != nil {
+		t.Fatalf("SetFeature: %v", err)
+	}
+	defer func() { _ = config.SetFeature(config.FeatureRequireAck, false) }()
+	rc := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {Title: "Section", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "City?", Type: "text", StoreKey: "city"}}},
+		},
+		AutoForward: config.AutoForwardConfig{Enabled: true, Time: "21:00", Cutoff: "20:00"},
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		run   func(store *state.Store, adapter *fakeadapter.FakeAdapter, deliveryID string)
+		check func(t *testing.T, store *state.Store, port *memadapter.MemAdapter, adapter *fakeadapter.FakeAdapter)
+	}{
+		{
+			name: "history",
+			run:  func(*state.Store, *fakeadapter.FakeAdapter, string) {},
+			check: func(t *testing.T, store *state.Store, _ *memadapter.MemAdapter, _ *fakeadapter.FakeAdapter) {
+				if got := store.Deliveries(12); len(got) != 1 || got[0].RecordID != "r1" || got[0].Status != state.DeliveryDelivered {
+					t.Fatalf("the delivery must be restored, got %+v", got)
+				}
+			},
+		},
+		{
+			name: "acknowledge",
+			run: func(store *state.Store, adapter *fakeadapter.FakeAdapter, deliveryID string) {
+				store.GetOrCreateUserState(12, "")
+				therapist := store.GetOrCreateUserState(600, "Therapist")
+				acknowledgeDelivery(ctx, callbackQuery(600, 5, CallbackAckPrefix+deliveryID), therapist, adapter, store, deliveryID)
+			},
+			check: func(t *testing.T, store *state.Store, port *memadapter.MemAdapter, adapter *fakeadapter.FakeAdapter) {
+				if pending := store.PendingDeliveries(12); len(pending) != 0 {
+					t.Fatalf("the delivery must be acknowledged, pending %+v", pending)
+				}
+				if ids := port.RecordIDs(12); len(ids) != 0 {
+					t.Fatalf("acknowledged record must be deleted from the backend, has %v", ids)
+				}
+			},
+		},
+		{
+			name: "auto-forward skips delivered records",
+			run: func(store *state.Store, adapter *fakeadapter.FakeAdapter, _ string) {
+				store.GetOrCreateUserState(12, "")
+				autoForwardUser(ctx, adapter, rc, store, 12, clock().Add(time.Hour))
+			},
+			check: func(t *testing.T, _ *state.Store, _ *memadapter.MemAdapter, adapter *fakeadapter.FakeAdapter) {
+				for _, call := range adapter.Calls {
+					if call.ChatID == 600 {
+						t.Fatalf("a delivered record must not be forwarded again, got %+v", call)
+					}
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := memadapter.New()
+			record := state.NewRecord()
+			record.ID, record.IsSaved, record.CreatedAt = "r1", true, clock().Add(-time.Hour)
+			record.Data["city"] = state.StringAnswer("Rome")
+			if err := port.SaveRecord(ctx, 12, record); err != nil {
+				t.Fatalf("SaveRecord: %v", err)
+			}
+
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			if err := store.SetBackend(storeport.NewBackend(port), 0); err != nil {
+				t.Fatalf("SetBackend: %v", err)
+			}
+			handleForwardAnsweredSections(ctx, store.GetOrCreateUserState(12, ""), adapter, rc, 12, store)
+			deliveryID := store.PendingDeliveries(12)[0].ID
+
+			adapter = &fakeadapter.FakeAdapter{}
+			restarted := newTestHandler(adapter, rc).Store()
+			if err := restarted.SetBackend(storeport.NewBackend(port), 0); err != nil {
+				t.Fatalf("SetBackend: %v", err)
+			}
+			tt.run(restarted, adapter, deliveryID)
+			tt.check(t, restarted, port, adapter)
+		})
+	}
+}
+
+func TestScheduledJobsAfterRestart(t *testing.T) {
+	rc := &config.RecordConfig{
+		Sections:  map[string]config.SectionConfig{},
+		Reminders: config.ReminderConfig{Enabled: true, Time: "20:00", Text: "Time to write"},
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func(store *state.Store, adapter *fakeadapter.FakeAdapter) string
+	}{
+		{name: "daily reminder", run: func(store *state.Store, adapter *fakeadapter.FakeAdapter) string {
+			sendReminders(ctx, adapter, rc, store)
+			return ""
+		}},
+		{name: "broadcast", run: func(store *state.Store, adapter *fakeadapter.FakeAdapter) string {
+			broadcast(ctx, &state.UserState{UserID: 1}, adapter, store, "News")
+			return ""
+		}},
+		{name: "users list", run: func(store *state.Store, _ *fakeadapter.FakeAdapter) string {
+			return renderUsers(store, clock())
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := memadapter.New()
+			for _, userID := range []int64{12, 13} {
+				if err := port.SaveUserState(ctx, &state.UserState{UserID: userID, UserName: "User"}); err != nil {
+					t.Fatalf("SaveUserState: %v", err)
+				}
+			}
+
+			adapter := &fakeadapter.FakeAdapter{}
+			restarted := newTestHandler(adapter, rc).Store()
+			if err := restarted.SetBackend(storeport.NewBackend(port), 0); err != nil {
+				t.Fatalf("SetBackend: %v", err)
+			}
+			reply := tt.run(restarted, adapter)
+
+			for _, userID := range []int64{12, 13} {
+				reached := strings.Contains(reply, fmt.Sprintf("%d — User", userID))
+				for _, call := range adapter.Calls {
+					reached = reached || call.ChatID == userID
+				}
+				if !reached {
+					t.Fatalf("stored user %d not seen since the restart was skipped: reply %q, calls %+v", userID, reply, adapter.Calls)
+				}
+			}
+		})
+	}
+}
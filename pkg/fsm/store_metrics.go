@@ -0,0 +1,49 @@
+package fsm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/metrics"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// StoreMetricsCollector returns a metrics collector publishing the store gauges on every scrape.
+func StoreMetricsCollector(store *state.Store) func() {
+	return func() {
+		publishStoreStats(store.Stats())
+	}
+}
+
+func publishStoreStats(stats state.StoreStats) {
+	metrics.SetGauge("telegram_survey_bot_store_users", "Users with state in memory.", nil, float64(stats.Users))
+	metrics.SetGauge("telegram_survey_bot_store_drafts", "Users with answers in an unsaved draft.", nil, float64(stats.Drafts))
+	metrics.SetGauge("telegram_survey_bot_store_records", "Saved records held in memory.", nil, float64(stats.Records))
+	metrics.SetGauge("telegram_survey_bot_store_deliveries", "Deliveries to the therapist tracked in memory.", nil, float64(stats.Deliveries))
+	metrics.SetGauge("telegram_survey_bot_store_memory_bytes", "Estimated memory held by user states, records and drafts.", nil, float64(stats.MemoryBytes))
+	for label, users := range stats.UsersByRecords {
+		metrics.SetGauge("telegram_survey_bot_store_users_by_records", "Users by number of saved records in memory.", map[string]string{"records": label}, float64(users))
+	}
+}
+
+// renderStoreStats answers /admin stats.
+func renderStoreStats(stats state.StoreStats) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Хранилище:\nПользователей: %d\nЧерновиков: %d\nЗаписей: %d\nОтправок: %d\nПамять (оценка): %s\n", stats.Users, stats.Drafts, stats.Records, stats.Deliveries, formatBytes(stats.MemoryBytes))
+	b.WriteString("Пользователи по числу записей:")
+	for _, label := range state.RecordBucketLabels() {
+		fmt.Fprintf(&b, "\n%s: %d", label, stats.UsersByRecords[label])
+	}
+	return b.String()
+}
+
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f МБ", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f КБ", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d Б", n)
+	}
+}
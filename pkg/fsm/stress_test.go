@@ -0,0 +1,171 @@
+package fsm
+
+import (
+	"context"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/dispatch"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/fsm/questions"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// Stress tests are meant for the race detector: go test -race -run Stress ./pkg/fsm
+
+const stressFirstUser = 1000
+
+// runBackgroundJobs runs the jobs that walk the whole store next to update handling (config reload,
+// stuck-state sweep, metrics) in a loop until the returned stop is called.
+func runBackgroundJobs(ctx context.Context, adapter *fakeadapter.FakeAdapter, rc *config.RecordConfig, store *state.Store) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		collect := StoreMetricsCollector(store)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			ReconcileUsers(ctx, adapter, rc, store)
+			sweepStuckStates(time.Now(), rc, store)
+			collect()
+			runtime.Gosched()
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// TestStressDispatchedUsers fills records for hundreds of users whose updates arrive interleaved and go
+// through the per-user queue of pkg/dispatch, as in production: every user must end up with the
+// draft a lone user gets.
+func TestStressDispatchedUsers(t *testing.T) {
+	questions.RegisterBuiltins()
+	rc := fuzzRecordConfig()
+	users := 300
+	if testing.Short() {
+		users = 50
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	ctx := context.Background()
+	dispatcher := dispatch.New(ctx, 16)
+	stop := runBackgroundJobs(ctx, adapter, rc, handler.Store())
+
+	var submitters sync.WaitGroup
+	for i := 0; i < users; i++ {
+		userID := int64(stressFirstUser + i)
+		submitters.Add(1)
+		go func() {
+			defer submitters.Done()
+			for _, step := range fillScript {
+				// The button to tap is looked up when the update runs, after the earlier ones of the user.
+				dispatcher.Submit(userID, func(ctx context.Context) {
+					if event, _, ok := step.event(adapter, userID); ok {
+						handler.HandleUpdate(ctx, event)
+					}
+				})
+				runtime.Gosched()
+			}
+		}()
+	}
+	submitters.Wait()
+	if err := dispatcher.Drain(time.Minute); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	stop()
+
+	for i := 0; i < users; i++ {
+		userID := int64(stressFirstUser + i)
+		userState, ok := handler.Store().Get(userID)
+		if !ok {
+			t.Fatalf("user %d has no state", userID)
+		}
+		if err := checkUserState(rc, userState); err != nil {
+			t.Fatalf("user %d: %v", userID, err)
+		}
+		if got := userState.RecordFSM.Current(); got != StateSelectingSection {
+			t.Fatalf("user %d ended in %s, want %s", userID, got, StateSelectingSection)
+		}
+		for key, value := range fillScriptAnswers {
+			if got := userState.CurrentRecord.Data[key].String(); got != value {
+				t.Fatalf("user %d: draft %s = %q, want %q", userID, key, got, value)
+			}
+		}
+	}
+}
+
+// TestStressConcurrentUpdates skips the queue: several goroutines per user send random taps and texts
+// at once, so only the store and the per-user lock keep the state consistent.
+func TestStressConcurrentUpdates(t *testing.T) {
+	questions.RegisterBuiltins()
+	rc := fuzzRecordConfig()
+	users, senders, steps := 100, 4, 30
+	if testing.Short() {
+		users = 20
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	ctx := context.Background()
+	stop := runBackgroundJobs(ctx, adapter, rc, handler.Store())
+
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		userID := int64(stressFirstUser + i)
+		for sender := 0; sender < senders; sender++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rng := rand.New(rand.NewPCG(uint64(userID), uint64(sender)))
+				for step := 0; step < steps; step++ {
+					handler.HandleUpdate(ctx, randomUpdate(rng, adapter, userID))
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	stop()
+
+	for i := 0; i < users; i++ {
+		userID := int64(stressFirstUser + i)
+		userState, ok := handler.Store().Get(userID)
+		if !ok {
+			t.Fatalf("user %d has no state", userID)
+		}
+		if err := checkUserState(rc, userState); err != nil {
+			t.Fatalf("user %d: %v", userID, err)
+		}
+		if err := checkLastMessage(adapter, userState); err != nil {
+			t.Fatalf("user %d: %v", userID, err)
+		}
+	}
+}
+
+// randomUpdate is a step of fillScript, or a tap on a random button of the newest keyboard in the chat.
+func randomUpdate(rng *rand.Rand, adapter *fakeadapter.FakeAdapter, userID int64) botport.InboundEvent {
+	if rng.IntN(2) == 0 {
+		calls := adapter.ChatCalls(userID)
+		for i := len(calls) - 1; i >= 0; i-- {
+			if callbacks := calls[i].Keyboard.Callbacks(); len(callbacks) > 0 {
+				data := callbacks[rng.IntN(len(callbacks))]
+				return botport.InboundEvent{Kind: botport.EventCallback, From: botport.Sender{ID: userID}, ChatID: userID, MessageID: calls[i].MessageID, Text: calls[i].Text, CallbackID: "cb", Data: data}
+			}
+		}
+	}
+	for {
+		if event, _, ok := fillScript[rng.IntN(len(fillScript))].event(adapter, userID); ok {
+			return event
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// recordConfigFor returns the config record was filled from: its survey template, or recordConfig for
+// records of the main config. A template that is no longer loaded falls back to recordConfig.
+func recordConfigFor(recordConfig *config.RecordConfig, record *state.Record) *config.RecordConfig {
+	if record == nil || record.Template == "" || record.Template == recordConfig.Template {
+		return recordConfig
+	}
+	if templateConf, ok := config.Template(record.Template); ok {
+		return templateConf
+	}
+	slog.Warn("record refers to an unknown survey template", "record_id", record.ID, "template", record.Template)
+	return recordConfig
+}
+
+// surveyConfig returns the config of survey id; the empty ID is the main config, which recordConfig is
+// unless the user's draft switched it to a template.
+func surveyConfig(recordConfig *config.RecordConfig, id string) (*config.RecordConfig, bool) {
+	if id != "" {
+		return config.Template(id)
+	}
+	if recordConfig.Template == "" {
+		return recordConfig, true
+	}
+	mainConf := config.GetConfig()
+	return mainConf, mainConf != nil
+}
+
+// surveyTitle names a survey in the /surveys list.
+func surveyTitle(userState *state.UserState, surveyConf *config.RecordConfig) string {
+	switch {
+	case surveyConf.Title != "":
+		return surveyConf.Title
+	case surveyConf.Template != "":
+		return surveyConf.Template
+	default:
+		return tr(userState, config.MsgSurveyDefaultTitle)
+	}
+}
+
+// handleSurveysCommand lists the main survey and the loaded templates as buttons.
+func handleSurveysCommand(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64) {
+	current := ""
+	if userState.CurrentRecord != nil {
+		current = userState.CurrentRecord.Template
+	}
+
+	keyboard := botport.NewKeyboard()
+	for _, id := range append([]string{""}, config.TemplateIDs()...) {
+		surveyConf, ok := surveyConfig(recordConfig, id)
+		if !ok {
+			continue
+		}
+		label := surveyTitle(userState, surveyConf)
+		if userState.CurrentRecord != nil && id == current {
+			label = "📝 " + label
+		}
+		keyboard.AddRow(botport.NewButton(label, CallbackSurveyPrefix+id))
+	}
+	_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgSurveysPrompt), keyboard)
+}
+
+// startSurvey starts or resumes a record of survey id. A draft of another survey is dropped when it
+// has no answers; otherwise the user has to save or discard it first.
+func startSurvey(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, chatID int64, id string) {
+	surveyConf, ok := surveyConfig(recordConfig, id)
+	if !ok {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgActionUnavailable), nil)
+		return
+	}
+	if userState.RecordFSM.Current() != StateRecordIdle {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgAlreadyFilling), nil)
+		return
+	}
+	if draft := userState.CurrentRecord; draft != nil && draft.Template != id {
+		if draftHasAnswers(draft) {
+			_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgSurveyOtherDraft, surveyTitle(userState, recordConfigFor(recordConfig, draft))), nil)
+			return
+		}
+		slog.InfoContext(ctx, "dropping the empty draft of another survey", "user_id", userState.UserID, "template", draft.Template)
+		userState.CurrentRecord = nil
+	}
+	slog.InfoContext(ctx, "starting survey", "user_id", userState.UserID, "template", id)
+	startOrResumeRecordCreation(ctx, userState, botPort, surveyConf, store, chatID)
+}
@@ -0,0 +1,128 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func surveyTestConfigs() (*config.RecordConfig, *config.RecordConfig) {
+	mainConf := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"day": {Title: "Day", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Mood", Type: "text", StoreKey: "mood"}}},
+	}}
+	sleepConf := &config.RecordConfig{Title: "Sleep log", Template: "sleep", Sections: map[string]config.SectionConfig{
+		"night": {Title: "Night", Questions: []config.QuestionConfig{{ID: "s1", Prompt: "Hours", Type: "text", StoreKey: "hours"}}},
+	}}
+	return mainConf, sleepConf
+}
+
+func TestSurveysCommand(t *testing.T) {
+	mainConf, sleepConf := surveyTestConfigs()
+	config.SetTemplates(map[string]*config.RecordConfig{"sleep": sleepConf})
+	defer config.SetTemplates(nil)
+
+	adapter := &fakeadapter.FakeAdapter{}
+	store := newTestHandler(adapter, mainConf).Store()
+	userState := store.GetOrCreateUserState(8, "User")
+
+	handleMessage(context.Background(), commandMessage(8, "/surveys"), userState, adapter, mainConf, store)
+
+	call := adapter.LastCall("send_message")
+	if call == nil || call.Text != tr(userState, config.MsgSurveysPrompt) {
+		t.Fatalf("reply %+v, want the surveys prompt", call)
+	}
+	for _, data := range []string{CallbackSurveyPrefix, CallbackSurveyPrefix + "sleep"} {
+		if !call.Keyboard.HasCallback(data) {
+			t.Fatalf("no %q button in %+v", data, call.Keyboard)
+		}
+	}
+}
+
+func TestStartSurvey(t *testing.T) {
+	mainConf, sleepConf := surveyTestConfigs()
+	config.SetTemplates(map[string]*config.RecordConfig{"sleep": sleepConf})
+	defer config.SetTemplates(nil)
+
+	answered := state.NewRecord()
+	answered.Data["mood"] = state.StringAnswer("ok")
+
+	tests := []struct {
+		name         string
+		draft        *state.Record
+		survey       string
+		wantTemplate string
+		wantText     string // Expected refusal; empty when the survey starts
+	}{
+		{name: "template without a draft", survey: "sleep", wantTemplate: "sleep"},
+		{name: "empty draft of another survey is dropped", draft: state.NewRecord(), survey: "sleep", wantTemplate: "sleep"},
+		{name: "answered draft of another survey", draft: answered, survey: "sleep", wantTemplate: "", wantText: config.Message("ru", config.MsgSurveyOtherDraft, config.Message("ru", config.MsgSurveyDefaultTitle))},
+		{name: "main survey", survey: "", wantTemplate: ""},
+		{name: "unknown survey", survey: "nope", wantText: config.Message("ru", config.MsgActionUnavailable)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, mainConf).Store()
+			userState := store.GetOrCreateUserState(9, "User")
+			userState.CurrentRecord = tt.draft
+
+			handleCallbackQuery(context.Background(), callbackQuery(9, 3, CallbackSurveyPrefix+tt.survey), userState, adapter, mainConf, store)
+
+			if tt.wantText != "" {
+				call := adapter.LastCall("send_message")
+				if call == nil || call.Text != tt.wantText {
+					t.Fatalf("reply %+v, want %q", call, tt.wantText)
+				}
+				if userState.RecordFSM.Current() != StateRecordIdle || userState.CurrentRecord != tt.draft {
+					t.Fatalf("survey started: state %s, draft %+v", userState.RecordFSM.Current(), userState.CurrentRecord)
+				}
+				return
+			}
+			if userState.RecordFSM.Current() != StateSelectingSection {
+				t.Fatalf("state %s, want %s", userState.RecordFSM.Current(), StateSelectingSection)
+			}
+			if userState.CurrentRecord == nil || userState.CurrentRecord.Template != tt.wantTemplate {
+				t.Fatalf("draft %+v, want template %q", userState.CurrentRecord, tt.wantTemplate)
+			}
+			wantSection := "day"
+			if tt.wantTemplate == "sleep" {
+				wantSection = "night"
+			}
+			if call := adapter.LastCall("send_message"); call == nil || !call.Keyboard.HasCallback(CallbackSectionPrefix+wantSection) {
+				t.Fatalf("section menu %+v, want section %q", call, wantSection)
+			}
+		})
+	}
+}
+
+func TestRecordConfigFor(t *testing.T) {
+	mainConf, sleepConf := surveyTestConfigs()
+	config.SetTemplates(map[string]*config.RecordConfig{"sleep": sleepConf})
+	defer config.SetTemplates(nil)
+
+	record := state.NewRecord()
+	record.IsSaved = true
+	record.Template = "sleep"
+	record.Data["hours"] = state.StringAnswer("7")
+	userState := &state.UserState{UserID: 9, UserName: "User", Records: []*state.Record{record}}
+
+	if got := recordConfigFor(mainConf, record); got != sleepConf {
+		t.Fatalf("config %+v, want the sleep template", got)
+	}
+	text, err := renderForwardMessage(buildForwardPayload(mainConf, record, userState))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(text, "Night") || !strings.Contains(text, "7") {
+		t.Fatalf("forward %q does not render the template answers", text)
+	}
+
+	record.Template = "removed"
+	if got := recordConfigFor(mainConf, record); got != mainConf {
+		t.Fatalf("unknown template resolved to %+v, want the main config", got)
+	}
+}
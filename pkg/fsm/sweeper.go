@@ -0,0 +1,62 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+const (
+	stuckSweepInterval = 15 * time.Minute
+	stuckAfter         = 6 * time.Hour
+)
+
+// RunStuckStateSweep periodically resets users left mid-record until ctx is cancelled.
+func RunStuckStateSweep(ctx context.Context, recordConfig *config.RecordConfig, store *state.Store) {
+	ticker := time.NewTicker(stuckSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			sweepStuckStates(now, recordConfig, store)
+		}
+	}
+}
+
+func sweepStuckStates(now time.Time, recordConfig *config.RecordConfig, store *state.Store) {
+	for _, userID := range store.UserIDs() {
+		userState, ok := store.LockUser(userID)
+		if !ok {
+			continue
+		}
+		if reason := stuckReason(now, userState, recordConfig); reason != "" {
+			slog.Warn("repairing a stuck user", "user_id", userID, "state", userState.RecordFSM.Current(), "section", userState.CurrentSection, "reason", reason)
+			resetUserFlow(userState)
+		}
+		userState.Mu.Unlock()
+	}
+}
+
+// stuckReason explains why a user's record flow needs a reset, or returns "" when it is healthy.
+// The draft itself is kept, so the user resumes where they stopped. Caller must hold userState.Mu.
+func stuckReason(now time.Time, userState *state.UserState, recordConfig *config.RecordConfig) string {
+	current := userState.RecordFSM.Current()
+	if current == StateRecordIdle {
+		return ""
+	}
+	if current == StateAnsweringQuestion {
+		if _, ok := recordConfigFor(recordConfig, userState.CurrentRecord).Sections[userState.CurrentSection]; !ok {
+			return "current section no longer exists"
+		}
+	}
+	if !userState.LastActivity.IsZero() && now.Sub(userState.LastActivity) > stuckAfter {
+		return "no activity since " + userState.LastActivity.Format(time.RFC3339)
+	}
+	return ""
+}
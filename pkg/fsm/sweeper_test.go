@@ -0,0 +1,48 @@
+package fsm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestSweepStuckStates(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{"a": {Title: "A"}}}
+
+	tests := []struct {
+		name         string
+		state        string
+		section      string
+		lastActivity time.Time
+		wantState    string
+	}{
+		{name: "active answering kept", state: StateAnsweringQuestion, section: "a", lastActivity: now.Add(-time.Hour), wantState: StateAnsweringQuestion},
+		{name: "idle answering reset", state: StateAnsweringQuestion, section: "a", lastActivity: now.Add(-7 * time.Hour), wantState: StateRecordIdle},
+		{name: "idle section menu reset", state: StateSelectingSection, lastActivity: now.Add(-7 * time.Hour), wantState: StateRecordIdle},
+		{name: "removed section reset", state: StateAnsweringQuestion, section: "gone", lastActivity: now, wantState: StateRecordIdle},
+		{name: "record idle untouched", state: StateRecordIdle, lastActivity: now.Add(-48 * time.Hour), wantState: StateRecordIdle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore()
+			userState := store.GetOrCreateUserState(1, "User")
+			userState.RecordFSM.SetState(tt.state)
+			userState.CurrentSection = tt.section
+			userState.LastActivity = tt.lastActivity
+			draft := state.NewRecord()
+			userState.CurrentRecord = draft
+
+			sweepStuckStates(now, rc, store)
+
+			if got := userState.RecordFSM.Current(); got != tt.wantState {
+				t.Fatalf("state = %s, want %s", got, tt.wantState)
+			}
+			if userState.CurrentRecord != draft {
+				t.Fatalf("sweep must keep the draft")
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// errTargetBlocked marks forwards that failed, or were not attempted, because the therapist they go
+// to has blocked the bot.
+var errTargetBlocked = errors.New("target blocked the bot")
+
+// targetLinkState tracks, per therapist (TARGET_USER_ID or one of THERAPIST_IDS), whether they can
+// receive messages. Once Telegram answers a forward with "forbidden", that therapist's link is broken:
+// further forwards to them fail fast without calling Telegram, and the patients who tried are
+// remembered so they can be told when the therapist writes to the bot again.
+type targetLinkState struct {
+	mu     sync.Mutex
+	broken map[int64]*brokenLink // By therapist ID
+}
+
+// brokenLink is a therapist who blocked the bot and the patients waiting for them.
+type brokenLink struct {
+	since   time.Time
+	waiting map[int64]bool
+}
+
+var targetLink = &targetLinkState{}
+
+// markBroken records that userID could not reach targetID; it reports whether the link was healthy.
+func (l *targetLinkState) markBroken(targetID, userID int64, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	link, ok := l.broken[targetID]
+	if !ok {
+		if l.broken == nil {
+			l.broken = make(map[int64]*brokenLink)
+		}
+		link = &brokenLink{since: now, waiting: make(map[int64]bool)}
+		l.broken[targetID] = link
+	}
+	link.waiting[userID] = true
+	return !ok
+}
+
+// blocked reports whether the link to targetID is broken and, if so, adds userID to the patients
+// waiting for it.
+func (l *targetLinkState) blocked(targetID, userID int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	link, ok := l.broken[targetID]
+	if !ok {
+		return false
+	}
+	link.waiting[userID] = true
+	return true
+}
+
+// restore marks the link to targetID healthy and returns the patients who failed to reach it meanwhile.
+func (l *targetLinkState) restore(targetID int64) (since time.Time, waiting []int64, wasBroken bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	link, ok := l.broken[targetID]
+	if !ok {
+		return time.Time{}, nil, false
+	}
+	for userID := range link.waiting {
+		waiting = append(waiting, userID)
+	}
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i] < waiting[j] })
+	delete(l.broken, targetID)
+	return link.since, waiting, true
+}
+
+// relink moves a waiting patient to the account they switched to.
+func (l *targetLinkState) relink(oldID, newID int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, link := range l.broken {
+		if link.waiting[oldID] {
+			delete(link.waiting, oldID)
+			link.waiting[newID] = true
+		}
+	}
+}
+
+// isBroken reports whether forwards to targetID are currently suspended.
+func (l *targetLinkState) isBroken(targetID int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.broken[targetID]
+	return ok
+}
+
+// checkTargetLink fails a forward of userState to targetUserID up front while that therapist is known
+// to have blocked the bot. Forwards to other chats are not affected.
+func checkTargetLink(userState *state.UserState, targetUserID int64) error {
+	if !config.IsTherapist(targetUserID) || !targetLink.blocked(targetUserID, userState.UserID) {
+		return nil
+	}
+	return errTargetBlocked
+}
+
+// noteTargetForbidden breaks the link to a therapist when Telegram refused a forward to them. The admin
+// may be that therapist and cannot be messaged, so the recovery steps go to the log.
+func noteTargetForbidden(userState *state.UserState, targetUserID int64, err error) bool {
+	if !botport.IsCode(err, "forbidden") || !config.IsTherapist(targetUserID) {
+		return false
+	}
+	if targetLink.markBroken(targetUserID, userState.UserID, time.Now()) {
+		slog.Error("a therapist blocked the bot; forwards to them are suspended. Have them unblock the bot in Telegram and send it /start to resume", "target_id", targetUserID)
+	}
+	return true
+}
+
+// restoreTargetLink runs when a therapist writes to the bot: their link is marked healthy, they learn
+// how many patients were affected, and those patients are told, in their own language, that they can
+// send again. The patients are read once the therapist's Mu is released.
+func restoreTargetLink(ctx context.Context, botPort botport.BotPort, store *state.Store, target *state.UserState) {
+	since, waiting, wasBroken := targetLink.restore(target.UserID)
+	if !wasBroken {
+		return
+	}
+	slog.InfoContext(ctx, "therapist is reachable again, notifying patients", "target_id", target.UserID, "after", time.Since(since).Round(time.Second), "patients", len(waiting))
+	_, _ = botPort.SendMessage(ctx, target.UserID, tr(target, config.MsgTargetLinkRestored, since.Format("02.01.2006 15:04"), len(waiting)), nil)
+	whenUnlocked(ctx, func() {
+		for _, userID := range waiting {
+			if userID == target.UserID {
+				continue
+			}
+			text := tr(nil, config.MsgTargetReachable)
+			if store != nil {
+				if patient, ok := store.Get(userID); ok {
+					patient.Mu.Lock()
+					text = tr(patient, config.MsgTargetReachable)
+					patient.Mu.Unlock()
+				}
+			}
+			_, _ = botPort.SendMessage(ctx, userID, text, nil)
+		}
+	})
+}
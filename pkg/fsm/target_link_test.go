@@ -0,0 +1,134 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestBlockedTargetSuspendsForwardsUntilItReturns(t *testing.T) {
+	config.SetTargetUserID(700)
+	defer config.SetTargetUserID(0)
+	config.SetTherapists([]int64{800})
+	defer config.SetTherapists(nil)
+	targetLink = &targetLinkState{}
+	defer func() { targetLink = &targetLinkState{} }()
+
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", StoreKey: "f1"}}},
+	}}
+	adapter := &fakeadapter.FakeAdapter{}
+	handler := newTestHandler(adapter, rc)
+	store := handler.Store()
+	patient := func(id, therapist int64, language string) *state.UserState {
+		rec := state.NewRecord()
+		rec.Data["f1"] = state.StringAnswer("Value")
+		rec.IsSaved = true
+		userState := store.GetOrCreateUserState(id, "")
+		userState.Records = []*state.Record{rec}
+		userState.Therapist, userState.Language = therapist, language
+		return userState
+	}
+	// 1 and 2 send to TARGET_USER_ID; 3 to their own therapist 800 and reads English.
+	first, second, third := patient(1, 0, ""), patient(2, 0, ""), patient(3, 800, "en")
+	forward := func(userState *state.UserState) func() {
+		return func() {
+			handleForwardAnsweredSections(context.Background(), userState, adapter, rc, userState.UserID, nil)
+		}
+	}
+	restore := func(therapistID int64) func() {
+		return func() {
+			restoreTargetLink(context.Background(), adapter, store, store.GetOrCreateUserState(therapistID, "Therapist"))
+		}
+	}
+	forbidden := botport.NewBotError("send_message", "forbidden", errors.New("Forbidden: bot was blocked by the user"))
+
+	steps := []struct {
+		name      string
+		fail      bool
+		run       func()
+		wantSends map[int64]int    // Successful sends to each therapist; the fake does not record failed calls
+		wantText  map[int64]string // Chat -> expected part of the last message; "" for no message
+	}{
+		{
+			name:      "forbidden breaks the link",
+			fail:      true,
+			run:       forward(first),
+			wantSends: map[int64]int{700: 0},
+			wantText:  map[int64]string{1: "заблокировал бота"},
+		},
+		{
+			name:      "a per-user therapist breaks their own link",
+			fail:      true,
+			run:       forward(third),
+			wantSends: map[int64]int{800: 0},
+			wantText:  map[int64]string{3: "blocked the bot"},
+		},
+		{
+			name:      "later forwards fail without calling Telegram",
+			run:       forward(second),
+			wantSends: map[int64]int{700: 0},
+			wantText:  map[int64]string{2: "заблокировал бота"},
+		},
+		{
+			name:      "target writing again restores only its link",
+			run:       restore(700),
+			wantSends: map[int64]int{700: 1, 800: 0},
+			wantText:  map[int64]string{700: "пациентов, не сумевших отправить ответы: 2", 1: "снова на связи", 2: "снова на связи", 3: ""},
+		},
+		{
+			name:      "the per-user therapist stays suspended",
+			run:       forward(third),
+			wantSends: map[int64]int{800: 0},
+			wantText:  map[int64]string{3: "blocked the bot"},
+		},
+		{
+			name:      "patients hear back in their own language",
+			run:       restore(800),
+			wantSends: map[int64]int{800: 1},
+			wantText:  map[int64]string{3: "Your therapist is reachable again", 1: ""},
+		},
+		{
+			name:      "forwards go through again",
+			run:       forward(second),
+			wantSends: map[int64]int{700: 1},
+			wantText:  map[int64]string{2: "Ответы отправлены на ID 700"},
+		},
+	}
+	for _, step := range steps {
+		adapter.Calls = nil
+		if step.fail {
+			adapter.Fail("send_message", forbidden)
+		}
+		step.run()
+
+		last := make(map[int64]string)
+		sends := make(map[int64]int)
+		for _, call := range adapter.Calls {
+			if call.Op != "send_message" {
+				continue
+			}
+			last[call.ChatID] = call.Text
+			sends[call.ChatID]++
+		}
+		for therapistID, want := range step.wantSends {
+			if sends[therapistID] != want {
+				t.Fatalf("%s: %d sends to %d, want %d; calls: %+v", step.name, sends[therapistID], therapistID, want, adapter.Calls)
+			}
+		}
+		for chatID, want := range step.wantText {
+			if want == "" && last[chatID] != "" || !strings.Contains(last[chatID], want) {
+				t.Fatalf("%s: chat %d got %q, want containing %q", step.name, chatID, last[chatID], want)
+			}
+		}
+	}
+	if len(first.Records) != 1 || len(third.Records) != 1 {
+		t.Fatalf("answers of a failed forward must be kept")
+	}
+}
@@ -0,0 +1,76 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// targetFor returns whom userState's records go to: the assigned therapist while they are still
+// configured, else TARGET_USER_ID.
+func targetFor(userState *state.UserState) int64 {
+	if userState != nil && config.IsTherapist(userState.Therapist) {
+		return userState.Therapist
+	}
+	return config.GetTargetUserID()
+}
+
+// handleTherapistLink assigns the therapist of a /start therapist_<id> deep link and tells both sides.
+func handleTherapistLink(ctx context.Context, userState *state.UserState, botPort botport.BotPort, store *state.Store, chatID int64, rawID string) {
+	therapistID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || !config.IsTherapist(therapistID) || therapistID == userState.UserID {
+		slog.WarnContext(ctx, "invalid therapist link", "user_id", userState.UserID, "therapist", rawID)
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgTherapistLinkInvalid), nil)
+		return
+	}
+	if userState.Therapist == therapistID {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgTherapistAssigned), nil)
+		return
+	}
+	userState.Therapist = therapistID
+	slog.InfoContext(ctx, "user assigned to a therapist by link", "user_id", userState.UserID, "therapist_id", therapistID)
+	_, _ = botPort.SendMessage(ctx, chatID, tr(userState, config.MsgTherapistAssigned), nil)
+	if _, err := botPort.SendMessage(ctx, therapistID, trTo(store, therapistID, config.MsgTherapistNewPatient, userState.UserName, userState.UserID), nil); err != nil {
+		slog.ErrorContext(ctx, "telling the therapist about the new user failed", "therapist_id", therapistID, "err", err)
+	}
+}
+
+// assignTherapist serves "/admin assign <user id> <therapist id|default>". It locks the user; callers
+// hold no user's Mu.
+func assignTherapist(adminID int64, args []string, store *state.Store) string {
+	const usage = "Использование: /admin assign <ID пользователя> <ID терапевта|default>"
+	if len(args) != 2 {
+		return usage
+	}
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return usage
+	}
+	var therapistID int64
+	if args[1] != "default" {
+		if therapistID, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return usage
+		}
+		if !config.IsTherapist(therapistID) {
+			return fmt.Sprintf("%d нет среди терапевтов (TARGET_USER_ID, THERAPIST_IDS): %v", therapistID, config.Therapists())
+		}
+	}
+
+	if store == nil {
+		return "Хранилище недоступно."
+	}
+	userState, ok := store.LockUser(userID)
+	if !ok {
+		return fmt.Sprintf("Пользователь %d не найден.", userID)
+	}
+	defer userState.Mu.Unlock()
+	defer syncUser(store, userState)
+	userState.Therapist = therapistID
+	slog.Info("admin assigned a therapist", "admin_id", adminID, "user_id", userID, "therapist_id", therapistID)
+	return fmt.Sprintf("Записи пользователя %d отправляются: %d.", userID, targetFor(userState))
+}
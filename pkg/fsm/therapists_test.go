@@ -0,0 +1,96 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestTherapistLink(t *testing.T) {
+	config.SetTargetUserID(700)
+	config.SetTherapists([]int64{800})
+	defer config.SetTargetUserID(0)
+	defer config.SetTherapists(nil)
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Field", Type: "text", StoreKey: "f1"}}},
+	}}
+
+	tests := []struct {
+		name          string
+		payload       string
+		wantText      string
+		wantTherapist int64
+		wantForwardTo int64
+	}{
+		{name: "therapist link", payload: "therapist_800", wantText: config.Message("ru", config.MsgTherapistAssigned), wantTherapist: 800, wantForwardTo: 800},
+		{name: "target link", payload: "therapist_700", wantText: config.Message("ru", config.MsgTherapistAssigned), wantTherapist: 700, wantForwardTo: 700},
+		{name: "unknown therapist", payload: "therapist_900", wantText: config.Message("ru", config.MsgTherapistLinkInvalid), wantForwardTo: 700},
+		{name: "malformed", payload: "therapist_x", wantText: config.Message("ru", config.MsgTherapistLinkInvalid), wantForwardTo: 700},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			userState := store.GetOrCreateUserState(30, "Anna")
+
+			handleMessage(context.Background(), commandMessage(30, "/start "+tt.payload), userState, adapter, rc, store)
+
+			if adapter.Calls[0].ChatID != 30 || adapter.Calls[0].Text != tt.wantText {
+				t.Fatalf("first reply %+v, want %q", adapter.Calls[0], tt.wantText)
+			}
+			if userState.Therapist != tt.wantTherapist {
+				t.Fatalf("therapist = %d, want %d", userState.Therapist, tt.wantTherapist)
+			}
+			notified := len(adapter.Calls) > 1 && adapter.Calls[1].ChatID == tt.wantTherapist
+			if notified != (tt.wantTherapist != 0) {
+				t.Fatalf("therapist notified = %t, calls %+v", notified, adapter.Calls)
+			}
+
+			record := state.NewRecord()
+			record.Data["f1"] = state.StringAnswer("Value")
+			record.IsSaved = true
+			userState.Records = []*state.Record{record}
+			adapter.Calls = nil
+			handleForwardAnsweredSections(context.Background(), userState, adapter, rc, 30, store)
+			if forwarded := adapter.Calls[0]; forwarded.ChatID != tt.wantForwardTo {
+				t.Fatalf("record went to %d, want %d", forwarded.ChatID, tt.wantForwardTo)
+			}
+		})
+	}
+}
+
+func TestAdminAssignTherapist(t *testing.T) {
+	config.SetTargetUserID(700)
+	config.SetTherapists([]int64{800})
+	defer config.SetTargetUserID(0)
+	defer config.SetTherapists(nil)
+
+	store := newTestStore()
+	admin := store.GetOrCreateUserState(700, "Admin")
+	patient := store.GetOrCreateUserState(31, "Boris")
+
+	steps := []struct {
+		args          string
+		wantReply     string
+		wantTherapist int64
+	}{
+		{args: "31 800", wantReply: "отправляются: 800", wantTherapist: 800},
+		{args: "31 900", wantReply: "900 нет среди терапевтов", wantTherapist: 800},
+		{args: "32 800", wantReply: "Пользователь 32 не найден", wantTherapist: 800},
+		{args: "31", wantReply: "Использование", wantTherapist: 800},
+		{args: "31 default", wantReply: "отправляются: 700", wantTherapist: 0},
+	}
+	for _, step := range steps {
+		reply := assignTherapist(admin.UserID, strings.Fields(step.args), store)
+		if !strings.Contains(reply, step.wantReply) {
+			t.Fatalf("%s: reply %q, want %q", step.args, reply, step.wantReply)
+		}
+		if patient.Therapist != step.wantTherapist {
+			t.Fatalf("%s: therapist = %d, want %d", step.args, patient.Therapist, step.wantTherapist)
+		}
+	}
+}
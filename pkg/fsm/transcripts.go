@@ -0,0 +1,161 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/transcript"
+)
+
+// transcripts keeps each user's conversation for support cases; nil keeps none.
+var transcripts *transcript.Log
+
+// SetTranscripts enables conversation transcripts. main wraps the bot port with the same log, so the
+// bot's messages land next to the users' own. Call it before handling updates.
+func SetTranscripts(log *transcript.Log) {
+	transcripts = log
+}
+
+const defaultTranscriptTail = 20
+
+// handleTranscriptCommand serves "/transcript <user id> [N|file]" and "/admin transcript": the last N
+// messages in chat, or the whole transcript as a text file. The admin reads every conversation, other
+// therapists those of the users whose records go to them. Another user is read once the viewer's Mu
+// is released, and the viewer is locked again to answer.
+func handleTranscriptCommand(ctx context.Context, viewer *state.UserState, botPort botport.BotPort, chatID int64, store *state.Store, args []string) {
+	if transcripts == nil {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(viewer, config.MsgTranscriptDisabled), nil)
+		return
+	}
+	if len(args) == 0 || len(args) > 2 {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(viewer, config.MsgTranscriptUsage), nil)
+		return
+	}
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(viewer, config.MsgTranscriptUsage), nil)
+		return
+	}
+	limit, asFile := defaultTranscriptTail, false
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		switch {
+		case args[1] == "file":
+			asFile = true
+		case err == nil && n > 0:
+			limit = n
+		default:
+			_, _ = botPort.SendMessage(ctx, chatID, tr(viewer, config.MsgTranscriptUsage), nil)
+			return
+		}
+	}
+
+	if userID == viewer.UserID {
+		sendTranscript(ctx, viewer, botPort, chatID, userID, viewer.UserName, limit, asFile)
+		return
+	}
+	viewerID := viewer.UserID
+	whenUnlocked(ctx, func() {
+		name, allowed := transcriptSubject(viewerID, userID, store)
+		viewer.Mu.Lock()
+		defer viewer.Mu.Unlock()
+		if !allowed {
+			slog.WarnContext(ctx, "transcript request denied", "viewer_id", viewerID, "user_id", userID)
+			_, _ = botPort.SendMessage(ctx, chatID, tr(viewer, config.MsgTranscriptDenied, userID), nil)
+			return
+		}
+		sendTranscript(ctx, viewer, botPort, chatID, userID, name, limit, asFile)
+	})
+}
+
+// sendTranscript sends the transcript of userID, called name, to the viewer. Caller must hold
+// viewer.Mu.
+func sendTranscript(ctx context.Context, viewer *state.UserState, botPort botport.BotPort, chatID, userID int64, name string, limit int, asFile bool) {
+	entries := transcripts.Entries(userID)
+	if len(entries) == 0 {
+		_, _ = botPort.SendMessage(ctx, chatID, tr(viewer, config.MsgTranscriptEmpty, userID), nil)
+		return
+	}
+	slog.InfoContext(ctx, "transcript viewed", "viewer_id", viewer.UserID, "user_id", userID, "entries", len(entries), "file", asFile)
+
+	if asFile {
+		if !botPort.Capabilities().Attachments {
+			_, _ = botPort.SendMessage(ctx, chatID, tr(viewer, config.MsgExportUnsupported), nil)
+			return
+		}
+		lines := make([]string, len(entries))
+		for i, entry := range entries {
+			lines[i] = renderTranscriptEntry(entry, "2006-01-02 15:04:05", 0)
+		}
+		data := []byte(strings.Join(lines, "\n") + "\n")
+		caption := tr(viewer, config.MsgTranscriptCaption, name, userID, len(entries))
+		if _, err := botPort.SendDocument(ctx, chatID, fmt.Sprintf("transcript-%d.txt", userID), data, caption, recordSendOptions()...); err != nil {
+			slog.ErrorContext(ctx, "sending the transcript failed", "user_id", userID, "err", err)
+			_, _ = botPort.SendMessage(ctx, chatID, botErrorText(viewer, err, tr(viewer, config.MsgExportFailed)), nil)
+		}
+		return
+	}
+
+	// Walk from the newest entry so the latest messages survive the reply size cap.
+	lines := make([]string, 0, limit)
+	size := 0
+	for i := len(entries) - 1; i >= 0 && len(lines) < limit; i-- {
+		line := renderTranscriptEntry(entries[i], "02.01 15:04", maxLogLineLength)
+		if size+len(line) > maxLogReplySize {
+			break
+		}
+		size += len(line) + 1
+		lines = append(lines, line)
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	header := tr(viewer, config.MsgTranscriptHeader, name, userID, len(lines), len(entries))
+	_, _ = botPort.SendMessage(ctx, chatID, header+"\n\n"+strings.Join(lines, "\n"), nil, recordSendOptions()...)
+}
+
+// transcriptSubject returns the name of user userID and whether viewerID may read their transcript.
+// It locks the user; callers hold no user's Mu.
+func transcriptSubject(viewerID, userID int64, store *state.Store) (string, bool) {
+	var user *state.UserState
+	ok := false
+	if store != nil {
+		user, ok = store.Get(userID)
+	}
+	if !ok {
+		// Only the admin reads the transcript of a user the store no longer knows.
+		return "", isAdmin(viewerID)
+	}
+	user.Mu.Lock()
+	defer user.Mu.Unlock()
+	return user.UserName, isAdmin(viewerID) || targetFor(user) == viewerID
+}
+
+// renderTranscriptEntry formats one entry as "<time> 👤|🤖 <text>". A positive maxLen truncates the
+// text and puts it on one line.
+func renderTranscriptEntry(entry transcript.Entry, layout string, maxLen int) string {
+	sender := "👤"
+	if entry.Direction == transcript.Outbound {
+		sender = "🤖"
+	}
+	text := entry.Text
+	switch entry.Kind {
+	case "callback":
+		text = "🔘 " + text
+	case "edit":
+		text = "✏️ " + text
+	case "message", "command":
+	default:
+		text = strings.TrimSpace("📎 " + entry.Kind + " " + text)
+	}
+	if maxLen > 0 {
+		text = truncateString(strings.Join(strings.Fields(text), " "), maxLen)
+	}
+	return fmt.Sprintf("%s %s %s", entry.Time.Format(layout), sender, text)
+}
@@ -0,0 +1,83 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/transcript"
+)
+
+func TestTranscriptCommand(t *testing.T) {
+	config.SetTargetUserID(700)
+	config.SetTherapists([]int64{800})
+	defer config.SetTargetUserID(0)
+	defer config.SetTherapists(nil)
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		from      int64
+		command   string
+		wantOp    string
+		wantText  string // Prefix of the reply or the document caption
+		wantLines []string
+	}{
+		{name: "disabled", from: 700, command: "/admin transcript 40", wantOp: "send_message", wantText: config.Message("ru", config.MsgTranscriptDisabled)},
+		{name: "admin reads any user", enabled: true, from: 700, command: "/admin transcript 40", wantOp: "send_message",
+			wantText: config.Message("ru", config.MsgTranscriptHeader, "Anna", 40, 2, 2), wantLines: []string{"👤 How do I save?", "🤖 Tap save."}},
+		{name: "tail", enabled: true, from: 700, command: "/transcript 40 1", wantOp: "send_message",
+			wantText: config.Message("ru", config.MsgTranscriptHeader, "Anna", 40, 1, 2), wantLines: []string{"🤖 Tap save."}},
+		{name: "file", enabled: true, from: 700, command: "/transcript 40 file", wantOp: "send_document",
+			wantText: config.Message("ru", config.MsgTranscriptCaption, "Anna", 40, 2)},
+		{name: "assigned therapist", enabled: true, from: 800, command: "/transcript 40", wantOp: "send_message",
+			wantText: config.Message("ru", config.MsgTranscriptHeader, "Anna", 40, 2, 2)},
+		{name: "other therapist's patient", enabled: true, from: 800, command: "/transcript 41", wantOp: "send_message",
+			wantText: config.Message("ru", config.MsgTranscriptDenied, 41)},
+		{name: "no conversation", enabled: true, from: 700, command: "/transcript 42", wantOp: "send_message",
+			wantText: config.Message("ru", config.MsgTranscriptEmpty, 42)},
+		{name: "patient", enabled: true, from: 40, command: "/transcript 40", wantOp: "send_message",
+			wantText: config.Message("ru", config.MsgUnknownCommand)},
+		{name: "usage", enabled: true, from: 700, command: "/transcript 40 all", wantOp: "send_message",
+			wantText: config.Message("ru", config.MsgTranscriptUsage)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTranscripts(nil)
+			if tt.enabled {
+				log := transcript.New(transcript.Limits{})
+				log.Add(40, transcript.Entry{Direction: transcript.Inbound, Kind: "message", Text: "How do I save?"})
+				log.Add(40, transcript.Entry{Direction: transcript.Outbound, Kind: "message", Text: "Tap save."})
+				log.Add(41, transcript.Entry{Direction: transcript.Inbound, Kind: "message", Text: "Hi"})
+				SetTranscripts(log)
+			}
+			defer SetTranscripts(nil)
+
+			adapter := &fakeadapter.FakeAdapter{}
+			handler := newTestHandler(adapter, &config.RecordConfig{})
+			store := handler.Store()
+			store.GetOrCreateUserState(40, "Anna").Therapist = 800
+			store.GetOrCreateUserState(41, "Boris")
+
+			handler.HandleUpdate(context.Background(), *commandMessage(tt.from, tt.command))
+
+			call := adapter.LastCall(tt.wantOp)
+			if call == nil || call.ChatID != tt.from || !strings.HasPrefix(call.Text, tt.wantText) {
+				t.Fatalf("%s %+v, want %q", tt.wantOp, call, tt.wantText)
+			}
+			for _, line := range tt.wantLines {
+				if !strings.Contains(call.Text, line) {
+					t.Fatalf("reply %q does not contain %q", call.Text, line)
+				}
+			}
+			if len(tt.wantLines) == 1 && strings.Contains(call.Text, "How do I save?") {
+				t.Fatalf("reply %q is not limited to the last message", call.Text)
+			}
+			if tt.wantOp == "send_document" && !strings.Contains(string(call.Data), "🤖 Tap save.") {
+				t.Fatalf("document %q misses the conversation", call.Data)
+			}
+		})
+	}
+}
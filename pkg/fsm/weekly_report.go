@@ -0,0 +1,169 @@
+package fsm
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/logging"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/report"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/report/pdf"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// reportFont is the font weekly PDF reports are typeset in; nil disables PDF reports.
+var reportFont *pdf.Font
+
+// SetReportFont sets the font of weekly PDF reports, which enables them. Call it before starting the
+// background jobs.
+func SetReportFont(font *pdf.Font) {
+	reportFont = font
+}
+
+// RunWeeklyReports sends the weekly reports every weekly_report.weekday at weekly_report.time until ctx
+// is cancelled.
+func RunWeeklyReports(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store) {
+	weekly := recordConfig.WeeklyReport
+	if !weekly.Enabled {
+		return
+	}
+	if reportFont == nil && !weekly.TextOnly() {
+		slog.WarnContext(ctx, "REPORT_FONT is not set, weekly reports are disabled")
+		return
+	}
+	weekday, ok := weekly.Day()
+	at, err := time.Parse(config.ReminderTimeLayout, weekly.Time)
+	if !ok || err != nil {
+		slog.ErrorContext(ctx, "invalid weekly report schedule", "weekday", weekly.Weekday, "time", weekly.Time)
+		return
+	}
+
+	for {
+		wait := time.Until(nextWeeklyRun(time.Now(), weekday, at.Hour(), at.Minute()))
+		slog.InfoContext(ctx, "next weekly reports scheduled", "in", wait.Round(time.Second))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		sendWeeklyReports(logging.NewContext(ctx), botPort, recordConfig, store, time.Now())
+	}
+}
+
+// nextWeeklyRun returns the next moment after now on weekday at hour:minute in now's location.
+func nextWeeklyRun(now time.Time, weekday time.Weekday, hour, minute int) time.Time {
+	next := nextDailyRun(now, hour, minute)
+	for next.Weekday() != weekday {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// sendWeeklyReports sends every user the report of the seven days before the day of now.
+func sendWeeklyReports(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, now time.Time) {
+	if !recordConfig.WeeklyReport.TextOnly() && !botPort.Capabilities().Attachments {
+		slog.WarnContext(ctx, "transport cannot send files, skipping weekly reports")
+		return
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -report.DaysInWeek)
+	for _, userID := range store.UserIDs() {
+		sendWeeklyReport(ctx, botPort, recordConfig, store, userID, start)
+	}
+}
+
+// sendWeeklyReport sends userID's report of the week from start to the configured recipients. Users
+// without records that week get nothing.
+func sendWeeklyReport(ctx context.Context, botPort botport.BotPort, recordConfig *config.RecordConfig, store *state.Store, userID int64, start time.Time) {
+	userState, ok := store.LockUser(userID)
+	if !ok {
+		return
+	}
+	defer userState.Mu.Unlock()
+
+	targetUserID := targetFor(userState)
+	if userID == targetUserID {
+		return
+	}
+	filter := state.RecordFilter{From: start, To: start.AddDate(0, 0, report.DaysInWeek)}
+	records := store.ListRecords(userID, filter, state.Page{})
+	if len(records) == 0 {
+		return
+	}
+	weekly := report.BuildWeekly(recordConfig, userID, userState.UserName, records, start)
+	textOnly := recordConfig.WeeklyReport.TextOnly()
+	var data []byte
+	if !textOnly {
+		var err error
+		if data, err = weekly.PDF(reportFont); err != nil {
+			slog.ErrorContext(ctx, "rendering the weekly report failed", "user_id", userID, "err", err)
+			return
+		}
+	}
+
+	opts := append(recordSendOptions(), lowPriorityOptions(recordConfig)...)
+	for _, to := range recordConfig.WeeklyReport.Recipients() {
+		chatID := userID
+		lang := userState.Lang()
+		caption := tr(userState, config.MsgWeeklyReportCaption, weekly.Period(), len(weekly.Records))
+		if to == config.ReportToTherapist {
+			if targetUserID == 0 || targetLink.isBroken(targetUserID) {
+				slog.WarnContext(ctx, "therapist unavailable, weekly report not sent to them", "user_id", userID)
+				continue
+			}
+			chatID = targetUserID
+			lang = store.Lang(targetUserID)
+			caption = trTo(store, targetUserID, config.MsgWeeklyReportTherapist, userState.UserName, userID, weekly.Period(), len(weekly.Records))
+		}
+		var err error
+		if textOnly {
+			_, err = botPort.SendMessage(ctx, chatID, reportSummary(lang, weekly, chatID != userID), nil, opts...)
+		} else {
+			_, err = botPort.SendDocument(ctx, chatID, weekly.FileName(), data, caption, opts...)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "sending the weekly report failed", "user_id", userID, "chat_id", chatID, "err", err)
+			continue
+		}
+		slog.InfoContext(ctx, "weekly report sent", "user_id", userID, "period", weekly.Period(), "chat_id", chatID)
+	}
+}
+
+// handleReportCommand answers /report with the summary of the last seven days, today included.
+func handleReportCommand(ctx context.Context, userState *state.UserState, botPort botport.BotPort, recordConfig *config.RecordConfig, chatID int64, store *state.Store) {
+	now := clock()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1-report.DaysInWeek)
+	filter := state.RecordFilter{From: start, To: start.AddDate(0, 0, report.DaysInWeek)}
+	records := store.ListRecords(userState.UserID, filter, state.Page{})
+	weekly := report.BuildWeekly(recordConfig, userState.UserID, userState.UserName, records, start)
+
+	text := reportSummary(userState.Lang(), weekly, false)
+	if len(weekly.Records) == 0 {
+		text = tr(userState, config.MsgReportEmpty, weekly.Period())
+	}
+	if _, err := botPort.SendMessage(ctx, chatID, text, nil); err != nil {
+		slog.ErrorContext(ctx, "sending the report failed", "user_id", userState.UserID, "err", err)
+	}
+}
+
+// reportSummary renders weekly as a short message in lang: the number of records and of days with
+// records, and the mean of every text_rating and rating question. forTherapist names the user in the header.
+func reportSummary(lang string, weekly report.Weekly, forTherapist bool) string {
+	var sb strings.Builder
+	if forTherapist {
+		sb.WriteString(config.Message(lang, config.MsgReportSummaryTherapist, weekly.UserName, weekly.UserID, weekly.Period(), len(weekly.Records)))
+	} else {
+		sb.WriteString(config.Message(lang, config.MsgReportSummary, weekly.Period(), len(weekly.Records)))
+	}
+	sb.WriteString("\n" + config.Message(lang, config.MsgReportDays, weekly.ActiveDays, report.DaysInWeek))
+	for _, trend := range weekly.Trends {
+		if !trend.Rating {
+			continue
+		}
+		sb.WriteString("\n" + config.Message(lang, config.MsgReportRating, trend.Title,
+			report.FormatNumber(trend.Mean), report.FormatNumber(trend.Min), report.FormatNumber(trend.Max), trend.Count))
+	}
+	return sb.String()
+}
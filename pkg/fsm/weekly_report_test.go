@@ -0,0 +1,202 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/report/pdf"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/report/pdf/pdftest"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestSendWeeklyReports(t *testing.T) {
+	font, err := pdf.ParseFont(pdftest.Font())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	SetReportFont(font)
+	defer SetReportFont(nil)
+	defer config.SetTargetUserID(0)
+
+	const userID, therapistID = 60, 900
+	now := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local) // Monday; the report covers 05.10–11.10
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Mood", Type: "text_rating", StoreKey: "mood"}}},
+	}}
+
+	tests := []struct {
+		name      string
+		sendTo    []string
+		target    int64
+		recordAt  []time.Time
+		wantChats []int64
+	}{
+		{name: "user by default", recordAt: []time.Time{now.AddDate(0, 0, -3)}, wantChats: []int64{userID}},
+		{name: "user and therapist", sendTo: []string{config.ReportToUser, config.ReportToTherapist}, target: therapistID, recordAt: []time.Time{now.AddDate(0, 0, -7)}, wantChats: []int64{userID, therapistID}},
+		{name: "therapist not configured", sendTo: []string{config.ReportToTherapist}, recordAt: []time.Time{now.AddDate(0, 0, -1)}},
+		{name: "no records this week", recordAt: []time.Time{now.AddDate(0, 0, -8), now.Add(time.Minute)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.SetTargetUserID(tt.target)
+			rc.WeeklyReport = config.WeeklyReportConfig{Enabled: true, Weekday: "monday", Time: "09:00", SendTo: tt.sendTo}
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			userState := store.GetOrCreateUserState(userID, "patient")
+			for _, at := range tt.recordAt {
+				record := state.NewRecord()
+				record.ID = at.Format("20060102")
+				record.IsSaved = true
+				record.CreatedAt = at
+				record.Data = map[string]state.Answer{"mood": state.ScoredAnswer(state.ScoredEntry{Text: "ok", Score: 7})}
+				userState.Records = append(userState.Records, record)
+			}
+
+			sendWeeklyReports(context.Background(), adapter, rc, store, now)
+
+			var chats []int64
+			for _, call := range adapter.Calls {
+				if call.Op != "send_document" {
+					t.Fatalf("unexpected %s call", call.Op)
+				}
+				if call.FileID != "report_2026-10-05.pdf" || len(call.Data) == 0 {
+					t.Fatalf("unexpected document %q (%d bytes)", call.FileID, len(call.Data))
+				}
+				chats = append(chats, call.ChatID)
+			}
+			if len(chats) != len(tt.wantChats) {
+				t.Fatalf("sent to %v, want %v", chats, tt.wantChats)
+			}
+			for i := range chats {
+				if chats[i] != tt.wantChats[i] {
+					t.Fatalf("sent to %v, want %v", chats, tt.wantChats)
+				}
+			}
+		})
+	}
+}
+
+func TestNextWeeklyRun(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{name: "later the same day", now: monday.Add(8 * time.Hour), want: monday.Add(9 * time.Hour)},
+		{name: "just missed", now: monday.Add(9 * time.Hour), want: monday.AddDate(0, 0, 7).Add(9 * time.Hour)},
+		{name: "midweek", now: monday.AddDate(0, 0, 3), want: monday.AddDate(0, 0, 7).Add(9 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextWeeklyRun(tt.now, time.Monday, 9, 0); !got.Equal(tt.want) {
+				t.Fatalf("nextWeeklyRun = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportCommand(t *testing.T) {
+	defer func() { clock = time.Now }()
+	now := time.Date(2026, 10, 12, 20, 0, 0, 0, time.Local) // The report covers 06.10–12.10
+	clock = func() time.Time { return now }
+
+	rc := &config.RecordConfig{Sections: map[string]config.SectionConfig{
+		"sec": {Title: "Main", Questions: []config.QuestionConfig{
+			{ID: "q1", Prompt: "Mood", Type: "text_rating", StoreKey: "mood"},
+			{ID: "q2", Prompt: "Note", Type: "text", StoreKey: "note"},
+		}},
+	}}
+	scored := func(scores ...int) state.Answer {
+		entries := make([]state.ScoredEntry, 0, len(scores))
+		for _, score := range scores {
+			entries = append(entries, state.ScoredEntry{Text: "x", Score: score})
+		}
+		return state.ScoredAnswer(entries...)
+	}
+
+	tests := []struct {
+		name     string
+		recordAt []time.Time
+		moods    []state.Answer
+		want     string
+	}{
+		{
+			name:     "no records",
+			recordAt: []time.Time{now.AddDate(0, 0, -7)},
+			moods:    []state.Answer{scored(5)},
+			want:     config.Message("ru", config.MsgReportEmpty, "06.10.2026 – 12.10.2026"),
+		},
+		{
+			name:     "ratings averaged",
+			recordAt: []time.Time{now.AddDate(0, 0, -6), now.AddDate(0, 0, -6).Add(time.Hour), now.Add(-time.Hour)},
+			moods:    []state.Answer{scored(4, 6), scored(8), scored(9)},
+			want: config.Message("ru", config.MsgReportSummary, "06.10.2026 – 12.10.2026", 3) + "\n" +
+				config.Message("ru", config.MsgReportDays, 2, 7) + "\n" +
+				config.Message("ru", config.MsgReportRating, "Main: Mood", "7.3", "5", "9", 3),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeadapter.FakeAdapter{}
+			store := newTestHandler(adapter, rc).Store()
+			userState := store.GetOrCreateUserState(61, "patient")
+			for i, at := range tt.recordAt {
+				record := state.NewRecord()
+				record.ID = at.Format("20060102150405")
+				record.IsSaved = true
+				record.CreatedAt = at
+				record.Data = map[string]state.Answer{"mood": tt.moods[i], "note": state.StringAnswer("ok")}
+				userState.Records = append(userState.Records, record)
+			}
+
+			handleMessage(context.Background(), commandMessage(61, "/report"), userState, adapter, rc, store)
+
+			call := adapter.LastCall("send_message")
+			if call == nil || call.Text != tt.want {
+				t.Fatalf("reply %+v, want %q", call, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendWeeklyTextReports(t *testing.T) {
+	defer config.SetTargetUserID(0)
+	config.SetTargetUserID(900)
+
+	now := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	rc := &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"sec": {Title: "Main", Questions: []config.QuestionConfig{{ID: "q1", Prompt: "Mood", Type: "text_rating", StoreKey: "mood"}}},
+		},
+		WeeklyReport: config.WeeklyReportConfig{Enabled: true, Weekday: "monday", Time: "09:00", Format: config.ReportFormatText,
+			SendTo: []string{config.ReportToUser, config.ReportToTherapist}},
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	store := newTestHandler(adapter, rc).Store()
+	userState := store.GetOrCreateUserState(62, "patient")
+	record := state.NewRecord()
+	record.IsSaved = true
+	record.CreatedAt = now.AddDate(0, 0, -2)
+	record.Data = map[string]state.Answer{"mood": state.ScoredAnswer(state.ScoredEntry{Text: "ok", Score: 7})}
+	userState.Records = append(userState.Records, record)
+
+	sendWeeklyReports(context.Background(), adapter, rc, store, now) // No REPORT_FONT needed
+
+	if len(adapter.Calls) != 2 {
+		t.Fatalf("calls %+v, want a summary to the user and the therapist", adapter.Calls)
+	}
+	wantHeaders := map[int64]string{
+		62:  config.Message("ru", config.MsgReportSummary, "05.10.2026 – 11.10.2026", 1),
+		900: config.Message("ru", config.MsgReportSummaryTherapist, "patient", 62, "05.10.2026 – 11.10.2026", 1),
+	}
+	for _, call := range adapter.Calls {
+		if call.Op != "send_message" || !strings.HasPrefix(call.Text, wantHeaders[call.ChatID]) {
+			t.Fatalf("%s to %d: %q, want a summary starting with %q", call.Op, call.ChatID, call.Text, wantHeaders[call.ChatID])
+		}
+	}
+}
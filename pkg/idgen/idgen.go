@@ -0,0 +1,106 @@
+// Package idgen generates the IDs of records and deliveries. IDs are random, so a shared ID says
+// nothing about the user it belongs to.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Generator returns a new unique ID on every call.
+type Generator interface {
+	NewID() string
+}
+
+// Supported ID formats, as accepted by New.
+const (
+	FormatShort = "short" // 10 Crockford base32 characters, e.g. "7KQ2M9XD4H"
+	FormatULID  = "ulid"  // 26 characters, sortable by creation time
+	FormatUUID  = "uuid"  // random (version 4) UUID
+)
+
+// ShortLen is the length of a short code, both of generated short IDs and of ShortCode.
+const ShortLen = 10
+
+// crockford is the Crockford base32 alphabet: no I, L, O or U, so codes read back without mix-ups.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// New returns the generator of format; an empty format means FormatShort.
+func New(format string) (Generator, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatShort:
+		return Short{}, nil
+	case FormatULID:
+		return ULID{}, nil
+	case FormatUUID:
+		return UUID{}, nil
+	}
+	return nil, fmt.Errorf("unknown ID format %q (want %s, %s or %s)", format, FormatShort, FormatULID, FormatUUID)
+}
+
+// ShortCode is the form of id shown to users and used in callback data: the last ShortLen letters and
+// digits of id, upper-cased. For short IDs that is the ID itself; for ULIDs and UUIDs it is their random
+// tail.
+func ShortCode(id string) string {
+	code := strings.ToUpper(strings.ReplaceAll(id, "-", ""))
+	if len(code) <= ShortLen {
+		return code
+	}
+	return code[len(code)-ShortLen:]
+}
+
+// Short generates random short codes.
+type Short struct{}
+
+func (Short) NewID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return encode(binary.BigEndian.Uint64(b[:]), ShortLen)
+}
+
+// ULID generates ULIDs: a millisecond timestamp followed by 80 random bits.
+type ULID struct {
+	Now func() time.Time // Defaults to time.Now
+}
+
+func (g ULID) NewID() string {
+	now := time.Now
+	if g.Now != nil {
+		now = g.Now
+	}
+	var entropy [10]byte
+	_, _ = rand.Read(entropy[:])
+	return encodeULID(uint64(now().UnixMilli()), entropy)
+}
+
+// encodeULID writes the 48-bit ms and the 80-bit entropy as 26 base32 characters, 10 for the time and
+// 16 for the entropy.
+func encodeULID(ms uint64, entropy [10]byte) string {
+	hi := uint64(entropy[0])<<32 | uint64(binary.BigEndian.Uint32(entropy[1:5]))
+	lo := uint64(entropy[5])<<32 | uint64(binary.BigEndian.Uint32(entropy[6:10]))
+	return encode(ms, 10) + encode(hi, 8) + encode(lo, 8)
+}
+
+// UUID generates random (version 4) UUIDs.
+type UUID struct{}
+
+func (UUID) NewID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// encode writes the low 5*n bits of v as n base32 characters, most significant first.
+func encode(v uint64, n int) string {
+	out := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		out[i] = crockford[v&31]
+		v >>= 5
+	}
+	return string(out)
+}
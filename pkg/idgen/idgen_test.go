@@ -0,0 +1,76 @@
+package idgen
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		format  string
+		pattern string
+		wantErr bool
+	}{
+		{format: "", pattern: `^[0-9A-HJKMNP-TV-Z]{10}$`},
+		{format: "short", pattern: `^[0-9A-HJKMNP-TV-Z]{10}$`},
+		{format: "ULID", pattern: `^[0-7][0-9A-HJKMNP-TV-Z]{25}$`},
+		{format: "uuid", pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{format: "nano", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			g, err := New(tt.format)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error for %q", tt.format)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pattern := regexp.MustCompile(tt.pattern)
+			seen := make(map[string]bool)
+			for i := 0; i < 1000; i++ {
+				id := g.NewID()
+				if !pattern.MatchString(id) {
+					t.Fatalf("id %q does not match %s", id, tt.pattern)
+				}
+				if seen[id] {
+					t.Fatalf("duplicate id %q", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestULIDSortsByTime(t *testing.T) {
+	at := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	earlier := ULID{Now: func() time.Time { return at }}.NewID()
+	later := ULID{Now: func() time.Time { return at.Add(time.Millisecond) }}.NewID()
+	if earlier >= later {
+		t.Fatalf("ULIDs must sort by time: %s >= %s", earlier, later)
+	}
+	// The reference ULID of the spec: time 1469918176385 with all-zero entropy.
+	if got := encodeULID(1469918176385, [10]byte{}); got != "01ARYZ6S410000000000000000" {
+		t.Fatalf("encodeULID = %s", got)
+	}
+}
+
+func TestShortCode(t *testing.T) {
+	tests := []struct {
+		id, want string
+	}{
+		{id: "7KQ2M9XD4H", want: "7KQ2M9XD4H"},
+		{id: "01ARYZ6S41TSV4RRFFQ69G5FAV", want: "FFQ69G5FAV"},
+		{id: "f47ac10b-58cc-4372-a567-0e02b2c3d479", want: "02B2C3D479"},
+		{id: "abc", want: "ABC"},
+	}
+	for _, tt := range tests {
+		if got := ShortCode(tt.id); got != tt.want {
+			t.Errorf("ShortCode(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package logbuffer keeps the most recent log records in memory so operators can inspect them from chat.
+package logbuffer
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UserKey is the log attribute Filter.UserID is matched against.
+const UserKey = "user_id"
+
+// Entry is a single captured log record.
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	UserID  string // Value of the top-level user_id attribute, if any
+	Message string // The message followed by its attributes as key=value
+}
+
+// Filter narrows Tail results.
+type Filter struct {
+	Limit    int        // Newest entries to return; zero returns all of them
+	MinLevel slog.Level // Entries below it are left out
+	UserID   string     // Only entries whose user_id is exactly this, when set
+}
+
+// Buffer is a fixed-size ring of log entries, filled through the slog.Handler of Handler.
+type Buffer struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// New creates a ring buffer holding up to capacity entries.
+func New(capacity int) *Buffer {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Buffer{entries: make([]Entry, capacity)}
+}
+
+// Default is the process-wide buffer wired into the default logger by main.
+var Default = New(500)
+
+func (b *Buffer) add(e Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = e
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Tail returns the newest entries matching the filter, oldest first.
+func (b *Buffer) Tail(f Filter) []Entry {
+	b.mu.Lock()
+	ordered := make([]Entry, 0, len(b.entries))
+	if b.full {
+		ordered = append(ordered, b.entries[b.next:]...)
+	}
+	ordered = append(ordered, b.entries[:b.next]...)
+	b.mu.Unlock()
+
+	matched := make([]Entry, 0, len(ordered))
+	for _, e := range ordered {
+		if e.Level < f.MinLevel {
+			continue
+		}
+		if f.UserID != "" && e.UserID != f.UserID {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	if f.Limit > 0 && len(matched) > f.Limit {
+		matched = matched[len(matched)-f.Limit:]
+	}
+	return matched
+}
+
+// Handler returns a slog.Handler that stores records at level or above in the buffer, keeping the
+// level and user_id of each record next to its text.
+func (b *Buffer) Handler(level slog.Leveler) slog.Handler {
+	return &handler{buffer: b, level: level}
+}
+
+// handler is the slog.Handler of a Buffer. attrs are those added through WithAttrs, already formatted
+// under their groups.
+type handler struct {
+	buffer *Buffer
+	level  slog.Leveler
+	attrs  []string
+	userID string
+	group  string // Prefix of attribute keys, "" or ending in "."
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *handler) Handle(_ context.Context, r slog.Record) error {
+	entry := Entry{Time: r.Time, Level: r.Level, UserID: h.userID}
+	parts := append([]string{r.Message}, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		if id, ok := h.userAttr(a); ok {
+			entry.UserID = id
+		}
+		parts = appendAttr(parts, h.group, a)
+		return true
+	})
+	entry.Message = strings.Join(parts, " ")
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	h.buffer.add(entry)
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append([]string(nil), h.attrs...)
+	for _, a := range attrs {
+		if id, ok := h.userAttr(a); ok {
+			next.userID = id
+		}
+		next.attrs = appendAttr(next.attrs, h.group, a)
+	}
+	return &next
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.group = h.group + name + "."
+	return &next
+}
+
+// userAttr reports the user ID a carries, if it is the top-level user_id attribute.
+func (h *handler) userAttr(a slog.Attr) (string, bool) {
+	if h.group != "" || a.Key != UserKey {
+		return "", false
+	}
+	return a.Value.Resolve().String(), true
+}
+
+// appendAttr formats a as key=value, flattening groups into dotted keys.
+func appendAttr(parts []string, prefix string, a slog.Attr) []string {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return parts
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, member := range a.Value.Group() {
+			parts = appendAttr(parts, prefix, member)
+		}
+		return parts
+	}
+	value := a.Value.String()
+	if value == "" || strings.ContainsAny(value, " =\"\n") {
+		value = strconv.Quote(value)
+	}
+	return append(parts, prefix+a.Key+"="+value)
+}
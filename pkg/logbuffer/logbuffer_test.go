@@ -0,0 +1,63 @@
+package logbuffer
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestTailWrapsAndFilters(t *testing.T) {
+	b := New(3)
+	logger := slog.New(b.Handler(slog.LevelDebug))
+	logger.Info("first", "user_id", 1)
+	logger.Warn("second", "user_id", 12)
+	logger.Error("third", "user_id", 123)
+	logger.With("user_id", 12).Debug("fourth", "err", "timed out")
+
+	all := b.Tail(Filter{MinLevel: slog.LevelDebug})
+	if len(all) != 3 || all[0].Message != "second user_id=12" || all[2].Message != `fourth user_id=12 err="timed out"` {
+		t.Fatalf("unexpected ring contents: %+v", all)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{name: "min level", filter: Filter{MinLevel: slog.LevelWarn}, want: []string{"second user_id=12", "third user_id=123"}},
+		{name: "exact user", filter: Filter{MinLevel: slog.LevelDebug, UserID: "12"}, want: []string{"second user_id=12", `fourth user_id=12 err="timed out"`}},
+		{name: "limit", filter: Filter{MinLevel: slog.LevelDebug, UserID: "12", Limit: 1}, want: []string{`fourth user_id=12 err="timed out"`}},
+		{name: "unknown user", filter: Filter{UserID: "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := b.Tail(tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %q", got, tt.want)
+			}
+			for i, e := range got {
+				if e.Message != tt.want[i] {
+					t.Fatalf("entry %d = %q, want %q", i, e.Message, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerKeepsLevelAndUser(t *testing.T) {
+	b := New(4)
+	logger := slog.New(b.Handler(slog.LevelInfo))
+	logger.Debug("dropped below the level")
+	logger.Info("save failed earlier, retrying", "user_id", int64(7))
+	logger.WithGroup("req").Error("nested user is not the user", "user_id", 8)
+
+	entries := b.Tail(Filter{MinLevel: slog.LevelDebug})
+	if len(entries) != 2 {
+		t.Fatalf("entries %+v, want the info and error records", entries)
+	}
+	if e := entries[0]; e.Level != slog.LevelInfo || e.UserID != "7" {
+		t.Fatalf("entry %+v, want info for user 7 despite the wording", e)
+	}
+	if e := entries[1]; e.Level != slog.LevelError || e.UserID != "" || e.Message != "nested user is not the user req.user_id=8" {
+		t.Fatalf("entry %+v, want an error without a user", e)
+	}
+}
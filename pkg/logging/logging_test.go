@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestHandlerAddsCorrelationID(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantCID string // Empty when the line must not carry one
+	}{
+		{name: "with id", ctx: WithCorrelationID(context.Background(), "abc123"), wantCID: "abc123"},
+		{name: "without id", ctx: context.Background()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(NewHandler(slog.NewTextHandler(&buf, nil))).With("user_id", 7)
+
+			logger.InfoContext(tt.ctx, "update received")
+
+			line := buf.String()
+			if !strings.Contains(line, "user_id=7") {
+				t.Fatalf("line %q lost the logger attributes", line)
+			}
+			hasCID := strings.Contains(line, CorrelationKey+"=")
+			if hasCID != (tt.wantCID != "") || (hasCID && !strings.Contains(line, CorrelationKey+"="+tt.wantCID)) {
+				t.Fatalf("line %q, want cid %q", line, tt.wantCID)
+			}
+		})
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	first := CorrelationID(NewContext(context.Background()))
+	second := CorrelationID(NewContext(context.Background()))
+	if len(first) != 12 || first == second {
+		t.Fatalf("ids %q and %q, want two different 12-character ids", first, second)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{in: "", want: slog.LevelInfo},
+		{in: "DEBUG", want: slog.LevelDebug},
+		{in: " warn ", want: slog.LevelWarn},
+		{in: "error", want: slog.LevelError},
+		{in: "verbose", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseLevel(%q) error = %v, wantErr %t", tt.in, err, tt.wantErr)
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Fatalf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTeeHandlerRespectsEachLevel(t *testing.T) {
+	var verbose, quiet bytes.Buffer
+	tee := teeHandler{
+		slog.NewTextHandler(&verbose, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.NewTextHandler(&quiet, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	}
+	logger := slog.New(tee).With("user_id", 7)
+
+	logger.Debug("details")
+	logger.Warn("trouble")
+
+	if !strings.Contains(verbose.String(), "msg=details") || !strings.Contains(verbose.String(), "msg=trouble user_id=7") {
+		t.Fatalf("debug handler got %q, want both records", verbose.String())
+	}
+	if strings.Contains(quiet.String(), "details") || !strings.Contains(quiet.String(), "msg=trouble user_id=7") {
+		t.Fatalf("warn handler got %q, want only the warning", quiet.String())
+	}
+}
@@ -0,0 +1,106 @@
+// Package metrics keeps a tiny in-process gauge registry and serves it in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type series struct {
+	labels map[string]string
+	value  float64
+}
+
+type family struct {
+	help   string
+	series map[string]series
+}
+
+var (
+	mu         sync.RWMutex
+	families   = make(map[string]*family)
+	collectors []func()
+)
+
+// RegisterCollector adds fn to the functions run before every Render, for gauges that are cheaper to
+// compute on scrape than to keep up to date.
+func RegisterCollector(fn func()) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	collectors = append(collectors, fn)
+}
+
+// SetGauge records value for the gauge name with the given labels, creating the series on first use.
+func SetGauge(name, help string, labels map[string]string, value float64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	fam, ok := families[name]
+	if !ok {
+		fam = &family{help: help, series: make(map[string]series)}
+		families[name] = fam
+	}
+	fam.series[formatLabels(labels)] = series{labels: labels, value: value}
+}
+
+// Handler exposes all gauges in the Prometheus text exposition format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte(Render()))
+	})
+}
+
+// Render returns the current registry contents in the Prometheus text exposition format.
+func Render() string {
+	mu.RLock()
+	pending := collectors
+	mu.RUnlock()
+	for _, collect := range pending {
+		collect()
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fam := families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, fam.help, name)
+		keys := make([]string, 0, len(fam.series))
+		for key := range fam.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s%s %g\n", name, key, fam.series[key].value)
+		}
+	}
+	return b.String()
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderPrometheusText(t *testing.T) {
+	SetGauge("test_build_info", "Build metadata", map[string]string{"version": "v1", "commit": "abc"}, 1)
+
+	out := Render()
+	for _, want := range []string{
+		"# TYPE test_build_info gauge",
+		`test_build_info{commit="abc",version="v1"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestCollectorsRunOnRender(t *testing.T) {
+	scrapes := 0
+	RegisterCollector(func() {
+		scrapes++
+		SetGauge("test_scrapes", "Scrapes seen by the collector", nil, float64(scrapes))
+	})
+
+	Render()
+	if out := Render(); !strings.Contains(out, "test_scrapes 2") {
+		t.Fatalf("expected the collector to refresh the gauge:\n%s", out)
+	}
+}
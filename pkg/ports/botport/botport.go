@@ -7,18 +7,33 @@ import (
 	"time"
 )
 
-// Package botport provides the outbound interface between the FSM and chat adapters.
+// Package botport provides the interface between the FSM and chat adapters: BotPort for outbound
+// calls and InboundEvent for the updates the adapters deliver.
 // See PRPs/ai_docs/botport_hex_adapter.md for the architectural rationale and error semantics.
 
 // BotMessage captures adapter-agnostic identifiers for previously sent messages.
 type BotMessage struct {
 	ChatID    int64
 	MessageID int
+	// PartIDs lists every message a text over MaxMessageLength was sent as, in order; MessageID is the
+	// last of them. It is nil for a text sent whole.
+	PartIDs   []int
 	Transport string
 	Payload   string
 	Meta      map[string]string
 }
 
+// MessageIDs returns the IDs of every message m was sent as.
+func (m BotMessage) MessageIDs() []int {
+	if len(m.PartIDs) > 0 {
+		return m.PartIDs
+	}
+	if m.MessageID == 0 {
+		return nil
+	}
+	return []int{m.MessageID}
+}
+
 // BotError wraps adapter failures with retry hints and normalized codes.
 type BotError struct {
 	Op         string
@@ -66,10 +81,67 @@ func IsCode(err error, code string) bool {
 	return false
 }
 
+// Capabilities describes what a transport can carry; startup checks configured question types against it.
+type Capabilities struct {
+	Text        bool // Delivers free-form text replies
+	Callbacks   bool // Renders inline buttons and delivers their callbacks
+	Attachments bool // Sends and receives files or media
+	EditInPlace bool // Edits previously sent messages
+}
+
+// SendOptions tunes how SendMessage delivers a message. Build it from SendOption values.
+type SendOptions struct {
+	ReplyTo             int             // Thread the message under this message ID; sent standalone if it is gone
+	DisableNotification bool            // Deliver without a sound
+	ProtectContent      bool            // Forbid forwarding and saving the message
+	OnRetry             func(err error) // Told of each rate_limited error the transport waits out to resend
+}
+
+// SendOption sets one field of SendOptions.
+type SendOption func(*SendOptions)
+
+// ReplyTo threads the message under messageID; zero leaves it standalone.
+func ReplyTo(messageID int) SendOption {
+	return func(o *SendOptions) { o.ReplyTo = messageID }
+}
+
+// Silent delivers the message without a notification sound.
+func Silent() SendOption {
+	return func(o *SendOptions) { o.DisableNotification = true }
+}
+
+// Protected forbids forwarding and saving the message.
+func Protected() SendOption {
+	return func(o *SendOptions) { o.ProtectContent = true }
+}
+
+// OnRetry has fn called with the rate_limited error every time the transport waits to send the message
+// again, before the wait; fn runs on the sending goroutine.
+func OnRetry(fn func(err error)) SendOption {
+	return func(o *SendOptions) { o.OnRetry = fn }
+}
+
+// ApplySendOptions folds opts into SendOptions; adapters call it.
+func ApplySendOptions(opts []SendOption) SendOptions {
+	var o SendOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
 // BotPort abstracts outbound message operations for adapters (Telegram, fake, etc.).
 type BotPort interface {
-	SendMessage(ctx context.Context, chatID int64, text string, markup interface{}) (BotMessage, error)
+	Capabilities() Capabilities
+	SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts ...SendOption) (BotMessage, error)
 	EditMessage(ctx context.Context, chatID int64, messageID int, text string, markup interface{}) (BotMessage, error)
 	AnswerCallback(ctx context.Context, callbackID string, text string) error
 	DeleteMessage(ctx context.Context, chatID int64, messageID int) error
+	// SendSticker and SendAnimation send media stored by the transport, referenced by fileID.
+	SendSticker(ctx context.Context, chatID int64, fileID string, opts ...SendOption) (BotMessage, error)
+	SendAnimation(ctx context.Context, chatID int64, fileID string, caption string, opts ...SendOption) (BotMessage, error)
+	// SendDocument uploads data as a file named fileName, such as an export of the user's records.
+	SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string, opts ...SendOption) (BotMessage, error)
 }
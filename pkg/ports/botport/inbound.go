@@ -0,0 +1,54 @@
+package botport
+
+// EventKind tells what an InboundEvent carries.
+type EventKind string
+
+const (
+	EventMessage  EventKind = "message"  // A message the user sent: text, a command or media
+	EventCallback EventKind = "callback" // A tap on an inline button
+)
+
+// Sender is the user behind an InboundEvent.
+type Sender struct {
+	ID           int64
+	FirstName    string
+	LastName     string
+	Username     string
+	LanguageCode string
+}
+
+// Media is a file attached to an inbound message, referenced the way the transport stores it.
+type Media struct {
+	Type   string // photo, document, voice, audio, video, sticker or animation
+	FileID string
+}
+
+// InboundEvent is one update from a transport. Each adapter's listener turns its own updates into
+// InboundEvents, so polling, webhooks and other transports share one entry point into the FSM.
+type InboundEvent struct {
+	ID         int64 // Transport update ID, for logs
+	Kind       EventKind
+	From       Sender
+	ChatID     int64
+	MessageID  int    // The message sent, or the one carrying the tapped button
+	ReplyTo    int    // The message a sent message replies to, zero when it replies to none
+	Text       string // Message text or media caption; for a callback, the text of the button's message
+	Command    string // Command without the slash or bot name when the message starts with one
+	Args       string // Text after the command
+	CallbackID string // Passed to AnswerCallback
+	Data       string // Action of the tapped button
+	Media      *Media
+}
+
+// IsCommand reports whether the event is a message starting with a command.
+func (e *InboundEvent) IsCommand() bool {
+	return e.Kind == EventMessage && e.Command != ""
+}
+
+// Name returns the sender's first and last name.
+func (s Sender) Name() string {
+	if s.LastName == "" {
+		return s.FirstName
+	}
+	return s.FirstName + " " + s.LastName
+}
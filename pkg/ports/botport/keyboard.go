@@ -0,0 +1,50 @@
+package botport
+
+// Keyboard is a transport-neutral inline keyboard: rows of buttons attached to a message. Adapters
+// render it in their own markup; a tapped button comes back as a callback carrying its Action.
+type Keyboard struct {
+	Rows [][]Button
+}
+
+// Button is one keyboard button. Action is the callback data delivered when it is tapped.
+type Button struct {
+	Label  string
+	Action string
+}
+
+// NewKeyboard returns a keyboard with the given rows.
+func NewKeyboard(rows ...[]Button) *Keyboard {
+	return &Keyboard{Rows: rows}
+}
+
+// NewRow groups buttons into one keyboard row.
+func NewRow(buttons ...Button) []Button {
+	return buttons
+}
+
+// NewButton returns a button sending action when tapped.
+func NewButton(label, action string) Button {
+	return Button{Label: label, Action: action}
+}
+
+// AddRow appends a row of buttons; an empty row is skipped.
+func (k *Keyboard) AddRow(buttons ...Button) {
+	if len(buttons) == 0 {
+		return
+	}
+	k.Rows = append(k.Rows, buttons)
+}
+
+// ReplyKeyboard replaces the user's keyboard with buttons that send their label as a plain message.
+// It stays on screen until a RemoveKeyboard is sent.
+type ReplyKeyboard struct {
+	Rows [][]string
+}
+
+// NewReplyKeyboard returns a reply keyboard with the given rows of labels.
+func NewReplyKeyboard(rows ...[]string) *ReplyKeyboard {
+	return &ReplyKeyboard{Rows: rows}
+}
+
+// RemoveKeyboard takes a ReplyKeyboard off the screen.
+type RemoveKeyboard struct{}
@@ -0,0 +1,105 @@
+package botport
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// MaxMessageLength is Telegram's limit on the text of one message, in UTF-16 code units.
+const MaxMessageLength = 4096
+
+// textSeparators are the boundaries SplitText prefers, best first: paragraphs, lines, words.
+var textSeparators = []string{"\n\n", "\n", " "}
+
+// SplitText cuts text into chunks of at most limit UTF-16 code units, the way Telegram counts message
+// length. It breaks between paragraphs where it can, else between lines or words, and cuts a word only
+// when it alone exceeds the limit. The separator at a break is dropped; blank chunks are left out.
+// Text within the limit, or a non-positive limit, gives text itself.
+func SplitText(text string, limit int) []string {
+	if limit <= 0 || textLength(text) <= limit {
+		return []string{text}
+	}
+	var chunks []string
+	for _, chunk := range splitOn(text, limit, textSeparators) {
+		if strings.TrimSpace(chunk) != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// truncationMark ends a text TruncateText cut short.
+const truncationMark = "…"
+
+// TruncateText returns text cut to at most limit UTF-16 code units at the boundary SplitText would
+// choose, ending in "…" when anything was cut. Text within the limit, or a non-positive limit, gives
+// text itself.
+func TruncateText(text string, limit int) string {
+	if limit <= 0 || textLength(text) <= limit {
+		return text
+	}
+	room := limit - textLength(truncationMark)
+	if room <= 0 {
+		return cutRunes(truncationMark, limit)[0]
+	}
+	return strings.TrimRight(splitOn(text, room, textSeparators)[0], " \n") + truncationMark
+}
+
+// splitOn packs the parts of text between seps[0] into chunks within limit, splitting a part that
+// does not fit on the next separators.
+func splitOn(text string, limit int, seps []string) []string {
+	if textLength(text) <= limit {
+		return []string{text}
+	}
+	if len(seps) == 0 {
+		return cutRunes(text, limit)
+	}
+
+	sep := seps[0]
+	var chunks []string
+	current, started := "", false
+	for _, part := range strings.Split(text, sep) {
+		if started && textLength(current)+textLength(sep)+textLength(part) <= limit {
+			current += sep + part
+			continue
+		}
+		if started {
+			chunks = append(chunks, current)
+		}
+		pieces := splitOn(part, limit, seps[1:])
+		chunks = append(chunks, pieces[:len(pieces)-1]...)
+		current, started = pieces[len(pieces)-1], true
+	}
+	return append(chunks, current)
+}
+
+// cutRunes cuts text into chunks of at most limit UTF-16 code units without splitting a character.
+func cutRunes(text string, limit int) []string {
+	var chunks []string
+	start, length := 0, 0
+	for i, r := range text {
+		size := utf16.RuneLen(r)
+		if size < 0 {
+			size = 1 // Invalid UTF-8 is sent as U+FFFD
+		}
+		if length+size > limit && i > start {
+			chunks = append(chunks, text[start:i])
+			start, length = i, 0
+		}
+		length += size
+	}
+	return append(chunks, text[start:])
+}
+
+// textLength is the length of text in UTF-16 code units.
+func textLength(text string) int {
+	n := 0
+	for _, r := range text {
+		if size := utf16.RuneLen(r); size > 0 {
+			n += size
+		} else {
+			n++
+		}
+	}
+	return n
+}
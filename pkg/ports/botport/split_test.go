@@ -0,0 +1,79 @@
+package botport
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{name: "within the limit", text: "one\n\ntwo", limit: 20, want: []string{"one\n\ntwo"}},
+		{name: "no limit", text: "one two", limit: 0, want: []string{"one two"}},
+		{name: "paragraphs packed", text: "aaa\n\nbbb\n\nccc", limit: 8, want: []string{"aaa\n\nbbb", "ccc"}},
+		{name: "long paragraph on lines", text: "aaa\nbbb\nccc\n\nd", limit: 7, want: []string{"aaa\nbbb", "ccc\n\nd"}},
+		{name: "long line on words", text: "aa bb cc dd", limit: 5, want: []string{"aa bb", "cc dd"}},
+		{name: "long word cut", text: "abcdefgh ij", limit: 3, want: []string{"abc", "def", "gh", "ij"}},
+		{name: "cyrillic counts once", text: "абв где", limit: 3, want: []string{"абв", "где"}},
+		{name: "emoji counts twice", text: "😀😀😀", limit: 4, want: []string{"😀😀", "😀"}},
+		{name: "blank chunks dropped", text: "aaa\n\n\n\n\n\nbbb", limit: 3, want: []string{"aaa", "bbb"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitText(tt.text, tt.limit)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Fatalf("SplitText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{name: "within the limit", text: "one two", limit: 7, want: "one two"},
+		{name: "no limit", text: "one two", limit: 0, want: "one two"},
+		{name: "cut between paragraphs", text: "aaa\n\nbbb\n\nccc", limit: 9, want: "aaa\n\nbbb…"},
+		{name: "cut between words", text: "aa bb cc dd", limit: 7, want: "aa bb…"},
+		{name: "long word cut", text: "abcdefgh", limit: 4, want: "abc…"},
+		{name: "room for the mark only", text: "abc", limit: 1, want: "…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateText(tt.text, tt.limit)
+			if got != tt.want || (tt.limit > 0 && textLength(got) > tt.limit) {
+				t.Fatalf("TruncateText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTextKeepsRecordsWithinTelegramLimit(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 300; i++ {
+		b.WriteString("- Что было важно сегодня? 🙂\n  прогулка, разговор с другом, книга (7)\n\n")
+	}
+	text := b.String()
+
+	chunks := SplitText(text, MaxMessageLength)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if n := textLength(chunk); n > MaxMessageLength {
+			t.Fatalf("chunk %d has %d code units", i, n)
+		}
+	}
+	// Every record is short, so the text breaks between records only.
+	if got := strings.Join(chunks, "\n\n"); strings.TrimSpace(got) != strings.TrimSpace(text) {
+		t.Fatalf("chunks do not add up to the text")
+	}
+}
@@ -0,0 +1,178 @@
+package storeport
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// Codec turns users, records and record metadata into the JSON adapters store. With Keys it seals
+// the answers: Record.Data, Record.Transient (the partial answers of multi-step questions) and
+// Record.Notes of saved records and drafts, the section snapshot of a user and the answer previews
+// of the metadata. IDs, dates, states and content filter tags stay readable. Plain JSON is still
+// read, so encryption can be turned on for an existing database.
+type Codec struct {
+	Keys *Keyring
+}
+
+// sealedRecord is the stored form of a record. Its Data, Transient and Notes shadow those of Record,
+// which are left out once the matching Sealed field holds them.
+type sealedRecord struct {
+	*state.Record
+	Data            map[string]state.Answer           `json:",omitempty"`
+	SealedData      *Sealed                           `json:",omitempty"`
+	Transient       map[string]*state.QuestionScratch `json:",omitempty"`
+	SealedTransient *Sealed                           `json:",omitempty"`
+	Notes           []state.Note                      `json:",omitempty"`
+	SealedNotes     *Sealed                           `json:",omitempty"`
+}
+
+func (c Codec) EncodeRecord(record *state.Record) ([]byte, error) {
+	stored := sealedRecord{Record: record, Data: record.Data, Transient: record.Transient, Notes: record.Notes}
+	if c.Keys != nil {
+		aad := "record:" + record.ID
+		sealed, err := c.Keys.seal(record.Data, aad)
+		if err != nil {
+			return nil, fmt.Errorf("seal record %s: %w", record.ID, err)
+		}
+		stored.Data, stored.SealedData = nil, sealed
+		if len(record.Transient) > 0 {
+			if stored.SealedTransient, err = c.Keys.seal(record.Transient, aad); err != nil {
+				return nil, fmt.Errorf("seal record %s: %w", record.ID, err)
+			}
+			stored.Transient = nil
+		}
+		if len(record.Notes) > 0 {
+			if stored.SealedNotes, err = c.Keys.seal(record.Notes, aad); err != nil {
+				return nil, fmt.Errorf("seal record %s: %w", record.ID, err)
+			}
+			stored.Notes = nil
+		}
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("encode record %s: %w", record.ID, err)
+	}
+	return data, nil
+}
+
+func (c Codec) DecodeRecord(data []byte) (*state.Record, error) {
+	var record state.Record
+	stored := sealedRecord{Record: &record}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("decode record: %w", err)
+	}
+	record.Data, record.Transient, record.Notes = stored.Data, stored.Transient, stored.Notes
+	aad := "record:" + record.ID
+	if stored.SealedData != nil {
+		if err := c.Keys.open(stored.SealedData, &record.Data, aad); err != nil {
+			return nil, fmt.Errorf("decode record %s: %w", record.ID, err)
+		}
+	}
+	if stored.SealedTransient != nil {
+		if err := c.Keys.open(stored.SealedTransient, &record.Transient, aad); err != nil {
+			return nil, fmt.Errorf("decode record %s: %w", record.ID, err)
+		}
+	}
+	if stored.SealedNotes != nil {
+		if err := c.Keys.open(stored.SealedNotes, &record.Notes, aad); err != nil {
+			return nil, fmt.Errorf("decode record %s: %w", record.ID, err)
+		}
+	}
+	if record.Data == nil {
+		record.Data = make(map[string]state.Answer)
+	}
+	return &record, nil
+}
+
+type sealedMeta struct {
+	state.RecordMeta
+	Preview       map[string]string `json:",omitempty"`
+	SealedPreview *Sealed           `json:",omitempty"`
+}
+
+func (c Codec) EncodeMeta(meta state.RecordMeta) ([]byte, error) {
+	stored := sealedMeta{RecordMeta: meta, Preview: meta.Preview}
+	if c.Keys != nil && len(meta.Preview) > 0 {
+		sealed, err := c.Keys.seal(meta.Preview, "meta:"+meta.ID)
+		if err != nil {
+			return nil, fmt.Errorf("seal record %s metadata: %w", meta.ID, err)
+		}
+		stored.Preview, stored.SealedPreview = nil, sealed
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("encode record %s metadata: %w", meta.ID, err)
+	}
+	return data, nil
+}
+
+func (c Codec) DecodeMeta(data []byte) (state.RecordMeta, error) {
+	var stored sealedMeta
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return state.RecordMeta{}, fmt.Errorf("decode record metadata: %w", err)
+	}
+	meta := stored.RecordMeta
+	meta.Preview = stored.Preview
+	if stored.SealedPreview != nil {
+		if err := c.Keys.open(stored.SealedPreview, &meta.Preview, "meta:"+meta.ID); err != nil {
+			return state.RecordMeta{}, fmt.Errorf("decode record %s metadata: %w", meta.ID, err)
+		}
+	}
+	return meta, nil
+}
+
+// sealedUser is the stored form of a user: the draft goes through EncodeRecord and the section
+// snapshot is sealed like the answers it backs up.
+type sealedUser struct {
+	UserSnapshot
+	Draft                 json.RawMessage         `json:"draft,omitempty"`
+	SectionSnapshot       map[string]state.Answer `json:"section_snapshot,omitempty"`
+	SealedSectionSnapshot *Sealed                 `json:"sealed_section_snapshot,omitempty"`
+}
+
+func (c Codec) EncodeUser(snapshot UserSnapshot) ([]byte, error) {
+	stored := sealedUser{UserSnapshot: snapshot, SectionSnapshot: snapshot.SectionSnapshot}
+	if snapshot.Draft != nil {
+		draft, err := c.EncodeRecord(snapshot.Draft)
+		if err != nil {
+			return nil, fmt.Errorf("encode user %d: %w", snapshot.UserID, err)
+		}
+		stored.Draft = draft
+	}
+	if c.Keys != nil && len(snapshot.SectionSnapshot) > 0 {
+		sealed, err := c.Keys.seal(snapshot.SectionSnapshot, fmt.Sprintf("user:%d", snapshot.UserID))
+		if err != nil {
+			return nil, fmt.Errorf("seal user %d: %w", snapshot.UserID, err)
+		}
+		stored.SectionSnapshot, stored.SealedSectionSnapshot = nil, sealed
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("encode user %d: %w", snapshot.UserID, err)
+	}
+	return data, nil
+}
+
+func (c Codec) DecodeUser(data []byte) (UserSnapshot, error) {
+	var stored sealedUser
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return UserSnapshot{}, fmt.Errorf("decode user: %w", err)
+	}
+	snapshot := stored.UserSnapshot
+	snapshot.SectionSnapshot = stored.SectionSnapshot
+	if stored.SealedSectionSnapshot != nil {
+		if err := c.Keys.open(stored.SealedSectionSnapshot, &snapshot.SectionSnapshot, fmt.Sprintf("user:%d", snapshot.UserID)); err != nil {
+			return UserSnapshot{}, fmt.Errorf("decode user %d: %w", snapshot.UserID, err)
+		}
+	}
+	if len(stored.Draft) > 0 && string(stored.Draft) != "null" {
+		draft, err := c.DecodeRecord(stored.Draft)
+		if err != nil {
+			return UserSnapshot{}, fmt.Errorf("decode user %d: %w", snapshot.UserID, err)
+		}
+		snapshot.Draft = draft
+	}
+	return snapshot, nil
+}
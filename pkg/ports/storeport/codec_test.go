@@ -0,0 +1,136 @@
+package storeport
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func testKeyring(t *testing.T, seeds ...byte) *Keyring {
+	t.Helper()
+	keys := make([][]byte, len(seeds))
+	for i, seed := range seeds {
+		keys[i] = bytes.Repeat([]byte{seed}, KeySize)
+	}
+	keyring, err := NewKeyring(keys...)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return keyring
+}
+
+func TestCodecUserRoundTrip(t *testing.T) {
+	draft := state.NewRecord()
+	draft.ID = "DRAFT1"
+	draft.Data["mood"] = state.StringAnswer("anxious")
+	draft.Transient = map[string]*state.QuestionScratch{"sleep": {Step: "score", Values: map[string]string{"text": "restless"}}}
+	snapshot := UserSnapshot{
+		UserID:          5,
+		UserName:        "Anna",
+		Draft:           draft,
+		CreatedAt:       time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		Section:         "day",
+		SectionSnapshot: map[string]state.Answer{"mood": state.StringAnswer("calm")},
+	}
+
+	for _, codec := range []Codec{{}, {Keys: testKeyring(t, 1)}} {
+		data, err := codec.EncodeUser(snapshot)
+		if err != nil {
+			t.Fatalf("EncodeUser: %v", err)
+		}
+		if sealed := !strings.Contains(string(data), "anxious") && !strings.Contains(string(data), "calm") && !strings.Contains(string(data), "restless"); sealed != (codec.Keys != nil) {
+			t.Fatalf("answers sealed = %t in %s", sealed, data)
+		}
+		got, err := codec.DecodeUser(data)
+		if err != nil {
+			t.Fatalf("DecodeUser: %v", err)
+		}
+		if got.UserName != "Anna" || got.Section != "day" || got.Draft == nil || got.Draft.Data["mood"].String() != "anxious" || got.Draft.Transient["sleep"].Values["text"] != "restless" || got.SectionSnapshot["mood"].String() != "calm" {
+			t.Fatalf("user changed on the way: %+v", got)
+		}
+	}
+}
+
+func TestCodecKeys(t *testing.T) {
+	record := state.NewRecord()
+	record.ID = "REC1"
+	record.Data["mood"] = state.StringAnswer("fine")
+	plain, err := Codec{}.EncodeRecord(record)
+	if err != nil {
+		t.Fatalf("EncodeRecord: %v", err)
+	}
+	sealedOld, err := Codec{Keys: testKeyring(t, 1)}.EncodeRecord(record)
+	if err != nil {
+		t.Fatalf("EncodeRecord: %v", err)
+	}
+	moved := bytes.Replace(sealedOld, []byte(`"ID":"REC1"`), []byte(`"ID":"REC2"`), 1)
+
+	tests := []struct {
+		name    string
+		codec   Codec
+		data    []byte
+		wantErr error // nil: decodes to the record; errAny: any error
+	}{
+		{name: "plain JSON with keys", codec: Codec{Keys: testKeyring(t, 2)}, data: plain},
+		{name: "old key after rotation", codec: Codec{Keys: testKeyring(t, 2, 1)}, data: sealedOld},
+		{name: "old key dropped", codec: Codec{Keys: testKeyring(t, 2)}, data: sealedOld, wantErr: ErrNoKey},
+		{name: "no keys", codec: Codec{}, data: sealedOld, wantErr: ErrNoKey},
+		{name: "copied to another record", codec: Codec{Keys: testKeyring(t, 1)}, data: moved, wantErr: errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.codec.DecodeRecord(tt.data)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("DecodeRecord: %v", err)
+			case tt.wantErr == nil && got.Data["mood"].String() != "fine":
+				t.Fatalf("record %+v, want the answer back", got)
+			case tt.wantErr == errAny && err == nil:
+				t.Fatalf("expected an error")
+			case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+var errAny = errors.New("any error")
+
+func TestNewKeyringRejectsShortKeys(t *testing.T) {
+	if _, err := NewKeyring([]byte("short")); err == nil {
+		t.Fatalf("expected an error for a short key")
+	}
+	if _, err := NewKeyring(); err == nil {
+		t.Fatalf("expected an error without keys")
+	}
+}
+
+func TestCodecRecordNotesRoundTrip(t *testing.T) {
+	record := state.NewRecord()
+	record.ID = "REC1"
+	record.IsSaved = true
+	record.Data["mood"] = state.StringAnswer("fine")
+	at := time.Date(2026, 3, 2, 20, 15, 0, 0, time.UTC)
+	record.AddNote(at, "slept badly after the call")
+
+	for _, codec := range []Codec{{}, {Keys: testKeyring(t, 1)}} {
+		data, err := codec.EncodeRecord(record)
+		if err != nil {
+			t.Fatalf("EncodeRecord: %v", err)
+		}
+		if sealed := !strings.Contains(string(data), "slept badly"); sealed != (codec.Keys != nil) {
+			t.Fatalf("note sealed = %t in %s", sealed, data)
+		}
+		got, err := codec.DecodeRecord(data)
+		if err != nil {
+			t.Fatalf("DecodeRecord: %v", err)
+		}
+		if len(got.Notes) != 1 || got.Notes[0].Text != "slept badly after the call" || !got.Notes[0].At.Equal(at) {
+			t.Fatalf("notes changed on the way: %+v", got.Notes)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package storeport
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the length of an encryption key: AES-256.
+const KeySize = 32
+
+// ErrNoKey is returned, possibly wrapped, for data sealed with a key the keyring does not have.
+var ErrNoKey = errors.New("no key for sealed data")
+
+// Keyring seals answers at rest with AES-256-GCM. The first key seals and every key opens, so a key is
+// rotated by putting the new one first and keeping the old ones until the data was sealed again.
+type Keyring struct {
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+// Sealed is an encrypted JSON value. Key names the key that sealed it: the start of the key's SHA-256.
+type Sealed struct {
+	Key   string `json:"key"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// NewKeyring builds a keyring from KeySize-byte keys, the sealing key first.
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring needs a key")
+	}
+	k := &Keyring{aeads: make(map[string]cipher.AEAD, len(keys))}
+	for i, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %d is %d bytes, want %d", i+1, len(key), KeySize)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i+1, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i+1, err)
+		}
+		sum := sha256.Sum256(key)
+		id := hex.EncodeToString(sum[:4])
+		if i == 0 {
+			k.current = id
+		}
+		k.aeads[id] = aead
+	}
+	return k, nil
+}
+
+// seal encrypts the JSON of v with the current key. aad binds the result to its owner, so sealed data
+// copied to another record or user does not open.
+func (k *Keyring) seal(v any, aad string) (*Sealed, error) {
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	aead := k.aeads[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("make nonce: %w", err)
+	}
+	return &Sealed{Key: k.current, Nonce: nonce, Data: aead.Seal(nil, nonce, plaintext, []byte(aad))}, nil
+}
+
+// open decrypts sealed into v.
+func (k *Keyring) open(sealed *Sealed, v any, aad string) error {
+	if k == nil {
+		return fmt.Errorf("%w: encryption is not configured", ErrNoKey)
+	}
+	aead, ok := k.aeads[sealed.Key]
+	if !ok {
+		return fmt.Errorf("%w: key %s", ErrNoKey, sealed.Key)
+	}
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Data, []byte(aad))
+	if err != nil {
+		return fmt.Errorf("open sealed data: %w", err)
+	}
+	return json.Unmarshal(plaintext, v)
+}
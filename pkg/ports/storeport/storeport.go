@@ -0,0 +1,205 @@
+// Package storeport provides the persistence interface between state.Store and storage adapters, the
+// storage counterpart of botport. The store stays the in-memory façade the FSM works with; a StorePort
+// behind it keeps users and saved records across restarts.
+package storeport
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// ErrNotFound is returned, possibly wrapped, for records an adapter does not have.
+var ErrNotFound = errors.New("not found")
+
+// StorePort is implemented by storage adapters.
+type StorePort interface {
+	// GetUserState returns the stored user without saved records, FSMs or on-screen messages; ok is
+	// false for unknown users.
+	GetUserState(ctx context.Context, userID int64) (*state.UserState, bool, error)
+	SaveUserState(ctx context.Context, userState *state.UserState) error
+	// ListUsers returns the IDs of every stored user, in no particular order.
+	ListUsers(ctx context.Context) ([]int64, error)
+	// DeleteUserState removes the stored user but not their records; deleting a missing user is not an
+	// error.
+	DeleteUserState(ctx context.Context, userID int64) error
+	// SaveRecord inserts or replaces the saved record, moving it to userID if another user had it.
+	SaveRecord(ctx context.Context, userID int64, record *state.Record) error
+	// ListRecords returns the metadata of the saved records of userID, oldest first.
+	ListRecords(ctx context.Context, userID int64) ([]state.RecordMeta, error)
+	GetRecord(ctx context.Context, userID int64, id string) (*state.Record, error)
+	// DeleteRecord removes the record; deleting a missing record is not an error.
+	DeleteRecord(ctx context.Context, userID int64, id string) error
+	// SaveDelivery inserts or replaces the delivery, keyed by its ID. Deliveries refer to the forwarded
+	// record by ID and carry no answers.
+	SaveDelivery(ctx context.Context, delivery *state.Delivery) error
+	// ListDeliveries returns every stored delivery, in no particular order.
+	ListDeliveries(ctx context.Context) ([]*state.Delivery, error)
+	Close() error
+}
+
+// UserSnapshot is the part of a UserState that adapters store. Saved records are stored on their own.
+// The FSM states and the position in the draft are kept, so a restored user continues where they left
+// off; the lock and message IDs belong to the running process, so the next prompt is a new message.
+type UserSnapshot struct {
+	UserID         int64         `json:"user_id"`
+	UserName       string        `json:"user_name"`
+	Profile        state.Profile `json:"profile"`
+	Draft          *state.Record `json:"draft,omitempty"`
+	AutoForwardOff bool          `json:"auto_forward_off,omitempty"`
+	Language       string        `json:"language,omitempty"`
+	Therapist      int64         `json:"therapist,omitempty"`
+	NudgesSent     int           `json:"nudges_sent,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	LastActivity   time.Time     `json:"last_activity"`
+
+	States          state.FSMStates         `json:"states"`
+	Section         string                  `json:"section,omitempty"`
+	Question        int                     `json:"question,omitempty"`
+	SectionSnapshot map[string]state.Answer `json:"section_snapshot,omitempty"`
+	EditingAnswer   bool                    `json:"editing_answer,omitempty"`
+	ListOffset      int                     `json:"list_offset,omitempty"`
+	SectionPage     int                     `json:"section_page,omitempty"`
+}
+
+// Snapshot takes the stored part of userState. During an admin preview the real draft is the backup,
+// not the throwaway record being answered, and the user is stored at the main menu.
+func Snapshot(userState *state.UserState) UserSnapshot {
+	draft := userState.CurrentRecord
+	if userState.Preview {
+		draft = userState.PreviewBackup
+	}
+	snapshot := UserSnapshot{
+		UserID:         userState.UserID,
+		UserName:       userState.UserName,
+		Profile:        userState.Profile,
+		Draft:          draft,
+		AutoForwardOff: userState.AutoForwardOff,
+		Language:       userState.Language,
+		Therapist:      userState.Therapist,
+		NudgesSent:     userState.NudgesSent,
+		CreatedAt:      userState.CreatedAt,
+		LastActivity:   userState.LastActivity,
+	}
+	if !userState.Preview && userState.MainMenuFSM != nil && userState.RecordFSM != nil {
+		snapshot.States = state.FSMStates{MainMenu: userState.MainMenuFSM.Current(), Record: userState.RecordFSM.Current()}
+		snapshot.Section = userState.CurrentSection
+		snapshot.Question = userState.CurrentQuestion
+		snapshot.SectionSnapshot = userState.SectionSnapshot
+		snapshot.EditingAnswer = userState.EditingAnswer
+		snapshot.ListOffset = userState.ListOffset
+		snapshot.SectionPage = userState.SectionPage
+	}
+	return snapshot
+}
+
+// UserState rebuilds a user from the snapshot. The store adds FSMs in the Resume states when it
+// restores the user.
+func (s UserSnapshot) UserState() *state.UserState {
+	return &state.UserState{
+		UserID:          s.UserID,
+		UserName:        s.UserName,
+		Profile:         s.Profile,
+		Records:         make([]*state.Record, 0),
+		CurrentRecord:   s.Draft,
+		CurrentSection:  s.Section,
+		CurrentQuestion: s.Question,
+		SectionSnapshot: s.SectionSnapshot,
+		EditingAnswer:   s.EditingAnswer,
+		ListOffset:      s.ListOffset,
+		SectionPage:     s.SectionPage,
+		AutoForwardOff:  s.AutoForwardOff,
+		Language:        s.Language,
+		Therapist:       s.Therapist,
+		NudgesSent:      s.NudgesSent,
+		CreatedAt:       s.CreatedAt,
+		LastActivity:    s.LastActivity,
+		Resume:          s.States,
+	}
+}
+
+// DefaultTimeout bounds every call Backend makes to the port.
+const DefaultTimeout = 5 * time.Second
+
+// Backend adapts a StorePort to state.Backend, which has no contexts: every call gets its own timeout.
+type Backend struct {
+	Port    StorePort
+	Timeout time.Duration // DefaultTimeout when zero
+}
+
+var _ state.Backend = (*Backend)(nil)
+
+// NewBackend wraps port with DefaultTimeout.
+func NewBackend(port StorePort) *Backend {
+	return &Backend{Port: port, Timeout: DefaultTimeout}
+}
+
+func (b *Backend) context() (context.Context, context.CancelFunc) {
+	timeout := b.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+func (b *Backend) SaveUser(userState *state.UserState) error {
+	ctx, cancel := b.context()
+	defer cancel()
+	return b.Port.SaveUserState(ctx, userState)
+}
+
+func (b *Backend) LoadUser(userID int64) (*state.UserState, bool, error) {
+	ctx, cancel := b.context()
+	defer cancel()
+	return b.Port.GetUserState(ctx, userID)
+}
+
+func (b *Backend) DeleteUser(userID int64) error {
+	ctx, cancel := b.context()
+	defer cancel()
+	return b.Port.DeleteUserState(ctx, userID)
+}
+
+func (b *Backend) UserIDs() ([]int64, error) {
+	ctx, cancel := b.context()
+	defer cancel()
+	return b.Port.ListUsers(ctx)
+}
+
+func (b *Backend) RecordMetas(userID int64) ([]state.RecordMeta, error) {
+	ctx, cancel := b.context()
+	defer cancel()
+	return b.Port.ListRecords(ctx, userID)
+}
+
+func (b *Backend) LoadRecord(userID int64, id string) (*state.Record, error) {
+	ctx, cancel := b.context()
+	defer cancel()
+	return b.Port.GetRecord(ctx, userID, id)
+}
+
+func (b *Backend) SaveRecord(userID int64, record *state.Record) error {
+	ctx, cancel := b.context()
+	defer cancel()
+	return b.Port.SaveRecord(ctx, userID, record)
+}
+
+func (b *Backend) DeleteRecord(userID int64, id string) error {
+	ctx, cancel := b.context()
+	defer cancel()
+	return b.Port.DeleteRecord(ctx, userID, id)
+}
+
+func (b *Backend) SaveDelivery(delivery *state.Delivery) error {
+	ctx, cancel := b.context()
+	defer cancel()
+	return b.Port.SaveDelivery(ctx, delivery)
+}
+
+func (b *Backend) LoadDeliveries() ([]*state.Delivery, error) {
+	ctx, cancel := b.context()
+	defer cancel()
+	return b.Port.ListDeliveries(ctx)
+}
@@ -0,0 +1,150 @@
+// Package storeporttest checks that a storeport.StorePort adapter behaves as the store expects.
+package storeporttest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/storeport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+
+	"github.com/looplab/fsm"
+)
+
+// Run exercises port with users 1001 and 1002 and delivery d1, which must not exist in it yet.
+func Run(t *testing.T, port storeport.StorePort) {
+	t.Helper()
+	ctx := context.Background()
+	const alice, bob = 1001, 1002
+	at := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("users", func(t *testing.T) {
+		if _, ok, err := port.GetUserState(ctx, alice); ok || err != nil {
+			t.Fatalf("unknown user: ok=%t err=%v", ok, err)
+		}
+		draft := state.NewRecord()
+		draft.Data["mood"] = state.ListAnswer("calm", "tired")
+		userState := &state.UserState{
+			UserID:          alice,
+			UserName:        "Alice",
+			Profile:         state.Profile{FirstName: "Alice", LanguageCode: "en"},
+			MainMenuFSM:     fsm.NewFSM("idle", nil, nil),
+			RecordFSM:       fsm.NewFSM("answering_question", nil, nil),
+			CurrentRecord:   draft,
+			CurrentSection:  "sec",
+			CurrentQuestion: 2,
+			LastMessageID:   77,
+			AutoForwardOff:  true,
+			Language:        "ru",
+			Therapist:       700,
+			CreatedAt:       at,
+			Records:         []*state.Record{savedRecord("r0", at, "Rome")},
+		}
+		if err := port.SaveUserState(ctx, userState); err != nil {
+			t.Fatalf("SaveUserState: %v", err)
+		}
+		got, ok, err := port.GetUserState(ctx, alice)
+		if !ok || err != nil {
+			t.Fatalf("GetUserState: ok=%t err=%v", ok, err)
+		}
+		if got.UserName != "Alice" || got.Profile.LanguageCode != "en" || !got.AutoForwardOff || got.Language != "ru" || got.Therapist != 700 || !got.CreatedAt.Equal(at) {
+			t.Fatalf("user not restored: %+v", got)
+		}
+		if got.CurrentRecord == nil || !got.CurrentRecord.Data["mood"].Equal(draft.Data["mood"]) {
+			t.Fatalf("draft not restored: %+v", got.CurrentRecord)
+		}
+		if got.CurrentSection != "sec" || got.CurrentQuestion != 2 || got.Resume != (state.FSMStates{MainMenu: "idle", Record: "answering_question"}) {
+			t.Fatalf("position not restored: section %q, question %d, states %+v", got.CurrentSection, got.CurrentQuestion, got.Resume)
+		}
+		if len(got.Records) != 0 || got.LastMessageID != 0 || got.RecordFSM != nil {
+			t.Fatalf("records, message IDs and FSMs must not be stored: %+v", got)
+		}
+		if ids, err := port.ListUsers(ctx); err != nil || len(ids) != 1 || ids[0] != alice {
+			t.Fatalf("ListUsers = %v, %v; want [%d]", ids, err, alice)
+		}
+		for range 2 { // The second delete finds nothing
+			if err := port.DeleteUserState(ctx, alice); err != nil {
+				t.Fatalf("DeleteUserState: %v", err)
+			}
+		}
+		if _, ok, err := port.GetUserState(ctx, alice); ok || err != nil {
+			t.Fatalf("deleted user: ok=%t err=%v", ok, err)
+		}
+	})
+
+	t.Run("records", func(t *testing.T) {
+		second := savedRecord("r2", at.Add(time.Hour), "Paris")
+		first := savedRecord("r1", at, "Rome")
+		for _, r := range []*state.Record{second, first} {
+			if err := port.SaveRecord(ctx, alice, r); err != nil {
+				t.Fatalf("SaveRecord: %v", err)
+			}
+		}
+		metas, err := port.ListRecords(ctx, alice)
+		if err != nil || len(metas) != 2 || metas[0].ID != "r1" || metas[1].ID != "r2" {
+			t.Fatalf("ListRecords = %+v, %v; want r1, r2", metas, err)
+		}
+		if metas[1].Preview["city"] != "Paris" {
+			t.Fatalf("metadata must carry the preview: %+v", metas[1])
+		}
+		got, err := port.GetRecord(ctx, alice, "r2")
+		if err != nil || got.Data["city"].String() != "Paris" || !got.CreatedAt.Equal(second.CreatedAt) {
+			t.Fatalf("GetRecord = %+v, %v", got, err)
+		}
+		if _, err := port.GetRecord(ctx, bob, "r2"); !errors.Is(err, storeport.ErrNotFound) {
+			t.Fatalf("records of other users must not be found, got %v", err)
+		}
+
+		if err := port.SaveRecord(ctx, bob, first); err != nil {
+			t.Fatalf("SaveRecord to another user: %v", err)
+		}
+		if metas, _ := port.ListRecords(ctx, bob); len(metas) != 1 || metas[0].ID != "r1" {
+			t.Fatalf("record must move to the new user, got %+v", metas)
+		}
+		for _, id := range []string{"r2", "missing"} {
+			if err := port.DeleteRecord(ctx, alice, id); err != nil {
+				t.Fatalf("DeleteRecord(%s): %v", id, err)
+			}
+		}
+		if metas, _ := port.ListRecords(ctx, alice); len(metas) != 0 {
+			t.Fatalf("records left after delete: %+v", metas)
+		}
+		_ = port.DeleteRecord(ctx, bob, "r1")
+	})
+
+	t.Run("deliveries", func(t *testing.T) {
+		delivery := &state.Delivery{ID: "d1", UserID: alice, RecordID: "r1", TargetID: 700, MessageID: 5, PartIDs: []int{4, 5}, Status: state.DeliveryDelivered, SentAt: at, Seq: 3}
+		if err := port.SaveDelivery(ctx, delivery); err != nil {
+			t.Fatalf("SaveDelivery: %v", err)
+		}
+		acked := *delivery
+		acked.UserID, acked.Status, acked.AckedAt = bob, state.DeliveryRead, at.Add(time.Hour)
+		if err := port.SaveDelivery(ctx, &acked); err != nil {
+			t.Fatalf("SaveDelivery again: %v", err)
+		}
+		var got *state.Delivery
+		deliveries, err := port.ListDeliveries(ctx)
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		for _, d := range deliveries {
+			if d.ID == "d1" {
+				got = d
+			}
+		}
+		if got == nil || got.UserID != bob || got.RecordID != "r1" || got.Status != state.DeliveryRead || !got.AckedAt.Equal(acked.AckedAt) || got.Seq != 3 || len(got.PartIDs) != 2 {
+			t.Fatalf("delivery must be replaced by its ID, got %+v", got)
+		}
+	})
+}
+
+func savedRecord(id string, at time.Time, city string) *state.Record {
+	record := state.NewRecord()
+	record.ID = id
+	record.IsSaved = true
+	record.CreatedAt = at
+	record.Data["city"] = state.StringAnswer(city)
+	return record
+}
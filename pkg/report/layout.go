@@ -0,0 +1,144 @@
+package report
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/report/pdf"
+)
+
+const (
+	margin       = 50.0
+	contentWidth = pdf.PageWidth - 2*margin
+	lineSpacing  = 1.35 // Line height as a multiple of the font size
+	chartHeight  = 110.0
+	chartAxis    = 30.0 // Room left of the plot for the scale labels
+)
+
+var weekdayNames = [...]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"}
+
+// layout flows text and charts down the pages of a document, starting a new page when the next block
+// does not fit.
+type layout struct {
+	doc *pdf.Document
+	y   float64 // Top of the free space on the current page
+}
+
+func newLayout(doc *pdf.Document) *layout {
+	l := &layout{doc: doc}
+	l.newPage()
+	return l
+}
+
+func (l *layout) newPage() {
+	l.doc.AddPage()
+	l.y = pdf.PageHeight - margin
+}
+
+// ensure starts a new page unless height points still fit on this one.
+func (l *layout) ensure(height float64) {
+	if l.y-height < margin {
+		l.newPage()
+	}
+}
+
+func (l *layout) space(height float64) {
+	l.y -= height
+}
+
+func (l *layout) heading(text string, size float64) {
+	l.ensure(size * lineSpacing * 2)
+	l.space(size * 0.5)
+	l.paragraph(text, size, 0)
+}
+
+// paragraph sets text at size, wrapped to the content width less indent. Line breaks in text are kept.
+func (l *layout) paragraph(text string, size, indent float64) {
+	l.doc.SetColor(0, 0, 0)
+	for _, line := range l.wrap(text, size, contentWidth-indent) {
+		l.ensure(size * lineSpacing)
+		l.y -= size * lineSpacing
+		l.doc.Text(margin+indent, l.y, size, line)
+	}
+}
+
+// wrap breaks text into lines no wider than width, between words where possible.
+func (l *layout) wrap(text string, size, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if l.doc.TextWidth(candidate, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = ""
+			for _, r := range word {
+				if line != "" && l.doc.TextWidth(line+string(r), size) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// chart draws the daily values of trend as a line over the seven days from start, on the trend's
+// scale, with the weekly mean as a thin line.
+func (l *layout) chart(trend Trend, start time.Time) {
+	l.ensure(chartHeight + 40)
+	l.paragraph(trend.Title, 9, 0)
+	l.space(6)
+
+	top := l.y
+	bottom := top - chartHeight
+	left := margin + chartAxis
+	right := margin + contentWidth
+	column := (right - left) / DaysInWeek
+	yOf := func(v float64) float64 {
+		return bottom + (v-trend.ScaleMin)/(trend.ScaleMax-trend.ScaleMin)*chartHeight
+	}
+	xOf := func(day int) float64 { return left + (float64(day)+0.5)*column }
+
+	l.doc.SetColor(0.6, 0.6, 0.6)
+	l.doc.Line(left, bottom, right, bottom, 0.5)
+	l.doc.Line(left, bottom, left, top, 0.5)
+	l.doc.Line(left, top, right, top, 0.25)
+	l.doc.Text(margin, top-7, 7, FormatNumber(trend.ScaleMax))
+	l.doc.Text(margin, bottom, 7, FormatNumber(trend.ScaleMin))
+	for day := 0; day < DaysInWeek; day++ {
+		date := start.AddDate(0, 0, day)
+		label := weekdayNames[date.Weekday()] + " " + date.Format("02.01")
+		l.doc.Text(xOf(day)-l.doc.TextWidth(label, 7)/2, bottom-10, 7, label)
+	}
+
+	l.doc.SetColor(0.85, 0.55, 0.2)
+	l.doc.Line(left, yOf(trend.Mean), right, yOf(trend.Mean), 0.5)
+
+	l.doc.SetColor(0.15, 0.35, 0.7)
+	prev := -1
+	for day := 0; day < DaysInWeek; day++ {
+		if !trend.HasDay[day] {
+			continue
+		}
+		x, y := xOf(day), yOf(trend.Days[day])
+		if prev >= 0 {
+			l.doc.Line(xOf(prev), yOf(trend.Days[prev]), x, y, 1.5)
+		}
+		l.doc.Rect(x-2.5, y-2.5, 5, 5)
+		l.doc.Text(x+4, y+3, 7, FormatNumber(trend.Days[day]))
+		prev = day
+	}
+
+	l.y = bottom - 24
+}
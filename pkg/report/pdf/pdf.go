@@ -0,0 +1,229 @@
+// Package pdf writes small PDF documents with text, lines and filled rectangles in one embedded
+// TrueType font. It covers what the reports need and nothing more: text is set as glyph IDs of the
+// font (Identity-H), so any script the font covers, Cyrillic included, prints correctly.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A4 page size in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Document is a PDF being built page by page. Coordinates are in points from the bottom-left corner
+// of the page.
+type Document struct {
+	font  *Font
+	pages []*bytes.Buffer
+	used  map[uint16]rune // Glyphs set so far and the characters they stand for
+}
+
+// New starts an empty document that sets its text in font.
+func New(font *Font) *Document {
+	return &Document{font: font, used: make(map[uint16]rune)}
+}
+
+// AddPage starts a new page; the drawing calls that follow go to it.
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount returns the number of pages so far.
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// SetColor sets the stroke and fill color, components from 0 to 1.
+func (d *Document) SetColor(r, g, b float64) {
+	fmt.Fprintf(d.page(), "%s %s %s RG %s %s %s rg\n", num(r), num(g), num(b), num(r), num(g), num(b))
+}
+
+// Text draws s with its baseline starting at (x, y). Characters the font lacks, such as emoji, are
+// left out.
+func (d *Document) Text(x, y, size float64, s string) {
+	var hex strings.Builder
+	for _, r := range s {
+		gid, ok := d.font.Glyph(r)
+		if !ok {
+			continue
+		}
+		d.used[gid] = r
+		fmt.Fprintf(&hex, "%04X", gid)
+	}
+	if hex.Len() == 0 {
+		return
+	}
+	fmt.Fprintf(d.page(), "BT /F1 %s Tf %s %s Td <%s> Tj ET\n", num(size), num(x), num(y), hex.String())
+}
+
+// TextWidth returns the width of s set at size, leaving out the characters Text leaves out.
+func (d *Document) TextWidth(s string, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		if gid, ok := d.font.Glyph(r); ok {
+			total += d.font.advance(gid)
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Line strokes a straight line of the given width.
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%s w %s %s m %s %s l S\n", num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Rect fills a rectangle with its bottom-left corner at (x, y).
+func (d *Document) Rect(x, y, w, h float64) {
+	fmt.Fprintf(d.page(), "%s %s %s %s re f\n", num(x), num(y), num(w), num(h))
+}
+
+// Bytes encodes the document. A document without pages gets one blank page.
+func (d *Document) Bytes() ([]byte, error) {
+	d.page()
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// Object numbers: 1 catalog, 2 page tree, 3-7 the font, then a page and its content per page.
+	const fontObjects = 7
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", fontObjects+1+i*2)
+	}
+	w.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	w.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	f := d.font
+	w.object(3, "<< /Type /Font /Subtype /Type0 /BaseFont /ReportFont /Encoding /Identity-H /DescendantFonts [4 0 R] /ToUnicode 7 0 R >>")
+	w.object(4, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /ReportFont /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor 5 0 R /CIDToGIDMap /Identity /DW 1000 /W [%s] >>", d.widths()))
+	w.object(5, fmt.Sprintf("<< /Type /FontDescriptor /FontName /ReportFont /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 6 0 R >>",
+		f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]), f.scale(f.ascent), f.scale(f.descent), f.scale(f.ascent)))
+	if err := w.stream(6, fmt.Sprintf("/Length1 %d", len(f.data)), f.data); err != nil {
+		return nil, err
+	}
+	if err := w.stream(7, "", []byte(d.toUnicode())); err != nil {
+		return nil, err
+	}
+
+	for i, content := range d.pages {
+		pageObj := fontObjects + 1 + i*2
+		w.object(pageObj, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), pageObj+1))
+		if err := w.stream(pageObj+1, "", content.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	return w.finish(1), nil
+}
+
+// sortedGlyphs lists the glyphs set so far in ID order.
+func (d *Document) sortedGlyphs() []uint16 {
+	gids := make([]uint16, 0, len(d.used))
+	for gid := range d.used {
+		gids = append(gids, gid)
+	}
+	sort.Slice(gids, func(i, j int) bool { return gids[i] < gids[j] })
+	return gids
+}
+
+func (d *Document) widths() string {
+	var b strings.Builder
+	for _, gid := range d.sortedGlyphs() {
+		fmt.Fprintf(&b, "%d [%d] ", gid, d.font.advance(gid))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// toUnicode maps the glyphs back to characters, so text can be searched and copied.
+func (d *Document) toUnicode() string {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	gids := d.sortedGlyphs()
+	for len(gids) > 0 {
+		n := min(len(gids), 100)
+		fmt.Fprintf(&b, "%d beginbfchar\n", n)
+		for _, gid := range gids[:n] {
+			fmt.Fprintf(&b, "<%04X> <%s>\n", gid, utf16Hex(d.used[gid]))
+		}
+		b.WriteString("endbfchar\n")
+		gids = gids[n:]
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.String()
+}
+
+func utf16Hex(r rune) string {
+	if r < 0x10000 {
+		return fmt.Sprintf("%04X", r)
+	}
+	r -= 0x10000
+	return fmt.Sprintf("%04X%04X", 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+}
+
+// num formats a coordinate with at most two decimals.
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// writer lays out numbered objects and the cross-reference table that points at them.
+type writer struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (w *writer) object(id int, body string) {
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream writes data compressed with Flate; extra goes into the stream dictionary.
+func (w *writer) stream(id int, extra string, data []byte) error {
+	var packed bytes.Buffer
+	zw := zlib.NewWriter(&packed)
+	if _, err := zw.Write(data); err != nil {
+		return fmt.Errorf("compress object %d: %w", id, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("compress object %d: %w", id, err)
+	}
+	if extra != "" {
+		extra = " " + extra
+	}
+	w.object(id, fmt.Sprintf("<< /Length %d /Filter /FlateDecode%s >>\nstream\n%s\nendstream", packed.Len(), extra, packed.Bytes()))
+	return nil
+}
+
+func (w *writer) finish(root int) []byte {
+	size := len(w.offsets) + 1
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for id := 1; id < size; id++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[id])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, root, xref)
+	return w.buf.Bytes()
+}
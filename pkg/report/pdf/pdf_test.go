@@ -0,0 +1,117 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/report/pdf/pdftest"
+)
+
+func TestParseFont(t *testing.T) {
+	font, err := ParseFont(pdftest.Font())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		r       rune
+		wantGID uint16
+		wantOK  bool
+	}{
+		{r: 'A', wantGID: 34, wantOK: true},
+		{r: 'Ж', wantGID: 118, wantOK: true},
+		{r: '中'},
+		{r: '🙂'},
+	}
+	for _, tt := range tests {
+		gid, ok := font.Glyph(tt.r)
+		if ok != tt.wantOK || (ok && gid != tt.wantGID) {
+			t.Errorf("Glyph(%q) = %d, %v; want %d, %v", tt.r, gid, ok, tt.wantGID, tt.wantOK)
+		}
+	}
+
+	if _, err := ParseFont([]byte("not a font")); err == nil {
+		t.Fatalf("expected an error for garbage input")
+	}
+}
+
+func TestDocumentBytes(t *testing.T) {
+	font, err := ParseFont(pdftest.Font())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc := New(font)
+	if got := doc.TextWidth("AЖ🙂", 10); got != 11 {
+		t.Fatalf("TextWidth = %v, want 11", got)
+	}
+	doc.Text(10, 20, 12, "AЖ🙂")
+	doc.SetColor(1, 0, 0)
+	doc.Line(0, 0, 100, 100, 0.5)
+	doc.AddPage()
+	doc.Rect(1, 2, 3, 4)
+
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("missing PDF header or trailer")
+	}
+	if !bytes.Contains(data, []byte("/Count 2")) {
+		t.Fatalf("expected two pages")
+	}
+	if !bytes.Contains(data, []byte("/W [34 [500] 118 [600]]")) {
+		t.Fatalf("expected widths of the used glyphs")
+	}
+
+	// Every cross-reference entry points at its object.
+	start, err := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(string(data))[1])
+	if err != nil {
+		t.Fatalf("bad startxref: %v", err)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(string(data[start:]), -1)
+	if len(entries) != 11 {
+		t.Fatalf("expected 11 objects, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Fatalf("xref entry %d points at %q", i+1, data[offset:offset+10])
+		}
+	}
+
+	streams := decodeStreams(t, data)
+	if !strings.Contains(streams, "BT /F1 12 Tf 10 20 Td <00220076> Tj ET") {
+		t.Fatalf("text not set as glyph IDs:\n%s", streams)
+	}
+	if !strings.Contains(streams, "<0076> <0416>") {
+		t.Fatalf("ToUnicode must map glyph 118 back to Ж")
+	}
+	if !strings.Contains(streams, "0.5 w 0 0 m 100 100 l S") || !strings.Contains(streams, "1 2 3 4 re f") {
+		t.Fatalf("drawing operators missing:\n%s", streams)
+	}
+}
+
+// decodeStreams inflates every stream of the document and joins them.
+func decodeStreams(t *testing.T, data []byte) string {
+	t.Helper()
+	var out strings.Builder
+	for _, m := range regexp.MustCompile(`(?s)/Length (\d+)[^>]*>>\nstream\n`).FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		zr, err := zlib.NewReader(bytes.NewReader(data[m[1] : m[1]+length]))
+		if err != nil {
+			t.Fatalf("bad stream: %v", err)
+		}
+		plain, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("bad stream: %v", err)
+		}
+		out.Write(plain)
+	}
+	return out.String()
+}
@@ -0,0 +1,77 @@
+// Package pdftest builds a tiny TrueType font for tests that render PDFs without a font file.
+package pdftest
+
+import "encoding/binary"
+
+// Glyph widths of Font, in 1/1000 of the font size.
+const (
+	LatinWidth    = 500
+	CyrillicWidth = 600
+)
+
+// Font returns a TrueType font with 1000 units per em and no outlines. It maps printable ASCII
+// (U+0020–U+007E) to glyphs 1–95, LatinWidth wide, and Cyrillic (U+0400–U+04FF) to glyphs 96–351,
+// CyrillicWidth wide; glyph 0 is .notdef.
+func Font() []byte {
+	type segment struct{ start, end, firstGlyph int }
+	segs := []segment{{0x20, 0x7E, 1}, {0x400, 0x4FF, 96}, {0xFFFF, 0xFFFF, 0}}
+	numGlyphs := 96 + 0x100
+
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint16(head[18:], 1000)
+	for i, v := range []int16{-100, -200, 900, 800} {
+		binary.BigEndian.PutUint16(head[36+i*2:], uint16(v))
+	}
+	hhea := make([]byte, 36)
+	descent := int16(-200)
+	binary.BigEndian.PutUint16(hhea[4:], 800)
+	binary.BigEndian.PutUint16(hhea[6:], uint16(descent))
+	binary.BigEndian.PutUint16(hhea[34:], uint16(numGlyphs))
+	maxp := make([]byte, 6)
+	binary.BigEndian.PutUint16(maxp[4:], uint16(numGlyphs))
+	hmtx := make([]byte, numGlyphs*4)
+	for gid := 0; gid < numGlyphs; gid++ {
+		width := CyrillicWidth
+		if gid < 96 {
+			width = LatinWidth
+		}
+		binary.BigEndian.PutUint16(hmtx[gid*4:], uint16(width))
+	}
+
+	sub := make([]byte, 16+len(segs)*8)
+	binary.BigEndian.PutUint16(sub[0:], 4)
+	binary.BigEndian.PutUint16(sub[2:], uint16(len(sub)))
+	binary.BigEndian.PutUint16(sub[6:], uint16(len(segs)*2))
+	for i, s := range segs {
+		delta := s.firstGlyph - s.start
+		if s.start == 0xFFFF {
+			delta = 1
+		}
+		binary.BigEndian.PutUint16(sub[14+i*2:], uint16(s.end))
+		binary.BigEndian.PutUint16(sub[16+len(segs)*2+i*2:], uint16(s.start))
+		binary.BigEndian.PutUint16(sub[16+len(segs)*4+i*2:], uint16(delta))
+	}
+	cmap := make([]byte, 12, 12+len(sub))
+	binary.BigEndian.PutUint16(cmap[2:], 1)
+	binary.BigEndian.PutUint16(cmap[4:], 3)
+	binary.BigEndian.PutUint16(cmap[6:], 1)
+	binary.BigEndian.PutUint32(cmap[8:], 12)
+	cmap = append(cmap, sub...)
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{{"cmap", cmap}, {"glyf", []byte{0, 0}}, {"head", head}, {"hhea", hhea}, {"hmtx", hmtx}, {"maxp", maxp}}
+	font := make([]byte, 12+len(tables)*16)
+	binary.BigEndian.PutUint32(font, 0x00010000)
+	binary.BigEndian.PutUint16(font[4:], uint16(len(tables)))
+	for i, table := range tables {
+		offset := len(font)
+		font = append(font, table.data...)
+		rec := font[12+i*16:]
+		copy(rec, table.tag)
+		binary.BigEndian.PutUint32(rec[8:], uint32(offset))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(table.data)))
+	}
+	return font
+}
@@ -0,0 +1,227 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Font is a TrueType font embedded whole into the documents that use it. Only the tables needed to
+// map characters to glyphs and measure them are read.
+type Font struct {
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	bbox       [4]int
+	advances   []uint16 // Advance width by glyph ID, in font units
+	glyphs     map[rune]uint16
+}
+
+// LoadFont reads and parses the TrueType font at path.
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read font: %w", err)
+	}
+	font, err := ParseFont(data)
+	if err != nil {
+		return nil, fmt.Errorf("font %s: %w", path, err)
+	}
+	return font, nil
+}
+
+// ParseFont parses a TrueType (glyf-based) font. It needs a Unicode cmap subtable of format 4 or 12.
+func ParseFont(data []byte) (*Font, error) {
+	tables, err := readTableDirectory(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("missing %s table", tag)
+		}
+	}
+	if tables["glyf"] == nil {
+		return nil, errors.New("not a TrueType outline font (no glyf table)")
+	}
+
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errors.New("truncated font header")
+	}
+	f := &Font{
+		data:       data,
+		unitsPerEm: int(u16(head, 18)),
+		ascent:     int(int16(u16(hhea, 4))),
+		descent:    int(int16(u16(hhea, 6))),
+		bbox: [4]int{
+			int(int16(u16(head, 36))), int(int16(u16(head, 38))),
+			int(int16(u16(head, 40))), int(int16(u16(head, 42))),
+		},
+	}
+	if f.unitsPerEm == 0 {
+		return nil, errors.New("font has no units per em")
+	}
+
+	numGlyphs := int(u16(maxp, 4))
+	numMetrics := int(u16(hhea, 34))
+	hmtx := tables["hmtx"]
+	if numMetrics == 0 || len(hmtx) < numMetrics*4 {
+		return nil, errors.New("truncated hmtx table")
+	}
+	f.advances = make([]uint16, numGlyphs)
+	for gid := range f.advances {
+		if gid < numMetrics {
+			f.advances[gid] = u16(hmtx, gid*4)
+		} else {
+			f.advances[gid] = f.advances[numMetrics-1]
+		}
+	}
+
+	if f.glyphs, err = readCmap(tables["cmap"]); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Glyph returns the glyph ID of r; ok is false when the font has no glyph for it.
+func (f *Font) Glyph(r rune) (gid uint16, ok bool) {
+	gid, ok = f.glyphs[r]
+	return gid, ok && gid != 0
+}
+
+// advance returns the advance width of gid in 1/1000 of the font size, the unit of PDF glyph widths.
+func (f *Font) advance(gid uint16) int {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return int(f.advances[gid]) * 1000 / f.unitsPerEm
+}
+
+// scale converts font units to 1/1000 of the font size.
+func (f *Font) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+func readTableDirectory(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, errors.New("not a TrueType font")
+	}
+	if version := binary.BigEndian.Uint32(data); version != 0x00010000 && version != 0x74727565 {
+		return nil, fmt.Errorf("unsupported font version %#x", version)
+	}
+	numTables := int(u16(data, 4))
+	if len(data) < 12+numTables*16 {
+		return nil, errors.New("truncated table directory")
+	}
+	tables := make(map[string][]byte, numTables)
+	for i := 0; i < numTables; i++ {
+		rec := data[12+i*16:]
+		offset, length := int(binary.BigEndian.Uint32(rec[8:])), int(binary.BigEndian.Uint32(rec[12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("table %q is out of bounds", rec[:4])
+		}
+		tables[string(rec[:4])] = data[offset : offset+length]
+	}
+	return tables, nil
+}
+
+// readCmap maps characters to glyphs with the best Unicode subtable: full-range format 12 when the
+// font has it, the BMP format 4 otherwise.
+func readCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errors.New("truncated cmap table")
+	}
+	var bmp, full []byte
+	numTables := int(u16(cmap, 2))
+	for i := 0; i < numTables && 4+i*8+8 <= len(cmap); i++ {
+		platform, encoding := u16(cmap, 4+i*8), u16(cmap, 4+i*8+2)
+		offset := int(binary.BigEndian.Uint32(cmap[4+i*8+4:]))
+		if offset+2 > len(cmap) {
+			continue
+		}
+		sub := cmap[offset:]
+		unicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		switch {
+		case !unicode:
+		case u16(sub, 0) == 12:
+			full = sub
+		case u16(sub, 0) == 4:
+			bmp = sub
+		}
+	}
+	switch {
+	case full != nil:
+		return readCmap12(full)
+	case bmp != nil:
+		return readCmap4(bmp)
+	}
+	return nil, errors.New("no Unicode cmap subtable of format 4 or 12")
+}
+
+func readCmap4(sub []byte) (map[rune]uint16, error) {
+	if len(sub) < 14 {
+		return nil, errors.New("truncated cmap format 4")
+	}
+	segCount := int(u16(sub, 6)) / 2
+	endCodes := 14
+	startCodes := endCodes + segCount*2 + 2
+	deltas := startCodes + segCount*2
+	rangeOffsets := deltas + segCount*2
+	if len(sub) < rangeOffsets+segCount*2 {
+		return nil, errors.New("truncated cmap format 4")
+	}
+	glyphs := make(map[rune]uint16)
+	for i := 0; i < segCount; i++ {
+		start, end := int(u16(sub, startCodes+i*2)), int(u16(sub, endCodes+i*2))
+		delta := u16(sub, deltas+i*2)
+		rangeOffset := int(u16(sub, rangeOffsets+i*2))
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			var gid uint16
+			if rangeOffset == 0 {
+				gid = uint16(c) + delta
+			} else {
+				at := rangeOffsets + i*2 + rangeOffset + (c-start)*2
+				if at+2 > len(sub) {
+					break
+				}
+				if gid = u16(sub, at); gid != 0 {
+					gid += delta
+				}
+			}
+			if gid != 0 {
+				glyphs[rune(c)] = gid
+			}
+		}
+	}
+	return glyphs, nil
+}
+
+func readCmap12(sub []byte) (map[rune]uint16, error) {
+	if len(sub) < 16 {
+		return nil, errors.New("truncated cmap format 12")
+	}
+	numGroups := int(binary.BigEndian.Uint32(sub[12:]))
+	if len(sub) < 16+numGroups*12 {
+		return nil, errors.New("truncated cmap format 12")
+	}
+	glyphs := make(map[rune]uint16)
+	for i := 0; i < numGroups; i++ {
+		group := sub[16+i*12:]
+		start, end := binary.BigEndian.Uint32(group), binary.BigEndian.Uint32(group[4:])
+		gid := binary.BigEndian.Uint32(group[8:])
+		for c := start; c <= end && c <= 0x10FFFF; c++ {
+			glyphs[rune(c)] = uint16(gid + c - start)
+		}
+	}
+	return glyphs, nil
+}
+
+func u16(b []byte, off int) uint16 {
+	if off+2 > len(b) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b[off:])
+}
@@ -0,0 +1,293 @@
+// Package report builds the periodic reports sent to a user and their therapist: a weekly PDF with the
+// week's records, score trends and summary statistics.
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/idgen"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/report/pdf"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// DaysInWeek is the length of the period a weekly report covers.
+const DaysInWeek = 7
+
+// Default scale of text_rating scores when the question leaves rating_min/rating_max unset.
+const (
+	defaultRatingMin = 1
+	defaultRatingMax = 10
+)
+
+// Weekly is the content of one user's report for the week starting at Start.
+type Weekly struct {
+	UserName   string
+	UserID     int64
+	Start      time.Time       // Midnight of the first day
+	Records    []*state.Record // Records of the week, oldest first
+	Trends     []Trend         // Numeric questions answered during the week, in section and question order
+	ActiveDays int             // Days with at least one record
+	Flagged    int             // Records with content filter findings
+
+	sections []string // Section IDs in display order
+	config   *config.RecordConfig
+}
+
+// Trend follows one numeric question through the week. A record contributes its number answer, or the
+// mean score of a text_rating answer; several records on a day are averaged.
+type Trend struct {
+	Title    string
+	Rating   bool    // Built from text_rating scores or a rating scale rather than plain number answers
+	ScaleMin float64 // Chart range: the rating scale, or the observed range of plain numbers
+	ScaleMax float64
+	Days     [DaysInWeek]float64
+	HasDay   [DaysInWeek]bool
+	Count    int // Answers seen during the week
+	Mean     float64
+	Min, Max float64
+}
+
+// End returns the midnight that ends the week.
+func (w Weekly) End() time.Time {
+	return w.Start.AddDate(0, 0, DaysInWeek)
+}
+
+// FileName is the name the report is sent under.
+func (w Weekly) FileName() string {
+	return fmt.Sprintf("report_%s.pdf", w.Start.Format("2006-01-02"))
+}
+
+// Period formats the covered days, e.g. "06.10.2026 – 12.10.2026".
+func (w Weekly) Period() string {
+	return w.Start.Format("02.01.2006") + " – " + w.End().AddDate(0, 0, -1).Format("02.01.2006")
+}
+
+// BuildWeekly collects the report of the week starting at start (truncated to midnight in its
+// location). records may be in any order; those outside the week are ignored.
+func BuildWeekly(recordConfig *config.RecordConfig, userID int64, userName string, records []*state.Record, start time.Time) Weekly {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	w := Weekly{UserName: userName, UserID: userID, Start: start, config: recordConfig}
+	w.sections = recordConfig.SectionIDs()
+
+	var active [DaysInWeek]bool
+	for _, record := range records {
+		if record == nil || !record.IsSaved {
+			continue
+		}
+		if day := w.dayIndex(record.CreatedAt); day >= 0 {
+			w.Records = append(w.Records, record)
+			active[day] = true
+		}
+	}
+	sort.SliceStable(w.Records, func(i, j int) bool { return w.Records[i].CreatedAt.Before(w.Records[j].CreatedAt) })
+	for _, ok := range active {
+		if ok {
+			w.ActiveDays++
+		}
+	}
+	for _, record := range w.Records {
+		if len(record.Flags) > 0 {
+			w.Flagged++
+		}
+	}
+
+	for _, sectionID := range w.sections {
+		section := recordConfig.Sections[sectionID]
+		for _, q := range section.Questions {
+			if trend, ok := w.trend(section, q); ok {
+				w.Trends = append(w.Trends, trend)
+			}
+		}
+	}
+	return w
+}
+
+// dayIndex returns the day of the week t falls on, counted from Start, or -1 outside the week.
+func (w Weekly) dayIndex(t time.Time) int {
+	t = t.In(w.Start.Location())
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, w.Start.Location())
+	for i := 0; i < DaysInWeek; i++ {
+		if day.Equal(w.Start.AddDate(0, 0, i)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w Weekly) trend(section config.SectionConfig, q config.QuestionConfig) (Trend, bool) {
+	trend := Trend{Title: section.Title + ": " + q.Prompt}
+	var sums [DaysInWeek]float64
+	var counts [DaysInWeek]int
+	scored := false
+	total := 0.0
+	for _, record := range w.Records {
+		value, ok := numericValue(record.Answer(q))
+		if !ok || !record.Visible(q) {
+			continue
+		}
+		scored = scored || record.Answer(q).Kind == state.AnswerScored
+		if trend.Count == 0 || value < trend.Min {
+			trend.Min = value
+		}
+		if trend.Count == 0 || value > trend.Max {
+			trend.Max = value
+		}
+		trend.Count++
+		total += value
+		day := w.dayIndex(record.CreatedAt)
+		sums[day] += value
+		counts[day]++
+	}
+	if trend.Count == 0 {
+		return Trend{}, false
+	}
+	trend.Mean = total / float64(trend.Count)
+	for day := range sums {
+		if counts[day] > 0 {
+			trend.Days[day] = sums[day] / float64(counts[day])
+			trend.HasDay[day] = true
+		}
+	}
+
+	scale := q.Type == "rating"
+	trend.Rating = scored || scale
+	trend.ScaleMin, trend.ScaleMax = trend.Min, trend.Max
+	switch {
+	case scale:
+		minRating, maxRating := q.RatingScale()
+		trend.ScaleMin, trend.ScaleMax = float64(minRating), float64(maxRating)
+	case scored:
+		trend.ScaleMin, trend.ScaleMax = defaultRatingMin, defaultRatingMax
+		if q.RatingMin != 0 {
+			trend.ScaleMin = float64(q.RatingMin)
+		}
+		if q.RatingMax != 0 {
+			trend.ScaleMax = float64(q.RatingMax)
+		}
+	}
+	if trend.ScaleMax <= trend.ScaleMin {
+		trend.ScaleMin, trend.ScaleMax = trend.ScaleMin-1, trend.ScaleMax+1
+	}
+	return trend, true
+}
+
+// numericValue returns the number an answer stands for in a trend: a number answer, or the mean score
+// of scored entries.
+func numericValue(answer state.Answer) (float64, bool) {
+	switch answer.Kind {
+	case state.AnswerNumber:
+		return answer.Number, true
+	case state.AnswerScored:
+		if len(answer.Scored) == 0 {
+			return 0, false
+		}
+		sum := 0
+		for _, entry := range answer.Scored {
+			sum += entry.Score
+		}
+		return float64(sum) / float64(len(answer.Scored)), true
+	}
+	return 0, false
+}
+
+// FormatNumber prints v with at most one decimal.
+func FormatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+// answerLines renders an answer as lines of the record block: one per list item or scored entry.
+func answerLines(answer state.Answer) []string {
+	switch answer.Kind {
+	case state.AnswerList:
+		lines := make([]string, 0, len(answer.List))
+		for _, item := range answer.List {
+			lines = append(lines, "• "+item)
+		}
+		return lines
+	case state.AnswerScored:
+		lines := make([]string, 0, len(answer.Scored))
+		for _, entry := range answer.Scored {
+			lines = append(lines, fmt.Sprintf("• %s — %d", entry.Text, entry.Score))
+		}
+		return lines
+	}
+	if text := answer.String(); text != "" {
+		return strings.Split(text, "\n")
+	}
+	return []string{"—"}
+}
+
+// recordTitle heads a record block with its creation time and short ID.
+func recordTitle(record *state.Record) string {
+	title := record.CreatedAt.Format("02.01.2006 15:04")
+	if record.ID != "" {
+		title += "  #" + idgen.ShortCode(record.ID)
+	}
+	return title
+}
+
+// PDF renders the report: the summary, a chart per trend, then every record of the week.
+func (w Weekly) PDF(font *pdf.Font) ([]byte, error) {
+	l := newLayout(pdf.New(font))
+	l.heading(fmt.Sprintf("Недельный отчёт: %s", w.UserName), 16)
+	l.paragraph(fmt.Sprintf("%s · ID %d", w.Period(), w.UserID), 10, 0)
+	l.space(8)
+
+	l.heading("Сводка", 13)
+	l.paragraph(fmt.Sprintf("Записей: %d", len(w.Records)), 10, 0)
+	l.paragraph(fmt.Sprintf("Дней с записями: %d из %d", w.ActiveDays, DaysInWeek), 10, 0)
+	if w.Flagged > 0 {
+		l.paragraph(fmt.Sprintf("Записей с отметками фильтра: %d", w.Flagged), 10, 0)
+	}
+	for _, trend := range w.Trends {
+		l.paragraph(fmt.Sprintf("%s: среднее %s (мин. %s, макс. %s, ответов: %d)",
+			trend.Title, FormatNumber(trend.Mean), FormatNumber(trend.Min), FormatNumber(trend.Max), trend.Count), 10, 0)
+	}
+	l.space(8)
+
+	if len(w.Trends) > 0 {
+		l.heading("Динамика оценок", 13)
+		for _, trend := range w.Trends {
+			l.chart(trend, w.Start)
+		}
+	}
+
+	l.heading("Записи", 13)
+	if len(w.Records) == 0 {
+		l.paragraph("За неделю записей нет.", 10, 0)
+	}
+	for _, record := range w.Records {
+		l.ensure(60)
+		l.heading(recordTitle(record), 11)
+		for _, sectionID := range w.sections {
+			section := w.config.Sections[sectionID]
+			var questions []config.QuestionConfig
+			for _, q := range section.Questions {
+				if record.Visible(q) && !record.Answer(q).IsEmpty() {
+					questions = append(questions, q)
+				}
+			}
+			if len(questions) == 0 {
+				continue
+			}
+			l.paragraph(section.Title, 10, 0)
+			for _, q := range questions {
+				l.paragraph(q.Prompt, 9, 12)
+				for _, line := range answerLines(record.Answer(q)) {
+					l.paragraph(line, 9, 24)
+				}
+				if flags := record.Flags[q.StoreKey]; len(flags) > 0 {
+					l.paragraph("Отмечено: "+strings.Join(flags, ", "), 8, 24)
+				}
+			}
+		}
+		l.space(6)
+	}
+	return l.doc.Bytes()
+}
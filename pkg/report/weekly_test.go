@@ -0,0 +1,137 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/report/pdf"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/report/pdf/pdftest"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func weeklyConfig() *config.RecordConfig {
+	return &config.RecordConfig{
+		Sections: map[string]config.SectionConfig{
+			"mood": {Title: "Настроение", Questions: []config.QuestionConfig{
+				{ID: "events", Prompt: "События", Type: "text_rating", StoreKey: "events", RatingMax: 5},
+				{ID: "sleep", Prompt: "Сон, часов", Type: "text", StoreKey: "sleep"},
+				{ID: "note", Prompt: "Заметка", Type: "text", StoreKey: "note"},
+				{ID: "feel", Prompt: "Самочувствие", Type: "rating", StoreKey: "feel", Labels: []string{"😞", "🙁", "😐", "🙂", "😄"}},
+			}},
+		},
+	}
+}
+
+func weeklyRecord(at time.Time, answers map[string]state.Answer) *state.Record {
+	record := state.NewRecord()
+	record.ID = at.Format("0102150405")
+	record.IsSaved = true
+	record.CreatedAt = at
+	record.Data = answers
+	return record
+}
+
+func TestBuildWeekly(t *testing.T) {
+	start := time.Date(2026, 10, 5, 15, 0, 0, 0, time.UTC) // Truncated to Monday midnight
+	day := func(d, hour int) time.Time { return time.Date(2026, 10, 5+d, hour, 0, 0, 0, time.UTC) }
+	records := []*state.Record{
+		weeklyRecord(day(2, 20), map[string]state.Answer{
+			"events": state.ScoredAnswer(state.ScoredEntry{Text: "a", Score: 2}, state.ScoredEntry{Text: "b", Score: 4}),
+			"sleep":  state.NumberAnswer(6),
+			"feel":   state.RatingAnswer(4, "🙂"),
+		}),
+		weeklyRecord(day(0, 9), map[string]state.Answer{
+			"events": state.ScoredAnswer(state.ScoredEntry{Text: "c", Score: 5}),
+			"note":   state.StringAnswer("ok"),
+		}),
+		weeklyRecord(day(2, 8), map[string]state.Answer{"sleep": state.NumberAnswer(8), "feel": state.RatingAnswer(2, "🙁")}),
+		weeklyRecord(day(7, 0), map[string]state.Answer{"sleep": state.NumberAnswer(1)}),   // Next week
+		weeklyRecord(day(-1, 23), map[string]state.Answer{"sleep": state.NumberAnswer(1)}), // Last week
+	}
+	records[1].Flags = map[string][]string{"note": {"risk"}}
+
+	w := BuildWeekly(weeklyConfig(), 7, "patient", records, start)
+
+	if w.Period() != "05.10.2026 – 11.10.2026" || w.FileName() != "report_2026-10-05.pdf" {
+		t.Fatalf("unexpected period %q / file %q", w.Period(), w.FileName())
+	}
+	if len(w.Records) != 3 || !w.Records[0].CreatedAt.Equal(day(0, 9)) {
+		t.Fatalf("expected the 3 records of the week oldest first, got %d", len(w.Records))
+	}
+	if w.ActiveDays != 2 || w.Flagged != 1 {
+		t.Fatalf("ActiveDays = %d, Flagged = %d; want 2, 1", w.ActiveDays, w.Flagged)
+	}
+	if len(w.Trends) != 3 {
+		t.Fatalf("expected trends for events, sleep and feel, got %+v", w.Trends)
+	}
+
+	tests := []struct {
+		name                       string
+		trend                      Trend
+		wantDays                   map[int]float64
+		wantMean                   float64
+		wantScaleMin, wantScaleMax float64
+		wantRating                 bool
+	}{
+		{name: "scored", trend: w.Trends[0], wantDays: map[int]float64{0: 5, 2: 3}, wantMean: 4, wantScaleMin: 1, wantScaleMax: 5, wantRating: true},
+		{name: "number", trend: w.Trends[1], wantDays: map[int]float64{2: 7}, wantMean: 7, wantScaleMin: 6, wantScaleMax: 8},
+		{name: "rating scale", trend: w.Trends[2], wantDays: map[int]float64{2: 3}, wantMean: 3, wantScaleMin: 1, wantScaleMax: 5, wantRating: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for d := 0; d < DaysInWeek; d++ {
+				want, ok := tt.wantDays[d]
+				if tt.trend.HasDay[d] != ok || tt.trend.Days[d] != want {
+					t.Fatalf("day %d = %v (%v), want %v (%v)", d, tt.trend.Days[d], tt.trend.HasDay[d], want, ok)
+				}
+			}
+			if tt.trend.Rating != tt.wantRating {
+				t.Fatalf("Rating = %t, want %t", tt.trend.Rating, tt.wantRating)
+			}
+			if tt.trend.Mean != tt.wantMean || tt.trend.ScaleMin != tt.wantScaleMin || tt.trend.ScaleMax != tt.wantScaleMax {
+				t.Fatalf("mean %v, scale %v–%v; want %v, %v–%v", tt.trend.Mean, tt.trend.ScaleMin, tt.trend.ScaleMax, tt.wantMean, tt.wantScaleMin, tt.wantScaleMax)
+			}
+		})
+	}
+}
+
+func TestWeeklyPDF(t *testing.T) {
+	font, err := pdf.ParseFont(pdftest.Font())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		records   int
+		wantPages int
+	}{
+		{name: "empty week", records: 0, wantPages: 1},
+		{name: "long week flows onto more pages", records: 40, wantPages: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var records []*state.Record
+			for i := 0; i < tt.records; i++ {
+				records = append(records, weeklyRecord(start.Add(time.Duration(i)*4*time.Hour), map[string]state.Answer{
+					"events": state.ScoredAnswer(state.ScoredEntry{Text: "событие", Score: 1 + i%5}),
+					"note":   state.StringAnswer("Длинная заметка, которая не помещается в одну строку и переносится по словам на следующую строку отчёта."),
+				}))
+			}
+			data, err := BuildWeekly(weeklyConfig(), 7, "patient", records, start).PDF(font)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.HasPrefix(data, []byte("%PDF-")) {
+				t.Fatalf("not a PDF")
+			}
+			pages := bytes.Count(data, []byte("/Type /Page "))
+			if pages < tt.wantPages {
+				t.Fatalf("pages = %d, want at least %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
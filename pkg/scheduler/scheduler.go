@@ -0,0 +1,194 @@
+// Package scheduler keeps per-user daily times, such as personal reminders, and persists them to a
+// JSON file so they survive restarts.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TimeLayout is the accepted format of a daily time.
+const TimeLayout = "15:04"
+
+// MaxLateness is how late a run may still fire, e.g. after a restart; older runs are skipped.
+const MaxLateness = time.Hour
+
+// Entry is the daily time of one user and when it last fired.
+type Entry struct {
+	Time    string    `json:"time"` // Local time of day, "HH:MM"
+	LastRun time.Time `json:"last_run,omitempty"`
+}
+
+// Schedule holds the daily times by user ID. With a file path every change is written to disk.
+type Schedule struct {
+	mu      sync.Mutex
+	path    string
+	entries map[int64]*Entry
+}
+
+// Open loads the schedule from path; a missing file starts an empty schedule and an empty path keeps
+// it in memory only.
+func Open(path string) (*Schedule, error) {
+	s := &Schedule{path: path, entries: make(map[int64]*Entry)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read schedule: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("parse schedule %s: %w", path, err)
+	}
+	for userID, entry := range s.entries {
+		if _, err := time.Parse(TimeLayout, entry.Time); err != nil {
+			return nil, fmt.Errorf("schedule %s: user %d has invalid time %q", path, userID, entry.Time)
+		}
+	}
+	return s, nil
+}
+
+// Set makes userID due every day at "HH:MM" starting from the next occurrence after now.
+func (s *Schedule) Set(userID int64, at string, now time.Time) error {
+	if _, err := time.Parse(TimeLayout, at); err != nil {
+		return fmt.Errorf("time %q must use HH:MM format", at)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[userID] = &Entry{Time: at, LastRun: now}
+	return s.saveLocked()
+}
+
+// Clear removes the daily time of userID.
+func (s *Schedule) Clear(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[userID]; !ok {
+		return nil
+	}
+	delete(s.entries, userID)
+	return s.saveLocked()
+}
+
+// Move hands the daily time of from, with its last run, to to after the user switched accounts, and
+// reports whether it did. A time to already has wins and the one of from is dropped.
+func (s *Schedule) Move(from, to int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[from]
+	if !ok {
+		return false, nil
+	}
+	delete(s.entries, from)
+	_, taken := s.entries[to]
+	if !taken {
+		s.entries[to] = entry
+	}
+	return !taken, s.saveLocked()
+}
+
+// Get returns the daily time of userID.
+func (s *Schedule) Get(userID int64) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[userID]
+	if !ok {
+		return "", false
+	}
+	return entry.Time, true
+}
+
+// Due returns the users whose time of day has come since their last run, in ID order, and records the
+// run. Runs later than MaxLateness are recorded without being returned.
+func (s *Schedule) Due(now time.Time) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []int64
+	changed := false
+	for userID, entry := range s.entries {
+		at, err := time.Parse(TimeLayout, entry.Time)
+		if err != nil {
+			continue
+		}
+		slot := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+		if slot.After(now) || !entry.LastRun.Before(slot) {
+			continue
+		}
+		entry.LastRun = now
+		changed = true
+		if now.Sub(slot) <= MaxLateness {
+			due = append(due, userID)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i] < due[j] })
+	if !changed {
+		return due, nil
+	}
+	return due, s.saveLocked()
+}
+
+// Run calls fire for every due user each interval until ctx is cancelled. A failed save is logged; the
+// runs stay recorded in memory, so nobody is reminded twice.
+func Run(ctx context.Context, s *Schedule, interval time.Duration, fire func(ctx context.Context, userID int64)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			due, err := s.Due(now)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to save the schedule", "err", err)
+			}
+			for _, userID := range due {
+				fire(ctx, userID)
+			}
+		}
+	}
+}
+
+// saveLocked writes the schedule to a temporary file and renames it over the old one, so a crash
+// never leaves a half-written file. Callers hold s.mu.
+func (s *Schedule) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode schedule: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("save schedule: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save schedule: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save schedule: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("save schedule: %w", err)
+	}
+	return nil
+}
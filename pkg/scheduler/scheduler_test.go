@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScheduleDue(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	schedule, err := Open("")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := schedule.Set(1, "09:00", at(8, 0)); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := schedule.Set(2, "07:30", at(8, 0)); err != nil { // Already passed today
+		t.Fatalf("set: %v", err)
+	}
+	if err := schedule.Set(3, "25:00", at(8, 0)); err == nil {
+		t.Fatalf("expected an invalid time to be rejected")
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want []int64
+	}{
+		{name: "before the time", now: at(8, 59)},
+		{name: "on time", now: at(9, 0), want: []int64{1}},
+		{name: "once a day", now: at(9, 1)},
+		{name: "next day", now: at(31, 30), want: []int64{2}},
+		{name: "too late after downtime", now: at(35, 0)}, // 09:00 of the second day, two hours late
+		{name: "third day", now: at(57, 0), want: []int64{1}},
+	}
+	for _, tt := range tests {
+		due, err := schedule.Due(tt.now)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(due) != len(tt.want) || (len(due) > 0 && due[0] != tt.want[0]) {
+			t.Fatalf("%s: due = %v, want %v", tt.name, due, tt.want)
+		}
+	}
+}
+
+func TestSchedulePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reminders.json")
+	now := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	schedule, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_ = schedule.Set(1, "09:00", now)
+	_ = schedule.Set(2, "10:00", now)
+	_ = schedule.Clear(2)
+	if due, _ := schedule.Due(now.Add(time.Hour)); len(due) != 1 {
+		t.Fatalf("due = %v", due)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if at, ok := reopened.Get(1); !ok || at != "09:00" {
+		t.Fatalf("Get(1) = %q, %t", at, ok)
+	}
+	if _, ok := reopened.Get(2); ok {
+		t.Fatalf("cleared entry survived the restart")
+	}
+	if due, _ := reopened.Due(now.Add(time.Hour + time.Minute)); len(due) != 0 {
+		t.Fatalf("run before the restart fired again: %v", due)
+	}
+}
+
+func TestScheduleMove(t *testing.T) {
+	now := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		from, to  string // Daily times before the move; empty for none
+		wantMoved bool
+		wantTo    string
+	}{
+		{name: "moves", from: "09:00", wantMoved: true, wantTo: "09:00"},
+		{name: "new account keeps its own", from: "09:00", to: "21:00", wantTo: "21:00"},
+		{name: "nothing to move", to: "21:00", wantTo: "21:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "reminders.json")
+			schedule, err := Open(path)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			for userID, at := range map[int64]string{1: tt.from, 2: tt.to} {
+				if at != "" {
+					_ = schedule.Set(userID, at, now)
+				}
+			}
+			moved, err := schedule.Move(1, 2)
+			if err != nil || moved != tt.wantMoved {
+				t.Fatalf("Move = %t, %v; want %t", moved, err, tt.wantMoved)
+			}
+
+			reopened, err := Open(path)
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			if _, ok := reopened.Get(1); ok {
+				t.Fatalf("the old account kept its time")
+			}
+			if at, _ := reopened.Get(2); at != tt.wantTo {
+				t.Fatalf("Get(2) = %q, want %q", at, tt.wantTo)
+			}
+		})
+	}
+}
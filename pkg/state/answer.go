@@ -0,0 +1,129 @@
+package state
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// AnswerKind identifies which field of an Answer carries the value.
+type AnswerKind string
+
+const (
+	AnswerString     AnswerKind = "string"
+	AnswerNumber     AnswerKind = "number"
+	AnswerList       AnswerKind = "list"
+	AnswerAttachment AnswerKind = "attachment"
+	AnswerScored     AnswerKind = "scored"
+)
+
+// Answer is a typed value stored in Record.Data. Only the field matching Kind is set, except that a
+// number picked on a labelled scale keeps the label in Text; the zero Answer means "not answered".
+type Answer struct {
+	Kind       AnswerKind     `json:"kind"`
+	Text       string         `json:"text,omitempty"`
+	Number     float64        `json:"number,omitempty"`
+	List       []string       `json:"list,omitempty"`
+	Attachment *AttachmentRef `json:"attachment,omitempty"`
+	Scored     []ScoredEntry  `json:"scored,omitempty"`
+}
+
+// AttachmentRef points to a file kept by the transport.
+type AttachmentRef struct {
+	FileID   string `json:"file_id"`
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+}
+
+// ScoredEntry is a free-text entry with a numeric score.
+type ScoredEntry struct {
+	Text  string `json:"text"`
+	Score int    `json:"score"`
+}
+
+func StringAnswer(text string) Answer {
+	return Answer{Kind: AnswerString, Text: text}
+}
+
+func NumberAnswer(n float64) Answer {
+	return Answer{Kind: AnswerNumber, Number: n}
+}
+
+// RatingAnswer is a number answer picked on a scale, with the label of the picked point ("" for none).
+func RatingAnswer(score int, label string) Answer {
+	return Answer{Kind: AnswerNumber, Number: float64(score), Text: label}
+}
+
+func ListAnswer(items ...string) Answer {
+	return Answer{Kind: AnswerList, List: items}
+}
+
+func AttachmentAnswer(ref AttachmentRef) Answer {
+	return Answer{Kind: AnswerAttachment, Attachment: &ref}
+}
+
+func ScoredAnswer(entries ...ScoredEntry) Answer {
+	return Answer{Kind: AnswerScored, Scored: entries}
+}
+
+// IsEmpty reports whether the answer carries no value.
+func (a Answer) IsEmpty() bool {
+	switch a.Kind {
+	case AnswerString:
+		return a.Text == ""
+	case AnswerNumber:
+		return false
+	case AnswerList:
+		return len(a.List) == 0
+	case AnswerAttachment:
+		return a.Attachment == nil
+	case AnswerScored:
+		return len(a.Scored) == 0
+	default:
+		return true
+	}
+}
+
+// Equal reports whether both answers hold the same value.
+func (a Answer) Equal(other Answer) bool {
+	if a.IsEmpty() && other.IsEmpty() {
+		return true
+	}
+	return reflect.DeepEqual(a, other)
+}
+
+// String renders the answer as plain text for displays, forwards and exports.
+func (a Answer) String() string {
+	switch a.Kind {
+	case AnswerString:
+		return a.Text
+	case AnswerNumber:
+		if a.Text != "" {
+			return a.Text + " (" + a.number() + ")"
+		}
+		return a.number()
+	case AnswerList:
+		return strings.Join(a.List, ", ")
+	case AnswerAttachment:
+		if a.Attachment == nil {
+			return ""
+		}
+		if a.Attachment.Name != "" {
+			return "📎 " + a.Attachment.Name
+		}
+		return "📎 " + a.Attachment.FileID
+	case AnswerScored:
+		lines := make([]string, 0, len(a.Scored))
+		for _, entry := range a.Scored {
+			lines = append(lines, fmt.Sprintf("- %s\n  Рейтинг: %d", entry.Text, entry.Score))
+		}
+		return strings.Join(lines, "\n")
+	default:
+		return ""
+	}
+}
+
+func (a Answer) number() string {
+	return strconv.FormatFloat(a.Number, 'f', -1, 64)
+}
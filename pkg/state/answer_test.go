@@ -0,0 +1,48 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAnswerJSONRoundTripAndString(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  Answer
+		wantStr string
+	}{
+		{name: "string", answer: StringAnswer("hello"), wantStr: "hello"},
+		{name: "number", answer: NumberAnswer(7.5), wantStr: "7.5"},
+		{name: "rating", answer: RatingAnswer(4, "🙂"), wantStr: "🙂 (4)"},
+		{name: "list", answer: ListAnswer("a", "b"), wantStr: "a, b"},
+		{name: "attachment", answer: AttachmentAnswer(AttachmentRef{FileID: "f1", Name: "scan.pdf"}), wantStr: "📎 scan.pdf"},
+		{name: "scored", answer: ScoredAnswer(ScoredEntry{Text: "walk", Score: 8}, ScoredEntry{Text: "work", Score: 3}), wantStr: "- walk\n  Рейтинг: 8\n- work\n  Рейтинг: 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := json.Marshal(tt.answer)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var decoded Answer
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !decoded.Equal(tt.answer) {
+				t.Fatalf("round trip = %+v, want %+v", decoded, tt.answer)
+			}
+			if got := decoded.String(); got != tt.wantStr {
+				t.Fatalf("String() = %q, want %q", got, tt.wantStr)
+			}
+		})
+	}
+}
+
+func TestAnswerIsEmpty(t *testing.T) {
+	if !(Answer{}).IsEmpty() || !StringAnswer("").IsEmpty() || !ListAnswer().IsEmpty() {
+		t.Fatalf("expected empty answers")
+	}
+	if NumberAnswer(0).IsEmpty() {
+		t.Fatalf("zero is a valid number answer")
+	}
+}
@@ -0,0 +1,149 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RecordWriter stores saved records in a persistent backend.
+type RecordWriter interface {
+	SaveRecord(userID int64, record *Record) error
+	DeleteRecord(userID int64, id string) error
+}
+
+// Backend keeps users, their saved records and the deliveries beyond the process. The store reads
+// records from it lazily, archives evicted users to it, writes changes back on Sync and writes
+// deliveries through as they change.
+type Backend interface {
+	RecordSource
+	UserArchive
+	RecordWriter
+	DeliveryLog
+}
+
+// SetBackend makes backend the record source, user archive, record writer and delivery log of the
+// store, keeping up to cacheSize loaded records per user (DefaultRecordCacheSize when not positive).
+// The deliveries table is replaced by the one in backend.
+func (s *Store) SetBackend(backend Backend, cacheSize int) error {
+	if err := s.loadDeliveries(backend); err != nil {
+		return err
+	}
+	s.SetRecordSource(backend, cacheSize)
+	s.SetUserArchive(backend)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writer = backend
+	s.written = make(map[int64]map[string]bool)
+	return nil
+}
+
+// Sync writes userState to the backend: the user, the records saved since the last Sync, and the removal
+// of written records that are no longer in memory. Records that fail to write are retried on the next
+// Sync. Without a backend it does nothing. Callers hold the user's Mu.
+func (s *Store) Sync(userState *UserState) error {
+	s.mu.Lock()
+	archive := s.archive
+	writer := s.writer
+	s.mu.Unlock()
+
+	if writer == nil {
+		return nil
+	}
+	if err := archive.SaveUser(userState); err != nil {
+		return fmt.Errorf("save user %d: %w", userState.UserID, err)
+	}
+	return s.syncRecords(writer, userState)
+}
+
+// UpdateRecord has the next Sync write record, a saved record of userState changed in place, again. A
+// record loaded from the source joins userState.Records for that. Callers hold the user's Mu.
+func (s *Store) UpdateRecord(userState *UserState, record *Record) {
+	inMemory := false
+	for _, r := range userState.Records {
+		if r == record {
+			inMemory = true
+			break
+		}
+	}
+	if !inMemory {
+		userState.Records = append(userState.Records, record)
+	}
+
+	s.mu.Lock()
+	delete(s.written[userState.UserID], record.ID)
+	s.mu.Unlock()
+}
+
+// DeleteRecord removes the saved record id of userID from memory, from the loaded records and from the
+// backend, whether or not this process wrote it there. A failed backend delete is retried on the next
+// Sync. Callers hold the user's Mu.
+func (s *Store) DeleteRecord(userID int64, id string) error {
+	s.mu.Lock()
+	userState := s.users[userID]
+	writer := s.writer
+	s.caches[userID].remove(id)
+	delete(s.written[userID], id)
+	s.mu.Unlock()
+
+	if userState != nil {
+		kept := make([]*Record, 0, len(userState.Records))
+		for _, r := range userState.Records {
+			if r != nil && r.ID != id {
+				kept = append(kept, r)
+			}
+		}
+		userState.Records = kept
+	}
+	if writer == nil {
+		return nil
+	}
+	if err := writer.DeleteRecord(userID, id); err != nil {
+		s.mu.Lock()
+		if s.written[userID] == nil {
+			s.written[userID] = make(map[string]bool)
+		}
+		s.written[userID][id] = true
+		s.mu.Unlock()
+		return fmt.Errorf("delete record %s of user %d: %w", id, userID, err)
+	}
+	return nil
+}
+
+// syncRecords brings the backend records of userState in line with memory. The writes run without
+// s.mu; the user's Mu keeps two syncs of the same user apart.
+func (s *Store) syncRecords(writer RecordWriter, userState *UserState) error {
+	s.mu.Lock()
+	written := s.written[userState.UserID]
+	s.mu.Unlock()
+
+	var errs []error
+	synced := make(map[string]bool)
+	for _, r := range userState.Records {
+		if r == nil || !r.IsSaved || r.ID == "" || synced[r.ID] {
+			continue
+		}
+		if !written[r.ID] {
+			if err := writer.SaveRecord(userState.UserID, r); err != nil {
+				errs = append(errs, fmt.Errorf("save record %s of user %d: %w", r.ID, userState.UserID, err))
+				continue
+			}
+		}
+		synced[r.ID] = true
+	}
+	for id := range written {
+		if synced[id] {
+			continue
+		}
+		if err := writer.DeleteRecord(userState.UserID, id); err != nil {
+			errs = append(errs, fmt.Errorf("delete record %s of user %d: %w", id, userState.UserID, err))
+			synced[id] = true // Still written; try again next time
+		}
+	}
+
+	s.mu.Lock()
+	s.written[userState.UserID] = synced
+	s.mu.Unlock()
+	return errors.Join(errs...)
+}
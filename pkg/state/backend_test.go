@@ -0,0 +1,240 @@
+package state
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBackend keeps records by ID and users by pointer; failSave makes SaveRecord fail.
+type fakeBackend struct {
+	fakeRecordSource
+	owners     map[string]int64
+	users      map[int64]*UserState
+	deliveries map[string]Delivery
+	failSave   bool
+	saves      []string // IDs of the records written, in order
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		fakeRecordSource: fakeRecordSource{records: make(map[string]*Record)},
+		owners:           make(map[string]int64),
+		users:            make(map[int64]*UserState),
+		deliveries:       make(map[string]Delivery),
+	}
+}
+
+func (f *fakeBackend) SaveUser(userState *UserState) error {
+	f.users[userState.UserID] = &UserState{UserID: userState.UserID, UserName: userState.UserName, CurrentRecord: userState.CurrentRecord}
+	return nil
+}
+
+func (f *fakeBackend) LoadUser(userID int64) (*UserState, bool, error) {
+	userState, ok := f.users[userID]
+	return userState, ok, nil
+}
+
+func (f *fakeBackend) DeleteUser(userID int64) error {
+	delete(f.users, userID)
+	return nil
+}
+
+func (f *fakeBackend) UserIDs() ([]int64, error) {
+	var ids []int64
+	for id := range f.users {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (f *fakeBackend) SaveRecord(userID int64, record *Record) error {
+	if f.failSave {
+		return errors.New("disk full")
+	}
+	f.records[record.ID] = record
+	f.owners[record.ID] = userID
+	f.saves = append(f.saves, record.ID)
+	return nil
+}
+
+func (f *fakeBackend) DeleteRecord(_ int64, id string) error {
+	delete(f.records, id)
+	delete(f.owners, id)
+	return nil
+}
+
+func (f *fakeBackend) SaveDelivery(d *Delivery) error {
+	f.deliveries[d.ID] = *d
+	return nil
+}
+
+func (f *fakeBackend) LoadDeliveries() ([]*Delivery, error) {
+	var deliveries []*Delivery
+	for _, d := range f.deliveries {
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, nil
+}
+
+func (f *fakeBackend) ids() string {
+	var ids []string
+	for id := range f.records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+func TestStoreSyncWritesChanges(t *testing.T) {
+	saved := func(id string) *Record {
+		record := NewRecord()
+		record.ID = id
+		record.IsSaved = true
+		record.CreatedAt = time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+		return record
+	}
+	backend := newFakeBackend()
+	store := NewStore(stubFSMCreator{})
+	if err := store.SetBackend(backend, 0); err != nil {
+		t.Fatalf("SetBackend: %v", err)
+	}
+	user := store.GetOrCreateUserState(1, "User")
+	a, b := saved("a"), saved("b")
+
+	steps := []struct {
+		name     string
+		change   func()
+		failSave bool
+		wantErr  bool
+		wantIDs  string
+	}{
+		{name: "nothing saved", change: func() {}, wantIDs: ""},
+		{name: "draft is not a record", change: func() { user.CurrentRecord = NewRecord() }, wantIDs: ""},
+		{name: "saved records are written", change: func() { user.Records = append(user.Records, a, b) }, wantIDs: "a,b"},
+		{name: "removed record is deleted", change: func() { user.Records = []*Record{b} }, wantIDs: "b"},
+		{name: "failed write is reported", change: func() { user.Records = append(user.Records, saved("c")) }, failSave: true, wantErr: true, wantIDs: "b"},
+		{name: "failed write is retried", change: func() {}, wantIDs: "b,c"},
+	}
+	for _, step := range steps {
+		step.change()
+		backend.failSave = step.failSave
+		err := store.Sync(user)
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: err = %v", step.name, err)
+		}
+		if got := backend.ids(); got != step.wantIDs {
+			t.Fatalf("%s: backend has %q, want %q", step.name, got, step.wantIDs)
+		}
+	}
+	if _, ok := backend.users[1]; !ok {
+		t.Fatalf("the user must be written too")
+	}
+
+	// An evicted user keeps the records in the backend; restored without them, it must not delete them.
+	user.Records = append(user.Records, saved("d"))
+	if evicted, err := store.Evict(1, time.Now().Add(time.Minute)); !evicted || err != nil {
+		t.Fatalf("Evict = %t, %v", evicted, err)
+	}
+	restored, ok := store.Get(1)
+	if !ok || len(restored.Records) != 0 {
+		t.Fatalf("restored user = %+v, %t", restored, ok)
+	}
+	if err := store.Sync(restored); err != nil || backend.ids() != "b,c,d" {
+		t.Fatalf("sync after restore: %v, backend has %q", err, backend.ids())
+	}
+	if metas := store.ListRecordMetas(1, RecordFilter{}, Page{}); len(metas) != 3 {
+		t.Fatalf("records must be listed from the backend, got %+v", metas)
+	}
+}
+
+func TestStoreUpdateRecord(t *testing.T) {
+	backend := newFakeBackend()
+	store := NewStore(stubFSMCreator{})
+	if err := store.SetBackend(backend, 0); err != nil {
+		t.Fatalf("SetBackend: %v", err)
+	}
+	user := store.GetOrCreateUserState(1, "User")
+	record := NewRecord()
+	record.ID = "a"
+	record.IsSaved = true
+	record.CreatedAt = time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	user.Records = append(user.Records, record)
+	noted := time.Date(2025, 3, 1, 21, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name      string
+		change    func()
+		wantSaves string
+	}{
+		{name: "saved record is written", change: func() {}, wantSaves: "a"},
+		{name: "unchanged record is not written again", change: func() {}, wantSaves: "a"},
+		{name: "updated record is written again", change: func() {
+			record.AddNote(noted, "first")
+			store.UpdateRecord(user, record)
+		}, wantSaves: "a,a"},
+		{name: "record loaded from the backend is written again", change: func() {
+			if evicted, err := store.Evict(1, time.Now().Add(time.Minute)); !evicted || err != nil {
+				t.Fatalf("Evict = %t, %v", evicted, err)
+			}
+			user, _ = store.Get(1)
+			last := store.LastRecord(1)
+			last.AddNote(noted, "second")
+			store.UpdateRecord(user, last)
+		}, wantSaves: "a,a,a"},
+		{name: "record stays written", change: func() {}, wantSaves: "a,a,a"},
+	}
+	for _, step := range steps {
+		step.change()
+		if err := store.Sync(user); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := strings.Join(backend.saves, ","); got != step.wantSaves {
+			t.Fatalf("%s: saves %q, want %q", step.name, got, step.wantSaves)
+		}
+	}
+	if notes := backend.records["a"].Notes; len(notes) != 2 || notes[1].Text != "second" || !notes[1].At.Equal(noted) {
+		t.Fatalf("backend notes = %+v", notes)
+	}
+}
+
+func TestStoreDeliveriesSurviveRestart(t *testing.T) {
+	backend := newFakeBackend()
+	sentAt := time.Date(2025, 3, 1, 21, 0, 0, 0, time.UTC)
+	start := func() *Store {
+		store := NewStore(stubFSMCreator{})
+		if err := store.SetBackend(backend, 0); err != nil {
+			t.Fatalf("SetBackend: %v", err)
+		}
+		return store
+	}
+
+	store := start()
+	store.AddDelivery(&Delivery{ID: "d1", UserID: 1, RecordID: "a", TargetID: 9, Status: DeliveryDelivered, SentAt: sentAt})
+	store.AddDelivery(&Delivery{ID: "d2", UserID: 1, RecordID: "b", TargetID: 9, Status: DeliveryDelivered, SentAt: sentAt})
+	if _, err := store.AckDelivery("d1", sentAt.Add(time.Hour)); err != nil {
+		t.Fatalf("AckDelivery: %v", err)
+	}
+
+	restarted := start()
+	steps := []struct {
+		name string
+		ok   bool
+	}{
+		{name: "history is kept", ok: len(restarted.Deliveries(1)) == 2},
+		{name: "acknowledgement is kept", ok: len(restarted.PendingDeliveries(1)) == 1 && restarted.PendingDeliveries(1)[0].ID == "d2"},
+		{name: "delivered records are known by ID", ok: restarted.Delivered("a") && restarted.Delivered("b") && !restarted.Delivered("c")},
+	}
+	for _, step := range steps {
+		if !step.ok {
+			t.Fatalf("%s: deliveries %+v", step.name, restarted.Deliveries(1))
+		}
+	}
+
+	restarted.AddDelivery(&Delivery{ID: "d3", UserID: 1, RecordID: "c", TargetID: 9, Status: DeliveryDelivered, SentAt: sentAt})
+	if list := restarted.Deliveries(1); list[0].ID != "d3" {
+		t.Fatalf("a delivery added after the restart must come after the loaded ones, got %+v", list)
+	}
+}
@@ -0,0 +1,208 @@
+package state
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+)
+
+// DeliveryStatus is the outcome of forwarding a record to the therapist.
+type DeliveryStatus string
+
+const (
+	DeliveryDelivered DeliveryStatus = "delivered" // Sent, not yet acknowledged
+	DeliveryRead      DeliveryStatus = "read"      // The therapist acknowledged it
+	DeliveryFailed    DeliveryStatus = "failed"
+	DeliveryRetrying  DeliveryStatus = "retrying" // Queued for another attempt
+)
+
+// Delivery is a record forwarded to the therapist, tracked until they acknowledge it.
+type Delivery struct {
+	ID        string
+	UserID    int64  // Patient whose record was forwarded
+	RecordID  string // Forwarded record; cleared from the patient once acknowledged (require_ack)
+	TargetID  int64
+	MessageID int   // Forwarded message in the therapist's chat; the last part of a forward sent in parts
+	PartIDs   []int // Every message of a forward sent in parts, nil for one message
+	Status    DeliveryStatus
+	Error     string // Last failure, for DeliveryFailed and DeliveryRetrying
+	SentAt    time.Time
+	AckedAt   time.Time
+	Seq       uint64 // Order the store registered it in; breaks ties of SentAt, shared by a digest
+}
+
+// DeliveryLog keeps the deliveries table beyond the process. The store writes every change of a
+// delivery through to it and loads the table back on SetBackend.
+type DeliveryLog interface {
+	SaveDelivery(d *Delivery) error
+	LoadDeliveries() ([]*Delivery, error)
+}
+
+// Acked reports whether the therapist acknowledged the delivery.
+func (d *Delivery) Acked() bool {
+	return !d.AckedAt.IsZero()
+}
+
+// AddDelivery registers a delivery, replacing the one with the same ID, whose place in the order it keeps.
+func (s *Store) AddDelivery(d *Delivery) {
+	s.mu.Lock()
+	if s.deliveries == nil {
+		s.deliveries = make(map[string]*Delivery)
+	}
+	if old, ok := s.deliveries[d.ID]; ok {
+		d.Seq = old.Seq
+	} else {
+		s.deliverySeq++
+		d.Seq = s.deliverySeq
+	}
+	s.deliveries[d.ID] = d
+	saved := *d
+	log := s.deliveryLog
+	s.mu.Unlock()
+
+	saveDeliveries(log, saved)
+}
+
+// saveDeliveries writes copies of deliveries, taken under s.mu, to log without holding it. A failed
+// write is logged: the table in memory stays authoritative until the next change of the delivery.
+func saveDeliveries(log DeliveryLog, deliveries ...Delivery) {
+	if log == nil {
+		return
+	}
+	for i := range deliveries {
+		if err := log.SaveDelivery(&deliveries[i]); err != nil {
+			slog.Error("saving the delivery failed", "delivery_id", deliveries[i].ID, "err", err)
+		}
+	}
+}
+
+// loadDeliveries replaces the deliveries table with the one kept in log.
+func (s *Store) loadDeliveries(log DeliveryLog) error {
+	deliveries, err := log.LoadDeliveries()
+	if err != nil {
+		return fmt.Errorf("load deliveries: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries = make(map[string]*Delivery, len(deliveries))
+	s.deliverySeq = 0
+	for _, d := range deliveries {
+		s.deliveries[d.ID] = d
+		s.deliverySeq = max(s.deliverySeq, d.Seq)
+	}
+	s.deliveryLog = log
+	return nil
+}
+
+// sentBefore orders deliveries by SentAt, then by registration.
+func sentBefore(a, b *Delivery) bool {
+	if !a.SentAt.Equal(b.SentAt) {
+		return a.SentAt.Before(b.SentAt)
+	}
+	return a.Seq < b.Seq
+}
+
+// Delivery returns the delivery with id.
+func (s *Store) Delivery(id string) (*Delivery, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[id]
+	return d, ok
+}
+
+// AckDelivery marks the delivery acknowledged at and returns it. Acknowledging twice is an error so
+// the record is cleared only once.
+func (s *Store) AckDelivery(id string, at time.Time) (*Delivery, error) {
+	s.mu.Lock()
+	d, ok := s.deliveries[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("delivery %s not found", id)
+	}
+	if d.Acked() {
+		s.mu.Unlock()
+		return d, fmt.Errorf("delivery %s already acknowledged", id)
+	}
+	if d.Status != DeliveryDelivered {
+		s.mu.Unlock()
+		return d, fmt.Errorf("delivery %s is %s", id, d.Status)
+	}
+	d.AckedAt = at
+	d.Status = DeliveryRead
+	saved := *d
+	log := s.deliveryLog
+	s.mu.Unlock()
+
+	saveDeliveries(log, saved)
+	return d, nil
+}
+
+// PendingDeliveries lists delivered but unacknowledged deliveries of userID, oldest first.
+func (s *Store) PendingDeliveries(userID int64) []*Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []*Delivery
+	for _, d := range s.deliveries {
+		if d.UserID == userID && d.Status == DeliveryDelivered {
+			pending = append(pending, d)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return sentBefore(pending[i], pending[j]) })
+	return pending
+}
+
+// Deliveries lists every delivery of userID, newest first.
+func (s *Store) Deliveries(userID int64) []*Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []*Delivery
+	for _, d := range s.deliveries {
+		if d.UserID == userID {
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return sentBefore(list[j], list[i]) })
+	return list
+}
+
+// Delivered reports whether the record recordID reached the therapist (delivered or read).
+func (s *Store) Delivered(recordID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if recordID == "" {
+		return false
+	}
+	for _, d := range s.deliveries {
+		if d.RecordID == recordID && (d.Status == DeliveryDelivered || d.Status == DeliveryRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// MessageDeliveries lists the deliveries sent to targetID in message messageID, or in a forward that
+// message is a part of; a digest carries several.
+func (s *Store) MessageDeliveries(targetID int64, messageID int) []*Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []*Delivery
+	if messageID == 0 {
+		return list
+	}
+	for _, d := range s.deliveries {
+		if d.TargetID == targetID && (d.MessageID == messageID || slices.Contains(d.PartIDs, messageID)) {
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
@@ -0,0 +1,140 @@
+package state
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// UserArchive keeps the state of users evicted from memory until they come back.
+type UserArchive interface {
+	SaveUser(userState *UserState) error
+	LoadUser(userID int64) (*UserState, bool, error)
+	// UserIDs lists the users the archive holds.
+	UserIDs() ([]int64, error)
+	DeleteUser(userID int64) error
+}
+
+// SetUserArchive makes Evict save users to archive and lookups restore them from it.
+func (s *Store) SetUserArchive(archive UserArchive) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.archive = archive
+}
+
+// HasUserArchive reports whether evicted users are archived rather than dropped.
+func (s *Store) HasUserArchive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.archive != nil
+}
+
+// Evict drops userID from memory, saving it to the archive first when one is set, unless the user was
+// looked up at or after idleBefore: an update that already holds the state keeps it. With a backend the
+// saved records are written first, as they are read back from it after a restore. The writes run
+// without s.mu, and the lookup time is checked again once they are done. Callers hold the user's Mu and
+// have checked that nothing in the state is still needed in memory. It reports whether the user was
+// evicted.
+func (s *Store) Evict(userID int64, idleBefore time.Time) (bool, error) {
+	s.mu.Lock()
+	userState, ok := s.users[userID]
+	idle := s.seen[userID].Before(idleBefore)
+	writer := s.writer
+	archive := s.archive
+	s.mu.Unlock()
+	if !ok || !idle {
+		return false, nil
+	}
+	if writer != nil {
+		if err := s.syncRecords(writer, userState); err != nil {
+			return false, fmt.Errorf("write records of user %d: %w", userID, err)
+		}
+	}
+	if archive != nil {
+		if err := archive.SaveUser(userState); err != nil {
+			return false, fmt.Errorf("archive user %d: %w", userID, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.users[userID] != userState || !s.seen[userID].Before(idleBefore) {
+		return false, nil
+	}
+	delete(s.users, userID)
+	delete(s.seen, userID)
+	delete(s.caches, userID)
+	delete(s.written, userID)
+	slog.Info("evicted idle user", "user_id", userID, "user_name", userState.UserName)
+	return true, nil
+}
+
+// lookup returns the in-memory state of userID, restoring an evicted user from the archive. The archive
+// is read without s.mu; other lookups of the same user wait for that read instead of starting their
+// own. Callers hold no s.mu.
+func (s *Store) lookup(userID int64) (*UserState, bool) {
+	s.mu.Lock()
+	for {
+		if userState, ok := s.users[userID]; ok {
+			s.mu.Unlock()
+			return userState, true
+		}
+		loading, busy := s.loading[userID]
+		if !busy {
+			break
+		}
+		s.mu.Unlock()
+		<-loading
+		s.mu.Lock()
+	}
+	archive := s.archive
+	if archive == nil {
+		s.mu.Unlock()
+		return nil, false
+	}
+	loading := make(chan struct{})
+	s.loading[userID] = loading
+	s.mu.Unlock()
+
+	userState, ok := s.restore(archive, userID)
+
+	s.mu.Lock()
+	delete(s.loading, userID)
+	if ok {
+		s.users[userID] = userState
+		s.langs[userID] = userState.Lang()
+	}
+	s.mu.Unlock()
+	close(loading)
+	return userState, ok
+}
+
+// restore reads userID from archive and gives it FSMs in the states it was archived in.
+func (s *Store) restore(archive UserArchive, userID int64) (*UserState, bool) {
+	userState, ok, err := archive.LoadUser(userID)
+	if err != nil {
+		slog.Error("failed to restore user from the archive", "user_id", userID, "err", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	if userState.MainMenuFSM == nil {
+		userState.MainMenuFSM = s.fsmCreator.NewMainMenuFSM()
+	}
+	if userState.RecordFSM == nil {
+		userState.RecordFSM = s.fsmCreator.NewRecordFSM()
+	}
+	if userState.Resume.MainMenu != "" {
+		userState.MainMenuFSM.SetState(userState.Resume.MainMenu)
+	}
+	if userState.Resume.Record != "" {
+		userState.RecordFSM.SetState(userState.Resume.Record)
+	}
+	userState.Resume = FSMStates{}
+	slog.Info("restored user from the archive", "user_id", userID, "user_name", userState.UserName)
+	return userState, true
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowArchive blocks LoadUser of user 2 until release is closed.
+type slowArchive struct {
+	fakeBackend
+	started chan struct{}
+	release chan struct{}
+	loads   atomic.Int32
+}
+
+func (a *slowArchive) LoadUser(userID int64) (*UserState, bool, error) {
+	if userID == 2 {
+		a.loads.Add(1)
+		a.started <- struct{}{}
+		<-a.release
+	}
+	return a.fakeBackend.LoadUser(userID)
+}
+
+func TestRestoreDoesNotBlockOtherUsers(t *testing.T) {
+	archive := &slowArchive{fakeBackend: *newFakeBackend(), started: make(chan struct{}, 1), release: make(chan struct{})}
+	archive.users[2] = &UserState{UserID: 2, UserName: "Archived"}
+	store := NewStore(stubFSMCreator{})
+	store.SetUserArchive(archive)
+	store.GetOrCreateUserState(1, "Loaded")
+
+	restored := make(chan *UserState, 2)
+	get := func() {
+		userState, _ := store.Get(2)
+		restored <- userState
+	}
+	go get()
+	<-archive.started
+
+	done := make(chan struct{})
+	go func() {
+		store.Get(1)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a lookup of another user waited for the archive")
+	}
+
+	go get()
+	close(archive.release)
+	first, second := <-restored, <-restored
+	if first == nil || first != second {
+		t.Fatalf("both lookups must get the one restored state, got %p and %p", first, second)
+	}
+	if loads := archive.loads.Load(); loads != 1 {
+		t.Fatalf("archive read %d times, want once", loads)
+	}
+}
+
+func TestLockUserAfterEviction(t *testing.T) {
+	store := NewStore(stubFSMCreator{})
+	store.SetUserArchive(newFakeBackend())
+	evicted := store.GetOrCreateUserState(1, "User")
+
+	evicted.Mu.Lock()
+	locked := make(chan *UserState)
+	go func() {
+		userState, _ := store.LockUser(1)
+		locked <- userState
+		userState.Mu.Unlock()
+	}()
+	runtime.Gosched() // Lets LockUser find the state and wait for its Mu
+	if ok, err := store.Evict(1, time.Now().Add(time.Hour)); !ok || err != nil {
+		t.Fatalf("Evict = %t, %v", ok, err)
+	}
+	evicted.Mu.Unlock()
+
+	userState := <-locked
+	if current, _ := store.Get(1); userState == evicted || userState != current {
+		t.Fatalf("LockUser returned %p, want the restored state %p", userState, current)
+	}
+}
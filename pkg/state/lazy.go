@@ -0,0 +1,213 @@
+package state
+
+import (
+	"container/list"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultRecordCacheSize is how many full records per user stay loaded from a RecordSource.
+const DefaultRecordCacheSize = 16
+
+// PreviewKeys are the store keys whose answers list views show next to each record.
+var PreviewKeys = []string{"name", "city"}
+
+// RecordMeta is what list views need of a saved record, without its answers.
+type RecordMeta struct {
+	ID        string
+	CreatedAt time.Time
+	Template  string            // Survey template of the record, as in Record.Template
+	Tags      []string          // Content filter findings over all answers, sorted
+	Preview   map[string]string // Rendered answers of PreviewKeys, when present
+}
+
+// Meta returns the metadata of the record.
+func (r *Record) Meta() RecordMeta {
+	meta := RecordMeta{ID: r.ID, CreatedAt: r.CreatedAt, Template: r.Template}
+	seen := make(map[string]bool)
+	for _, findings := range r.Flags {
+		for _, finding := range findings {
+			if !seen[finding] {
+				seen[finding] = true
+				meta.Tags = append(meta.Tags, finding)
+			}
+		}
+	}
+	sort.Strings(meta.Tags)
+	for _, key := range PreviewKeys {
+		if answer := r.Data[key]; !answer.IsEmpty() {
+			if meta.Preview == nil {
+				meta.Preview = make(map[string]string)
+			}
+			meta.Preview[key] = answer.String()
+		}
+	}
+	return meta
+}
+
+// HasTag reports whether the record carries the content filter finding tag.
+func (m RecordMeta) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordSource is a persistent backend of saved records. The store lists records by their metadata
+// and loads the answers of a record only when it is opened.
+type RecordSource interface {
+	RecordMetas(userID int64) ([]RecordMeta, error)
+	LoadRecord(userID int64, id string) (*Record, error)
+}
+
+// SetRecordSource makes the store read saved records from source, keeping up to cacheSize loaded
+// records per user (DefaultRecordCacheSize when not positive). Records saved since start-up stay in
+// UserState.Records and take precedence over the source.
+func (s *Store) SetRecordSource(source RecordSource, cacheSize int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cacheSize <= 0 {
+		cacheSize = DefaultRecordCacheSize
+	}
+	s.source = source
+	s.cacheSize = cacheSize
+	s.caches = make(map[int64]*recordCache)
+}
+
+// LoadRecord returns the saved record id of userID with its answers: from memory, from the user's
+// cache, or from the record source. Callers hold the user's Mu.
+func (s *Store) LoadRecord(userID int64, id string) (*Record, error) {
+	s.mu.Lock()
+	userState := s.users[userID]
+	source := s.source
+	cache := s.caches[userID]
+	s.mu.Unlock()
+
+	if userState != nil {
+		for _, r := range userState.Records {
+			if r != nil && r.IsSaved && r.ID == id {
+				return r, nil
+			}
+		}
+	}
+	if source == nil {
+		return nil, fmt.Errorf("record %s of user %d not found", id, userID)
+	}
+	if record, ok := cache.get(id); ok {
+		return record, nil
+	}
+
+	record, err := source.LoadRecord(userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("load record %s of user %d: %w", id, userID, err)
+	}
+
+	s.mu.Lock()
+	cache = s.caches[userID]
+	if cache == nil {
+		cache = newRecordCache(s.cacheSize)
+		s.caches[userID] = cache
+	}
+	s.mu.Unlock()
+	cache.put(record)
+	return record, nil
+}
+
+// recordMetas lists the saved records of userID, in memory and in the source, oldest first.
+func (s *Store) recordMetas(userID int64) []RecordMeta {
+	s.mu.Lock()
+	userState := s.users[userID]
+	source := s.source
+	s.mu.Unlock()
+
+	var metas []RecordMeta
+	inMemory := make(map[string]bool)
+	if userState != nil {
+		for _, r := range userState.Records {
+			if r != nil && r.IsSaved {
+				metas = append(metas, r.Meta())
+				inMemory[r.ID] = true
+			}
+		}
+	}
+	if source == nil {
+		return metas
+	}
+
+	stored, err := source.RecordMetas(userID)
+	if err != nil {
+		slog.Error("failed to list stored records", "user_id", userID, "err", err)
+		return metas
+	}
+	for _, meta := range stored {
+		if !inMemory[meta.ID] {
+			metas = append(metas, meta)
+		}
+	}
+	sort.SliceStable(metas, func(i, j int) bool { return metas[i].CreatedAt.Before(metas[j].CreatedAt) })
+	return metas
+}
+
+// recordCache is a per-user LRU of records loaded from the source. It has its own lock, as readers
+// of different users share the store.
+type recordCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // Front is the most recently used
+	items map[string]*list.Element
+}
+
+func newRecordCache(size int) *recordCache {
+	return &recordCache{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *recordCache) get(id string) (*Record, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*Record), true
+}
+
+func (c *recordCache) remove(id string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[id]; ok {
+		c.order.Remove(elem)
+		delete(c.items, id)
+	}
+}
+
+func (c *recordCache) put(record *Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[record.ID]; ok {
+		elem.Value = record
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[record.ID] = c.order.PushFront(record)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*Record).ID)
+	}
+}
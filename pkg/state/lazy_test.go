@@ -0,0 +1,85 @@
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeRecordSource struct {
+	records map[string]*Record
+	loads   []string
+}
+
+func (f *fakeRecordSource) RecordMetas(int64) ([]RecordMeta, error) {
+	var metas []RecordMeta
+	for _, r := range f.records {
+		metas = append(metas, r.Meta())
+	}
+	return metas, nil
+}
+
+func (f *fakeRecordSource) LoadRecord(_ int64, id string) (*Record, error) {
+	f.loads = append(f.loads, id)
+	if r, ok := f.records[id]; ok {
+		return r, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestStoreLoadsRecordsLazily(t *testing.T) {
+	day := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	source := &fakeRecordSource{records: make(map[string]*Record)}
+	for i, id := range []string{"a", "b", "c"} {
+		record := NewRecord()
+		record.ID = id
+		record.IsSaved = true
+		record.CreatedAt = day.Add(time.Duration(i) * time.Hour)
+		record.Data["name"] = StringAnswer("Name " + id)
+		source.records[id] = record
+	}
+	store := NewStore(stubFSMCreator{})
+	store.SetRecordSource(source, 2)
+	user := store.GetOrCreateUserState(1, "User")
+	fresh := NewRecord()
+	fresh.ID = "d"
+	fresh.IsSaved = true
+	fresh.CreatedAt = day.Add(5 * time.Hour)
+	user.Records = append(user.Records, fresh)
+
+	metas := store.ListRecordMetas(1, RecordFilter{}, Page{Limit: 3})
+	if len(metas) != 3 || metas[0].ID != "d" || metas[1].ID != "c" || metas[1].Preview["name"] != "Name c" {
+		t.Fatalf("metas = %+v", metas)
+	}
+	if len(source.loads) != 0 {
+		t.Fatalf("listing loaded records: %v", source.loads)
+	}
+
+	steps := []struct {
+		id        string
+		wantLoads int
+	}{
+		{id: "d", wantLoads: 0}, // Saved since start-up, already in memory
+		{id: "a", wantLoads: 1},
+		{id: "a", wantLoads: 1}, // Cached
+		{id: "b", wantLoads: 2},
+		{id: "c", wantLoads: 3}, // Evicts a, the least recently used
+		{id: "b", wantLoads: 3},
+		{id: "a", wantLoads: 4},
+	}
+	for _, step := range steps {
+		record, err := store.LoadRecord(1, step.id)
+		if err != nil || record.ID != step.id {
+			t.Fatalf("LoadRecord(%s) = %+v, %v", step.id, record, err)
+		}
+		if len(source.loads) != step.wantLoads {
+			t.Fatalf("after %s: loads = %v, want %d", step.id, source.loads, step.wantLoads)
+		}
+	}
+	if _, err := store.LoadRecord(1, "missing"); err == nil {
+		t.Fatalf("expected an error for a missing record")
+	}
+	if n := store.CountRecords(1, RecordFilter{From: day.Add(time.Hour)}); n != 3 {
+		t.Fatalf("CountRecords = %d, want 3", n)
+	}
+}
@@ -11,29 +11,147 @@ import (
 
 type Record struct {
 	ID        string
-	Data      map[string]string
+	Data      map[string]Answer
 	IsSaved   bool
 	CreatedAt time.Time
+	StartedAt time.Time // When the draft was started
+	// Transient holds multi-step question state keyed by question ID. It belongs to the draft only:
+	// it is dropped on save and never rendered, forwarded or exported.
+	Transient map[string]*QuestionScratch
+	// Flags lists content filter findings per store key; the therapist sees them next to the answer.
+	Flags map[string][]string
+	// Late marks a record saved after the survey deadline; LateSections lists the sections completed
+	// after their own deadline.
+	Late         bool
+	LateSections []string
+	// Sampled tells, by question ID, whether a question of a sampled section was drawn for this record
+	// (true) or left out (false). Questions it does not mention are asked.
+	Sampled map[string]bool `json:",omitempty"`
+	// Template is the survey template the record was filled from; empty means the main config.
+	Template string `json:",omitempty"`
+	// FirstRecord marks a draft started while the user had no saved record, the only draft profile
+	// prefills are offered on. It belongs to the draft and is cleared on save.
+	FirstRecord bool `json:",omitempty"`
+	// Notes are free-text additions the user made after saving the record, oldest first.
+	Notes []Note `json:",omitempty"`
+}
+
+// Note is a timestamped free-text addition to a saved record.
+type Note struct {
+	At   time.Time
+	Text string
+}
+
+// AddNote appends text, written at at, to the notes of the record.
+func (r *Record) AddNote(at time.Time, text string) {
+	r.Notes = append(r.Notes, Note{At: at, Text: text})
+}
+
+// MarkSectionLate records that sectionID was completed after its deadline, once.
+func (r *Record) MarkSectionLate(sectionID string) {
+	if r.SectionLate(sectionID) {
+		return
+	}
+	r.LateSections = append(r.LateSections, sectionID)
+}
+
+// SectionLate reports whether sectionID was completed after its deadline.
+func (r *Record) SectionLate(sectionID string) bool {
+	for _, id := range r.LateSections {
+		if id == sectionID {
+			return true
+		}
+	}
+	return false
+}
+
+// Flag records finding for the answer stored under storeKey, once.
+func (r *Record) Flag(storeKey, finding string) {
+	if r.Flags == nil {
+		r.Flags = make(map[string][]string)
+	}
+	for _, existing := range r.Flags[storeKey] {
+		if existing == finding {
+			return
+		}
+	}
+	r.Flags[storeKey] = append(r.Flags[storeKey], finding)
+}
+
+// QuestionScratch is the in-progress state of a multi-step question: its current sub-step and the
+// values collected so far.
+// Profile is the Telegram profile data of a user.
+type Profile struct {
+	FirstName    string
+	LastName     string
+	Username     string
+	LanguageCode string
+}
+
+type QuestionScratch struct {
+	Step   string
+	Values map[string]string
+}
+
+// Reset moves the scratch back to step with no collected values.
+func (s *QuestionScratch) Reset(step string) {
+	s.Step = step
+	s.Values = make(map[string]string)
 }
 
 type UserState struct {
 	UserID          int64
 	UserName        string
+	Profile         Profile // Telegram profile as of the latest update
 	Records         []*Record
 	MainMenuFSM     *fsm.FSM
 	RecordFSM       *fsm.FSM
 	CurrentRecord   *Record
 	CurrentSection  string
 	CurrentQuestion int
+	SectionSnapshot map[string]Answer
 	LastMessageID   int
 	LastPrompt      botport.BotMessage
+	ReplyKeyboard   bool // The main menu's reply keyboard is on screen
 	ListOffset      int
+	SectionPage     int // Page of the section menu on screen, from 0
+	CreatedAt       time.Time
+	LastActivity    time.Time
+	NudgesSent      int
+	AutoForwardOff  bool   // Opted out of the scheduled auto-forward (/autoforward off)
+	Language        string // Chosen with /language; empty follows the Telegram client
+	Therapist       int64  // Assigned recipient of the user's records; 0 sends to TARGET_USER_ID
+	Preview         bool
+	PreviewBackup   *Record
+	PendingAnswer   string    // Over-long answer cut to the limit, waiting for the user to accept the truncation
+	EditingAnswer   bool      // Re-asking one answered question; the answer returns to the section menu
+	Resume          FSMStates // States for the FSMs of a user restored from storage; the store applies and clears them
 	Mu              sync.Mutex
 }
 
+// FSMStates names the current states of a user's two FSMs. Storage keeps them so a user restored after
+// a restart or an eviction continues where they left off.
+type FSMStates struct {
+	MainMenu string `json:"main_menu,omitempty"`
+	Record   string `json:"record,omitempty"`
+}
+
+// Lang returns the language the bot talks to the user in: the one chosen with /language, else the
+// language of their Telegram client.
+func (u *UserState) Lang() string {
+	if u == nil {
+		return ""
+	}
+	if u.Language != "" {
+		return u.Language
+	}
+	return u.Profile.LanguageCode
+}
+
 func NewRecord() *Record {
 	return &Record{
-		Data:    make(map[string]string),
-		IsSaved: false,
+		Data:      make(map[string]Answer),
+		IsSaved:   false,
+		StartedAt: time.Now(),
 	}
 }
@@ -0,0 +1,124 @@
+package state
+
+import (
+	"log/slog"
+	"time"
+)
+
+// RecordFilter selects saved records of a user; the zero filter matches all of them.
+type RecordFilter struct {
+	From time.Time // Created at or after From, when set
+	To   time.Time // Created before To, when set
+	Tag  string    // Carries this content filter finding (Record.Flags), when set
+}
+
+// Match reports whether record is a saved record selected by the filter.
+func (f RecordFilter) Match(record *Record) bool {
+	if record == nil || !record.IsSaved {
+		return false
+	}
+	return f.MatchMeta(record.Meta())
+}
+
+// MatchMeta reports whether the saved record described by meta is selected by the filter.
+func (f RecordFilter) MatchMeta(meta RecordMeta) bool {
+	if !f.From.IsZero() && meta.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !meta.CreatedAt.Before(f.To) {
+		return false
+	}
+	if f.Tag != "" && !meta.HasTag(f.Tag) {
+		return false
+	}
+	return true
+}
+
+// HasTag reports whether any answer of the record was flagged with tag.
+func (r *Record) HasTag(tag string) bool {
+	for _, findings := range r.Flags {
+		for _, finding := range findings {
+			if finding == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Page selects a window of query results: Limit results after skipping Offset. A zero Limit returns
+// everything after Offset.
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// ListRecords returns the saved records of userID selected by filter, newest first, cut to page, with
+// their answers loaded. A record the source fails to load is skipped. Callers hold the user's Mu, as
+// for any other read of the user's records.
+func (s *Store) ListRecords(userID int64, filter RecordFilter, page Page) []*Record {
+	metas := s.ListRecordMetas(userID, filter, page)
+	records := make([]*Record, 0, len(metas))
+	for _, meta := range metas {
+		record, err := s.LoadRecord(userID, meta.ID)
+		if err != nil {
+			slog.Warn("skipping record", "err", err)
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// ListRecordMetas is ListRecords without loading answers, for list views.
+func (s *Store) ListRecordMetas(userID int64, filter RecordFilter, page Page) []RecordMeta {
+	matched := s.queryMetas(userID, filter)
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	if page.Offset >= len(matched) {
+		return nil
+	}
+	matched = matched[page.Offset:]
+	if page.Limit > 0 && page.Limit < len(matched) {
+		matched = matched[:page.Limit]
+	}
+	return matched
+}
+
+// CountRecords returns how many saved records of userID the filter selects.
+func (s *Store) CountRecords(userID int64, filter RecordFilter) int {
+	return len(s.queryMetas(userID, filter))
+}
+
+// LastRecord returns the newest saved record of userID, or nil.
+func (s *Store) LastRecord(userID int64) *Record {
+	if records := s.ListRecords(userID, RecordFilter{}, Page{Limit: 1}); len(records) > 0 {
+		return records[0]
+	}
+	return nil
+}
+
+// FindByTag lists the saved records of userID flagged with tag, newest first.
+func (s *Store) FindByTag(userID int64, tag string) []*Record {
+	return s.ListRecords(userID, RecordFilter{Tag: tag}, Page{})
+}
+
+// FindByDate lists the saved records of userID created on the calendar day of day, in day's
+// location, newest first.
+func (s *Store) FindByDate(userID int64, day time.Time) []*Record {
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	return s.ListRecords(userID, RecordFilter{From: from, To: from.AddDate(0, 0, 1)}, Page{})
+}
+
+// queryMetas walks the saved records newest first.
+func (s *Store) queryMetas(userID int64, filter RecordFilter) []RecordMeta {
+	metas := s.recordMetas(userID)
+	var matched []RecordMeta
+	for i := len(metas) - 1; i >= 0; i-- {
+		if filter.MatchMeta(metas[i]) {
+			matched = append(matched, metas[i])
+		}
+	}
+	return matched
+}
@@ -0,0 +1,66 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/looplab/fsm"
+)
+
+type stubFSMCreator struct{}
+
+func (stubFSMCreator) NewMainMenuFSM() *fsm.FSM { return fsm.NewFSM("idle", nil, nil) }
+func (stubFSMCreator) NewRecordFSM() *fsm.FSM   { return fsm.NewFSM("idle", nil, nil) }
+
+func TestStoreRecordQueries(t *testing.T) {
+	store := NewStore(stubFSMCreator{})
+	user := store.GetOrCreateUserState(1, "User")
+	day := time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local)
+	for i := 0; i < 5; i++ {
+		record := NewRecord()
+		record.ID = string(rune('a' + i))
+		record.IsSaved = true
+		record.CreatedAt = day.Add(time.Duration(i) * 12 * time.Hour)
+		if i%2 == 0 {
+			record.Flag("mood", "email")
+		}
+		user.Records = append(user.Records, record)
+	}
+	user.Records = append(user.Records, NewRecord()) // Unsaved records never match
+
+	ids := func(records []*Record) string {
+		var s string
+		for _, r := range records {
+			s += r.ID
+		}
+		return s
+	}
+
+	tests := []struct {
+		name string
+		got  []*Record
+		want string
+	}{
+		{name: "all newest first", got: store.ListRecords(1, RecordFilter{}, Page{}), want: "edcba"},
+		{name: "page", got: store.ListRecords(1, RecordFilter{}, Page{Offset: 1, Limit: 2}), want: "dc"},
+		{name: "page past the end", got: store.ListRecords(1, RecordFilter{}, Page{Offset: 9, Limit: 2}), want: ""},
+		{name: "date range", got: store.ListRecords(1, RecordFilter{From: day.Add(12 * time.Hour), To: day.Add(48 * time.Hour)}, Page{}), want: "dcb"},
+		{name: "by tag", got: store.FindByTag(1, "email"), want: "eca"},
+		{name: "by date", got: store.FindByDate(1, day.Add(24*time.Hour)), want: "dc"},
+		{name: "unknown user", got: store.ListRecords(2, RecordFilter{}, Page{}), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(tt.got); got != tt.want {
+				t.Fatalf("records = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if n := store.CountRecords(1, RecordFilter{Tag: "email"}); n != 3 {
+		t.Fatalf("CountRecords = %d, want 3", n)
+	}
+	if last := store.LastRecord(1); last == nil || last.ID != "e" {
+		t.Fatalf("LastRecord = %+v, want e", last)
+	}
+}
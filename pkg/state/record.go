@@ -0,0 +1,166 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+)
+
+// ErrNoAnswer is returned by typed getters when the question has not been answered.
+var ErrNoAnswer = errors.New("no answer")
+
+// AnswerKindResolver maps a question type to the answer kind its strategy stores.
+type AnswerKindResolver func(questionType string) (AnswerKind, bool)
+
+var (
+	answerKindResolver AnswerKindResolver
+	resolverMu         sync.RWMutex
+)
+
+// RegisterAnswerKindResolver wires schema checks of the Record accessors to the question strategies.
+func RegisterAnswerKindResolver(fn AnswerKindResolver) {
+	resolverMu.Lock()
+	defer resolverMu.Unlock()
+	answerKindResolver = fn
+}
+
+func expectedKind(question config.QuestionConfig) (AnswerKind, bool) {
+	resolverMu.RLock()
+	fn := answerKindResolver
+	resolverMu.RUnlock()
+	if fn == nil {
+		return "", false
+	}
+	return fn(question.Type)
+}
+
+// SetAnswer stores value under the question's store key after checking it matches the kind the
+// question type declares.
+func (r *Record) SetAnswer(question config.QuestionConfig, value Answer) error {
+	if question.StoreKey == "" {
+		return fmt.Errorf("question '%s' has no store_key", question.ID)
+	}
+	if kind, ok := expectedKind(question); ok && kind != value.Kind {
+		return fmt.Errorf("question '%s' (type '%s') stores %s answers, got %s", question.ID, question.Type, kind, value.Kind)
+	}
+	if r.Data == nil {
+		r.Data = make(map[string]Answer)
+	}
+	r.Data[question.StoreKey] = value
+	return nil
+}
+
+// Answer returns the stored answer of the question; the zero Answer when unanswered.
+func (r *Record) Answer(question config.QuestionConfig) Answer {
+	if r == nil || r.Data == nil {
+		return Answer{}
+	}
+	return r.Data[question.StoreKey]
+}
+
+// Visible reports whether the question's show_if condition holds for the record; questions without
+// one are always shown. Questions left out by section sampling are hidden.
+func (r *Record) Visible(question config.QuestionConfig) bool {
+	if r != nil && r.Sampled != nil {
+		if asked, drawn := r.Sampled[question.ID]; drawn && !asked {
+			return false
+		}
+	}
+	cond := question.ShowIf
+	if cond == nil {
+		return true
+	}
+	var answer Answer
+	if r != nil && r.Data != nil {
+		answer = r.Data[cond.StoreKey]
+	}
+	if answer.IsEmpty() {
+		return false
+	}
+	if len(cond.Values) == 0 {
+		return true
+	}
+	var items []string
+	switch answer.Kind {
+	case AnswerList:
+		items = answer.List
+	case AnswerNumber:
+		// A rating matches by its number or by its label.
+		items = []string{answer.number()}
+		if answer.Text != "" {
+			items = append(items, answer.Text)
+		}
+	default:
+		items = []string{answer.String()}
+	}
+	for _, item := range items {
+		for _, value := range cond.Values {
+			if item == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasAnswer reports whether the question has a non-empty answer.
+func (r *Record) HasAnswer(question config.QuestionConfig) bool {
+	return !r.Answer(question).IsEmpty()
+}
+
+// Size is the length of the record in runes, counted over the rendered answers.
+func (r *Record) Size() int {
+	if r == nil {
+		return 0
+	}
+	size := 0
+	for _, answer := range r.Data {
+		size += utf8.RuneCountInString(answer.String())
+	}
+	return size
+}
+
+// GetString renders the answer of any kind as text; "" when unanswered.
+func (r *Record) GetString(question config.QuestionConfig) string {
+	return r.Answer(question).String()
+}
+
+// GetInt returns a number answer, or a string answer holding an integer (e.g. a numeric button value).
+func (r *Record) GetInt(question config.QuestionConfig) (int, error) {
+	answer := r.Answer(question)
+	if answer.IsEmpty() {
+		return 0, ErrNoAnswer
+	}
+	switch answer.Kind {
+	case AnswerNumber:
+		return int(answer.Number), nil
+	case AnswerString:
+		n, err := strconv.Atoi(answer.Text)
+		if err != nil {
+			return 0, fmt.Errorf("question '%s' answer %q is not an integer", question.ID, answer.Text)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("question '%s' stores %s answers, not numbers", question.ID, answer.Kind)
+	}
+}
+
+// GetList returns a list answer; a single string answer is returned as a one-item list.
+func (r *Record) GetList(question config.QuestionConfig) ([]string, error) {
+	answer := r.Answer(question)
+	if answer.IsEmpty() {
+		return nil, ErrNoAnswer
+	}
+	switch answer.Kind {
+	case AnswerList:
+		return answer.List, nil
+	case AnswerString:
+		return []string{answer.Text}, nil
+	default:
+		return nil, fmt.Errorf("question '%s' stores %s answers, not lists", question.ID, answer.Kind)
+	}
+}
@@ -0,0 +1,101 @@
+package state
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/config"
+)
+
+func TestRecordAccessors(t *testing.T) {
+	RegisterAnswerKindResolver(func(questionType string) (AnswerKind, bool) {
+		switch questionType {
+		case "text", "buttons":
+			return AnswerString, true
+		case "number":
+			return AnswerNumber, true
+		}
+		return "", false
+	})
+	defer RegisterAnswerKindResolver(nil)
+
+	text := config.QuestionConfig{ID: "q1", Type: "text", StoreKey: "mood"}
+	button := config.QuestionConfig{ID: "q2", Type: "buttons", StoreKey: "level"}
+	number := config.QuestionConfig{ID: "q3", Type: "number", StoreKey: "hours"}
+	record := NewRecord()
+
+	if err := record.SetAnswer(text, NumberAnswer(1)); err == nil {
+		t.Fatalf("expected kind mismatch error for text question")
+	}
+	for _, set := range []struct {
+		question config.QuestionConfig
+		answer   Answer
+	}{
+		{text, StringAnswer("calm")},
+		{button, StringAnswer("3")},
+		{number, NumberAnswer(7)},
+	} {
+		if err := record.SetAnswer(set.question, set.answer); err != nil {
+			t.Fatalf("SetAnswer(%s): %v", set.question.ID, err)
+		}
+	}
+
+	if got := record.GetString(text); got != "calm" {
+		t.Fatalf("GetString = %q", got)
+	}
+	if got, err := record.GetInt(button); err != nil || got != 3 {
+		t.Fatalf("GetInt(button) = %d, %v", got, err)
+	}
+	if got, err := record.GetInt(number); err != nil || got != 7 {
+		t.Fatalf("GetInt(number) = %d, %v", got, err)
+	}
+	if _, err := record.GetInt(text); err == nil {
+		t.Fatalf("expected error reading non-numeric text as int")
+	}
+	if got, err := record.GetList(text); err != nil || !reflect.DeepEqual(got, []string{"calm"}) {
+		t.Fatalf("GetList(text) = %v, %v", got, err)
+	}
+	missing := config.QuestionConfig{ID: "q4", Type: "text", StoreKey: "missing"}
+	if _, err := record.GetInt(missing); !errors.Is(err, ErrNoAnswer) {
+		t.Fatalf("expected ErrNoAnswer, got %v", err)
+	}
+	if record.HasAnswer(missing) {
+		t.Fatalf("expected no answer for missing question")
+	}
+}
+
+func TestRecordVisible(t *testing.T) {
+	conditional := func(values ...string) config.QuestionConfig {
+		return config.QuestionConfig{ID: "q2", StoreKey: "k2", ShowIf: &config.ShowIfConfig{StoreKey: "k1", Values: values}}
+	}
+
+	tests := []struct {
+		name     string
+		answer   Answer
+		question config.QuestionConfig
+		want     bool
+	}{
+		{name: "no condition", question: config.QuestionConfig{ID: "q2", StoreKey: "k2"}, want: true},
+		{name: "unanswered", question: conditional()},
+		{name: "answered at all", answer: StringAnswer("x"), question: conditional(), want: true},
+		{name: "matching value", answer: StringAnswer("bad"), question: conditional("bad", "awful"), want: true},
+		{name: "other value", answer: StringAnswer("good"), question: conditional("bad")},
+		{name: "list contains value", answer: ListAnswer("sleep", "pain"), question: conditional("pain"), want: true},
+		{name: "number", answer: NumberAnswer(3), question: conditional("3"), want: true},
+		{name: "rating by number", answer: RatingAnswer(1, "😞"), question: conditional("1", "2"), want: true},
+		{name: "rating by label", answer: RatingAnswer(1, "😞"), question: conditional("😞"), want: true},
+		{name: "other rating", answer: RatingAnswer(5, "😄"), question: conditional("1", "😞")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := NewRecord()
+			if !tt.answer.IsEmpty() {
+				record.Data["k1"] = tt.answer
+			}
+			if got := record.Visible(tt.question); got != tt.want {
+				t.Fatalf("Visible = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package state
+
+import "strconv"
+
+// Rough in-memory sizes used by Stats. They cover the structs, maps and FSMs around the answer text,
+// which is counted byte for byte; the total is an estimate for capacity planning, not an exact figure.
+const (
+	userOverheadBytes   = 2048
+	recordOverheadBytes = 256
+	answerOverheadBytes = 64
+)
+
+// RecordBuckets are the upper bounds of the records-per-user distribution in StoreStats; users above
+// the last bound fall into an open-ended bucket.
+var RecordBuckets = []int{0, 5, 20, 100}
+
+// StoreStats is a snapshot of what the store keeps in memory.
+type StoreStats struct {
+	Users          int
+	Drafts         int // Users with answers in an unsaved draft
+	Records        int // Saved records held in memory
+	Deliveries     int
+	UsersByRecords map[string]int // Users per RecordBuckets label ("0", "1-5", …, "101+")
+	MemoryBytes    int            // Estimated footprint of the users, records and drafts
+}
+
+// Stats walks every user in memory, locking each while it is read. Callers hold no user's Mu.
+func (s *Store) Stats() StoreStats {
+	s.mu.Lock()
+	users := make([]*UserState, 0, len(s.users))
+	for _, userState := range s.users {
+		users = append(users, userState)
+	}
+	stats := StoreStats{Users: len(users), Deliveries: len(s.deliveries), UsersByRecords: make(map[string]int)}
+	s.mu.Unlock()
+
+	for _, label := range RecordBucketLabels() {
+		stats.UsersByRecords[label] = 0
+	}
+	for _, userState := range users {
+		userState.Mu.Lock()
+		saved := 0
+		stats.MemoryBytes += userOverheadBytes
+		for _, record := range userState.Records {
+			if record != nil && record.IsSaved {
+				saved++
+			}
+			stats.MemoryBytes += record.estimatedBytes()
+		}
+		if draft := userState.CurrentRecord; draft != nil {
+			stats.MemoryBytes += draft.estimatedBytes()
+			if len(draft.Data) > 0 {
+				stats.Drafts++
+			}
+		}
+		userState.Mu.Unlock()
+		stats.Records += saved
+		stats.UsersByRecords[recordBucket(saved)]++
+	}
+	return stats
+}
+
+// RecordBucketLabels lists the UsersByRecords labels in bucket order.
+func RecordBucketLabels() []string {
+	labels := make([]string, 0, len(RecordBuckets)+1)
+	lower := 0
+	for _, upper := range RecordBuckets {
+		labels = append(labels, bucketLabel(lower, upper))
+		lower = upper + 1
+	}
+	return append(labels, strconv.Itoa(lower)+"+")
+}
+
+func recordBucket(records int) string {
+	lower := 0
+	for _, upper := range RecordBuckets {
+		if records <= upper {
+			return bucketLabel(lower, upper)
+		}
+		lower = upper + 1
+	}
+	return strconv.Itoa(lower) + "+"
+}
+
+func bucketLabel(lower, upper int) string {
+	if lower == upper {
+		return strconv.Itoa(upper)
+	}
+	return strconv.Itoa(lower) + "-" + strconv.Itoa(upper)
+}
+
+func (r *Record) estimatedBytes() int {
+	if r == nil {
+		return 0
+	}
+	size := recordOverheadBytes + len(r.ID)
+	for key, answer := range r.Data {
+		size += answerOverheadBytes + len(key) + len(answer.String())
+	}
+	return size
+}
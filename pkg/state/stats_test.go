@@ -0,0 +1,35 @@
+package state
+
+import "testing"
+
+func TestStoreStats(t *testing.T) {
+	store := NewStore(stubFSMCreator{})
+	store.GetOrCreateUserState(1, "Empty")
+	writer := store.GetOrCreateUserState(2, "Writer")
+	for i := 0; i < 7; i++ {
+		record := NewRecord()
+		record.IsSaved = true
+		record.Data["mood"] = StringAnswer("good")
+		writer.Records = append(writer.Records, record)
+	}
+	drafting := store.GetOrCreateUserState(3, "Drafting")
+	drafting.CurrentRecord = NewRecord()
+	drafting.CurrentRecord.Data["mood"] = StringAnswer("meh")
+	drafting.Records = append(drafting.Records, &Record{IsSaved: true})
+	store.AddDelivery(&Delivery{ID: "d1", UserID: 2})
+
+	stats := store.Stats()
+
+	if stats.Users != 3 || stats.Drafts != 1 || stats.Records != 8 || stats.Deliveries != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	want := map[string]int{"0": 1, "1-5": 1, "6-20": 1, "21-100": 0, "101+": 0}
+	for label, users := range want {
+		if stats.UsersByRecords[label] != users {
+			t.Fatalf("UsersByRecords[%s] = %d, want %d (%v)", label, stats.UsersByRecords[label], users, stats.UsersByRecords)
+		}
+	}
+	if min := 3*userOverheadBytes + 9*recordOverheadBytes; stats.MemoryBytes < min {
+		t.Fatalf("MemoryBytes = %d, want at least %d", stats.MemoryBytes, min)
+	}
+}
@@ -1,25 +1,49 @@
 package state
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
+	"sort"
 	"sync"
+	"time"
 )
 
 type Store struct {
-	users      map[int64]*UserState
-	fsmCreator FSMCreator
-	mu         sync.Mutex
+	users       map[int64]*UserState
+	deliveries  map[string]*Delivery
+	deliverySeq uint64      // Seq of the last delivery added
+	deliveryLog DeliveryLog // Where deliveries are written through; nil keeps them in memory
+	fsmCreator  FSMCreator
+	source      RecordSource // Persistent saved records, loaded on demand; nil keeps everything in memory
+	cacheSize   int
+	caches      map[int64]*recordCache
+	archive     UserArchive               // Where evicted users go; nil drops them
+	seen        map[int64]time.Time       // Last lookup through GetOrCreateUserState, for eviction
+	loading     map[int64]chan struct{}   // Users being restored from the archive, closed when done
+	langs       map[int64]string          // UserState.Lang of every user as of their last NoteLang
+	writer      RecordWriter              // Where Sync writes saved records; nil keeps them in memory
+	written     map[int64]map[string]bool // IDs of the in-memory records Sync has written, by user
+	mu          sync.Mutex
 }
 
 func NewStore(f FSMCreator) *Store {
 	return &Store{
 		users:      make(map[int64]*UserState),
+		deliveries: make(map[string]*Delivery),
+		seen:       make(map[int64]time.Time),
+		langs:      make(map[int64]string),
+		loading:    make(map[int64]chan struct{}),
 		fsmCreator: f,
 	}
 }
 
 func (s *Store) GetOrCreateUserState(userID int64, userName string) *UserState {
 
+	s.mu.Lock()
+	s.seen[userID] = time.Now() // Keeps Evict away from the restored state
+	s.mu.Unlock()
+	s.lookup(userID)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -28,21 +52,21 @@ func (s *Store) GetOrCreateUserState(userID int64, userName string) *UserState {
 	if exists {
 
 		if userState.UserName != userName {
-			log.Printf("Updating username for user %d: '%s' -> '%s'", userID, userState.UserName, userName)
+			slog.Info("updating username", "user_id", userID, "old", userState.UserName, "new", userName)
 			userState.UserName = userName
 		}
 
 		return userState
 	}
 
-	log.Printf("Creating new state for user %d ('%s')", userID, userName)
+	slog.Info("creating user state", "user_id", userID, "user_name", userName)
 
 	mainFSM := s.fsmCreator.NewMainMenuFSM()
 	recordFSM := s.fsmCreator.NewRecordFSM()
-	log.Printf("Fsms created for user %d ('%s')", userID, userName)
+	slog.Debug("FSMs created", "user_id", userID, "user_name", userName)
 	if mainFSM == nil || recordFSM == nil {
 
-		log.Printf("CRITICAL: Failed to initialize FSM instances for user %d", userID)
+		slog.Error("failed to initialize FSM instances", "user_id", userID)
 
 	}
 
@@ -53,11 +77,197 @@ func (s *Store) GetOrCreateUserState(userID int64, userName string) *UserState {
 		MainMenuFSM:   mainFSM,
 		RecordFSM:     recordFSM,
 		CurrentRecord: nil,
+		CreatedAt:     time.Now(),
 	}
-	log.Printf("Userstate created for user %d ('%s')", userID, userName)
+	slog.Debug("user state created", "user_id", userID, "user_name", userName)
 
 	s.users[userID] = newUserState
-	log.Printf("Userstate saved for user %d ('%s')", userID, userName)
+	slog.Debug("user state saved", "user_id", userID, "user_name", userName)
 
 	return newUserState
 }
+
+// UserIDs returns the IDs of all known users: those in memory and those in the archive, which Get
+// restores. Scheduled jobs loop over it, so users evicted or not seen since a restart are included.
+// When the archive cannot be listed only the users in memory are returned.
+func (s *Store) UserIDs() []int64 {
+	ids := s.LoadedUserIDs()
+	s.mu.Lock()
+	archive := s.archive
+	s.mu.Unlock()
+	if archive == nil {
+		return ids
+	}
+
+	archived, err := archive.UserIDs()
+	if err != nil {
+		slog.Error("failed to list archived users", "err", err)
+		return ids
+	}
+	known := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		known[id] = true
+	}
+	for _, id := range archived {
+		if !known[id] {
+			known[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// LoadedUserIDs returns the IDs of the users with in-memory state only.
+func (s *Store) LoadedUserIDs() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int64, 0, len(s.users))
+	for id := range s.users {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Lang returns the language of userID as of their last NoteLang without taking their Mu, so code
+// holding another user's Mu can write to them in their own language. A user not seen since the start
+// is restored from the archive first; unknown users, and every user without a store, get "", the
+// default language.
+func (s *Store) Lang(userID int64) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	lang, ok := s.langs[userID]
+	s.mu.Unlock()
+	if ok {
+		return lang
+	}
+	if _, ok := s.Get(userID); !ok {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.langs[userID]
+}
+
+// NoteLang records the language of userState for Lang. Callers hold the user's Mu and call it
+// whenever the chosen language or the Telegram profile changes.
+func (s *Store) NoteLang(userState *UserState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.langs[userState.UserID] = userState.Lang()
+}
+
+// Get returns the state of userID without creating it, restoring an evicted user from the archive.
+// Code that locks the state to change it uses LockUser.
+func (s *Store) Get(userID int64) (*UserState, bool) {
+	return s.lookup(userID)
+}
+
+// LockUser returns the state of userID like Get, with its Mu held. A state evicted while the caller
+// waited for the Mu has left the store and changes to it would be lost, so LockUser then tries again
+// with the restored one. Callers hold no user's Mu.
+func (s *Store) LockUser(userID int64) (*UserState, bool) {
+	for {
+		userState, ok := s.lookup(userID)
+		if !ok {
+			return nil, false
+		}
+		userState.Mu.Lock()
+		s.mu.Lock()
+		current := s.users[userID] == userState
+		s.mu.Unlock()
+		if current {
+			return userState, true
+		}
+		userState.Mu.Unlock()
+	}
+}
+
+// Relink moves the history of from to to after the user switched Telegram accounts: saved records
+// (merged in creation order), the draft unless to already has answers in one, the auto-forward opt-out
+// and the deliveries to the therapist. Records kept only in the backend are loaded into to, so the
+// next Sync of to writes them under the new ID. from is dropped from the store and its archive. Callers
+// hold the Mu of both users. It returns the number of records and of deliveries moved.
+func (s *Store) Relink(from, to *UserState) (records, deliveries int, err error) {
+	if from.UserID == to.UserID {
+		return 0, 0, fmt.Errorf("cannot relink user %d to itself", from.UserID)
+	}
+	moving := from.Records
+	inMemory := make(map[string]bool, len(from.Records))
+	for _, r := range from.Records {
+		if r != nil {
+			inMemory[r.ID] = true
+		}
+	}
+	for _, r := range s.ListRecords(from.UserID, RecordFilter{}, Page{}) {
+		if !inMemory[r.ID] {
+			moving = append(moving, r)
+		}
+	}
+
+	var moved []Delivery
+	var log DeliveryLog
+	var archive UserArchive
+	defer func() { // Runs once s.mu is released
+		saveDeliveries(log, moved...)
+		if archive == nil {
+			return
+		}
+		if err := archive.DeleteUser(from.UserID); err != nil {
+			slog.Error("failed to delete the relinked user from the archive", "user_id", from.UserID, "err", err)
+		}
+	}()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.users[from.UserID] != from {
+		return 0, 0, fmt.Errorf("user %d not found", from.UserID)
+	}
+
+	for _, r := range moving {
+		if r != nil && r.IsSaved {
+			records++
+		}
+	}
+	to.Records = append(moving, to.Records...)
+	sort.SliceStable(to.Records, func(i, j int) bool { return to.Records[i].CreatedAt.Before(to.Records[j].CreatedAt) })
+	if from.CurrentRecord != nil && (to.CurrentRecord == nil || len(to.CurrentRecord.Data) == 0) {
+		to.CurrentRecord = from.CurrentRecord
+	}
+	to.AutoForwardOff = to.AutoForwardOff || from.AutoForwardOff
+	if to.Language == "" {
+		to.Language = from.Language
+	}
+	s.langs[to.UserID] = to.Lang()
+	if to.Therapist == 0 {
+		to.Therapist = from.Therapist
+	}
+	if !from.CreatedAt.IsZero() && (to.CreatedAt.IsZero() || from.CreatedAt.Before(to.CreatedAt)) {
+		to.CreatedAt = from.CreatedAt
+	}
+
+	for _, d := range s.deliveries {
+		if d.UserID == from.UserID {
+			d.UserID = to.UserID
+			moved = append(moved, *d)
+			deliveries++
+		}
+	}
+	log = s.deliveryLog
+	archive = s.archive
+
+	from.Records = nil
+	from.CurrentRecord = nil
+	delete(s.users, from.UserID)
+	delete(s.caches, from.UserID)
+	delete(s.seen, from.UserID)
+	delete(s.written, from.UserID)
+	delete(s.langs, from.UserID)
+	s.users[to.UserID] = to
+	slog.Info("relinked user", "from", from.UserID, "to", to.UserID, "records", records, "deliveries", deliveries)
+	return records, deliveries, nil
+}
@@ -0,0 +1,216 @@
+package memadapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/storeport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// MemAdapter implements storeport.StorePort in memory for tests. Users and records are kept as JSON,
+// like a real adapter would store them, so callers never share pointers with it.
+type MemAdapter struct {
+	mu         sync.Mutex
+	users      map[int64][]byte
+	records    map[string]storedRecord
+	deliveries map[string][]byte
+	// FailNext makes the next call of the named method (e.g. "SaveRecord") return the error.
+	FailNext map[string]error
+}
+
+type storedRecord struct {
+	userID int64
+	meta   state.RecordMeta
+	data   []byte
+}
+
+var _ storeport.StorePort = (*MemAdapter)(nil)
+
+// New returns an empty adapter.
+func New() *MemAdapter {
+	return &MemAdapter{users: make(map[int64][]byte), records: make(map[string]storedRecord), deliveries: make(map[string][]byte)}
+}
+
+func (m *MemAdapter) failure(method string) error {
+	err := m.FailNext[method]
+	delete(m.FailNext, method)
+	return err
+}
+
+func (m *MemAdapter) GetUserState(ctx context.Context, userID int64) (*state.UserState, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.failure("GetUserState"); err != nil {
+		return nil, false, err
+	}
+
+	data, ok := m.users[userID]
+	if !ok {
+		return nil, false, nil
+	}
+	var snapshot storeport.UserSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, false, fmt.Errorf("decode user %d: %w", userID, err)
+	}
+	return snapshot.UserState(), true, nil
+}
+
+func (m *MemAdapter) SaveUserState(ctx context.Context, userState *state.UserState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.failure("SaveUserState"); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(storeport.Snapshot(userState))
+	if err != nil {
+		return fmt.Errorf("encode user %d: %w", userState.UserID, err)
+	}
+	m.users[userState.UserID] = data
+	return nil
+}
+
+func (m *MemAdapter) ListUsers(ctx context.Context) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.failure("ListUsers"); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(m.users))
+	for id := range m.users {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (m *MemAdapter) DeleteUserState(ctx context.Context, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.failure("DeleteUserState"); err != nil {
+		return err
+	}
+
+	delete(m.users, userID)
+	return nil
+}
+
+func (m *MemAdapter) SaveRecord(ctx context.Context, userID int64, record *state.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.failure("SaveRecord"); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode record %s: %w", record.ID, err)
+	}
+	m.records[record.ID] = storedRecord{userID: userID, meta: record.Meta(), data: data}
+	return nil
+}
+
+func (m *MemAdapter) ListRecords(ctx context.Context, userID int64) ([]state.RecordMeta, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.failure("ListRecords"); err != nil {
+		return nil, err
+	}
+	return m.metas(userID), nil
+}
+
+func (m *MemAdapter) metas(userID int64) []state.RecordMeta {
+	var metas []state.RecordMeta
+	for _, r := range m.records {
+		if r.userID == userID {
+			metas = append(metas, r.meta)
+		}
+	}
+	sort.Slice(metas, func(i, j int) bool { return metas[i].CreatedAt.Before(metas[j].CreatedAt) })
+	return metas
+}
+
+func (m *MemAdapter) GetRecord(ctx context.Context, userID int64, id string) (*state.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.failure("GetRecord"); err != nil {
+		return nil, err
+	}
+
+	r, ok := m.records[id]
+	if !ok || r.userID != userID {
+		return nil, fmt.Errorf("record %s of user %d: %w", id, userID, storeport.ErrNotFound)
+	}
+	var record state.Record
+	if err := json.Unmarshal(r.data, &record); err != nil {
+		return nil, fmt.Errorf("decode record %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+func (m *MemAdapter) DeleteRecord(ctx context.Context, userID int64, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.failure("DeleteRecord"); err != nil {
+		return err
+	}
+
+	if r, ok := m.records[id]; ok && r.userID == userID {
+		delete(m.records, id)
+	}
+	return nil
+}
+
+func (m *MemAdapter) SaveDelivery(ctx context.Context, delivery *state.Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.failure("SaveDelivery"); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("encode delivery %s: %w", delivery.ID, err)
+	}
+	m.deliveries[delivery.ID] = data
+	return nil
+}
+
+func (m *MemAdapter) ListDeliveries(ctx context.Context) ([]*state.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.failure("ListDeliveries"); err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*state.Delivery, 0, len(m.deliveries))
+	for id, data := range m.deliveries {
+		var delivery state.Delivery
+		if err := json.Unmarshal(data, &delivery); err != nil {
+			return nil, fmt.Errorf("decode delivery %s: %w", id, err)
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, nil
+}
+
+// RecordIDs returns the IDs of the stored records of userID, oldest first.
+func (m *MemAdapter) RecordIDs(userID int64) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metas := m.metas(userID)
+	ids := make([]string, 0, len(metas))
+	for _, meta := range metas {
+		ids = append(ids, meta.ID)
+	}
+	return ids
+}
+
+func (m *MemAdapter) Close() error {
+	return nil
+}
@@ -0,0 +1,11 @@
+package memadapter
+
+import (
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/storeport/storeporttest"
+)
+
+func TestMemAdapter(t *testing.T) {
+	storeporttest.Run(t, New())
+}
@@ -0,0 +1,312 @@
+package postgresadapter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/storeport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+// DriverName is the database/sql driver the adapter opens, pgx (driver_pgx.go).
+const DriverName = "pgx"
+
+// schema creates the tables on first start. Users, records and deliveries are stored as JSON
+// documents; the columns beside them are what queries filter and sort on.
+const schema = `
+CREATE TABLE IF NOT EXISTS survey_users (
+	user_id    BIGINT PRIMARY KEY,
+	state      JSONB NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS survey_records (
+	id         TEXT PRIMARY KEY,
+	user_id    BIGINT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	meta       JSONB NOT NULL,
+	record     JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS survey_records_user_created ON survey_records (user_id, created_at);
+CREATE TABLE IF NOT EXISTS survey_deliveries (
+	id        TEXT PRIMARY KEY,
+	user_id   BIGINT NOT NULL,
+	record_id TEXT NOT NULL,
+	delivery  JSONB NOT NULL
+);
+`
+
+// Adapter implements storeport.StorePort on PostgreSQL.
+type Adapter struct {
+	db    *sql.DB
+	codec storeport.Codec
+}
+
+var _ storeport.StorePort = (*Adapter)(nil)
+
+// Open connects to the database at dsn and creates the schema when it is missing. With keys the
+// answers are stored encrypted (see storeport.Codec); nil keys store plain JSON.
+func Open(ctx context.Context, dsn string, keys *storeport.Keyring) (*Adapter, error) {
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	adapter := New(db, keys)
+	if err := adapter.Migrate(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return adapter, nil
+}
+
+// New wraps an open database.
+func New(db *sql.DB, keys *storeport.Keyring) *Adapter {
+	return &Adapter{db: db, codec: storeport.Codec{Keys: keys}}
+}
+
+// Migrate creates the tables and indexes that do not exist yet.
+func (a *Adapter) Migrate(ctx context.Context) error {
+	if _, err := a.db.ExecContext(ctx, schema); err != nil {
+		return fmt.Errorf("create schema: %w", err)
+	}
+	return nil
+}
+
+func (a *Adapter) GetUserState(ctx context.Context, userID int64) (*state.UserState, bool, error) {
+	var data []byte
+	err := a.db.QueryRowContext(ctx, `SELECT state FROM survey_users WHERE user_id = $1`, userID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("get user %d: %w", userID, err)
+	}
+	snapshot, err := a.codec.DecodeUser(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("get user %d: %w", userID, err)
+	}
+	return snapshot.UserState(), true, nil
+}
+
+func (a *Adapter) SaveUserState(ctx context.Context, userState *state.UserState) error {
+	data, err := a.codec.EncodeUser(storeport.Snapshot(userState))
+	if err != nil {
+		return err
+	}
+	_, err = a.db.ExecContext(ctx, `
+INSERT INTO survey_users (user_id, state, updated_at) VALUES ($1, $2, now())
+ON CONFLICT (user_id) DO UPDATE SET state = EXCLUDED.state, updated_at = EXCLUDED.updated_at`,
+		userState.UserID, data)
+	if err != nil {
+		return fmt.Errorf("save user %d: %w", userState.UserID, err)
+	}
+	return nil
+}
+
+func (a *Adapter) ListUsers(ctx context.Context) ([]int64, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT user_id FROM survey_users`)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("list users: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	return ids, nil
+}
+
+func (a *Adapter) DeleteUserState(ctx context.Context, userID int64) error {
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM survey_users WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete user %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (a *Adapter) SaveRecord(ctx context.Context, userID int64, record *state.Record) error {
+	meta, data, err := a.encodeRecord(record)
+	if err != nil {
+		return err
+	}
+	_, err = a.db.ExecContext(ctx, `
+INSERT INTO survey_records (id, user_id, created_at, meta, record) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id) DO UPDATE SET user_id = EXCLUDED.user_id, created_at = EXCLUDED.created_at,
+	meta = EXCLUDED.meta, record = EXCLUDED.record`,
+		record.ID, userID, record.CreatedAt, meta, data)
+	if err != nil {
+		return fmt.Errorf("save record %s: %w", record.ID, err)
+	}
+	return nil
+}
+
+func (a *Adapter) ListRecords(ctx context.Context, userID int64) ([]state.RecordMeta, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT meta FROM survey_records WHERE user_id = $1 ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list records of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	var metas []state.RecordMeta
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("list records of user %d: %w", userID, err)
+		}
+		meta, err := a.codec.DecodeMeta(data)
+		if err != nil {
+			return nil, fmt.Errorf("list records of user %d: %w", userID, err)
+		}
+		metas = append(metas, meta)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list records of user %d: %w", userID, err)
+	}
+	return metas, nil
+}
+
+func (a *Adapter) GetRecord(ctx context.Context, userID int64, id string) (*state.Record, error) {
+	var data []byte
+	err := a.db.QueryRowContext(ctx, `SELECT record FROM survey_records WHERE user_id = $1 AND id = $2`, userID, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("record %s of user %d: %w", id, userID, storeport.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get record %s: %w", id, err)
+	}
+	return a.codec.DecodeRecord(data)
+}
+
+func (a *Adapter) DeleteRecord(ctx context.Context, userID int64, id string) error {
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM survey_records WHERE user_id = $1 AND id = $2`, userID, id); err != nil {
+		return fmt.Errorf("delete record %s: %w", id, err)
+	}
+	return nil
+}
+
+func (a *Adapter) SaveDelivery(ctx context.Context, delivery *state.Delivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("encode delivery %s: %w", delivery.ID, err)
+	}
+	_, err = a.db.ExecContext(ctx, `
+INSERT INTO survey_deliveries (id, user_id, record_id, delivery) VALUES ($1, $2, $3, $4)
+ON CONFLICT (id) DO UPDATE SET user_id = EXCLUDED.user_id, record_id = EXCLUDED.record_id, delivery = EXCLUDED.delivery`,
+		delivery.ID, delivery.UserID, delivery.RecordID, data)
+	if err != nil {
+		return fmt.Errorf("save delivery %s: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+func (a *Adapter) ListDeliveries(ctx context.Context) ([]*state.Delivery, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT delivery FROM survey_deliveries`)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*state.Delivery
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("list deliveries: %w", err)
+		}
+		var delivery state.Delivery
+		if err := json.Unmarshal(data, &delivery); err != nil {
+			return nil, fmt.Errorf("decode delivery: %w", err)
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (a *Adapter) Close() error {
+	return a.db.Close()
+}
+
+// Reseal rewrites every stored user and record through the adapter's codec and returns how many rows
+// it rewrote. After a key rotation it seals everything with the new key, so the old keys can be
+// dropped; turning encryption on, it encrypts what was stored in plain JSON.
+func (a *Adapter) Reseal(ctx context.Context) (int, error) {
+	users, err := a.readAll(ctx, `SELECT user_id::TEXT, state FROM survey_users`)
+	if err != nil {
+		return 0, fmt.Errorf("reseal users: %w", err)
+	}
+	records, err := a.readAll(ctx, `SELECT id, record FROM survey_records`)
+	if err != nil {
+		return 0, fmt.Errorf("reseal records: %w", err)
+	}
+
+	rewritten := 0
+	for id, data := range users {
+		snapshot, err := a.codec.DecodeUser(data)
+		if err != nil {
+			return rewritten, fmt.Errorf("reseal user %s: %w", id, err)
+		}
+		if data, err = a.codec.EncodeUser(snapshot); err != nil {
+			return rewritten, err
+		}
+		if _, err := a.db.ExecContext(ctx, `UPDATE survey_users SET state = $2 WHERE user_id = $1`, snapshot.UserID, data); err != nil {
+			return rewritten, fmt.Errorf("reseal user %s: %w", id, err)
+		}
+		rewritten++
+	}
+	for id, data := range records {
+		record, err := a.codec.DecodeRecord(data)
+		if err != nil {
+			return rewritten, fmt.Errorf("reseal record %s: %w", id, err)
+		}
+		meta, data, err := a.encodeRecord(record)
+		if err != nil {
+			return rewritten, err
+		}
+		if _, err := a.db.ExecContext(ctx, `UPDATE survey_records SET meta = $2, record = $3 WHERE id = $1`, id, meta, data); err != nil {
+			return rewritten, fmt.Errorf("reseal record %s: %w", id, err)
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
+
+// readAll returns the second column of query by the first, read before anything is rewritten.
+func (a *Adapter) readAll(ctx context.Context, query string) (map[string][]byte, error) {
+	rows, err := a.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	values := make(map[string][]byte)
+	for rows.Next() {
+		var key string
+		var data []byte
+		if err := rows.Scan(&key, &data); err != nil {
+			return nil, err
+		}
+		values[key] = data
+	}
+	return values, rows.Err()
+}
+
+// encodeRecord returns the stored JSON of the record's metadata and of the record itself.
+func (a *Adapter) encodeRecord(record *state.Record) (meta, data []byte, err error) {
+	if meta, err = a.codec.EncodeMeta(record.Meta()); err != nil {
+		return nil, nil, err
+	}
+	if data, err = a.codec.EncodeRecord(record); err != nil {
+		return nil, nil, err
+	}
+	return meta, data, nil
+}
@@ -0,0 +1,70 @@
+package postgresadapter
+
+import (
+	"bytes"
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/storeport"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/state"
+)
+
+func TestRecordEncoding(t *testing.T) {
+	record := state.NewRecord()
+	record.ID = "7KQ2M9XD4H"
+	record.IsSaved = true
+	record.CreatedAt = time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	record.Data["name"] = state.StringAnswer("Ann")
+	record.Data["score"] = state.ScoredAnswer(state.ScoredEntry{Text: "sleep", Score: 3})
+	record.Flag("name", "phone")
+
+	keys, err := storeport.NewKeyring(bytes.Repeat([]byte{7}, storeport.KeySize))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	tests := []struct {
+		name      string
+		keys      *storeport.Keyring
+		wantPlain bool // Answers are readable in the stored JSON
+	}{
+		{name: "plain", wantPlain: true},
+		{name: "encrypted", keys: keys},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := New(nil, tt.keys)
+			meta, data, err := adapter.encodeRecord(record)
+			if err != nil {
+				t.Fatalf("encodeRecord: %v", err)
+			}
+			got, err := adapter.codec.DecodeRecord(data)
+			if err != nil {
+				t.Fatalf("DecodeRecord: %v", err)
+			}
+			if got.ID != record.ID || !got.CreatedAt.Equal(record.CreatedAt) || !got.Data["score"].Equal(record.Data["score"]) || got.Flags["name"][0] != "phone" {
+				t.Fatalf("record changed on the way: %+v", got)
+			}
+			gotMeta, err := adapter.codec.DecodeMeta(meta)
+			if err != nil || gotMeta.Preview["name"] != "Ann" || gotMeta.Tags[0] != "phone" {
+				t.Fatalf("metadata must carry preview and tags: %+v, %v", gotMeta, err)
+			}
+			for _, stored := range [][]byte{meta, data} {
+				if plain := strings.Contains(string(stored), "Ann"); plain != tt.wantPlain {
+					t.Fatalf("answer readable = %t in %s", plain, stored)
+				}
+			}
+			if _, err := adapter.codec.DecodeUser([]byte("{")); err == nil {
+				t.Fatalf("expected an error for broken user JSON")
+			}
+		})
+	}
+}
+
+func TestDriverIsRegistered(t *testing.T) {
+	if !slices.Contains(sql.Drivers(), DriverName) {
+		t.Fatalf("driver %q is not registered; drivers: %v", DriverName, sql.Drivers())
+	}
+}
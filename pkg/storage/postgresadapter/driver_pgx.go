@@ -0,0 +1,4 @@
+package postgresadapter
+
+// The pgx driver registers itself under DriverName.
+import _ "github.com/jackc/pgx/v5/stdlib"
@@ -0,0 +1,29 @@
+package postgresadapter
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/storeport/storeporttest"
+)
+
+// TestAdapterAgainstPostgres runs the port checks on a real database:
+// POSTGRES_TEST_DSN=postgres://... go test ./pkg/storage/postgresadapter
+func TestAdapterAgainstPostgres(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+	adapter, err := Open(context.Background(), dsn, nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer adapter.Close()
+	for _, table := range []string{"survey_deliveries", "survey_records", "survey_users"} {
+		if _, err := adapter.db.Exec("DELETE FROM " + table + " WHERE user_id IN (1001, 1002)"); err != nil {
+			t.Fatalf("clean %s: %v", table, err)
+		}
+	}
+	storeporttest.Run(t, adapter)
+}
@@ -0,0 +1,80 @@
+package transcript
+
+import (
+	"context"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+// recordingPort adds every message the bot sends or edits to the transcript of its chat. Private chats
+// share the user's ID, so outbound entries land next to the user's own messages.
+type recordingPort struct {
+	botport.BotPort
+	log *Log
+}
+
+var _ botport.BotPort = (*recordingPort)(nil)
+
+// Wrap returns next with its sends and edits recorded in log; a nil log returns next as is.
+func Wrap(next botport.BotPort, log *Log) botport.BotPort {
+	if log == nil {
+		return next
+	}
+	return &recordingPort{BotPort: next, log: log}
+}
+
+func (p *recordingPort) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts ...botport.SendOption) (botport.BotMessage, error) {
+	msg, err := p.BotPort.SendMessage(ctx, chatID, text, markup, opts...)
+	p.record(chatID, "message", text, err)
+	return msg, err
+}
+
+func (p *recordingPort) EditMessage(ctx context.Context, chatID int64, messageID int, text string, markup interface{}) (botport.BotMessage, error) {
+	msg, err := p.BotPort.EditMessage(ctx, chatID, messageID, text, markup)
+	p.record(chatID, "edit", text, err)
+	return msg, err
+}
+
+func (p *recordingPort) SendSticker(ctx context.Context, chatID int64, fileID string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	msg, err := p.BotPort.SendSticker(ctx, chatID, fileID, opts...)
+	p.record(chatID, "sticker", "", err)
+	return msg, err
+}
+
+func (p *recordingPort) SendAnimation(ctx context.Context, chatID int64, fileID string, caption string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	msg, err := p.BotPort.SendAnimation(ctx, chatID, fileID, caption, opts...)
+	p.record(chatID, "animation", caption, err)
+	return msg, err
+}
+
+func (p *recordingPort) SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string, opts ...botport.SendOption) (botport.BotMessage, error) {
+	msg, err := p.BotPort.SendDocument(ctx, chatID, fileName, data, caption, opts...)
+	text := fileName
+	if caption != "" {
+		text += ": " + caption
+	}
+	p.record(chatID, "document", text, err)
+	return msg, err
+}
+
+// record keeps what reached the chat; failed calls are left out.
+func (p *recordingPort) record(chatID int64, kind, text string, err error) {
+	if err != nil {
+		return
+	}
+	p.log.Add(chatID, Entry{Direction: Outbound, Kind: kind, Text: text})
+}
+
+// FromEvent is the transcript entry of an inbound event.
+func FromEvent(event botport.InboundEvent) Entry {
+	entry := Entry{Direction: Inbound, Kind: "message", Text: event.Text}
+	switch {
+	case event.Kind == botport.EventCallback:
+		entry.Kind, entry.Text = "callback", event.Data
+	case event.IsCommand():
+		entry.Kind = "command"
+	case event.Media != nil:
+		entry.Kind = event.Media.Type
+	}
+	return entry
+}
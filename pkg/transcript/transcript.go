@@ -0,0 +1,102 @@
+// Package transcript keeps the latest messages exchanged with each user in memory, so the admin or the
+// user's therapist can review a conversation for a support case. It is off unless main creates a Log.
+package transcript
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Direction tells who sent an entry.
+type Direction string
+
+const (
+	Inbound  Direction = "in"  // From the user
+	Outbound Direction = "out" // From the bot
+)
+
+// Entry is one message of a conversation.
+type Entry struct {
+	Time      time.Time
+	Direction Direction
+	Kind      string // message, command, callback, edit or the type of the media sent (photo, document, ...)
+	Text      string
+}
+
+// Limits bound what a Log keeps per user; zero values keep everything.
+type Limits struct {
+	MaxEntries int           // Newest entries kept per user
+	MaxAge     time.Duration // Entries older than this are dropped
+}
+
+// pruneEvery is how often Add drops expired entries of all users, so users who went quiet do not keep
+// their transcript past MaxAge.
+const pruneEvery = time.Hour
+
+// Log is an in-memory transcript per user. A nil Log records nothing, so callers need no checks.
+type Log struct {
+	mu        sync.Mutex
+	limits    Limits
+	users     map[int64][]Entry
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// New returns an empty Log keeping entries within limits.
+func New(limits Limits) *Log {
+	return &Log{limits: limits, users: make(map[int64][]Entry), now: time.Now}
+}
+
+// Add appends entry to userID's transcript, stamping it with the current time when it has none.
+func (l *Log) Add(userID int64, entry Entry) {
+	if l == nil || userID == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if entry.Time.IsZero() {
+		entry.Time = now
+	}
+	entries := append(l.users[userID], entry)
+	if max := l.limits.MaxEntries; max > 0 && len(entries) > max {
+		entries = append(entries[:0:0], entries[len(entries)-max:]...)
+	}
+	l.users[userID] = l.expire(entries, now)
+	if now.Sub(l.lastPrune) >= pruneEvery {
+		l.prune(now)
+	}
+}
+
+// Entries returns userID's transcript, oldest first.
+func (l *Log) Entries(userID int64) []Entry {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := l.expire(l.users[userID], l.now())
+	return append([]Entry(nil), entries...)
+}
+
+// expire drops the entries older than MaxAge; entries are in time order.
+func (l *Log) expire(entries []Entry, now time.Time) []Entry {
+	if l.limits.MaxAge <= 0 {
+		return entries
+	}
+	cutoff := now.Add(-l.limits.MaxAge)
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Time.After(cutoff) })
+	return entries[i:]
+}
+
+func (l *Log) prune(now time.Time) {
+	l.lastPrune = now
+	for id, entries := range l.users {
+		if entries = l.expire(entries, now); len(entries) == 0 {
+			delete(l.users, id)
+		} else {
+			l.users[id] = entries
+		}
+	}
+}
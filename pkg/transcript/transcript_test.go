@@ -0,0 +1,113 @@
+package transcript
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dkalashnik/telegram-survey-bot/pkg/bot/fakeadapter"
+	"github.com/dkalashnik/telegram-survey-bot/pkg/ports/botport"
+)
+
+func TestLogLimits(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	now := start
+	log := New(Limits{MaxEntries: 3, MaxAge: 2 * time.Hour})
+	log.now = func() time.Time { return now }
+
+	for _, text := range []string{"a", "b", "c", "d"} {
+		log.Add(1, Entry{Direction: Inbound, Kind: "message", Text: text})
+		now = now.Add(30 * time.Minute)
+	}
+	log.Add(2, Entry{Direction: Outbound, Kind: "message", Text: "other"})
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		userID int64
+		want   []string
+	}{
+		{name: "newest entries kept", at: start.Add(2 * time.Hour), userID: 1, want: []string{"b", "c", "d"}},
+		{name: "expired entries dropped", at: start.Add(3*time.Hour + time.Minute), userID: 1, want: []string{"d"}},
+		{name: "users are separate", at: start.Add(3 * time.Hour), userID: 2, want: []string{"other"}},
+		{name: "unknown user", at: start, userID: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = tt.at
+			var got []string
+			for _, entry := range log.Entries(tt.userID) {
+				got = append(got, entry.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("entries %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	now = start.Add(10 * time.Hour)
+	log.Add(3, Entry{Text: "late"})
+	if _, ok := log.users[1]; ok {
+		t.Fatalf("user 1 kept after all entries expired")
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	var log *Log
+	log.Add(1, Entry{Text: "ignored"})
+	if entries := log.Entries(1); entries != nil {
+		t.Fatalf("nil log returned %+v", entries)
+	}
+	adapter := &fakeadapter.FakeAdapter{}
+	if Wrap(adapter, nil) != botport.BotPort(adapter) {
+		t.Fatalf("Wrap with a nil log should return the port as is")
+	}
+}
+
+func TestWrapRecordsOutbound(t *testing.T) {
+	ctx := context.Background()
+	log := New(Limits{})
+	adapter := &fakeadapter.FakeAdapter{}
+	port := Wrap(adapter, log)
+
+	_, _ = port.SendMessage(ctx, 5, "Hello", nil)
+	_, _ = port.EditMessage(ctx, 5, 1, "Hello again", nil)
+	_, _ = port.SendDocument(ctx, 5, "records.csv", []byte("x"), "Your records")
+	adapter.Fail("send_message", errors.New("blocked"))
+	_, _ = port.SendMessage(ctx, 5, "Lost", nil)
+	_ = port.AnswerCallback(ctx, "cb", "")
+
+	var got []string
+	for _, entry := range log.Entries(5) {
+		if entry.Direction != Outbound || entry.Time.IsZero() {
+			t.Fatalf("entry %+v, want a stamped outbound entry", entry)
+		}
+		got = append(got, entry.Kind+":"+entry.Text)
+	}
+	want := []string{"message:Hello", "edit:Hello again", "document:records.csv: Your records"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("entries %q, want %q", got, want)
+	}
+}
+
+func TestFromEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event botport.InboundEvent
+		want  Entry
+	}{
+		{name: "text", event: botport.InboundEvent{Kind: botport.EventMessage, Text: "fine"}, want: Entry{Direction: Inbound, Kind: "message", Text: "fine"}},
+		{name: "command", event: botport.InboundEvent{Kind: botport.EventMessage, Text: "/report", Command: "report"}, want: Entry{Direction: Inbound, Kind: "command", Text: "/report"}},
+		{name: "callback", event: botport.InboundEvent{Kind: botport.EventCallback, Text: "Sections", Data: "section:day"}, want: Entry{Direction: Inbound, Kind: "callback", Text: "section:day"}},
+		{name: "photo", event: botport.InboundEvent{Kind: botport.EventMessage, Text: "my day", Media: &botport.Media{Type: "photo", FileID: "f"}}, want: Entry{Direction: Inbound, Kind: "photo", Text: "my day"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromEvent(tt.event); got != tt.want {
+				t.Fatalf("entry %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}